@@ -0,0 +1,23 @@
+package bin
+
+// Compilers of binary executables, as identified from compiler metadata and
+// heuristics of the file format parsers (e.g. Rich header, linker version,
+// section names and startup code).
+const (
+	// Microsoft Visual C++.
+	CompilerMSVC = "msvc"
+	// MinGW (GCC targeting Windows).
+	CompilerMinGW = "mingw"
+	// GNU Compiler Collection.
+	CompilerGCC = "gcc"
+	// Clang.
+	CompilerClang = "clang"
+	// Borland C++ (and C++Builder).
+	CompilerBorland = "borland"
+	// Delphi.
+	CompilerDelphi = "delphi"
+	// Open Watcom C/C++.
+	CompilerWatcom = "watcom"
+	// Go.
+	CompilerGo = "go"
+)
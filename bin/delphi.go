@@ -0,0 +1,193 @@
+package bin
+
+import (
+	"github.com/pkg/errors"
+)
+
+// maxDelphiMethods specifies the maximum number of published methods of Delphi
+// classes.
+const maxDelphiMethods = 4096
+
+// Offsets of Delphi VMT entries relative to the vmtSelfPtr entry, in pointer
+// sized units.
+//
+// The VMT pointer of a class points to its first virtual method, and is
+// preceded by class metadata. The vmtSelfPtr entry (which points to the VMT
+// itself) is located 19 entries before the first virtual method in Delphi 3
+// through Delphi 2007, and 22 entries before in Delphi 2009 and later (which
+// added Equals, GetHashCode and ToString).
+//
+//    vmtSelfPtr      = -76 (-88 in Delphi 2009+)
+//    vmtIntfTable    = -72
+//    vmtAutoTable    = -68
+//    vmtInitTable    = -64
+//    vmtTypeInfo     = -60
+//    vmtFieldTable   = -56
+//    vmtMethodTable  = -52
+//    vmtDynamicTable = -48
+//    vmtClassName    = -44
+//    vmtInstanceSize = -40
+//    vmtParent       = -36
+const (
+	vmtMethodTable = 6
+	vmtClassName   = 8
+)
+
+// delphiSelfPtrs specifies the offsets of the vmtSelfPtr entry preceding the
+// first virtual method of Delphi VMTs, in pointer sized units.
+var delphiSelfPtrs = []int{19, 22}
+
+// ParseDelphi locates the class metadata (VMTs) of Delphi executables, and
+// records the names of published methods in file.Symbols; e.g.
+// "TForm1.Button1Click". The compiler of the executable is recorded as
+// CompilerDelphi if the VMT of TObject is located. The boolean return value
+// reports whether any VMT was located.
+//
+// Names of functions already present in the symbol table are retained.
+func (file *File) ParseDelphi() bool {
+	ptrSize := Address(file.Arch.BitSize() / 8)
+	if ptrSize != 4 && ptrSize != 8 {
+		return false
+	}
+	order := file.Arch.ByteOrder()
+	found := false
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 {
+			// Skip segments, as their contents are included in sections.
+			continue
+		}
+		// VMTs are pointer aligned, and located in both code (e.g. CODE and
+		// .text) and data sections.
+		data := sect.Data
+		for off := 0; off+int(ptrSize) <= len(data); off += int(ptrSize) {
+			addr := sect.Addr + Address(off)
+			var v uint64
+			if ptrSize == 8 {
+				v = order.Uint64(data[off:])
+			} else {
+				v = uint64(order.Uint32(data[off:]))
+			}
+			for _, selfPtr := range delphiSelfPtrs {
+				if Address(v) != addr+Address(selfPtr)*ptrSize {
+					continue
+				}
+				className, ok := file.delphiClassName(addr, ptrSize)
+				if !ok {
+					continue
+				}
+				found = true
+				if className == "TObject" {
+					file.Compiler = CompilerDelphi
+				}
+				if err := file.parseDelphiMethods(addr, ptrSize, className); err != nil {
+					warn.Printf("unable to parse published methods of Delphi class %q; %v", className, err)
+				}
+			}
+		}
+	}
+	return found
+}
+
+// delphiClassName returns the class name of the Delphi VMT with the given
+// vmtSelfPtr entry address. The boolean return value indicates success.
+func (file *File) delphiClassName(selfPtr, ptrSize Address) (string, bool) {
+	v, err := file.ReadUintptr(selfPtr + vmtClassName*ptrSize)
+	if err != nil || v == 0 {
+		return "", false
+	}
+	name, ok := file.readShortString(Address(v))
+	if !ok || !isDelphiIdent(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// parseDelphiMethods parses the published method table of the Delphi VMT with
+// the given vmtSelfPtr entry address, and records the names of the published
+// methods in file.Symbols.
+//
+//    struct MethodTable {
+//       uint16 Count;
+//       struct {
+//          uint16      Size; // size of entry, including Size
+//          uintptr     Code;
+//          ShortString Name;
+//       } Entries[Count];
+//    };
+func (file *File) parseDelphiMethods(selfPtr, ptrSize Address, className string) error {
+	v, err := file.ReadUintptr(selfPtr + vmtMethodTable*ptrSize)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if v == 0 {
+		return nil
+	}
+	table := Address(v)
+	count, err := file.ReadUint16(table)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if count > maxDelphiMethods {
+		return errors.Errorf("invalid method table count %d of Delphi class %q at %v", count, className, table)
+	}
+	entry := table + 2
+	for i := uint16(0); i < count; i++ {
+		size, err := file.ReadUint16(entry)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if Address(size) < 2+ptrSize+1 {
+			return errors.Errorf("invalid method entry size %d of Delphi class %q at %v", size, className, entry)
+		}
+		code, err := file.ReadUintptr(entry + 2)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		name, ok := file.readShortString(entry + 2 + ptrSize)
+		if !ok || !isDelphiIdent(name) {
+			return errors.Errorf("invalid method name of Delphi class %q at %v", className, entry)
+		}
+		addr := Address(code)
+		if file.IsCode(addr) {
+			if file.Symbols == nil {
+				file.Symbols = make(map[Address]string)
+			}
+			if _, ok := file.Symbols[addr]; !ok {
+				file.Symbols[addr] = className + "." + name
+			}
+		}
+		entry += Address(size)
+	}
+	return nil
+}
+
+// readShortString reads the Pascal short string (length prefixed) at the given
+// address. The boolean return value indicates success.
+func (file *File) readShortString(addr Address) (string, bool) {
+	n, err := file.ReadUint8(addr)
+	if err != nil || n == 0 {
+		return "", false
+	}
+	buf, err := file.ReadData(addr+1, int(n))
+	if err != nil {
+		return "", false
+	}
+	return string(buf), true
+}
+
+// isDelphiIdent reports whether the given string is a valid Delphi identifier.
+func isDelphiIdent(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package bin_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestFileParseDelphi(t *testing.T) {
+	data := make([]byte, 0x200)
+	// VMT of TObject (Delphi 3 through Delphi 2007 layout).
+	binary.LittleEndian.PutUint32(data[0x00:], 0x40204C) // vmtSelfPtr
+	binary.LittleEndian.PutUint32(data[0x18:], 0x402100) // vmtMethodTable
+	binary.LittleEndian.PutUint32(data[0x20:], 0x402110) // vmtClassName
+	// Method table.
+	binary.LittleEndian.PutUint16(data[0x100:], 1)  // Count
+	binary.LittleEndian.PutUint16(data[0x102:], 12) // Size
+	binary.LittleEndian.PutUint32(data[0x104:], 0x401000)
+	copy(data[0x108:], "\x05Click")
+	// Class name.
+	copy(data[0x110:], "\x07TObject")
+	file := &bin.File{
+		Arch: bin.ArchX86_32,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Data: []byte{0xC3}, Perm: bin.PermR | bin.PermX},
+			{Name: ".data", Addr: 0x402000, Data: data, Perm: bin.PermR | bin.PermW},
+		},
+	}
+	if !file.ParseDelphi() {
+		t.Fatalf("unable to locate Delphi VMT")
+	}
+	if file.Compiler != bin.CompilerDelphi {
+		t.Errorf("compiler mismatch; expected %q, got %q", bin.CompilerDelphi, file.Compiler)
+	}
+	if want, got := "TObject.Click", file.Symbols[0x401000]; got != want {
+		t.Errorf("method name mismatch; expected %q, got %q", want, got)
+	}
+}
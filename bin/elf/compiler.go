@@ -0,0 +1,38 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+
+	"github.com/decomp/exp/bin"
+)
+
+// identifyCompiler identifies the compiler of the ELF file based on the
+// identification strings of the .comment section (e.g. "GCC: (GNU) 9.2.0" and
+// "clang version 9.0.0"), and records it in file.Compiler unless already
+// identified (e.g. Go by its pclntab).
+//
+// Executables compiled by Clang typically include the identification string of
+// the GCC compiled C runtime as well.
+func identifyCompiler(f *elf.File, file *bin.File) {
+	if len(file.Compiler) > 0 {
+		return
+	}
+	s := f.Section(".comment")
+	if s == nil {
+		return
+	}
+	data, err := s.Data()
+	if err != nil {
+		return
+	}
+	switch {
+	case bytes.Contains(data, []byte("clang version")):
+		file.Compiler = bin.CompilerClang
+	case bytes.Contains(data, []byte("GCC: (")):
+		file.Compiler = bin.CompilerGCC
+	default:
+		return
+	}
+	trace.Printf("compiler: %s", file.Compiler)
+}
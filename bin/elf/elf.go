@@ -12,9 +12,21 @@ import (
 	"sort"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/logging"
+	"github.com/mewkiz/pkg/term"
 	"github.com/pkg/errors"
 )
 
+// Loggers.
+var (
+	// trace represents a logger with the "elf:" prefix, which logs detailed
+	// tracing messages to standard error.
+	trace = logging.New("elf", logging.LevelTrace, term.BlueBold("elf:")+" ")
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = logging.New("elf", logging.LevelInfo, term.RedBold("warning:")+" ")
+)
+
 // Register ELF format.
 func init() {
 	// Executable and Linkable Format (ELF)
@@ -125,6 +137,19 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 	// Sort segments in ascending order.
 	sort.Slice(segments, less)
 
+	// Parse image base; the address of the first loadable segment (e.g. 0 for
+	// position independent executables).
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_LOAD {
+			base := prog.Vaddr
+			if prog.Align > 1 {
+				base &^= prog.Align - 1
+			}
+			file.ImageBase = bin.Address(base)
+			break
+		}
+	}
+
 	// Fix section permissions.
 	if len(segments) > 0 {
 		for _, sect := range file.Sections {
@@ -140,96 +165,22 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 	}
 
 	// Append segments as sections.
+	file.Segments = segments
 	file.Sections = append(file.Sections, segments...)
 
 	// Sort sections (and segments) in ascending order.
 	sort.Slice(segments, less)
 
-	// TODO: Parse imports.
-
 	// Parse imports.
-	gotplt := f.Section(".got.plt")
-	// TODO: Add support for reading .got.plt from segments when section
-	// information is missing. Locate using DT_PLTGOT in .dynamic.
-	if gotplt != nil {
-		gotpltData, err := gotplt.Data()
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		dynSyms, err := f.DynamicSymbols()
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		// Program Linkage Table example.
-		//
-		//    plt:
-		//
-		//      ...
-		//
-		//      .printf:
-		//        jmp     [rel (BASE_DATA - BASE_CODE) + got_plt.printf]
-		//
-		//      .resolve_printf:
-		//        push    QWORD dynsym.printf_idx
-		//        jmp     NEAR .resolve
-		//        jmp     [rel (BASE_DATA - BASE_CODE) + got_plt.printf]
-		//
-		//      ...
-		//
-		// ref: https://github.com/mewrev/dissection/blob/master/elf.asm
-
-		// The length of the 32- and 64-bit JMP instruction.
-		//
-		//    jmp     [rel (BASE_DATA - BASE_CODE) + got_plt.printf]
-		const jmplen = 6
-		switch file.Arch.BitSize() {
-		case 32:
-			// skip .got.plt:dynamic            (4 bytes)
-			// skip .got.plt:link_map           (4 bytes)
-			// skip .got.plt:dl_runtime_resolve (4 bytes)
-			r := bytes.NewReader(gotpltData[4+4+4:])
-			for _, dynSym := range dynSyms {
-				var v uint32
-				if err := binary.Read(r, binary.LittleEndian, &v); err != nil {
-					if errors.Cause(err) == io.EOF {
-						break
-					}
-					return nil, errors.WithStack(err)
-				}
-				// v points to .plt:resolve_printf, and .plt:printf is at the jmp
-				// instruction directly preceding; thus subtract the length of the
-				// jmp instruction from v to arrive at .plt:printf.
-				addr := bin.Address(v) - jmplen
-				file.Imports[addr] = dynSym.Name
-			}
-		case 64:
-			// skip .got.plt:dynamic            (8 bytes)
-			// skip .got.plt:link_map           (8 bytes)
-			// skip .got.plt:dl_runtime_resolve (8 bytes)
-			r := bytes.NewReader(gotpltData[8+8+8:])
-			for _, dynSym := range dynSyms {
-				var v uint64
-				if err := binary.Read(r, binary.LittleEndian, &v); err != nil {
-					if errors.Cause(err) == io.EOF {
-						break
-					}
-					return nil, errors.WithStack(err)
-				}
-				// v points to .plt:resolve_printf, and .plt:printf is at the jmp
-				// instruction directly preceding; thus subtract the length of the
-				// jmp instruction from v to arrive at .plt:printf.
-				addr := bin.Address(v) - jmplen
-				file.Imports[addr] = dynSym.Name
-			}
-		default:
-			panic(fmt.Errorf("support for CPU bit size %d not yet implemented", file.Arch.BitSize()))
-		}
+	if err := parseImports(f, file); err != nil {
+		return nil, errors.WithStack(err)
 	}
 
 	// Parse exports.
 	symtab := f.Section(".symtab")
 	strtab := f.Section(".strtab")
 	if symtab != nil && strtab != nil {
+		file.Symbols = make(map[bin.Address]string)
 		symtabData, err := symtab.Data()
 		if err != nil {
 			return nil, errors.WithStack(err)
@@ -284,6 +235,9 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 				if typ == SymTypeFunc && sym.SectHdrIndex != undef {
 					file.Exports[addr] = name
 				}
+				if (typ == SymTypeFunc || typ == SymTypeObject) && sym.SectHdrIndex != undef && len(name) > 0 {
+					file.Symbols[addr] = name
+				}
 			}
 		case 64:
 			// Sym64 represents a 64-bit symbol descriptor.
@@ -326,12 +280,24 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 				if typ == SymTypeFunc && sym.SectHdrIndex != undef {
 					file.Exports[addr] = name
 				}
+				if (typ == SymTypeFunc || typ == SymTypeObject) && sym.SectHdrIndex != undef && len(name) > 0 {
+					file.Symbols[addr] = name
+				}
 			}
 		default:
 			panic(fmt.Errorf("support for CPU bit size %d not yet implemented", file.Arch.BitSize()))
 		}
 	}
 
+	// Parse functions of Go executables. Executables are still usable without
+	// the names of Go functions.
+	if _, err := file.ParseGoFuncs(); err != nil {
+		warn.Printf("unable to parse Go functions; %v", err)
+	}
+
+	// Identify compiler.
+	identifyCompiler(f, file)
+
 	return file, nil
 }
 
@@ -466,6 +432,10 @@ const (
 // section header flags.
 func parseSectFlags(flags elf.SectionFlag) bin.Perm {
 	var perm bin.Perm
+	if flags&elf.SHF_ALLOC != 0 {
+		// Sections occupying memory during process execution are readable.
+		perm |= bin.PermR
+	}
 	if flags&elf.SHF_WRITE != 0 {
 		perm |= bin.PermW
 	}
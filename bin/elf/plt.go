@@ -0,0 +1,209 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// parseImports parses the imports of the ELF file, based on the dynamic
+// relocations of GOT entries and the PLT stubs jumping through them.
+//
+// Function imports are recorded in file.Imports, both at the address of the GOT
+// entry (e.g. `call [rel printf@GOT]` of code compiled with -fno-plt) and at
+// the address of the PLT stub (e.g. `call printf@plt`). Data imports are
+// recorded in file.DataImports at the address of the GOT entry.
+func parseImports(f *elf.File, file *bin.File) error {
+	dynSyms, err := f.DynamicSymbols()
+	if err != nil {
+		if errors.Cause(err) == elf.ErrNoSymbols {
+			// Statically linked executable.
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	got, err := parseRelocs(f, file, dynSyms)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for slot, sym := range got {
+		switch elf.ST_TYPE(sym.Info) {
+		case elf.STT_OBJECT, elf.STT_TLS:
+			if file.DataImports == nil {
+				file.DataImports = make(map[bin.Address]string)
+			}
+			file.DataImports[slot] = sym.Name
+		default:
+			file.Imports[slot] = sym.Name
+		}
+	}
+
+	// Locate PLT stubs.
+	//
+	// The base address of 32-bit PIC PLT stubs (e.g. `jmp [ebx+printf@GOT]`) is
+	// the address of the GOT, as held by EBX.
+	var gotBase bin.Address
+	if s := f.Section(".got.plt"); s != nil {
+		gotBase = bin.Address(s.Addr)
+	} else if s := f.Section(".got"); s != nil {
+		gotBase = bin.Address(s.Addr)
+	}
+	for _, s := range f.Sections {
+		switch s.Name {
+		case ".plt", ".plt.sec", ".plt.got":
+		default:
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// PLT entries are 8 or 16 bytes in size.
+		const align = 8
+		for off := 0; off < len(data); off += align {
+			addr := bin.Address(s.Addr) + bin.Address(off)
+			slot, ok := pltSlot(data[off:], addr, gotBase, file.Arch.BitSize())
+			if !ok {
+				continue
+			}
+			sym, ok := got[slot]
+			if !ok || file.DataImports[slot] != "" {
+				continue
+			}
+			trace.Printf("PLT stub of %q at %v", sym.Name, addr)
+			file.Imports[addr] = sym.Name
+		}
+	}
+	return nil
+}
+
+// parseRelocs parses the dynamic relocations of the ELF file, and returns a
+// mapping from GOT entry address to the symbol resolved by the dynamic linker
+// (R_386_GLOB_DAT, R_386_JMP_SLOT, R_X86_64_GLOB_DAT and R_X86_64_JMP_SLOT).
+//
+// The locations of relative relocations (R_386_RELATIVE and R_X86_64_RELATIVE)
+// of position independent executables are recorded in file.Relocs. The addends
+// of such relocations are stored at their locations, so that absolute
+// addresses of data (e.g. vtables and jump tables) are relative to the link
+// time image base.
+func parseRelocs(f *elf.File, file *bin.File, dynSyms []elf.Symbol) (map[bin.Address]elf.Symbol, error) {
+	// Relocation types; identical for 32- and 64-bit x86.
+	const (
+		relGlobDat  = 6
+		relJmpSlot  = 7
+		relRelative = 8
+	)
+	got := make(map[bin.Address]elf.Symbol)
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_REL && s.Type != elf.SHT_RELA {
+			continue
+		}
+		if int(s.Link) >= len(f.Sections) || f.Sections[s.Link].Type != elf.SHT_DYNSYM {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		r := bytes.NewReader(data)
+		for {
+			var (
+				off     uint64
+				symIdx  uint32
+				relType uint32
+				addend  int64
+				isRela  = s.Type == elf.SHT_RELA
+			)
+			switch {
+			case f.Class == elf.ELFCLASS32 && !isRela:
+				var rel elf.Rel32
+				err = binary.Read(r, f.ByteOrder, &rel)
+				off, symIdx, relType = uint64(rel.Off), elf.R_SYM32(rel.Info), elf.R_TYPE32(rel.Info)
+			case f.Class == elf.ELFCLASS32:
+				var rel elf.Rela32
+				err = binary.Read(r, f.ByteOrder, &rel)
+				off, symIdx, relType, addend = uint64(rel.Off), elf.R_SYM32(rel.Info), elf.R_TYPE32(rel.Info), int64(rel.Addend)
+			case !isRela:
+				var rel elf.Rel64
+				err = binary.Read(r, f.ByteOrder, &rel)
+				off, symIdx, relType = rel.Off, elf.R_SYM64(rel.Info), elf.R_TYPE64(rel.Info)
+			default:
+				var rel elf.Rela64
+				err = binary.Read(r, f.ByteOrder, &rel)
+				off, symIdx, relType, addend = rel.Off, elf.R_SYM64(rel.Info), elf.R_TYPE64(rel.Info), rel.Addend
+			}
+			if err != nil {
+				if errors.Cause(err) == io.EOF {
+					break
+				}
+				return nil, errors.WithStack(err)
+			}
+			if relType == relRelative {
+				addr := bin.Address(off)
+				if isRela {
+					if err := file.WriteUintptr(addr, uint64(addend)); err != nil {
+						// Relocation of uninitialized data (e.g. .bss).
+						continue
+					}
+				}
+				file.Relocs = append(file.Relocs, addr)
+				continue
+			}
+			if relType != relGlobDat && relType != relJmpSlot {
+				continue
+			}
+			// Symbol index 0 is the undefined symbol, which is not included in
+			// dynSyms.
+			if symIdx == 0 || int(symIdx) > len(dynSyms) {
+				continue
+			}
+			sym := dynSyms[symIdx-1]
+			if len(sym.Name) == 0 {
+				continue
+			}
+			got[bin.Address(off)] = sym
+		}
+	}
+	sort.Sort(bin.Addresses(file.Relocs))
+	return got, nil
+}
+
+// pltSlot returns the address of the GOT entry jumped through by the PLT stub
+// at the given address. The boolean return value indicates success.
+//
+//    jmp     [rel printf@GOT]        ; FF 25 disp32 (64-bit)
+//    jmp     [printf@GOT]            ; FF 25 addr32 (32-bit)
+//    jmp     [ebx+printf@GOT]        ; FF A3 disp32 (32-bit PIC)
+//
+// The jmp instruction may be preceded by ENDBR32/ENDBR64 and a BND prefix
+// (e.g. .plt.sec of executables with Intel CET enabled).
+func pltSlot(data []byte, addr, gotBase bin.Address, bitSize int) (bin.Address, bool) {
+	n := 0
+	if bytes.HasPrefix(data, []byte{0xF3, 0x0F, 0x1E, 0xFA}) || bytes.HasPrefix(data, []byte{0xF3, 0x0F, 0x1E, 0xFB}) {
+		// ENDBR64 or ENDBR32.
+		n += 4
+	}
+	if n < len(data) && data[n] == 0xF2 {
+		// BND prefix.
+		n++
+	}
+	if len(data) < n+6 || data[n] != 0xFF {
+		return 0, false
+	}
+	disp := int32(binary.LittleEndian.Uint32(data[n+2:]))
+	next := addr + bin.Address(n+6)
+	switch {
+	case data[n+1] == 0x25 && bitSize == 64:
+		return next + bin.Address(int64(disp)), true
+	case data[n+1] == 0x25 && bitSize == 32:
+		return bin.Address(uint32(disp)), true
+	case data[n+1] == 0xA3 && bitSize == 32 && gotBase != 0:
+		return gotBase + bin.Address(int64(disp)), true
+	}
+	return 0, false
+}
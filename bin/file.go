@@ -3,22 +3,70 @@ package bin
 
 import (
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 )
 
 // A File is a binary exectuable.
 type File struct {
+	// Binary executable format (e.g. "pe" or "elf"); or empty if unknown.
+	Format string
+	// Compiler of the executable (e.g. CompilerMSVC); or empty if unknown.
+	Compiler string
 	// Machine architecture specifying the assembly instruction set.
 	Arch Arch
 	// Entry point of the executable.
 	Entry Address
+	// Base address of the executable image; or 0 if not applicable.
+	ImageBase Address
 	// Sections (and segments) of the exectuable.
 	Sections []*Section
+	// Memory segments of the executable; or nil if the format lacks segment
+	// information (e.g. PE files, the sections of which are mapped directly, and
+	// raw executables, the contents of which are a single unnamed section).
+	// Segments are also included in Sections.
+	Segments []*Section
 	// Function imports.
 	Imports map[Address]string
+	// Data imports, mapping from the address of the GOT entry holding the
+	// address of the imported variable to its name; or nil if not present.
+	DataImports map[Address]string
 	// Function exports.
 	Exports map[Address]string
+	// Symbols of the symbol table (functions and data); or nil if the executable
+	// is stripped.
+	Symbols map[Address]string
+	// Tables of the executable (e.g. the import table of PE files), mapping
+	// from table name (e.g. TableImport) to location in memory; or nil if not
+	// present.
+	Tables map[string]Table
+	// Function bounds from exception handling metadata (e.g. .pdata), mapping
+	// from function start address to function end address (exclusive); or nil
+	// if not present.
+	FuncBounds map[Address]Address
+	// Function chunks from exception handling metadata (e.g. chained unwind
+	// information and __except blocks), mapping from chunk start address to
+	// the start address of its parent function; or nil if not present.
+	FuncChunks map[Address]Address
+	// Exception handlers and filters (e.g. SafeSEH handlers); or nil if not
+	// present. Handlers are typically unreachable from normal control flow.
+	Handlers map[Address]bool
+	// Additional entry points of the executable (e.g. TLS callbacks, and
+	// DllMain of DLLs), mapping from address to function name; or nil if not
+	// present.
+	Roots map[Address]string
+	// Locations of pointer sized absolute addresses in code and data, which
+	// are adjusted when the executable is rebased (e.g. base relocations),
+	// sorted in ascending order; or nil if not present.
+	Relocs []Address
+	// Initial segment register values of 16-bit real mode executables (e.g.
+	// DOS executables); or nil if not applicable.
+	RealMode *RealMode
+	// Data appended to the executable file after the last section (e.g. the
+	// payload of installers and self-extracting archives); or nil if not
+	// present.
+	Overlay *Overlay
 }
 
 // Code returns the code starting at the specified address of the binary
@@ -93,6 +141,27 @@ func locateData(addr Address, sects []*Section) ([]byte, bool) {
 	return nil, false
 }
 
+// Perm returns the access permissions of the section containing the given
+// address; or 0 if the address is not contained within any section.
+func (file *File) Perm(addr Address) Perm {
+	for _, sect := range file.Sections {
+		size := len(sect.Data)
+		if sect.MemSize > size {
+			size = sect.MemSize
+		}
+		if sect.Addr <= addr && addr < sect.Addr+Address(size) {
+			return sect.Perm
+		}
+	}
+	return 0
+}
+
+// IsCode reports whether the given address is contained within an executable
+// section.
+func (file *File) IsCode(addr Address) bool {
+	return file.Perm(addr)&PermX != 0
+}
+
 // Arch represents the set of machine architectures.
 type Arch uint
 
@@ -108,11 +177,16 @@ const (
 	ArchMIPS_32
 	// ArchPowerPC_32 represents the 32-bit PowerPC machine architecture.
 	ArchPowerPC_32
+	// ArchX86_16 represents the 16-bit x86 machine architecture in real mode, as
+	// used by DOS.
+	ArchX86_16
 )
 
 // BitSize returns the bit size of the machine architecture.
 func (arch Arch) BitSize() int {
 	m := map[Arch]int{
+		// 16-bit architectures.
+		ArchX86_16: 16,
 		// 32-bit architectures.
 		ArchX86_32:     32,
 		ArchMIPS_32:    32,
@@ -129,6 +203,7 @@ func (arch Arch) BitSize() int {
 // Set sets arch to the machine architecture represented by s.
 func (arch *Arch) Set(s string) error {
 	m := map[string]Arch{
+		"x86_16":     ArchX86_16,
 		"x86_32":     ArchX86_32,
 		"x86_64":     ArchX86_64,
 		"MIPS_32":    ArchMIPS_32,
@@ -146,9 +221,15 @@ func (arch *Arch) Set(s string) error {
 	return fmt.Errorf("support for machine architecture %q not yet implemented;\n\tsupported machine architectures: %v", s, strings.Join(ss, ", "))
 }
 
+// UnmarshalText unmarshals the text into arch.
+func (arch *Arch) UnmarshalText(text []byte) error {
+	return arch.Set(string(text))
+}
+
 // String returns a string representation of the machine architecture.
 func (arch Arch) String() string {
 	m := map[Arch]string{
+		ArchX86_16:     "x86_16",
 		ArchX86_32:     "x86_32",
 		ArchX86_64:     "x86_64",
 		ArchMIPS_32:    "MIPS_32",
@@ -163,6 +244,21 @@ func (arch Arch) String() string {
 	panic(fmt.Errorf("support for machine architecture %v not yet implemented", uint(arch)))
 }
 
+// OrdinalName returns the synthesized name of the function imported by ordinal
+// from the given DLL; e.g. "ws2_32_ord_23" for ordinal 23 of "WS2_32.dll". The
+// DLL name is converted to lower case, as its case varies between linkers, and
+// characters not valid in identifiers are replaced with underscores.
+func OrdinalName(dllName string, ordinal uint64) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, strings.ToLower(strings.TrimSuffix(dllName, filepath.Ext(dllName))))
+	return fmt.Sprintf("%s_ord_%d", name, ordinal)
+}
+
 // A Section represents a continuous section of memory.
 type Section struct {
 	// Section name; or empty if unnamed section or memory segment.
@@ -188,6 +284,28 @@ type Section struct {
 	Perm Perm
 }
 
+// A Table specifies the location in memory of a table of the executable.
+type Table struct {
+	// Start address of the table.
+	Addr Address
+	// Size in bytes of the table.
+	Size int
+}
+
+// Names of tables.
+const (
+	// TableExport specifies the export table.
+	TableExport = "export_table"
+	// TableImport specifies the import table.
+	TableImport = "import_table"
+	// TableResource specifies the resource table.
+	TableResource = "resource_table"
+	// TableReloc specifies the base relocation table.
+	TableReloc = "reloc_table"
+	// TableIAT specifies the import address table.
+	TableIAT = "iat"
+)
+
 // Perm specifies the access permissions of a segment or section in memory.
 type Perm uint8
 
@@ -6,9 +6,9 @@ package bin
 
 import (
 	"io"
-	"log"
 	"os"
 
+	"github.com/decomp/exp/logging"
 	"github.com/mewkiz/pkg/term"
 	"github.com/pkg/errors"
 )
@@ -17,7 +17,7 @@ import (
 var (
 	// warn represents a logger with the "warning:" prefix, which logs warning
 	// messages to standard error.
-	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+	warn = logging.New("bin", logging.LevelInfo, term.RedBold("warning:")+" ")
 )
 
 // RegisterFormat registers a binary executable format for use by Parse. Name is
@@ -55,8 +55,13 @@ func ParseFile(path string) (*File, error) {
 
 // Parse parses the given binary executable, reading from r.
 //
+// Formats sharing the same magic prefix (e.g. "MZ" of PE and DOS executables)
+// are tried in order of registration, until one succeeds to parse the binary
+// executable.
+//
 // Users are responsible for closing r.
 func Parse(r io.ReaderAt) (*File, error) {
+	var firstErr error
 	for _, format := range formats {
 		buf := make([]byte, len(format.magic))
 		if n, err := r.ReadAt(buf, 0); err != nil {
@@ -67,9 +72,20 @@ func Parse(r io.ReaderAt) (*File, error) {
 			return nil, errors.WithStack(err)
 		}
 		if match(format.magic, buf) {
-			return format.parse(r)
+			file, err := format.parse(r)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errors.WithStack(err)
+				}
+				continue
+			}
+			file.Format = format.name
+			return file, nil
 		}
 	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
 	return nil, errors.New("unknown binary executable format;\n\ttip: try loading as raw binary executable")
 }
 
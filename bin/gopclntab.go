@@ -0,0 +1,221 @@
+package bin
+
+import (
+	"bytes"
+	"debug/gosym"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// goPclntabMagics specifies the magic numbers of the pclntab of the Go runtime
+// (Go 1.2, 1.16, 1.18 and 1.20).
+var goPclntabMagics = []uint32{0xFFFFFFFB, 0xFFFFFFFA, 0xFFFFFFF0, 0xFFFFFFF1}
+
+// ParseGoFuncs locates the pclntab (program counter line table) of the Go
+// runtime in the binary executable, and records the names and bounds of Go
+// functions in file.Symbols and file.FuncBounds. The pclntab is retained in
+// stripped Go executables. The compiler of the executable is recorded as
+// CompilerGo. The boolean return value reports whether a pclntab was located.
+//
+// Names of functions already present in the symbol table are retained. The
+// file is left unmodified if the pclntab is invalid; e.g. if functions of the
+// function table are located outside of the code section.
+func (file *File) ParseGoFuncs() (bool, error) {
+	data, ok := file.goPclntab()
+	if !ok {
+		return false, nil
+	}
+	text, ok := file.textSect()
+	if !ok {
+		return false, nil
+	}
+	table, err := parseGoTable(data, text.Addr)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	textEnd := text.Addr + Address(len(text.Data))
+	if text.MemSize > len(text.Data) {
+		textEnd = text.Addr + Address(text.MemSize)
+	}
+	for _, fn := range table.Funcs {
+		if entry := Address(fn.Entry); entry < text.Addr || entry >= textEnd {
+			return false, errors.Errorf("invalid pclntab; entry %v of function %q outside of code section %q", entry, fn.Name, text.Name)
+		}
+	}
+	if file.Symbols == nil {
+		file.Symbols = make(map[Address]string)
+	}
+	if file.FuncBounds == nil {
+		file.FuncBounds = make(map[Address]Address)
+	}
+	file.Compiler = CompilerGo
+	for _, fn := range table.Funcs {
+		entry := Address(fn.Entry)
+		if !file.IsCode(entry) {
+			continue
+		}
+		if _, ok := file.Symbols[entry]; !ok {
+			file.Symbols[entry] = fn.Name
+		}
+		if fn.End > fn.Entry {
+			file.FuncBounds[entry] = Address(fn.End)
+		}
+	}
+	return true, nil
+}
+
+// parseGoTable parses the given pclntab of the Go runtime, the code of which
+// starts at the specified address.
+func parseGoTable(data []byte, textStart Address) (table *gosym.Table, err error) {
+	// The Go symbol table parser panics on malformed pclntabs.
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("invalid pclntab; %v", e)
+		}
+	}()
+	table, err = gosym.NewTable(nil, gosym.NewLineTable(data, uint64(textStart)))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return table, nil
+}
+
+// goPclntab returns the contents of the pclntab of the Go runtime, as located
+// through its section name (e.g. .gopclntab of ELF files) or by searching
+// non-executable sections for a valid pclntab header. The boolean return value
+// indicates success.
+//
+//    struct pcHeader {
+//       uint32 magic;
+//       uint8  pad1, pad2; // 0, 0
+//       uint8  minLC;      // instruction size quantum; 1 on x86
+//       uint8  ptrSize;    // size of uintptr
+//       ...
+//    };
+func (file *File) goPclntab() ([]byte, bool) {
+	for _, sect := range file.Sections {
+		if sect.Name == ".gopclntab" && len(sect.Data) > 0 {
+			return sect.Data, true
+		}
+	}
+	ptrSize := byte(file.Arch.BitSize() / 8)
+	for _, sect := range file.Sections {
+		if sect.Perm&PermX != 0 || len(sect.Name) == 0 {
+			// Skip executable sections and segments.
+			continue
+		}
+		data := sect.Data
+		for _, magic := range goPclntabMagics {
+			var hdr [8]byte
+			binary.LittleEndian.PutUint32(hdr[:], magic)
+			hdr[6] = 1
+			hdr[7] = ptrSize
+			// The pclntab is pointer aligned.
+			for off := 0; off+len(hdr) <= len(data); {
+				pos := bytes.Index(data[off:], hdr[:])
+				if pos == -1 {
+					break
+				}
+				if start := off + pos; start%int(ptrSize) == 0 && file.validGoPclntab(data[start:]) {
+					return data[start:], true
+				}
+				off += pos + 1
+			}
+		}
+	}
+	return nil, false
+}
+
+// validGoPclntab reports whether the given data starts with a valid pclntab
+// header; i.e. whether the function table and the tables referenced by offset
+// are located within the data, and the code referenced by the header (Go 1.18
+// and later) is located within an executable section.
+//
+//    // Go 1.2.
+//    struct pcHeader {
+//       uint32  magic;   // 0xFFFFFFFB
+//       uint8   pad1, pad2, minLC, ptrSize;
+//       uintptr nfunc;
+//       // function table of nfunc (uintptr entry, uintptr offset) pairs.
+//    };
+//
+//    // Go 1.16.
+//    struct pcHeader {
+//       uint32  magic;   // 0xFFFFFFFA
+//       uint8   pad1, pad2, minLC, ptrSize;
+//       uintptr nfunc, nfiles;
+//       uintptr funcnameOffset, cuOffset, filetabOffset, pctabOffset, pclnOffset;
+//    };
+//
+//    // Go 1.18 and Go 1.20.
+//    struct pcHeader {
+//       uint32  magic;   // 0xFFFFFFF0 or 0xFFFFFFF1
+//       uint8   pad1, pad2, minLC, ptrSize;
+//       uintptr nfunc, nfiles, textStart;
+//       uintptr funcnameOffset, cuOffset, filetabOffset, pctabOffset, pclnOffset;
+//    };
+func (file *File) validGoPclntab(data []byte) bool {
+	ptrSize := uint64(data[7])
+	// field returns the i:th pointer sized field following the magic, padding,
+	// minLC and ptrSize fields of the header.
+	field := func(i int) (uint64, bool) {
+		off := 8 + uint64(i)*ptrSize
+		if off+ptrSize > uint64(len(data)) {
+			return 0, false
+		}
+		if ptrSize == 4 {
+			return uint64(binary.LittleEndian.Uint32(data[off:])), true
+		}
+		return binary.LittleEndian.Uint64(data[off:]), true
+	}
+	n := uint64(len(data))
+	nfunc, ok := field(0)
+	if !ok || nfunc == 0 || nfunc > n {
+		return false
+	}
+	switch magic := binary.LittleEndian.Uint32(data); magic {
+	case 0xFFFFFFFB:
+		// Function table of (entry, offset) pairs, followed by the end address
+		// of the last function.
+		return 8+ptrSize+(2*nfunc+1)*ptrSize <= n
+	case 0xFFFFFFFA, 0xFFFFFFF0, 0xFFFFFFF1:
+		first, entrySize := 2, ptrSize
+		if magic != 0xFFFFFFFA {
+			textStart, ok := field(2)
+			if !ok || !file.IsCode(Address(textStart)) {
+				return false
+			}
+			// Function table entries are pairs of 32-bit offsets since Go 1.18.
+			first, entrySize = 3, 4
+		}
+		hdrSize := 8 + uint64(first+5)*ptrSize
+		var offs [5]uint64
+		for i := range offs {
+			off, ok := field(first + i)
+			if !ok || off < hdrSize || off >= n {
+				return false
+			}
+			offs[i] = off
+		}
+		pclnOffset := offs[4]
+		return pclnOffset+(2*nfunc+1)*entrySize <= n
+	}
+	return false
+}
+
+// textSect returns the code section of the binary executable (e.g. .text). The
+// boolean return value indicates success.
+func (file *File) textSect() (*Section, bool) {
+	for _, sect := range file.Sections {
+		if sect.Name == ".text" {
+			return sect, true
+		}
+	}
+	for _, sect := range file.Sections {
+		if sect.Perm&PermX != 0 {
+			return sect, true
+		}
+	}
+	return nil, false
+}
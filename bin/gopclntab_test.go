@@ -0,0 +1,86 @@
+package bin_test
+
+import (
+	"encoding/binary"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/elf"
+)
+
+func TestFileParseGoFuncs(t *testing.T) {
+	// newFile returns a 32-bit executable with the given read-only data.
+	newFile := func(rdata []byte) *bin.File {
+		return &bin.File{
+			Arch: bin.ArchX86_32,
+			Sections: []*bin.Section{
+				{Name: ".text", Addr: 0x1000, Data: make([]byte, 0x100), Perm: bin.PermR | bin.PermX},
+				{Name: ".rdata", Addr: 0x2000, Data: rdata, Perm: bin.PermR},
+			},
+		}
+	}
+	// Go 1.2 pclntab header.
+	hdr := []byte{0xFB, 0xFF, 0xFF, 0xFF, 0, 0, 1, 4}
+
+	// Data matching the pclntab magic, with a function count exceeding the
+	// data.
+	rdata := make([]byte, 0x40)
+	copy(rdata[8:], hdr)
+	binary.LittleEndian.PutUint32(rdata[16:], 0x7FFFFFFF) // nfunc
+	file := newFile(rdata)
+	found, err := file.ParseGoFuncs()
+	if err != nil {
+		t.Fatalf("unable to parse Go functions; %+v", err)
+	}
+	if found {
+		t.Errorf("pclntab mismatch; expected none, got pclntab with %d functions", 0x7FFFFFFF)
+	}
+	if file.Compiler != "" || file.Symbols != nil {
+		t.Errorf("file mismatch; expected unmodified file, got compiler %q and %d symbols", file.Compiler, len(file.Symbols))
+	}
+
+	// Pclntab with a function located outside of the code section.
+	rdata = make([]byte, 0x40)
+	copy(rdata, hdr)
+	binary.LittleEndian.PutUint32(rdata[8:], 1)       // nfunc
+	binary.LittleEndian.PutUint32(rdata[12:], 0x9000) // entry
+	binary.LittleEndian.PutUint32(rdata[16:], 0x20)   // func offset
+	binary.LittleEndian.PutUint32(rdata[20:], 0x9010) // end
+	binary.LittleEndian.PutUint32(rdata[0x20:], 0x9000)
+	file = newFile(rdata)
+	if _, err := file.ParseGoFuncs(); err == nil {
+		t.Errorf("expected error for function outside of code section")
+	}
+	if file.Compiler != "" || file.Symbols != nil {
+		t.Errorf("file mismatch; expected unmodified file, got compiler %q and %d symbols", file.Compiler, len(file.Symbols))
+	}
+}
+
+func TestFileParseGoFuncsExecutable(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("test executable of %q is not an ELF file", runtime.GOOS)
+	}
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatalf("unable to locate test executable; %v", err)
+	}
+	file, err := elf.ParseFile(path)
+	if err != nil {
+		t.Fatalf("unable to parse %q; %+v", path, err)
+	}
+	if file.Compiler != bin.CompilerGo {
+		t.Errorf("compiler mismatch; expected %q, got %q", bin.CompilerGo, file.Compiler)
+	}
+	var found bool
+	for _, name := range file.Symbols {
+		if name == "runtime.main" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("unable to locate symbol of %q", "runtime.main")
+	}
+}
@@ -0,0 +1,698 @@
+// Package le provides access to LE (Linear Executable) and LX files; 32-bit
+// executables of DOS extenders (e.g. DOS/4GW), OS/2 2.x and Windows VxDs.
+//
+// Objects of LE and LX executables are loaded at their relocation base
+// addresses. Imported functions are assigned addresses of a synthetic section
+// following the last object, and fixups referring to imports are resolved to
+// these addresses.
+package le
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/logging"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = logging.New("le", logging.LevelInfo, term.RedBold("warning:")+" ")
+)
+
+// Register LE format.
+func init() {
+	// Linear Executable (LE and LX) format. The MZ stub is followed by the LE
+	// header, located at the e_lfanew offset of the MZ header.
+	//
+	//    4D 5A  |MZ|
+	const magic = "MZ"
+	bin.RegisterFormat("le", magic, Parse)
+}
+
+// ParseFile parses the given LE or LX executable, reading from path.
+func ParseFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// header is the LE and LX header.
+type header struct {
+	Magic          [2]byte // "LE" or "LX"
+	ByteOrder      uint8
+	WordOrder      uint8
+	FormatLevel    uint32
+	CPU            uint16
+	OS             uint16
+	ModVersion     uint32
+	ModFlags       uint32
+	NPages         uint32
+	EIPObj         uint32 // object number (1-based)
+	EIP            uint32
+	ESPObj         uint32 // object number (1-based)
+	ESP            uint32
+	PageSize       uint32
+	PageShift      uint32 // LE: size of last page; LX: page offset shift
+	FixupSize      uint32
+	FixupCsum      uint32
+	LoaderSize     uint32
+	LoaderCsum     uint32
+	ObjTabOff      uint32 // relative to LE header
+	NObjs          uint32
+	PageTabOff     uint32 // relative to LE header
+	IterPagesOff   uint32
+	ResTabOff      uint32
+	NResources     uint32
+	ResNamesOff    uint32 // relative to LE header
+	EntryTabOff    uint32 // relative to LE header
+	DirectivesOff  uint32
+	NDirectives    uint32
+	FixupPageOff   uint32 // relative to LE header
+	FixupRecOff    uint32 // relative to LE header
+	ImpModOff      uint32 // relative to LE header
+	NImpMods       uint32
+	ImpProcOff     uint32 // relative to LE header
+	PageCsumOff    uint32
+	DataPagesOff   uint32 // relative to start of file
+	NPreloadPages  uint32
+	NonresNamesOff uint32 // relative to start of file
+}
+
+// object is an entry of the object table.
+type object struct {
+	// Size in bytes of the object in memory.
+	Size uint32
+	// Relocation base address of the object.
+	Addr uint32
+	// Object flags.
+	Flags uint32
+	// Index of the first entry of the object in the object page table
+	// (1-based).
+	PageIndex uint32
+	// Number of entries in the object page table.
+	NPages uint32
+	// Reserved.
+	Reserved uint32
+}
+
+// Object flags.
+const (
+	objRead  = 0x0001
+	objWrite = 0x0002
+	objExec  = 0x0004
+	// objBig specifies that the object holds 32-bit code.
+	objBig = 0x2000
+)
+
+// Parse parses the given LE or LX executable, reading from r.
+//
+// Users are responsible for closing r.
+func Parse(r io.ReaderAt) (*bin.File, error) {
+	// Locate LE header, as referenced by the e_lfanew field of the MZ header.
+	var buf [4]byte
+	if _, err := r.ReadAt(buf[:2], 0); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if string(buf[:2]) != "MZ" {
+		return nil, errors.Errorf("invalid MZ magic; expected %q, got %q", "MZ", buf[:2])
+	}
+	if _, err := r.ReadAt(buf[:], 0x3C); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	leOff := int64(binary.LittleEndian.Uint32(buf[:]))
+	var hdr header
+	if err := binary.Read(io.NewSectionReader(r, leOff, 0xAC), binary.LittleEndian, &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	magic := string(hdr.Magic[:])
+	if magic != "LE" && magic != "LX" {
+		return nil, errors.Errorf("invalid LE magic; expected %q or %q, got %q", "LE", "LX", hdr.Magic[:])
+	}
+	if hdr.ByteOrder != 0 || hdr.WordOrder != 0 {
+		return nil, errors.New("support for big-endian LE executables not yet implemented")
+	}
+	isLX := magic == "LX"
+
+	// Parse object table.
+	objs := make([]object, hdr.NObjs)
+	if err := binary.Read(io.NewSectionReader(r, leOff+int64(hdr.ObjTabOff), int64(24*len(objs))), binary.LittleEndian, objs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Parse object page table; file offsets and sizes of pages.
+	pageOffs := make([]int64, hdr.NPages)
+	pageSizes := make([]int, hdr.NPages)
+	for i := range pageOffs {
+		if isLX {
+			var entry struct {
+				Offset uint32
+				Size   uint16
+				Flags  uint16
+			}
+			if err := binary.Read(io.NewSectionReader(r, leOff+int64(hdr.PageTabOff)+int64(8*i), 8), binary.LittleEndian, &entry); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			pageOffs[i] = int64(hdr.DataPagesOff) + int64(entry.Offset)<<hdr.PageShift
+			pageSizes[i] = int(entry.Size)
+			continue
+		}
+		// LE page table entries hold a 24-bit big-endian page number (1-based),
+		// followed by flags.
+		var entry [4]byte
+		if _, err := r.ReadAt(entry[:], leOff+int64(hdr.PageTabOff)+int64(4*i)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		n := int64(entry[0])<<16 | int64(entry[1])<<8 | int64(entry[2])
+		pageOffs[i] = int64(hdr.DataPagesOff) + (n-1)*int64(hdr.PageSize)
+		pageSizes[i] = int(hdr.PageSize)
+		if i == len(pageOffs)-1 && hdr.PageShift != 0 {
+			// Size of last page.
+			pageSizes[i] = int(hdr.PageShift)
+		}
+	}
+
+	// Parse objects.
+	file := &bin.File{
+		Arch:    bin.ArchX86_32,
+		Imports: make(map[bin.Address]string),
+		Exports: make(map[bin.Address]string),
+	}
+	// Object page of each page (0-based) within its object.
+	type pageLoc struct {
+		obj int
+		off int
+	}
+	pageLocs := make(map[int]pageLoc)
+	for i, obj := range objs {
+		data := make([]byte, obj.Size)
+		for j := 0; j < int(obj.NPages); j++ {
+			page := int(obj.PageIndex) - 1 + j
+			if page < 0 || page >= len(pageOffs) {
+				return nil, errors.Errorf("invalid page index %d of object %d", page+1, i+1)
+			}
+			off := j * int(hdr.PageSize)
+			pageLocs[page] = pageLoc{obj: i, off: off}
+			if off >= len(data) {
+				continue
+			}
+			end := off + pageSizes[page]
+			if end > len(data) {
+				end = len(data)
+			}
+			if _, err := r.ReadAt(data[off:end], pageOffs[page]); err != nil && errors.Cause(err) != io.EOF {
+				return nil, errors.WithStack(err)
+			}
+		}
+		var perm bin.Perm
+		if obj.Flags&objRead != 0 {
+			perm |= bin.PermR
+		}
+		if obj.Flags&objWrite != 0 {
+			perm |= bin.PermW
+		}
+		if obj.Flags&objExec != 0 {
+			perm |= bin.PermX
+		}
+		sect := &bin.Section{
+			Name:     fmt.Sprintf("obj%d", i+1),
+			Addr:     bin.Address(obj.Addr),
+			Data:     data,
+			FileSize: len(data),
+			MemSize:  len(data),
+			Perm:     perm,
+		}
+		if obj.NPages > 0 {
+			sect.Offset = uint64(pageOffs[obj.PageIndex-1])
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+	if len(file.Sections) > 0 {
+		file.ImageBase = file.Sections[0].Addr
+	}
+	objAddr := func(n uint16, off uint32) (bin.Address, bool) {
+		if n < 1 || int(n) > len(objs) {
+			return 0, false
+		}
+		return bin.Address(objs[n-1].Addr) + bin.Address(off), true
+	}
+
+	// Parse entry point; 16-bit objects (e.g. of VxDs) are decoded as 16-bit
+	// code.
+	if addr, ok := objAddr(uint16(hdr.EIPObj), hdr.EIP); ok {
+		file.Entry = addr
+		if objs[hdr.EIPObj-1].Flags&objBig == 0 {
+			file.Arch = bin.ArchX86_16
+		}
+	}
+
+	// Parse entry table, resident and non-resident names.
+	entries, err := parseEntries(r, leOff+int64(hdr.EntryTabOff))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	names := make(map[uint16]string)
+	if err := parseNames(r, leOff+int64(hdr.ResNamesOff), names); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if hdr.NonresNamesOff != 0 {
+		if err := parseNames(r, int64(hdr.NonresNamesOff), names); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	for ordinal, entry := range entries {
+		addr, ok := objAddr(entry.obj, entry.off)
+		if !ok {
+			continue
+		}
+		name, ok := names[ordinal]
+		if !ok {
+			name = fmt.Sprintf("ordinal_%d", ordinal)
+		}
+		file.Exports[addr] = name
+	}
+
+	// Parse imported module names.
+	var modNames []string
+	off := leOff + int64(hdr.ImpModOff)
+	for i := 0; i < int(hdr.NImpMods); i++ {
+		name, err := readName(r, off)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		modNames = append(modNames, name)
+		off += 1 + int64(len(name))
+	}
+
+	// Apply fixups. Imported functions are assigned consecutive addresses of
+	// the imports section.
+	var impBase bin.Address
+	for _, sect := range file.Sections {
+		if end := sect.Addr + bin.Address(sect.MemSize); end > impBase {
+			impBase = end
+		}
+	}
+	impBase = (impBase + 0xFFF) &^ 0xFFF
+	impAddrs := make(map[string]bin.Address)
+	importAddr := func(name string) bin.Address {
+		addr, ok := impAddrs[name]
+		if !ok {
+			addr = impBase + bin.Address(4*len(impAddrs))
+			impAddrs[name] = addr
+			file.Imports[addr] = name
+		}
+		return addr
+	}
+	fixupPages := make([]uint32, hdr.NPages+1)
+	if err := binary.Read(io.NewSectionReader(r, leOff+int64(hdr.FixupPageOff), int64(4*len(fixupPages))), binary.LittleEndian, fixupPages); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	recOff := leOff + int64(hdr.FixupRecOff)
+	for page := 0; page < int(hdr.NPages); page++ {
+		loc, ok := pageLocs[page]
+		if !ok {
+			continue
+		}
+		start, end := fixupPages[page], fixupPages[page+1]
+		if end <= start {
+			continue
+		}
+		recs := make([]byte, end-start)
+		if _, err := r.ReadAt(recs, recOff+int64(start)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		fixups, err := parseFixups(recs)
+		if err != nil {
+			return nil, errors.Errorf("unable to parse fixups of page %d; %v", page+1, err)
+		}
+		sect := file.Sections[loc.obj]
+		for _, fixup := range fixups {
+			// Locate target of fixup.
+			var target bin.Address
+			switch fixup.targetType {
+			case targetInternal:
+				addr, ok := objAddr(fixup.obj, fixup.off)
+				if !ok {
+					warn.Printf("invalid object number %d of fixup in page %d", fixup.obj, page+1)
+					continue
+				}
+				target = addr
+			case targetImportOrdinal, targetImportName:
+				if int(fixup.obj) < 1 || int(fixup.obj) > len(modNames) {
+					warn.Printf("invalid module index %d of fixup in page %d", fixup.obj, page+1)
+					continue
+				}
+				modName := modNames[fixup.obj-1]
+				name := fmt.Sprintf("%s_ordinal_%d", modName, fixup.off)
+				if fixup.targetType == targetImportName {
+					procName, err := readName(r, leOff+int64(hdr.ImpProcOff)+int64(fixup.off))
+					if err != nil {
+						return nil, errors.WithStack(err)
+					}
+					name = procName
+				}
+				target = importAddr(name)
+			default:
+				// Internal references through the entry table.
+				entry, ok := entries[uint16(fixup.obj)]
+				if !ok {
+					warn.Printf("unable to locate entry ordinal %d of fixup in page %d", fixup.obj, page+1)
+					continue
+				}
+				addr, ok := objAddr(entry.obj, entry.off)
+				if !ok {
+					continue
+				}
+				target = addr
+			}
+			target += bin.Address(fixup.additive)
+			for _, srcOff := range fixup.srcOffs {
+				pos := loc.off + int(srcOff)
+				if applyFixup(sect, pos, fixup.srcType, target) {
+					file.Relocs = append(file.Relocs, sect.Addr+bin.Address(pos))
+				}
+			}
+		}
+	}
+	sort.Sort(bin.Addresses(file.Relocs))
+	if len(impAddrs) > 0 {
+		sect := &bin.Section{
+			Name:    "imports",
+			Addr:    impBase,
+			MemSize: 4 * len(impAddrs),
+			Perm:    bin.PermR,
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+	return file, nil
+}
+
+// entry is an entry point of the entry table.
+type entry struct {
+	// Object number (1-based).
+	obj uint16
+	// Offset within object.
+	off uint32
+}
+
+// parseEntries parses the entry table at the given file offset, and returns
+// the entry points indexed by ordinal.
+func parseEntries(r io.ReaderAt, offset int64) (map[uint16]entry, error) {
+	entries := make(map[uint16]entry)
+	ordinal := uint16(1)
+	for {
+		// Bundle of entries.
+		var bundle [2]byte
+		if _, err := r.ReadAt(bundle[:], offset); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		n, typ := int(bundle[0]), bundle[1]
+		offset += 2
+		if n == 0 {
+			return entries, nil
+		}
+		if typ == 0x00 {
+			// Unused entries.
+			ordinal += uint16(n)
+			continue
+		}
+		// Object number, followed by entries of the bundle.
+		var objBuf [2]byte
+		if _, err := r.ReadAt(objBuf[:], offset); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		obj := binary.LittleEndian.Uint16(objBuf[:])
+		offset += 2
+		var size int64
+		switch typ & 0x7F {
+		case 0x01:
+			// 16-bit entry; flags and 16-bit offset.
+			size = 3
+		case 0x02:
+			// 286 call gate entry; flags, 16-bit offset and call gate selector.
+			size = 5
+		case 0x03:
+			// 32-bit entry; flags and 32-bit offset.
+			size = 5
+		case 0x04:
+			// Forwarder entry; flags, module ordinal and offset or ordinal.
+			size = 7
+		default:
+			return nil, errors.Errorf("support for entry bundle type 0x%02X not yet implemented", typ)
+		}
+		buf := make([]byte, size*int64(n))
+		if _, err := r.ReadAt(buf, offset); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		offset += int64(len(buf))
+		for i := 0; i < n; i++ {
+			e := buf[int64(i)*size:]
+			switch typ & 0x7F {
+			case 0x01, 0x02:
+				entries[ordinal] = entry{obj: obj, off: uint32(binary.LittleEndian.Uint16(e[1:]))}
+			case 0x03:
+				entries[ordinal] = entry{obj: obj, off: binary.LittleEndian.Uint32(e[1:])}
+			}
+			ordinal++
+		}
+	}
+}
+
+// parseNames parses the resident or non-resident name table at the given file
+// offset, and records the names of the table in names, indexed by ordinal. The
+// first entry of the table, holding the module name or description, is
+// skipped.
+func parseNames(r io.ReaderAt, offset int64, names map[uint16]string) error {
+	for first := true; ; first = false {
+		name, err := readName(r, offset)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(name) == 0 {
+			return nil
+		}
+		var buf [2]byte
+		if _, err := r.ReadAt(buf[:], offset+1+int64(len(name))); err != nil {
+			return errors.WithStack(err)
+		}
+		offset += 1 + int64(len(name)) + 2
+		if !first {
+			names[binary.LittleEndian.Uint16(buf[:])] = name
+		}
+	}
+}
+
+// readName reads the length-prefixed string at the given file offset.
+func readName(r io.ReaderAt, offset int64) (string, error) {
+	var n [1]byte
+	if _, err := r.ReadAt(n[:], offset); err != nil {
+		return "", errors.WithStack(err)
+	}
+	buf := make([]byte, n[0])
+	if _, err := r.ReadAt(buf, offset+1); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(buf), nil
+}
+
+// fixup is a fixup record of a page.
+type fixup struct {
+	// Source type (e.g. srcOffset32).
+	srcType uint8
+	// Target type (e.g. targetImportOrdinal).
+	targetType uint8
+	// Offsets within the page of the source locations; may be negative for
+	// source locations crossing a page boundary.
+	srcOffs []int16
+	// Object number (internal references), module index (imports) or entry
+	// ordinal (internal references through the entry table).
+	obj uint16
+	// Offset (internal references), ordinal (import by ordinal) or name
+	// offset (import by name).
+	off uint32
+	// Value added to the target.
+	additive uint32
+}
+
+// Source types of fixups.
+const (
+	srcByte       = 0x00
+	srcSelector16 = 0x02
+	srcPtr16_16   = 0x03
+	srcOffset16   = 0x05
+	srcPtr16_32   = 0x06
+	srcOffset32   = 0x07
+	srcRel32      = 0x08
+	// srcAlias specifies a fixup to a 16:16 alias.
+	srcAlias = 0x10
+	// srcList specifies that the fixup has a list of source offsets.
+	srcList = 0x20
+)
+
+// Target types of fixups.
+const (
+	targetInternal      = 0x00
+	targetImportOrdinal = 0x01
+	targetImportName    = 0x02
+	targetEntry         = 0x03
+)
+
+// Target flags of fixups.
+const (
+	// flagAdditive specifies that an additive value is present.
+	flagAdditive = 0x04
+	// flagTarget32 specifies 32-bit target offsets (16-bit otherwise).
+	flagTarget32 = 0x10
+	// flagAdditive32 specifies a 32-bit additive value (16-bit otherwise).
+	flagAdditive32 = 0x20
+	// flagObj16 specifies 16-bit object numbers and module indices (8-bit
+	// otherwise).
+	flagObj16 = 0x40
+	// flagOrdinal8 specifies 8-bit import ordinals.
+	flagOrdinal8 = 0x80
+)
+
+// parseFixups parses the fixup records of a page.
+func parseFixups(buf []byte) ([]fixup, error) {
+	var fixups []fixup
+	// read reads an n-byte little-endian value.
+	read := func(n int) (uint32, error) {
+		if len(buf) < n {
+			return 0, errors.New("unexpected end of fixup records")
+		}
+		var v uint32
+		for i := n - 1; i >= 0; i-- {
+			v = v<<8 | uint32(buf[i])
+		}
+		buf = buf[n:]
+		return v, nil
+	}
+	for len(buf) > 0 {
+		src, err := read(1)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		flags, err := read(1)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		f := fixup{srcType: uint8(src) &^ (srcAlias | srcList), targetType: uint8(flags) & 0x03}
+		nsrcs := uint32(1)
+		if src&srcList != 0 {
+			if nsrcs, err = read(1); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		} else {
+			v, err := read(2)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			f.srcOffs = append(f.srcOffs, int16(v))
+		}
+		objSize := 1
+		if flags&flagObj16 != 0 {
+			objSize = 2
+		}
+		offSize := 2
+		if flags&flagTarget32 != 0 {
+			offSize = 4
+		}
+		obj, err := read(objSize)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		f.obj = uint16(obj)
+		switch f.targetType {
+		case targetInternal:
+			// Selector fixups have no target offset.
+			if f.srcType != srcSelector16 {
+				if f.off, err = read(offSize); err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
+		case targetImportOrdinal:
+			ordSize := offSize
+			if flags&flagOrdinal8 != 0 {
+				ordSize = 1
+			}
+			if f.off, err = read(ordSize); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		case targetImportName:
+			if f.off, err = read(offSize); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		case targetEntry:
+			// Entry ordinal held by obj.
+		}
+		if flags&flagAdditive != 0 {
+			n := 2
+			if flags&flagAdditive32 != 0 {
+				n = 4
+			}
+			if f.additive, err = read(n); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		for i := uint32(0); i < nsrcs && src&srcList != 0; i++ {
+			v, err := read(2)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			f.srcOffs = append(f.srcOffs, int16(v))
+		}
+		fixups = append(fixups, f)
+	}
+	return fixups, nil
+}
+
+// applyFixup applies the fixup of the given source type at the given offset of
+// the section, resolving the source location to the target address. Portions of
+// source locations outside of the section are skipped. The boolean return value
+// indicates whether a 32-bit absolute address was written, which is adjusted
+// when the executable is rebased.
+func applyFixup(sect *bin.Section, pos int, srcType uint8, target bin.Address) bool {
+	put := func(pos int, v []byte) {
+		for i, b := range v {
+			if j := pos + i; j >= 0 && j < len(sect.Data) {
+				sect.Data[j] = b
+			}
+		}
+	}
+	var v [4]byte
+	switch srcType {
+	case srcByte:
+		put(pos, []byte{uint8(target)})
+	case srcOffset16:
+		binary.LittleEndian.PutUint16(v[:], uint16(target))
+		put(pos, v[:2])
+	case srcOffset32, srcPtr16_32:
+		// The selector of 16:32 pointers is left as is.
+		binary.LittleEndian.PutUint32(v[:], uint32(target))
+		put(pos, v[:])
+		return pos >= 0 && pos+4 <= len(sect.Data)
+	case srcRel32:
+		src := sect.Addr + bin.Address(pos) + 4
+		binary.LittleEndian.PutUint32(v[:], uint32(target-src))
+		put(pos, v[:])
+	case srcSelector16, srcPtr16_16:
+		// Selectors of 16-bit segments are left as is.
+		if srcType == srcPtr16_16 {
+			binary.LittleEndian.PutUint16(v[:], uint16(target))
+			put(pos, v[:2])
+		}
+	default:
+		warn.Printf("support for fixup source type 0x%02X not yet implemented", srcType)
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+// Package mz provides access to DOS executables; MZ executables (.EXE) and flat
+// binary executables (.COM) of 16-bit real mode.
+//
+// The load module of MZ executables and the contents of .COM executables are
+// loaded at linear address LoadSeg*16, following the Program Segment Prefix
+// (PSP), which is located at segment LoadSeg-0x10. Segment fixups of MZ
+// executables are applied relative to LoadSeg.
+package mz
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Register MZ format.
+func init() {
+	// DOS MZ executable format. The magic number is shared with new executable
+	// formats (e.g. PE), which are rejected by Parse.
+	//
+	//    4D 5A  |MZ|
+	const magic = "MZ"
+	bin.RegisterFormat("mz", magic, Parse)
+}
+
+// LoadSeg specifies the segment at which the load module of DOS executables is
+// loaded.
+const LoadSeg = 0x1000
+
+// pspSize specifies the size in bytes of the Program Segment Prefix (PSP).
+const pspSize = 0x100
+
+// ParseFile parses the given MZ executable, reading from path.
+func ParseFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses the given MZ executable, reading from r.
+//
+// Users are responsible for closing r.
+func Parse(r io.ReaderAt) (*bin.File, error) {
+	// Parse MZ header.
+	//
+	//    struct IMAGE_DOS_HEADER {
+	//       uint16 e_magic;    // "MZ"
+	//       uint16 e_cblp;     // bytes on last page of file
+	//       uint16 e_cp;       // pages (512 bytes) in file
+	//       uint16 e_crlc;     // number of relocations
+	//       uint16 e_cparhdr;  // size of header in paragraphs
+	//       uint16 e_minalloc; // minimum extra paragraphs needed
+	//       uint16 e_maxalloc; // maximum extra paragraphs needed
+	//       uint16 e_ss;       // initial (relative) SS
+	//       uint16 e_sp;       // initial SP
+	//       uint16 e_csum;     // checksum
+	//       uint16 e_ip;       // initial IP
+	//       uint16 e_cs;       // initial (relative) CS
+	//       uint16 e_lfarlc;   // file offset of relocation table
+	//       uint16 e_ovno;     // overlay number
+	//       ...
+	//       uint32 e_lfanew;   // file offset of new executable header
+	//    };
+	var hdr struct {
+		Magic    [2]byte
+		Cblp     uint16
+		Cp       uint16
+		Crlc     uint16
+		Cparhdr  uint16
+		Minalloc uint16
+		Maxalloc uint16
+		SS       uint16
+		SP       uint16
+		Csum     uint16
+		IP       uint16
+		CS       uint16
+		Lfarlc   uint16
+		Ovno     uint16
+	}
+	sr := io.NewSectionReader(r, 0, 1<<31-1)
+	if err := binary.Read(sr, binary.LittleEndian, &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if string(hdr.Magic[:]) != "MZ" {
+		return nil, errors.Errorf("invalid MZ magic; expected %q, got %q", "MZ", hdr.Magic[:])
+	}
+	if sig, ok := newExeSig(r, hdr.Lfarlc); ok {
+		return nil, errors.Errorf("unable to parse %q executable as MZ executable", sig)
+	}
+
+	// Locate load module.
+	size := int64(hdr.Cp) * 512
+	if hdr.Cblp != 0 {
+		size -= 512 - int64(hdr.Cblp)
+	}
+	start := int64(hdr.Cparhdr) * 16
+	if size < start {
+		return nil, errors.Errorf("invalid size of load module; image size (%d) smaller than header size (%d)", size, start)
+	}
+	data := make([]byte, size-start)
+	n, err := r.ReadAt(data, start)
+	if err != nil && errors.Cause(err) != io.EOF {
+		return nil, errors.WithStack(err)
+	}
+	// Truncated executables are loaded up to the end of file.
+	data = data[:n]
+
+	// Apply segment fixups.
+	file := &bin.File{
+		Arch:      bin.ArchX86_16,
+		ImageBase: bin.SegmentBase(LoadSeg),
+		RealMode: &bin.RealMode{
+			CS: LoadSeg + hdr.CS,
+			DS: LoadSeg - pspSize/16,
+			ES: LoadSeg - pspSize/16,
+			SS: LoadSeg + hdr.SS,
+			SP: hdr.SP,
+		},
+	}
+	relocs := make([]uint16, 2*int(hdr.Crlc))
+	if len(relocs) > 0 {
+		sr := io.NewSectionReader(r, int64(hdr.Lfarlc), int64(4*len(relocs)))
+		if err := binary.Read(sr, binary.LittleEndian, relocs); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	for i := 0; i < len(relocs); i += 2 {
+		// Segmented address of the fixup, relative to the load module.
+		off, seg := relocs[i], relocs[i+1]
+		pos := uint64(bin.LinearAddr(seg, off))
+		if pos+2 > uint64(len(data)) {
+			continue
+		}
+		v := binary.LittleEndian.Uint16(data[pos:])
+		binary.LittleEndian.PutUint16(data[pos:], v+LoadSeg)
+		file.Relocs = append(file.Relocs, file.ImageBase+bin.Address(pos))
+	}
+	sort.Sort(bin.Addresses(file.Relocs))
+
+	// Parse load module. Real mode segments are unprotected; the load module is
+	// followed by at least e_minalloc paragraphs of uninitialized memory.
+	sect := &bin.Section{
+		Name:     "load",
+		Addr:     file.ImageBase,
+		Offset:   uint64(start),
+		Data:     data,
+		FileSize: len(data),
+		MemSize:  len(data) + 16*int(hdr.Minalloc),
+		Perm:     bin.PermR | bin.PermW | bin.PermX,
+	}
+	file.Sections = append(file.Sections, sect)
+	file.Entry = bin.LinearAddr(file.RealMode.CS, hdr.IP)
+
+	// Parse overlay, following the load module (e.g. overlays of overlay
+	// managers and the payload of self-extracting archives).
+	overlay, err := bin.ReadOverlay(r, size)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file.Overlay = overlay
+	return file, nil
+}
+
+// newExeSig returns the signature of the new executable header (e.g. "PE" or
+// "NE") of the given executable, as referenced from the e_lfanew field of the
+// MZ header. The boolean return value indicates whether a new executable header
+// is present.
+func newExeSig(r io.ReaderAt, lfarlc uint16) (string, bool) {
+	// The relocation table of new executables is located at offset 0x40 or
+	// above.
+	if lfarlc < 0x40 {
+		return "", false
+	}
+	var buf [4]byte
+	if _, err := r.ReadAt(buf[:], 0x3C); err != nil {
+		return "", false
+	}
+	lfanew := int64(binary.LittleEndian.Uint32(buf[:]))
+	if lfanew == 0 {
+		return "", false
+	}
+	if _, err := r.ReadAt(buf[:], lfanew); err != nil {
+		return "", false
+	}
+	sigs := []string{"PE\x00\x00", "NE", "LE", "LX"}
+	for _, sig := range sigs {
+		if bytes.HasPrefix(buf[:], []byte(sig)) {
+			return string(bytes.TrimRight([]byte(sig), "\x00")), true
+		}
+	}
+	return "", false
+}
+
+// ParseCOMFile parses the given .COM executable, reading from path.
+func ParseCOMFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseCOM(f)
+}
+
+// ParseCOM parses the given .COM executable, reading from r. Executables with
+// an MZ header are parsed as MZ executables, as is done by DOS.
+//
+// The contents of .COM executables are loaded at offset 0x100 of the segment of
+// the PSP, which is the initial value of all segment registers.
+func ParseCOM(r io.Reader) (*bin.File, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if bytes.HasPrefix(data, []byte("MZ")) {
+		file, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		file.Format = "mz"
+		return file, nil
+	}
+	const maxSize = 0x10000 - pspSize
+	if len(data) > maxSize {
+		return nil, errors.Errorf("invalid size of .COM executable; expected <= %d bytes, got %d", maxSize, len(data))
+	}
+	psp := uint16(LoadSeg - pspSize/16)
+	file := &bin.File{
+		Format:    "com",
+		Arch:      bin.ArchX86_16,
+		ImageBase: bin.SegmentBase(LoadSeg),
+		RealMode: &bin.RealMode{
+			CS: psp,
+			DS: psp,
+			ES: psp,
+			SS: psp,
+			SP: 0xFFFE,
+		},
+	}
+	// The segment of .COM executables spans 64 KB, including the PSP.
+	sect := &bin.Section{
+		Name:     "com",
+		Addr:     file.ImageBase,
+		Data:     data,
+		FileSize: len(data),
+		MemSize:  maxSize,
+		Perm:     bin.PermR | bin.PermW | bin.PermX,
+	}
+	file.Sections = append(file.Sections, sect)
+	file.Entry = bin.LinearAddr(psp, pspSize)
+	return file, nil
+}
@@ -0,0 +1,67 @@
+package mz_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/mz"
+)
+
+func TestParse(t *testing.T) {
+	// MZ executable with a header of 2 paragraphs and a load module of 6 bytes;
+	// the segment of `mov ax, 0x0001` is fixed up by the loader.
+	//
+	//    mov ax, 0x0001
+	//    mov ds, ax
+	//    retf
+	buf := []byte{
+		'M', 'Z',
+		0x26, 0x00, // e_cblp
+		0x01, 0x00, // e_cp
+		0x01, 0x00, // e_crlc
+		0x02, 0x00, // e_cparhdr
+		0x00, 0x00, // e_minalloc
+		0xFF, 0xFF, // e_maxalloc
+		0x00, 0x00, // e_ss
+		0x00, 0x01, // e_sp
+		0x00, 0x00, // e_csum
+		0x00, 0x00, // e_ip
+		0x00, 0x00, // e_cs
+		0x1C, 0x00, // e_lfarlc
+		0x00, 0x00, // e_ovno
+		0x01, 0x00, 0x00, 0x00, // relocation 0000:0001
+	}
+	buf = append(buf, 0xB8, 0x01, 0x00, 0x8E, 0xD8, 0xCB)
+	file, err := mz.Parse(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("unable to parse MZ executable; %v", err)
+	}
+	if want := bin.SegmentBase(mz.LoadSeg); file.Entry != want {
+		t.Errorf("entry mismatch; expected %v, got %v", want, file.Entry)
+	}
+	v, err := file.ReadUint16(file.Entry + 1)
+	if err != nil {
+		t.Fatalf("unable to read fixup; %v", err)
+	}
+	if want := uint16(mz.LoadSeg + 1); v != want {
+		t.Errorf("fixup mismatch; expected 0x%04X, got 0x%04X", want, v)
+	}
+}
+
+func TestParseCOM(t *testing.T) {
+	// mov ah, 0x4C
+	// int 0x21
+	buf := []byte{0xB4, 0x4C, 0xCD, 0x21}
+	file, err := mz.ParseCOM(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("unable to parse .COM executable; %v", err)
+	}
+	psp := uint16(mz.LoadSeg - 0x10)
+	if want := bin.LinearAddr(psp, 0x100); file.Entry != want {
+		t.Errorf("entry mismatch; expected %v, got %v", want, file.Entry)
+	}
+	if file.RealMode.DS != psp {
+		t.Errorf("data segment mismatch; expected 0x%04X, got 0x%04X", psp, file.RealMode.DS)
+	}
+}
@@ -0,0 +1,505 @@
+// Package ne provides access to NE (New Executable) files; 16-bit executables
+// and DLLs of Windows 3.x and OS/2 1.x.
+//
+// Segments of NE executables are loaded at consecutive 64 KB aligned linear
+// addresses; the i:th segment (1-based) is loaded at segment Seg(i) (see
+// bin.RealMode). Imported functions are assigned addresses of a synthetic
+// segment following the last segment, and far pointer fixups referring to
+// imports are resolved to these addresses.
+package ne
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/logging"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = logging.New("ne", logging.LevelInfo, term.RedBold("warning:")+" ")
+)
+
+// Register NE format.
+func init() {
+	// New Executable (NE) format. The MZ stub is followed by the NE header,
+	// located at the e_lfanew offset of the MZ header.
+	//
+	//    4D 5A  |MZ|
+	const magic = "MZ"
+	bin.RegisterFormat("ne", magic, Parse)
+}
+
+// LoadSeg specifies the segment at which the first segment of NE executables is
+// loaded.
+const LoadSeg = 0x1000
+
+// Seg returns the segment at which the i:th segment (1-based) of NE executables
+// is loaded.
+func Seg(i int) uint16 {
+	return uint16(LoadSeg + 0x1000*(i-1))
+}
+
+// ParseFile parses the given NE executable, reading from path.
+func ParseFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// header is the NE header.
+type header struct {
+	Magic           [2]byte // "NE"
+	LinkerVer       uint8
+	LinkerRev       uint8
+	EntryTabOff     uint16 // relative to NE header
+	EntryTabSize    uint16
+	CRC             uint32
+	Flags           uint16
+	AutoDataSeg     uint16 // segment index of automatic data segment
+	HeapSize        uint16
+	StackSize       uint16
+	IP              uint16
+	CS              uint16 // segment index
+	SP              uint16
+	SS              uint16 // segment index
+	NSegs           uint16
+	NModRefs        uint16
+	NonresNamesSize uint16
+	SegTabOff       uint16 // relative to NE header
+	ResTabOff       uint16 // relative to NE header
+	ResNamesOff     uint16 // relative to NE header
+	ModRefTabOff    uint16 // relative to NE header
+	ImpNamesOff     uint16 // relative to NE header
+	NonresNamesOff  uint32 // relative to start of file
+	NMovable        uint16
+	AlignShift      uint16
+	NResSegs        uint16
+	TargetOS        uint8
+	Flags2          uint8
+}
+
+// segEntry is an entry of the segment table.
+type segEntry struct {
+	// File offset of segment contents, in units of 1 << AlignShift; or 0 if
+	// no contents.
+	Sector uint16
+	// Size in bytes of segment contents; or 0 if 64 KB (or no contents).
+	Size uint16
+	// Segment flags.
+	Flags uint16
+	// Minimum allocation size in bytes; or 0 if 64 KB.
+	MinAlloc uint16
+}
+
+// Segment flags.
+const (
+	// segData specifies that the segment is a data segment (code otherwise).
+	segData = 0x0001
+	// segRelocs specifies that the segment contents are followed by relocation
+	// records.
+	segRelocs = 0x0100
+)
+
+// Parse parses the given NE executable, reading from r.
+//
+// Users are responsible for closing r.
+func Parse(r io.ReaderAt) (*bin.File, error) {
+	// Locate NE header, as referenced by the e_lfanew field of the MZ header.
+	var buf [4]byte
+	if _, err := r.ReadAt(buf[:2], 0); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if string(buf[:2]) != "MZ" {
+		return nil, errors.Errorf("invalid MZ magic; expected %q, got %q", "MZ", buf[:2])
+	}
+	if _, err := r.ReadAt(buf[:], 0x3C); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	neOff := int64(binary.LittleEndian.Uint32(buf[:]))
+	var hdr header
+	if err := binary.Read(io.NewSectionReader(r, neOff, 0x40), binary.LittleEndian, &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if string(hdr.Magic[:]) != "NE" {
+		return nil, errors.Errorf("invalid NE magic; expected %q, got %q", "NE", hdr.Magic[:])
+	}
+
+	// Parse segment table.
+	segs := make([]segEntry, hdr.NSegs)
+	if err := binary.Read(io.NewSectionReader(r, neOff+int64(hdr.SegTabOff), int64(8*len(segs))), binary.LittleEndian, segs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file := &bin.File{
+		Arch:      bin.ArchX86_16,
+		ImageBase: bin.SegmentBase(Seg(1)),
+		Imports:   make(map[bin.Address]string),
+		Exports:   make(map[bin.Address]string),
+	}
+	var datas [][]byte
+	for i, seg := range segs {
+		size := int(seg.Size)
+		if size == 0 && seg.Sector != 0 {
+			size = 0x10000
+		}
+		data := make([]byte, size)
+		offset := int64(seg.Sector) << hdr.AlignShift
+		if seg.Sector != 0 {
+			if _, err := r.ReadAt(data, offset); err != nil && errors.Cause(err) != io.EOF {
+				return nil, errors.WithStack(err)
+			}
+		}
+		datas = append(datas, data)
+		memSize := int(seg.MinAlloc)
+		if memSize == 0 {
+			memSize = 0x10000
+		}
+		if memSize < size {
+			memSize = size
+		}
+		perm := bin.PermR | bin.PermX
+		name := fmt.Sprintf("seg%d", i+1)
+		if seg.Flags&segData != 0 {
+			perm = bin.PermR | bin.PermW
+		}
+		sect := &bin.Section{
+			Name:     name,
+			Addr:     bin.SegmentBase(Seg(i + 1)),
+			Offset:   uint64(offset),
+			Data:     data,
+			FileSize: size,
+			MemSize:  memSize,
+			Perm:     perm,
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+
+	// Parse entry table, resident and non-resident names.
+	entries, err := parseEntries(r, neOff+int64(hdr.EntryTabOff), int64(hdr.EntryTabSize))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	names := make(map[uint16]string)
+	if err := parseNames(r, neOff+int64(hdr.ResNamesOff), names); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if hdr.NonresNamesOff != 0 {
+		if err := parseNames(r, int64(hdr.NonresNamesOff), names); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	for ordinal, entry := range entries {
+		addr := bin.LinearAddr(Seg(int(entry.seg)), entry.off)
+		name, ok := names[ordinal]
+		if !ok {
+			name = fmt.Sprintf("ordinal_%d", ordinal)
+		}
+		file.Exports[addr] = name
+	}
+
+	// Parse imported module names.
+	modRefs := make([]uint16, hdr.NModRefs)
+	if err := binary.Read(io.NewSectionReader(r, neOff+int64(hdr.ModRefTabOff), int64(2*len(modRefs))), binary.LittleEndian, modRefs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	impNamesOff := neOff + int64(hdr.ImpNamesOff)
+	var modNames []string
+	for _, off := range modRefs {
+		name, err := readName(r, impNamesOff+int64(off))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		modNames = append(modNames, name)
+	}
+
+	// Apply segment fixups. Imported functions are assigned consecutive
+	// addresses of the imports segment.
+	impSeg := Seg(len(segs) + 1)
+	impAddrs := make(map[string]uint16)
+	importAddr := func(name string) (uint16, uint16) {
+		off, ok := impAddrs[name]
+		if !ok {
+			off = uint16(len(impAddrs))
+			impAddrs[name] = off
+			file.Imports[bin.LinearAddr(impSeg, off)] = name
+		}
+		return impSeg, off
+	}
+	for i, seg := range segs {
+		if seg.Flags&segRelocs == 0 || seg.Sector == 0 {
+			continue
+		}
+		sect := file.Sections[i]
+		relocsOff := int64(sect.Offset) + int64(len(datas[i]))
+		fixups, err := parseFixups(r, relocsOff)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, fixup := range fixups {
+			// Locate target of fixup.
+			var targetSeg, targetOff uint16
+			switch fixup.TargetType & 0x03 {
+			case targetInternal:
+				segIndex, off := uint16(fixup.Target1&0xFF), fixup.Target2
+				if segIndex == 0xFF {
+					// Movable segment; referenced through entry table ordinal.
+					entry, ok := entries[fixup.Target2]
+					if !ok {
+						warn.Printf("unable to locate entry ordinal %d of fixup in segment %d", fixup.Target2, i+1)
+						continue
+					}
+					segIndex, off = uint16(entry.seg), entry.off
+				}
+				targetSeg, targetOff = Seg(int(segIndex)), off
+			case targetImportOrdinal, targetImportName:
+				if int(fixup.Target1) < 1 || int(fixup.Target1) > len(modNames) {
+					warn.Printf("invalid module index %d of fixup in segment %d", fixup.Target1, i+1)
+					continue
+				}
+				modName := modNames[fixup.Target1-1]
+				name := fmt.Sprintf("%s_ordinal_%d", modName, fixup.Target2)
+				if fixup.TargetType&0x03 == targetImportName {
+					procName, err := readName(r, impNamesOff+int64(fixup.Target2))
+					if err != nil {
+						return nil, errors.WithStack(err)
+					}
+					name = procName
+				}
+				targetSeg, targetOff = importAddr(name)
+			default:
+				// Operating system fixups (e.g. floating-point emulation) are left
+				// as is.
+				continue
+			}
+			relocs := applyFixup(datas[i], fixup, targetSeg, targetOff)
+			for _, off := range relocs {
+				file.Relocs = append(file.Relocs, sect.Addr+bin.Address(off))
+			}
+		}
+	}
+	sort.Sort(bin.Addresses(file.Relocs))
+	if len(impAddrs) > 0 {
+		sect := &bin.Section{
+			Name:    "imports",
+			Addr:    bin.SegmentBase(impSeg),
+			MemSize: len(impAddrs),
+			Perm:    bin.PermR,
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+
+	// Parse initial segment registers. The stack segment is the automatic data
+	// segment if not specified.
+	dataSeg := uint16(0)
+	if hdr.AutoDataSeg != 0 {
+		dataSeg = Seg(int(hdr.AutoDataSeg))
+	}
+	stackSeg := dataSeg
+	if hdr.SS != 0 {
+		stackSeg = Seg(int(hdr.SS))
+	}
+	file.RealMode = &bin.RealMode{
+		CS: Seg(int(hdr.CS)),
+		DS: dataSeg,
+		ES: dataSeg,
+		SS: stackSeg,
+		SP: hdr.SP,
+	}
+	if hdr.CS != 0 {
+		file.Entry = bin.LinearAddr(file.RealMode.CS, hdr.IP)
+	}
+	return file, nil
+}
+
+// entry is an entry point of the entry table.
+type entry struct {
+	// Segment index (1-based).
+	seg uint8
+	// Offset within segment.
+	off uint16
+}
+
+// parseEntries parses the entry table at the given file offset, and returns
+// the entry points indexed by ordinal.
+func parseEntries(r io.ReaderAt, offset, size int64) (map[uint16]entry, error) {
+	buf := make([]byte, size)
+	if _, err := r.ReadAt(buf, offset); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	entries := make(map[uint16]entry)
+	ordinal := uint16(1)
+	for len(buf) >= 2 {
+		// Bundle of entries.
+		n, seg := int(buf[0]), buf[1]
+		buf = buf[2:]
+		if n == 0 {
+			break
+		}
+		switch seg {
+		case 0x00:
+			// Unused entries.
+			ordinal += uint16(n)
+		case 0xFF:
+			// Movable segment entries; flags, INT 3Fh, segment and offset.
+			for i := 0; i < n && len(buf) >= 6; i++ {
+				entries[ordinal] = entry{seg: buf[3], off: binary.LittleEndian.Uint16(buf[4:])}
+				ordinal++
+				buf = buf[6:]
+			}
+		default:
+			// Fixed segment entries; flags and offset.
+			for i := 0; i < n && len(buf) >= 3; i++ {
+				entries[ordinal] = entry{seg: seg, off: binary.LittleEndian.Uint16(buf[1:])}
+				ordinal++
+				buf = buf[3:]
+			}
+		}
+	}
+	return entries, nil
+}
+
+// parseNames parses the resident or non-resident name table at the given file
+// offset, and records the names of the table in names, indexed by ordinal. The
+// first entry of the table, holding the module name or description, is
+// skipped.
+func parseNames(r io.ReaderAt, offset int64, names map[uint16]string) error {
+	for first := true; ; first = false {
+		name, err := readName(r, offset)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(name) == 0 {
+			return nil
+		}
+		var buf [2]byte
+		if _, err := r.ReadAt(buf[:], offset+1+int64(len(name))); err != nil {
+			return errors.WithStack(err)
+		}
+		offset += 1 + int64(len(name)) + 2
+		if !first {
+			names[binary.LittleEndian.Uint16(buf[:])] = name
+		}
+	}
+}
+
+// readName reads the length-prefixed string at the given file offset.
+func readName(r io.ReaderAt, offset int64) (string, error) {
+	var n [1]byte
+	if _, err := r.ReadAt(n[:], offset); err != nil {
+		return "", errors.WithStack(err)
+	}
+	buf := make([]byte, n[0])
+	if _, err := r.ReadAt(buf, offset+1); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(buf), nil
+}
+
+// fixup is a relocation record of a segment.
+type fixup struct {
+	// Source type (e.g. srcFarPtr).
+	SrcType uint8
+	// Target type (e.g. targetImportOrdinal), and additive flag.
+	TargetType uint8
+	// Offset within segment of the first location of the fixup chain.
+	Offset uint16
+	// Segment index (internal references) or module index (imports).
+	Target1 uint16
+	// Offset (internal references), ordinal (import by ordinal) or name
+	// offset (import by name).
+	Target2 uint16
+}
+
+// Source types of fixups.
+const (
+	srcLoByte = 0x00
+	srcSeg    = 0x02
+	srcFarPtr = 0x03
+	srcOffset = 0x05
+)
+
+// Target types of fixups.
+const (
+	targetInternal      = 0x00
+	targetImportOrdinal = 0x01
+	targetImportName    = 0x02
+	// targetAdditive specifies that the target is added to the source location,
+	// rather than replacing a chain of source locations.
+	targetAdditive = 0x04
+)
+
+// parseFixups parses the relocation records at the given file offset.
+func parseFixups(r io.ReaderAt, offset int64) ([]fixup, error) {
+	var buf [2]byte
+	if _, err := r.ReadAt(buf[:], offset); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	fixups := make([]fixup, binary.LittleEndian.Uint16(buf[:]))
+	if err := binary.Read(io.NewSectionReader(r, offset+2, int64(8*len(fixups))), binary.LittleEndian, fixups); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return fixups, nil
+}
+
+// applyFixup applies the fixup to the given segment contents, resolving source
+// locations to the target segment and offset. The offsets of segment values
+// written by the fixup are returned.
+func applyFixup(data []byte, fixup fixup, seg, off uint16) []uint16 {
+	var relocs []uint16
+	additive := fixup.TargetType&targetAdditive != 0
+	put := func(pos int, v uint16) {
+		if additive {
+			v += binary.LittleEndian.Uint16(data[pos:])
+		}
+		binary.LittleEndian.PutUint16(data[pos:], v)
+	}
+	// Follow chain of source locations, terminated by 0xFFFF.
+	pos := int(fixup.Offset)
+	visited := make(map[int]bool)
+	for !visited[pos] {
+		visited[pos] = true
+		if pos+1 >= len(data) {
+			break
+		}
+		next := int(binary.LittleEndian.Uint16(data[pos:]))
+		switch fixup.SrcType {
+		case srcLoByte:
+			v := uint8(off)
+			if additive {
+				v += data[pos]
+			}
+			data[pos] = v
+		case srcSeg:
+			put(pos, seg)
+			relocs = append(relocs, uint16(pos))
+		case srcFarPtr:
+			if pos+3 >= len(data) {
+				return relocs
+			}
+			put(pos, off)
+			put(pos+2, seg)
+			relocs = append(relocs, uint16(pos+2))
+		case srcOffset:
+			put(pos, off)
+		default:
+			warn.Printf("support for fixup source type 0x%02X not yet implemented", fixup.SrcType)
+			return relocs
+		}
+		if additive || next == 0xFFFF {
+			break
+		}
+		pos = next
+	}
+	return relocs
+}
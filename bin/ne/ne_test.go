@@ -0,0 +1,71 @@
+package ne_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/ne"
+)
+
+func TestParse(t *testing.T) {
+	// NE executable with a single code segment, exporting MAIN and importing
+	// ordinal 5 of KERNEL.
+	//
+	//    call far KERNEL.5
+	//    retf
+	buf := make([]byte, 0x100)
+	copy(buf, "MZ")
+	binary.LittleEndian.PutUint32(buf[0x3C:], 0x40)
+	hdr := buf[0x40:]
+	copy(hdr, "NE")
+	put16 := func(off int, v uint16) {
+		binary.LittleEndian.PutUint16(hdr[off:], v)
+	}
+	put16(0x04, 0x61) // entry table
+	put16(0x06, 0x06) // entry table size
+	put16(0x14, 0x00) // IP
+	put16(0x16, 0x01) // CS
+	put16(0x1C, 0x01) // number of segments
+	put16(0x1E, 0x01) // number of module references
+	put16(0x22, 0x40) // segment table
+	put16(0x24, 0x48) // resource table
+	put16(0x26, 0x48) // resident names
+	put16(0x28, 0x57) // module reference table
+	put16(0x2A, 0x59) // imported names
+	put16(0x32, 0x08) // alignment shift
+	// Segment table.
+	copy(hdr[0x40:], []byte{0x01, 0x00, 0x06, 0x00, 0x00, 0x01, 0x06, 0x00})
+	// Resident names.
+	copy(hdr[0x48:], "\x04TEST\x00\x00\x04MAIN\x01\x00\x00")
+	// Module reference table and imported names.
+	copy(hdr[0x57:], "\x01\x00\x00\x06KERNEL")
+	// Entry table.
+	copy(hdr[0x61:], []byte{0x01, 0x01, 0x01, 0x00, 0x00, 0x00})
+	// Segment contents, followed by fixups.
+	buf = append(buf, 0x9A, 0xFF, 0xFF, 0x00, 0x00, 0xCB)
+	buf = append(buf, 0x01, 0x00, 0x03, 0x01, 0x01, 0x00, 0x01, 0x00, 0x05, 0x00)
+	file, err := ne.Parse(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("unable to parse NE executable; %v", err)
+	}
+	entry := bin.SegmentBase(ne.Seg(1))
+	if file.Entry != entry {
+		t.Errorf("entry mismatch; expected %v, got %v", entry, file.Entry)
+	}
+	if name := file.Exports[entry]; name != "MAIN" {
+		t.Errorf("export mismatch; expected %q, got %q", "MAIN", name)
+	}
+	imp := bin.SegmentBase(ne.Seg(2))
+	if name := file.Imports[imp]; name != "KERNEL_ordinal_5" {
+		t.Errorf("import mismatch; expected %q, got %q", "KERNEL_ordinal_5", name)
+	}
+	seg, err := file.ReadUint16(entry + 3)
+	if err != nil {
+		t.Fatalf("unable to read fixup; %v", err)
+	}
+	if want := ne.Seg(2); seg != want {
+		t.Errorf("fixup segment mismatch; expected 0x%04X, got 0x%04X", want, seg)
+	}
+}
@@ -0,0 +1,39 @@
+package bin
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// An Overlay is data appended to an executable file, which is not loaded into
+// memory by the loader.
+type Overlay struct {
+	// File offset of the overlay.
+	Offset uint64
+	// Overlay contents.
+	Data []byte
+}
+
+// ReadOverlay reads the overlay of the given executable file, starting at the
+// specified file offset and extending to the end of the file. A nil overlay is
+// returned if no data is present at the offset.
+func ReadOverlay(r io.ReaderAt, offset int64) (*Overlay, error) {
+	var data []byte
+	buf := make([]byte, 64*1024)
+	for off := offset; ; {
+		n, err := r.ReadAt(buf, off)
+		data = append(data, buf[:n]...)
+		off += int64(n)
+		if err != nil {
+			if errors.Cause(err) == io.EOF {
+				break
+			}
+			return nil, errors.WithStack(err)
+		}
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return &Overlay{Offset: uint64(offset), Data: data}, nil
+}
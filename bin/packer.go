@@ -0,0 +1,210 @@
+package bin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Packers of binary executables, as identified by DetectPacker.
+const (
+	// UPX (Ultimate Packer for eXecutables).
+	PackerUPX = "upx"
+	// ASPack.
+	PackerASPack = "aspack"
+	// Themida (and WinLicense) of Oreans.
+	PackerThemida = "themida"
+	// Unknown packer, as identified by the entropy of executable sections.
+	PackerUnknown = "unknown"
+)
+
+// A Packer records the packer of a packed binary executable.
+type Packer struct {
+	// Name of the packer (e.g. PackerUPX).
+	Name string
+	// Entry point of the unpacking stub.
+	Stub Address
+	// Evidence of the packer; e.g. `section name "UPX0"`.
+	Evidence string
+}
+
+// String returns a string representation of the packer; e.g.
+//
+//    upx (section name "UPX0"); unpacking stub at 0x409A80
+func (p *Packer) String() string {
+	return fmt.Sprintf("%s (%s); unpacking stub at %v", p.Name, p.Evidence, p.Stub)
+}
+
+// A packerSig is a signature of the unpacking stub at the entry point of
+// packed executables.
+type packerSig struct {
+	// Name of the packer.
+	name string
+	// Byte pattern of the unpacking stub, as hexadecimal bytes; wildcard bytes
+	// are specified by "??".
+	pattern string
+}
+
+// packerSigs specifies the signatures of unpacking stubs of known packers.
+var packerSigs = []packerSig{
+	// UPX (32-bit PE).
+	//
+	//    pushad
+	//    mov     esi, UPX1
+	//    lea     edi, [esi+UPX0-UPX1]
+	//    push    edi
+	{name: PackerUPX, pattern: "60 BE ?? ?? ?? ?? 8D BE ?? ?? ?? ?? 57"},
+	// UPX (64-bit PE).
+	//
+	//    push    rbx
+	//    push    rsi
+	//    push    rdi
+	//    push    rbp
+	//    lea     rsi, [rel UPX1]
+	//    lea     rdi, [rsi+UPX0-UPX1]
+	{name: PackerUPX, pattern: "53 56 57 55 48 8D 35 ?? ?? ?? ?? 48 8D BE"},
+	// ASPack 2.x.
+	//
+	//    pushad
+	//    call    $+8
+	//    jmp     ...
+	{name: PackerASPack, pattern: "60 E8 03 00 00 00 E9 EB"},
+	// Themida 1.x.
+	//
+	//    mov     eax, 0
+	//    pushad
+	//    or      eax, eax
+	//    jz      ...
+	//    call    $+5
+	//    pop     eax
+	{name: PackerThemida, pattern: "B8 ?? ?? ?? ?? 60 0B C0 74 ?? E8 00 00 00 00 58"},
+}
+
+// packerSects maps from section names of known packers to packer names.
+var packerSects = map[string]string{
+	"UPX0":     PackerUPX,
+	"UPX1":     PackerUPX,
+	".aspack":  PackerASPack,
+	".adata":   PackerASPack,
+	".themida": PackerThemida,
+	".winlice": PackerThemida,
+}
+
+// upxMagic specifies the magic number of the UPX packheader, located in the
+// file headers of UPX-packed executables.
+var upxMagic = []byte("UPX!")
+
+// maxHeaderSize specifies the maximum size in bytes of file headers searched for
+// the UPX packheader magic.
+const maxHeaderSize = 1024
+
+// Minimum entropy in bits per byte of executable sections of packed
+// executables, and minimum size in bytes of such sections; compiled code
+// typically has an entropy below 6.5 bits per byte.
+const (
+	packedEntropy = 7.2
+	minPackedSize = 512
+)
+
+// DetectPacker detects the packer of the binary executable, based on the
+// following heuristics, in order:
+//
+//    1. signature of the unpacking stub at the entry point (UPX, ASPack and Themida)
+//    2. section names (e.g. UPX0 and .aspack)
+//    3. UPX packheader magic ("UPX!")
+//    4. entropy of executable sections
+//
+// A nil packer is returned if the executable does not appear packed. The entry
+// point of the executable is assumed to be the entry point of the unpacking
+// stub.
+func (file *File) DetectPacker() *Packer {
+	// Unpacking stub.
+	for _, sig := range packerSigs {
+		if file.matchEntry(sig.pattern) {
+			evidence := fmt.Sprintf("unpacking stub signature %q", sig.pattern)
+			return &Packer{Name: sig.name, Stub: file.Entry, Evidence: evidence}
+		}
+	}
+	// Section names.
+	for _, sect := range file.Sections {
+		if name, ok := packerSects[sect.Name]; ok {
+			evidence := fmt.Sprintf("section name %q", sect.Name)
+			return &Packer{Name: name, Stub: file.Entry, Evidence: evidence}
+		}
+	}
+	// UPX packheader magic, in the file headers of memory segments mapping the
+	// start of the executable file (e.g. the first PT_LOAD segment of ELF
+	// executables).
+	for _, sect := range file.Sections {
+		if sect.Offset != 0 {
+			continue
+		}
+		hdr := sect.Data
+		if len(hdr) > maxHeaderSize {
+			hdr = hdr[:maxHeaderSize]
+		}
+		if i := bytes.Index(hdr, upxMagic); i != -1 {
+			evidence := fmt.Sprintf("UPX magic at %v", sect.Addr+Address(i))
+			return &Packer{Name: PackerUPX, Stub: file.Entry, Evidence: evidence}
+		}
+	}
+	// Entropy.
+	for _, sect := range file.Sections {
+		if sect.Perm&PermX == 0 || len(sect.Data) < minPackedSize {
+			continue
+		}
+		if e := Entropy(sect.Data); e >= packedEntropy {
+			evidence := fmt.Sprintf("entropy %.2f of executable section %q", e, sect.Name)
+			return &Packer{Name: PackerUnknown, Stub: file.Entry, Evidence: evidence}
+		}
+	}
+	return nil
+}
+
+// matchEntry reports whether the code at the entry point of the binary
+// executable matches the given byte pattern of hexadecimal bytes, where "??"
+// matches any byte.
+func (file *File) matchEntry(pattern string) bool {
+	fields := strings.Fields(pattern)
+	code, err := file.ReadData(file.Entry, len(fields))
+	if err != nil {
+		return false
+	}
+	for i, field := range fields {
+		if field == "??" {
+			continue
+		}
+		b, err := hex.DecodeString(field)
+		if err != nil {
+			panic(fmt.Errorf("invalid byte %q of packer signature %q; %v", field, pattern, err))
+		}
+		if code[i] != b[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// Entropy returns the Shannon entropy of the given data, in bits per byte; in
+// range [0, 8]. Compressed and encrypted data has an entropy close to 8.
+func Entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var freqs [256]int
+	for _, b := range data {
+		freqs[b]++
+	}
+	e := 0.0
+	n := float64(len(data))
+	for _, freq := range freqs {
+		if freq == 0 {
+			continue
+		}
+		p := float64(freq) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
@@ -0,0 +1,83 @@
+package bin_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestEntropy(t *testing.T) {
+	uniform := make([]byte, 256)
+	for i := range uniform {
+		uniform[i] = byte(i)
+	}
+	golden := []struct {
+		data []byte
+		want float64
+	}{
+		{data: nil, want: 0},
+		{data: []byte{0x90, 0x90, 0x90, 0x90}, want: 0},
+		{data: []byte{0x00, 0xFF}, want: 1},
+		{data: uniform, want: 8},
+	}
+	for _, g := range golden {
+		got := bin.Entropy(g.data)
+		if math.Abs(got-g.want) > 1e-9 {
+			t.Errorf("entropy mismatch of % X; expected %v, got %v", g.data, g.want, got)
+		}
+	}
+}
+
+func TestFileDetectPacker(t *testing.T) {
+	// UPX unpacking stub at the entry point.
+	//
+	//    pushad
+	//    mov     esi, 0x409000
+	//    lea     edi, [esi-0x8000]
+	//    push    edi
+	stub := []byte{0x60, 0xBE, 0x00, 0x90, 0x40, 0x00, 0x8D, 0xBE, 0x00, 0x80, 0xFF, 0xFF, 0x57}
+	random := make([]byte, 4096)
+	x := uint32(1)
+	for i := range random {
+		// xorshift32
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		random[i] = byte(x)
+	}
+	golden := []struct {
+		file *bin.File
+		want string
+	}{
+		{
+			file: &bin.File{Entry: 0x409A80, Sections: []*bin.Section{{Name: ".text", Addr: 0x409A80, Offset: 0x400, Data: stub, Perm: bin.PermR | bin.PermX}}},
+			want: bin.PackerUPX,
+		},
+		{
+			file: &bin.File{Entry: 0x401000, Sections: []*bin.Section{{Name: ".aspack", Addr: 0x401000, Offset: 0x400, Data: []byte{0xC3}, Perm: bin.PermR | bin.PermX}}},
+			want: bin.PackerASPack,
+		},
+		{
+			file: &bin.File{Entry: 0x401000, Sections: []*bin.Section{{Name: ".text", Addr: 0x401000, Offset: 0x400, Data: random, Perm: bin.PermR | bin.PermX}}},
+			want: bin.PackerUnknown,
+		},
+		// Not packed.
+		{
+			file: &bin.File{Entry: 0x401000, Sections: []*bin.Section{{Name: ".text", Addr: 0x401000, Offset: 0x400, Data: make([]byte, 4096), Perm: bin.PermR | bin.PermX}}},
+			want: "",
+		},
+	}
+	for _, g := range golden {
+		got := ""
+		if p := g.file.DetectPacker(); p != nil {
+			got = p.Name
+			if p.Stub != g.file.Entry {
+				t.Errorf("unpacking stub mismatch of packer %q; expected %v, got %v", p.Name, g.file.Entry, p.Stub)
+			}
+		}
+		if got != g.want {
+			t.Errorf("packer mismatch of section %q; expected %q, got %q", g.file.Sections[0].Name, g.want, got)
+		}
+	}
+}
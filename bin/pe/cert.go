@@ -0,0 +1,168 @@
+package pe
+
+import (
+	"crypto/x509"
+	"debug/pe"
+	"encoding/asn1"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// A CertTable is the attribute certificate table of a PE file, as referenced by
+// the security data directory; e.g. holding the Authenticode signature of the
+// executable. Contrary to other data directories, the certificate table is
+// referenced by file offset, and is not loaded into memory.
+type CertTable struct {
+	// File offset of the certificate table.
+	Offset int64
+	// Size in bytes of the certificate table.
+	Size int64
+	// Certificate table located at the end of file; i.e. following the
+	// overlay, if any.
+	AtEOF bool
+	// Certificates of the table.
+	Certs []*Certificate
+}
+
+// A Certificate is an entry of the attribute certificate table.
+//
+//    struct WIN_CERTIFICATE {
+//       uint32 dwLength; // including header
+//       uint16 wRevision;
+//       uint16 wCertificateType;
+//       uint8  bCertificate[];
+//    };
+type Certificate struct {
+	// Certificate revision (e.g. 0x0200).
+	Revision uint16
+	// Certificate type (e.g. CertTypePKCSSignedData).
+	Type uint16
+	// Certificate contents; e.g. a PKCS #7 SignedData structure.
+	Data []byte
+}
+
+// Certificate types.
+const (
+	// CertTypeX509 specifies an X.509 certificate.
+	CertTypeX509 = 0x0001
+	// CertTypePKCSSignedData specifies a PKCS #7 SignedData structure (i.e.
+	// Authenticode signature).
+	CertTypePKCSSignedData = 0x0002
+)
+
+// securityDirIndex specifies the data directory index of the certificate table.
+const securityDirIndex = 4
+
+// ParseCertTable parses the attribute certificate table of the given PE file. A
+// nil certificate table is returned if not present.
+func ParseCertTable(r io.ReaderAt) (*CertTable, error) {
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	offset, size := certTableDir(f)
+	if size == 0 {
+		return nil, nil
+	}
+	t := &CertTable{
+		Offset: int64(offset),
+		Size:   int64(size),
+	}
+	var b [1]byte
+	if _, err := r.ReadAt(b[:], t.Offset+t.Size); errors.Cause(err) == io.EOF {
+		t.AtEOF = true
+	}
+	// Certificate entries are aligned to 8 bytes.
+	for pos := t.Offset; pos+8 <= t.Offset+t.Size; {
+		var hdr [8]byte
+		if _, err := r.ReadAt(hdr[:], pos); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		n := int64(binary.LittleEndian.Uint32(hdr[:]))
+		if n < 8 || pos+n > t.Offset+t.Size {
+			return nil, errors.Errorf("invalid certificate length %d at file offset 0x%X", n, pos)
+		}
+		cert := &Certificate{
+			Revision: binary.LittleEndian.Uint16(hdr[4:]),
+			Type:     binary.LittleEndian.Uint16(hdr[6:]),
+			Data:     make([]byte, n-8),
+		}
+		if _, err := r.ReadAt(cert.Data, pos+8); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		t.Certs = append(t.Certs, cert)
+		pos += (n + 7) &^ 7
+	}
+	return t, nil
+}
+
+// certTableDir returns the file offset and size of the certificate table of the
+// PE file, as specified by the security data directory.
+func certTableDir(f *pe.File) (offset, size uint32) {
+	switch opt := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if opt.NumberOfRvaAndSizes > securityDirIndex {
+			dir := opt.DataDirectory[securityDirIndex]
+			return dir.VirtualAddress, dir.Size
+		}
+	case *pe.OptionalHeader64:
+		if opt.NumberOfRvaAndSizes > securityDirIndex {
+			dir := opt.DataDirectory[securityDirIndex]
+			return dir.VirtualAddress, dir.Size
+		}
+	}
+	return 0, 0
+}
+
+// X509Certs returns the X.509 certificates embedded in the PKCS #7 SignedData
+// structure of the Authenticode signature; i.e. the signing certificate and
+// its certificate chain.
+func (cert *Certificate) X509Certs() ([]*x509.Certificate, error) {
+	switch cert.Type {
+	case CertTypeX509:
+		c, err := x509.ParseCertificate(cert.Data)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return []*x509.Certificate{c}, nil
+	case CertTypePKCSSignedData:
+		// ref: RFC 2315, section 7 and 9.1
+		//
+		//    ContentInfo ::= SEQUENCE {
+		//       contentType ContentType,
+		//       content [0] EXPLICIT ANY DEFINED BY contentType OPTIONAL }
+		//
+		//    SignedData ::= SEQUENCE {
+		//       version Version,
+		//       digestAlgorithms DigestAlgorithmIdentifiers,
+		//       contentInfo ContentInfo,
+		//       certificates [0] IMPLICIT ExtendedCertificatesAndCertificates OPTIONAL,
+		//       crls [1] IMPLICIT CertificateRevocationLists OPTIONAL,
+		//       signerInfos SignerInfos }
+		var contentInfo struct {
+			ContentType asn1.ObjectIdentifier
+			Content     asn1.RawValue `asn1:"explicit,tag:0"`
+		}
+		if _, err := asn1.Unmarshal(cert.Data, &contentInfo); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var signedData struct {
+			Version          int
+			DigestAlgorithms asn1.RawValue
+			ContentInfo      asn1.RawValue
+			Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+		}
+		if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &signedData); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		certs, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return certs, nil
+	default:
+		return nil, errors.Errorf("support for certificate type 0x%04X not yet implemented", cert.Type)
+	}
+}
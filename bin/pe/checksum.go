@@ -0,0 +1,54 @@
+package pe
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// ChecksumOffset returns the file offset of the CheckSum field of the optional
+// header of the given PE file. The offset is the same for PE32 and PE32+ files.
+func ChecksumOffset(buf []byte) (int, error) {
+	if len(buf) < 0x40 {
+		return 0, errors.Errorf("invalid PE file; size %d smaller than DOS header", len(buf))
+	}
+	// Offset of PE header.
+	lfanew := int(binary.LittleEndian.Uint32(buf[0x3C:]))
+	if lfanew+4 > len(buf) || string(buf[lfanew:lfanew+4]) != "PE\x00\x00" {
+		return 0, errors.Errorf("invalid PE file; unable to locate PE signature at offset 0x%X", lfanew)
+	}
+	// The optional header follows the PE signature and the file header (20
+	// bytes), and the CheckSum field is located at offset 64 of the optional
+	// header.
+	off := lfanew + 4 + 20 + 64
+	if off+4 > len(buf) {
+		return 0, errors.Errorf("invalid PE file; CheckSum field at offset 0x%X out of bounds", off)
+	}
+	return off, nil
+}
+
+// Checksum returns the checksum of the given PE file, as computed by the
+// CheckSumMappedFile function of imagehlp.dll; i.e. the 16-bit one's
+// complement sum of the file contents, excluding the CheckSum field of the
+// optional header, plus the file size.
+func Checksum(buf []byte) (uint32, error) {
+	off, err := ChecksumOffset(buf)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	var sum uint32
+	for i := 0; i < len(buf); i += 2 {
+		if i == off || i == off+2 {
+			// Skip CheckSum field.
+			continue
+		}
+		w := uint32(buf[i])
+		if i+1 < len(buf) {
+			w |= uint32(buf[i+1]) << 8
+		}
+		sum += w
+		sum = sum&0xFFFF + sum>>16
+	}
+	sum = sum&0xFFFF + sum>>16
+	return sum + uint32(len(buf)), nil
+}
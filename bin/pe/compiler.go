@@ -0,0 +1,87 @@
+package pe
+
+import (
+	"bytes"
+	"debug/pe"
+	"io"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+)
+
+// borlandHook specifies the startup code at the entry point of Borland C++
+// executables; a jump over the "fb:C++HOOK" marker.
+//
+//    jmp     short $+0x12    ; EB 10
+//    db      "fb:C++HOOK"
+var borlandHook = []byte("\xEB\x10fb:C++HOOK")
+
+// identifyCompiler identifies the compiler of the PE file, and records it in
+// file.Compiler unless already identified (e.g. Delphi by its PACKAGEINFO
+// resource). The compiler is identified based on the following heuristics, in
+// order:
+//
+//    1. startup code at the entry point (Borland C++)
+//    2. section names (Borland, Watcom and MinGW)
+//    3. Rich header of the DOS stub (MSVC)
+//    4. linker version of the optional header
+func identifyCompiler(r io.ReaderAt, f *pe.File, file *bin.File) {
+	if len(file.Compiler) > 0 {
+		return
+	}
+	compiler := func() string {
+		// Startup code.
+		if code, err := file.ReadData(file.Entry, len(borlandHook)); err == nil && bytes.Equal(code, borlandHook) {
+			return bin.CompilerBorland
+		}
+		// Section names.
+		sects := make(map[string]bool)
+		for _, s := range f.Sections {
+			sects[s.Name] = true
+			if strings.HasPrefix(s.Name, "/") {
+				// Long section names of the COFF string table (e.g. "/4" of
+				// .debug_info), as emitted by GNU ld.
+				return bin.CompilerMinGW
+			}
+		}
+		switch {
+		case sects["CODE"] && sects["DATA"]:
+			return bin.CompilerBorland
+		case sects["AUTO"] || sects["DGROUP"] || sects["BEGTEXT"]:
+			return bin.CompilerWatcom
+		case sects[".CRT"]:
+			return bin.CompilerMinGW
+		}
+		// Rich header.
+		if rich, _ := ParseRichHeader(r); rich != nil {
+			return bin.CompilerMSVC
+		}
+		// Linker version.
+		var major, minor uint8
+		switch opt := f.OptionalHeader.(type) {
+		case *pe.OptionalHeader32:
+			major, minor = opt.MajorLinkerVersion, opt.MinorLinkerVersion
+		case *pe.OptionalHeader64:
+			major, minor = opt.MajorLinkerVersion, opt.MinorLinkerVersion
+		}
+		switch {
+		case major == 2 && minor == 18:
+			// Open Watcom linker (wlink).
+			return bin.CompilerWatcom
+		case major == 2 && minor == 25:
+			// Borland linker (ilink32); also used by Delphi.
+			return bin.CompilerBorland
+		case major == 2 && minor > 25:
+			// GNU ld of binutils 2.26 and later.
+			return bin.CompilerMinGW
+		case major >= 5:
+			// Microsoft linker.
+			return bin.CompilerMSVC
+		}
+		return ""
+	}()
+	if len(compiler) > 0 {
+		trace.Printf("compiler: %s", compiler)
+		file.Compiler = compiler
+	}
+}
@@ -0,0 +1,90 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// A delayImportDesc is a delay-load import descriptor.
+//
+// ref: https://docs.microsoft.com/en-us/windows/win32/debug/pe-format#delay-load-import-tables-image-only
+type delayImportDesc struct {
+	// Attributes; bit 0 is set if addresses are RVAs, and clear if addresses
+	// are VAs (Visual C++ 6.0).
+	Attrs uint32
+	// DLL name address.
+	DLLName uint32
+	// Module handle address.
+	ModuleHandle uint32
+	// Delay-load import address table address.
+	ImportAddressTable uint32
+	// Delay-load import name table address.
+	ImportNameTable uint32
+	// Bound delay-load import address table address.
+	BoundImportAddressTable uint32
+	// Unload delay-load import address table address.
+	UnloadImportAddressTable uint32
+	// Time stamp.
+	Date uint32
+}
+
+// parseDelayImports parses the delay-load import table of the given size,
+// located at the specified relative virtual address.
+//
+// Entries of the delay-load import address table initially point to thunks
+// which load the DLL and resolve the import on first use. As calls are made
+// indirectly through the import address table (e.g. `call [__imp_Sleep]`),
+// entries are recorded as imports of the file.
+func parseDelayImports(file *bin.File, imageBase, delayRVA, delaySize uint64) error {
+	const descSize = 32
+	ptrSize := bin.Address(file.Arch.BitSize() / 8)
+	ordinalFlag := uint64(1) << uint(file.Arch.BitSize()-1)
+	for off := uint64(0); off+descSize <= delaySize; off += descSize {
+		descAddr := bin.Address(imageBase + delayRVA + off)
+		data, err := file.ReadData(descAddr, descSize)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		var desc delayImportDesc
+		if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &desc); err != nil {
+			return errors.WithStack(err)
+		}
+		if desc.DLLName == 0 {
+			break
+		}
+		trace.Dump("delayImportDesc:", desc)
+		// addr returns the virtual address of the given delay-load address.
+		addr := func(v uint64) bin.Address {
+			if desc.Attrs&1 != 0 {
+				return bin.Address(imageBase + v)
+			}
+			return bin.Address(v)
+		}
+		dllName := parseString(file.Data(addr(uint64(desc.DLLName))))
+		trace.Println("delay-load dll name:", dllName)
+		inAddr := addr(uint64(desc.ImportNameTable))
+		iaAddr := addr(uint64(desc.ImportAddressTable))
+		for ; ; inAddr, iaAddr = inAddr+ptrSize, iaAddr+ptrSize {
+			v, err := file.ReadUintptr(inAddr)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if v == 0 {
+				break
+			}
+			if v&ordinalFlag != 0 {
+				ordinal := v &^ ordinalFlag
+				file.Imports[iaAddr] = bin.OrdinalName(dllName, ordinal)
+				continue
+			}
+			// Skip hint of hint/name entry.
+			impName := parseString(file.Data(addr(v) + 2))
+			trace.Println("delay-load impName:", impName)
+			file.Imports[iaAddr] = impName
+		}
+	}
+	return nil
+}
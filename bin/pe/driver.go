@@ -0,0 +1,68 @@
+package pe
+
+import (
+	"debug/pe"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+)
+
+// kernelLibs specifies the libraries imported by kernel-mode drivers.
+var kernelLibs = map[string]bool{
+	"ntoskrnl.exe": true,
+	"ntkrnlpa.exe": true,
+	"hal.dll":      true,
+	"ndis.sys":     true,
+	"wdfldr.sys":   true,
+	"fltmgr.sys":   true,
+	"videoprt.sys": true,
+	"scsiport.sys": true,
+	"storport.sys": true,
+	"ks.sys":       true,
+	"portcls.sys":  true,
+	"wdmsec.sys":   true,
+	"tdi.sys":      true,
+	"classpnp.sys": true,
+	"usbd.sys":     true,
+}
+
+// entryName returns the name of the native entry point of executables of the
+// given subsystem; i.e. DriverEntry of kernel-mode drivers, NtProcessStartup of
+// native user-mode applications, and efi_main of EFI images. An empty string is
+// returned for other subsystems, the entry point of which is handled by the C
+// runtime (or DllMain of DLLs).
+//
+// Kernel-mode drivers are identified by the WDM driver flag of the DLL
+// characteristics, or by libs (the imported libraries) including libraries of
+// the kernel.
+func entryName(subsystem, dllChars uint16, isDLL bool, libs []string) string {
+	switch subsystem {
+	case pe.IMAGE_SUBSYSTEM_NATIVE:
+		if dllChars&pe.IMAGE_DLLCHARACTERISTICS_WDM_DRIVER != 0 {
+			return "DriverEntry"
+		}
+		for _, lib := range libs {
+			if kernelLibs[strings.ToLower(lib)] {
+				return "DriverEntry"
+			}
+		}
+		if !isDLL {
+			return "NtProcessStartup"
+		}
+	case pe.IMAGE_SUBSYSTEM_EFI_APPLICATION, pe.IMAGE_SUBSYSTEM_EFI_BOOT_SERVICE_DRIVER, pe.IMAGE_SUBSYSTEM_EFI_RUNTIME_DRIVER, pe.IMAGE_SUBSYSTEM_EFI_ROM:
+		return "efi_main"
+	}
+	return ""
+}
+
+// addEntryRoot adds the native entry point of the given subsystem (e.g.
+// DriverEntry of kernel-mode drivers) as an additional entry point of the file.
+func addEntryRoot(file *bin.File, subsystem, dllChars uint16, isDLL bool, libs []string) {
+	if file.Entry == file.ImageBase {
+		// No entry point (e.g. resource-only DLLs).
+		return
+	}
+	if name := entryName(subsystem, dllChars, isDLL, libs); len(name) > 0 {
+		addRoot(file, file.Entry, name)
+	}
+}
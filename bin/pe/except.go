@@ -0,0 +1,261 @@
+package pe
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Unwind information flags.
+//
+// ref: https://docs.microsoft.com/en-us/cpp/build/exception-handling-x64
+const (
+	// The function has an exception handler.
+	unwFlagEHandler = 0x1
+	// The function has a termination handler.
+	unwFlagUHandler = 0x2
+	// The unwind information is chained to the unwind information of a
+	// previous RUNTIME_FUNCTION entry.
+	unwFlagChainInfo = 0x4
+)
+
+// parseExceptions parses the exception handling metadata of the PE file; the
+// exception table (.pdata) of x64 executables and the SafeSEH handler table of
+// the load configuration of x86 executables.
+//
+// Function bounds are recorded in file.FuncBounds, function chunks in
+// file.FuncChunks and exception handlers and filters in file.Handlers.
+func parseExceptions(file *bin.File, imageBase, exceptRVA, exceptSize, loadConfigRVA, loadConfigSize uint64) error {
+	switch file.Arch {
+	case bin.ArchX86_64:
+		if exceptSize == 0 {
+			return nil
+		}
+		return parsePdata(file, imageBase, exceptRVA, exceptSize)
+	case bin.ArchX86_32:
+		if loadConfigSize == 0 {
+			return nil
+		}
+		return parseSafeSEH(file, imageBase, loadConfigRVA, loadConfigSize)
+	}
+	return nil
+}
+
+// parsePdata parses the exception table of x64 executables; an array of
+// RUNTIME_FUNCTION entries.
+//
+//    struct RUNTIME_FUNCTION {
+//       uint32 BeginAddress;
+//       uint32 EndAddress;
+//       uint32 UnwindInfoAddress;
+//    };
+//
+//    struct UNWIND_INFO {
+//       uint8  VersionAndFlags;    // version (3 bits), flags (5 bits)
+//       uint8  SizeOfProlog;
+//       uint8  CountOfUnwindCodes;
+//       uint8  FrameRegAndOffset;
+//       uint16 UnwindCodes[];      // aligned to an even number of entries
+//       // optional; if UNW_FLAG_EHANDLER or UNW_FLAG_UHANDLER
+//       uint32 ExceptionHandler;
+//       uint8  LanguageSpecificData[];
+//       // optional; if UNW_FLAG_CHAININFO
+//       RUNTIME_FUNCTION ChainedFunctionEntry;
+//    };
+func parsePdata(file *bin.File, imageBase, exceptRVA, exceptSize uint64) error {
+	base := bin.Address(imageBase)
+	pdata := base + bin.Address(exceptRVA)
+	file.FuncBounds = make(map[bin.Address]bin.Address)
+	file.FuncChunks = make(map[bin.Address]bin.Address)
+	file.Handlers = make(map[bin.Address]bool)
+	const entrySize = 12
+	for i := uint64(0); i+entrySize <= exceptSize; i += entrySize {
+		entry := pdata + bin.Address(i)
+		begin, err := file.ReadUint32(entry)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		end, err := file.ReadUint32(entry + 4)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		unwind, err := file.ReadUint32(entry + 8)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if begin == 0 {
+			break
+		}
+		start := base + bin.Address(begin)
+		file.FuncBounds[start] = base + bin.Address(end)
+		// Bit 0 of the unwind information address is set for entries sharing the
+		// unwind information of another entry (e.g. ARM64 packed unwind data);
+		// not applicable to x64.
+		if unwind&1 != 0 {
+			continue
+		}
+		if err := parseUnwindInfo(file, base, start, base+bin.Address(unwind)); err != nil {
+			trace.Printf("unable to parse unwind information of function at %v; %v", start, err)
+		}
+	}
+	// Chunks of functions are not functions of their own.
+	for chunk := range file.FuncChunks {
+		delete(file.FuncBounds, chunk)
+	}
+	return nil
+}
+
+// parseUnwindInfo parses the unwind information at the given address, of the
+// function starting at the specified address.
+func parseUnwindInfo(file *bin.File, base, start, addr bin.Address) error {
+	versionAndFlags, err := file.ReadUint8(addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ncodes, err := file.ReadUint8(addr + 2)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	flags := versionAndFlags >> 3
+	// Unwind codes are aligned to an even number of entries.
+	p := addr + 4 + 2*bin.Address((uint(ncodes)+1)&^1)
+	switch {
+	case flags&unwFlagChainInfo != 0:
+		// The function start is a chunk of the function of the chained
+		// RUNTIME_FUNCTION entry.
+		parentBegin, err := file.ReadUint32(p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		parent := base + bin.Address(parentBegin)
+		if parent != start {
+			file.FuncChunks[start] = parent
+		}
+	case flags&(unwFlagEHandler|unwFlagUHandler) != 0:
+		rva, err := file.ReadUint32(p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		handler := base + bin.Address(rva)
+		file.Handlers[handler] = true
+		if isCSpecificHandler(file, handler) {
+			return parseScopeTable(file, base, start, p+4)
+		}
+	}
+	return nil
+}
+
+// isCSpecificHandler reports whether the exception handler at the given address
+// is an import thunk of __C_specific_handler (e.g. `jmp [rip+disp]`), the
+// language specific handler of __try/__except and __try/__finally blocks.
+func isCSpecificHandler(file *bin.File, handler bin.Address) bool {
+	data, err := file.ReadData(handler, 6)
+	if err != nil {
+		return false
+	}
+	if data[0] != 0xFF || data[1] != 0x25 {
+		return false
+	}
+	disp, err := file.ReadUint32(handler + 2)
+	if err != nil {
+		return false
+	}
+	target := handler + 6 + bin.Address(int32(disp))
+	return file.Imports[target] == "__C_specific_handler"
+}
+
+// parseScopeTable parses the scope table of __C_specific_handler, located at
+// the given address, of the function starting at the specified address.
+//
+//    struct SCOPE_TABLE {
+//       uint32 Count;
+//       struct {
+//          uint32 BeginAddress;
+//          uint32 EndAddress;
+//          uint32 HandlerAddress; // filter function or EXCEPTION_EXECUTE_HANDLER (1)
+//          uint32 JumpTarget;     // __except block; or 0 for __finally
+//       } ScopeRecord[];
+//    };
+func parseScopeTable(file *bin.File, base, start, addr bin.Address) error {
+	count, err := file.ReadUint32(addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	const maxScopes = 1024
+	if count > maxScopes {
+		return errors.Errorf("invalid scope table count %d at %v", count, addr)
+	}
+	for i := uint32(0); i < count; i++ {
+		rec := addr + 4 + bin.Address(16*i)
+		handler, err := file.ReadUint32(rec + 8)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		target, err := file.ReadUint32(rec + 12)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		switch {
+		case target != 0:
+			// __except block, entered from the exception dispatcher.
+			file.FuncChunks[base+bin.Address(target)] = start
+			if handler > 1 {
+				// Exception filter function.
+				file.Handlers[base+bin.Address(handler)] = true
+			}
+		case handler != 0:
+			// __finally function.
+			file.Handlers[base+bin.Address(handler)] = true
+		}
+	}
+	return nil
+}
+
+// parseSafeSEH parses the SafeSEH handler table of the load configuration of
+// x86 executables.
+//
+//    struct IMAGE_LOAD_CONFIG_DIRECTORY32 {
+//       ...
+//       uint32 SEHandlerTable; // offset 0x40; VA of array of handler RVAs
+//       uint32 SEHandlerCount; // offset 0x44
+//       ...
+//    };
+func parseSafeSEH(file *bin.File, imageBase, loadConfigRVA, loadConfigSize uint64) error {
+	const (
+		seHandlerTableOffset = 0x40
+		seHandlerCountOffset = 0x44
+	)
+	if loadConfigSize < seHandlerCountOffset+4 {
+		return nil
+	}
+	base := bin.Address(imageBase)
+	loadConfig := base + bin.Address(loadConfigRVA)
+	// The Size field of the load configuration may differ from the size of
+	// the data directory entry.
+	size, err := file.ReadUint32(loadConfig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if size < seHandlerCountOffset+4 {
+		return nil
+	}
+	table, err := file.ReadUint32(loadConfig + seHandlerTableOffset)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	count, err := file.ReadUint32(loadConfig + seHandlerCountOffset)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if table == 0 || count == 0 {
+		return nil
+	}
+	file.Handlers = make(map[bin.Address]bool)
+	for i := uint32(0); i < count; i++ {
+		rva, err := file.ReadUint32(bin.Address(table) + bin.Address(4*i))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		file.Handlers[base+bin.Address(rva)] = true
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package pe
+
+import (
+	"debug/pe"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// maxExports specifies the maximum number of functions and names of an export
+// table.
+const maxExports = 0x10000
+
+// maxExportNameLen specifies the maximum length in bytes of export names.
+const maxExportNameLen = 1024
+
+// parseExports parses the export table of the given size, located at the
+// specified relative virtual address, and records the exported functions in
+// file.Exports. Exports forwarded to other DLLs (e.g. "NTDLL.RtlAllocateHeap")
+// and exported data are ignored; functions exported by ordinal only are named
+// after the DLL and ordinal (e.g. "ws2_32_ord_23").
+//
+//    struct IMAGE_EXPORT_DIRECTORY {
+//       uint32 Characteristics;
+//       uint32 TimeDateStamp;
+//       uint16 MajorVersion;
+//       uint16 MinorVersion;
+//       uint32 Name;                  // RVA of DLL name
+//       uint32 Base;                  // ordinal base
+//       uint32 NumberOfFunctions;
+//       uint32 NumberOfNames;
+//       uint32 AddressOfFunctions;    // RVA of EAT
+//       uint32 AddressOfNames;        // RVA of name pointer table
+//       uint32 AddressOfNameOrdinals; // RVA of ordinal table
+//    };
+func parseExports(file *bin.File, imageBase, expRVA, expSize uint64) error {
+	dirAddr := bin.Address(imageBase + expRVA)
+	dirEnd := dirAddr + bin.Address(expSize)
+	var fields [7]uint32
+	for i := range fields {
+		v, err := file.ReadUint32(dirAddr + 12 + bin.Address(4*i))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fields[i] = v
+	}
+	nameRVA, base, nfuncs, nnames := fields[0], fields[1], fields[2], fields[3]
+	eatRVA, namesRVA, ordsRVA := fields[4], fields[5], fields[6]
+	if nfuncs > maxExports || nnames > maxExports {
+		return errors.Errorf("invalid export directory at %v; %d functions and %d names", dirAddr, nfuncs, nnames)
+	}
+	dllName, err := readString(file, bin.Address(imageBase)+bin.Address(nameRVA))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	trace.Println("export dll name:", dllName)
+	// Names of exports, indexed by export address table index.
+	names := make(map[uint32]string)
+	for i := uint32(0); i < nnames; i++ {
+		ord, err := file.ReadUint16(bin.Address(imageBase) + bin.Address(ordsRVA) + bin.Address(2*i))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		rva, err := file.ReadUint32(bin.Address(imageBase) + bin.Address(namesRVA) + bin.Address(4*i))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		name, err := readString(file, bin.Address(imageBase)+bin.Address(rva))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		names[uint32(ord)] = name
+	}
+	for i := uint32(0); i < nfuncs; i++ {
+		rva, err := file.ReadUint32(bin.Address(imageBase) + bin.Address(eatRVA) + bin.Address(4*i))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		addr := bin.Address(imageBase) + bin.Address(rva)
+		if rva == 0 || (dirAddr <= addr && addr < dirEnd) || !file.IsCode(addr) {
+			// Unused ordinal, forwarder or exported data.
+			continue
+		}
+		name, ok := names[i]
+		if !ok {
+			name = bin.OrdinalName(dllName, uint64(base+i))
+		}
+		trace.Printf("export %q at %v", name, addr)
+		file.Exports[addr] = name
+	}
+	return nil
+}
+
+// parseSymbols records the functions and data defined by the COFF symbol table
+// of the PE file in file.Symbols (e.g. present in executables produced by
+// MinGW). Symbols are left nil for stripped executables.
+func parseSymbols(file *bin.File, f *pe.File, imageBase uint64) {
+	// Symbol storage classes.
+	const (
+		// symClassExternal specifies an external symbol.
+		symClassExternal = 2
+		// symClassStatic specifies a static symbol (e.g. static function).
+		symClassStatic = 3
+	)
+	// Symbol type of functions.
+	const symTypeFunc = 0x20
+	for _, sym := range f.Symbols {
+		if sym.SectionNumber <= 0 || int(sym.SectionNumber) > len(f.Sections) || len(sym.Name) == 0 {
+			// Undefined, absolute and debugging symbols.
+			continue
+		}
+		switch {
+		case sym.StorageClass == symClassExternal:
+		case sym.StorageClass == symClassStatic && sym.Type == symTypeFunc:
+		default:
+			// Section symbols, labels and file names.
+			continue
+		}
+		sect := f.Sections[sym.SectionNumber-1]
+		addr := bin.Address(imageBase) + bin.Address(sect.VirtualAddress) + bin.Address(sym.Value)
+		if file.Symbols == nil {
+			file.Symbols = make(map[bin.Address]string)
+		}
+		if _, ok := file.Symbols[addr]; ok {
+			// Keep the first of aliased symbols.
+			continue
+		}
+		file.Symbols[addr] = sym.Name
+	}
+}
+
+// readString reads the NULL-terminated string at the specified address.
+func readString(file *bin.File, addr bin.Address) (string, error) {
+	var buf []byte
+	for i := 0; i < maxExportNameLen; i++ {
+		b, err := file.ReadUint8(addr + bin.Address(i))
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if b == 0 {
+			return string(buf), nil
+		}
+		buf = append(buf, b)
+	}
+	return "", errors.Errorf("unable to locate NULL-terminated string at address %v", addr)
+}
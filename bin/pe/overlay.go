@@ -0,0 +1,52 @@
+package pe
+
+import (
+	"debug/pe"
+	"io"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// parseOverlay parses the overlay of the PE file; i.e. the data following the
+// headers, the raw data of the last section and the COFF symbol table (e.g. of
+// MinGW executables), up to the end of the file. The certificate table (e.g.
+// Authenticode signature) is excluded from the overlay, if located at the end
+// of the file.
+func parseOverlay(r io.ReaderAt, f *pe.File, hdrSize uint32) (*bin.Overlay, error) {
+	end := int64(hdrSize)
+	for _, s := range f.Sections {
+		if s.Size == 0 {
+			continue
+		}
+		if sectEnd := int64(s.Offset) + int64(s.Size); sectEnd > end {
+			end = sectEnd
+		}
+	}
+	if off := f.FileHeader.PointerToSymbolTable; off != 0 {
+		// The symbol table is followed by the string table, prefixed by its
+		// 32-bit size.
+		const symSize = 18
+		symEnd := int64(off) + symSize*int64(f.FileHeader.NumberOfSymbols) + 4 + int64(len(f.StringTable))
+		if symEnd > end {
+			end = symEnd
+		}
+	}
+	overlay, err := bin.ReadOverlay(r, end)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if certOff, certSize := certTableDir(f); overlay != nil && certSize != 0 && int64(certOff) >= end {
+		certStart := int64(certOff) - end
+		if certStart+int64(certSize) == int64(len(overlay.Data)) {
+			overlay.Data = overlay.Data[:certStart]
+			if len(overlay.Data) == 0 {
+				overlay = nil
+			}
+		}
+	}
+	if overlay != nil {
+		trace.Printf("overlay of %d bytes at file offset 0x%X", len(overlay.Data), overlay.Offset)
+	}
+	return overlay, nil
+}
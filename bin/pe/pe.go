@@ -12,11 +12,21 @@ import (
 	"sort"
 
 	"github.com/decomp/exp/bin"
-	"github.com/kr/pretty"
-	"github.com/mewkiz/pkg/pathutil"
+	"github.com/decomp/exp/logging"
+	"github.com/mewkiz/pkg/term"
 	"github.com/pkg/errors"
 )
 
+// Loggers.
+var (
+	// trace represents a logger with the "pe:" prefix, which logs detailed
+	// tracing messages to standard error.
+	trace = logging.New("pe", logging.LevelTrace, term.BlueBold("pe:")+" ")
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = logging.New("pe", logging.LevelInfo, term.RedBold("warning:")+" ")
+)
+
 // Register PE format.
 func init() {
 	// Portable Executable (PE) format.
@@ -49,6 +59,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 	// Parse machine architecture.
 	file := &bin.File{
 		Imports: make(map[bin.Address]string),
+		Exports: make(map[bin.Address]string),
 	}
 	switch f.FileHeader.Machine {
 	case pe.IMAGE_FILE_MACHINE_I386:
@@ -65,36 +76,109 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 	var (
 		// Image base address.
 		imageBase uint64
+		// Size of headers.
+		hdrSize uint32
+		// Subsystem and DLL characteristics.
+		subsystem uint16
+		dllChars  uint16
+		// Export table RVA and size.
+		expRVA  uint64
+		expSize uint64
 		// Import table RVA and size.
 		itRVA  uint64
 		itSize uint64
 		// Import address table (IAT) RVA and size.
 		iatRVA  uint64
 		iatSize uint64
+		// Exception table RVA and size.
+		exceptRVA  uint64
+		exceptSize uint64
+		// Load configuration table RVA and size.
+		loadConfigRVA  uint64
+		loadConfigSize uint64
+		// TLS table RVA and size.
+		tlsRVA  uint64
+		tlsSize uint64
+		// Delay-load import table RVA and size.
+		delayRVA  uint64
+		delaySize uint64
+		// Base relocation table RVA and size.
+		relocRVA  uint64
+		relocSize uint64
+		// Resource table RVA and size.
+		rsrcRVA  uint64
+		rsrcSize uint64
 	)
 	// Data directory indices.
 	const (
+		ExportTableIndex        = 0
 		ImportTableIndex        = 1
+		ResourceTableIndex      = 2
+		ExceptionTableIndex     = 3
+		BaseRelocTableIndex     = 5
+		TLSTableIndex           = 9
+		LoadConfigTableIndex    = 10
 		ImportAddressTableIndex = 12
+		DelayImportTableIndex   = 13
 	)
 	switch opt := f.OptionalHeader.(type) {
 	case *pe.OptionalHeader32:
 		file.Entry = bin.Address(opt.ImageBase + opt.AddressOfEntryPoint)
 		imageBase = uint64(opt.ImageBase)
+		hdrSize = opt.SizeOfHeaders
+		subsystem = opt.Subsystem
+		dllChars = opt.DllCharacteristics
+		expRVA = uint64(opt.DataDirectory[ExportTableIndex].VirtualAddress)
+		expSize = uint64(opt.DataDirectory[ExportTableIndex].Size)
 		itRVA = uint64(opt.DataDirectory[ImportTableIndex].VirtualAddress)
 		itSize = uint64(opt.DataDirectory[ImportTableIndex].Size)
 		iatRVA = uint64(opt.DataDirectory[ImportAddressTableIndex].VirtualAddress)
 		iatSize = uint64(opt.DataDirectory[ImportAddressTableIndex].Size)
+		exceptRVA = uint64(opt.DataDirectory[ExceptionTableIndex].VirtualAddress)
+		exceptSize = uint64(opt.DataDirectory[ExceptionTableIndex].Size)
+		loadConfigRVA = uint64(opt.DataDirectory[LoadConfigTableIndex].VirtualAddress)
+		loadConfigSize = uint64(opt.DataDirectory[LoadConfigTableIndex].Size)
+		tlsRVA = uint64(opt.DataDirectory[TLSTableIndex].VirtualAddress)
+		tlsSize = uint64(opt.DataDirectory[TLSTableIndex].Size)
+		delayRVA = uint64(opt.DataDirectory[DelayImportTableIndex].VirtualAddress)
+		delaySize = uint64(opt.DataDirectory[DelayImportTableIndex].Size)
+		relocRVA = uint64(opt.DataDirectory[BaseRelocTableIndex].VirtualAddress)
+		relocSize = uint64(opt.DataDirectory[BaseRelocTableIndex].Size)
+		rsrcRVA = uint64(opt.DataDirectory[ResourceTableIndex].VirtualAddress)
+		rsrcSize = uint64(opt.DataDirectory[ResourceTableIndex].Size)
 	case *pe.OptionalHeader64:
 		file.Entry = bin.Address(opt.ImageBase) + bin.Address(opt.AddressOfEntryPoint)
 		imageBase = uint64(opt.ImageBase)
+		hdrSize = opt.SizeOfHeaders
+		subsystem = opt.Subsystem
+		dllChars = opt.DllCharacteristics
+		expRVA = uint64(opt.DataDirectory[ExportTableIndex].VirtualAddress)
+		expSize = uint64(opt.DataDirectory[ExportTableIndex].Size)
 		itRVA = uint64(opt.DataDirectory[ImportTableIndex].VirtualAddress)
 		itSize = uint64(opt.DataDirectory[ImportTableIndex].Size)
 		iatRVA = uint64(opt.DataDirectory[ImportAddressTableIndex].VirtualAddress)
 		iatSize = uint64(opt.DataDirectory[ImportAddressTableIndex].Size)
+		exceptRVA = uint64(opt.DataDirectory[ExceptionTableIndex].VirtualAddress)
+		exceptSize = uint64(opt.DataDirectory[ExceptionTableIndex].Size)
+		loadConfigRVA = uint64(opt.DataDirectory[LoadConfigTableIndex].VirtualAddress)
+		loadConfigSize = uint64(opt.DataDirectory[LoadConfigTableIndex].Size)
+		tlsRVA = uint64(opt.DataDirectory[TLSTableIndex].VirtualAddress)
+		tlsSize = uint64(opt.DataDirectory[TLSTableIndex].Size)
+		delayRVA = uint64(opt.DataDirectory[DelayImportTableIndex].VirtualAddress)
+		delaySize = uint64(opt.DataDirectory[DelayImportTableIndex].Size)
+		relocRVA = uint64(opt.DataDirectory[BaseRelocTableIndex].VirtualAddress)
+		relocSize = uint64(opt.DataDirectory[BaseRelocTableIndex].Size)
+		rsrcRVA = uint64(opt.DataDirectory[ResourceTableIndex].VirtualAddress)
+		rsrcSize = uint64(opt.DataDirectory[ResourceTableIndex].Size)
 	default:
 		panic(fmt.Errorf("support for optional header type %T not yet implemented", opt))
 	}
+	file.ImageBase = bin.Address(imageBase)
+	addTable(file, bin.TableExport, imageBase, expRVA, expSize)
+	addTable(file, bin.TableImport, imageBase, itRVA, itSize)
+	addTable(file, bin.TableResource, imageBase, rsrcRVA, rsrcSize)
+	addTable(file, bin.TableReloc, imageBase, relocRVA, relocSize)
+	addTable(file, bin.TableIAT, imageBase, iatRVA, iatSize)
 
 	// Parse sections.
 	for _, s := range f.Sections {
@@ -134,54 +218,136 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 	}
 	sort.Slice(file.Sections, less)
 
+	// Parse overlay.
+	overlay, err := parseOverlay(r, f, hdrSize)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file.Overlay = overlay
+
 	// Parse import address table (IAT).
-	fmt.Println("iat")
+	trace.Println("iat")
 	if iatSize != 0 {
 		iatAddr := bin.Address(imageBase + iatRVA)
-		fmt.Println("iat addr:", iatAddr)
-		data := file.Data(iatAddr)
-		data = data[:iatSize]
-		fmt.Println(hex.Dump(data))
+		trace.Println("iat addr:", iatAddr)
+		// The IAT may be located outside of the initialized data of sections
+		// (e.g. of kernel-mode drivers).
+		if data, err := file.ReadData(iatAddr, int(iatSize)); err == nil {
+			trace.Println(hex.Dump(data))
+		}
 	}
 
-	// Early return if import table not present.
-	if itSize == 0 {
-		return file, nil
+	// Parse import table.
+	if itSize != 0 {
+		if err := parseImports(file, imageBase, itRVA, itSize); err != nil {
+			return nil, errors.WithStack(err)
+		}
 	}
 
-	// Parse import table.
-	fmt.Println("it")
+	// Parse export table.
+	if expSize != 0 {
+		if err := parseExports(file, imageBase, expRVA, expSize); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Parse COFF symbol table.
+	parseSymbols(file, f, imageBase)
+
+	// Parse delay-load import table.
+	if delaySize != 0 {
+		if err := parseDelayImports(file, imageBase, delayRVA, delaySize); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Parse exception handling metadata.
+	if err := parseExceptions(file, imageBase, exceptRVA, exceptSize, loadConfigRVA, loadConfigSize); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Parse base relocations.
+	if relocSize != 0 {
+		if err := parseRelocs(file, imageBase, relocRVA, relocSize); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Parse TLS callbacks and DLL entry point.
+	if err := parseTLS(file, imageBase, tlsRVA, tlsSize); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	isDLL := f.FileHeader.Characteristics&pe.IMAGE_FILE_DLL != 0
+	// Imported libraries are only used to identify kernel-mode drivers; errors
+	// are ignored for executables without import table.
+	libs, _ := f.ImportedLibraries()
+	addEntryRoot(file, subsystem, dllChars, isDLL, libs)
+	if isDLL && file.Entry != file.ImageBase {
+		addRoot(file, file.Entry, "DllMain")
+	}
+
+	// Parse functions of Go executables. Executables are still usable without
+	// the names of Go functions.
+	if _, err := file.ParseGoFuncs(); err != nil {
+		warn.Printf("unable to parse Go functions; %v", err)
+	}
+
+	// Parse class metadata of Delphi executables. Delphi executables include a
+	// PACKAGEINFO resource, listing the units linked into the executable.
+	if rsrcSize != 0 && hasResource(file, bin.Address(imageBase+rsrcRVA), RTRCData, "PACKAGEINFO") {
+		file.Compiler = bin.CompilerDelphi
+	}
+	file.ParseDelphi()
+
+	// Identify compiler.
+	identifyCompiler(r, f, file)
+
+	return file, nil
+}
+
+// parseImports parses the import table of the given size, located at the
+// specified relative virtual address.
+func parseImports(file *bin.File, imageBase, itRVA, itSize uint64) error {
+	trace.Println("it")
 	itAddr := bin.Address(imageBase + itRVA)
-	fmt.Println("it addr:", itAddr)
-	data := file.Data(itAddr)
-	data = data[:itSize]
-	fmt.Println(hex.Dump(data))
+	trace.Println("it addr:", itAddr)
+	data, err := file.ReadData(itAddr, int(itSize))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	trace.Println(hex.Dump(data))
 	br := bytes.NewReader(data)
 	zero := importDesc{}
 	var impDescs []importDesc
 	for {
 		var impDesc importDesc
 		if err := binary.Read(br, binary.LittleEndian, &impDesc); err != nil {
-			return nil, errors.WithStack(err)
+			return errors.WithStack(err)
 		}
 		if impDesc == zero {
 			break
 		}
 		impDescs = append(impDescs, impDesc)
 	}
-	pretty.Println("impDescs:", impDescs)
+	trace.Dump("impDescs:", impDescs)
 
 	for _, impDesc := range impDescs {
-		pretty.Println("impDesc:", impDesc)
+		trace.Dump("impDesc:", impDesc)
 		dllNameAddr := bin.Address(imageBase) + bin.Address(impDesc.DLLNameRVA)
 		data := file.Data(dllNameAddr)
 		dllName := parseString(data)
-		fmt.Println("dll name:", dllName)
+		trace.Println("dll name:", dllName)
 		// Parse import name table and import address table.
 		impNameTableAddr := bin.Address(imageBase) + bin.Address(impDesc.ImportNameTableRVA)
 		impAddrTableAddr := bin.Address(imageBase) + bin.Address(impDesc.ImportAddressTableRVA)
+		if impDesc.ImportNameTableRVA == 0 {
+			// Some linkers (e.g. Borland) omit the import name table; the import
+			// address table holds the names, unless the imports are bound.
+			impNameTableAddr = impAddrTableAddr
+		}
 		inAddr := impNameTableAddr
 		iaAddr := impAddrTableAddr
+		ordinalFlag := uint64(1) << uint(file.Arch.BitSize()-1)
 		for {
 			impNameRVA, n := readUintptr(file, inAddr)
 			if impNameRVA == 0 {
@@ -190,13 +356,12 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			impAddr := iaAddr
 			inAddr += bin.Address(n)
 			iaAddr += bin.Address(n)
-			fmt.Println("impAddr:", impAddr)
-			if impNameRVA&0x80000000 != 0 {
+			trace.Println("impAddr:", impAddr)
+			if impNameRVA&ordinalFlag != 0 {
 				// ordinal
-				ordinal := impNameRVA &^ 0x80000000
-				fmt.Println("===> ordinal", ordinal)
-				impName := fmt.Sprintf("%s_ordinal_%d", pathutil.TrimExt(dllName), ordinal)
-				file.Imports[impAddr] = impName
+				ordinal := impNameRVA &^ ordinalFlag
+				trace.Println("===> ordinal", ordinal)
+				file.Imports[impAddr] = bin.OrdinalName(dllName, ordinal)
 				continue
 			}
 			impNameAddr := bin.Address(imageBase + impNameRVA)
@@ -204,14 +369,14 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			ordinal := binary.LittleEndian.Uint16(data)
 			data = data[2:]
 			impName := parseString(data)
-			fmt.Println("ordinal:", ordinal)
-			fmt.Println("impName:", impName)
+			trace.Println("ordinal:", ordinal)
+			trace.Println("impName:", impName)
 			file.Imports[impAddr] = impName
 		}
-		fmt.Println()
+		trace.Println()
 	}
 
-	return file, nil
+	return nil
 }
 
 // ref: https://msdn.microsoft.com/en-us/library/ms809762.aspx
@@ -266,6 +431,21 @@ func parsePerm(char uint32) bin.Perm {
 
 // ### [ Helper functions ] ####################################################
 
+// addTable records the table of the given size, located at the specified
+// relative virtual address, in file.Tables. Empty tables are ignored.
+func addTable(file *bin.File, name string, imageBase, rva, size uint64) {
+	if size == 0 {
+		return
+	}
+	if file.Tables == nil {
+		file.Tables = make(map[string]bin.Table)
+	}
+	file.Tables[name] = bin.Table{
+		Addr: bin.Address(imageBase + rva),
+		Size: int(size),
+	}
+}
+
 // parseString parses the NULL-terminated string in the given data.
 func parseString(data []byte) string {
 	pos := bytes.IndexByte(data, '\x00')
@@ -278,20 +458,9 @@ func parseString(data []byte) string {
 // readUintptr reads a little-endian encoded value of pointer size based on the
 // CPU architecture, and returns the number of bytes read.
 func readUintptr(file *bin.File, addr bin.Address) (uint64, int) {
-	bits := file.Arch.BitSize()
-	data := file.Data(addr)
-	switch bits {
-	case 32:
-		if len(data) < 4 {
-			panic(fmt.Errorf("data length too short; expected >= 4 bytes, got %d", len(data)))
-		}
-		return uint64(binary.LittleEndian.Uint32(data)), 4
-	case 64:
-		if len(data) < 8 {
-			panic(fmt.Errorf("data length too short; expected >= 8 bytes, got %d", len(data)))
-		}
-		return binary.LittleEndian.Uint64(data), 8
-	default:
-		panic(fmt.Errorf("support for machine architecture with bit size %d not yet implemented", bits))
+	v, err := file.ReadUintptr(addr)
+	if err != nil {
+		panic(fmt.Errorf("unable to read pointer at address %v; %v", addr, err))
 	}
+	return v, file.Arch.BitSize() / 8
 }
@@ -0,0 +1,66 @@
+package pe
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Base relocation types.
+//
+// ref: https://docs.microsoft.com/en-us/windows/win32/debug/pe-format#base-relocation-types
+const (
+	// The base relocation is skipped; used to pad blocks.
+	relBasedAbsolute = 0
+	// The base relocation applies all 32 bits of the difference.
+	relBasedHighLow = 3
+	// The base relocation applies the difference to the 64-bit field.
+	relBasedDir64 = 10
+)
+
+// parseRelocs parses the base relocation table of the given size, located at
+// the specified relative virtual address, and records the locations of
+// absolute addresses in file.Relocs, sorted in ascending order.
+//
+//    struct IMAGE_BASE_RELOCATION {
+//       uint32 PageRVA;
+//       uint32 BlockSize; // including header
+//       uint16 Entries[]; // type (4 bits), page offset (12 bits)
+//    };
+func parseRelocs(file *bin.File, imageBase, relocRVA, relocSize uint64) error {
+	base := bin.Address(imageBase)
+	start := base + bin.Address(relocRVA)
+	end := start + bin.Address(relocSize)
+	for block := start; block+8 <= end; {
+		pageRVA, err := file.ReadUint32(block)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		blockSize, err := file.ReadUint32(block + 4)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if blockSize < 8 {
+			break
+		}
+		for entry := block + 8; entry+2 <= block+bin.Address(blockSize); entry += 2 {
+			v, err := file.ReadUint16(entry)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			switch typ := v >> 12; typ {
+			case relBasedAbsolute:
+				// padding.
+			case relBasedHighLow, relBasedDir64:
+				addr := base + bin.Address(pageRVA) + bin.Address(v&0xFFF)
+				file.Relocs = append(file.Relocs, addr)
+			default:
+				trace.Printf("unsupported base relocation type %d at %v", typ, entry)
+			}
+		}
+		block += bin.Address(blockSize)
+	}
+	sort.Sort(bin.Addresses(file.Relocs))
+	return nil
+}
@@ -0,0 +1,178 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// A RichHeader is the undocumented Rich header of PE files linked by the
+// Microsoft linker, located between the DOS stub and the PE header. The Rich
+// header lists the tools (e.g. compiler and linker versions) that produced the
+// object files of the executable.
+//
+//    dd "DanS" ^ key
+//    dd 0 ^ key, 0 ^ key, 0 ^ key ; padding
+//    dd (prodid << 16 | build) ^ key, count ^ key ; for each entry
+//    dd "Rich", key
+type RichHeader struct {
+	// File offset of the Rich header (i.e. the "DanS" marker).
+	Offset int64
+	// Size in bytes of the Rich header, including the "Rich" marker and key.
+	Size int64
+	// XOR key of the Rich header; a checksum of the DOS header, the DOS stub
+	// and the entries of the Rich header.
+	Key uint32
+	// Decoded padding following the "DanS" marker; typically zero.
+	Padding [3]uint32
+	// Tool entries of the Rich header.
+	Entries []RichEntry
+}
+
+// A RichEntry is a tool entry of the Rich header.
+type RichEntry struct {
+	// Product ID of the tool (e.g. 0x0105 of the Visual Studio 2015 C++
+	// compiler).
+	ProdID uint16
+	// Build number of the tool (e.g. 24215).
+	Build uint16
+	// Number of object files produced by the tool.
+	Count uint32
+}
+
+// CompID returns the combined product ID and build number of the entry, as
+// stored in the Rich header.
+func (e RichEntry) CompID() uint32 {
+	return uint32(e.ProdID)<<16 | uint32(e.Build)
+}
+
+// Product returns the name of the tool of the entry (e.g. "Utc1900_CPP" of the
+// Visual Studio 2015 C++ compiler); or the product ID if unknown.
+func (e RichEntry) Product() string {
+	if name, ok := richProducts[e.ProdID]; ok {
+		return name
+	}
+	return fmt.Sprintf("prodid_0x%04X", e.ProdID)
+}
+
+// richProducts maps from product ID to tool name of well-known tools of the
+// Rich header. Tool names are prefixed by the tool (e.g. Utc for the C/C++
+// compiler) and suffixed by the toolset version (e.g. 1900 for Visual Studio
+// 2015 and later).
+var richProducts = map[uint16]string{
+	0x0000: "Unknown",
+	0x0001: "Import0",
+	0x0002: "Linker510",
+	0x0004: "Linker600",
+	0x0006: "Cvtres500",
+	0x000A: "Utc12_C",
+	0x000B: "Utc12_CPP",
+	0x000E: "Masm613",
+	0x001C: "Utc13_C",
+	0x001D: "Utc13_CPP",
+	0x003D: "Linker700",
+	0x003F: "Export700",
+	0x0040: "Masm700",
+	0x0045: "Cvtres700",
+	0x005A: "Linker710",
+	0x005C: "Export710",
+	0x005D: "Implib710",
+	0x005E: "Cvtres710",
+	0x005F: "Utc1310_C",
+	0x0060: "Utc1310_CPP",
+	0x006D: "Utc1400_C",
+	0x006E: "Utc1400_CPP",
+	0x0078: "Linker800",
+	0x007A: "Export800",
+	0x007B: "Implib800",
+	0x007C: "Cvtres800",
+	0x007D: "Masm800",
+	0x0083: "Utc1500_C",
+	0x0084: "Utc1500_CPP",
+	0x0091: "Linker900",
+	0x0092: "Export900",
+	0x0093: "Implib900",
+	0x0094: "Cvtres900",
+	0x0095: "Masm900",
+	0x009A: "Cvtres1000",
+	0x009B: "Export1000",
+	0x009C: "Implib1000",
+	0x009D: "Linker1000",
+	0x009E: "Masm1000",
+	0x00AA: "Utc1600_C",
+	0x00AB: "Utc1600_CPP",
+	0x00FF: "Cvtres1400",
+	0x0100: "Export1400",
+	0x0101: "Implib1400",
+	0x0102: "Linker1400",
+	0x0103: "Masm1400",
+	0x0104: "Utc1900_C",
+	0x0105: "Utc1900_CPP",
+}
+
+// ParseRichHeader parses the Rich header of the given PE file. A nil Rich
+// header is returned if not present.
+func ParseRichHeader(r io.ReaderAt) (*RichHeader, error) {
+	var hdr [0x40]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Offset of PE header.
+	lfanew := binary.LittleEndian.Uint32(hdr[0x3C:])
+	const maxStubSize = 0x1000
+	if lfanew <= 0x40 || lfanew > maxStubSize {
+		return nil, nil
+	}
+	stub := make([]byte, lfanew)
+	if _, err := r.ReadAt(stub, 0); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Locate "Rich" marker, followed by the XOR key.
+	end := bytes.LastIndex(stub[0x40:], []byte("Rich"))
+	if end == -1 {
+		return nil, nil
+	}
+	end += 0x40
+	if end+8 > len(stub) {
+		return nil, nil
+	}
+	key := binary.LittleEndian.Uint32(stub[end+4:])
+	// Locate "DanS" marker, encoded by the XOR key.
+	const dans = 0x536E6144 // "DanS"
+	start := -1
+	for i := end - 4; i >= 0x40; i -= 4 {
+		if binary.LittleEndian.Uint32(stub[i:])^key == dans {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return nil, errors.Errorf("unable to locate start of Rich header ending at offset 0x%X", end)
+	}
+	rich := &RichHeader{
+		Offset: int64(start),
+		Size:   int64(end + 8 - start),
+		Key:    key,
+	}
+	for i := range rich.Padding {
+		pos := start + 4 + 4*i
+		if pos+4 > end {
+			return nil, errors.Errorf("invalid Rich header at offset 0x%X; missing padding", start)
+		}
+		rich.Padding[i] = binary.LittleEndian.Uint32(stub[pos:]) ^ key
+	}
+	for pos := start + 16; pos+8 <= end; pos += 8 {
+		compID := binary.LittleEndian.Uint32(stub[pos:]) ^ key
+		count := binary.LittleEndian.Uint32(stub[pos+4:]) ^ key
+		entry := RichEntry{
+			ProdID: uint16(compID >> 16),
+			Build:  uint16(compID),
+			Count:  count,
+		}
+		rich.Entries = append(rich.Entries, entry)
+	}
+	return rich, nil
+}
@@ -0,0 +1,268 @@
+package pe
+
+import (
+	"unicode/utf16"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Resource types.
+const (
+	// Hardware-dependent cursor image.
+	RTCursor = 1
+	// Bitmap, stored as a device-independent bitmap without file header.
+	RTBitmap = 2
+	// Hardware-dependent icon image.
+	RTIcon = 3
+	// Menu template.
+	RTMenu = 4
+	// Dialog box template.
+	RTDialog = 5
+	// String table block of 16 strings.
+	RTString = 6
+	// Font directory.
+	RTFontDir = 7
+	// Font.
+	RTFont = 8
+	// Accelerator table.
+	RTAccelerator = 9
+	// Application-defined raw data (e.g. PACKAGEINFO and DVCLAL of Delphi
+	// executables).
+	RTRCData = 10
+	// Message table.
+	RTMessageTable = 11
+	// Hardware-independent cursor group, referencing RTCursor images.
+	RTGroupCursor = 12
+	// Hardware-independent icon group, referencing RTIcon images.
+	RTGroupIcon = 14
+	// Version information.
+	RTVersion = 16
+	// Dialog include file name.
+	RTDlgInclude = 17
+	// Plug and Play resource.
+	RTPlugPlay = 19
+	// VxD.
+	RTVxD = 20
+	// Animated cursor.
+	RTAniCursor = 21
+	// Animated icon.
+	RTAniIcon = 22
+	// HTML document.
+	RTHTML = 23
+	// Side-by-side assembly manifest.
+	RTManifest = 24
+)
+
+// maxResourceEntries specifies the maximum number of entries of resource
+// directories.
+const maxResourceEntries = 4096
+
+// hasResource reports whether the resource directory located at the given
+// address contains a resource of the specified type and name.
+//
+//    struct IMAGE_RESOURCE_DIRECTORY {
+//       uint32 Characteristics;
+//       uint32 TimeDateStamp;
+//       uint16 MajorVersion;
+//       uint16 MinorVersion;
+//       uint16 NumberOfNamedEntries;
+//       uint16 NumberOfIdEntries;
+//       IMAGE_RESOURCE_DIRECTORY_ENTRY Entries[];
+//    };
+//
+//    struct IMAGE_RESOURCE_DIRECTORY_ENTRY {
+//       uint32 Name;         // string offset if high bit set; ID otherwise
+//       uint32 OffsetToData; // subdirectory offset if high bit set
+//    };
+func hasResource(file *bin.File, rsrc bin.Address, typ uint32, name string) bool {
+	// Locate subdirectory of resource type.
+	typeDir, ok := findResourceEntry(file, rsrc, rsrc, func(id uint32, _ string) bool {
+		return id == typ
+	})
+	if !ok {
+		return false
+	}
+	_, ok = findResourceEntry(file, rsrc, typeDir, func(_ uint32, s string) bool {
+		return s == name
+	})
+	return ok
+}
+
+// findResourceEntry returns the address of the subdirectory (or data entry) of
+// the first entry of the resource directory at the given address, for which
+// match returns true; match is invoked with the ID of entries identified by ID
+// and the name of named entries. The boolean return value indicates success.
+func findResourceEntry(file *bin.File, rsrc, dir bin.Address, match func(id uint32, name string) bool) (bin.Address, bool) {
+	entries, err := readResourceDir(file, rsrc, dir)
+	if err != nil {
+		return 0, false
+	}
+	for _, entry := range entries {
+		if match(entry.id, entry.name) {
+			return entry.addr, true
+		}
+	}
+	return 0, false
+}
+
+// resourceDirEntry is an entry of a resource directory.
+type resourceDirEntry struct {
+	// ID of entries identified by ID.
+	id uint32
+	// Name of named entries.
+	name string
+	// Address of the subdirectory or data entry.
+	addr bin.Address
+	// Entry refers to a subdirectory.
+	isDir bool
+}
+
+// readResourceDir reads the entries of the resource directory at the given
+// address. Named entries with invalid names are skipped.
+func readResourceDir(file *bin.File, rsrc, dir bin.Address) ([]resourceDirEntry, error) {
+	nnamed, err := file.ReadUint16(dir + 12)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	nids, err := file.ReadUint16(dir + 14)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	n := int(nnamed) + int(nids)
+	if n > maxResourceEntries {
+		return nil, errors.Errorf("invalid number of entries (%d) in resource directory at %v", n, dir)
+	}
+	const highBit = 0x80000000
+	var entries []resourceDirEntry
+	for i := 0; i < n; i++ {
+		entry := dir + 16 + bin.Address(8*i)
+		nameField, err := file.ReadUint32(entry)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		offset, err := file.ReadUint32(entry + 4)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		e := resourceDirEntry{
+			addr:  rsrc + bin.Address(offset&^highBit),
+			isDir: offset&highBit != 0,
+		}
+		if nameField&highBit != 0 {
+			s, ok := readResourceString(file, rsrc+bin.Address(nameField&^highBit))
+			if !ok {
+				continue
+			}
+			e.name = s
+		} else {
+			e.id = nameField
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// readResourceString reads the length prefixed UTF-16 string of a resource
+// name at the given address. The boolean return value indicates success.
+func readResourceString(file *bin.File, addr bin.Address) (string, bool) {
+	n, err := file.ReadUint16(addr)
+	if err != nil {
+		return "", false
+	}
+	buf := make([]uint16, n)
+	for i := range buf {
+		c, err := file.ReadUint16(addr + 2 + bin.Address(2*i))
+		if err != nil {
+			return "", false
+		}
+		buf[i] = c
+	}
+	return string(utf16.Decode(buf)), true
+}
+
+// A Resource is a resource of the resource directory of a PE file, as located
+// by the type, name and language levels of the resource directory tree.
+type Resource struct {
+	// Resource type (e.g. RTVersion) of resources identified by type ID.
+	Type uint32
+	// Resource type name of resources identified by type name; or empty.
+	TypeName string
+	// Resource ID of resources identified by ID.
+	ID uint32
+	// Resource name of resources identified by name; or empty.
+	Name string
+	// Language ID (e.g. 0x0409 for English (United States)).
+	Lang uint32
+	// Code page of the resource data.
+	CodePage uint32
+	// Resource data.
+	Data []byte
+}
+
+// ParseResources parses the resources of the resource directory located at the
+// given address.
+//
+//    struct IMAGE_RESOURCE_DATA_ENTRY {
+//       uint32 OffsetToData; // RVA
+//       uint32 Size;
+//       uint32 CodePage;
+//       uint32 Reserved;
+//    };
+func ParseResources(file *bin.File, rsrc bin.Address) ([]*Resource, error) {
+	types, err := readResourceDir(file, rsrc, rsrc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var rs []*Resource
+	for _, typ := range types {
+		if !typ.isDir {
+			return nil, errors.Errorf("invalid resource type entry at %v; expected subdirectory", typ.addr)
+		}
+		names, err := readResourceDir(file, rsrc, typ.addr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, name := range names {
+			if !name.isDir {
+				return nil, errors.Errorf("invalid resource name entry at %v; expected subdirectory", name.addr)
+			}
+			langs, err := readResourceDir(file, rsrc, name.addr)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			for _, lang := range langs {
+				if lang.isDir {
+					return nil, errors.Errorf("invalid resource language entry at %v; expected data entry", lang.addr)
+				}
+				rva, err := file.ReadUint32(lang.addr)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				size, err := file.ReadUint32(lang.addr + 4)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				codePage, err := file.ReadUint32(lang.addr + 8)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				data, err := file.ReadData(file.ImageBase+bin.Address(rva), int(size))
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				r := &Resource{
+					Type:     typ.id,
+					TypeName: typ.name,
+					ID:       name.id,
+					Name:     name.name,
+					Lang:     lang.id,
+					CodePage: codePage,
+					Data:     data,
+				}
+				rs = append(rs, r)
+			}
+		}
+	}
+	return rs, nil
+}
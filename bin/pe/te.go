@@ -0,0 +1,148 @@
+package pe
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Register TE format.
+func init() {
+	// Terse Executable (TE) format, as used by EFI firmware images. TE images
+	// are PE images with stripped headers, and are thus registered as PE
+	// executables (e.g. to use the calling conventions of PE files).
+	//
+	//    56 5A  |VZ|
+	const magic = "VZ"
+	bin.RegisterFormat("pe", magic, ParseTE)
+}
+
+// ParseTEFile parses the given TE binary executable, reading from path.
+func ParseTEFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseTE(f)
+}
+
+// teHeader is the header of TE images, which replaces the MZ, PE and optional
+// headers of the original PE image.
+//
+// ref: https://uefi.org/specs/PI/1.8/V1_TE_Image.html
+type teHeader struct {
+	// Signature ("VZ").
+	Signature [2]byte
+	// Machine architecture (e.g. pe.IMAGE_FILE_MACHINE_AMD64).
+	Machine uint16
+	// Number of section headers, following the TE header.
+	NumberOfSections uint8
+	// Subsystem (e.g. pe.IMAGE_SUBSYSTEM_EFI_APPLICATION).
+	Subsystem uint8
+	// Number of bytes stripped from the start of the original PE image.
+	StrippedSize uint16
+	// Entry point RVA.
+	AddressOfEntryPoint uint32
+	// Base of code RVA.
+	BaseOfCode uint32
+	// Image base address.
+	ImageBase uint64
+	// Base relocation table and debug directory.
+	DataDirectory [2]pe.DataDirectory
+}
+
+// teHeaderSize specifies the size in bytes of the TE header.
+const teHeaderSize = 40
+
+// ParseTE parses the given TE binary executable, reading from r.
+//
+// Users are responsible for closing r.
+func ParseTE(r io.ReaderAt) (*bin.File, error) {
+	// Parse TE header.
+	var hdr teHeader
+	if err := binary.Read(io.NewSectionReader(r, 0, teHeaderSize), binary.LittleEndian, &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if string(hdr.Signature[:]) != "VZ" {
+		return nil, errors.Errorf("invalid TE signature; expected %q, got %q", "VZ", hdr.Signature[:])
+	}
+	if hdr.StrippedSize < teHeaderSize {
+		return nil, errors.Errorf("invalid stripped size of TE header; expected >= %d, got %d", teHeaderSize, hdr.StrippedSize)
+	}
+
+	// Parse machine architecture.
+	file := &bin.File{
+		Entry:     bin.Address(hdr.ImageBase) + bin.Address(hdr.AddressOfEntryPoint),
+		ImageBase: bin.Address(hdr.ImageBase),
+		Imports:   make(map[bin.Address]string),
+		Exports:   make(map[bin.Address]string),
+	}
+	switch hdr.Machine {
+	case pe.IMAGE_FILE_MACHINE_I386:
+		file.Arch = bin.ArchX86_32
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		file.Arch = bin.ArchX86_64
+	default:
+		panic(fmt.Errorf("support for machine architecture %v not yet implemented", hdr.Machine))
+	}
+
+	// Parse sections. File offsets of section headers are relative to the
+	// original PE image, and thus adjusted by the number of stripped bytes.
+	delta := int64(hdr.StrippedSize) - teHeaderSize
+	shdrs := make([]pe.SectionHeader32, hdr.NumberOfSections)
+	if err := binary.Read(io.NewSectionReader(r, teHeaderSize, int64(40*len(shdrs))), binary.LittleEndian, shdrs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, s := range shdrs {
+		name := string(s.Name[:])
+		if pos := strings.IndexByte(name, 0); pos != -1 {
+			name = name[:pos]
+		}
+		offset := int64(s.PointerToRawData) - delta
+		raw := make([]byte, s.SizeOfRawData)
+		if s.PointerToRawData != 0 {
+			if _, err := r.ReadAt(raw, offset); err != nil && errors.Cause(err) != io.EOF {
+				return nil, errors.WithStack(err)
+			}
+		}
+		data := raw
+		fileSize := len(raw)
+		memSize := int(s.VirtualSize)
+		if fileSize > memSize {
+			// Ignore section alignment padding.
+			data = raw[:memSize]
+		}
+		sect := &bin.Section{
+			Name:     name,
+			Addr:     bin.Address(hdr.ImageBase) + bin.Address(s.VirtualAddress),
+			Offset:   uint64(offset),
+			Data:     data,
+			FileSize: fileSize,
+			MemSize:  memSize,
+			Perm:     parsePerm(s.Characteristics),
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+	sort.Slice(file.Sections, func(i, j int) bool {
+		return file.Sections[i].Addr < file.Sections[j].Addr
+	})
+
+	// Parse base relocations.
+	if reloc := hdr.DataDirectory[0]; reloc.Size != 0 {
+		if err := parseRelocs(file, hdr.ImageBase, uint64(reloc.VirtualAddress), uint64(reloc.Size)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Parse native entry point (e.g. efi_main).
+	addEntryRoot(file, uint16(hdr.Subsystem), 0, false, nil)
+	return file, nil
+}
@@ -0,0 +1,63 @@
+package pe
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// maxTLSCallbacks specifies the maximum number of TLS callbacks.
+const maxTLSCallbacks = 256
+
+// parseTLS parses the TLS directory of the PE file, and records the TLS
+// callbacks as additional entry points in file.Roots.
+//
+//    struct IMAGE_TLS_DIRECTORY {
+//       uintptr StartAddressOfRawData;
+//       uintptr EndAddressOfRawData;
+//       uintptr AddressOfIndex;
+//       uintptr AddressOfCallBacks; // VA of NULL-terminated array of VAs
+//       uint32  SizeOfZeroFill;
+//       uint32  Characteristics;
+//    };
+func parseTLS(file *bin.File, imageBase, tlsRVA, tlsSize uint64) error {
+	if tlsSize == 0 {
+		return nil
+	}
+	ptrSize := bin.Address(file.Arch.BitSize() / 8)
+	tls := bin.Address(imageBase + tlsRVA)
+	v, err := file.ReadUintptr(tls + 3*ptrSize)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	callbacks := bin.Address(v)
+	if callbacks == 0 {
+		return nil
+	}
+	for i := 0; i < maxTLSCallbacks; i++ {
+		v, err := file.ReadUintptr(callbacks + bin.Address(i)*ptrSize)
+		if err != nil {
+			// The callback array may be populated at run time.
+			break
+		}
+		if v == 0 {
+			break
+		}
+		addRoot(file, bin.Address(v), fmt.Sprintf("TlsCallback_%d", i))
+	}
+	return nil
+}
+
+// addRoot adds the given function as an additional entry point of the file,
+// unless already exported.
+func addRoot(file *bin.File, addr bin.Address, name string) {
+	if _, ok := file.Exports[addr]; ok {
+		return
+	}
+	if file.Roots == nil {
+		file.Roots = make(map[bin.Address]string)
+	}
+	trace.Printf("root %q at %v", name, addr)
+	file.Roots[addr] = name
+}
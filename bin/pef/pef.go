@@ -11,10 +11,18 @@ import (
 	"time"
 
 	"github.com/decomp/exp/bin"
-	"github.com/kr/pretty"
+	"github.com/decomp/exp/logging"
+	"github.com/mewkiz/pkg/term"
 	"github.com/pkg/errors"
 )
 
+// Loggers.
+var (
+	// trace represents a logger with the "pef:" prefix, which logs detailed
+	// tracing messages to standard error.
+	trace = logging.New("pef", logging.LevelTrace, term.BlueBold("pef:")+" ")
+)
+
 // Register PEF format.
 func init() {
 	// Preferred Executable Format (PEF) format.
@@ -175,7 +183,7 @@ func parseContainer(r io.ReaderAt) (*Container, int64, error) {
 
 	// Parse Loader section.
 	for _, sect := range container.Sections {
-		fmt.Println("kind:", sect.SectionKind)
+		trace.Println("kind:", sect.SectionKind)
 		if sect.SectionKind == kindLoader {
 			if err := parseLoaderSection(sect); err != nil {
 				return nil, 0, errors.WithStack(err)
@@ -438,7 +446,7 @@ func parseLoaderSection(sect *Section) error {
 		return errors.WithStack(err)
 	}
 
-	pretty.Println(hdr)
+	trace.Dump(hdr)
 
 	return nil
 }
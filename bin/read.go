@@ -0,0 +1,96 @@
+package bin
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// ReadData reads n bytes of data starting at the specified virtual address of
+// the binary executable. Uninitialized data (e.g. .bss) is read as zero bytes.
+func (file *File) ReadData(addr Address, n int) ([]byte, error) {
+	end := addr + Address(n)
+	for _, sect := range file.Sections {
+		size := len(sect.Data)
+		if sect.MemSize > size {
+			size = sect.MemSize
+		}
+		if addr < sect.Addr || end > sect.Addr+Address(size) {
+			continue
+		}
+		buf := make([]byte, n)
+		if off := int(addr - sect.Addr); off < len(sect.Data) {
+			copy(buf, sect.Data[off:])
+		}
+		return buf, nil
+	}
+	return nil, errors.Errorf("unable to read %d bytes at address %v; not contained within any section", n, addr)
+}
+
+// ReadUint8 reads an 8-bit unsigned integer at the specified virtual address of
+// the binary executable.
+func (file *File) ReadUint8(addr Address) (uint8, error) {
+	buf, err := file.ReadData(addr, 1)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return buf[0], nil
+}
+
+// ReadUint16 reads a 16-bit unsigned integer at the specified virtual address
+// of the binary executable, using the byte order of the machine architecture.
+func (file *File) ReadUint16(addr Address) (uint16, error) {
+	buf, err := file.ReadData(addr, 2)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return file.Arch.ByteOrder().Uint16(buf), nil
+}
+
+// ReadUint32 reads a 32-bit unsigned integer at the specified virtual address
+// of the binary executable, using the byte order of the machine architecture.
+func (file *File) ReadUint32(addr Address) (uint32, error) {
+	buf, err := file.ReadData(addr, 4)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return file.Arch.ByteOrder().Uint32(buf), nil
+}
+
+// ReadUint64 reads a 64-bit unsigned integer at the specified virtual address
+// of the binary executable, using the byte order of the machine architecture.
+func (file *File) ReadUint64(addr Address) (uint64, error) {
+	buf, err := file.ReadData(addr, 8)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return file.Arch.ByteOrder().Uint64(buf), nil
+}
+
+// ReadUintptr reads a pointer sized unsigned integer at the specified virtual
+// address of the binary executable, using the bit size and byte order of the
+// machine architecture.
+func (file *File) ReadUintptr(addr Address) (uint64, error) {
+	switch bits := file.Arch.BitSize(); bits {
+	case 16:
+		v, err := file.ReadUint16(addr)
+		return uint64(v), err
+	case 32:
+		v, err := file.ReadUint32(addr)
+		return uint64(v), err
+	case 64:
+		return file.ReadUint64(addr)
+	default:
+		return 0, errors.Errorf("support for machine architecture with bit size %d not yet implemented", bits)
+	}
+}
+
+// ByteOrder returns the byte order of the machine architecture.
+func (arch Arch) ByteOrder() binary.ByteOrder {
+	switch arch {
+	case ArchPowerPC_32:
+		return binary.BigEndian
+	default:
+		return binary.LittleEndian
+	}
+}
@@ -0,0 +1,71 @@
+package bin_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestFileReadData(t *testing.T) {
+	file := &bin.File{
+		Arch: bin.ArchX86_32,
+		Sections: []*bin.Section{
+			{Name: ".data", Addr: 0x1000, Data: []byte{0x01, 0x02, 0x03, 0x04, 0x05}, MemSize: 8},
+		},
+	}
+	golden := []struct {
+		addr bin.Address
+		n    int
+		want []byte
+		err  bool
+	}{
+		{addr: 0x1000, n: 2, want: []byte{0x01, 0x02}},
+		// Uninitialized data.
+		{addr: 0x1004, n: 4, want: []byte{0x05, 0x00, 0x00, 0x00}},
+		// Out of bounds.
+		{addr: 0x1006, n: 4, err: true},
+		{addr: 0x0FFF, n: 1, err: true},
+	}
+	for _, g := range golden {
+		got, err := file.ReadData(g.addr, g.n)
+		if g.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got nil", g.addr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error; %v", g.addr, err)
+			continue
+		}
+		if !bytes.Equal(got, g.want) {
+			t.Errorf("%v: data mismatch; expected % 02X, got % 02X", g.addr, g.want, got)
+		}
+	}
+}
+
+func TestFileReadUint32(t *testing.T) {
+	data := []byte{0x78, 0x56, 0x34, 0x12}
+	golden := []struct {
+		arch bin.Arch
+		want uint32
+	}{
+		{arch: bin.ArchX86_32, want: 0x12345678},
+		{arch: bin.ArchPowerPC_32, want: 0x78563412},
+	}
+	for _, g := range golden {
+		file := &bin.File{
+			Arch:     g.arch,
+			Sections: []*bin.Section{{Addr: 0x1000, Data: data}},
+		}
+		got, err := file.ReadUint32(0x1000)
+		if err != nil {
+			t.Errorf("%v: unexpected error; %v", g.arch, err)
+			continue
+		}
+		if got != g.want {
+			t.Errorf("%v: value mismatch; expected 0x%08X, got 0x%08X", g.arch, g.want, got)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package bin
+
+// RealMode specifies the initial segment register values of a 16-bit real mode
+// executable, as set up by the loader at the entry point.
+//
+// Addresses of 16-bit real mode executables are linear addresses; i.e. the
+// segmented address segment:offset is represented by segment*16+offset (see
+// LinearAddr). Segments of protected mode executables (e.g. Win16 NE) are
+// assigned distinct paragraph-aligned segments by the loader, and are thus
+// represented the same way.
+type RealMode struct {
+	// Code segment (CS).
+	CS uint16
+	// Data segment (DS); the segment of the Program Segment Prefix (PSP) of DOS
+	// executables, or the automatic data segment of NE executables.
+	DS uint16
+	// Extra segment (ES); the segment of the PSP of DOS executables.
+	ES uint16
+	// Stack segment (SS).
+	SS uint16
+	// Initial stack pointer (SP).
+	SP uint16
+}
+
+// LinearAddr returns the linear address of the given segmented address of 16-bit
+// real mode; i.e. segment*16+offset.
+func LinearAddr(seg, off uint16) Address {
+	return Address(seg)<<4 + Address(off)
+}
+
+// SegmentBase returns the linear address of the first byte of the given segment
+// of 16-bit real mode.
+func SegmentBase(seg uint16) Address {
+	return LinearAddr(seg, 0)
+}
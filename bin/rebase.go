@@ -0,0 +1,128 @@
+package bin
+
+import (
+	"github.com/pkg/errors"
+)
+
+// WriteUintptr writes a pointer sized unsigned integer to the specified virtual
+// address of the binary executable, using the bit size and byte order of the
+// machine architecture. The value is written to every section (and segment)
+// containing the address.
+func (file *File) WriteUintptr(addr Address, v uint64) error {
+	n := file.Arch.BitSize() / 8
+	found := false
+	for _, sect := range file.Sections {
+		if addr < sect.Addr || addr+Address(n) > sect.Addr+Address(len(sect.Data)) {
+			continue
+		}
+		buf := sect.Data[addr-sect.Addr:]
+		switch n {
+		case 2:
+			file.Arch.ByteOrder().PutUint16(buf, uint16(v))
+		case 4:
+			file.Arch.ByteOrder().PutUint32(buf, uint32(v))
+		case 8:
+			file.Arch.ByteOrder().PutUint64(buf, v)
+		default:
+			return errors.Errorf("support for machine architecture with bit size %d not yet implemented", 8*n)
+		}
+		found = true
+	}
+	if !found {
+		return errors.Errorf("unable to write %d bytes at address %v; not contained within any section", n, addr)
+	}
+	return nil
+}
+
+// Rebase relocates the binary executable to the given image base address.
+//
+// The addresses of sections, symbols and functions are adjusted, as are the
+// absolute addresses stored in code and data at the locations of relocations
+// (e.g. base relocations of PE files and relative relocations of position
+// independent ELF executables).
+//
+// Addresses of associated JSON files (e.g. funcs.json) are not adjusted, and
+// should be specified relative to the new image base.
+//
+// Executables of 16-bit real mode are relocated by segment; the image base must
+// be paragraph aligned, and the relocations (segment fixups of DOS executables)
+// are adjusted by the number of paragraphs moved.
+func (file *File) Rebase(base Address) error {
+	delta := base - file.ImageBase
+	if delta == 0 {
+		return nil
+	}
+	// Adjustment of relocated values.
+	adjust := uint64(delta)
+	if file.RealMode != nil {
+		if delta%16 != 0 {
+			return errors.Errorf("unable to rebase 16-bit real mode executable to %v; image base not paragraph aligned", base)
+		}
+		adjust = uint64(delta) >> 4
+		segs := []*uint16{&file.RealMode.CS, &file.RealMode.DS, &file.RealMode.ES, &file.RealMode.SS}
+		for _, seg := range segs {
+			*seg += uint16(adjust)
+		}
+	}
+	// Adjust absolute addresses at relocations.
+	for _, addr := range file.Relocs {
+		v, err := file.ReadUintptr(addr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := file.WriteUintptr(addr, v+adjust); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for i := range file.Relocs {
+		file.Relocs[i] += delta
+	}
+	// Adjust addresses of sections and segments; segments are also included in
+	// Sections.
+	for _, sect := range file.Sections {
+		sect.Addr += delta
+	}
+	file.Entry += delta
+	file.ImageBase = base
+	file.Imports = rebaseNames(file.Imports, delta)
+	file.DataImports = rebaseNames(file.DataImports, delta)
+	file.Exports = rebaseNames(file.Exports, delta)
+	file.Symbols = rebaseNames(file.Symbols, delta)
+	file.Roots = rebaseNames(file.Roots, delta)
+	file.FuncBounds = rebaseAddrs(file.FuncBounds, delta)
+	file.FuncChunks = rebaseAddrs(file.FuncChunks, delta)
+	if file.Handlers != nil {
+		handlers := make(map[Address]bool)
+		for addr, v := range file.Handlers {
+			handlers[addr+delta] = v
+		}
+		file.Handlers = handlers
+	}
+	return nil
+}
+
+// rebaseNames returns a copy of the given name mapping, with addresses offset by
+// delta.
+func rebaseNames(m map[Address]string, delta Address) map[Address]string {
+	if m == nil {
+		return nil
+	}
+	names := make(map[Address]string)
+	for addr, name := range m {
+		names[addr+delta] = name
+	}
+	return names
+}
+
+// rebaseAddrs returns a copy of the given address mapping, with keys and values
+// offset by delta.
+func rebaseAddrs(m map[Address]Address, delta Address) map[Address]Address {
+	if m == nil {
+		return nil
+	}
+	addrs := make(map[Address]Address)
+	for addr, v := range m {
+		addrs[addr+delta] = v + delta
+	}
+	return addrs
+}
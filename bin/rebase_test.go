@@ -0,0 +1,41 @@
+package bin_test
+
+import (
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestFileRebase(t *testing.T) {
+	file := &bin.File{
+		Arch:      bin.ArchX86_32,
+		Entry:     0x401000,
+		ImageBase: 0x400000,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Data: []byte{0xC3}, Perm: bin.PermR | bin.PermX},
+			// Pointer to entry point.
+			{Name: ".data", Addr: 0x402000, Data: []byte{0x00, 0x10, 0x40, 0x00}, Perm: bin.PermR | bin.PermW},
+		},
+		Imports: map[bin.Address]string{0x402000: "f"},
+		Relocs:  []bin.Address{0x402000},
+	}
+	if err := file.Rebase(0x10000000); err != nil {
+		t.Fatalf("unable to rebase; %v", err)
+	}
+	if want := bin.Address(0x10001000); file.Entry != want {
+		t.Errorf("entry mismatch; expected %v, got %v", want, file.Entry)
+	}
+	if want := bin.Address(0x10002000); file.Sections[1].Addr != want {
+		t.Errorf("section address mismatch; expected %v, got %v", want, file.Sections[1].Addr)
+	}
+	if _, ok := file.Imports[0x10002000]; !ok {
+		t.Errorf("unable to locate rebased import at %v", bin.Address(0x10002000))
+	}
+	v, err := file.ReadUint32(0x10002000)
+	if err != nil {
+		t.Fatalf("unable to read relocated pointer; %v", err)
+	}
+	if want := uint32(0x10001000); v != want {
+		t.Errorf("relocated pointer mismatch; expected 0x%08X, got 0x%08X", want, v)
+	}
+}
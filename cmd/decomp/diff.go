@@ -0,0 +1,460 @@
+package main
+
+import (
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// minSimilarity specifies the minimum similarity of functions matched by the
+// similarity of their instructions and constants; in range [0, 1].
+const minSimilarity = 0.6
+
+// diffMain runs the diff subcommand with the given command name and command
+// line arguments. The diff subcommand matches the functions of two versions of
+// a binary executable, and lists the changed, added and removed functions.
+//
+// Functions are matched in order by:
+//
+//    1. symbol names (e.g. exports), and entry points
+//    2. hash of instructions, with addresses normalized
+//    3. call graph shape; i.e. the n:th callee of matched functions
+//    4. similarity of instructions and constants
+//
+// Output format.
+//
+//    changed  0x401000  0x401020  main  0.92
+//    added              0x402300  f_402300
+//    removed  0x402200            f_402200
+func diffMain(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// loader specifies how to parse the binary executables.
+		loader cli.Loader
+		// all specifies whether to list unchanged functions.
+		all bool
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+Match the functions of two versions of a binary executable, and list the
+changed, added and removed functions.
+
+Functions are located from the entry point, symbols and project file of each
+executable, and from the targets of calls. JSON files of the current directory
+(e.g. funcs.json) apply to both executables.
+
+Usage:
+
+	%s [OPTION]... OLD NEW
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&all, "a", false, "also list unchanged functions")
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	loader.RegisterFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	oldPath, newPath := flags.Arg(0), flags.Arg(1)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Disassemble functions of both executables.
+	oldFuncs, err := diffFuncs(&loader, oldPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	newFuncs, err := diffFuncs(&loader, newPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Match functions.
+	m := matchFuncs(oldFuncs, newFuncs)
+	unchanged, changed := 0, 0
+	for _, old := range oldFuncs {
+		cur, ok := m[old]
+		switch {
+		case !ok:
+			fmt.Printf("removed  %-10v            %s\n", old.addr, old.name)
+		case old.hash == cur.hash:
+			unchanged++
+			if all {
+				fmt.Printf("same     %-10v  %-10v  %s\n", old.addr, cur.addr, cur.name)
+			}
+		default:
+			changed++
+			fmt.Printf("changed  %-10v  %-10v  %s  %.2f\n", old.addr, cur.addr, cur.name, similarity(old, cur))
+		}
+	}
+	matched := make(map[*diffFunc]bool)
+	for _, cur := range m {
+		matched[cur] = true
+	}
+	added := 0
+	for _, cur := range newFuncs {
+		if !matched[cur] {
+			added++
+			fmt.Printf("added                %-10v  %s\n", cur.addr, cur.name)
+		}
+	}
+	dbg.Printf("%d unchanged, %d changed, %d added and %d removed functions", unchanged, changed, added, len(oldFuncs)-len(m))
+}
+
+// A diffFunc records the features of a function used for matching functions
+// across binary executables.
+type diffFunc struct {
+	// Entry address of the function.
+	addr bin.Address
+	// Function name; symbol name if present.
+	name string
+	// Specifies whether the function name is a symbol name, rather than
+	// synthesized from the entry address.
+	named bool
+	// Specifies whether the function is the entry point of the executable.
+	entry bool
+	// Hash of the instructions of the function, with addresses normalized.
+	hash [sha1.Size]byte
+	// Normalized instructions of the function, with number of occurrences.
+	insts map[string]int
+	// Immediates of the function, which are not addresses.
+	consts map[int64]bool
+	// Callees of the function, in order of call site address; without
+	// duplicates.
+	callees []*diffFunc
+	// Entry addresses of callees; resolved to callees once all functions are
+	// disassembled.
+	calleeAddrs []bin.Address
+}
+
+// diffFuncs disassembles the functions of the given binary executable, as
+// located from the entry point, symbols and project file, and from the targets
+// of calls. The functions are returned sorted by address.
+func diffFuncs(loader *cli.Loader, binPath string) ([]*diffFunc, error) {
+	file, err := loader.ParseFile(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	funcs := make(map[bin.Address]*diffFunc)
+	queue := append([]bin.Address{}, dis.FuncAddrs...)
+	for len(queue) > 0 {
+		funcAddr := queue[0]
+		queue = queue[1:]
+		if _, ok := funcs[funcAddr]; ok {
+			continue
+		}
+		if _, ok := file.Imports[funcAddr]; ok || !file.IsCode(funcAddr) {
+			continue
+		}
+		f, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			warn.Printf("unable to decode function at %v of %q; %v", funcAddr, binPath, err)
+			continue
+		}
+		df := newDiffFunc(file, f)
+		funcs[funcAddr] = df
+		queue = append(queue, df.calleeAddrs...)
+	}
+	var sorted []*diffFunc
+	for _, df := range funcs {
+		for _, calleeAddr := range df.calleeAddrs {
+			if callee, ok := funcs[calleeAddr]; ok {
+				df.callees = append(df.callees, callee)
+			}
+		}
+		sorted = append(sorted, df)
+	}
+	less := func(i, j int) bool {
+		return sorted[i].addr < sorted[j].addr
+	}
+	sort.Slice(sorted, less)
+	dbg.Printf("located %d functions in %q", len(sorted), binPath)
+	return sorted, nil
+}
+
+// newDiffFunc returns the features of the given function.
+func newDiffFunc(file *bin.File, f *x86.Func) *diffFunc {
+	df := &diffFunc{
+		addr:   f.Addr,
+		name:   fmt.Sprintf("f_%06X", uint64(f.Addr)),
+		insts:  make(map[string]int),
+		consts: make(map[int64]bool),
+		entry:  f.Addr == file.Entry,
+	}
+	if name, ok := file.Symbols[f.Addr]; ok {
+		df.name, df.named = name, true
+	} else if name, ok := file.Exports[f.Addr]; ok {
+		df.name, df.named = name, true
+	}
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	h := sha1.New()
+	seen := make(map[bin.Address]bool)
+	for _, blockAddr := range blockAddrs {
+		block := f.Blocks[blockAddr]
+		insts := block.Insts
+		if !block.Term.IsDummyTerm() {
+			insts = append(insts[:len(insts):len(insts)], block.Term)
+		}
+		for _, inst := range insts {
+			s := normInst(file, inst, df.consts)
+			df.insts[s]++
+			fmt.Fprintln(h, s)
+			if callee, ok := x86.CallTarget(inst); ok && !seen[callee] {
+				seen[callee] = true
+				df.calleeAddrs = append(df.calleeAddrs, callee)
+			}
+		}
+	}
+	copy(df.hash[:], h.Sum(nil))
+	return df
+}
+
+// normInst returns the normalized string representation of the given
+// instruction, with branch targets and addresses of sections replaced by
+// placeholders, so that functions relocated between versions of a binary
+// executable compare equal. Immediates which are not addresses are recorded in
+// consts.
+func normInst(file *bin.File, inst *x86.Inst, consts map[int64]bool) string {
+	parts := []string{inst.Op.String()}
+	for _, arg := range inst.Args {
+		if arg == nil {
+			break
+		}
+		switch arg := arg.(type) {
+		case x86asm.Rel:
+			parts = append(parts, "rel")
+		case x86asm.Imm:
+			if isAddr(file, bin.Address(arg)) {
+				parts = append(parts, "addr")
+				continue
+			}
+			consts[int64(arg)] = true
+			parts = append(parts, arg.String())
+		case x86asm.Mem:
+			if arg.Base == x86asm.RIP || (arg.Base == 0 && isAddr(file, bin.Address(arg.Disp))) {
+				arg.Disp = 0
+				parts = append(parts, "mem:"+arg.String())
+				continue
+			}
+			parts = append(parts, arg.String())
+		default:
+			parts = append(parts, arg.String())
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// isAddr reports whether the given value is an address contained within a
+// loaded section of the binary executable. Sections not loaded into memory
+// (e.g. .symtab of ELF executables) are located at address 0.
+func isAddr(file *bin.File, v bin.Address) bool {
+	for _, sect := range file.Sections {
+		if sect.Addr == 0 {
+			continue
+		}
+		size := len(sect.Data)
+		if sect.MemSize > size {
+			size = sect.MemSize
+		}
+		if sect.Addr <= v && v < sect.Addr+bin.Address(size) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFuncs matches the functions of the old binary executable with the
+// functions of the new binary executable, and returns the mapping from old to
+// new functions.
+func matchFuncs(oldFuncs, newFuncs []*diffFunc) map[*diffFunc]*diffFunc {
+	m := make(map[*diffFunc]*diffFunc)
+	matched := make(map[*diffFunc]bool)
+	match := func(old, cur *diffFunc) {
+		m[old] = cur
+		matched[cur] = true
+	}
+	// Match by symbol names.
+	newByName := make(map[string]*diffFunc)
+	for _, cur := range newFuncs {
+		if cur.named {
+			newByName[cur.name] = cur
+		}
+	}
+	for _, old := range oldFuncs {
+		if cur, ok := newByName[old.name]; ok && old.named {
+			match(old, cur)
+		}
+	}
+	// Match entry points.
+	for _, old := range oldFuncs {
+		for _, cur := range newFuncs {
+			if old.entry && cur.entry && m[old] == nil && !matched[cur] {
+				match(old, cur)
+			}
+		}
+	}
+	// Match by unique hashes.
+	oldByHash := uniqueHashes(oldFuncs)
+	newByHash := uniqueHashes(newFuncs)
+	for hash, old := range oldByHash {
+		cur, ok := newByHash[hash]
+		if ok && old != nil && cur != nil && m[old] == nil && !matched[cur] {
+			match(old, cur)
+		}
+	}
+	// Match by call graph shape; the n:th callees of matched functions with the
+	// same number of callees are matched, until no more functions are matched.
+	propagate := func() {
+		for changed := true; changed; {
+			changed = false
+			for _, old := range oldFuncs {
+				cur, ok := m[old]
+				if !ok || len(old.callees) != len(cur.callees) {
+					continue
+				}
+				for i, oldCallee := range old.callees {
+					newCallee := cur.callees[i]
+					if m[oldCallee] != nil || matched[newCallee] {
+						continue
+					}
+					match(oldCallee, newCallee)
+					changed = true
+				}
+			}
+		}
+	}
+	propagate()
+	// Match by similarity of instructions and constants; most similar pairs
+	// first.
+	type pair struct {
+		old, cur *diffFunc
+		sim      float64
+	}
+	var pairs []pair
+	for _, old := range oldFuncs {
+		if m[old] != nil {
+			continue
+		}
+		for _, cur := range newFuncs {
+			if matched[cur] {
+				continue
+			}
+			if sim := similarity(old, cur); sim >= minSimilarity {
+				pairs = append(pairs, pair{old: old, cur: cur, sim: sim})
+			}
+		}
+	}
+	less := func(i, j int) bool {
+		if pairs[i].sim != pairs[j].sim {
+			return pairs[i].sim > pairs[j].sim
+		}
+		if pairs[i].old.addr != pairs[j].old.addr {
+			return pairs[i].old.addr < pairs[j].old.addr
+		}
+		return pairs[i].cur.addr < pairs[j].cur.addr
+	}
+	sort.Slice(pairs, less)
+	for _, p := range pairs {
+		if m[p.old] != nil || matched[p.cur] {
+			continue
+		}
+		match(p.old, p.cur)
+	}
+	// Match callees of functions matched by similarity.
+	propagate()
+	return m
+}
+
+// uniqueHashes returns the functions indexed by instruction hash. Hashes shared
+// by several functions map to nil.
+func uniqueHashes(funcs []*diffFunc) map[[sha1.Size]byte]*diffFunc {
+	m := make(map[[sha1.Size]byte]*diffFunc)
+	for _, f := range funcs {
+		if _, ok := m[f.hash]; ok {
+			m[f.hash] = nil
+			continue
+		}
+		m[f.hash] = f
+	}
+	return m
+}
+
+// similarity returns the similarity of the given functions; in range [0, 1].
+// The similarity is the average of the Jaccard similarity of their normalized
+// instructions (as multisets), and of their constants.
+func similarity(a, b *diffFunc) float64 {
+	inter, union := 0, 0
+	for s, n := range a.insts {
+		m := b.insts[s]
+		inter += min(n, m)
+		union += max(n, m)
+	}
+	for s, m := range b.insts {
+		if _, ok := a.insts[s]; !ok {
+			union += m
+		}
+	}
+	instSim := 1.0
+	if union > 0 {
+		instSim = float64(inter) / float64(union)
+	}
+	inter, union = 0, len(b.consts)
+	for c := range a.consts {
+		if b.consts[c] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	constSim := 1.0
+	if union > 0 {
+		constSim = float64(inter) / float64(union)
+	}
+	return (instSim + constSim) / 2
+}
+
+// min returns the smaller of x and y.
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// max returns the larger of x and y.
+func max(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,92 @@
+// The decomp tool decompiles binary executables. It unifies the bin2ll, bin2asm
+// and bin2dot tools as subcommands, which share command line flags and loaders
+// of binary executables.
+//
+// Usage:
+//
+//    decomp COMMAND [OPTION]... FILE
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/decomp/exp/cmd/internal/bin2asm"
+	"github.com/decomp/exp/cmd/internal/bin2dot"
+	"github.com/decomp/exp/cmd/internal/bin2ll"
+	"github.com/mewkiz/pkg/term"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "decomp:" prefix, which logs debug
+	// messages to standard error.
+	dbg = log.New(os.Stderr, term.BlueBold("decomp:")+" ", 0)
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+)
+
+// A command is a subcommand of the decomp tool.
+type command struct {
+	// Name of the subcommand (e.g. "lift").
+	name string
+	// Short description of the subcommand.
+	desc string
+	// main runs the subcommand with the given command name (e.g. "decomp lift")
+	// and the command line arguments following the subcommand.
+	main func(name string, args []string)
+}
+
+// commands specifies the subcommands of the decomp tool.
+var commands = []*command{
+	{name: "lift", desc: "lift binary executables to LLVM IR assembly (*.exe -> *.ll)", main: bin2ll.Main},
+	{name: "asm", desc: "disassemble binary executables (*.exe -> *.asm)", main: bin2asm.Main},
+	{name: "cfg", desc: "generate control flow graphs of binary executables (*.exe -> *.dot)", main: bin2dot.Main},
+	{name: "strings", desc: "list the strings of binary executables", main: stringsMain},
+	{name: "xref", desc: "list the cross-references of binary executables", main: xrefMain},
+	{name: "recompile", desc: "recompile binary executables, verifying that lifted modules are compilable (*.exe -> *.so)", main: recompileMain},
+	{name: "diff", desc: "match the functions of two versions of a binary executable, and list changes", main: diffMain},
+	{name: "scan", desc: "scan binary executables for packers, and list the entropy of sections", main: scanMain},
+	{name: "serve", desc: "serve the lifter over an HTTP API, for web front-ends and CI pipelines", main: bin2ll.Serve},
+}
+
+func usage() {
+	const use = `
+Decompile binary executables.
+
+Usage:
+
+	decomp COMMAND [OPTION]... FILE
+
+Commands:
+
+`
+	fmt.Fprint(os.Stderr, use[1:])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-10s%s\n", cmd.name, cmd.desc)
+	}
+	fmt.Fprint(os.Stderr, "\nUse \"decomp COMMAND -h\" for the flags of a command.\n")
+}
+
+func main() {
+	// Parse command line arguments.
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			cmd.main("decomp "+name, flag.Args()[1:])
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "decomp: unknown command %q\n\n", name)
+	flag.Usage()
+	os.Exit(1)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/decomp/exp/cmd/internal/bin2ll"
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/pkg/errors"
+)
+
+// recompileMain runs the recompile subcommand with the given command name and
+// command line arguments. The recompile subcommand lifts the binary executable
+// to LLVM IR, applies cleanup passes using opt, compiles the lifted module to
+// an object file using llc, and links it against a runtime shim using clang;
+// verifying that the lifted module is compilable end-to-end. The runtime shim
+// generated by the lift step (shim.c), which stubs imported functions and
+// implements the system call and segment helpers of the lifter, is linked
+// together with the user-provided runtime shims; the weak definitions of the
+// generated shim are overridden by those of user-provided shims.
+//
+// The object file is linked into a shared object without the standard library,
+// so that imported functions not provided by the runtime shim remain
+// unresolved rather than failing the link.
+func recompileMain(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// output specifies the output path of the linked shared object.
+		output string
+		// shimPaths specifies the source files (C or LLVM IR) or object files of
+		// user-provided runtime shims.
+		shimPaths cli.StringList
+		// workDir specifies the directory of intermediate files; or empty to
+		// use a temporary directory, which is removed when done.
+		workDir string
+		// passes specifies the cleanup passes of opt.
+		passes string
+		// triple specifies the target triple of the lifted module; or empty to
+		// use the target triple of the binary executable.
+		triple string
+		// liftFlags specifies additional command line flags of the lift step.
+		liftFlags string
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+Recompile binary executables (*.exe -> *.ll -> *.o -> *.so), verifying that the
+lifted module is compilable end-to-end.
+
+Usage:
+
+	%s [OPTION]... FILE
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&liftFlags, "lift", "", "additional space-separated flags of the lift step (e.g. \"-app -continue\")")
+	flags.StringVar(&output, "o", "", "output path of linked shared object (default FILE.so)")
+	flags.StringVar(&passes, "passes", "mem2reg,instcombine,simplifycfg,dce", "cleanup passes of opt; or empty to skip opt")
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flags.Var(&shimPaths, "shim", "source file (*.c or *.ll) or object file of runtime shim (may be repeated)")
+	flags.StringVar(&triple, "triple", "", "target triple of lifted module (default based on the binary executable; e.g. i686-pc-linux-gnu to link Windows executables on Linux)")
+	flags.StringVar(&workDir, "work", "", "directory of intermediate files (lifted.ll, shim.c, opt.ll and lifted.o); kept when done (default temporary directory)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	binPath := flags.Arg(0)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+	if len(output) == 0 {
+		output = strings.TrimSuffix(filepath.Base(binPath), filepath.Ext(binPath)) + ".so"
+	}
+	if len(workDir) > 0 {
+		if err := os.MkdirAll(workDir, 0755); err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+	} else {
+		tmpDir, err := ioutil.TempDir("", "decomp-recompile-")
+		if err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+		defer os.RemoveAll(tmpDir)
+		workDir = tmpDir
+	}
+
+	// Lift binary executable.
+	llPath := filepath.Join(workDir, "lifted.ll")
+	shimPath := filepath.Join(workDir, "shim.c")
+	liftArgs := strings.Fields(liftFlags)
+	liftArgs = append(liftArgs, "-mem2reg", "-o", llPath, "-shim", shimPath)
+	if quiet {
+		liftArgs = append(liftArgs, "-q")
+	}
+	if len(triple) > 0 {
+		liftArgs = append(liftArgs, "-triple", triple)
+	}
+	liftArgs = append(liftArgs, binPath)
+	dbg.Printf("lifting %q to %q", binPath, llPath)
+	bin2ll.Main("decomp lift", liftArgs)
+
+	// Apply cleanup passes.
+	optPath := llPath
+	if len(passes) > 0 {
+		optPath = filepath.Join(workDir, "opt.ll")
+		dbg.Printf("applying cleanup passes %q", passes)
+		if err := runTool("opt", "-S", "-passes="+passes, "-o", optPath, llPath); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Compile lifted module.
+	objPath := filepath.Join(workDir, "lifted.o")
+	dbg.Printf("compiling %q to %q", optPath, objPath)
+	if err := runTool("llc", "-filetype=obj", "-relocation-model=pic", "-o", objPath, optPath); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Link against runtime shim.
+	linkArgs := []string{"-shared", "-nostdlib", "-ffreestanding", "-fPIC", "-Wno-override-module", "-o", output}
+	if len(triple) > 0 {
+		linkArgs = append(linkArgs, "-target", triple)
+	}
+	linkArgs = append(linkArgs, objPath, shimPath)
+	linkArgs = append(linkArgs, shimPaths...)
+	dbg.Printf("linking %q", output)
+	if err := runTool("clang", linkArgs...); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	dbg.Printf("recompiled %q to %q", binPath, output)
+}
+
+// runTool runs the given external tool (e.g. llc) with the specified command
+// line arguments.
+func runTool(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		return errors.Errorf("unable to run %s; %v\n%s", name, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
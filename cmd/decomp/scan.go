@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/decomp/exp/logging"
+)
+
+// scanMain runs the scan subcommand with the given command name and command
+// line arguments. The scan subcommand lists the entropy of the sections of the
+// binary executable, and the detected packer and entry point of its unpacking
+// stub, if packed.
+//
+// Output format.
+//
+//    0x401000  UPX1      r-x   45056  7.89
+//    packer: upx (section name "UPX0"); unpacking stub at 0x40BA80
+func scanMain(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// loader specifies how to parse the binary executable.
+		loader cli.Loader
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+Scan binary executables for packers, and list the entropy of sections.
+
+Usage:
+
+	%s [OPTION]... FILE
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	loader.RegisterFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	binPath := flags.Arg(0)
+	// Mute debug and warning messages if `-q` is set. The packer is reported
+	// below, regardless of the warning of the loader.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+	logging.SetLevel(logging.LevelQuiet)
+
+	// Parse binary executable.
+	file, err := loader.ParseFile(binPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// List entropy of sections.
+	for _, sect := range file.Sections {
+		name := sect.Name
+		if len(name) == 0 {
+			name = "-"
+		}
+		fmt.Printf("%v  %-8s  %v  %6d  %.2f\n", sect.Addr, name, sect.Perm, len(sect.Data), bin.Entropy(sect.Data))
+	}
+
+	// Report packer.
+	p := file.DetectPacker()
+	if p == nil {
+		dbg.Printf("no packer detected in %q", binPath)
+		return
+	}
+	fmt.Printf("packer: %v\n", p)
+}
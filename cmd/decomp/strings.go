@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/decomp/exp/lift/x86"
+)
+
+// stringsMain runs the strings subcommand with the given command name and
+// command line arguments. The strings subcommand lists the ASCII and UTF-16
+// strings located in the sections of the binary executable, and the strings
+// located by radare2 if -r2 is set.
+//
+// Output format.
+//
+//    0x403120  .rdata  ascii  "kernel32.dll"
+func stringsMain(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// loader specifies how to parse the binary executable.
+		loader cli.Loader
+		// minLen specifies the minimum length in characters of strings.
+		minLen int
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+List the strings of binary executables.
+
+Usage:
+
+	%s [OPTION]... FILE
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.IntVar(&minLen, "n", 4, "minimum length in characters of strings")
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	loader.RegisterFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	binPath := flags.Arg(0)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Parse binary executable.
+	file, err := loader.ParseFile(binPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// List strings.
+	strs := findStrings(file, minLen)
+	strs = mergeStrings(strs, file, loader.Strings, minLen)
+	dbg.Printf("located %d strings in %q", len(strs), binPath)
+	for _, s := range strs {
+		kind := "ascii"
+		if s.wide {
+			kind = "utf16"
+		}
+		fmt.Printf("%v  %-8s  %-5s  %s\n", s.addr, s.sect, kind, strconv.Quote(s.text))
+	}
+}
+
+// A str is a string located in a section of a binary executable.
+type str struct {
+	// Address of the string.
+	addr bin.Address
+	// Name of the section containing the string.
+	sect string
+	// Specifies whether the string is encoded in UTF-16 (little-endian).
+	wide bool
+	// String contents.
+	text string
+}
+
+// findStrings returns the ASCII and UTF-16 strings of at least minLen
+// characters located in the sections of the given binary executable, sorted by
+// address. UTF-16 strings are restricted to printable ASCII characters.
+func findStrings(file *bin.File, minLen int) []*str {
+	var strs []*str
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 {
+			// Ignore segments.
+			continue
+		}
+		data := sect.Data
+		for i := 0; i < len(data); {
+			// ASCII string.
+			n := 0
+			for i+n < len(data) && isStringChar(data[i+n]) {
+				n++
+			}
+			if n >= minLen {
+				s := &str{addr: sect.Addr + bin.Address(i), sect: sect.Name, text: string(data[i : i+n])}
+				strs = append(strs, s)
+				i += n
+				continue
+			}
+			// UTF-16 string.
+			n = 0
+			for i+2*n+1 < len(data) && isStringChar(data[i+2*n]) && data[i+2*n+1] == 0 {
+				n++
+			}
+			if n >= minLen && (sect.Addr+bin.Address(i))%2 == 0 {
+				buf := make([]byte, n)
+				for j := range buf {
+					buf[j] = data[i+2*j]
+				}
+				s := &str{addr: sect.Addr + bin.Address(i), sect: sect.Name, wide: true, text: string(buf)}
+				strs = append(strs, s)
+				i += 2 * n
+				continue
+			}
+			i++
+		}
+	}
+	return strs
+}
+
+// mergeStrings merges the given known strings (e.g. located by radare2) of at
+// least minLen characters into the located strings, and returns the strings
+// sorted by address. Known strings take precedence over located strings at the
+// same address.
+func mergeStrings(strs []*str, file *bin.File, known map[bin.Address]x86.KnownString, minLen int) []*str {
+	if len(known) == 0 {
+		return strs
+	}
+	var merged []*str
+	for _, s := range strs {
+		if _, ok := known[s.addr]; !ok {
+			merged = append(merged, s)
+		}
+	}
+	for addr, k := range known {
+		if utf8.RuneCountInString(k.Text) < minLen {
+			continue
+		}
+		s := &str{addr: addr, wide: k.Wide, text: k.Text}
+		for _, sect := range file.Sections {
+			if len(sect.Name) > 0 && sect.Addr <= addr && addr < sect.Addr+bin.Address(len(sect.Data)) {
+				s.sect = sect.Name
+				break
+			}
+		}
+		merged = append(merged, s)
+	}
+	less := func(i, j int) bool {
+		return merged[i].addr < merged[j].addr
+	}
+	sort.Slice(merged, less)
+	return merged
+}
+
+// isStringChar reports whether the given byte is a printable ASCII character or
+// whitespace.
+func isStringChar(b byte) bool {
+	return (' ' <= b && b <= '~') || b == '\t' || b == '\n' || b == '\r'
+}
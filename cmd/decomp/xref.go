@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// xrefMain runs the xref subcommand with the given command name and command
+// line arguments. The xref subcommand lists the cross-references of the
+// disassembled functions of the binary executable; i.e. the addresses of calls,
+// jumps, memory operands and immediates referring to the sections of the
+// executable.
+//
+// Output format.
+//
+//    0x403120  <-  0x401012  mem
+func xrefMain(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// sel specifies the functions to disassemble.
+		sel cli.FuncSelector
+		// loader specifies how to parse the binary executable.
+		loader cli.Loader
+		// to specifies the referenced address to list cross-references of; or 0
+		// to list all cross-references.
+		to bin.Address
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+List the cross-references of binary executables.
+
+Usage:
+
+	%s [OPTION]... FILE
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flags.Var(&to, "to", "only list cross-references to the given address")
+	sel.RegisterFlags(flags, "disassemble")
+	loader.RegisterFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	binPath := flags.Arg(0)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Prepare disassembler for the binary executable.
+	file, err := loader.ParseFile(binPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if loader.Project != nil {
+		sel.Exclude = loader.Project.Exclude
+	}
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Disassemble basic block or functions.
+	var blocks []*x86.BasicBlock
+	if sel.Block != 0 {
+		block, err := dis.DecodeBlock(sel.Block)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		blocks = append(blocks, block)
+	} else {
+		for _, funcAddr := range sel.Select(dis.FuncAddrs) {
+			f, err := dis.DecodeFunc(funcAddr)
+			if err != nil {
+				log.Fatalf("%+v", err)
+			}
+			for _, block := range f.Blocks {
+				blocks = append(blocks, block)
+			}
+		}
+	}
+
+	// List cross-references.
+	var xrefs []xref
+	for _, block := range blocks {
+		for _, inst := range block.Insts {
+			xrefs = append(xrefs, instXrefs(file, inst)...)
+		}
+		if !block.Term.IsDummyTerm() {
+			xrefs = append(xrefs, instXrefs(file, block.Term)...)
+		}
+	}
+	sort.Slice(xrefs, func(i, j int) bool {
+		if xrefs[i].to != xrefs[j].to {
+			return xrefs[i].to < xrefs[j].to
+		}
+		return xrefs[i].from < xrefs[j].from
+	})
+	for _, ref := range xrefs {
+		if to != 0 && ref.to != to {
+			continue
+		}
+		fmt.Printf("%v  <-  %v  %s\n", ref.to, ref.from, ref.kind)
+	}
+}
+
+// A xref is a cross-reference from an instruction to an address.
+type xref struct {
+	// Address of the referring instruction.
+	from bin.Address
+	// Referenced address.
+	to bin.Address
+	// Kind of reference (call, jmp, mem or imm).
+	kind string
+}
+
+// instXrefs returns the cross-references of the given instruction. Memory
+// operands and immediates are only considered references if they refer to a
+// section of the binary executable.
+func instXrefs(file *bin.File, inst *x86.Inst) []xref {
+	next := inst.Addr + bin.Address(inst.Len)
+	var xrefs []xref
+	for _, arg := range inst.Args {
+		if arg == nil {
+			break
+		}
+		switch arg := arg.(type) {
+		case x86asm.Rel:
+			kind := "jmp"
+			if inst.Op == x86asm.CALL {
+				kind = "call"
+			}
+			xrefs = append(xrefs, xref{from: inst.Addr, to: next + bin.Address(int64(arg)), kind: kind})
+		case x86asm.Mem:
+			var target bin.Address
+			switch {
+			case arg.Base == x86asm.RIP:
+				target = next + bin.Address(arg.Disp)
+			case arg.Base == 0 && arg.Index == 0:
+				if inst.AddrSize == 64 {
+					target = bin.Address(arg.Disp)
+				} else {
+					target = bin.Address(uint32(arg.Disp))
+				}
+			default:
+				continue
+			}
+			if file.Perm(target) != 0 {
+				xrefs = append(xrefs, xref{from: inst.Addr, to: target, kind: "mem"})
+			}
+		case x86asm.Imm:
+			target := bin.Address(arg)
+			if target != 0 && file.Perm(target) != 0 {
+				xrefs = append(xrefs, xref{from: inst.Addr, to: target, kind: "imm"})
+			}
+		}
+	}
+	return xrefs
+}
@@ -0,0 +1,94 @@
+package bin2asm
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	binpe "github.com/decomp/exp/bin/pe"
+	"github.com/pkg/errors"
+)
+
+// parseCertTable parses the certificate table of the given PE file, and reports
+// the signers of the Authenticode signature. A nil certificate table is
+// returned if not present.
+func parseCertTable(binPath string) (*binpe.CertTable, error) {
+	f, err := os.Open(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	t, err := binpe.ParseCertTable(f)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if t == nil {
+		return nil, nil
+	}
+	dbg.Printf("certificate table of %d bytes at file offset 0x%X\n", t.Size, t.Offset)
+	for _, cert := range t.Certs {
+		certs, err := cert.X509Certs()
+		if err != nil {
+			warn.Printf("unable to parse certificate of type 0x%04X; %v", cert.Type, err)
+			continue
+		}
+		for _, c := range certs {
+			dbg.Printf("certificate: subject %q, issuer %q, serial %v\n", c.Subject, c.Issuer, c.SerialNumber)
+		}
+	}
+	return t, nil
+}
+
+// dumpCertTable dumps the certificate table of the given PE file in NASM
+// syntax. The contents of the certificate table are stored in certificate.bin,
+// and included verbatim from certificate.asm.
+func dumpCertTable(binPath string, t *binpe.CertTable) error {
+	// Store certificate table contents.
+	f, err := os.Open(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	data := make([]byte, t.Size)
+	if _, err := f.ReadAt(data, t.Offset); err != nil && errors.Cause(err) != io.EOF {
+		return errors.WithStack(err)
+	}
+	binPath = filepath.Join(outDir, "certificate.bin")
+	dbg.Printf("creating %q\n", binPath)
+	if err := ioutil.WriteFile(binPath, data, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	// Dump certificate table.
+	//
+	//    ; <certificate table>
+	//    ;
+	//    ;    file offset:    0x00012000
+	//    ;    size:           0x00001A38
+	//
+	//    SECTION cert
+	//
+	//    incbin 'certificate.bin'
+	//
+	//       cert_table_size      equ     $ - $$
+	const certFormat = `
+; <certificate table>
+;
+;    file offset:    0x%08X
+;    size:           0x%08X
+
+SECTION cert
+
+incbin 'certificate.bin'
+
+   cert_table_size      equ     $ - $$
+`
+	buf := fmt.Sprintf(certFormat[1:], t.Offset, t.Size)
+	outPath := filepath.Join(outDir, "certificate.asm")
+	dbg.Printf("creating %q\n", outPath)
+	if err := ioutil.WriteFile(outPath, []byte(buf), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
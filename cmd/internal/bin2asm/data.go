@@ -0,0 +1,304 @@
+package bin2asm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// minStringLen specifies the minimum length in characters of detected strings.
+const minStringLen = 4
+
+// Kinds of data chunks.
+const (
+	// Raw bytes.
+	dataRaw = iota
+	// Pointer sized absolute address, covered by a base relocation.
+	dataPtr
+	// NULL-terminated ASCII string.
+	dataString
+	// NULL-terminated UTF-16 string.
+	dataWideString
+)
+
+// A dataChunk is a data item of a non-executable section, which is rendered
+// once the labels of all sections are known.
+type dataChunk struct {
+	// Address of the data chunk.
+	addr bin.Address
+	// Kind of the data chunk (e.g. dataString).
+	kind int
+	// Data item of the data chunk.
+	item *dataItem
+}
+
+// dataRefs returns the addresses referenced by the given instructions, by base
+// relocations and by exports of the binary executable.
+func dataRefs(file *bin.File, insts map[bin.Address]*x86.Inst) map[bin.Address]bool {
+	refs := make(map[bin.Address]bool)
+	for _, addr := range file.Relocs {
+		v, err := file.ReadUintptr(addr)
+		if err != nil {
+			continue
+		}
+		refs[bin.Address(v)] = true
+	}
+	for addr := range file.Exports {
+		refs[addr] = true
+	}
+	for _, inst := range insts {
+		for _, arg := range inst.Args {
+			if arg == nil {
+				break
+			}
+			mem, ok := arg.(x86asm.Mem)
+			if !ok {
+				continue
+			}
+			switch {
+			case mem.Base == x86asm.RIP:
+				refs[inst.Addr+bin.Address(int64(inst.Len)+mem.Disp)] = true
+			case mem.Base == 0 && mem.Index == 0:
+				if inst.AddrSize == 64 {
+					refs[bin.Address(mem.Disp)] = true
+				} else {
+					refs[bin.Address(uint32(mem.Disp))] = true
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// dataChunks splits the raw data of non-executable sections not covered by
+// data items into data chunks; i.e. pointers, strings and raw bytes. Data
+// chunks are split at referenced addresses (refs) and labels, and are added to
+// items. Data chunks located at referenced addresses are labeled.
+func dataChunks(file *bin.File, items map[bin.Address]*dataItem, labels map[bin.Address][]string, refs map[bin.Address]bool) []*dataChunk {
+	relocs := make(map[bin.Address]bool)
+	for _, addr := range file.Relocs {
+		relocs[addr] = true
+	}
+	ptrSize := file.Arch.BitSize() / 8
+	var chunks []*dataChunk
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 || sect.Perm&bin.PermX != 0 {
+			continue
+		}
+		end := sect.Addr + bin.Address(len(sect.Data))
+		// isBoundary reports whether a data chunk must start at the given
+		// address.
+		isBoundary := func(addr bin.Address) bool {
+			_, isItem := items[addr]
+			return isItem || refs[addr] || relocs[addr] || len(labels[addr]) > 0
+		}
+		// fits reports whether a data chunk of the given size may start at the
+		// given address.
+		fits := func(addr bin.Address, size int) bool {
+			if addr+bin.Address(size) > end {
+				return false
+			}
+			for i := 1; i < size; i++ {
+				if isBoundary(addr + bin.Address(i)) {
+					return false
+				}
+			}
+			return true
+		}
+		data := func(addr bin.Address) []byte {
+			return sect.Data[addr-sect.Addr:]
+		}
+		// stringKind returns the kind and size of the string at the given
+		// address; or 0 if not a string.
+		stringKind := func(addr bin.Address) (kind, size int) {
+			if n := stringLen(data(addr)); n > 0 && fits(addr, n) {
+				return dataString, n
+			}
+			if n := wideStringLen(data(addr)); n > 0 && addr%2 == 0 && fits(addr, n) {
+				return dataWideString, n
+			}
+			return 0, 0
+		}
+		for addr := sect.Addr; addr < end; {
+			if item, ok := items[addr]; ok {
+				addr += bin.Address(item.size)
+				continue
+			}
+			kind, size := dataRaw, 1
+			if relocs[addr] && fits(addr, ptrSize) {
+				kind, size = dataPtr, ptrSize
+			} else if k, n := stringKind(addr); n > 0 {
+				kind, size = k, n
+			} else {
+				// Extend raw bytes up to the next boundary or string; strings are
+				// only detected following NULL bytes.
+				for a := addr + 1; a < end && !isBoundary(a); a++ {
+					if data(a - 1)[0] == 0 {
+						if _, n := stringKind(a); n > 0 {
+							break
+						}
+					}
+					size++
+				}
+			}
+			item := &dataItem{size: size}
+			if refs[addr] {
+				if _, ok := file.Exports[addr]; ok {
+					// Exports are referred to by the addr_XXXXXX label.
+					item.label(fmt.Sprintf("addr_%06X", uint64(addr)), "")
+				}
+				prefix := [...]string{dataRaw: "data", dataPtr: "off", dataString: "str", dataWideString: "wstr"}[kind]
+				item.label(fmt.Sprintf("%s_%06X", prefix, uint64(addr)), "")
+			}
+			items[addr] = item
+			chunks = append(chunks, &dataChunk{addr: addr, kind: kind, item: item})
+			addr += bin.Address(size)
+		}
+	}
+	return chunks
+}
+
+// render renders the data directives of the data chunk; referring to the
+// targets of pointers by label.
+//
+//    str_403120:
+//                            db      "kernel32.dll", 0x00
+//    off_403130:
+//                            dd      sub_401010
+//    data_403134:
+//                            dd      0x00000001, 0x00000002
+//                            times 16 db 0x00
+func (chunk *dataChunk) render(file *bin.File, syms *symbolizer) error {
+	buf, err := file.ReadData(chunk.addr, chunk.item.size)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	item := chunk.item
+	switch chunk.kind {
+	case dataPtr:
+		var (
+			kind    string
+			v       uint64
+			operand string
+		)
+		if len(buf) == 8 {
+			v = file.Arch.ByteOrder().Uint64(buf)
+			kind, operand = "dq", fmt.Sprintf("0x%016X", v)
+		} else {
+			v = uint64(file.Arch.ByteOrder().Uint32(buf))
+			kind, operand = "dd", fmt.Sprintf("0x%08X", v)
+		}
+		if label, ok := syms.symbol(bin.Address(v)); ok {
+			operand = label
+		}
+		item.directive(kind, operand, "")
+	case dataString:
+		item.directive("db", nasmString(string(buf[:len(buf)-1])), "")
+	case dataWideString:
+		if outSyntax != syntaxNASM {
+			// UTF-16 string literals are specific to NASM.
+			var operands []string
+			for i := 0; i+1 < len(buf); i += 2 {
+				operands = append(operands, fmt.Sprintf("0x%04X", uint16(buf[i])|uint16(buf[i+1])<<8))
+			}
+			item.directive("dw", strings.Join(operands, ", "), "")
+			break
+		}
+		s := make([]byte, 0, len(buf)/2-1)
+		for i := 0; i+2 < len(buf); i += 2 {
+			s = append(s, buf[i])
+		}
+		item.directive("dw", fmt.Sprintf(`__utf16__("%s"), 0x0000`, s), "")
+	default:
+		renderRaw(item, chunk.addr, buf)
+	}
+	return nil
+}
+
+// renderRaw renders the given raw bytes as data directives of the data item;
+// aligned dwords as dd, runs of zero bytes as fill directives (e.g. times), and
+// remaining bytes as db.
+func renderRaw(item *dataItem, addr bin.Address, buf []byte) {
+	// zeros returns the length of the run of zero bytes at the given index,
+	// if long enough to be rendered using times; and 0 otherwise.
+	const minZeros = 8
+	zeros := func(i int) int {
+		n := 0
+		for i+n < len(buf) && buf[i+n] == 0 {
+			n++
+		}
+		if n < minZeros {
+			return 0
+		}
+		return n
+	}
+	for i := 0; i < len(buf); {
+		if n := zeros(i); n > 0 {
+			item.line(outSyntax.fill(fmt.Sprintf("%d", n), 0x00), "")
+			i += n
+			continue
+		}
+		var operands []string
+		if (addr+bin.Address(i))%4 == 0 && i+4 <= len(buf) {
+			// Aligned dwords.
+			for len(operands) < 4 && i+4 <= len(buf) && zeros(i) == 0 {
+				v := uint32(buf[i]) | uint32(buf[i+1])<<8 | uint32(buf[i+2])<<16 | uint32(buf[i+3])<<24
+				operands = append(operands, fmt.Sprintf("0x%08X", v))
+				i += 4
+			}
+			if len(operands) > 0 {
+				item.directive("dd", strings.Join(operands, ", "), "")
+				continue
+			}
+		}
+		// Unaligned bytes.
+		for len(operands) < 8 && i < len(buf) && zeros(i) == 0 {
+			operands = append(operands, fmt.Sprintf("0x%02X", buf[i]))
+			i++
+			if (addr+bin.Address(i))%4 == 0 && i+4 <= len(buf) {
+				break
+			}
+		}
+		item.directive("db", strings.Join(operands, ", "), "")
+	}
+}
+
+// stringLen returns the length in bytes (including NULL-terminator) of the
+// ASCII string at the start of buf; or 0 if not a string.
+func stringLen(buf []byte) int {
+	for i, b := range buf {
+		if b == 0 {
+			if i < minStringLen {
+				return 0
+			}
+			return i + 1
+		}
+		if !isPrint(b) && b != '\t' && b != '\n' && b != '\r' {
+			return 0
+		}
+	}
+	return 0
+}
+
+// wideStringLen returns the length in bytes (including NULL-terminator) of the
+// UTF-16 string, restricted to printable ASCII characters, at the start of buf;
+// or 0 if not a string.
+func wideStringLen(buf []byte) int {
+	for i := 0; i+1 < len(buf); i += 2 {
+		b, hi := buf[i], buf[i+1]
+		if b == 0 && hi == 0 {
+			if i/2 < minStringLen {
+				return 0
+			}
+			return i + 2
+		}
+		if hi != 0 || !isPrint(b) || b == '"' {
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,415 @@
+package bin2asm
+
+import (
+	"bufio"
+	debugpe "debug/pe"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"unicode"
+
+	"github.com/decomp/exp/bin"
+	binpe "github.com/decomp/exp/bin/pe"
+	"github.com/mewkiz/pkg/goutil"
+	"github.com/mewrev/pe"
+	"github.com/pkg/errors"
+)
+
+// dumpMainAsm dumps the main.asm file of the executable. The optional header of
+// PE32+ files is used to select 64-bit mode.
+func dumpMainAsm(file *pe.File, opt64 *debugpe.OptionalHeader64, hasOverlay, hasCert bool) error {
+	sectHdrs, err := file.SectHeaders()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var includes []string
+	for _, sectHdr := range sectHdrs {
+		sectName := underline(sectHdr.Name)
+		includes = append(includes, sectName)
+	}
+	if hasOverlay {
+		includes = append(includes, "overlay")
+	}
+	if hasCert {
+		includes = append(includes, "certificate")
+	}
+	if err := writeMainAsm("pe-hdr.asm", bits(opt64), includes); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// writeMainAsm writes the main.asm file of the executable, which includes the
+// given header file (e.g. "pe-hdr.asm") and the dumped sections, in the
+// specified operand size (in bits).
+func writeMainAsm(header string, bits int, includes []string) error {
+	t, err := parseTemplate("main.asm.tmpl")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Store output.
+	data := map[string]interface{}{
+		"Bits":     bits,
+		"Header":   header,
+		"Includes": includes,
+	}
+	if err := writeFile(t, "main.asm", data); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// dumpMainSyntax dumps the main file and the common.inc include file of the
+// executable in the GAS or MASM assembly syntax of the output. The include file
+// defines the image base, and the virtual addresses and sizes of sections not
+// defined by the dumped sections.
+func dumpMainSyntax(file *bin.File) error {
+	syn := outSyntax
+	c := syn.comment()
+	// Dump common include file.
+	common := &strings.Builder{}
+	fmt.Fprintf(common, "%s Base address of the executable.\n", c)
+	fmt.Fprintln(common, syn.equ("IMAGE_BASE", syn.hex(uint64(file.ImageBase), 8)))
+	var includes []string
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 {
+			// Ignore segments.
+			continue
+		}
+		sectName := underline(sect.Name)
+		includes = append(includes, sectName+syn.ext())
+		fmt.Fprintf(common, "\n%s <%s>\n", c, sect.Name)
+		fmt.Fprintln(common, syn.equ(sectName+"_vstart", syn.hex(uint64(sect.Addr), 8)))
+		// The size (in uninitialized sections) or virtual size (in sections with
+		// padding) is defined by the dumped section.
+		if sect.MemSize > len(sect.Data) {
+			fmt.Fprintln(common, syn.equ(sectName+"_vsize", syn.hex(uint64(sect.MemSize), 8)))
+		} else {
+			fmt.Fprintln(common, syn.equ(sectName+"_size", syn.hex(uint64(len(sect.Data)), 8)))
+		}
+	}
+	path := filepath.Join(outDir, "common.inc")
+	dbg.Printf("creating %q\n", path)
+	if err := ioutil.WriteFile(path, []byte(common.String()), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	// Dump main file.
+	//
+	//    .code32
+	//    .include "common.inc"
+	//    .include "_text.s"
+	main := &strings.Builder{}
+	is64 := file.Arch.BitSize() == 64
+	switch syn {
+	case syntaxGAS:
+		if is64 {
+			fmt.Fprintln(main, ".code64")
+		} else {
+			fmt.Fprintln(main, ".code32")
+		}
+		fmt.Fprintln(main, `.include "common.inc"`)
+		for _, include := range includes {
+			fmt.Fprintf(main, ".include \"%s\"\n", include)
+		}
+	case syntaxMASM:
+		if !is64 {
+			fmt.Fprintln(main, ".686p")
+			fmt.Fprintln(main, ".model flat")
+		}
+		fmt.Fprintln(main, "include common.inc")
+		for _, include := range includes {
+			fmt.Fprintf(main, "include %s\n", include)
+		}
+		fmt.Fprintln(main, "END")
+	default:
+		panic(fmt.Errorf("support for assembly syntax %v not yet implemented", syn))
+	}
+	path = filepath.Join(outDir, "main"+syn.ext())
+	dbg.Printf("creating %q\n", path)
+	if err := ioutil.WriteFile(path, []byte(main.String()), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// dumpPEHeaderAsm dumps the pe-hdr.asm file of the executable. The Rich header
+// is optional, and dumped in place of the corresponding bytes of the DOS stub.
+// The security data directory refers to the dumped certificate table if hasCert
+// is set, and is cleared if stripCert is set. The optional header of PE32+ files
+// is dumped from opt64, if present.
+func dumpPEHeaderAsm(file *pe.File, opt64 *debugpe.OptionalHeader64, rich *binpe.RichHeader, hasCert, stripCert bool) error {
+	t, err := parseTemplate("pe-hdr.asm.tmpl")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var (
+		optHdr    *pe.OptHeader
+		sectAlign uint32
+	)
+	if opt64 != nil {
+		sectAlign = opt64.SectionAlignment
+	} else {
+		optHdr, err = file.OptHeader()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		sectAlign = uint32(optHdr.SectAlign)
+	}
+	dataDirs, err := dataDirectories(file, opt64)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dosHdr, err := file.DOSHeader()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dosStub, err := file.DOSStub()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Split DOS stub at the Rich header; the DOS stub is located directly after
+	// the DOS header.
+	const dosHdrSize = 0x40
+	var richPad []byte
+	if rich != nil {
+		start := int(rich.Offset) - dosHdrSize
+		end := start + int(rich.Size)
+		if start < 0 || end > len(dosStub) {
+			return errors.Errorf("invalid Rich header at offset 0x%X; outside of DOS stub", rich.Offset)
+		}
+		richPad = dosStub[end:]
+		dosStub = dosStub[:start]
+	}
+	fileHdr, err := file.FileHeader()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	sectAlignKB := sectAlign / 1024
+	sectHdrs, err := file.SectHeaders()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Calculate size of sections containing data.
+	var dataSizes []string
+	for _, sectHdr := range sectHdrs {
+		if sectHdr.Flags&pe.SectFlagData == 0 {
+			// Ignore non-data sections.
+			continue
+		}
+		if sectHdr.Size < sectHdr.VirtSize {
+			// Section contains uninitialized data.
+			dataSize := fmt.Sprintf("%s_vsize", underline(sectHdr.Name))
+			dataSizes = append(dataSizes, dataSize)
+		} else {
+			// Section is padded.
+			dataSize := fmt.Sprintf("%s_size", underline(sectHdr.Name))
+			dataSizes = append(dataSizes, dataSize)
+		}
+	}
+	// Store output.
+	data := map[string]interface{}{
+		"OptHdr":      optHdr,
+		"Opt64":       opt64,
+		"DosHdr":      dosHdr,
+		"DOSStub":     dosStub,
+		"Rich":        rich,
+		"RichPad":     richPad,
+		"HasCert":     hasCert,
+		"StripCert":   stripCert,
+		"FileHdr":     fileHdr,
+		"SectAlignKB": sectAlignKB,
+		"SectHdrs":    sectHdrs,
+		"DataSizes":   strings.Join(dataSizes, " + "),
+		"DataDirs":    dataDirs,
+	}
+	if opt64 != nil {
+		// Pretty-print PE32+ fields using the types of the PE parser.
+		data["State"] = pe.OptState(opt64.Magic)
+		data["Subsystem"] = pe.Subsystem(opt64.Subsystem)
+		data["DLLFlags"] = pe.DLLFlag(opt64.DllCharacteristics)
+	}
+	if err := writeFile(t, "pe-hdr.asm", data); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// dataDirectories returns the data directories of the given PE file. The data
+// directories of PE32+ files are located by the PE32+ optional header opt64.
+func dataDirectories(file *pe.File, opt64 *debugpe.OptionalHeader64) ([]pe.DataDirectory, error) {
+	if opt64 == nil {
+		optHdr, err := file.OptHeader()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return optHdr.DataDirs, nil
+	}
+	var dataDirs []pe.DataDirectory
+	for i := 0; i < int(opt64.NumberOfRvaAndSizes) && i < len(opt64.DataDirectory); i++ {
+		dir := opt64.DataDirectory[i]
+		dataDir := pe.DataDirectory{
+			RelAddr: dir.VirtualAddress,
+			Size:    dir.Size,
+		}
+		dataDirs = append(dataDirs, dataDir)
+	}
+	return dataDirs, nil
+}
+
+// bits returns the operand size (in bits) of the executable; i.e. 64 for PE32+
+// files, as specified by the presence of opt64, and 32 otherwise.
+func bits(opt64 *debugpe.OptionalHeader64) int {
+	if opt64 != nil {
+		return 64
+	}
+	return 32
+}
+
+// writeFile applies a parsed template to the specified data object, writing the
+// output to the specified file.
+func writeFile(t *template.Template, filename string, data interface{}) error {
+	path := filepath.Join(outDir, filename)
+	dbg.Printf("creating %q\n", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	defer bw.Flush()
+	tw := tabwriter.NewWriter(bw, 1, 8, 1, ' ', 0)
+	if err := t.Execute(tw, data); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// bin2asmDir specifies the source directory of the bin2asm package, which
+// contains the templates of the output; located on first use.
+var bin2asmDir string
+
+// parseTemplate parses and returns the given template.
+func parseTemplate(filename string) (*template.Template, error) {
+	funcMap := map[string]interface{}{
+		"isprint":     isPrint,
+		"underline":   underline,
+		"nameArray":   nameArray,
+		"sectLabel":   sectLabel,
+		"dataDirName": dataDirName,
+		"ui16":        ui16,
+		"ui32":        ui32,
+	}
+	if len(bin2asmDir) == 0 {
+		dir, err := goutil.SrcDir("github.com/decomp/exp/cmd/internal/bin2asm")
+		if err != nil {
+			return nil, errors.Errorf("unable to locate source directory of bin2asm; %v", err)
+		}
+		bin2asmDir = dir
+	}
+	path := filepath.Join(bin2asmDir, filename)
+	t, err := template.New(filename).Funcs(funcMap).ParseFiles(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return t, nil
+}
+
+// isPrint reports if the given byte is printable.
+func isPrint(b byte) bool {
+	if b >= 0x7F {
+		return false
+	}
+	return unicode.IsPrint(rune(b))
+}
+
+// underline replaces dot characters, and other characters not valid in NASM
+// identifiers, in the given string with underscore characters.
+func underline(s string) string {
+	return sanitize(s)
+}
+
+// nameArray converts the given string to a byte array of 8 characters, pretty-
+// printed in NASM syntax.
+func nameArray(s string) string {
+	out := fmt.Sprintf("%q", s)
+	for i := len(s); i < 8; i++ {
+		out += ", 0x00"
+	}
+	return out
+}
+
+// sectLabel returns the local label of the section header of the given
+// section; e.g. "text" for ".text". Characters not valid in NASM identifiers
+// are replaced with underscore characters.
+func sectLabel(name string) string {
+	label := sanitize(strings.TrimPrefix(name, "."))
+	if len(label) == 0 {
+		return "_"
+	}
+	return label
+}
+
+// dataDirNames specifies the names of data directory entries, as used for the
+// local labels of data directories.
+var dataDirNames = [...]string{
+	0:  "export_table",
+	1:  "import_table",
+	2:  "resource_table",
+	3:  "exception_table",
+	4:  "certificate_table",
+	5:  "base_relocation_table",
+	6:  "debug",
+	7:  "architecture",
+	8:  "global_ptr",
+	9:  "tls_table",
+	10: "load_config_table",
+	11: "bound_import",
+	12: "import_address_table",
+	13: "delay_import_descriptor",
+	14: "clr_runtime_header",
+	15: "reserved",
+}
+
+// dataDirName returns the name of the data directory entry at the given index.
+func dataDirName(i int) string {
+	if i < len(dataDirNames) {
+		return dataDirNames[i]
+	}
+	return fmt.Sprintf("data_dir_%d", i)
+}
+
+// ui16 converts x to a 16-bit unsigned integer.
+func ui16(x interface{}) uint16 {
+	switch x := x.(type) {
+	case pe.Arch:
+		return uint16(x)
+	case pe.DLLFlag:
+		return uint16(x)
+	case pe.Flag:
+		return uint16(x)
+	case pe.OptState:
+		return uint16(x)
+	case pe.Subsystem:
+		return uint16(x)
+	default:
+		panic(fmt.Errorf("main.ui16: support for type %T not yet implemented", x))
+	}
+}
+
+// ui32 converts x to a 32-bit unsigned integer.
+func ui32(x interface{}) uint32 {
+	switch x := x.(type) {
+	case pe.SectFlag:
+		return uint32(x)
+	case pe.Time:
+		return uint32(x)
+	default:
+		panic(fmt.Errorf("main.ui32: support for type %T not yet implemented", x))
+	}
+}
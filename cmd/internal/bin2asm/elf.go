@@ -0,0 +1,499 @@
+package bin2asm
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+)
+
+// An elfHeader is the file header of an ELF file. Fields of ELF32 files are
+// widened to 64 bits.
+type elfHeader struct {
+	Ident     [elf.EI_NIDENT]byte
+	Type      uint16
+	Machine   uint16
+	Version   uint32
+	Entry     uint64
+	Phoff     uint64
+	Shoff     uint64
+	Flags     uint32
+	Ehsize    uint16
+	Phentsize uint16
+	Phnum     uint16
+	Shentsize uint16
+	Shnum     uint16
+	Shstrndx  uint16
+}
+
+// An elfProg is a program header of an ELF file. Fields of ELF32 files are
+// widened to 64 bits.
+type elfProg struct {
+	Type   uint32
+	Flags  uint32
+	Off    uint64
+	Vaddr  uint64
+	Paddr  uint64
+	Filesz uint64
+	Memsz  uint64
+	Align  uint64
+}
+
+// TypeName returns the name of the program header type; e.g. PT_LOAD.
+func (prog *elfProg) TypeName() string {
+	return elf.ProgType(prog.Type).String()
+}
+
+// FlagsName returns the names of the program header flags; e.g. PF_X+PF_R.
+func (prog *elfProg) FlagsName() string {
+	return elf.ProgFlag(prog.Flags).String()
+}
+
+// An elfSect is a section header of an ELF file. Fields of ELF32 files are
+// widened to 64 bits.
+type elfSect struct {
+	// Section name; or empty if not present.
+	Name      string
+	NameOff   uint32
+	Type      uint32
+	Flags     uint64
+	Addr      uint64
+	Off       uint64
+	Size      uint64
+	Link      uint32
+	Info      uint32
+	Addralign uint64
+	Entsize   uint64
+	// Expressions of the sh_addr, sh_offset and sh_size fields in NASM syntax;
+	// referring to the dumped section, if present.
+	AddrExpr, OffExpr, SizeExpr string
+}
+
+// TypeName returns the name of the section type; e.g. SHT_PROGBITS.
+func (sect *elfSect) TypeName() string {
+	return elf.SectionType(sect.Type).String()
+}
+
+// FlagsName returns the names of the section flags; e.g. SHF_WRITE+SHF_ALLOC.
+func (sect *elfSect) FlagsName() string {
+	return elf.SectionFlag(sect.Flags).String()
+}
+
+// isAlloc reports whether the section occupies memory during execution.
+func (sect *elfSect) isAlloc() bool {
+	return elf.SectionFlag(sect.Flags)&elf.SHF_ALLOC != 0
+}
+
+// parseELFHeaders parses the file header, program headers and section headers
+// of the given ELF file.
+func parseELFHeaders(binPath string) (*elfHeader, []*elfProg, []*elfSect, error) {
+	buf, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		return nil, nil, nil, errors.WithStack(err)
+	}
+	if len(buf) < elf.EI_NIDENT {
+		return nil, nil, nil, errors.Errorf("invalid ELF file; size %d smaller than e_ident", len(buf))
+	}
+	var order binary.ByteOrder
+	switch elf.Data(buf[elf.EI_DATA]) {
+	case elf.ELFDATA2LSB:
+		order = binary.LittleEndian
+	case elf.ELFDATA2MSB:
+		order = binary.BigEndian
+	default:
+		return nil, nil, nil, errors.Errorf("invalid ELF file; unknown data encoding %v", elf.Data(buf[elf.EI_DATA]))
+	}
+	r := bytes.NewReader(buf)
+	// read reads the structure located at the given file offset.
+	read := func(off uint64, v interface{}) error {
+		if _, err := r.Seek(int64(off), io.SeekStart); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := binary.Read(r, order, v); err != nil {
+			return errors.Errorf("unable to read %T at file offset 0x%X; %v", v, off, err)
+		}
+		return nil
+	}
+	hdr := &elfHeader{}
+	var progs []*elfProg
+	var sects []*elfSect
+	switch elf.Class(buf[elf.EI_CLASS]) {
+	case elf.ELFCLASS32:
+		h := &elf.Header32{}
+		if err := read(0, h); err != nil {
+			return nil, nil, nil, errors.WithStack(err)
+		}
+		*hdr = elfHeader{Ident: h.Ident, Type: h.Type, Machine: h.Machine, Version: h.Version, Entry: uint64(h.Entry), Phoff: uint64(h.Phoff), Shoff: uint64(h.Shoff), Flags: h.Flags, Ehsize: h.Ehsize, Phentsize: h.Phentsize, Phnum: h.Phnum, Shentsize: h.Shentsize, Shnum: h.Shnum, Shstrndx: h.Shstrndx}
+		for i := 0; i < int(hdr.Phnum); i++ {
+			p := &elf.Prog32{}
+			if err := read(hdr.Phoff+uint64(i)*uint64(hdr.Phentsize), p); err != nil {
+				return nil, nil, nil, errors.WithStack(err)
+			}
+			progs = append(progs, &elfProg{Type: p.Type, Flags: p.Flags, Off: uint64(p.Off), Vaddr: uint64(p.Vaddr), Paddr: uint64(p.Paddr), Filesz: uint64(p.Filesz), Memsz: uint64(p.Memsz), Align: uint64(p.Align)})
+		}
+		for i := 0; i < int(hdr.Shnum); i++ {
+			s := &elf.Section32{}
+			if err := read(hdr.Shoff+uint64(i)*uint64(hdr.Shentsize), s); err != nil {
+				return nil, nil, nil, errors.WithStack(err)
+			}
+			sects = append(sects, &elfSect{NameOff: s.Name, Type: s.Type, Flags: uint64(s.Flags), Addr: uint64(s.Addr), Off: uint64(s.Off), Size: uint64(s.Size), Link: s.Link, Info: s.Info, Addralign: uint64(s.Addralign), Entsize: uint64(s.Entsize)})
+		}
+	case elf.ELFCLASS64:
+		h := &elf.Header64{}
+		if err := read(0, h); err != nil {
+			return nil, nil, nil, errors.WithStack(err)
+		}
+		*hdr = elfHeader{Ident: h.Ident, Type: h.Type, Machine: h.Machine, Version: h.Version, Entry: h.Entry, Phoff: h.Phoff, Shoff: h.Shoff, Flags: h.Flags, Ehsize: h.Ehsize, Phentsize: h.Phentsize, Phnum: h.Phnum, Shentsize: h.Shentsize, Shnum: h.Shnum, Shstrndx: h.Shstrndx}
+		for i := 0; i < int(hdr.Phnum); i++ {
+			p := &elf.Prog64{}
+			if err := read(hdr.Phoff+uint64(i)*uint64(hdr.Phentsize), p); err != nil {
+				return nil, nil, nil, errors.WithStack(err)
+			}
+			progs = append(progs, &elfProg{Type: p.Type, Flags: p.Flags, Off: p.Off, Vaddr: p.Vaddr, Paddr: p.Paddr, Filesz: p.Filesz, Memsz: p.Memsz, Align: p.Align})
+		}
+		for i := 0; i < int(hdr.Shnum); i++ {
+			s := &elf.Section64{}
+			if err := read(hdr.Shoff+uint64(i)*uint64(hdr.Shentsize), s); err != nil {
+				return nil, nil, nil, errors.WithStack(err)
+			}
+			sects = append(sects, &elfSect{NameOff: s.Name, Type: s.Type, Flags: s.Flags, Addr: s.Addr, Off: s.Off, Size: s.Size, Link: s.Link, Info: s.Info, Addralign: s.Addralign, Entsize: s.Entsize})
+		}
+	default:
+		return nil, nil, nil, errors.Errorf("invalid ELF file; unknown class %v", elf.Class(buf[elf.EI_CLASS]))
+	}
+	// Resolve section names.
+	if int(hdr.Shstrndx) < len(sects) {
+		strtab := sects[hdr.Shstrndx]
+		if strtab.Off+strtab.Size <= uint64(len(buf)) {
+			names := buf[strtab.Off : strtab.Off+strtab.Size]
+			for _, sect := range sects {
+				if int(sect.NameOff) < len(names) {
+					name := names[sect.NameOff:]
+					if end := bytes.IndexByte(name, 0); end != -1 {
+						sect.Name = string(name[:end])
+					}
+				}
+			}
+		}
+	}
+	return hdr, progs, sects, nil
+}
+
+// dumpELF dumps the given ELF file in NASM syntax; i.e. the main.asm file, the
+// common include file, the ELF header, program headers and section header
+// table, and the contents of sections. Sections occupying memory during
+// execution are disassembled, and remaining sections (e.g. symbol tables) are
+// dumped as data.
+func dumpELF(binPath string, file *bin.File, fs []*x86.Func, rawCode bool) error {
+	hdr, progs, sects, err := parseELFHeaders(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	allocFile, others := splitAllocSections(file, sects)
+	// Dump sections occupying memory.
+	if err := dumpSections(allocFile, fs, rawCode); err != nil {
+		return errors.WithStack(err)
+	}
+	// Dump remaining sections.
+	for _, sect := range others {
+		if err := dumpELFSection(sect); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Dump headers, common include file and main.asm.
+	if err := dumpELFHeaderAsm(file, hdr, progs, sects, others); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := dumpELFCommon(file, hdr, sects, others); err != nil {
+		return errors.WithStack(err)
+	}
+	var includes []string
+	for _, sect := range dumpedSections(file, sects) {
+		includes = append(includes, underline(sect.Name))
+	}
+	if err := writeMainAsm("elf-hdr.asm", file.Arch.BitSize(), includes); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// splitAllocSections splits the sections of the given ELF file into sections
+// occupying memory during execution and remaining sections, as specified by the
+// section headers. The former are returned as a copy of file, and the latter
+// (e.g. .symtab) are located at address 0.
+func splitAllocSections(file *bin.File, sects []*elfSect) (*bin.File, []*bin.Section) {
+	alloc := make(map[string]bool)
+	for _, sect := range sects {
+		if sect.isAlloc() {
+			alloc[sect.Name] = true
+		}
+	}
+	allocFile := *file
+	allocFile.Sections = nil
+	var others []*bin.Section
+	for _, sect := range file.Sections {
+		switch {
+		case len(sect.Name) == 0:
+			// Segments.
+			allocFile.Sections = append(allocFile.Sections, sect)
+		case alloc[sect.Name]:
+			allocFile.Sections = append(allocFile.Sections, sect)
+		default:
+			others = append(others, sect)
+		}
+	}
+	return &allocFile, others
+}
+
+// dumpedSections returns the dumped sections of the given ELF file, sorted by
+// file offset. Sections without contents in the file (e.g. .bss) follow.
+func dumpedSections(file *bin.File, sects []*elfSect) []*bin.Section {
+	var dumped []*bin.Section
+	for _, sect := range file.Sections {
+		if len(sect.Name) > 0 {
+			dumped = append(dumped, sect)
+		}
+	}
+	less := func(i, j int) bool {
+		a, b := dumped[i], dumped[j]
+		if (len(a.Data) == 0) != (len(b.Data) == 0) {
+			return len(a.Data) > 0
+		}
+		return a.Offset < b.Offset
+	}
+	sort.SliceStable(dumped, less)
+	return dumped
+}
+
+// dumpELFSection dumps the given section, which does not occupy memory during
+// execution, in NASM syntax. String tables are dumped as strings, and remaining
+// sections as raw data.
+//
+//    ; <.comment>
+//    ;
+//    ;    file offset:    0x00003028
+//
+//    SECTION .comment
+//
+//                            db      "GCC: (GNU) 12.2.0", 0x00
+//
+//       _comment_size        equ     $ - $$
+func dumpELFSection(sect *bin.Section) error {
+	buf := &bytes.Buffer{}
+	sectName := underline(sect.Name)
+	const sectHeader = `
+; <%s>
+;
+;    file offset:    0x%08X
+
+SECTION %s
+
+`
+	fmt.Fprintf(buf, sectHeader[1:], sect.Name, sect.Offset, sect.Name)
+	item := &dataItem{size: len(sect.Data)}
+	if isStringTable(sect) {
+		for _, s := range strings.SplitAfter(string(sect.Data), "\x00") {
+			if len(s) > 0 {
+				item.directive("db", nasmString(strings.TrimSuffix(s, "\x00")), "")
+			}
+		}
+	} else {
+		// Align data directives by file offset.
+		renderRaw(item, bin.Address(sect.Offset), sect.Data)
+	}
+	buf.Write(item.buf.Bytes())
+	fmt.Fprintf(buf, "\n%s\n", outSyntax.equ(sectName+"_size", "$ - $$"))
+	outPath := filepath.Join(outDir, underline(sect.Name)+".asm")
+	dbg.Printf("creating %q\n", outPath)
+	if err := ioutil.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// isStringTable reports whether the given section is a string table of
+// NUL-terminated printable strings.
+func isStringTable(sect *bin.Section) bool {
+	if len(sect.Data) == 0 || sect.Data[len(sect.Data)-1] != 0 || !strings.HasSuffix(sect.Name, "str") && !strings.HasSuffix(sect.Name, "strtab") && sect.Name != ".comment" {
+		return false
+	}
+	for _, b := range sect.Data {
+		if b != 0 && !isPrint(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// dumpELFHeaderAsm dumps the elf-hdr.asm file of the ELF file; i.e. the ELF
+// header, program headers and section header table. Section headers refer to
+// the dumped sections by label.
+func dumpELFHeaderAsm(file *bin.File, hdr *elfHeader, progs []*elfProg, sects []*elfSect, others []*bin.Section) error {
+	t, err := parseTemplate("elf-hdr.asm.tmpl")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	is64 := elf.Class(hdr.Ident[elf.EI_CLASS]) == elf.ELFCLASS64
+	word, digits := "dd", 8
+	if is64 {
+		word, digits = "dq", 16
+	}
+	isOther := make(map[string]bool)
+	for _, sect := range others {
+		isOther[sect.Name] = true
+	}
+	dumped := make(map[string]*bin.Section)
+	for _, sect := range file.Sections {
+		if len(sect.Name) > 0 {
+			dumped[sect.Name] = sect
+		}
+	}
+	for _, sect := range sects {
+		sect.AddrExpr = fmt.Sprintf("0x%0*X", digits, sect.Addr)
+		sect.OffExpr = fmt.Sprintf("0x%0*X", digits, sect.Off)
+		sect.SizeExpr = fmt.Sprintf("0x%0*X", digits, sect.Size)
+		s, ok := dumped[sect.Name]
+		if !ok || s.Offset != sect.Off {
+			continue
+		}
+		sectName := underline(sect.Name)
+		if isOther[sect.Name] {
+			sect.SizeExpr = sectName + "_size"
+		} else {
+			sect.AddrExpr = sectName + "_vstart"
+			sect.SizeExpr = sectName + "_vsize"
+		}
+		// NASM section names are referred to by section.<name>.start, and must
+		// thus be valid in identifiers.
+		if len(s.Data) > 0 && sectName == strings.Replace(sect.Name, ".", "_", -1) {
+			sect.OffExpr = fmt.Sprintf("section.%s.start", sect.Name)
+		}
+	}
+	pad := make([]string, elf.EI_NIDENT-elf.EI_PAD)
+	for i := range pad {
+		pad[i] = fmt.Sprintf("0x%02X", hdr.Ident[elf.EI_PAD+i])
+	}
+	shstr := ""
+	if int(hdr.Shstrndx) < len(sects) {
+		shstr = sects[hdr.Shstrndx].Name
+	}
+	// The entry point is labeled if located within a dumped section.
+	hasStart := false
+	for _, sect := range file.Sections {
+		if len(sect.Name) > 0 && !isOther[sect.Name] && sect.Addr <= file.Entry && file.Entry < sect.Addr+bin.Address(len(sect.Data)) {
+			hasStart = true
+		}
+	}
+	data := map[string]interface{}{
+		"Hdr":       hdr,
+		"Progs":     progs,
+		"Sects":     sects,
+		"Bits":      map[bool]int{false: 32, true: 64}[is64],
+		"Is64":      is64,
+		"Word":      word,
+		"Digits":    digits,
+		"ImageBase": uint64(file.ImageBase),
+		"HasStart":  hasStart,
+		"IdentPad":  strings.Join(pad, ", "),
+		"Class":     elf.Class(hdr.Ident[elf.EI_CLASS]),
+		"Data":      elf.Data(hdr.Ident[elf.EI_DATA]),
+		"OSABI":     elf.OSABI(hdr.Ident[elf.EI_OSABI]),
+		"Type":      elf.Type(hdr.Type),
+		"Machine":   elf.Machine(hdr.Machine),
+		"Shstr":     shstr,
+	}
+	if err := writeFile(t, "elf-hdr.asm", data); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// dumpELFCommon dumps a common include file of the ELF file. Sections with
+// contents in the file are located at their original file offsets, and the
+// section header table follows at the end of the file.
+func dumpELFCommon(file *bin.File, hdr *elfHeader, sects []*elfSect, others []*bin.Section) error {
+	buf := &bytes.Buffer{}
+	const commonFormat = `
+%%ifndef __COMMON_INC__
+%%define __COMMON_INC__
+
+%%define IMAGE_BASE      0x%08X
+
+   hdr_vstart           equ     IMAGE_BASE
+`
+	fmt.Fprintf(buf, commonFormat[1:], uint64(file.ImageBase))
+	isOther := make(map[string]bool)
+	for _, sect := range others {
+		isOther[sect.Name] = true
+	}
+	dumped := dumpedSections(file, sects)
+	for _, sect := range dumped {
+		if isOther[sect.Name] {
+			continue
+		}
+		fmt.Fprintln(buf, syntaxNASM.equ(underline(sect.Name)+"_vstart", fmt.Sprintf("0x%08X", uint64(sect.Addr))))
+	}
+	buf.WriteString("\n")
+	for _, sect := range dumped {
+		if isOther[sect.Name] {
+			continue
+		}
+		if sect.MemSize > len(sect.Data) {
+			// Section with uninitialized data.
+			buf.WriteString(";")
+		}
+		fmt.Fprintln(buf, syntaxNASM.equ(underline(sect.Name)+"_size", fmt.Sprintf("0x%08X", len(sect.Data))))
+	}
+	buf.WriteString("\n")
+	for _, sect := range dumped {
+		if isOther[sect.Name] {
+			continue
+		}
+		if sect.MemSize <= len(sect.Data) {
+			// Section with padding.
+			buf.WriteString(";")
+		}
+		fmt.Fprintln(buf, syntaxNASM.equ(underline(sect.Name)+"_vsize", fmt.Sprintf("0x%08X", sect.MemSize)))
+	}
+	buf.WriteString("\n")
+
+	const bitsHeader = `
+BITS %d
+
+SECTION hdr    start=0x00000000  vstart=hdr_vstart
+`
+	fmt.Fprintf(buf, bitsHeader[1:], file.Arch.BitSize())
+	for _, sect := range dumped {
+		switch {
+		case isOther[sect.Name]:
+			fmt.Fprintf(buf, "SECTION %s  start=0x%08X\n", sect.Name, sect.Offset)
+		case len(sect.Data) == 0:
+			// Section without contents in the file (e.g. .bss).
+			fmt.Fprintf(buf, "SECTION %s  nobits  vstart=%s_vstart\n", sect.Name, underline(sect.Name))
+		default:
+			fmt.Fprintf(buf, "SECTION %s  start=0x%08X  vstart=%s_vstart\n", sect.Name, sect.Offset, underline(sect.Name))
+		}
+	}
+	if len(sects) > 0 {
+		fmt.Fprintf(buf, "SECTION shdr  start=0x%08X\n", hdr.Shoff)
+	}
+	buf.WriteString("\n")
+	buf.WriteString("%endif ; %ifndef __COMMON_INC__\n")
+
+	// Store output.
+	outPath := filepath.Join(outDir, "common.inc")
+	dbg.Printf("creating %q\n", outPath)
+	if err := ioutil.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
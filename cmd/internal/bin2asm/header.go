@@ -1,30 +1,21 @@
-package main
+package bin2asm
 
 import (
 	"bytes"
+	debugpe "debug/pe"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
-	"strings"
 
-	"github.com/mewkiz/pkg/goutil"
 	"github.com/mewrev/pe"
 	"github.com/pkg/errors"
 )
 
-// bin2asmDir specifies the source directory of the bin2asm command.
-var bin2asmDir string
-
-func init() {
-	var err error
-	bin2asmDir, err = goutil.SrcDir("github.com/decomp/exp/cmd/bin2asm")
-	if err != nil {
-		panic(fmt.Errorf("unable to locate source directory of bin2asm; %v", err))
-	}
-}
-
-// dumpCommon dumps a common include file of the executable.
-func dumpCommon(file *pe.File) error {
+// dumpCommon dumps a common include file of the executable. The overlay section
+// follows the last section of the executable, and the certificate table follows
+// the overlay, if present. The image base and code base of PE32+ files are
+// located by the PE32+ optional header opt64.
+func dumpCommon(file *pe.File, opt64 *debugpe.OptionalHeader64, hasOverlay, hasCert bool) error {
 	buf := &bytes.Buffer{}
 	const commonFormat = `
 %%ifndef __COMMON_INC__
@@ -36,19 +27,33 @@ func dumpCommon(file *pe.File) error {
 
    hdr_vstart           equ     IMAGE_BASE
 `
-	optHdr, err := file.OptHeader()
-	if err != nil {
-		return errors.WithStack(err)
-	}
+	const commonFormat64 = `
+%%ifndef __COMMON_INC__
+%%define __COMMON_INC__
+
+%%define IMAGE_BASE      0x%016X
+%%define CODE_BASE       0x%08X
+
+   hdr_vstart           equ     IMAGE_BASE
+`
 	sectHdrs, err := file.SectHeaders()
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	fmt.Fprintf(buf, commonFormat[1:], optHdr.ImageBase, optHdr.CodeBase, optHdr.DataBase)
+	if opt64 != nil {
+		// PE32+ files have no base of data.
+		fmt.Fprintf(buf, commonFormat64[1:], opt64.ImageBase, opt64.BaseOfCode)
+	} else {
+		optHdr, err := file.OptHeader()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Fprintf(buf, commonFormat[1:], optHdr.ImageBase, optHdr.CodeBase, optHdr.DataBase)
+	}
 
 	for _, sectHdr := range sectHdrs {
 		rawName := sectHdr.Name
-		sectName := strings.Replace(rawName, ".", "_", -1)
+		sectName := underline(rawName)
 		fmt.Fprintf(buf, "   %s_vstart         equ     IMAGE_BASE + 0x%08X\n", sectName, sectHdr.RelAddr)
 	}
 	buf.WriteString("\n")
@@ -58,7 +63,7 @@ func dumpCommon(file *pe.File) error {
 			buf.WriteString(";")
 		}
 		rawName := sectHdr.Name
-		sectName := strings.Replace(rawName, ".", "_", -1)
+		sectName := underline(rawName)
 		fmt.Fprintf(buf, "   %s_size           equ     0x%08X\n", sectName, sectHdr.Size)
 	}
 	buf.WriteString("\n")
@@ -68,25 +73,33 @@ func dumpCommon(file *pe.File) error {
 			buf.WriteString(";")
 		}
 		rawName := sectHdr.Name
-		sectName := strings.Replace(rawName, ".", "_", -1)
+		sectName := underline(rawName)
 		fmt.Fprintf(buf, "   %s_vsize          equ     0x%08X\n", sectName, sectHdr.VirtSize)
 	}
 	buf.WriteString("\n")
 
 	const bitsHeader = `
-BITS 32
+BITS %d
 
 SECTION hdr    vstart=hdr_vstart
 `
-	buf.WriteString(bitsHeader[1:])
+	fmt.Fprintf(buf, bitsHeader[1:], bits(opt64))
 
 	prev := "hdr"
 	for _, sectHdr := range sectHdrs {
 		rawName := sectHdr.Name
-		sectName := strings.Replace(rawName, ".", "_", -1)
+		sectName := underline(rawName)
 		fmt.Fprintf(buf, "SECTION %s  vstart=%s_vstart  follows=%s\n", rawName, sectName, prev)
 		prev = rawName
 	}
+	if hasOverlay {
+		fmt.Fprintf(buf, "SECTION overlay  follows=%s  align=1\n", prev)
+		prev = "overlay"
+	}
+	if hasCert {
+		// Entries of the certificate table are aligned to 8 bytes.
+		fmt.Fprintf(buf, "SECTION cert  follows=%s  align=8\n", prev)
+	}
 	buf.WriteString("\n")
 	buf.WriteString("%endif ; %ifndef __COMMON_INC__\n")
 
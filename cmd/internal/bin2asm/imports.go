@@ -0,0 +1,442 @@
+package bin2asm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// maxTableEntries specifies the maximum number of entries of import and export
+// tables.
+const maxTableEntries = 65536
+
+// ### [ Import table ] ########################################################
+
+// importItems adds data items of the import table of the given PE file to
+// items; i.e. the import descriptors, the import name and address tables
+// (INT/IAT), the hint/name table and the DLL names. Table entries refer to
+// their targets by label, so that the import table is preserved when
+// reassembled.
+//
+//    struct IMAGE_IMPORT_DESCRIPTOR {
+//       uint32 OriginalFirstThunk; // RVA of INT
+//       uint32 TimeDateStamp;
+//       uint32 ForwarderChain;
+//       uint32 Name;               // RVA of DLL name
+//       uint32 FirstThunk;         // RVA of IAT
+//    };
+func importItems(file *bin.File, items map[bin.Address]*dataItem) error {
+	it, ok := file.Tables[bin.TableImport]
+	if !ok {
+		return nil
+	}
+	t := newTableDumper(file, items)
+	itAddr := it.Addr
+	for i := 0; i < maxTableEntries; i++ {
+		descAddr := itAddr + bin.Address(20*i)
+		var fields [5]uint32
+		for j := range fields {
+			v, err := file.ReadUint32(descAddr + bin.Address(4*j))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			fields[j] = v
+		}
+		oft, timestamp, forwarderChain, nameRVA, ft := fields[0], fields[1], fields[2], fields[3], fields[4]
+		if fields == [5]uint32{} {
+			// Terminating null import descriptor.
+			item := &dataItem{size: 20}
+			item.directive("dd", "0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000", "end of import descriptors")
+			items[descAddr] = item
+			return nil
+		}
+		dllName, err := t.readString(file.ImageBase + bin.Address(nameRVA))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		dll := sanitize(strings.ToLower(dllName))
+		// DLL name.
+		dllLabel := t.label(fmt.Sprintf("dll_%s", dll))
+		t.addString(file.ImageBase+bin.Address(nameRVA), dllLabel, dllName)
+		// Import name table and import address table.
+		intLabel := "0x00000000"
+		if oft != 0 {
+			intLabel = t.label(fmt.Sprintf("int_%s", dll))
+			if err := t.addThunks(file.ImageBase+bin.Address(oft), intLabel, false, nil); err != nil {
+				return errors.WithStack(err)
+			}
+			intLabel += " - IMAGE_BASE"
+		}
+		iatLabel := t.label(fmt.Sprintf("iat_%s", dll))
+		// IAT entries hold the same values as INT entries, unless bound.
+		var intAddr *bin.Address
+		if oft != 0 && timestamp == 0 {
+			addr := file.ImageBase + bin.Address(oft)
+			intAddr = &addr
+		}
+		if err := t.addThunks(file.ImageBase+bin.Address(ft), iatLabel, true, intAddr); err != nil {
+			return errors.WithStack(err)
+		}
+		// Import descriptor.
+		item := &dataItem{size: 20}
+		item.label(t.label(fmt.Sprintf("import_%s", dll)), "IMAGE_IMPORT_DESCRIPTOR")
+		item.directive("dd", intLabel, "OriginalFirstThunk")
+		item.directive("dd", fmt.Sprintf("0x%08X", timestamp), "TimeDateStamp")
+		item.directive("dd", fmt.Sprintf("0x%08X", forwarderChain), "ForwarderChain")
+		item.directive("dd", dllLabel+" - IMAGE_BASE", "Name")
+		item.directive("dd", iatLabel+" - IMAGE_BASE", "FirstThunk")
+		items[descAddr] = item
+	}
+	return errors.Errorf("invalid import table at %v; missing terminating import descriptor", itAddr)
+}
+
+// addThunks adds the thunk array (INT or IAT) at the given address to the data
+// items. Entries of the IAT are labeled by the name of the imported function.
+// Thunks of bound imports (i.e. IAT entries differing from the corresponding
+// INT entry at intAddr, if present) are dumped verbatim.
+func (t *tableDumper) addThunks(addr bin.Address, label string, isIAT bool, intAddr *bin.Address) error {
+	ptrSize := 4
+	ordFlag := uint64(0x80000000)
+	if t.file.Arch == bin.ArchX86_64 {
+		ptrSize = 8
+		ordFlag = 0x8000000000000000
+	}
+	for i := 0; i < maxTableEntries; i++ {
+		thunkAddr := addr + bin.Address(ptrSize*i)
+		v, err := t.readPtr(thunkAddr, ptrSize)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		item := &dataItem{size: ptrSize}
+		if i == 0 {
+			item.label(label, "")
+		}
+		if v == 0 {
+			item.directive(t.ptrDirective(ptrSize), "0", "end of thunks")
+			t.items[thunkAddr] = item
+			return nil
+		}
+		if isIAT {
+			// Label IAT entry by name of imported function.
+			if name, ok := t.file.Imports[thunkAddr]; ok {
+				item.label(t.label(fmt.Sprintf("imp_%s", sanitize(name))), "")
+			}
+		}
+		if intAddr != nil {
+			intThunk, err := t.readPtr(*intAddr+bin.Address(ptrSize*i), ptrSize)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if intThunk != v {
+				// Bound import.
+				item.directive(t.ptrDirective(ptrSize), fmt.Sprintf("0x%0*X", 2*ptrSize, v), "bound address")
+				t.items[thunkAddr] = item
+				continue
+			}
+		}
+		switch {
+		case v&ordFlag != 0:
+			ordinal := v &^ ordFlag
+			item.directive(t.ptrDirective(ptrSize), fmt.Sprintf("0x%0*X | %d", 2*ptrSize, ordFlag, ordinal), fmt.Sprintf("ordinal %d", ordinal))
+		default:
+			hnAddr := t.file.ImageBase + bin.Address(v)
+			hnLabel, err := t.addHintName(hnAddr)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			item.directive(t.ptrDirective(ptrSize), hnLabel+" - IMAGE_BASE", "")
+		}
+		t.items[thunkAddr] = item
+	}
+	return errors.Errorf("invalid thunk array at %v; missing terminating thunk", addr)
+}
+
+// addHintName adds the hint/name table entry at the given address to the data
+// items, and returns its label.
+//
+//    struct IMAGE_IMPORT_BY_NAME {
+//       uint16 Hint;
+//       char   Name[];
+//    };
+func (t *tableDumper) addHintName(addr bin.Address) (string, error) {
+	if label, ok := t.labels[addr]; ok {
+		return label, nil
+	}
+	hint, err := t.file.ReadUint16(addr)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	name, err := t.readString(addr + 2)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	label := t.label(fmt.Sprintf("hn_%s", sanitize(name)))
+	t.labels[addr] = label
+	item := &dataItem{size: 2 + len(name) + 1}
+	item.label(label, "IMAGE_IMPORT_BY_NAME")
+	item.directive("dw", fmt.Sprintf("0x%04X", hint), "Hint")
+	item.directive("db", nasmString(name), "Name")
+	t.items[addr] = item
+	return label, nil
+}
+
+// ### [ Export table ] ########################################################
+
+// exportItems adds data items of the export table of the given PE file to
+// items; i.e. the export directory, the export address table (EAT), the name
+// pointer and ordinal tables, and the exported names. Exported functions are
+// referred to by the addr_XXXXXX labels of the section dump.
+//
+//    struct IMAGE_EXPORT_DIRECTORY {
+//       uint32 Characteristics;
+//       uint32 TimeDateStamp;
+//       uint16 MajorVersion;
+//       uint16 MinorVersion;
+//       uint32 Name;                  // RVA of DLL name
+//       uint32 Base;                  // ordinal base
+//       uint32 NumberOfFunctions;
+//       uint32 NumberOfNames;
+//       uint32 AddressOfFunctions;    // RVA of EAT
+//       uint32 AddressOfNames;        // RVA of name pointer table
+//       uint32 AddressOfNameOrdinals; // RVA of ordinal table
+//    };
+func exportItems(file *bin.File, items map[bin.Address]*dataItem) error {
+	dir, ok := file.Tables[bin.TableExport]
+	if !ok {
+		return nil
+	}
+	t := newTableDumper(file, items)
+	dirAddr := dir.Addr
+	dirEnd := dirAddr + bin.Address(dir.Size)
+	var fields [11]uint32
+	for i, offset := range []int{0, 4, 8, 10, 12, 16, 20, 24, 28, 32, 36} {
+		var (
+			v   uint32
+			err error
+		)
+		if offset == 8 || offset == 10 {
+			var v16 uint16
+			v16, err = file.ReadUint16(dirAddr + bin.Address(offset))
+			v = uint32(v16)
+		} else {
+			v, err = file.ReadUint32(dirAddr + bin.Address(offset))
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fields[i] = v
+	}
+	nameRVA, base, nfuncs, nnames := fields[4], fields[5], fields[6], fields[7]
+	eatRVA, namesRVA, ordsRVA := fields[8], fields[9], fields[10]
+	if nfuncs > maxTableEntries || nnames > maxTableEntries {
+		return errors.Errorf("invalid export directory at %v; %d functions and %d names", dirAddr, nfuncs, nnames)
+	}
+	// DLL name.
+	dllName, err := t.readString(file.ImageBase + bin.Address(nameRVA))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	t.addString(file.ImageBase+bin.Address(nameRVA), "export_dll_name", dllName)
+	// Export address table.
+	eatAddr := file.ImageBase + bin.Address(eatRVA)
+	for i := uint32(0); i < nfuncs; i++ {
+		entryAddr := eatAddr + bin.Address(4*i)
+		rva, err := file.ReadUint32(entryAddr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		item := &dataItem{size: 4}
+		if i == 0 {
+			item.label("export_funcs", "")
+		}
+		comment := fmt.Sprintf("ordinal %d", base+i)
+		target := file.ImageBase + bin.Address(rva)
+		switch {
+		case rva == 0:
+			item.directive("dd", "0x00000000", comment)
+		case dirAddr <= target && target < dirEnd:
+			// Forwarder (e.g. "NTDLL.RtlAllocateHeap").
+			fwd, err := t.readString(target)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			label := t.label(fmt.Sprintf("fwd_%s", sanitize(fwd)))
+			t.addString(target, label, fwd)
+			item.directive("dd", label+" - IMAGE_BASE", comment+" (forwarder)")
+		default:
+			item.directive("dd", fmt.Sprintf("addr_%06X - IMAGE_BASE", uint64(target)), comment)
+		}
+		items[entryAddr] = item
+	}
+	// Name pointer table and ordinal table.
+	namesAddr := file.ImageBase + bin.Address(namesRVA)
+	ordsAddr := file.ImageBase + bin.Address(ordsRVA)
+	for i := uint32(0); i < nnames; i++ {
+		nameAddr := namesAddr + bin.Address(4*i)
+		rva, err := file.ReadUint32(nameAddr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		name, err := t.readString(file.ImageBase + bin.Address(rva))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		label := t.label(fmt.Sprintf("expname_%s", sanitize(name)))
+		t.addString(file.ImageBase+bin.Address(rva), label, name)
+		item := &dataItem{size: 4}
+		if i == 0 {
+			item.label("export_names", "")
+		}
+		item.directive("dd", label+" - IMAGE_BASE", "")
+		items[nameAddr] = item
+		ordAddr := ordsAddr + bin.Address(2*i)
+		ord, err := file.ReadUint16(ordAddr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		item = &dataItem{size: 2}
+		if i == 0 {
+			item.label("export_ordinals", "")
+		}
+		item.directive("dw", fmt.Sprintf("%d", ord), name)
+		items[ordAddr] = item
+	}
+	// Export directory.
+	ref := func(label string, n uint32) string {
+		if n == 0 {
+			return "0x00000000"
+		}
+		return label + " - IMAGE_BASE"
+	}
+	item := &dataItem{size: 40}
+	item.label("export_dir", "IMAGE_EXPORT_DIRECTORY")
+	item.directive("dd", fmt.Sprintf("0x%08X", fields[0]), "Characteristics")
+	item.directive("dd", fmt.Sprintf("0x%08X", fields[1]), "TimeDateStamp")
+	item.directive("dw", fmt.Sprintf("0x%04X", fields[2]), "MajorVersion")
+	item.directive("dw", fmt.Sprintf("0x%04X", fields[3]), "MinorVersion")
+	item.directive("dd", "export_dll_name - IMAGE_BASE", "Name")
+	item.directive("dd", fmt.Sprintf("%d", base), "Base")
+	item.directive("dd", fmt.Sprintf("%d", nfuncs), "NumberOfFunctions")
+	item.directive("dd", fmt.Sprintf("%d", nnames), "NumberOfNames")
+	item.directive("dd", ref("export_funcs", nfuncs), "AddressOfFunctions")
+	item.directive("dd", ref("export_names", nnames), "AddressOfNames")
+	item.directive("dd", ref("export_ordinals", nnames), "AddressOfNameOrdinals")
+	items[dirAddr] = item
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// A tableDumper dumps data items of import and export tables, tracking the
+// labels in use.
+type tableDumper struct {
+	// Binary executable.
+	file *bin.File
+	// Data items of the binary executable.
+	items map[bin.Address]*dataItem
+	// Labels of hint/name table entries, indexed by address.
+	labels map[bin.Address]string
+	// Labels in use.
+	used map[string]bool
+}
+
+// newTableDumper returns a new table dumper for the given binary executable.
+func newTableDumper(file *bin.File, items map[bin.Address]*dataItem) *tableDumper {
+	return &tableDumper{
+		file:   file,
+		items:  items,
+		labels: make(map[bin.Address]string),
+		used:   make(map[string]bool),
+	}
+}
+
+// label returns a unique label based on the given name.
+func (t *tableDumper) label(name string) string {
+	label := name
+	for i := 2; t.used[label]; i++ {
+		label = fmt.Sprintf("%s_%d", name, i)
+	}
+	t.used[label] = true
+	return label
+}
+
+// addString adds a NUL-terminated string with the given label to the data
+// items.
+func (t *tableDumper) addString(addr bin.Address, label, s string) {
+	item := &dataItem{size: len(s) + 1}
+	item.label(label, "")
+	item.directive("db", nasmString(s), "")
+	t.items[addr] = item
+}
+
+// readString reads the NUL-terminated string at the given address.
+func (t *tableDumper) readString(addr bin.Address) (string, error) {
+	var buf []byte
+	for {
+		b, err := t.file.ReadUint8(addr + bin.Address(len(buf)))
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if b == 0 {
+			return string(buf), nil
+		}
+		buf = append(buf, b)
+	}
+}
+
+// readPtr reads the pointer-sized value at the given address.
+func (t *tableDumper) readPtr(addr bin.Address, ptrSize int) (uint64, error) {
+	if ptrSize == 8 {
+		return t.file.ReadUint64(addr)
+	}
+	v, err := t.file.ReadUint32(addr)
+	return uint64(v), err
+}
+
+// ptrDirective returns the data directive of pointer-sized values.
+func (t *tableDumper) ptrDirective(ptrSize int) string {
+	if ptrSize == 8 {
+		return "dq"
+	}
+	return "dd"
+}
+
+// sanitize replaces characters of the given name which are not valid in NASM
+// identifiers with underscore characters.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		case strings.ContainsRune("_$#@~?", r):
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// nasmString returns the given NUL-terminated string in NASM syntax.
+//
+//    "KERNEL32.dll", 0x00
+func nasmString(s string) string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if isPrint(b) && b != '"' {
+			continue
+		}
+		if start < i {
+			parts = append(parts, fmt.Sprintf(`"%s"`, s[start:i]))
+		}
+		parts = append(parts, fmt.Sprintf("0x%02X", b))
+		start = i + 1
+	}
+	if start < len(s) {
+		parts = append(parts, fmt.Sprintf(`"%s"`, s[start:]))
+	}
+	parts = append(parts, "0x00")
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,921 @@
+package bin2asm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// A symbolizer maps addresses to the labels of the dumped sections, and is
+// used to refer to branch targets and data by label in disassembled code.
+type symbolizer struct {
+	// Binary executable.
+	file *bin.File
+	// Data items, indexed by address.
+	items map[bin.Address]*dataItem
+	// Functions, indexed by address.
+	funcs map[bin.Address]*x86.Func
+	// Basic blocks, indexed by address.
+	blocks map[bin.Address]*x86.BasicBlock
+	// Addresses covered by (but not located at the start of) dumped data items
+	// and instructions; i.e. addresses without labels.
+	interior map[bin.Address]bool
+	// Locations of base relocations.
+	relocs map[bin.Address]bool
+	// Referenced addresses; labeled if located in uninitialized data.
+	refs map[bin.Address]bool
+}
+
+// newSymbolizer returns a new symbolizer for the given binary executable. The
+// dumped instructions are indexed by address; and so are the data items, which
+// take precedence over instructions. Referenced addresses (refs) of
+// uninitialized data are labeled.
+func newSymbolizer(file *bin.File, items map[bin.Address]*dataItem, funcs map[bin.Address]*x86.Func, blocks map[bin.Address]*x86.BasicBlock, insts map[bin.Address]*x86.Inst, refs map[bin.Address]bool) *symbolizer {
+	s := &symbolizer{
+		file:     file,
+		items:    items,
+		funcs:    funcs,
+		blocks:   blocks,
+		interior: make(map[bin.Address]bool),
+		relocs:   make(map[bin.Address]bool),
+		refs:     refs,
+	}
+	for _, addr := range file.Relocs {
+		s.relocs[addr] = true
+	}
+	// Mirror the traversal of dumpSection, to locate the dumped data items and
+	// instructions.
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 {
+			continue
+		}
+		end := sect.Addr + bin.Address(len(sect.Data))
+		for addr := sect.Addr; addr < end; {
+			n := 1
+			if item, ok := items[addr]; ok {
+				n = item.size
+			} else if inst, ok := insts[addr]; ok && sect.Perm&bin.PermX != 0 {
+				n = inst.Len
+			}
+			for i := 1; i < n; i++ {
+				s.interior[addr+bin.Address(i)] = true
+			}
+			addr += bin.Address(n)
+		}
+	}
+	return s
+}
+
+// symbol returns the label of the given address. The boolean return value
+// indicates success.
+func (s *symbolizer) symbol(addr bin.Address) (string, bool) {
+	if s.interior[addr] {
+		return "", false
+	}
+	for _, sect := range s.file.Sections {
+		if len(sect.Name) == 0 {
+			continue
+		}
+		end := sect.Addr + bin.Address(len(sect.Data))
+		if end <= addr && addr < sect.Addr+bin.Address(sect.MemSize) && s.refs[addr] {
+			// Uninitialized data.
+			return fmt.Sprintf("bss_%06X", uint64(addr)), true
+		}
+		if addr < sect.Addr || addr >= end {
+			continue
+		}
+		if item, ok := s.items[addr]; ok {
+			return item.name, len(item.name) > 0
+		}
+		if sect.Perm&bin.PermX != 0 {
+			if _, ok := s.funcs[addr]; ok {
+				return fmt.Sprintf("sub_%06X", uint64(addr)), true
+			}
+			if _, ok := s.blocks[addr]; ok {
+				return fmt.Sprintf("loc_%06X", uint64(addr)), true
+			}
+		}
+		return fmt.Sprintf("addr_%06X", uint64(addr)), true
+	}
+	return "", false
+}
+
+// relocTargets returns the target addresses of the base relocations located
+// within the given instruction.
+func (s *symbolizer) relocTargets(inst *x86.Inst) map[bin.Address]bool {
+	targets := make(map[bin.Address]bool)
+	ptrSize := s.file.Arch.BitSize() / 8
+	for i := 0; i+ptrSize <= inst.Len; i++ {
+		addr := inst.Addr + bin.Address(i)
+		if !s.relocs[addr] {
+			continue
+		}
+		v, err := s.file.ReadUintptr(addr)
+		if err != nil {
+			continue
+		}
+		targets[bin.Address(v)] = true
+	}
+	return targets
+}
+
+// Kinds of instruction operands.
+const (
+	// Register operand.
+	operandReg = iota
+	// Memory operand.
+	operandMem
+	// Immediate operand.
+	operandImm
+	// Branch target of relative branch.
+	operandRel
+)
+
+// An operand is an instruction operand, independent of assembly syntax.
+type operand struct {
+	// Kind of the operand (e.g. operandMem).
+	kind int
+	// Register of register operands, in NASM syntax (e.g. "st0").
+	reg string
+	// Size in bytes of memory operands; or 0 if not specified.
+	size int
+	// Segment, base and index registers of memory operands; or empty.
+	seg, base, index string
+	// Scale of index register.
+	scale int
+	// Displacement of memory operands, value of immediate operands, and address
+	// of branch targets.
+	disp int64
+	// Label of the displacement, immediate or branch target; or empty if
+	// numeric.
+	sym string
+	// RIP-relative memory operand.
+	rip bool
+	// Size in bytes to force for the displacement or immediate (i.e. the size
+	// of the original encoding); or 0 if the shortest encoding is used.
+	force int
+	// Displacement of memory operand present in the encoding, even if 0.
+	hasDisp bool
+	// Jump size keyword ("short" or "near") of relative branches; or empty.
+	jump string
+}
+
+// formatInst returns the given instruction in the specified assembly syntax,
+// referring to branch targets and data by label. The boolean return value
+// indicates success, and is false for instructions which may not be
+// represented faithfully in the assembly syntax (e.g. redundant prefixes), and
+// are thus to be dumped as raw bytes.
+//
+// Absolute addresses of immediates and displacements are referred to by label
+// if covered by base relocations; or, for executables without base
+// relocations, if used as the displacement of a memory operand without base
+// and index registers.
+//
+// Note, NASM and MASM may pick a different encoding of the same length for
+// some instructions (e.g. 89 /r rather than 8B /r for register to register
+// moves), and GAS may relax the size of branches. Use the -rawcode flag for a
+// byte-exact dump.
+func (s *symbolizer) formatInst(inst *x86.Inst, raw []byte, syn syntax) (string, bool) {
+	// Locate opcode.
+	opcode := raw
+	for len(opcode) > 0 {
+		b := opcode[0]
+		isPrefix := false
+		switch b {
+		case 0x26, 0x2E, 0x36, 0x3E, 0x64, 0x65, 0x66, 0x67, 0xF0, 0xF2, 0xF3:
+			isPrefix = true
+		default:
+			isPrefix = inst.Mode == 64 && b&0xF0 == 0x40
+		}
+		if !isPrefix {
+			break
+		}
+		opcode = opcode[1:]
+	}
+	if len(opcode) == 0 {
+		return "", false
+	}
+	// Prefixes.
+	var prefixes []string
+	hasDataSize := false
+	for _, p := range inst.Prefix {
+		if p == 0 {
+			break
+		}
+		if p&(x86asm.PrefixIgnored|x86asm.PrefixInvalid) != 0 || p.IsVEX() {
+			return "", false
+		}
+		if p&x86asm.PrefixImplicit != 0 {
+			if p&0xFF == x86asm.PrefixDataSize {
+				hasDataSize = true
+			}
+			continue
+		}
+		switch p {
+		case x86asm.PrefixLOCK:
+			prefixes = append(prefixes, "lock")
+		case x86asm.PrefixREP:
+			prefixes = append(prefixes, "rep")
+		case x86asm.PrefixREPN:
+			prefixes = append(prefixes, "repne")
+		default:
+			// Explicit segment overrides, operand and address size overrides,
+			// branch hints, etc.
+			return "", false
+		}
+	}
+	// Mnemonic.
+	mnemonic, ok := nasmMnemonic(inst, opcode)
+	if !ok {
+		return "", false
+	}
+	if isStringOp(inst.Op) {
+		// Dump string instructions without explicit operands; e.g. movsb.
+		if syn == syntaxGAS {
+			mnemonic = gasMnemonic(mnemonic, true)
+		}
+		return strings.Join(append(prefixes, mnemonic), " "), true
+	}
+	// Operands.
+	targets := s.relocTargets(inst)
+	var operands []*operand
+	hasSize := false
+	for _, arg := range inst.Args {
+		// The operand of int3 is implicit.
+		if arg == nil || mnemonic == "int3" {
+			break
+		}
+		var op *operand
+		switch arg := arg.(type) {
+		case x86asm.Reg:
+			if x86asm.ES <= arg && arg <= x86asm.GS && hasDataSize {
+				// Assemblers omit the operand size prefix of segment register
+				// moves.
+				return "", false
+			}
+			reg, ok := nasmReg(arg)
+			if !ok {
+				return "", false
+			}
+			op = &operand{kind: operandReg, reg: reg}
+		case x86asm.Mem:
+			op, ok = s.memOperand(inst, opcode, arg, targets)
+			if !ok {
+				return "", false
+			}
+		case x86asm.Imm:
+			if (opcode[0] == 0xC0 || opcode[0] == 0xC1) && arg == 1 {
+				// Assemblers encode shifts by 1 using D0 and D1.
+				return "", false
+			}
+			op = s.immOperand(inst, opcode, arg, targets)
+		case x86asm.Rel:
+			if inst.PCRel != 1 && inst.PCRel != 4 {
+				return "", false
+			}
+			target := inst.Addr + bin.Address(inst.Len) + bin.Address(int64(arg))
+			if inst.Mode != 64 {
+				target &= 0xFFFFFFFF
+			}
+			op = &operand{kind: operandRel, disp: int64(target)}
+			if label, ok := s.symbol(target); ok {
+				op.sym = label
+			}
+			if inst.Op == x86asm.JMP || isCondJump(inst.Op) {
+				op.jump = "near"
+				if inst.PCRel == 1 {
+					op.jump = "short"
+				}
+			}
+		default:
+			// Far pointers.
+			return "", false
+		}
+		if op.kind == operandReg || op.kind == operandMem {
+			hasSize = true
+		}
+		operands = append(operands, op)
+	}
+	if hasDataSize && !hasSize {
+		// The operand size is implied by operands only.
+		return "", false
+	}
+	switch syn {
+	case syntaxGAS:
+		return gasInst(inst, opcode, prefixes, mnemonic, operands)
+	case syntaxMASM:
+		return masmInst(prefixes, mnemonic, operands)
+	}
+	return nasmInst(prefixes, mnemonic, operands)
+}
+
+// memOperand returns the given memory operand. The boolean return value
+// indicates success.
+func (s *symbolizer) memOperand(inst *x86.Inst, opcode []byte, mem x86asm.Mem, targets map[bin.Address]bool) (*operand, bool) {
+	op := &operand{kind: operandMem, scale: int(mem.Scale)}
+	if inst.Op != x86asm.LEA {
+		op.size = inst.MemBytes
+	}
+	// Force the displacement size of the original encoding; assemblers pick the
+	// shortest encoding otherwise.
+	mod, _ := modrmMod(opcode)
+	if mem.Base != 0 && mem.Base != x86asm.RIP {
+		switch {
+		case mod == 1 && mem.Disp == 0 && mem.Base != x86asm.EBP && mem.Base != x86asm.RBP && mem.Base != x86asm.R13:
+			op.force = 1
+		case mod == 2 && -0x80 <= mem.Disp && mem.Disp < 0x80:
+			op.force = 4
+		}
+	}
+	var ok bool
+	if mem.Segment != 0 {
+		if op.seg, ok = nasmReg(mem.Segment); !ok {
+			return nil, false
+		}
+	}
+	if mem.Base == x86asm.RIP {
+		// RIP-relative addressing.
+		op.rip = true
+		target := inst.Addr + bin.Address(int64(inst.Len)+mem.Disp)
+		op.disp = int64(target)
+		if label, ok := s.symbol(target); ok {
+			op.sym = label
+		}
+		return op, true
+	}
+	if mem.Base != 0 {
+		if op.base, ok = nasmReg(mem.Base); !ok {
+			return nil, false
+		}
+	}
+	if mem.Index != 0 {
+		if op.index, ok = nasmReg(mem.Index); !ok {
+			return nil, false
+		}
+	}
+	op.disp = mem.Disp
+	if inst.AddrSize == 32 {
+		op.disp = int64(int32(op.disp))
+	}
+	addr := bin.Address(uint32(op.disp))
+	if inst.AddrSize == 64 {
+		addr = bin.Address(op.disp)
+	}
+	if len(op.base) == 0 && len(op.index) == 0 {
+		op.disp = int64(addr)
+	}
+	isAbs := targets[addr] || (len(s.file.Relocs) == 0 && mem.Base == 0 && mem.Index == 0)
+	if label, ok := s.symbol(addr); ok && isAbs {
+		op.sym = label
+	}
+	// Displacements of mod 1 and 2 are present in the encoding, even if 0.
+	op.hasDisp = mod != 0
+	return op, true
+}
+
+// immOperand returns the given immediate operand.
+func (s *symbolizer) immOperand(inst *x86.Inst, opcode []byte, imm x86asm.Imm, targets map[bin.Address]bool) *operand {
+	op := &operand{kind: operandImm, disp: int64(imm)}
+	addr := bin.Address(uint32(imm))
+	if inst.Mode == 64 {
+		addr = bin.Address(imm)
+	}
+	if targets[addr] {
+		if label, ok := s.symbol(addr); ok {
+			op.sym = label
+			return op
+		}
+	}
+	// Force the immediate size of the original encoding for instructions with
+	// sign extended 8-bit immediate forms; assemblers pick the shortest
+	// encoding otherwise.
+	if -0x80 <= op.disp && op.disp < 0x80 {
+		switch opcode[0] {
+		case 0x05, 0x0D, 0x15, 0x1D, 0x25, 0x2D, 0x35, 0x3D, 0x68, 0x69, 0x81:
+			op.force = 4
+			if inst.DataSize == 16 {
+				op.force = 2
+			}
+		}
+	}
+	return op
+}
+
+// ### [ NASM syntax ] #########################################################
+
+// nasmInst returns the given instruction in NASM syntax; e.g.
+//
+//    mov     eax, dword [ebp-0x8]
+//    push    strict dword 0x1
+//    jne     short loc_4010A0
+func nasmInst(prefixes []string, mnemonic string, operands []*operand) (string, bool) {
+	var args []string
+	for _, op := range operands {
+		switch op.kind {
+		case operandReg:
+			args = append(args, op.reg)
+		case operandMem:
+			args = append(args, nasmMem(op))
+		case operandImm:
+			arg := op.sym
+			if len(arg) == 0 {
+				arg = syntaxNASM.signed(op.disp)
+			}
+			switch op.force {
+			case 2:
+				arg = "strict word " + arg
+			case 4:
+				arg = "strict dword " + arg
+			}
+			args = append(args, arg)
+		case operandRel:
+			arg := op.sym
+			if len(arg) == 0 {
+				arg = syntaxNASM.hex(uint64(op.disp), 1)
+			}
+			if len(op.jump) > 0 {
+				arg = op.jump + " " + arg
+			}
+			args = append(args, arg)
+		}
+	}
+	return joinInst(prefixes, mnemonic, args), true
+}
+
+// nasmMem returns the given memory operand in NASM syntax; e.g.
+//
+//    dword [ebp-0x8]
+//    dword [addr_40A000+eax*4]
+//    qword [rel imp_ExitProcess]
+func nasmMem(op *operand) string {
+	buf := &strings.Builder{}
+	if size, ok := nasmSizes[op.size]; ok {
+		buf.WriteString(size + " ")
+	}
+	buf.WriteString("[")
+	switch op.force {
+	case 1:
+		buf.WriteString("byte ")
+	case 4:
+		buf.WriteString("dword ")
+	}
+	if len(op.base) == 0 && len(op.index) > 0 {
+		// Prevent NASM from splitting [eax*2] into [eax+eax].
+		buf.WriteString("nosplit ")
+	}
+	if len(op.seg) > 0 {
+		buf.WriteString(op.seg + ":")
+	}
+	if op.rip {
+		buf.WriteString("rel ")
+	}
+	buf.WriteString(intelAddr(op, syntaxNASM))
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// intelAddr returns the address expression of the given memory operand in
+// Intel syntax; e.g. ebp-0x8 or addr_40A000+eax*4.
+func intelAddr(op *operand, syn syntax) string {
+	if op.rip {
+		if len(op.sym) > 0 {
+			return op.sym
+		}
+		return syn.hex(uint64(op.disp), 1)
+	}
+	buf := &strings.Builder{}
+	plus := ""
+	if len(op.base) > 0 {
+		buf.WriteString(op.base)
+		plus = "+"
+	}
+	if len(op.index) > 0 {
+		fmt.Fprintf(buf, "%s%s*%d", plus, op.index, op.scale)
+		plus = "+"
+	}
+	switch {
+	case len(op.sym) > 0:
+		buf.WriteString(plus + op.sym)
+	case len(plus) == 0:
+		buf.WriteString(syn.hex(uint64(op.disp), 1))
+	case op.disp < 0:
+		buf.WriteString(syn.signed(op.disp))
+	case op.disp > 0 || op.hasDisp:
+		buf.WriteString(plus + syn.hex(uint64(op.disp), 1))
+	}
+	return buf.String()
+}
+
+// nasmSizes maps from memory operand size in bytes to NASM size specifier.
+var nasmSizes = map[int]string{
+	1:  "byte",
+	2:  "word",
+	4:  "dword",
+	8:  "qword",
+	10: "tword",
+	16: "oword",
+	32: "yword",
+}
+
+// ### [ MASM syntax ] #########################################################
+
+// masmInst returns the given instruction in MASM syntax; e.g.
+//
+//    mov     eax, dword ptr [ebp-08h]
+//    push    offset str_4031A8
+//    jne     short loc_4010A0
+func masmInst(prefixes []string, mnemonic string, operands []*operand) (string, bool) {
+	if mnemonic == "int3" {
+		// MASM encodes int 3 as CC.
+		mnemonic = "int 3"
+	}
+	var args []string
+	for _, op := range operands {
+		switch op.kind {
+		case operandReg:
+			args = append(args, masmReg(op.reg))
+		case operandMem:
+			if op.force > 0 || (op.rip && len(op.sym) == 0) {
+				// MASM has no syntax to force the size of displacements.
+				return "", false
+			}
+			buf := &strings.Builder{}
+			if size, ok := masmSizes[op.size]; ok {
+				buf.WriteString(size + " ptr ")
+			}
+			switch {
+			case len(op.seg) > 0:
+				buf.WriteString(masmReg(op.seg) + ":")
+			case len(op.base) == 0 && len(op.index) == 0 && len(op.sym) == 0 && !op.rip:
+				// MASM treats [0403000h] as an immediate, unless prefixed by a
+				// segment register.
+				buf.WriteString("ds:")
+			}
+			fmt.Fprintf(buf, "[%s]", intelAddr(op, syntaxMASM))
+			args = append(args, buf.String())
+		case operandImm:
+			if op.force > 0 {
+				// MASM has no syntax to force the size of immediates.
+				return "", false
+			}
+			if len(op.sym) > 0 {
+				args = append(args, "offset "+op.sym)
+				continue
+			}
+			args = append(args, syntaxMASM.signed(op.disp))
+		case operandRel:
+			if len(op.sym) == 0 {
+				return "", false
+			}
+			switch op.jump {
+			case "short":
+				args = append(args, "short "+op.sym)
+			case "near":
+				args = append(args, "near ptr "+op.sym)
+			default:
+				args = append(args, op.sym)
+			}
+		}
+	}
+	return joinInst(prefixes, mnemonic, args), true
+}
+
+// masmReg returns the MASM name of the given register in NASM syntax.
+func masmReg(reg string) string {
+	if strings.HasPrefix(reg, "st") {
+		return fmt.Sprintf("st(%s)", reg[len("st"):])
+	}
+	return reg
+}
+
+// masmSizes maps from memory operand size in bytes to MASM size specifier.
+var masmSizes = map[int]string{
+	1:  "byte",
+	2:  "word",
+	4:  "dword",
+	8:  "qword",
+	10: "tbyte",
+	16: "xmmword",
+	32: "ymmword",
+}
+
+// ### [ GAS syntax ] ##########################################################
+
+// gasInst returns the given instruction in the AT&T syntax of GAS; e.g.
+//
+//    movl    -0x8(%ebp), %eax
+//    pushl   $0x1
+//    call    *imp_ExitProcess
+func gasInst(inst *x86.Inst, opcode []byte, prefixes []string, mnemonic string, operands []*operand) (string, bool) {
+	// Force the load form (e.g. 8B /r rather than 89 /r) of register to
+	// register instructions encoded using the load form; GAS picks the store
+	// form otherwise.
+	if mod, ok := modrmMod(opcode); ok && mod == 3 {
+		op := opcode[0]
+		if (op < 0x40 && op&0x06 == 0x02) || op == 0x8A || op == 0x8B {
+			prefixes = append([]string{"{load}"}, prefixes...)
+		}
+	}
+	memSize := 0
+	for _, op := range operands {
+		switch op.kind {
+		case operandMem:
+			memSize = op.size
+			switch op.force {
+			case 1:
+				prefixes = append([]string{"{disp8}"}, prefixes...)
+			case 4:
+				prefixes = append([]string{"{disp32}"}, prefixes...)
+			}
+		case operandImm:
+			if op.force > 0 {
+				// GAS has no syntax to force the size of immediates.
+				return "", false
+			}
+		}
+	}
+	mnemonic = gasMnemonic(mnemonic, false)
+	switch {
+	case inst.Op == x86asm.MOVSX || inst.Op == x86asm.MOVZX:
+		// Source and destination operand sizes; e.g. movsbl.
+		src := gasSuffixes[memSize]
+		if reg, ok := inst.Args[1].(x86asm.Reg); ok {
+			src = gasRegSuffix(reg)
+		}
+		dst := gasSuffixes[inst.DataSize/8]
+		if len(src) == 0 || len(dst) == 0 {
+			return "", false
+		}
+		mnemonic = mnemonic[:len("movs")] + src + dst
+	case strings.HasPrefix(mnemonic, "f") && memSize != 0:
+		// The suffixes of x87 instructions denote floating-point and integer
+		// operand sizes.
+		return "", false
+	case memSize != 0 && gasNeedsSuffix(inst):
+		// The operand size is not implied by register operands.
+		suffix, ok := gasSuffixes[memSize]
+		if !ok {
+			return "", false
+		}
+		mnemonic += suffix
+	}
+	var args []string
+	for i := len(operands) - 1; i >= 0; i-- {
+		op := operands[i]
+		var arg string
+		switch op.kind {
+		case operandReg:
+			arg = gasReg(op.reg)
+		case operandMem:
+			arg = gasMem(op)
+		case operandImm:
+			arg = op.sym
+			if len(arg) == 0 {
+				arg = syntaxGAS.signed(op.disp)
+			}
+			arg = "$" + arg
+		case operandRel:
+			arg = op.sym
+			if len(arg) == 0 {
+				arg = syntaxGAS.hex(uint64(op.disp), 1)
+			}
+		}
+		if (inst.Op == x86asm.CALL || inst.Op == x86asm.JMP) && op.kind != operandRel {
+			// Indirect branch.
+			arg = "*" + arg
+		}
+		args = append(args, arg)
+	}
+	return joinInst(prefixes, mnemonic, args), true
+}
+
+// gasMem returns the given memory operand in AT&T syntax; e.g.
+//
+//    -0x8(%ebp)
+//    addr_40A000(,%eax,4)
+//    imp_ExitProcess(%rip)
+func gasMem(op *operand) string {
+	buf := &strings.Builder{}
+	if len(op.seg) > 0 {
+		buf.WriteString(gasReg(op.seg) + ":")
+	}
+	switch {
+	case len(op.sym) > 0:
+		buf.WriteString(op.sym)
+	case op.rip || (len(op.base) == 0 && len(op.index) == 0):
+		buf.WriteString(syntaxGAS.hex(uint64(op.disp), 1))
+	case op.disp != 0 || op.hasDisp:
+		buf.WriteString(syntaxGAS.signed(op.disp))
+	}
+	switch {
+	case op.rip:
+		buf.WriteString("(%rip)")
+	case len(op.index) > 0:
+		fmt.Fprintf(buf, "(%s,%s,%d)", gasReg(op.base), gasReg(op.index), op.scale)
+	case len(op.base) > 0:
+		fmt.Fprintf(buf, "(%s)", gasReg(op.base))
+	}
+	return buf.String()
+}
+
+// gasReg returns the AT&T name of the given register in NASM syntax; or empty
+// if reg is empty.
+func gasReg(reg string) string {
+	switch {
+	case len(reg) == 0:
+		return ""
+	case strings.HasPrefix(reg, "st"):
+		return fmt.Sprintf("%%st(%s)", reg[len("st"):])
+	}
+	return "%" + reg
+}
+
+// gasRegSuffix returns the operand size suffix of the given general purpose
+// register; e.g. "b" for AL.
+func gasRegSuffix(reg x86asm.Reg) string {
+	switch {
+	case x86asm.AL <= reg && reg <= x86asm.R15B:
+		return "b"
+	case x86asm.AX <= reg && reg <= x86asm.R15W:
+		return "w"
+	case x86asm.EAX <= reg && reg <= x86asm.R15L:
+		return "l"
+	case x86asm.RAX <= reg && reg <= x86asm.R15:
+		return "q"
+	}
+	return ""
+}
+
+// gasNeedsSuffix reports whether the given instruction with a memory operand
+// requires an operand size suffix in AT&T syntax; i.e. whether the operand size
+// is not implied by register operands.
+func gasNeedsSuffix(inst *x86.Inst) bool {
+	switch inst.Op {
+	case x86asm.PUSH, x86asm.POP, x86asm.INC, x86asm.DEC, x86asm.NOT, x86asm.NEG, x86asm.MUL, x86asm.DIV, x86asm.IDIV, x86asm.NOP:
+		return true
+	case x86asm.SHL, x86asm.SHR, x86asm.SAR, x86asm.ROL, x86asm.ROR, x86asm.RCL, x86asm.RCR:
+		// The shift count (CL or immediate) does not imply the operand size.
+		return true
+	case x86asm.IMUL:
+		return inst.Args[1] == nil
+	}
+	for _, arg := range inst.Args {
+		if _, ok := arg.(x86asm.Imm); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// gasSuffixes maps from operand size in bytes to AT&T mnemonic suffix.
+var gasSuffixes = map[int]string{
+	1: "b",
+	2: "w",
+	4: "l",
+	8: "q",
+}
+
+// gasMnemonic returns the AT&T mnemonic corresponding to the given NASM
+// mnemonic of a string instruction (if stringOp is set) or other instruction.
+func gasMnemonic(mnemonic string, stringOp bool) string {
+	if stringOp {
+		m := map[string]string{
+			"cmpsd": "cmpsl",
+			"insd":  "insl",
+			"lodsd": "lodsl",
+			"movsd": "movsl",
+			"outsd": "outsl",
+			"scasd": "scasl",
+			"stosd": "stosl",
+		}
+		if s, ok := m[mnemonic]; ok {
+			return s
+		}
+		return mnemonic
+	}
+	m := map[string]string{
+		"cbw":    "cbtw",
+		"cwd":    "cwtd",
+		"cwde":   "cwtl",
+		"cdq":    "cltd",
+		"cdqe":   "cltq",
+		"cqo":    "cqto",
+		"retf":   "lret",
+		"movsxd": "movslq",
+		"pushad": "pushal",
+		"popad":  "popal",
+		"pushfd": "pushfl",
+		"popfd":  "popfl",
+		"iretd":  "iretl",
+	}
+	if s, ok := m[mnemonic]; ok {
+		return s
+	}
+	return mnemonic
+}
+
+// ### [ Helper functions ] ####################################################
+
+// joinInst joins the prefixes, mnemonic and operands of an instruction.
+func joinInst(prefixes []string, mnemonic string, args []string) string {
+	text := strings.Join(append(prefixes, mnemonic), " ")
+	if len(args) > 0 {
+		text = fmt.Sprintf("%-7s %s", text, strings.Join(args, ", "))
+	}
+	return text
+}
+
+// nasmMnemonic returns the NASM mnemonic of the given instruction. The boolean
+// return value indicates success.
+func nasmMnemonic(inst *x86.Inst, opcode []byte) (string, bool) {
+	switch inst.Op {
+	case x86asm.INT:
+		// Distinguish int3 (CC) from int 3 (CD 03).
+		if opcode[0] == 0xCC {
+			return "int3", true
+		}
+	case x86asm.LRET:
+		return "retf", true
+	case x86asm.LCALL, x86asm.LJMP:
+		return "", false
+	case x86asm.MOVSD_XMM:
+		return "movsd", true
+	case x86asm.CMPSD_XMM:
+		return "cmpsd", true
+	}
+	s := inst.Op.String()
+	if len(s) == 0 || strings.HasPrefix(s, "Op(") || strings.Contains(s, "_") {
+		return "", false
+	}
+	return strings.ToLower(s), true
+}
+
+// nasmReg returns the NASM name of the given register. The boolean return
+// value indicates success.
+func nasmReg(reg x86asm.Reg) (string, bool) {
+	switch {
+	case x86asm.SPB <= reg && reg <= x86asm.DIB:
+		return [...]string{"spl", "bpl", "sil", "dil"}[reg-x86asm.SPB], true
+	case x86asm.R8L <= reg && reg <= x86asm.R15L:
+		return fmt.Sprintf("r%dd", 8+int(reg-x86asm.R8L)), true
+	case x86asm.F0 <= reg && reg <= x86asm.F7:
+		return fmt.Sprintf("st%d", int(reg-x86asm.F0)), true
+	case x86asm.M0 <= reg && reg <= x86asm.M7:
+		return fmt.Sprintf("mm%d", int(reg-x86asm.M0)), true
+	case x86asm.X0 <= reg && reg <= x86asm.X15:
+		return fmt.Sprintf("xmm%d", int(reg-x86asm.X0)), true
+	case x86asm.AL <= reg && reg <= x86asm.R15:
+		return strings.ToLower(reg.String()), true
+	case x86asm.ES <= reg && reg <= x86asm.GS:
+		return strings.ToLower(reg.String()), true
+	case x86asm.CR0 <= reg && reg <= x86asm.DR15:
+		return strings.ToLower(reg.String()), true
+	}
+	return "", false
+}
+
+// modrmMod returns the mod field of the ModR/M byte following the given
+// opcode. The boolean return value indicates success.
+func modrmMod(opcode []byte) (byte, bool) {
+	n := 1
+	if opcode[0] == 0x0F {
+		n = 2
+		if len(opcode) > 1 && (opcode[1] == 0x38 || opcode[1] == 0x3A) {
+			n = 3
+		}
+	}
+	if len(opcode) <= n {
+		return 0, false
+	}
+	return opcode[n] >> 6, true
+}
+
+// isStringOp reports whether the given operation is a string instruction.
+func isStringOp(op x86asm.Op) bool {
+	switch op {
+	case x86asm.CMPSB, x86asm.CMPSW, x86asm.CMPSD, x86asm.CMPSQ,
+		x86asm.INSB, x86asm.INSW, x86asm.INSD,
+		x86asm.LODSB, x86asm.LODSW, x86asm.LODSD, x86asm.LODSQ,
+		x86asm.MOVSB, x86asm.MOVSW, x86asm.MOVSD, x86asm.MOVSQ,
+		x86asm.OUTSB, x86asm.OUTSW, x86asm.OUTSD,
+		x86asm.SCASB, x86asm.SCASW, x86asm.SCASD, x86asm.SCASQ,
+		x86asm.STOSB, x86asm.STOSW, x86asm.STOSD, x86asm.STOSQ:
+		return true
+	}
+	return false
+}
+
+// isCondJump reports whether the given operation is a conditional jump with
+// short and near forms.
+func isCondJump(op x86asm.Op) bool {
+	switch op {
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JE, x86asm.JG,
+		x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP,
+		x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JS:
+		return true
+	}
+	return false
+}
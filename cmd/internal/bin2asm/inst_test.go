@@ -0,0 +1,85 @@
+package bin2asm
+
+import (
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+func TestFormatInst(t *testing.T) {
+	golden := []struct {
+		// Machine code of the instruction, located at 0x401020.
+		raw []byte
+		// Processor mode.
+		mode int
+		// Instruction in NASM, GAS and MASM syntax; or empty if dumped as raw
+		// bytes.
+		nasm, gas, masm string
+	}{
+		// int3 (CC) and int 3 (CD 03).
+		{raw: []byte{0xCC}, mode: 32, nasm: "int3", gas: "int3", masm: "int 3"},
+		{raw: []byte{0xCD, 0x03}, mode: 32, nasm: "int     0x3", gas: "int     $0x3", masm: "int     03h"},
+		// One-byte and multi-byte nops.
+		{raw: []byte{0x90}, mode: 32, nasm: "nop", gas: "nop", masm: "nop"},
+		{raw: []byte{0x0F, 0x1F, 0x00}, mode: 32, nasm: "nop     dword [eax]", gas: "nopl    (%eax)", masm: "nop     dword ptr [eax]"},
+		{raw: []byte{0x0F, 0x1F, 0x40, 0x00}, mode: 32, nasm: "nop     dword [byte eax+0x0]", gas: "{disp8} nopl 0x0(%eax)", masm: ""},
+		// Load and store forms of register to register moves.
+		{raw: []byte{0x8B, 0xC1}, mode: 32, nasm: "mov     eax, ecx", gas: "{load} mov %ecx, %eax", masm: "mov     eax, ecx"},
+		{raw: []byte{0x89, 0xC8}, mode: 32, nasm: "mov     eax, ecx", gas: "mov     %ecx, %eax", masm: "mov     eax, ecx"},
+		// Memory operands.
+		{raw: []byte{0x8B, 0x45, 0xF8}, mode: 32, nasm: "mov     eax, dword [ebp-0x8]", gas: "mov     -0x8(%ebp), %eax", masm: "mov     eax, dword ptr [ebp-08h]"},
+		{raw: []byte{0xFF, 0x30}, mode: 32, nasm: "push    dword [eax]", gas: "pushl   (%eax)", masm: "push    dword ptr [eax]"},
+		// Immediates of 8-bit and 32-bit encodings.
+		{raw: []byte{0x6A, 0x01}, mode: 32, nasm: "push    0x1", gas: "push    $0x1", masm: "push    01h"},
+		{raw: []byte{0x68, 0x01, 0x00, 0x00, 0x00}, mode: 32, nasm: "push    strict dword 0x1", gas: "", masm: ""},
+		// Branches to labeled functions and basic blocks.
+		{raw: []byte{0xEB, 0xEE}, mode: 32, nasm: "jmp     short loc_401010", gas: "jmp     loc_401010", masm: "jmp     short loc_401010"},
+		{raw: []byte{0xE8, 0xDB, 0xFF, 0xFF, 0xFF}, mode: 32, nasm: "call    sub_401000", gas: "call    sub_401000", masm: "call    sub_401000"},
+		// String instructions.
+		{raw: []byte{0xF3, 0xA4}, mode: 32, nasm: "rep movsb", gas: "rep movsb", masm: "rep movsb"},
+		{raw: []byte{0xA5}, mode: 32, nasm: "movsd", gas: "movsl", masm: "movsd"},
+		{raw: []byte{0xC3}, mode: 32, nasm: "ret", gas: "ret", masm: "ret"},
+	}
+	file := &bin.File{
+		Format: "pe",
+		Arch:   bin.ArchX86_32,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Offset: 0x400, Data: make([]byte, 0x40), FileSize: 0x200, MemSize: 0x40, Perm: bin.PermR | bin.PermX},
+		},
+	}
+	funcs := map[bin.Address]*x86.Func{
+		0x401000: {Addr: 0x401000},
+	}
+	blocks := map[bin.Address]*x86.BasicBlock{
+		0x401000: {Addr: 0x401000},
+		0x401010: {Addr: 0x401010},
+	}
+	for _, g := range golden {
+		in, err := x86asm.Decode(g.raw, g.mode)
+		if err != nil {
+			t.Errorf("% X: unable to decode instruction; %v", g.raw, err)
+			continue
+		}
+		inst := &x86.Inst{Addr: 0x401020, Inst: in}
+		insts := map[bin.Address]*x86.Inst{inst.Addr: inst}
+		syms := newSymbolizer(file, nil, funcs, blocks, insts, nil)
+		for _, want := range []struct {
+			syn  syntax
+			text string
+		}{
+			{syn: syntaxNASM, text: g.nasm},
+			{syn: syntaxGAS, text: g.gas},
+			{syn: syntaxMASM, text: g.masm},
+		} {
+			got, ok := syms.formatInst(inst, g.raw, want.syn)
+			if !ok {
+				got = ""
+			}
+			if got != want.text {
+				t.Errorf("% X (%v): instruction mismatch; expected %q, got %q", g.raw, want.syn, want.text, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Package bin2asm implements the bin2asm tool, which disassembles binary
+// executables (*.exe, *.elf -> *.asm).
+package bin2asm
+
+import (
+	debugpe "debug/pe"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/decomp/exp/bin"
+	binpe "github.com/decomp/exp/bin/pe"
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/mewkiz/pkg/term"
+	"github.com/mewrev/pe"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "bin2asm:" prefix, which logs debug
+	// messages to standard error.
+	dbg = log.New(os.Stderr, term.YellowBold("bin2asm:")+" ", 0)
+	// warn represents a logger with the "bin2asm:" prefix, which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("bin2asm:")+" ", 0)
+)
+
+// Main runs the bin2asm tool with the given command name (e.g. "decomp asm")
+// and command line arguments.
+func Main(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// sel specifies the functions to disassemble.
+		sel cli.FuncSelector
+		// loader specifies how to parse the binary executable.
+		loader cli.Loader
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+		// rawCode specifies whether to dump code as raw bytes rather than
+		// disassembled instructions.
+		rawCode bool
+		// stripCert specifies whether to strip the certificate table (e.g.
+		// Authenticode signature) of PE files.
+		stripCert bool
+		// verify specifies whether to reassemble the dumped assembly using NASM,
+		// and verify that the output is byte-identical to the original
+		// executable.
+		verify bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+Disassemble binary executables (*.exe -> *.asm).
+
+Usage:
+
+	%s [OPTION]... FILE
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flags.BoolVar(&rawCode, "rawcode", false, "dump code as raw bytes (byte-exact) rather than disassembled instructions")
+	flags.Var(&outSyntax, "syntax", "assembly syntax of output (nasm, gas or masm)")
+	flags.BoolVar(&stripCert, "stripcert", false, "strip certificate table (Authenticode signature) of PE files")
+	flags.BoolVar(&verify, "verify", false, "reassemble the output using NASM and compare against the original executable (PE only)")
+	sel.RegisterFlags(flags, "disassemble")
+	loader.RegisterFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	binPath := flags.Arg(0)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Prepare disassembler for the binary executable.
+	file, err := loader.ParseFile(binPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if loader.Project != nil {
+		sel.Exclude = loader.Project.Exclude
+	}
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	userNames = dis.Names
+	// Disassemble basic block.
+	if sel.Block != 0 {
+		block, err := dis.DecodeBlock(sel.Block)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		_ = block
+		return
+	}
+
+	// Disassemble functions.
+	var fs []*x86.Func
+	for _, funcAddr := range sel.Select(dis.FuncAddrs) {
+		f, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		fs = append(fs, f)
+	}
+
+	// Create output directory.
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Dump output.
+	switch {
+	case outSyntax != syntaxNASM:
+		// Dump sections in GAS or MASM syntax.
+		if err := dumpOtherSyntax(binPath, dis.File, fs, rawCode); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	case dis.File.Format == "pe":
+		// Dump PE file in NASM syntax.
+		if err := dumpPE(binPath, dis.File, fs, rawCode, stripCert, verify); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	case dis.File.Format == "elf":
+		// Dump ELF file in NASM syntax.
+		if err := dumpELF(binPath, dis.File, fs, rawCode); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	default:
+		// Headers are only dumped for PE and ELF files.
+		if err := dumpSections(dis.File, fs, rawCode); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	if verify {
+		warn.Printf("unable to verify output of %q; reassembly only supported for PE files in NASM syntax", binPath)
+	}
+}
+
+// dumpPE dumps the given PE file in NASM syntax; i.e. the main.asm file, the
+// common include file, the PE header, the sections, resources, overlay and
+// certificate table. The certificate table is stripped if stripCert is set. The
+// output is reassembled and compared against the original executable if
+// verify is set.
+func dumpPE(binPath string, file *bin.File, fs []*x86.Func, rawCode, stripCert, verify bool) error {
+	// Parse PE file.
+	peFile, err := pe.Open(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer peFile.Close()
+	// Parse optional header of PE32+ files.
+	opt64, err := parseOptHeader64(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	overlay := file.Overlay
+	hasOverlay := overlay != nil
+
+	// Parse certificate table. Certificate tables located at the end of file
+	// are dumped separately from the overlay, and may thus be stripped.
+	certTable, err := parseCertTable(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var hasCert bool
+	if certTable != nil {
+		switch {
+		case !certTable.AtEOF:
+			if stripCert {
+				warn.Printf("unable to strip certificate table at file offset 0x%X; not located at end of file", certTable.Offset)
+			}
+			// Preserved as part of the overlay.
+			stripCert = false
+		case !stripCert:
+			hasCert = true
+		}
+	} else {
+		stripCert = false
+	}
+
+	// Dump main file.
+	if err := dumpMainAsm(peFile, opt64, hasOverlay, hasCert); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Dump common include file.
+	if err := dumpCommon(peFile, opt64, hasOverlay, hasCert); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Parse Rich header.
+	rich, err := parseRichHeader(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Dump PE header in NASM syntax.
+	if err := dumpPEHeaderAsm(peFile, opt64, rich, hasCert, stripCert); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Dump sections in NASM syntax.
+	if err := dumpSections(file, fs, rawCode); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Dump resources as resource script.
+	if err := dumpResources(file); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Dump overlay.
+	if hasOverlay {
+		if err := dumpOverlay(overlay); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// Dump certificate table.
+	if hasCert {
+		if err := dumpCertTable(binPath, certTable); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// Verify reassembled executable.
+	if verify {
+		switch {
+		case stripCert:
+			warn.Printf("unable to verify output of %q; certificate table stripped", binPath)
+		case !rawCode:
+			// Instructions may be reassembled using different encodings.
+			warn.Printf("verifying disassembled code of %q; use -rawcode for byte-identical output", binPath)
+			fallthrough
+		default:
+			if err := verifyAsm(binPath, file); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// dumpOtherSyntax dumps the sections, main file and common include file of the
+// given binary executable in the GAS or MASM assembly syntax of the output.
+// Tables of PE files are labeled, but headers, resources, overlay and
+// certificate table are only dumped in NASM syntax; as are the headers and
+// non-allocated sections of ELF files.
+func dumpOtherSyntax(binPath string, file *bin.File, fs []*x86.Func, rawCode bool) error {
+	if file.Format == "elf" {
+		// Sections not occupying memory during execution are only dumped in NASM
+		// syntax.
+		_, _, sects, err := parseELFHeaders(binPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		file, _ = splitAllocSections(file, sects)
+	}
+	if file.Format == "pe" {
+		warn.Printf("headers of %q are only dumped in NASM syntax", binPath)
+	}
+	if err := dumpSections(file, fs, rawCode); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := dumpMainSyntax(file); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// outDir specifies the output direcotry.
+const outDir = "_dump_"
+
+// userNames specifies the user-supplied symbol names and comments of
+// names.json, indexed by address; dumped as comments of the output.
+var userNames map[bin.Address]*disasm.Name
+
+// parseOptHeader64 parses the optional header of the given PE32+ file, the
+// 64-bit fields of which are not supported by the PE parser. A nil optional
+// header is returned for PE32 files.
+func parseOptHeader64(binPath string) (*debugpe.OptionalHeader64, error) {
+	f, err := debugpe.Open(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	opt64, _ := f.OptionalHeader.(*debugpe.OptionalHeader64)
+	return opt64, nil
+}
+
+// parseRichHeader parses the Rich header of the given PE file. A nil Rich
+// header is returned if not present.
+func parseRichHeader(binPath string) (*binpe.RichHeader, error) {
+	f, err := os.Open(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	rich, err := binpe.ParseRichHeader(f)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if rich != nil {
+		for _, entry := range rich.Entries {
+			dbg.Printf("Rich header: %s (build %d), %d objects\n", entry.Product(), entry.Build, entry.Count)
+		}
+	}
+	return rich, nil
+}
@@ -0,0 +1,50 @@
+package bin2asm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// dumpOverlay dumps the overlay of the PE file in NASM syntax. The overlay
+// contents are stored in overlay.bin, and included verbatim from overlay.asm
+// to preserve the data appended to the executable (e.g. installer payloads)
+// when reassembled.
+func dumpOverlay(overlay *bin.Overlay) error {
+	// Store overlay contents.
+	binPath := filepath.Join(outDir, "overlay.bin")
+	dbg.Printf("creating %q\n", binPath)
+	if err := ioutil.WriteFile(binPath, overlay.Data, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	// Dump overlay.
+	//
+	//    ; <overlay>
+	//    ;
+	//    ;    file offset:    0x00012000
+	//    ;    size:           0x00003400
+	//
+	//    SECTION overlay
+	//
+	//    incbin 'overlay.bin'
+	const overlayFormat = `
+; <overlay>
+;
+;    file offset:    0x%08X
+;    size:           0x%08X
+
+SECTION overlay
+
+incbin 'overlay.bin'
+`
+	buf := fmt.Sprintf(overlayFormat[1:], overlay.Offset, len(overlay.Data))
+	outPath := filepath.Join(outDir, "overlay.asm")
+	dbg.Printf("creating %q\n", outPath)
+	if err := ioutil.WriteFile(outPath, []byte(buf), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
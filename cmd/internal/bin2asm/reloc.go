@@ -0,0 +1,153 @@
+package bin2asm
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+)
+
+// Base relocation types.
+//
+// ref: https://docs.microsoft.com/en-us/windows/win32/debug/pe-format#base-relocation-types
+var relocTypeNames = map[uint16]string{
+	0:  "IMAGE_REL_BASED_ABSOLUTE",
+	3:  "IMAGE_REL_BASED_HIGHLOW",
+	10: "IMAGE_REL_BASED_DIR64",
+}
+
+// relocItems adds data items of the base relocation table of the given PE file
+// to items; i.e. the IMAGE_BASE_RELOCATION blocks. In NASM syntax, entries of
+// blocks refer to the fixed-up locations by label, so that the base relocation
+// table is preserved when the code or data of the sections is relocated.
+// Fixed-up locations of non-executable sections are labeled by reloc_XXXXXX
+// labels, which are added to labels; while fixed-up locations of executable
+// sections are referred to relative to the addr_XXXXXX label of the
+// instruction covering them.
+//
+//    struct IMAGE_BASE_RELOCATION {
+//       uint32 PageRVA;
+//       uint32 BlockSize; // including header
+//       uint16 Entries[]; // type (4 bits), page offset (12 bits)
+//    };
+func relocItems(file *bin.File, insts map[bin.Address]*x86.Inst, labels map[bin.Address][]string, items map[bin.Address]*dataItem) error {
+	table, ok := file.Tables[bin.TableReloc]
+	if !ok {
+		return nil
+	}
+	t := newTableDumper(file, items)
+	start := table.Addr
+	end := start + bin.Address(table.Size)
+	for block := start; block+8 <= end; {
+		pageRVA, err := file.ReadUint32(block)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		blockSize, err := file.ReadUint32(block + 4)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if blockSize < 8 || block+bin.Address(blockSize) > end {
+			return errors.Errorf("invalid base relocation block at %v; block size %d", block, blockSize)
+		}
+		item := &dataItem{size: int(blockSize)}
+		item.label(t.label(fmt.Sprintf("reloc_block_%08X", pageRVA)), "IMAGE_BASE_RELOCATION")
+		item.directive("dd", fmt.Sprintf("0x%08X", pageRVA), "PageRVA")
+		item.directive("dd", fmt.Sprintf("0x%08X", blockSize), "BlockSize")
+		for entry := block + 8; entry+2 <= block+bin.Address(blockSize); entry += 2 {
+			v, err := file.ReadUint16(entry)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			typ := v >> 12
+			typeName, ok := relocTypeNames[typ]
+			if !ok {
+				typeName = fmt.Sprintf("type %d", typ)
+			}
+			if typ == 0 {
+				// Padding.
+				item.directive("dw", fmt.Sprintf("0x%04X", v), typeName)
+				continue
+			}
+			addr := file.ImageBase + bin.Address(pageRVA) + bin.Address(v&0xFFF)
+			target, ok := relocTarget(file, insts, labels, addr)
+			if !ok {
+				// Fixed-up location outside of the raw data of sections.
+				item.directive("dw", fmt.Sprintf("0x%04X", v), typeName)
+				continue
+			}
+			if outSyntax != syntaxNASM {
+				// Label arithmetic of 16-bit fields is resolved at link time by
+				// GAS and MASM, and may overflow the field in object files.
+				item.directive("dw", fmt.Sprintf("0x%04X", v), fmt.Sprintf("%s (%s)", typeName, target))
+				continue
+			}
+			// Type in the high 4 bits, and offset within page in the low 12
+			// bits.
+			operand := fmt.Sprintf("0x%04X + (%s - IMAGE_BASE - 0x%08X)", typ<<12, target, pageRVA)
+			item.directive("dw", operand, typeName)
+		}
+		items[block] = item
+		block += bin.Address(blockSize)
+	}
+	return nil
+}
+
+// relocTarget returns the label expression of the fixed-up location at the
+// given address; e.g. reloc_403010 or addr_401012 + 2. Labels of fixed-up
+// locations of non-executable sections are added to labels. The boolean return
+// value indicates success.
+func relocTarget(file *bin.File, insts map[bin.Address]*x86.Inst, labels map[bin.Address][]string, addr bin.Address) (string, bool) {
+	sect, ok := dataSection(file, addr)
+	if !ok {
+		return "", false
+	}
+	if sect.Perm&bin.PermX == 0 {
+		label := fmt.Sprintf("reloc_%06X", uint64(addr))
+		if !hasLabel(labels[addr], label) {
+			labels[addr] = append(labels[addr], label+":\n")
+		}
+		return label, true
+	}
+	// Locate the instruction covering the fixed-up location. Bytes of
+	// executable sections not covered by instructions are labeled individually.
+	const maxInstLen = 15
+	for offset := 0; offset < maxInstLen && sect.Addr+bin.Address(offset) <= addr; offset++ {
+		instAddr := addr - bin.Address(offset)
+		inst, ok := insts[instAddr]
+		if !ok || offset >= inst.Len {
+			continue
+		}
+		if offset == 0 {
+			return fmt.Sprintf("addr_%06X", uint64(instAddr)), true
+		}
+		return fmt.Sprintf("addr_%06X + %d", uint64(instAddr), offset), true
+	}
+	return fmt.Sprintf("addr_%06X", uint64(addr)), true
+}
+
+// dataSection returns the section containing raw data at the given address.
+// The boolean return value indicates success.
+func dataSection(file *bin.File, addr bin.Address) (*bin.Section, bool) {
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 {
+			// Ignore segments.
+			continue
+		}
+		if sect.Addr <= addr && addr < sect.Addr+bin.Address(len(sect.Data)) {
+			return sect, true
+		}
+	}
+	return nil, false
+}
+
+// hasLabel reports whether the given label lines contain the label.
+func hasLabel(lines []string, label string) bool {
+	for _, line := range lines {
+		if line == label+":\n" {
+			return true
+		}
+	}
+	return false
+}
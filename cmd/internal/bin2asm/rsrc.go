@@ -0,0 +1,840 @@
+package bin2asm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/decomp/exp/bin"
+	binpe "github.com/decomp/exp/bin/pe"
+	"github.com/pkg/errors"
+)
+
+// rsrcDir specifies the output directory of the resource script and extracted
+// resource payloads.
+var rsrcDir = filepath.Join(outDir, "rsrc")
+
+// dumpResources dumps the resources of the given PE file as a resource script
+// (rsrc/resource.rc), which may be compiled by a resource compiler (e.g. rc or
+// windres) to reconstruct the resource section. Version information, string
+// tables and dialogs are decoded into resource statements, while icons,
+// cursors, bitmaps, manifests and other resources are extracted into binary
+// payload files referenced by the resource script.
+func dumpResources(file *bin.File) error {
+	table, ok := file.Tables[bin.TableResource]
+	if !ok {
+		// No resource directory.
+		return nil
+	}
+	rs, err := binpe.ParseResources(file, table.Addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(rsrcDir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Index icon and cursor images, referenced by icon and cursor groups.
+	icons := make(map[uint32]*binpe.Resource)
+	cursors := make(map[uint32]*binpe.Resource)
+	for _, r := range rs {
+		if len(r.TypeName) > 0 || len(r.Name) > 0 {
+			continue
+		}
+		switch r.Type {
+		case binpe.RTIcon:
+			icons[r.ID] = r
+		case binpe.RTCursor:
+			cursors[r.ID] = r
+		}
+	}
+	// Icon and cursor images are regenerated by the resource compiler from the
+	// icon and cursor files of groups.
+	grouped := make(map[*binpe.Resource]bool)
+	for _, r := range rs {
+		if len(r.TypeName) > 0 {
+			continue
+		}
+		switch r.Type {
+		case binpe.RTGroupIcon:
+			markGroup(r, icons, grouped)
+		case binpe.RTGroupCursor:
+			markGroup(r, cursors, grouped)
+		}
+	}
+
+	// Dump resource script.
+	buf := &bytes.Buffer{}
+	buf.WriteString("// Resource script; paths are relative to the directory of the script.\n\n")
+	buf.WriteString("#pragma code_page(65001)\n")
+	lang := ^uint32(0)
+	for _, r := range rs {
+		if grouped[r] {
+			continue
+		}
+		if r.Lang != lang {
+			// Primary and sublanguage ID of language.
+			fmt.Fprintf(buf, "\nLANGUAGE 0x%02X, 0x%02X\n", r.Lang&0x3FF, r.Lang>>10)
+			lang = r.Lang
+		}
+		buf.WriteString("\n")
+		if err := dumpResource(buf, r, icons, cursors); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	outPath := filepath.Join(rsrcDir, "resource.rc")
+	dbg.Printf("creating %q\n", outPath)
+	if err := ioutil.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// markGroup marks the images referenced by the given icon or cursor group.
+func markGroup(group *binpe.Resource, images map[uint32]*binpe.Resource, grouped map[*binpe.Resource]bool) {
+	entries, err := parseGroupDir(group.Data, group.Type == binpe.RTGroupCursor)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if img, ok := images[uint32(entry.id)]; ok {
+			grouped[img] = true
+		}
+	}
+}
+
+// dumpResource dumps the resource statement of the given resource. Resources
+// which fail to decode are extracted verbatim.
+func dumpResource(buf *bytes.Buffer, r *binpe.Resource, icons, cursors map[uint32]*binpe.Resource) error {
+	name := rcName(r.ID, r.Name)
+	if len(r.TypeName) == 0 {
+		var (
+			b   []byte
+			err error
+		)
+		switch r.Type {
+		case binpe.RTVersion:
+			b, err = versionInfo(name, r.Data)
+		case binpe.RTString:
+			if len(r.Name) == 0 {
+				b, err = stringTable(r.ID, r.Data)
+			} else {
+				err = errors.Errorf("invalid string table name %q; expected block ID", r.Name)
+			}
+		case binpe.RTDialog:
+			b, err = dialog(name, r.Data)
+		case binpe.RTManifest:
+			return dumpPayload(buf, r, name, "24", "manifest", ".xml", r.Data)
+		case binpe.RTBitmap:
+			b, err = bmpFile(r.Data)
+			if err == nil {
+				return dumpPayload(buf, r, name, "BITMAP", "bitmap", ".bmp", b)
+			}
+		case binpe.RTGroupIcon:
+			b, err = iconFile(r.Data, icons, false)
+			if err == nil {
+				return dumpPayload(buf, r, name, "ICON", "icon", ".ico", b)
+			}
+		case binpe.RTGroupCursor:
+			b, err = iconFile(r.Data, cursors, true)
+			if err == nil {
+				return dumpPayload(buf, r, name, "CURSOR", "cursor", ".cur", b)
+			}
+		default:
+			return dumpRaw(buf, r, name)
+		}
+		if err == nil {
+			buf.Write(b)
+			return nil
+		}
+		warn.Printf("unable to decode resource %s of type %d; %v", name, r.Type, err)
+	}
+	return dumpRaw(buf, r, name)
+}
+
+// dumpRaw extracts the contents of the given resource verbatim, and dumps a
+// user-defined resource statement of the resource.
+func dumpRaw(buf *bytes.Buffer, r *binpe.Resource, name string) error {
+	typ := rcName(r.Type, r.TypeName)
+	kind := fmt.Sprintf("type_%s", fileName(r.Type, r.TypeName))
+	if len(r.TypeName) == 0 && r.Type == binpe.RTRCData {
+		typ = "RCDATA"
+		kind = "rcdata"
+	}
+	return dumpPayload(buf, r, name, typ, kind, ".bin", r.Data)
+}
+
+// dumpPayload stores the given payload of the resource in a file, and dumps a
+// resource statement referencing the file.
+//
+//    1 ICON "icon_1_0409.ico"
+func dumpPayload(buf *bytes.Buffer, r *binpe.Resource, name, typ, kind, ext string, data []byte) error {
+	filename := fmt.Sprintf("%s_%s_%04X%s", kind, fileName(r.ID, r.Name), r.Lang, ext)
+	outPath := filepath.Join(rsrcDir, filename)
+	dbg.Printf("creating %q\n", outPath)
+	if err := ioutil.WriteFile(outPath, data, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	fmt.Fprintf(buf, "%s %s %s\n", name, typ, rcQuote(filename))
+	return nil
+}
+
+// rcName returns the resource name (or type) of the given ID or name in
+// resource script syntax.
+func rcName(id uint32, name string) string {
+	if len(name) == 0 {
+		return fmt.Sprintf("%d", id)
+	}
+	for i, r := range name {
+		if !(r == '_' || 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z' || i > 0 && '0' <= r && r <= '9') {
+			return rcQuote(name)
+		}
+	}
+	return name
+}
+
+// fileName returns a file name component of the given ID or name.
+func fileName(id uint32, name string) string {
+	if len(name) == 0 {
+		return fmt.Sprintf("%d", id)
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// rcQuote returns the given string as a quoted string literal of resource
+// scripts.
+func rcQuote(s string) string {
+	buf := &strings.Builder{}
+	buf.WriteString(`"`)
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`""`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\%03o`, r)
+				continue
+			}
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteString(`"`)
+	return buf.String()
+}
+
+// ### [ Version information ] #################################################
+
+// A versionNode is a node of the version information tree; i.e. the
+// VS_VERSIONINFO root, StringFileInfo and VarFileInfo blocks, string tables,
+// and their values.
+//
+//    struct VS_VERSIONINFO {
+//       uint16 wLength;      // including children
+//       uint16 wValueLength; // in words for text values; bytes otherwise
+//       uint16 wType;        // 1 for text values; 0 for binary values
+//       WCHAR  szKey[];
+//       uint16 Padding1[];   // DWORD alignment
+//       uint8  Value[];
+//       uint16 Padding2[];   // DWORD alignment
+//       Children[];
+//    };
+type versionNode struct {
+	// Node key (e.g. "CompanyName").
+	key string
+	// Value is text.
+	isText bool
+	// Node value.
+	value []byte
+	// Child nodes.
+	children []*versionNode
+}
+
+// parseVersionNode parses the version information node at the current position
+// of the reader.
+func parseVersionNode(r *rsrcReader) *versionNode {
+	start := r.pos
+	length := int(r.uint16())
+	valueLength := int(r.uint16())
+	typ := r.uint16()
+	if r.err == nil && (length < 6 || start+length > len(r.data)) {
+		r.err = errors.Errorf("invalid version information node length %d at offset 0x%X", length, start)
+	}
+	end := start + length
+	node := &versionNode{
+		key:    r.sz(),
+		isText: typ == 1,
+	}
+	r.align(4)
+	if node.isText {
+		valueLength *= 2
+	}
+	if r.pos+valueLength > end {
+		// Some linkers specify the length of text values in bytes.
+		valueLength = end - r.pos
+	}
+	node.value = r.bytes(valueLength)
+	r.align(4)
+	for r.err == nil && r.pos < end {
+		node.children = append(node.children, parseVersionNode(r))
+		r.align(4)
+	}
+	return node
+}
+
+// versionInfo returns the VERSIONINFO statement of the given version
+// information resource.
+//
+//    1 VERSIONINFO
+//    FILEVERSION 1,0,0,1
+//    PRODUCTVERSION 1,0,0,1
+//    FILEFLAGSMASK 0x3F
+//    FILEFLAGS 0x0
+//    FILEOS 0x40004
+//    FILETYPE 0x1
+//    FILESUBTYPE 0x0
+//    BEGIN
+//       BLOCK "StringFileInfo"
+//       BEGIN
+//          BLOCK "040904B0"
+//          BEGIN
+//             VALUE "CompanyName", "Foo"
+//          END
+//       END
+//       BLOCK "VarFileInfo"
+//       BEGIN
+//          VALUE "Translation", 0x0409, 0x04B0
+//       END
+//    END
+func versionInfo(name string, data []byte) ([]byte, error) {
+	r := &rsrcReader{data: data}
+	root := parseVersionNode(r)
+	if r.err != nil {
+		return nil, errors.WithStack(r.err)
+	}
+	// VS_FIXEDFILEINFO structure.
+	var fixed struct {
+		Signature      uint32
+		StrucVersion   uint32
+		FileVersion    [2]uint32
+		ProductVersion [2]uint32
+		FileFlagsMask  uint32
+		FileFlags      uint32
+		FileOS         uint32
+		FileType       uint32
+		FileSubtype    uint32
+		FileDate       [2]uint32
+	}
+	if root.key != "VS_VERSION_INFO" || len(root.value) < binary.Size(fixed) {
+		return nil, errors.Errorf("invalid version information; missing fixed file information")
+	}
+	if err := binary.Read(bytes.NewReader(root.value), binary.LittleEndian, &fixed); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if fixed.Signature != 0xFEEF04BD {
+		return nil, errors.Errorf("invalid fixed file information signature; expected 0xFEEF04BD, got 0x%08X", fixed.Signature)
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s VERSIONINFO\n", name)
+	version := func(v [2]uint32) string {
+		return fmt.Sprintf("%d,%d,%d,%d", v[0]>>16, v[0]&0xFFFF, v[1]>>16, v[1]&0xFFFF)
+	}
+	fmt.Fprintf(buf, "FILEVERSION %s\n", version(fixed.FileVersion))
+	fmt.Fprintf(buf, "PRODUCTVERSION %s\n", version(fixed.ProductVersion))
+	fmt.Fprintf(buf, "FILEFLAGSMASK 0x%X\n", fixed.FileFlagsMask)
+	fmt.Fprintf(buf, "FILEFLAGS 0x%X\n", fixed.FileFlags)
+	fmt.Fprintf(buf, "FILEOS 0x%X\n", fixed.FileOS)
+	fmt.Fprintf(buf, "FILETYPE 0x%X\n", fixed.FileType)
+	fmt.Fprintf(buf, "FILESUBTYPE 0x%X\n", fixed.FileSubtype)
+	dumpVersionBlock(buf, root.children, "")
+	return buf.Bytes(), nil
+}
+
+// dumpVersionBlock dumps the given child nodes of a version information block.
+func dumpVersionBlock(buf *bytes.Buffer, nodes []*versionNode, indent string) {
+	fmt.Fprintf(buf, "%sBEGIN\n", indent)
+	for _, node := range nodes {
+		if len(node.children) > 0 || len(node.value) == 0 && !node.isText {
+			fmt.Fprintf(buf, "%s   BLOCK %s\n", indent, rcQuote(node.key))
+			dumpVersionBlock(buf, node.children, indent+"   ")
+			continue
+		}
+		fmt.Fprintf(buf, "%s   VALUE %s", indent, rcQuote(node.key))
+		if node.isText {
+			fmt.Fprintf(buf, ", %s\n", rcQuote(decodeUTF16(node.value)))
+			continue
+		}
+		for i := 0; i+2 <= len(node.value); i += 2 {
+			fmt.Fprintf(buf, ", 0x%04X", binary.LittleEndian.Uint16(node.value[i:]))
+		}
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(buf, "%sEND\n", indent)
+}
+
+// ### [ String tables ] #######################################################
+
+// stringTable returns the STRINGTABLE statement of the given string table
+// block. Each block holds 16 length prefixed UTF-16 strings, the IDs of which
+// are derived from the block ID.
+//
+//    STRINGTABLE
+//    BEGIN
+//       1, "foo"
+//    END
+func stringTable(blockID uint32, data []byte) ([]byte, error) {
+	if blockID == 0 {
+		return nil, errors.Errorf("invalid string table block ID 0")
+	}
+	r := &rsrcReader{data: data}
+	buf := &bytes.Buffer{}
+	buf.WriteString("STRINGTABLE\nBEGIN\n")
+	for i := uint32(0); i < 16; i++ {
+		n := int(r.uint16())
+		s := decodeUTF16(r.bytes(2 * n))
+		if r.err != nil {
+			return nil, errors.WithStack(r.err)
+		}
+		if n == 0 {
+			continue
+		}
+		fmt.Fprintf(buf, "   %d, %s\n", (blockID-1)*16+i, rcQuote(s))
+	}
+	buf.WriteString("END\n")
+	return buf.Bytes(), nil
+}
+
+// ### [ Dialogs ] #############################################################
+
+// Dialog styles.
+const (
+	// DS_SETFONT specifies that the dialog template includes a font.
+	dsSetFont = 0x40
+)
+
+// dialog returns the DIALOG or DIALOGEX statement of the given dialog
+// template.
+//
+//    100 DIALOGEX 0, 0, 186, 95
+//    STYLE 0x80C800C8
+//    EXSTYLE 0x0
+//    CAPTION "About"
+//    FONT 8, "MS Shell Dlg", 400, 0, 0x1
+//    BEGIN
+//       CONTROL "OK", 1, "Button", 0x50010001, 129, 7, 50, 14, 0x0, 0
+//    END
+func dialog(name string, data []byte) ([]byte, error) {
+	r := &rsrcReader{data: data}
+	// DLGTEMPLATEEX is identified by version 1 and signature 0xFFFF.
+	ex := len(data) >= 4 && binary.LittleEndian.Uint16(data) == 1 && binary.LittleEndian.Uint16(data[2:]) == 0xFFFF
+	var helpID, exStyle, style uint32
+	if ex {
+		r.uint32() // version and signature
+		helpID = r.uint32()
+		exStyle = r.uint32()
+		style = r.uint32()
+	} else {
+		style = r.uint32()
+		exStyle = r.uint32()
+	}
+	n := int(r.uint16())
+	x, y, cx, cy := int16(r.uint16()), int16(r.uint16()), int16(r.uint16()), int16(r.uint16())
+	menu := r.szOrOrd()
+	class := r.szOrOrd()
+	caption := r.sz()
+	buf := &bytes.Buffer{}
+	kind := "DIALOG"
+	if ex {
+		kind = "DIALOGEX"
+	}
+	fmt.Fprintf(buf, "%s %s %d, %d, %d, %d", name, kind, x, y, cx, cy)
+	if ex && helpID != 0 {
+		fmt.Fprintf(buf, ", %d", helpID)
+	}
+	buf.WriteString("\n")
+	fmt.Fprintf(buf, "STYLE 0x%08X\n", style)
+	if exStyle != 0 {
+		fmt.Fprintf(buf, "EXSTYLE 0x%08X\n", exStyle)
+	}
+	if len(caption) > 0 {
+		fmt.Fprintf(buf, "CAPTION %s\n", rcQuote(caption))
+	}
+	if len(menu) > 0 {
+		fmt.Fprintf(buf, "MENU %s\n", menu)
+	}
+	if len(class) > 0 {
+		fmt.Fprintf(buf, "CLASS %s\n", class)
+	}
+	if style&dsSetFont != 0 {
+		size := r.uint16()
+		if ex {
+			weight := r.uint16()
+			italic := r.uint8()
+			charset := r.uint8()
+			fmt.Fprintf(buf, "FONT %d, %s, %d, %d, 0x%X\n", size, rcQuote(r.sz()), weight, italic, charset)
+		} else {
+			fmt.Fprintf(buf, "FONT %d, %s\n", size, rcQuote(r.sz()))
+		}
+	}
+	buf.WriteString("BEGIN\n")
+	for i := 0; i < n && r.err == nil; i++ {
+		r.align(4)
+		var itemHelpID, itemExStyle, itemStyle, id uint32
+		if ex {
+			itemHelpID = r.uint32()
+			itemExStyle = r.uint32()
+			itemStyle = r.uint32()
+		} else {
+			itemStyle = r.uint32()
+			itemExStyle = r.uint32()
+		}
+		x, y, cx, cy := int16(r.uint16()), int16(r.uint16()), int16(r.uint16()), int16(r.uint16())
+		if ex {
+			id = r.uint32()
+		} else {
+			id = uint32(r.uint16())
+		}
+		class := r.szOrOrd()
+		title := r.szOrOrd()
+		if len(title) == 0 {
+			title = `""`
+		}
+		if extra := r.uint16(); extra != 0 {
+			return nil, errors.Errorf("support for dialog control creation data not yet implemented")
+		}
+		fmt.Fprintf(buf, "   CONTROL %s, %d, %s, 0x%08X, %d, %d, %d, %d", title, int32(id), controlClass(class), itemStyle, x, y, cx, cy)
+		if ex {
+			fmt.Fprintf(buf, ", 0x%X, %d", itemExStyle, itemHelpID)
+		} else if itemExStyle != 0 {
+			fmt.Fprintf(buf, ", 0x%X", itemExStyle)
+		}
+		buf.WriteString("\n")
+	}
+	if r.err != nil {
+		return nil, errors.WithStack(r.err)
+	}
+	buf.WriteString("END\n")
+	return buf.Bytes(), nil
+}
+
+// controlClass returns the window class name of predefined control classes,
+// identified by ordinal.
+func controlClass(class string) string {
+	switch class {
+	case "0x0080":
+		return `"Button"`
+	case "0x0081":
+		return `"Edit"`
+	case "0x0082":
+		return `"Static"`
+	case "0x0083":
+		return `"ListBox"`
+	case "0x0084":
+		return `"ScrollBar"`
+	case "0x0085":
+		return `"ComboBox"`
+	}
+	return class
+}
+
+// ### [ Icons, cursors and bitmaps ] ##########################################
+
+// A groupDirEntry is an entry of an icon or cursor group.
+//
+//    struct GRPICONDIRENTRY {
+//       uint8  bWidth;
+//       uint8  bHeight;
+//       uint8  bColorCount;
+//       uint8  bReserved;
+//       uint16 wPlanes;
+//       uint16 wBitCount;
+//       uint32 dwBytesInRes;
+//       uint16 nID;
+//    };
+//
+//    struct GRPCURSORDIRENTRY {
+//       uint16 wWidth;
+//       uint16 wHeight; // twice the height of the cursor
+//       uint16 wPlanes;
+//       uint16 wBitCount;
+//       uint32 dwBytesInRes;
+//       uint16 nID;
+//    };
+type groupDirEntry struct {
+	// Width, height and color count of icons, as stored in ICONDIRENTRY.
+	width, height, colors uint8
+	// Planes and bit count.
+	planes, bitCount uint16
+	// Image resource ID.
+	id uint16
+}
+
+// parseGroupDir parses the entries of the given icon or cursor group.
+func parseGroupDir(data []byte, isCursor bool) ([]groupDirEntry, error) {
+	r := &rsrcReader{data: data}
+	r.uint16() // reserved
+	r.uint16() // type
+	n := int(r.uint16())
+	var entries []groupDirEntry
+	for i := 0; i < n; i++ {
+		var entry groupDirEntry
+		if isCursor {
+			entry.width = uint8(r.uint16())
+			entry.height = uint8(r.uint16() / 2)
+		} else {
+			entry.width = r.uint8()
+			entry.height = r.uint8()
+			entry.colors = r.uint8()
+			r.uint8() // reserved
+		}
+		entry.planes = r.uint16()
+		entry.bitCount = r.uint16()
+		r.uint32() // bytes in resource
+		entry.id = r.uint16()
+		entries = append(entries, entry)
+	}
+	if r.err != nil {
+		return nil, errors.WithStack(r.err)
+	}
+	return entries, nil
+}
+
+// iconFile returns the contents of the icon (.ico) or cursor (.cur) file of the
+// given icon or cursor group, with images located by resource ID.
+//
+//    struct ICONDIR {
+//       uint16 idReserved;
+//       uint16 idType; // 1 for icons; 2 for cursors
+//       uint16 idCount;
+//       ICONDIRENTRY idEntries[];
+//    };
+//
+//    struct ICONDIRENTRY {
+//       uint8  bWidth;
+//       uint8  bHeight;
+//       uint8  bColorCount;
+//       uint8  bReserved;
+//       uint16 wPlanes;   // hotspot x of cursors
+//       uint16 wBitCount; // hotspot y of cursors
+//       uint32 dwBytesInRes;
+//       uint32 dwImageOffset;
+//    };
+func iconFile(data []byte, images map[uint32]*binpe.Resource, isCursor bool) ([]byte, error) {
+	entries, err := parseGroupDir(data, isCursor)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	typ := uint16(1)
+	if isCursor {
+		typ = 2
+	}
+	hdr := &bytes.Buffer{}
+	body := &bytes.Buffer{}
+	binary.Write(hdr, binary.LittleEndian, [3]uint16{0, typ, uint16(len(entries))})
+	offset := 6 + 16*len(entries)
+	for _, entry := range entries {
+		img, ok := images[uint32(entry.id)]
+		if !ok {
+			return nil, errors.Errorf("unable to locate image %d of group", entry.id)
+		}
+		imgData := img.Data
+		planes, bitCount := entry.planes, entry.bitCount
+		if isCursor {
+			// Cursor images are prefixed by the hotspot of the cursor.
+			if len(imgData) < 4 {
+				return nil, errors.Errorf("invalid cursor image %d; missing hotspot", entry.id)
+			}
+			planes = binary.LittleEndian.Uint16(imgData)
+			bitCount = binary.LittleEndian.Uint16(imgData[2:])
+			imgData = imgData[4:]
+		}
+		hdr.Write([]byte{entry.width, entry.height, entry.colors, 0})
+		binary.Write(hdr, binary.LittleEndian, [2]uint16{planes, bitCount})
+		binary.Write(hdr, binary.LittleEndian, [2]uint32{uint32(len(imgData)), uint32(offset + body.Len())})
+		body.Write(imgData)
+	}
+	hdr.Write(body.Bytes())
+	return hdr.Bytes(), nil
+}
+
+// bmpFile returns the contents of the bitmap (.bmp) file of the given
+// device-independent bitmap, by prepending a BITMAPFILEHEADER.
+//
+//    struct BITMAPFILEHEADER {
+//       uint16 bfType; // "BM"
+//       uint32 bfSize;
+//       uint16 bfReserved1;
+//       uint16 bfReserved2;
+//       uint32 bfOffBits;
+//    };
+func bmpFile(data []byte) ([]byte, error) {
+	r := &rsrcReader{data: data}
+	hdrSize := r.uint32()
+	var (
+		bitCount          uint16
+		compression, used uint32
+		entrySize         = uint32(4)
+	)
+	if hdrSize == 12 {
+		// BITMAPCOREHEADER.
+		r.uint32() // width and height
+		r.uint16() // planes
+		bitCount = r.uint16()
+		entrySize = 3
+	} else {
+		// BITMAPINFOHEADER and later versions.
+		r.bytes(8) // width and height
+		r.uint16() // planes
+		bitCount = r.uint16()
+		compression = r.uint32()
+		r.bytes(12) // image size and resolution
+		used = r.uint32()
+	}
+	if r.err != nil {
+		return nil, errors.WithStack(r.err)
+	}
+	if used == 0 && bitCount <= 8 {
+		used = 1 << bitCount
+	}
+	offBits := 14 + hdrSize + used*entrySize
+	const biBitfields = 3
+	if hdrSize == 40 && compression == biBitfields {
+		// Color masks follow BITMAPINFOHEADER.
+		offBits += 12
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString("BM")
+	binary.Write(buf, binary.LittleEndian, uint32(14+len(data)))
+	binary.Write(buf, binary.LittleEndian, uint32(0))
+	binary.Write(buf, binary.LittleEndian, offBits)
+	buf.Write(data)
+	return buf.Bytes(), nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// rsrcReader reads little-endian values of resource data, recording the first
+// error encountered. Values read after an error are zero.
+type rsrcReader struct {
+	// Resource data.
+	data []byte
+	// Current position.
+	pos int
+	// First error encountered.
+	err error
+}
+
+// bytes reads n bytes.
+func (r *rsrcReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = errors.Errorf("unexpected end of resource data at offset 0x%X; expected %d bytes", r.pos, n)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// uint8 reads an 8-bit value.
+func (r *rsrcReader) uint8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+// uint16 reads a 16-bit value.
+func (r *rsrcReader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+// uint32 reads a 32-bit value.
+func (r *rsrcReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// align aligns the current position to n bytes.
+func (r *rsrcReader) align(n int) {
+	if r.err != nil {
+		return
+	}
+	r.pos = (r.pos + n - 1) &^ (n - 1)
+	if r.pos > len(r.data) {
+		r.pos = len(r.data)
+	}
+}
+
+// sz reads a NUL-terminated UTF-16 string.
+func (r *rsrcReader) sz() string {
+	var buf []uint16
+	for r.err == nil {
+		c := r.uint16()
+		if c == 0 {
+			break
+		}
+		buf = append(buf, c)
+	}
+	return string(utf16.Decode(buf))
+}
+
+// szOrOrd reads a string or ordinal of dialog templates, as used by menu, class
+// and title fields; and returns it in resource script syntax (i.e. a quoted
+// string or hexadecimal ordinal). An empty string is returned if not present.
+//
+//    0x0000            ; not present
+//    0xFFFF, ordinal   ; ordinal
+//    WCHAR sz[]        ; NUL-terminated string
+func (r *rsrcReader) szOrOrd() string {
+	if r.err != nil || r.pos+2 > len(r.data) {
+		r.uint16()
+		return ""
+	}
+	switch binary.LittleEndian.Uint16(r.data[r.pos:]) {
+	case 0x0000:
+		r.uint16()
+		return ""
+	case 0xFFFF:
+		r.uint16()
+		return fmt.Sprintf("0x%04X", r.uint16())
+	}
+	return rcQuote(r.sz())
+}
+
+// decodeUTF16 decodes the given UTF-16 encoded text, stripping trailing NUL
+// characters.
+func decodeUTF16(b []byte) string {
+	buf := make([]uint16, len(b)/2)
+	for i := range buf {
+		buf[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	for len(buf) > 0 && buf[len(buf)-1] == 0 {
+		buf = buf[:len(buf)-1]
+	}
+	return string(utf16.Decode(buf))
+}
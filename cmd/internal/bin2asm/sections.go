@@ -0,0 +1,410 @@
+package bin2asm
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// dumpSections dumps the sections of the given binary executable in the
+// assembly syntax of the output. Tables of the executable (e.g. the import
+// table of PE files) are labeled and dumped as data items. Code is dumped as
+// raw bytes if rawCode is set, and disassembled otherwise.
+func dumpSections(file *bin.File, fs []*x86.Func, rawCode bool) error {
+	// Index functions, basic blocks and instructions.
+	funcs := make(map[bin.Address]*x86.Func)
+	blocks := make(map[bin.Address]*x86.BasicBlock)
+	insts := make(map[bin.Address]*x86.Inst)
+	for _, f := range fs {
+		funcs[f.Addr] = f
+		for _, block := range f.Blocks {
+			blocks[block.Addr] = block
+			for _, inst := range block.Insts {
+				insts[inst.Addr] = inst
+			}
+			if !block.Term.IsDummyTerm() {
+				insts[block.Term.Addr] = block.Term
+			}
+		}
+	}
+	// Data items of import and export tables.
+	items := make(map[bin.Address]*dataItem)
+	if err := importItems(file, items); err != nil {
+		warn.Printf("unable to dump import table; %v", err)
+	}
+	if err := exportItems(file, items); err != nil {
+		warn.Printf("unable to dump export table; %v", err)
+	}
+	labels := tableLabels(file.Entry, file.Tables, outSyntax)
+	if err := relocItems(file, insts, labels, items); err != nil {
+		warn.Printf("unable to dump base relocation table; %v", err)
+	}
+	// Drop data items which may not be dumped in place of their raw bytes;
+	// i.e. data items with labels located within, and data items crossing
+	// section boundaries.
+	for addr, item := range items {
+		if !itemFits(file, addr, item, labels) {
+			delete(items, addr)
+		}
+	}
+	// Split remaining data of non-executable sections into data chunks; e.g.
+	// strings and pointers.
+	refs := dataRefs(file, insts)
+	chunks := dataChunks(file, items, labels, refs)
+	syms := newSymbolizer(file, items, funcs, blocks, insts, refs)
+	for _, chunk := range chunks {
+		if err := chunk.render(file, syms); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 {
+			// Ignore segments.
+			continue
+		}
+		data := func(addr bin.Address) (byte, bool) {
+			i := int(addr - sect.Addr)
+			if i < 0 || i >= len(sect.Data) {
+				return 0, false
+			}
+			return sect.Data[addr-sect.Addr], true
+		}
+		buf := dumpSection(sect, labels, items, funcs, blocks, insts, syms, rawCode, data)
+		filename := underline(sect.Name) + outSyntax.ext()
+		outPath := filepath.Join(outDir, filename)
+		dbg.Printf("creating %q\n", outPath)
+		if err := ioutil.WriteFile(outPath, buf, 0644); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// tableLabels returns the labels of the entry point and of the given tables in
+// the given assembly syntax, indexed by address. Tables are labeled by name.
+func tableLabels(entry bin.Address, tables map[string]bin.Table, syn syntax) map[bin.Address][]string {
+	labels := make(map[bin.Address][]string)
+	labels[entry] = append(labels[entry], "\nstart:\n")
+	// Tables are labeled in a fixed order, as multiple labels may be located at
+	// the same address.
+	names := []string{bin.TableExport, bin.TableImport, bin.TableResource, bin.TableReloc, bin.TableIAT}
+	for _, name := range names {
+		table, ok := tables[name]
+		if !ok {
+			continue
+		}
+		addr := table.Addr
+		labels[addr] = append(labels[addr], fmt.Sprintf("\n%s:\n", name))
+		addr += bin.Address(table.Size)
+		size := syn.equ(name+"_size", fmt.Sprintf("%s - %s", syn.here(), name))
+		labels[addr] = append(labels[addr], "\n"+size+"\n")
+	}
+	return labels
+}
+
+// itemFits reports whether the given data item may be dumped in place of the
+// raw bytes it covers; i.e. whether it is located within the raw data of a
+// section, and no labels are located within the data item.
+func itemFits(file *bin.File, addr bin.Address, item *dataItem, labels map[bin.Address][]string) bool {
+	for a := addr + 1; a < addr+bin.Address(item.size); a++ {
+		if len(labels[a]) > 0 {
+			return false
+		}
+	}
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 {
+			continue
+		}
+		end := sect.Addr + bin.Address(len(sect.Data))
+		if sect.Addr <= addr && addr+bin.Address(item.size) <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpSection dumps the given section in the assembly syntax of the output. Data items are dumped in
+// place of the raw bytes they cover. Instructions are disassembled using the
+// given symbolizer, unless rawCode is set.
+func dumpSection(sect *bin.Section, labels map[bin.Address][]string, items map[bin.Address]*dataItem, funcs map[bin.Address]*x86.Func, blocks map[bin.Address]*x86.BasicBlock, insts map[bin.Address]*x86.Inst, syms *symbolizer, rawCode bool, data func(addr bin.Address) (byte, bool)) []byte {
+	buf := &bytes.Buffer{}
+	sectName := underline(sect.Name)
+	// Dump section header.
+	//
+	//    ; <.text>
+	//    ;
+	//    ;    file offset:    0x00000400
+	//    ;    virtual offset: 0x00401000
+	//
+	//    SECTION .text
+	const sectHeader = `
+%[1]s <%[2]s>
+%[1]s
+%[1]s    file offset:    0x%08[3]X
+%[1]s    virtual offset: 0x%08[4]X
+
+%[5]s
+
+`
+	c := outSyntax.comment()
+	fmt.Fprintf(buf, sectHeader[1:], c, sect.Name, sect.Offset, uint64(sect.Addr), outSyntax.section(sect.Name, sectName, syms.file.Format, false, ""))
+	end := sect.Addr + bin.Address(len(sect.Data))
+	for addr := sect.Addr; addr <= end; {
+		for _, label := range labels[addr] {
+			buf.WriteString(label)
+		}
+		if addr < end {
+			buf.WriteString(nameComments(addr))
+		}
+		if addr == end {
+			// Only labels are dumped at the end of the section; e.g. sizes of
+			// tables. Instructions and data items located at the end belong to
+			// the following section.
+			break
+		}
+		// Dump data item.
+		if item, ok := items[addr]; ok {
+			buf.Write(item.buf.Bytes())
+			addr += bin.Address(item.size)
+			continue
+		}
+		a := uint64(addr)
+		if sect.Perm&bin.PermX != 0 {
+			// Dump function header.
+			//
+			//    times (0x401000 - _text_vstart) - ($ - $$) db 0xCC
+			//    sub_401000:
+			_, isFunc := funcs[addr]
+			if isFunc {
+				if addr != sect.Addr {
+					buf.WriteString("\n")
+				}
+				offset := fmt.Sprintf("(%s - %s_vstart)", outSyntax.hex(a, 6), sectName)
+				fmt.Fprintf(buf, "%s\nsub_%06X:\n", outSyntax.org(sectName, offset, 0xCC), a)
+			}
+			// Dump basic block header.
+			//
+			//    loc_401010:
+			if _, ok := blocks[addr]; ok {
+				switch {
+				case rawCode:
+					fmt.Fprintf(buf, "%s block_%06X\n", c, a)
+				case !isFunc:
+					fmt.Fprintf(buf, "loc_%06X:\n", a)
+				}
+			}
+			if inst, ok := insts[addr]; ok {
+				raw := make([]byte, inst.Len)
+				for i := range raw {
+					b, ok := data(addr + bin.Address(i))
+					if !ok {
+						panic(fmt.Errorf("unable to locate data at %v", addr+bin.Address(i)))
+					}
+					raw[i] = b
+				}
+				// Dump disassembled instruction.
+				//
+				//    addr_401000:          sub     esp, 0x8                                ; 83 EC 08
+				if !rawCode {
+					if text, ok := syms.formatInst(inst, raw, outSyntax); ok {
+						line := fmt.Sprintf("  addr_%06X:          %s", a, text)
+						pad := " "
+						if n := 80 - len(line); n > 0 {
+							pad = strings.Repeat(" ", n)
+						}
+						fmt.Fprintf(buf, "%s%s%s % X\n", line, pad, c, raw)
+						addr += bin.Address(inst.Len)
+						continue
+					}
+				}
+				// Dump instruction as raw bytes.
+				//
+				//    addr_401000:          db      0x83, 0xEC, 0x08                                ; sub    esp,0x8
+				line := fmt.Sprintf("  addr_%06X:          %s", a, rawBytes(raw))
+				pad := " "
+				if n := 80 - len(line); n > 0 {
+					pad = strings.Repeat(" ", n)
+				}
+				fmt.Fprintf(buf, "%s%s%s %s\n", line, pad, c, x86asm.IntelSyntax(inst.Inst, uint64(addr), nil))
+				addr += bin.Address(inst.Len)
+				continue
+			}
+		}
+
+		// Dump data.
+		//
+		//    addr_48B054:          db      0x44 ; 'D'
+		if b, ok := data(addr); ok {
+			char := ""
+			if isPrint(b) {
+				char = fmt.Sprintf(" %s %q", c, b)
+			}
+			fmt.Fprintf(buf, "  addr_%06X:          %s%s\n", a, rawBytes([]byte{b}), char)
+		}
+		addr++
+	}
+
+	// The virtual size (sect.MemSize) is larger in unitialized sections, and the
+	// raw size (len(sect.Data)) is larger in sections with padding.
+	if sect.MemSize > len(sect.Data) {
+		// Section with uninitialized data. The uninitialized data is dumped to a
+		// nobits section, located directly after the raw data in memory, and
+		// labeled at referenced addresses.
+		//
+		//       _data_size           equ     $ - $$
+		//
+		//    ; Uninitialized data (allocated by the linker).
+		//    SECTION .data_bss  nobits  vstart=_data_vstart + _data_size
+		//
+		//    bss_404400:
+		//                            resb    0x10
+		//    bss_404410:
+		//                            resb    _data_vsize - _data_size - ($ - $$)
+		const sectFooter = `
+%s
+
+%s Uninitialized data (allocated by the linker).
+%s
+`
+		bssName := sectName + "_bss"
+		size := outSyntax.equ(sectName+"_size", outSyntax.offset(sectName))
+		vstart := fmt.Sprintf("%s_vstart + %s_size", sectName, sectName)
+		bss := outSyntax.section(sect.Name+"_bss", bssName, syms.file.Format, true, vstart)
+		fmt.Fprintf(buf, sectFooter, outSyntax.sectionEnd(sectName)+size, c, bss)
+		var bssAddrs []bin.Address
+		for addr := range syms.refs {
+			if end <= addr && addr < sect.Addr+bin.Address(sect.MemSize) {
+				bssAddrs = append(bssAddrs, addr)
+			}
+		}
+		sort.Sort(bin.Addresses(bssAddrs))
+		buf.WriteString("\n")
+		prev := end
+		for _, addr := range bssAddrs {
+			if addr > prev {
+				fmt.Fprintf(buf, "                        %s\n", outSyntax.reserve(outSyntax.hex(uint64(addr-prev), 1)))
+			}
+			fmt.Fprintf(buf, "bss_%06X:\n", uint64(addr))
+			prev = addr
+		}
+		offset := fmt.Sprintf("%s_vsize - %s_size", sectName, sectName)
+		fmt.Fprintf(buf, "                        %s\n", outSyntax.reserveTo(bssName, offset))
+		buf.WriteString(outSyntax.sectionEnd(bssName))
+	} else {
+		// Section with padding.
+		//
+		//       _text_vsize          equ     $ - $$
+		//
+		//    ; Section alignment.
+		//    times _text_size - ($ - $$) db 0x00
+		const sectFooter = `
+%s
+
+%s Section alignment.
+%s
+%s`
+		vsize := outSyntax.equ(sectName+"_vsize", outSyntax.offset(sectName))
+		align := outSyntax.org(sectName, sectName+"_size", 0x00)
+		fmt.Fprintf(buf, sectFooter, vsize, c, align, outSyntax.sectionEnd(sectName))
+	}
+	return buf.Bytes()
+}
+
+// A dataItem is a structured data item of a section (e.g. an import
+// descriptor), which is dumped in place of the raw bytes it covers.
+type dataItem struct {
+	// Size in bytes of the data item.
+	size int
+	// Label of the data item; or empty if unlabeled. The last label takes
+	// precedence for data items with multiple labels.
+	name string
+	// Data item in the assembly syntax of the output.
+	buf bytes.Buffer
+}
+
+// label adds a label with an optional comment to the data item.
+//
+//    import_kernel32_dll:                                            ; IMAGE_IMPORT_DESCRIPTOR
+func (item *dataItem) label(label, comment string) {
+	item.name = label
+	line := label + ":"
+	if len(comment) > 0 {
+		line = padComment(line, comment)
+	}
+	item.buf.WriteString(line + "\n")
+}
+
+// directive adds a data directive with an optional comment to the data item.
+//
+//                            dd      0x00000000                              ; TimeDateStamp
+//
+// The operand is specified in NASM syntax, and converted to the assembly syntax
+// of the output.
+func (item *dataItem) directive(kind, operand, comment string) {
+	for _, text := range outSyntax.directive(kind, operand) {
+		item.line(text, comment)
+		// Comment first line only.
+		comment = ""
+	}
+}
+
+// line adds a line of the given text with an optional comment to the data
+// item.
+func (item *dataItem) line(text, comment string) {
+	line := "                        " + text
+	if len(comment) > 0 {
+		line = padComment(line, comment)
+	}
+	item.buf.WriteString(line + "\n")
+}
+
+// nameComments returns the user-supplied symbol name and comment of the given
+// address as comment lines; or the empty string if not present.
+//
+//    ; start
+//    ; entry point of the C runtime
+func nameComments(addr bin.Address) string {
+	n, ok := userNames[addr]
+	if !ok {
+		return ""
+	}
+	c := outSyntax.comment()
+	var lines []string
+	if len(n.Name) > 0 {
+		lines = append(lines, c+" "+n.Name+"\n")
+	}
+	if len(n.Comment) > 0 {
+		for _, line := range strings.Split(n.Comment, "\n") {
+			lines = append(lines, c+" "+line+"\n")
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// padComment appends the given comment to the line, aligned to column 64.
+func padComment(line, comment string) string {
+	pad := " "
+	if n := 64 - len(line); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	return line + pad + outSyntax.comment() + " " + comment
+}
+
+// rawBytes returns a data directive of the given raw bytes; e.g.
+//
+//    db      0x83, 0xEC, 0x08
+func rawBytes(raw []byte) string {
+	hexBytes := make([]string, len(raw))
+	for i, b := range raw {
+		hexBytes[i] = fmt.Sprintf("0x%02X", b)
+	}
+	return outSyntax.directive("db", strings.Join(hexBytes, ", "))[0]
+}
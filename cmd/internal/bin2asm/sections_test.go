@@ -0,0 +1,99 @@
+package bin2asm
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+func TestDumpSection(t *testing.T) {
+	// Code of the .text section, located at 0x401000.
+	//
+	//    sub_401000:
+	//       push    ebp
+	//       mov     ebp, esp
+	//       int3
+	//       nop     dword [byte eax+0x0]
+	//       nop     dword [eax]
+	//       push    strict dword 0x1
+	//       pop     eax
+	//       pop     ebp
+	//       ret
+	//       db      0xCC
+	code := []byte{
+		0x55,
+		0x8B, 0xEC,
+		0xCC,
+		0x0F, 0x1F, 0x40, 0x00,
+		0x0F, 0x1F, 0x00,
+		0x68, 0x01, 0x00, 0x00, 0x00,
+		0x58,
+		0x5D,
+		0xC3,
+		0xCC,
+	}
+	golden := []struct {
+		// Binary file format.
+		format string
+		// Assembly syntax of the output.
+		syn syntax
+		// Path to the expected output.
+		want string
+	}{
+		{format: "pe", syn: syntaxNASM, want: "testdata/sections/pe/nasm/_text.asm"},
+		{format: "pe", syn: syntaxGAS, want: "testdata/sections/pe/gas/_text.s"},
+		{format: "pe", syn: syntaxMASM, want: "testdata/sections/pe/masm/_text.asm"},
+		{format: "elf", syn: syntaxNASM, want: "testdata/sections/elf/nasm/_text.asm"},
+		{format: "elf", syn: syntaxGAS, want: "testdata/sections/elf/gas/_text.s"},
+	}
+	defer func(syn syntax) { outSyntax = syn }(outSyntax)
+	for _, g := range golden {
+		outSyntax = g.syn
+		sect := &bin.Section{Name: ".text", Addr: 0x401000, Offset: 0x400, Data: code, FileSize: len(code), MemSize: len(code), Perm: bin.PermR | bin.PermX}
+		file := &bin.File{
+			Format:   g.format,
+			Arch:     bin.ArchX86_32,
+			Entry:    0x401000,
+			Sections: []*bin.Section{sect},
+		}
+		// Decode the function, leaving the trailing byte as data.
+		block := &x86.BasicBlock{Addr: sect.Addr}
+		insts := make(map[bin.Address]*x86.Inst)
+		for addr := sect.Addr; addr < sect.Addr+bin.Address(len(code)-1); {
+			in, err := x86asm.Decode(code[addr-sect.Addr:], 32)
+			if err != nil {
+				t.Fatalf("unable to decode instruction at %v; %v", addr, err)
+			}
+			inst := &x86.Inst{Addr: addr, Inst: in}
+			block.Insts = append(block.Insts, inst)
+			insts[addr] = inst
+			addr += bin.Address(in.Len)
+		}
+		funcs := map[bin.Address]*x86.Func{
+			sect.Addr: {Addr: sect.Addr, Blocks: map[bin.Address]*x86.BasicBlock{block.Addr: block}},
+		}
+		blocks := map[bin.Address]*x86.BasicBlock{block.Addr: block}
+		labels := tableLabels(file.Entry, nil, g.syn)
+		syms := newSymbolizer(file, nil, funcs, blocks, insts, nil)
+		data := func(addr bin.Address) (byte, bool) {
+			i := int(addr - sect.Addr)
+			if i < 0 || i >= len(sect.Data) {
+				return 0, false
+			}
+			return sect.Data[i], true
+		}
+		got := dumpSection(sect, labels, nil, funcs, blocks, insts, syms, false, data)
+		buf, err := ioutil.ReadFile(g.want)
+		if err != nil {
+			t.Errorf("%q: unable to read file; %v", g.want, err)
+			continue
+		}
+		if want := string(buf); string(got) != want {
+			t.Errorf("%q: output mismatch; expected:\n%s\ngot:\n%s", filepath.Base(g.want), want, got)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package bin2asm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A syntax specifies the assembly syntax (i.e. assembler dialect) of the
+// output.
+type syntax uint8
+
+// Assembly syntaxes.
+const (
+	// syntaxNASM specifies the syntax of the Netwide Assembler (NASM).
+	syntaxNASM syntax = iota
+	// syntaxGAS specifies the AT&T syntax of the GNU assembler (GAS).
+	syntaxGAS
+	// syntaxMASM specifies the syntax of the Microsoft Macro Assembler (MASM).
+	syntaxMASM
+)
+
+// outSyntax specifies the assembly syntax of the output.
+var outSyntax = syntaxNASM
+
+// String returns the string representation of the assembly syntax.
+func (s syntax) String() string {
+	m := map[syntax]string{
+		syntaxNASM: "nasm",
+		syntaxGAS:  "gas",
+		syntaxMASM: "masm",
+	}
+	if name, ok := m[s]; ok {
+		return name
+	}
+	panic(fmt.Errorf("support for assembly syntax %d not yet implemented", uint8(s)))
+}
+
+// Set implements the flag.Value interface. It sets the assembly syntax to the
+// given value (nasm, gas or masm).
+func (s *syntax) Set(name string) error {
+	switch name {
+	case "nasm":
+		*s = syntaxNASM
+	case "gas":
+		*s = syntaxGAS
+	case "masm":
+		*s = syntaxMASM
+	default:
+		return errors.Errorf("support for assembly syntax %q not yet implemented", name)
+	}
+	return nil
+}
+
+// ext returns the file extension of assembly files of the syntax.
+func (s syntax) ext() string {
+	if s == syntaxGAS {
+		return ".s"
+	}
+	return ".asm"
+}
+
+// comment returns the comment prefix of the syntax.
+func (s syntax) comment() string {
+	if s == syntaxGAS {
+		return "#"
+	}
+	return ";"
+}
+
+// hex returns the given value as a hexadecimal literal of at least the
+// specified number of digits; e.g. 0x00401000 or 000401000h.
+func (s syntax) hex(v uint64, digits int) string {
+	if s == syntaxMASM {
+		// MASM requires hexadecimal literals to start with a decimal digit.
+		return fmt.Sprintf("0%0*Xh", digits, v)
+	}
+	return fmt.Sprintf("0x%0*X", digits, v)
+}
+
+// signed returns the given signed value as a hexadecimal literal; e.g. -0x8.
+func (s syntax) signed(v int64) string {
+	if v < 0 {
+		return "-" + s.hex(uint64(-v), 1)
+	}
+	return s.hex(uint64(v), 1)
+}
+
+// here returns the current location expression of the syntax.
+func (s syntax) here() string {
+	if s == syntaxGAS {
+		return "."
+	}
+	return "$"
+}
+
+// offset returns an expression of the offset of the current location within
+// the given section; e.g. ($ - $$) or (. - _text_start).
+func (s syntax) offset(sectName string) string {
+	if s == syntaxNASM {
+		return "($ - $$)"
+	}
+	return fmt.Sprintf("(%s - %s_start)", s.here(), sectName)
+}
+
+// equ returns an equate of the given name to the expression.
+//
+//    _text_vsize          equ     $ - $$
+//    .set    _text_vsize, . - _text_start
+func (s syntax) equ(name, expr string) string {
+	if s == syntaxGAS {
+		return fmt.Sprintf("   .set    %s, %s", name, expr)
+	}
+	pad := " "
+	if n := 24 - (len("   ") + len(name)); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	return fmt.Sprintf("   %s%sequ     %s", name, pad, expr)
+}
+
+// fill returns a directive repeating the byte b count times.
+//
+//    times 16 db 0x00
+//    .fill   16, 1, 0x00
+//    db      16 dup (000h)
+func (s syntax) fill(count string, b byte) string {
+	switch s {
+	case syntaxGAS:
+		return fmt.Sprintf(".fill   %s, 1, 0x%02X", count, b)
+	case syntaxMASM:
+		return fmt.Sprintf("db      %s dup (0%02Xh)", count, b)
+	}
+	return fmt.Sprintf("times %s db 0x%02X", count, b)
+}
+
+// reserve returns a directive reserving count bytes of uninitialized data.
+func (s syntax) reserve(count string) string {
+	switch s {
+	case syntaxGAS:
+		return fmt.Sprintf(".skip   %s", count)
+	case syntaxMASM:
+		return fmt.Sprintf("db      %s dup (?)", count)
+	}
+	return fmt.Sprintf("resb    %s", count)
+}
+
+// org returns a directive padding the given section with the byte b up to the
+// specified offset expression.
+//
+//    times _text_size - ($ - $$) db 0x00
+//    .org    _text_size, 0x00
+//    db      _text_size - ($ - _text_start) dup (000h)
+func (s syntax) org(sectName, offset string, b byte) string {
+	if s == syntaxGAS {
+		return fmt.Sprintf(".org    %s, 0x%02X", offset, b)
+	}
+	return s.fill(fmt.Sprintf("%s - %s", offset, s.offset(sectName)), b)
+}
+
+// reserveTo returns a directive reserving uninitialized data of the given
+// section up to the specified offset expression.
+//
+//    resb    _data_vsize - _data_size - ($ - $$)
+//    .org    _data_vsize - _data_size
+//    db      _data_vsize - _data_size - ($ - _data_bss_start) dup (?)
+func (s syntax) reserveTo(sectName, offset string) string {
+	if s == syntaxGAS {
+		return fmt.Sprintf(".org    %s", offset)
+	}
+	return s.reserve(fmt.Sprintf("%s - %s", offset, s.offset(sectName)))
+}
+
+// section returns the start of the given section of a binary executable of
+// the specified format (e.g. "pe"). Sections of uninitialized data are located
+// at the specified virtual address expression.
+//
+//    SECTION .text
+//    .section .text
+//    _text SEGMENT
+func (s syntax) section(name, sectName, format string, nobits bool, vstart string) string {
+	switch s {
+	case syntaxGAS:
+		if nobits {
+			flags := `"aw", @nobits`
+			if format == "pe" {
+				flags = `"bw"`
+			}
+			return fmt.Sprintf(".section %s, %s\n%s_start:", name, flags, sectName)
+		}
+		return fmt.Sprintf(".section %s\n%s_start:", name, sectName)
+	case syntaxMASM:
+		return fmt.Sprintf("%s SEGMENT\n%s_start:", sectName, sectName)
+	}
+	if nobits {
+		return fmt.Sprintf("SECTION %s  nobits  vstart=%s", name, vstart)
+	}
+	return fmt.Sprintf("SECTION %s", name)
+}
+
+// sectionEnd returns the end of the given section; or empty if not applicable.
+func (s syntax) sectionEnd(sectName string) string {
+	if s == syntaxMASM {
+		return fmt.Sprintf("%s ENDS\n", sectName)
+	}
+	return ""
+}
+
+// directive returns the lines of the given data directive (db, dw, dd or dq)
+// in the syntax. The operand is specified in NASM syntax; e.g.
+//
+//    "kernel32.dll", 0x00
+//    0x80000000 | 1
+func (s syntax) directive(kind, operand string) []string {
+	switch s {
+	case syntaxGAS:
+		gasKinds := map[string]string{"db": ".byte", "dw": ".short", "dd": ".long", "dq": ".quad"}
+		if kind != "db" || !strings.Contains(operand, `"`) {
+			return []string{fmt.Sprintf("%-8s%s", gasKinds[kind], operand)}
+		}
+		// Strings are not valid operands of .byte in GAS.
+		var lines, nums []string
+		for _, part := range splitOperands(operand) {
+			if strings.HasPrefix(part, `"`) {
+				if len(nums) > 0 {
+					lines = append(lines, ".byte   "+strings.Join(nums, ", "))
+					nums = nil
+				}
+				str := strings.Replace(part[1:len(part)-1], `\`, `\\`, -1)
+				lines = append(lines, fmt.Sprintf(`.ascii  "%s"`, str))
+				continue
+			}
+			nums = append(nums, part)
+		}
+		if len(nums) > 0 {
+			lines = append(lines, ".byte   "+strings.Join(nums, ", "))
+		}
+		return lines
+	case syntaxMASM:
+		var parts []string
+		for _, part := range splitOperands(operand) {
+			if !strings.HasPrefix(part, `"`) {
+				part = masmExpr(part)
+			}
+			parts = append(parts, part)
+		}
+		return []string{fmt.Sprintf("%-8s%s", kind, strings.Join(parts, ", "))}
+	}
+	return []string{fmt.Sprintf("%-8s%s", kind, operand)}
+}
+
+// splitOperands splits the given comma-separated operands, ignoring commas of
+// quoted strings.
+func splitOperands(operand string) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(operand); i++ {
+		switch operand[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				parts = append(parts, strings.TrimSpace(operand[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(operand[start:]))
+}
+
+// hexLit matches hexadecimal literals in NASM syntax.
+var hexLit = regexp.MustCompile(`\b0x([0-9A-Fa-f]+)\b`)
+
+// masmExpr converts the given expression from NASM to MASM syntax.
+func masmExpr(expr string) string {
+	expr = hexLit.ReplaceAllString(expr, "0${1}h")
+	return strings.Replace(expr, "|", "OR", -1)
+}
@@ -0,0 +1,27 @@
+# <.text>
+#
+#    file offset:    0x00000400
+#    virtual offset: 0x00401000
+
+.section .text
+_text_start:
+
+
+start:
+.org    (0x401000 - _text_vstart), 0xCC
+sub_401000:
+  addr_401000:          push    %ebp                                            # 55
+  addr_401001:          {load} mov %esp, %ebp                                   # 8B EC
+  addr_401003:          int3                                                    # CC
+  addr_401004:          {disp8} nopl 0x0(%eax)                                  # 0F 1F 40 00
+  addr_401008:          nopl    (%eax)                                          # 0F 1F 00
+  addr_40100B:          .byte   0x68, 0x01, 0x00, 0x00, 0x00                    # push 0x1
+  addr_401010:          pop     %eax                                            # 58
+  addr_401011:          pop     %ebp                                            # 5D
+  addr_401012:          ret                                                     # C3
+  addr_401013:          .byte   0xCC
+
+   .set    _text_vsize, (. - _text_start)
+
+# Section alignment.
+.org    _text_size, 0x00
@@ -0,0 +1,28 @@
+; <.text>
+;
+;    file offset:    0x00000400
+;    virtual offset: 0x00401000
+
+_text SEGMENT
+_text_start:
+
+
+start:
+db      (0401000h - _text_vstart) - ($ - _text_start) dup (0CCh)
+sub_401000:
+  addr_401000:          push    ebp                                             ; 55
+  addr_401001:          mov     ebp, esp                                        ; 8B EC
+  addr_401003:          int 3                                                   ; CC
+  addr_401004:          db      00Fh, 01Fh, 040h, 000h                          ; nop dword ptr [eax], eax
+  addr_401008:          nop     dword ptr [eax]                                 ; 0F 1F 00
+  addr_40100B:          db      068h, 001h, 000h, 000h, 000h                    ; push 0x1
+  addr_401010:          pop     eax                                             ; 58
+  addr_401011:          pop     ebp                                             ; 5D
+  addr_401012:          ret                                                     ; C3
+  addr_401013:          db      0CCh
+
+   _text_vsize          equ     ($ - _text_start)
+
+; Section alignment.
+db      _text_size - ($ - _text_start) dup (000h)
+_text ENDS
@@ -0,0 +1,26 @@
+; <.text>
+;
+;    file offset:    0x00000400
+;    virtual offset: 0x00401000
+
+SECTION .text
+
+
+start:
+times (0x401000 - _text_vstart) - ($ - $$) db 0xCC
+sub_401000:
+  addr_401000:          push    ebp                                             ; 55
+  addr_401001:          mov     ebp, esp                                        ; 8B EC
+  addr_401003:          int3                                                    ; CC
+  addr_401004:          nop     dword [byte eax+0x0]                            ; 0F 1F 40 00
+  addr_401008:          nop     dword [eax]                                     ; 0F 1F 00
+  addr_40100B:          push    strict dword 0x1                                ; 68 01 00 00 00
+  addr_401010:          pop     eax                                             ; 58
+  addr_401011:          pop     ebp                                             ; 5D
+  addr_401012:          ret                                                     ; C3
+  addr_401013:          db      0xCC
+
+   _text_vsize          equ     ($ - $$)
+
+; Section alignment.
+times _text_size - ($ - $$) db 0x00
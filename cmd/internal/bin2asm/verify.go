@@ -0,0 +1,99 @@
+package bin2asm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/decomp/exp/bin"
+	binpe "github.com/decomp/exp/bin/pe"
+	"github.com/pkg/errors"
+)
+
+// verifyAsm assembles the dumped main.asm file using NASM, and compares the
+// reassembled executable byte by byte against the original executable,
+// reporting the first divergent file offset. The CheckSum field of the optional
+// header of the reassembled executable is recomputed, if set in the original
+// executable.
+func verifyAsm(binPath string, file *bin.File) error {
+	// Assemble main.asm.
+	name := filepath.Base(binPath)
+	outPath := filepath.Join(outDir, name)
+	dbg.Printf("creating %q\n", outPath)
+	cmd := exec.Command("nasm", "-f", "bin", "-o", name, "main.asm")
+	cmd.Dir = outDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Errorf("unable to assemble %q; %v", filepath.Join(outDir, "main.asm"), err)
+	}
+	want, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	got, err := ioutil.ReadFile(outPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Recompute checksum.
+	off, err := binpe.ChecksumOffset(want)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if sum := binary.LittleEndian.Uint32(want[off:]); sum != 0 && off+4 <= len(got) {
+		newSum, err := binpe.Checksum(got)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if newSum != sum {
+			dbg.Printf("updating checksum of %q from 0x%08X to 0x%08X\n", outPath, sum, newSum)
+			binary.LittleEndian.PutUint32(got[off:], newSum)
+			if err := ioutil.WriteFile(outPath, got, 0644); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	// Compare reassembled executable against original.
+	for i := 0; i < len(want) && i < len(got); i++ {
+		if got[i] != want[i] {
+			return errors.Errorf("mismatch at file offset 0x%X (%s) of %q; expected 0x%02X, got 0x%02X", i, fileLocation(file, uint64(i)), outPath, want[i], got[i])
+		}
+	}
+	if len(got) != len(want) {
+		return errors.Errorf("size mismatch of %q; expected %d bytes, got %d bytes", outPath, len(want), len(got))
+	}
+	dbg.Printf("verified %q; byte-identical to %q\n", outPath, binPath)
+	return nil
+}
+
+// fileLocation returns a description of the location of the given file offset
+// within the binary executable; e.g. "section .text, address 0x401012".
+func fileLocation(file *bin.File, offset uint64) string {
+	for _, sect := range file.Sections {
+		if len(sect.Name) == 0 {
+			continue
+		}
+		if sect.Offset <= offset && offset < sect.Offset+uint64(len(sect.Data)) {
+			return fmt.Sprintf("section %s, address %v", sect.Name, sect.Addr+bin.Address(offset-sect.Offset))
+		}
+	}
+	if file.Overlay != nil && offset >= file.Overlay.Offset {
+		return "overlay"
+	}
+	// The headers precede the raw data of the first section.
+	hdrEnd := ^uint64(0)
+	for _, sect := range file.Sections {
+		if len(sect.Name) > 0 && len(sect.Data) > 0 && sect.Offset < hdrEnd {
+			hdrEnd = sect.Offset
+		}
+	}
+	if offset < hdrEnd {
+		return "headers"
+	}
+	return "section padding"
+}
@@ -1,4 +1,4 @@
-package main
+package bin2dot
 
 import (
 	"fmt"
@@ -18,7 +18,14 @@ func dumpCFG(dis *x86.Disasm, f *x86.Func) (graph.Directed, error) {
 	// Index functions, basic blocks and instructions.
 	g := simple.NewDirectedGraph()
 	nodes := make(map[bin.Address]*Node)
-	for _, block := range f.Blocks {
+	// Add nodes in address order, as node IDs determine the output order.
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	for _, blockAddr := range blockAddrs {
+		block := f.Blocks[blockAddr]
 		id := strconv.Quote(block.Addr.String())
 		n := &Node{
 			Node:  g.NewNode(),
@@ -31,7 +38,8 @@ func dumpCFG(dis *x86.Disasm, f *x86.Func) (graph.Directed, error) {
 		nodes[block.Addr] = n
 		g.AddNode(n)
 	}
-	for _, block := range f.Blocks {
+	for _, blockAddr := range blockAddrs {
+		block := f.Blocks[blockAddr]
 		targets := dis.Targets(block.Term, f.Addr)
 		fmt.Println("block.Addr:", block.Addr)
 		fmt.Println("block.Term:", block.Term)
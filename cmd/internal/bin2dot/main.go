@@ -0,0 +1,138 @@
+// Package bin2dot implements the bin2dot tool, which generates control flow
+// graphs from binary executables (*.exe -> *.dot).
+package bin2dot
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gonum.org/v1/gonum/graph/encoding/dot"
+
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "bin2dot:" prefix, which logs debug
+	// messages to standard error.
+	dbg = log.New(os.Stderr, term.YellowBold("bin2dot:")+" ", 0)
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+)
+
+// Main runs the bin2dot tool with the given command name (e.g. "decomp cfg")
+// and command line arguments.
+func Main(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// sel specifies the functions to disassemble.
+		sel cli.FuncSelector
+		// loader specifies how to parse the binary executable.
+		loader cli.Loader
+		// prune specifies whether to prune infeasible edges of conditional
+		// branches, as determined by symbolic execution.
+		prune bool
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+Generate control flow graphs from binary executables (*.exe -> *.dot).
+
+Usage:
+
+	%s [OPTION]... FILE
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&prune, "prune", false, "prune infeasible edges of conditional branches (e.g. opaque predicates) and unreachable basic blocks, as determined by symbolic execution")
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	sel.RegisterFlags(flags, "disassemble")
+	loader.RegisterFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	binPath := flags.Arg(0)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Prepare disassembler for the binary executable.
+	file, err := loader.ParseFile(binPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if loader.Project != nil {
+		sel.Exclude = loader.Project.Exclude
+	}
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	dis.Prune = prune
+	// Disassemble basic block.
+	if sel.Block != 0 {
+		block, err := dis.DecodeBlock(sel.Block)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		_ = block
+		return
+	}
+
+	// Disassemble functions.
+	var fs []*x86.Func
+	for _, funcAddr := range sel.Select(dis.FuncAddrs) {
+		f, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		fs = append(fs, f)
+	}
+
+	// Create output directory.
+	if err := os.RemoveAll(outDir); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Dump control flow graphs.
+	for _, f := range fs {
+		filename := fmt.Sprintf("f_%06X.dot", uint64(f.Addr))
+		path := filepath.Join(outDir, filename)
+		g, err := dumpCFG(dis, f)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		name := strconv.Quote(f.Addr.String())
+		data, err := dot.Marshal(g, name, "", "\t", false)
+		if err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+}
+
+// outDir specifies the output direcotry.
+const outDir = "_dump_"
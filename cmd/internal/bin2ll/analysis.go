@@ -0,0 +1,297 @@
+package bin2ll
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	disasm "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/pkg/errors"
+)
+
+// An analysis records the results of analysing a binary executable, for
+// consumption by external tools.
+type analysis struct {
+	// Functions.
+	Funcs []funcInfo `json:"funcs"`
+	// Call graph edges.
+	Calls []callEdge `json:"calls"`
+	// Global variables.
+	Globals []globalInfo `json:"globals"`
+	// String literals.
+	Strings []stringInfo `json:"strings"`
+	// Functions which failed to be decoded or lifted.
+	Failures []failure `json:"failures"`
+	// Conflicting decodings of function discovery (-hybrid), sorted by address.
+	Conflicts []conflict `json:"conflicts"`
+	// Thunks, sorted by address.
+	Thunks []thunkInfo `json:"thunks"`
+}
+
+// A thunkInfo records a thunk function (e.g. an incremental linking thunk or an
+// import thunk), which is not lifted.
+type thunkInfo struct {
+	// Address of the thunk.
+	Addr bin.Address `json:"addr"`
+	// Name of the thunk (e.g. export), emitted as a forwarding function of the
+	// target; or empty if unnamed.
+	Name string `json:"name,omitempty"`
+	// Ultimate target of the thunk; either the entry address of a function or
+	// the address of an import.
+	Target bin.Address `json:"target"`
+	// Name of the target; or empty if unknown.
+	TargetName string `json:"target_name,omitempty"`
+}
+
+// A funcInfo records the analysis results of a function.
+type funcInfo struct {
+	// Entry address of the function.
+	Addr bin.Address `json:"addr"`
+	// Function name.
+	Name string `json:"name"`
+	// Function signature.
+	Sig string `json:"sig"`
+	// Address ranges of the basic blocks of the function, sorted by address.
+	Blocks []blockRange `json:"blocks"`
+	// Stack imbalances of the function, sorted by address.
+	Imbalances []stackImbalance `json:"imbalances"`
+	// Names of well-known constants of cryptographic, checksum and compression
+	// algorithms referenced by the function (e.g. "AES S-box").
+	Crypto []string `json:"crypto,omitempty"`
+	// Names of the YARA rules matching within the function.
+	Yara []string `json:"yara,omitempty"`
+	// Address ranges of overlapping instructions of the function, sorted by
+	// address.
+	Overlaps []blockRange `json:"overlaps,omitempty"`
+	// Address ranges of executable sections written by the function (i.e.
+	// self-modifying code), sorted by address.
+	SMC []blockRange `json:"smc,omitempty"`
+}
+
+// A stackImbalance records an imbalance of the stack pointer of a function.
+type stackImbalance struct {
+	// Address of the instruction at which the imbalance was detected.
+	Addr bin.Address `json:"addr"`
+	// Kind of imbalance; "merge", "return" or "purge".
+	Kind string `json:"kind"`
+	// Stack pointer delta relative to function entry (or number of bytes purged
+	// on return) along the offending path.
+	Got int64 `json:"got"`
+	// Expected stack pointer delta (or number of bytes purged on return).
+	Want int64 `json:"want"`
+}
+
+// A conflict records a conflicting decoding of function discovery, and its
+// resolution.
+type conflict struct {
+	// Address of the first conflicting instruction of the candidate function.
+	Addr bin.Address `json:"addr"`
+	// Kind of conflict; "overlap" (overlapping instructions) or "data" (data
+	// decoded as code).
+	Kind string `json:"kind"`
+	// Entry address of the candidate function.
+	Func bin.Address `json:"func"`
+	// Score of the candidate function.
+	Score int `json:"score"`
+	// Entry address of the conflicting function; or 0 if conflicting with data.
+	Other bin.Address `json:"other,omitempty"`
+	// Score of the conflicting function.
+	OtherScore int `json:"other_score,omitempty"`
+	// Resolution of the conflict; "drop" (candidate dropped), "replace"
+	// (conflicting function dropped) or "keep" (both kept).
+	Resolution string `json:"resolution"`
+}
+
+// A blockRange records the address range of a basic block.
+type blockRange struct {
+	// Start address of the basic block.
+	Start bin.Address `json:"start"`
+	// End address of the basic block (exclusive).
+	End bin.Address `json:"end"`
+}
+
+// A callEdge records a call from one function to another.
+type callEdge struct {
+	// Entry address of the caller.
+	Caller bin.Address `json:"caller"`
+	// Address of the call instruction.
+	Site bin.Address `json:"site"`
+	// Address of the callee; or the address of the function pointer for
+	// indirect calls through memory (e.g. imports).
+	Callee bin.Address `json:"callee"`
+	// Name of the callee; or empty if unknown.
+	Name string `json:"name,omitempty"`
+}
+
+// A globalInfo records an inferred global variable.
+type globalInfo struct {
+	// Address of the global variable.
+	Addr bin.Address `json:"addr"`
+	// Global variable name.
+	Name string `json:"name"`
+	// Content type of the global variable.
+	Type string `json:"type"`
+	// Global variable is located in a read-only section.
+	Const bool `json:"const"`
+	// Names of the YARA rules matching within the global variable.
+	Yara []string `json:"yara,omitempty"`
+}
+
+// A stringInfo records a string literal referenced from code.
+type stringInfo struct {
+	// Address of the string literal.
+	Addr bin.Address `json:"addr"`
+	// Global variable name.
+	Name string `json:"name"`
+	// Contents of the string literal; excluding NUL terminator.
+	Text string `json:"text"`
+}
+
+// newAnalysis returns the analysis results of the lifted functions at the given
+// addresses, and their stack imbalances.
+func newAnalysis(l *x86.Lifter, funcAddrs []bin.Address, imbalances map[bin.Address][]x86.StackImbalance, failures []failure) *analysis {
+	a := &analysis{
+		Funcs:     []funcInfo{},
+		Calls:     []callEdge{},
+		Globals:   []globalInfo{},
+		Strings:   []stringInfo{},
+		Failures:  failures,
+		Conflicts: []conflict{},
+		Thunks:    []thunkInfo{},
+	}
+	if a.Failures == nil {
+		a.Failures = []failure{}
+	}
+	for _, funcAddr := range funcAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok {
+			continue
+		}
+		info := funcInfo{
+			Addr:       funcAddr,
+			Name:       f.Name,
+			Sig:        f.Sig.String(),
+			Blocks:     []blockRange{},
+			Imbalances: []stackImbalance{},
+			Crypto:     f.Crypto,
+			Yara:       yaraRules(f.Metadata),
+		}
+		for _, r := range f.Overlaps {
+			info.Overlaps = append(info.Overlaps, blockRange{Start: r.Start, End: r.End})
+		}
+		for _, r := range f.SMC {
+			info.SMC = append(info.SMC, blockRange{Start: r.Start, End: r.End})
+		}
+		for _, imb := range imbalances[funcAddr] {
+			info.Imbalances = append(info.Imbalances, stackImbalance{Addr: imb.Addr, Kind: imb.Kind, Got: imb.Got, Want: imb.Want})
+		}
+		if f.AsmFunc != nil {
+			var blockAddrs bin.Addresses
+			for blockAddr := range f.AsmFunc.Blocks {
+				blockAddrs = append(blockAddrs, blockAddr)
+			}
+			sort.Sort(blockAddrs)
+			for _, blockAddr := range blockAddrs {
+				block := f.AsmFunc.Blocks[blockAddr]
+				end := block.Term.Addr + bin.Address(block.Term.Len)
+				info.Blocks = append(info.Blocks, blockRange{Start: blockAddr, End: end})
+				for _, inst := range block.Insts {
+					a.addCall(l, funcAddr, inst)
+				}
+				a.addCall(l, funcAddr, block.Term)
+			}
+		}
+		a.Funcs = append(a.Funcs, info)
+	}
+	var thunks bin.Addresses
+	for thunk := range l.Thunks {
+		thunks = append(thunks, thunk)
+	}
+	sort.Sort(thunks)
+	for _, thunk := range thunks {
+		target := l.Thunks[thunk]
+		info := thunkInfo{Addr: thunk, Name: l.NamedThunks[thunk], Target: target}
+		if f, ok := l.Funcs[target]; ok {
+			info.TargetName = f.Name
+		}
+		a.Thunks = append(a.Thunks, info)
+	}
+	var globalAddrs bin.Addresses
+	for globalAddr := range l.Globals {
+		globalAddrs = append(globalAddrs, globalAddr)
+	}
+	sort.Sort(globalAddrs)
+	for _, globalAddr := range globalAddrs {
+		g := l.Globals[globalAddr]
+		if s, ok := l.Strings[globalAddr]; ok {
+			a.Strings = append(a.Strings, stringInfo{Addr: globalAddr, Name: s.Name, Text: stringText(s)})
+			continue
+		}
+		a.Globals = append(a.Globals, globalInfo{Addr: globalAddr, Name: g.Name, Type: g.Content.String(), Const: g.IsConst, Yara: yaraRules(g.Metadata)})
+	}
+	return a
+}
+
+// addConflicts records the given conflicting decodings of function discovery.
+func (a *analysis) addConflicts(conflicts []*disasm.Conflict) {
+	for _, c := range conflicts {
+		a.Conflicts = append(a.Conflicts, conflict{Addr: c.Addr, Kind: c.Kind, Func: c.Func, Score: c.Score, Other: c.Other, OtherScore: c.OtherScore, Resolution: c.Resolution})
+	}
+}
+
+// addCall records the call edge of the given instruction, if a CALL instruction
+// with static target.
+func (a *analysis) addCall(l *x86.Lifter, caller bin.Address, inst *disasm.Inst) {
+	callee, ok := disasm.CallTarget(inst)
+	if !ok {
+		return
+	}
+	// Calls through thunks are recorded as calls to their targets.
+	callee = l.ResolveThunk(callee)
+	edge := callEdge{
+		Caller: caller,
+		Site:   inst.Addr,
+		Callee: callee,
+	}
+	if f, ok := l.Funcs[callee]; ok {
+		edge.Name = f.Name
+	} else if name, ok := l.File.Imports[callee]; ok {
+		edge.Name = name
+	}
+	a.Calls = append(a.Calls, edge)
+}
+
+// stringText returns the contents of the given string literal, excluding NUL
+// terminator. The contents of wide string literals are recorded as "text"
+// metadata.
+func stringText(g *ir.Global) string {
+	if md, ok := g.Metadata["text"]; ok && len(md.Nodes) > 0 {
+		if s, ok := md.Nodes[0].(*metadata.String); ok {
+			return s.Val
+		}
+	}
+	if c, ok := g.Init.(*constant.CharArray); ok {
+		return string(bytes.TrimRight(c.X, "\x00"))
+	}
+	return ""
+}
+
+// storeAnalysis stores a JSON encoded representation of the analysis results to
+// the given file.
+func storeAnalysis(path string, a *analysis) error {
+	buf, err := json.MarshalIndent(a, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package bin2ll
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// isBitcode reports whether the given output path is the path of an LLVM
+// bitcode file (*.bc).
+func isBitcode(output string) bool {
+	return strings.EqualFold(filepath.Ext(output), ".bc")
+}
+
+// writeBitcode writes the given LLVM IR module as LLVM bitcode to the output
+// path, with the function attributes inferred by the lifter. The textual LLVM IR assembly is converted into bitcode using llvm-as,
+// as bitcode may be fed directly to opt and llc without a parse step.
+func writeBitcode(output string, l *x86.Lifter, m *ir.Module) error {
+	cmd := exec.Command("llvm-as", "-o", output, "-")
+	cmd.Stdin = strings.NewReader(l.FormatModule(m))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Errorf("unable to create LLVM bitcode file %q using llvm-as; %v", output, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package bin2ll
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	golden := []struct {
+		// Path to input binary executable.
+		in string
+		// Declarations expected exactly once in the output.
+		decls []string
+	}{
+		{
+			in: "testdata/x86_32/cache/cache.so",
+			decls: []string{
+				"declare void @asm.cpuid()",
+				"declare void @llvm.trap()",
+			},
+		},
+	}
+	for _, g := range golden {
+		dir, err := ioutil.TempDir("", "bin2ll")
+		if err != nil {
+			t.Fatalf("unable to create temporary directory; %+v", err)
+		}
+		defer os.RemoveAll(dir)
+		cacheDir := filepath.Join(dir, "cache")
+		// Lift the binary twice; first populating the cache, then loading each
+		// function from the cache.
+		var outputs [][]byte
+		for _, out := range []string{"cold.ll", "warm.ll"} {
+			outPath := filepath.Join(dir, out)
+			Main("bin2ll", []string{"-q", "-cache", cacheDir, "-o", outPath, g.in})
+			buf, err := ioutil.ReadFile(outPath)
+			if err != nil {
+				t.Errorf("%q: unable to read output; %+v", g.in, err)
+				continue
+			}
+			outputs = append(outputs, buf)
+		}
+		if len(outputs) != 2 {
+			continue
+		}
+		cold, warm := outputs[0], outputs[1]
+		if !bytes.Equal(cold, warm) {
+			t.Errorf("%q: output mismatch between cold and warm cache; expected:\n%s\ngot:\n%s", g.in, cold, warm)
+			continue
+		}
+		for _, decl := range g.decls {
+			if n := bytes.Count(warm, []byte(decl)); n != 1 {
+				t.Errorf("%q: expected one %q in output; got %d", g.in, decl, n)
+			}
+		}
+	}
+}
@@ -1,6 +1,6 @@
 //+build ignore
 
-package main
+package bin2ll
 
 import (
 	"fmt"
@@ -113,11 +113,18 @@ func (n Node) Attributes() []encoding.Attribute {
 }
 
 func genCallGraph(funcs map[bin.Address]*x86.Func) error {
+	// Add nodes in address order, as node IDs determine the output order.
+	var funcAddrs bin.Addresses
+	for funcAddr := range funcs {
+		funcAddrs = append(funcAddrs, funcAddr)
+	}
+	sort.Sort(funcAddrs)
 	for _, source := range sources {
 		nodes := make(map[string]graph.Node)
 		g := simple.NewDirectedGraph()
 		fmt.Println("source:", source.Name)
-		for addr, f := range funcs {
+		for _, addr := range funcAddrs {
+			f := funcs[addr]
 			if !(source.Start <= addr && addr <= source.End) {
 				continue
 			}
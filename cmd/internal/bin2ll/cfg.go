@@ -0,0 +1,114 @@
+package bin2ll
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// dumpCFGs stores the control flow graphs of the given function in Graphviz DOT
+// format to the specified output directory; both before (x86 assembly) and
+// after (LLVM IR) translation.
+//
+// Output files.
+//
+//    f_401000.asm.dot
+//    f_401000.ll.dot
+func dumpCFGs(dir string, l *x86.Lifter, f *x86.Func) error {
+	asmPath := filepath.Join(dir, f.Name+".asm.dot")
+	dbg.Printf("creating %q", asmPath)
+	if err := ioutil.WriteFile(asmPath, asmCFG(l, f), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	llPath := filepath.Join(dir, f.Name+".ll.dot")
+	dbg.Printf("creating %q", llPath)
+	if err := ioutil.WriteFile(llPath, llCFG(f.Function), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// asmCFG returns the control flow graph of the input assembly of the given
+// function in Graphviz DOT format. Nodes are labelled by basic block address
+// and terminator kind.
+func asmCFG(l *x86.Lifter, f *x86.Func) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "digraph %q {\n", f.Name)
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.AsmFunc.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	for _, blockAddr := range blockAddrs {
+		block := f.AsmFunc.Blocks[blockAddr]
+		kind := "fallthrough"
+		if !block.Term.IsDummyTerm() {
+			kind = strings.ToLower(block.Term.Op.String())
+		}
+		label := fmt.Sprintf("block_%06X\\n%s", uint64(blockAddr), kind)
+		fmt.Fprintf(buf, "\t%q [label=\"%s\"]\n", blockAddr.String(), label)
+	}
+	for _, blockAddr := range blockAddrs {
+		block := f.AsmFunc.Blocks[blockAddr]
+		targets := l.Targets(block.Term, f.AsmFunc.Addr)
+		for i, target := range targets {
+			fmt.Fprintf(buf, "\t%q -> %q%s\n", blockAddr.String(), target.String(), branchAttrs(i, len(targets)))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// llCFG returns the control flow graph of the given LLVM IR function in
+// Graphviz DOT format. Nodes are labelled by basic block name and terminator
+// kind.
+func llCFG(fn *ir.Function) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "digraph %q {\n", fn.Name)
+	ids := make(map[*ir.BasicBlock]string)
+	for i, block := range fn.Blocks {
+		ids[block] = fmt.Sprintf("b%d", i)
+	}
+	for i, block := range fn.Blocks {
+		name := block.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("%d", i)
+		}
+		kind := strings.ToLower(strings.TrimPrefix(fmt.Sprintf("%T", block.Term), "*ir.Term"))
+		fmt.Fprintf(buf, "\t%s [label=\"%s\\n%s\"]\n", ids[block], name, kind)
+	}
+	for _, block := range fn.Blocks {
+		if block.Term == nil {
+			continue
+		}
+		succs := block.Term.Succs()
+		for i, succ := range succs {
+			fmt.Fprintf(buf, "\t%s -> %s%s\n", ids[block], ids[succ], branchAttrs(i, len(succs)))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// branchAttrs returns the DOT attributes of the i:th outgoing edge of a basic
+// block with n successors. The edges of two-way conditional branches are
+// labelled true and false, respectively.
+func branchAttrs(i, n int) string {
+	if n != 2 {
+		return ""
+	}
+	if i == 0 {
+		// true branch.
+		return ` [label="true" color="darkgreen"]`
+	}
+	// false branch.
+	return ` [label="false" color="red"]`
+}
@@ -0,0 +1,253 @@
+package bin2ll
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/pkg/errors"
+)
+
+// A coverageReport records the fraction of each executable section claimed by
+// decoded instructions, for tracking lifter progress on a binary executable.
+type coverageReport struct {
+	// Number of bytes of executable sections.
+	Size int `json:"size"`
+	// Number of bytes claimed by decoded instructions.
+	Claimed int `json:"claimed"`
+	// Fraction of bytes claimed by decoded instructions; in range [0, 1].
+	Coverage float64 `json:"coverage"`
+	// Number of bytes of unclaimed gaps classified as padding (int3 sleds, nop
+	// padding and alignment zeros) or literal pools.
+	Benign int `json:"benign"`
+	// Number of bytes of unclaimed gaps classified as unanalyzed code.
+	Unanalyzed int `json:"unanalyzed"`
+	// Coverage of executable sections, sorted by address.
+	Sections []sectionCoverage `json:"sections"`
+}
+
+// A sectionCoverage records the coverage of an executable section.
+type sectionCoverage struct {
+	// Section name; or empty if unnamed section or memory segment.
+	Name string `json:"name"`
+	// Start address of the section.
+	Addr bin.Address `json:"addr"`
+	// Number of bytes of the section.
+	Size int `json:"size"`
+	// Number of bytes claimed by decoded instructions.
+	Claimed int `json:"claimed"`
+	// Fraction of bytes claimed by decoded instructions; in range [0, 1].
+	Coverage float64 `json:"coverage"`
+	// Number of bytes of unclaimed gaps classified as padding or literal pools.
+	Benign int `json:"benign"`
+	// Number of bytes of unclaimed gaps classified as unanalyzed code.
+	Unanalyzed int `json:"unanalyzed"`
+	// Unclaimed gaps of the section, sorted by address.
+	Gaps []coverageGap `json:"gaps"`
+}
+
+// A coverageGap records an address range of an executable section not claimed
+// by decoded instructions.
+type coverageGap struct {
+	// Start address of the gap.
+	Start bin.Address `json:"start"`
+	// End address of the gap (exclusive).
+	End bin.Address `json:"end"`
+	// Size in bytes of the gap.
+	Size int `json:"size"`
+	// Kind of gap; one of gapInt3, gapNop, gapZero, gapData and gapCode.
+	Kind string `json:"kind"`
+	// Name of the function claiming the bytes preceding the gap; or empty if
+	// at the start of the section.
+	Before string `json:"before,omitempty"`
+	// Name of the function claiming the bytes following the gap; or empty if
+	// at the end of the section.
+	After string `json:"after,omitempty"`
+}
+
+// A claim records an address range claimed by the decoded instructions of a
+// function.
+type claim struct {
+	// Start address of the claimed range.
+	start bin.Address
+	// End address of the claimed range (exclusive).
+	end bin.Address
+	// Name of the function claiming the range.
+	name string
+}
+
+// newCoverage returns the coverage report of the executable sections of the
+// binary executable, as claimed by the decoded instructions of the functions
+// at the given addresses.
+func newCoverage(l *x86.Lifter, funcAddrs []bin.Address) *coverageReport {
+	// Address ranges claimed by decoded instructions, merged and sorted by
+	// address.
+	var claims []claim
+	for _, funcAddr := range funcAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || f.AsmFunc == nil {
+			continue
+		}
+		for _, block := range f.AsmFunc.Blocks {
+			for _, inst := range append(block.Insts, block.Term) {
+				if inst.IsDummyTerm() {
+					continue
+				}
+				start := inst.Addr
+				claims = append(claims, claim{start: start, end: start + bin.Address(inst.Len), name: f.Name})
+			}
+		}
+	}
+	less := func(i, j int) bool {
+		return claims[i].start < claims[j].start
+	}
+	sort.Slice(claims, less)
+	var merged []claim
+	for _, c := range claims {
+		if n := len(merged); n > 0 && c.start <= merged[n-1].end && c.name == merged[n-1].name {
+			if c.end > merged[n-1].end {
+				merged[n-1].end = c.end
+			}
+			continue
+		}
+		merged = append(merged, c)
+	}
+	r := &coverageReport{
+		Sections: []sectionCoverage{},
+	}
+	for _, sect := range codeSections(l.File) {
+		sc := coverSection(l, sect, merged)
+		r.Size += sc.Size
+		r.Claimed += sc.Claimed
+		r.Benign += sc.Benign
+		r.Unanalyzed += sc.Unanalyzed
+		r.Sections = append(r.Sections, sc)
+	}
+	if r.Size > 0 {
+		r.Coverage = float64(r.Claimed) / float64(r.Size)
+	}
+	return r
+}
+
+// coverSection returns the coverage of the given executable section, based on
+// the specified claimed address ranges, sorted by address.
+func coverSection(l *x86.Lifter, sect *bin.Section, claims []claim) sectionCoverage {
+	size := len(sect.Data)
+	if sect.MemSize > size {
+		size = sect.MemSize
+	}
+	start, end := sect.Addr, sect.Addr+bin.Address(size)
+	sc := sectionCoverage{
+		Name: sect.Name,
+		Addr: sect.Addr,
+		Size: size,
+		Gaps: []coverageGap{},
+	}
+	// End address of the bytes claimed so far, and name of the function
+	// claiming them.
+	pos, before := start, ""
+	for _, c := range claims {
+		if c.end <= pos || c.start >= end {
+			continue
+		}
+		if c.start > pos {
+			sc.addGaps(l, sect, pos, c.start, before, c.name)
+			pos = c.start
+		}
+		cend := c.end
+		if cend > end {
+			cend = end
+		}
+		sc.Claimed += int(cend - pos)
+		pos, before = cend, c.name
+	}
+	if pos < end {
+		sc.addGaps(l, sect, pos, end, before, "")
+	}
+	if size > 0 {
+		sc.Coverage = float64(sc.Claimed) / float64(size)
+	}
+	return sc
+}
+
+// codeSections returns the executable sections of the given binary executable.
+// Memory segments are only included for executables lacking executable
+// sections.
+func codeSections(file *bin.File) []*bin.Section {
+	segs := make(map[*bin.Section]bool)
+	for _, seg := range file.Segments {
+		segs[seg] = true
+	}
+	var sects, segments []*bin.Section
+	for _, sect := range file.Sections {
+		if sect.Perm&bin.PermX == 0 {
+			continue
+		}
+		if segs[sect] {
+			segments = append(segments, sect)
+			continue
+		}
+		sects = append(sects, sect)
+	}
+	if len(sects) == 0 {
+		sects = segments
+	}
+	less := func(i, j int) bool {
+		return sects[i].Addr < sects[j].Addr
+	}
+	sort.Slice(sects, less)
+	return sects
+}
+
+// storeCoverage stores the coverage report to the given file; encoded as JSON
+// for *.json output paths, and as text otherwise.
+func storeCoverage(path string, r *coverageReport) error {
+	var buf []byte
+	if filepath.Ext(path) == ".json" {
+		var err error
+		if buf, err = json.MarshalIndent(r, "", "\t"); err != nil {
+			return errors.WithStack(err)
+		}
+		buf = append(buf, '\n')
+	} else {
+		buf = r.text()
+	}
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// text returns the text representation of the coverage report; e.g.
+//
+//    total: 1024 of 1280 bytes (80.00%); 16 bytes of padding and literal pools, 240 bytes unanalyzed
+//
+//    section .text at 0x401000: 1024 of 1280 bytes (80.00%); 16 bytes of padding and literal pools, 240 bytes unanalyzed
+//       code gap 0x401100-0x4011F0 (240 bytes) after f_4010A0, before f_401200
+//       int3 gap 0x4011F0-0x401200 (16 bytes) after f_4010A0, before f_401200
+func (r *coverageReport) text() []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "total: %d of %d bytes (%.2f%%); %d bytes of padding and literal pools, %d bytes unanalyzed\n", r.Claimed, r.Size, 100*r.Coverage, r.Benign, r.Unanalyzed)
+	for _, sc := range r.Sections {
+		fmt.Fprintf(buf, "\nsection %s at %v: %d of %d bytes (%.2f%%); %d bytes of padding and literal pools, %d bytes unanalyzed\n", sc.Name, sc.Addr, sc.Claimed, sc.Size, 100*sc.Coverage, sc.Benign, sc.Unanalyzed)
+		for _, gap := range sc.Gaps {
+			fmt.Fprintf(buf, "   %s gap %v-%v (%d bytes)", gap.Kind, gap.Start, gap.End, gap.Size)
+			if len(gap.Before) > 0 {
+				fmt.Fprintf(buf, " after %s", gap.Before)
+			}
+			if len(gap.After) > 0 {
+				if len(gap.Before) > 0 {
+					buf.WriteString(",")
+				}
+				fmt.Fprintf(buf, " before %s", gap.After)
+			}
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes()
+}
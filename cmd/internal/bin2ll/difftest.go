@@ -0,0 +1,646 @@
+package bin2ll
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decomp/exp/bin"
+	disasm "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Differential testing ] ################################################
+
+const (
+	// maxDiffSteps specifies the maximum number of instructions to emulate per
+	// input.
+	maxDiffSteps = 100000
+	// maxDiffGlobalSize specifies the maximum size in bytes of global variables
+	// compared by differential tests.
+	maxDiffGlobalSize = 0x10000
+	// diffRetAddr specifies the synthetic return address of emulated functions.
+	diffRetAddr = 0xFFFF0000
+	// diffTimeout specifies the time budget of each native execution.
+	diffTimeout = 5 * time.Second
+)
+
+// A diffReport records the results of differential testing of lifted
+// functions, as stored in difftest.json.
+type diffReport struct {
+	// Number of functions tested.
+	Funcs int `json:"funcs"`
+	// Number of inputs compared.
+	Inputs int `json:"inputs"`
+	// Number of inputs skipped, as the original code could not be emulated
+	// (e.g. unsupported instructions or unknown memory).
+	Skipped int `json:"skipped"`
+	// Mismatches between the native execution of lifted functions and the
+	// emulation of the original code.
+	Mismatches []diffMismatch `json:"mismatches"`
+}
+
+// A diffMismatch records a mismatch between the native execution of a lifted
+// function and the emulation of the original code, on a given input.
+type diffMismatch struct {
+	// Function name.
+	Func string `json:"func"`
+	// Entry address of the function.
+	Addr bin.Address `json:"addr"`
+	// Input arguments of the function.
+	Args []uint64 `json:"args"`
+	// Location of the mismatch; "ret" for the return value, "exec" for failed
+	// native execution (e.g. segmentation fault), or the name and byte offset
+	// of a global variable (e.g. "g_404000+4").
+	Loc string `json:"loc"`
+	// Emulated value of the original code.
+	Want string `json:"want"`
+	// Native value of the lifted function.
+	Got string `json:"got"`
+}
+
+// A diffFunc is a lifted function under differential test.
+type diffFunc struct {
+	// Lifted function.
+	f *x86.Func
+	// Global variables referenced by the function.
+	globals []diffGlobal
+}
+
+// A diffGlobal is a global variable referenced by a function under
+// differential test.
+type diffGlobal struct {
+	// Global variable name.
+	name string
+	// Address of the global variable.
+	addr bin.Address
+	// Size in bytes of the global variable.
+	size int
+}
+
+// A diffState records the observable state after running a function on a given
+// input.
+type diffState struct {
+	// Return value, masked to the size of the return type.
+	ret uint64
+	// Specifies whether the return value is known.
+	retKnown bool
+	// Contents of the global variables referenced by the function, indexed by
+	// name.
+	globals map[string][]byte
+	// Known bytes of the global variables referenced by the function, indexed
+	// by name; or nil if all bytes are known.
+	known map[string][]bool
+	// Failure of native execution (e.g. "signal: segmentation fault"); or
+	// empty on success.
+	failure string
+}
+
+// diffTest runs differential tests of the small lifted functions (i.e. leaf
+// functions with integer parameters and return values) of the given LLVM IR
+// module, using n generated inputs per function. The lifted module is compiled
+// into a test harness using llc and clang, and the return value and referenced
+// global variables of each lifted function are compared against those of the
+// emulation of the original code on the same inputs.
+func diffTest(l *x86.Lifter, m *ir.Module, funcAddrs []bin.Address, n int) (*diffReport, error) {
+	r := &diffReport{Mismatches: []diffMismatch{}}
+	var dfs []*diffFunc
+	for _, funcAddr := range funcAddrs {
+		if df, ok := newDiffFunc(l, funcAddr); ok {
+			dfs = append(dfs, df)
+		}
+	}
+	if len(dfs) == 0 {
+		warn.Printf("no lifted functions eligible for differential testing")
+		return r, nil
+	}
+	tmpDir, err := ioutil.TempDir("", "decomp-difftest-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	harness, err := buildHarness(tmpDir, l, m, dfs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for i, df := range dfs {
+		r.Funcs++
+		for _, args := range diffInputs(df.f, n) {
+			want, err := df.emulate(l, args)
+			if err != nil {
+				dbg.Printf("unable to emulate function %q on input %x; %v", df.f.Name, args, err)
+				r.Skipped++
+				continue
+			}
+			got, err := df.execute(harness, i, args)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			r.Inputs++
+			r.Mismatches = append(r.Mismatches, df.compare(args, want, got)...)
+		}
+	}
+	return r, nil
+}
+
+// newDiffFunc returns a new function under differential test, based on the
+// lifted function at the given address. The boolean return value indicates
+// whether the function is eligible for differential testing; i.e. whether it
+// is a lifted leaf function of 32- or 64-bit code with integer parameters and
+// return value.
+func newDiffFunc(l *x86.Lifter, funcAddr bin.Address) (*diffFunc, bool) {
+	if l.Mode != 32 && l.Mode != 64 {
+		return nil, false
+	}
+	f, ok := l.Funcs[funcAddr]
+	if !ok || f.AsmFunc == nil || len(f.Blocks) == 0 || f.Sig.Variadic {
+		return nil, false
+	}
+	if _, ok := cCallConvs[f.CallConv]; !ok {
+		return nil, false
+	}
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			if _, ok := inst.(*ir.InstCall); ok {
+				// Calls to functions, stubs or runtime helpers.
+				return nil, false
+			}
+		}
+	}
+	for _, param := range f.Sig.Params {
+		if t, ok := param.Typ.(*types.IntType); !ok || t.Size > 64 {
+			return nil, false
+		}
+	}
+	switch t := f.Sig.Ret.(type) {
+	case *types.VoidType:
+	case *types.IntType:
+		if t.Size > 64 {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	// Locate global variables referenced by the function.
+	globalAddrs := make(map[string]bin.Address)
+	for addr, g := range l.Globals {
+		globalAddrs[g.Name] = addr
+	}
+	df := &diffFunc{f: f}
+	for _, g := range l.FuncModule(f).Globals {
+		addr, ok := globalAddrs[g.Name]
+		if !ok {
+			// External global variable; e.g. data import.
+			continue
+		}
+		size := int(l.SizeOf(g.Content))
+		if size == 0 || size > maxDiffGlobalSize {
+			continue
+		}
+		df.globals = append(df.globals, diffGlobal{name: g.Name, addr: addr, size: size})
+	}
+	return df, true
+}
+
+// diffInputs returns n inputs of the parameters of the given function; boundary
+// values followed by pseudo-random values. Inputs are deterministic for each
+// function.
+func diffInputs(f *x86.Func, n int) [][]uint64 {
+	if len(f.Sig.Params) == 0 {
+		// Single input of functions without parameters.
+		return [][]uint64{nil}
+	}
+	boundary := []uint64{0, 1, ^uint64(0), 0x7FFFFFFF, 0x80000000}
+	r := rand.New(rand.NewSource(int64(f.AsmFunc.Addr)))
+	var inputs [][]uint64
+	for i := 0; i < n; i++ {
+		args := make([]uint64, len(f.Sig.Params))
+		for j, param := range f.Sig.Params {
+			var v uint64
+			switch {
+			case i < len(boundary):
+				v = boundary[(i+j)%len(boundary)]
+			case r.Intn(2) == 0:
+				// Small values; e.g. counts and indices.
+				v = uint64(r.Intn(256))
+			default:
+				v = r.Uint64()
+			}
+			args[j] = v & intMask(param.Typ)
+		}
+		inputs = append(inputs, args)
+	}
+	return inputs
+}
+
+// emulate emulates the original code of the function on the given input
+// arguments, up to the return of the function. Arguments are passed in the
+// registers and stack slots of their parameters, as identified by parameter
+// name (e.g. arg_ecx or arg_8).
+func (df *diffFunc) emulate(l *x86.Lifter, args []uint64) (*diffState, error) {
+	e := disasm.NewEmulator(l.File, l.Mode)
+	sp, ax, dx := x86asm.ESP, x86asm.EAX, x86asm.EDX
+	if l.Mode == 64 {
+		sp, ax, dx = x86asm.RSP, x86asm.RAX, x86asm.RDX
+	}
+	ptrSize := l.Mode / 8
+	top, _ := e.Reg(sp)
+	// Leave room for the stack arguments of the caller.
+	top -= 0x100
+	if err := e.SetReg(sp, top); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	e.SetMem(bin.Address(top), ptrSize, diffRetAddr)
+	for i, param := range df.f.Sig.Params {
+		loc := strings.TrimPrefix(param.Name, "arg_")
+		if offset, err := strconv.Atoi(loc); err == nil {
+			size := int(param.Typ.(*types.IntType).Size+7) / 8
+			e.SetMem(bin.Address(top)+bin.Address(offset), size, args[i])
+			continue
+		}
+		reg, ok := regByName(loc)
+		if !ok {
+			return nil, errors.Errorf("unable to locate register of parameter %q", param.Name)
+		}
+		if err := e.SetReg(reg, args[i]); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if err := e.Run(df.f.AsmFunc.Addr, diffRetAddr, maxDiffSteps); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &diffState{
+		globals: make(map[string][]byte),
+		known:   make(map[string][]bool),
+	}
+	if t, ok := df.f.Sig.Ret.(*types.IntType); ok {
+		lo, loKnown := e.Reg(ax)
+		s.ret, s.retKnown = lo, loKnown
+		if int(t.Size) > l.Mode {
+			// 64-bit return value of 32-bit code, in EDX:EAX.
+			hi, hiKnown := e.Reg(dx)
+			s.ret, s.retKnown = hi<<32|lo, loKnown && hiKnown
+		}
+		s.ret &= intMask(t)
+	}
+	for _, g := range df.globals {
+		buf := make([]byte, g.size)
+		known := make([]bool, g.size)
+		for i := range buf {
+			v, ok := e.Mem(g.addr+bin.Address(i), 1)
+			buf[i], known[i] = byte(v), ok
+		}
+		s.globals[g.name] = buf
+		s.known[g.name] = known
+	}
+	return s, nil
+}
+
+// execute runs the lifted function with the given index in the test harness on
+// the given input arguments.
+func (df *diffFunc) execute(harness string, index int, args []uint64) (*diffState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), diffTimeout)
+	defer cancel()
+	cmdArgs := []string{strconv.FormatInt(int64(index), 16)}
+	for _, arg := range args {
+		cmdArgs = append(cmdArgs, strconv.FormatUint(arg, 16))
+	}
+	cmd := exec.CommandContext(ctx, harness, cmdArgs...)
+	out, err := cmd.Output()
+	s := &diffState{globals: make(map[string][]byte)}
+	if err != nil {
+		if ctx.Err() != nil {
+			s.failure = "timeout"
+			return s, nil
+		}
+		if _, ok := err.(*exec.ExitError); ok {
+			s.failure = err.Error()
+			return s, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	// Parse output; e.g.
+	//
+	//    ret 000000000000002a
+	//    mem g_404000 2a000000
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(nil, 4*maxDiffGlobalSize)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		switch {
+		case len(fields) == 2 && fields[0] == "ret":
+			ret, err := strconv.ParseUint(fields[1], 16, 64)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			s.ret, s.retKnown = ret&intMask(df.f.Sig.Ret), true
+		case len(fields) == 3 && fields[0] == "mem":
+			buf, err := hex.DecodeString(fields[2])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			s.globals[fields[1]] = buf
+		default:
+			return nil, errors.Errorf("invalid output line %q of test harness", sc.Text())
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s, nil
+}
+
+// compare compares the native execution of the lifted function against the
+// emulation of the original code on the given input arguments, and returns the
+// mismatches. Unknown values of the emulation are not compared, and at most one
+// mismatch is reported per global variable.
+func (df *diffFunc) compare(args []uint64, want, got *diffState) []diffMismatch {
+	var mismatches []diffMismatch
+	add := func(loc, w, g string) {
+		m := diffMismatch{Func: df.f.Name, Addr: df.f.AsmFunc.Addr, Args: args, Loc: loc, Want: w, Got: g}
+		warn.Printf("differential test of function %q on input %x failed at %s; expected %s, got %s", m.Func, args, loc, w, g)
+		mismatches = append(mismatches, m)
+	}
+	if len(got.failure) > 0 {
+		add("exec", "return", got.failure)
+		return mismatches
+	}
+	if want.retKnown && got.retKnown && want.ret != got.ret {
+		add("ret", fmt.Sprintf("0x%X", want.ret), fmt.Sprintf("0x%X", got.ret))
+	}
+	for _, g := range df.globals {
+		wantBuf, gotBuf, known := want.globals[g.name], got.globals[g.name], want.known[g.name]
+		for i := range wantBuf {
+			if !known[i] {
+				continue
+			}
+			if i >= len(gotBuf) {
+				add(fmt.Sprintf("%s+%d", g.name, i), fmt.Sprintf("0x%02X", wantBuf[i]), "none")
+				break
+			}
+			if wantBuf[i] != gotBuf[i] {
+				add(fmt.Sprintf("%s+%d", g.name, i), fmt.Sprintf("0x%02X", wantBuf[i]), fmt.Sprintf("0x%02X", gotBuf[i]))
+				break
+			}
+		}
+	}
+	return mismatches
+}
+
+// regByName returns the general purpose register of the given lowercase name
+// (e.g. "ecx"). The boolean return value indicates success.
+func regByName(name string) (x86asm.Reg, bool) {
+	for reg := x86asm.AL; reg <= x86asm.R15; reg++ {
+		if strings.ToLower(disasm.Register(reg).String()) == name {
+			return reg, true
+		}
+	}
+	return 0, false
+}
+
+// intMask returns the bit mask of values of the given integer type; or 0 if not
+// an integer type.
+func intMask(t types.Type) uint64 {
+	it, ok := t.(*types.IntType)
+	if !ok {
+		return 0
+	}
+	if it.Size >= 64 {
+		return ^uint64(0)
+	}
+	return 1<<uint(it.Size) - 1
+}
+
+// storeDiffReport stores the given differential testing report to the
+// specified path in JSON format.
+func storeDiffReport(path string, r *diffReport) error {
+	buf, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ### [ Test harness ] ########################################################
+
+// cCallConvs maps from the LLVM IR calling conventions supported by test
+// harnesses to the corresponding C function attributes.
+var cCallConvs = map[ir.CallConv]string{
+	ir.CallConvNone:         "",
+	ir.CallConvC:            "",
+	ir.CallConvX86_FastCall: "__attribute__((fastcall)) ",
+	ir.CallConvX86_StdCall:  "__attribute__((stdcall)) ",
+	ir.CallConvX86_ThisCall: "__attribute__((thiscall)) ",
+	ir.CallConvX86_64_SysV:  "__attribute__((sysv_abi)) ",
+	ir.CallConvX86_64_Win64: "__attribute__((ms_abi)) ",
+}
+
+// harnessPrelude is the prelude of test harness drivers, providing the entry
+// point and output helpers of the driver.
+const harnessPrelude = `// Test harness driver of lifted module; generated by bin2ll.
+//
+// Usage: harness FUNC [ARG]...
+//
+// Runs the lifted function with the given index on the given arguments (in
+// hexadecimal), and prints the return value and the contents of the global
+// variables referenced by the function.
+
+#include "shim.c"
+
+#if defined(__x86_64__)
+#define DRIVER_SYS_EXIT 60
+
+__asm__(".globl driver_start\ndriver_start:\n\txor %ebp, %ebp\n\tmov %rsp, %rdi\n\tand $-16, %rsp\n\tcall driver_main\n\thlt\n");
+#else
+#define DRIVER_SYS_EXIT 1
+
+__asm__(".globl driver_start\ndriver_start:\n\txor %ebp, %ebp\n\tmov %esp, %eax\n\tand $-16, %esp\n\tsub $12, %esp\n\tpush %eax\n\tcall driver_main\n\thlt\n");
+#endif
+
+// driver_parse returns the value of the given hexadecimal string.
+static unsigned long long driver_parse(const char *s) {
+	unsigned long long v = 0;
+	for (; *s != 0; s++) {
+		if (*s >= '0' && *s <= '9') {
+			v = v<<4 | (unsigned long long)(*s - '0');
+		} else if (*s >= 'a' && *s <= 'f') {
+			v = v<<4 | (unsigned long long)(*s - 'a' + 10);
+		}
+	}
+	return v;
+}
+
+// driver_write writes the given string of n bytes to standard output.
+static void driver_write(const char *s, unsigned long n) {
+	shim_syscall(SHIM_SYS_WRITE, 1, (long)s, n, 0, 0, 0);
+}
+
+// driver_hex writes the given bytes to standard output in hexadecimal.
+static void driver_hex(const unsigned char *buf, unsigned long n) {
+	static const char digits[] = "0123456789abcdef";
+	char s[128];
+	unsigned long i = 0;
+	while (i < n) {
+		unsigned long j = 0;
+		for (; i < n && j < sizeof(s); i++) {
+			s[j++] = digits[buf[i]>>4];
+			s[j++] = digits[buf[i]&0xF];
+		}
+		driver_write(s, j);
+	}
+}
+
+// driver_ret prints the given return value.
+static void driver_ret(unsigned long long v) {
+	unsigned char buf[8];
+	for (int i = 0; i < 8; i++) {
+		buf[i] = (unsigned char)(v >> (8*(7-i)));
+	}
+	driver_write("ret ", 4);
+	driver_hex(buf, 8);
+	driver_write("\n", 1);
+}
+
+// driver_mem prints the contents of the given global variable.
+static void driver_mem(const char *name, const unsigned char *buf, unsigned long n) {
+	unsigned long len = 0;
+	while (name[len] != 0) {
+		len++;
+	}
+	driver_write("mem ", 4);
+	driver_write(name, len);
+	driver_write(" ", 1);
+	driver_hex(buf, n);
+	driver_write("\n", 1);
+}
+`
+
+// buildHarness compiles the given LLVM IR module into a test harness of the
+// given functions under differential test, within the specified directory, and
+// returns the path of the test harness executable. The harness is a static
+// Linux executable consisting of the lifted module, the runtime shim of the
+// module and a driver, which runs a lifted function on the input arguments
+// given on the command line.
+func buildHarness(dir string, l *x86.Lifter, m *ir.Module, dfs []*diffFunc) (string, error) {
+	llPath := filepath.Join(dir, "lifted.ll")
+	if err := writeModule(llPath, l, m); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err := writeShim(filepath.Join(dir, "shim.c"), m.Funcs); err != nil {
+		return "", errors.WithStack(err)
+	}
+	driverPath := filepath.Join(dir, "driver.c")
+	if err := ioutil.WriteFile(driverPath, harnessDriver(dfs), 0644); err != nil {
+		return "", errors.WithStack(err)
+	}
+	// Compile lifted module and driver for Linux, regardless of the file
+	// format of the binary executable.
+	triple := "i686-pc-linux-gnu"
+	if l.Mode == 64 {
+		triple = "x86_64-pc-linux-gnu"
+	}
+	objPath := filepath.Join(dir, "lifted.o")
+	if err := runTool("llc", "-filetype=obj", "-relocation-model=pic", "-mtriple="+triple, "-o", objPath, llPath); err != nil {
+		return "", errors.WithStack(err)
+	}
+	harness := filepath.Join(dir, "harness")
+	if err := runTool("clang", "-target", triple, "-static", "-nostdlib", "-ffreestanding", "-fno-pie", "-no-pie", "-Wl,-e,driver_start", "-o", harness, objPath, driverPath); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return harness, nil
+}
+
+// harnessDriver returns the C source code of the test harness driver of the
+// given functions under differential test.
+func harnessDriver(dfs []*diffFunc) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(harnessPrelude)
+	buf.WriteString("\n// Functions under test.\n")
+	for i, df := range dfs {
+		ret, _ := cType(df.f.Sig.Ret)
+		var params []string
+		for j, param := range df.f.Sig.Params {
+			typ, _ := cType(param.Typ)
+			params = append(params, cDecl(typ, fmt.Sprintf("a%d", j)))
+		}
+		if len(params) == 0 {
+			params = append(params, "void")
+		}
+		proto := cDecl(ret, fmt.Sprintf("t%d(%s)", i, strings.Join(params, ", ")))
+		fmt.Fprintf(buf, "%s%s __asm__(%q);\n", cCallConvs[df.f.CallConv], proto, df.f.Name)
+	}
+	buf.WriteString("\n// Global variables referenced by functions under test.\n")
+	globals := make(map[string]string)
+	for _, df := range dfs {
+		for _, g := range df.globals {
+			if _, ok := globals[g.name]; ok {
+				continue
+			}
+			globals[g.name] = fmt.Sprintf("d%d", len(globals))
+			fmt.Fprintf(buf, "extern unsigned char %s[] __asm__(%q);\n", globals[g.name], g.name)
+		}
+	}
+	buf.WriteString(`
+void driver_main(long *sp) {
+	int argc = (int)sp[0];
+	char **argv = (char **)(sp + 1);
+	unsigned long long args[16] = {0};
+	for (int i = 2; i < argc && i-2 < 16; i++) {
+		args[i-2] = driver_parse(argv[i]);
+	}
+	switch (argc < 2 ? -1 : (long)driver_parse(argv[1])) {
+`)
+	for i, df := range dfs {
+		var args []string
+		for j := range df.f.Sig.Params {
+			args = append(args, fmt.Sprintf("args[%d]", j))
+		}
+		call := fmt.Sprintf("t%d(%s)", i, strings.Join(args, ", "))
+		fmt.Fprintf(buf, "\tcase %d:\n", i)
+		if types.Equal(df.f.Sig.Ret, types.Void) {
+			fmt.Fprintf(buf, "\t\t%s;\n", call)
+		} else {
+			fmt.Fprintf(buf, "\t\tdriver_ret(%s);\n", call)
+		}
+		for _, g := range df.globals {
+			fmt.Fprintf(buf, "\t\tdriver_mem(%q, %s, %d);\n", g.name, globals[g.name], g.size)
+		}
+		buf.WriteString("\t\tbreak;\n")
+	}
+	buf.WriteString(`	}
+	shim_syscall(DRIVER_SYS_EXIT, 0, 0, 0, 0, 0, 0);
+}
+`)
+	return buf.Bytes()
+}
+
+// runTool runs the given external tool (e.g. llc) with the specified command
+// line arguments.
+func runTool(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Errorf("unable to run %s; %v\n%s", name, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
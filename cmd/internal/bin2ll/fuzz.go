@@ -0,0 +1,175 @@
+package bin2ll
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/pkg/errors"
+)
+
+// fuzzHeader is the header comment of libFuzzer harnesses; formatted with the
+// name of the lifted function.
+const fuzzHeader = `// libFuzzer harness of lifted function %s; generated by bin2ll.
+//
+// Build (e.g.):
+//
+//    llc -filetype=obj -relocation-model=pic lifted.ll -o lifted.o
+//    clang -fsanitize=fuzzer,address fuzz.c lifted.o -o fuzz
+//
+// Integer and floating-point parameters are read in order from the start of
+// the fuzz input (little-endian, zero-padded if the input is too short), and
+// pointer parameters receive separate heap-allocated copies of the remaining
+// input, terminated by a NUL byte. Imported functions of the lifted module are
+// resolved by the C standard library, and the system call and segment helpers
+// of the lifter are defined below.
+//
+// Note that global variables of the lifted module retain their values between
+// runs of the lifted function.
+
+`
+
+// fuzzHelpers are the helpers of libFuzzer harnesses used to marshal fuzz input
+// into the parameters of the lifted function.
+const fuzzHelpers = `
+#include <stddef.h>
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+
+// fuzz_read reads a little-endian value of n bytes from the fuzz input; zero
+// bytes are read beyond the end of the input.
+SHIM_UNUSED static uint64_t fuzz_read(const uint8_t **data, size_t *size, size_t n) {
+	uint64_t v = 0;
+	for (size_t i = 0; i < n; i++) {
+		if (i < *size) {
+			v |= (uint64_t)(*data)[i] << (8*i);
+		}
+	}
+	if (n > *size) {
+		n = *size;
+	}
+	*data += n;
+	*size -= n;
+	return v;
+}
+
+// fuzz_buf returns a heap-allocated copy of the given fuzz input, terminated by
+// a NUL byte.
+SHIM_UNUSED static void *fuzz_buf(const uint8_t *data, size_t size) {
+	uint8_t *buf = malloc(size + 1);
+	memcpy(buf, data, size);
+	buf[size] = 0;
+	return buf;
+}
+`
+
+// writeFuzz writes a libFuzzer harness of the specified lifted function (name
+// or address) of the given LLVM IR module to the output path. The harness
+// marshals fuzz input into the recovered parameters of the function; e.g.
+//
+//    int LLVMFuzzerTestOneInput(const uint8_t *data, size_t size) {
+//    	unsigned int a0 = (unsigned int)fuzz_read(&data, &size, 4);
+//    	void *a1 = fuzz_buf(data, size);
+//    	target(a0, a1);
+//    	free(a1);
+//    	return 0;
+//    }
+func writeFuzz(path string, l *x86.Lifter, m *ir.Module, funcName string) error {
+	f, err := fuzzTarget(l, funcName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, fuzzHeader, f.Name)
+	if err := writeShimDefs(buf, m.Funcs, false); err != nil {
+		return errors.WithStack(err)
+	}
+	buf.WriteString(fuzzHelpers)
+
+	// Prototype of target function.
+	ret, err := cType(f.Sig.Ret)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var params, args []string
+	var scalars, ptrs bytes.Buffer
+	for i, param := range f.Sig.Params {
+		typ, err := cType(param.Typ)
+		if err != nil {
+			return errors.Wrapf(err, "unable to marshal parameter %q of function %q", param.Name, f.Name)
+		}
+		arg := fmt.Sprintf("a%d", i)
+		params = append(params, cDecl(typ, arg))
+		args = append(args, arg)
+		switch t := param.Typ.(type) {
+		case *types.PointerType:
+			fmt.Fprintf(&ptrs, "\tvoid *%s = fuzz_buf(data, size);\n", arg)
+		case *types.IntType:
+			fmt.Fprintf(&scalars, "\t%s = (%s)fuzz_read(&data, &size, %d);\n", cDecl(typ, arg), typ, (t.Size+7)/8)
+		case *types.FloatType:
+			var size int
+			switch typ {
+			case "float":
+				size = 4
+			case "double":
+				size = 8
+			default:
+				return errors.Errorf("support for marshalling parameter %q of type %v of function %q not yet implemented", param.Name, param.Typ, f.Name)
+			}
+			fmt.Fprintf(&scalars, "\t%s;\n", cDecl(typ, arg))
+			fmt.Fprintf(&scalars, "\t{\n\t\tuint64_t v = fuzz_read(&data, &size, %d);\n\t\tmemcpy(&%s, &v, %d);\n\t}\n", size, arg, size)
+		}
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+	proto := cDecl(ret, fmt.Sprintf("target(%s)", strings.Join(params, ", ")))
+	fmt.Fprintf(buf, "\n// Lifted function under test.\n")
+	fmt.Fprintf(buf, "%s%s __asm__(%q);\n", cCallConvs[f.CallConv], proto, f.Name)
+
+	// Fuzz entry point.
+	buf.WriteString("\nint LLVMFuzzerTestOneInput(const uint8_t *data, size_t size) {\n")
+	buf.Write(scalars.Bytes())
+	buf.Write(ptrs.Bytes())
+	fmt.Fprintf(buf, "\ttarget(%s);\n", strings.Join(args, ", "))
+	for i, param := range f.Sig.Params {
+		if types.IsPointer(param.Typ) {
+			fmt.Fprintf(buf, "\tfree(a%d);\n", i)
+		}
+	}
+	buf.WriteString("\treturn 0;\n}\n")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// fuzzTarget returns the lifted function with the given name or address (e.g.
+// "f_401000" or "0x401000").
+func fuzzTarget(l *x86.Lifter, funcName string) (*x86.Func, error) {
+	var target *x86.Func
+	var funcAddr bin.Address
+	if err := funcAddr.Set(funcName); err == nil {
+		target = l.Funcs[funcAddr]
+	} else {
+		for _, f := range l.Funcs {
+			if f.Name == funcName {
+				target = f
+				break
+			}
+		}
+	}
+	if target == nil || len(target.Blocks) == 0 {
+		return nil, errors.Errorf("unable to locate lifted function %q", funcName)
+	}
+	if _, ok := cCallConvs[target.CallConv]; !ok {
+		return nil, errors.Errorf("support for calling convention %v of function %q not yet implemented", target.CallConv, target.Name)
+	}
+	return target, nil
+}
@@ -0,0 +1,211 @@
+package bin2ll
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Kinds of unclaimed gaps of executable sections.
+const (
+	// Sled of INT3 instructions (0xCC); e.g. padding between functions of MSVC.
+	gapInt3 = "int3"
+	// NOP padding, including multi-byte NOPs (e.g. `nop dword [eax+eax]`) and
+	// NOP equivalents (e.g. `lea esi, [esi]`); e.g. padding between functions
+	// of GCC.
+	gapNop = "nop"
+	// Alignment zeros.
+	gapZero = "zero"
+	// Literal pool; e.g. jump tables and pointers embedded in code.
+	gapData = "data"
+	// Genuinely unanalyzed code.
+	gapCode = "code"
+)
+
+// addGaps classifies the unclaimed gap [start, end) of the given section, and
+// appends it to the gaps of the section. The gap is split into runs of
+// padding, literal pools and code, by a linear sweep; code runs extend up to
+// the first unconditional jump or return instruction.
+func (sc *sectionCoverage) addGaps(l *x86.Lifter, sect *bin.Section, start, end bin.Address, before, after string) {
+	add := func(start, end bin.Address, kind string) {
+		size := int(end - start)
+		if kind == gapCode {
+			sc.Unanalyzed += size
+		} else {
+			sc.Benign += size
+		}
+		if n := len(sc.Gaps); n > 0 && sc.Gaps[n-1].End == start && sc.Gaps[n-1].Kind == kind {
+			// Merge adjacent gaps of the same kind.
+			sc.Gaps[n-1].End = end
+			sc.Gaps[n-1].Size += size
+			return
+		}
+		gap := coverageGap{Start: start, End: end, Size: size, Kind: kind, Before: before, After: after}
+		sc.Gaps = append(sc.Gaps, gap)
+	}
+	for addr := start; addr < end; {
+		// Literal pools are located before padding, as pointers may start with
+		// zero bytes.
+		if n := literalPool(l, sect, addr, end); n > 0 {
+			add(addr, addr+bin.Address(n), gapData)
+			addr += bin.Address(n)
+			continue
+		}
+		if n, kind := padding(l, sect, addr, end); n > 0 {
+			add(addr, addr+bin.Address(n), kind)
+			addr += bin.Address(n)
+			continue
+		}
+		n := codeRun(l, sect, addr, end)
+		add(addr, addr+bin.Address(n), gapCode)
+		addr += bin.Address(n)
+	}
+}
+
+// padding returns the length in bytes of the run of padding at the start of
+// the range [start, end) of the given section, and its kind.
+func padding(l *x86.Lifter, sect *bin.Section, start, end bin.Address) (int, string) {
+	b := sectByte(sect, start)
+	switch b {
+	case 0xCC, 0x00:
+		n := 0
+		for addr := start; addr < end && sectByte(sect, addr) == b; addr++ {
+			n++
+		}
+		if b == 0xCC {
+			return n, gapInt3
+		}
+		return n, gapZero
+	}
+	n := 0
+	for addr := start; addr < end; {
+		size, ok := nopAt(l, sect, addr, end)
+		if !ok {
+			break
+		}
+		n += size
+		addr += bin.Address(size)
+	}
+	return n, gapNop
+}
+
+// codeRun returns the length in bytes of the run of code at the start of the
+// range [start, end) of the given section, as decoded by a linear sweep up to
+// and including the first unconditional jump or return instruction. Bytes
+// which fail to decode are considered part of the run.
+func codeRun(l *x86.Lifter, sect *bin.Section, start, end bin.Address) int {
+	addr := start
+	for addr < end {
+		off := int(addr - sect.Addr)
+		if off >= len(sect.Data) {
+			return int(end - start)
+		}
+		code := sect.Data[off:]
+		if n := int(end - addr); n < len(code) {
+			code = code[:n]
+		}
+		inst, err := x86asm.Decode(code, l.Mode)
+		if err != nil {
+			addr++
+			continue
+		}
+		addr += bin.Address(inst.Len)
+		switch inst.Op {
+		case x86asm.RET, x86asm.LRET, x86asm.JMP, x86asm.LJMP:
+			return int(addr - start)
+		}
+	}
+	return int(end - start)
+}
+
+// nopAt returns the length in bytes of the NOP instruction (or NOP equivalent)
+// at the given address of the specified section, not extending past end. The
+// boolean return value indicates success.
+func nopAt(l *x86.Lifter, sect *bin.Section, addr, end bin.Address) (int, bool) {
+	off := int(addr - sect.Addr)
+	if off >= len(sect.Data) {
+		return 0, false
+	}
+	code := sect.Data[off:]
+	if n := int(end - addr); n < len(code) {
+		code = code[:n]
+	}
+	inst, err := x86asm.Decode(code, l.Mode)
+	if err != nil {
+		return 0, false
+	}
+	switch inst.Op {
+	case x86asm.NOP:
+		return inst.Len, true
+	case x86asm.MOV, x86asm.XCHG:
+		// E.g. `mov edi, edi` and `xchg ax, ax`.
+		if reg, ok := inst.Args[0].(x86asm.Reg); ok && inst.Args[1] == reg {
+			return inst.Len, true
+		}
+	case x86asm.LEA:
+		// E.g. `lea esi, [esi+0]` and `lea esi, [esi+eiz*1+0]`.
+		reg, ok := inst.Args[0].(x86asm.Reg)
+		mem, ok2 := inst.Args[1].(x86asm.Mem)
+		if ok && ok2 && mem.Base == reg && mem.Index == 0 && mem.Disp == 0 && mem.Segment == 0 {
+			return inst.Len, true
+		}
+	}
+	return 0, false
+}
+
+// minPoolWords specifies the minimum number of pointers of literal pools not
+// located through jump tables.
+const minPoolWords = 2
+
+// literalPool returns the length in bytes of the literal pool at the start of
+// the range [start, end) of the given section; i.e. a jump table, or a
+// sequence of aligned pointers into the sections of the binary executable. The
+// length is 0 if not a literal pool.
+func literalPool(l *x86.Lifter, sect *bin.Section, start, end bin.Address) int {
+	size := bin.Address(l.Mode / 8)
+	if targets, ok := l.Tables[start]; ok {
+		n := bin.Address(len(targets)) * size
+		if start+n > end {
+			n = end - start
+		}
+		return int(n)
+	}
+	if start%size != 0 {
+		return 0
+	}
+	order := l.File.Arch.ByteOrder()
+	addr := start
+	for ; addr+size <= end; addr += size {
+		off := int(addr - sect.Addr)
+		if off+int(size) > len(sect.Data) {
+			break
+		}
+		buf := sect.Data[off : off+int(size)]
+		var v uint64
+		switch size {
+		case 2:
+			v = uint64(order.Uint16(buf))
+		case 4:
+			v = uint64(order.Uint32(buf))
+		case 8:
+			v = order.Uint64(buf)
+		}
+		if v == 0 || l.File.Perm(bin.Address(v)) == 0 {
+			break
+		}
+	}
+	if addr-start < minPoolWords*size {
+		return 0
+	}
+	return int(addr - start)
+}
+
+// sectByte returns the byte at the given address of the specified section;
+// bytes past the initialized data of the section are zero.
+func sectByte(sect *bin.Section, addr bin.Address) byte {
+	off := int(addr - sect.Addr)
+	if off >= len(sect.Data) {
+		return 0
+	}
+	return sect.Data[off]
+}
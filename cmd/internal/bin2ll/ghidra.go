@@ -0,0 +1,189 @@
+package bin2ll
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/pkg/errors"
+)
+
+// ghidraHeader is the header of Ghidra XML exports.
+const ghidraHeader = `<?xml version="1.0" standalone="yes"?>
+<?program_dtd version="1"?>
+`
+
+// ghidraCallConvs maps from LLVM IR calling conventions to the calling
+// convention keywords of Ghidra prototypes of 32-bit functions.
+var ghidraCallConvs = map[ir.CallConv]string{
+	ir.CallConvNone:         "__cdecl",
+	ir.CallConvC:            "__cdecl",
+	ir.CallConvX86_FastCall: "__fastcall",
+	ir.CallConvX86_StdCall:  "__stdcall",
+	ir.CallConvX86_ThisCall: "__thiscall",
+}
+
+// storeGhidra stores the recovered functions, names and types of the given
+// analysis results as a Ghidra XML export, to be added to a Ghidra program of
+// the binary executable (File -> Add To Program...) or imported by the -ghidra
+// flag. Function prototypes are recorded as TYPEINFO_CMT, with parameters named
+// param_1, param_2, etc.
+func storeGhidra(path string, l *x86.Lifter, binPath string, a *analysis) error {
+	prog := &cli.GhidraProgram{
+		Name:      filepath.Base(binPath),
+		ExeFormat: l.File.Format,
+		ImageBase: cli.GhidraAddr(l.File.ImageBase),
+		Processor: &cli.GhidraProcessor{
+			Name:         "x86",
+			Endian:       "little",
+			AddressModel: fmt.Sprintf("%d-bit", l.Mode),
+		},
+	}
+	// Functions.
+	for _, info := range a.Funcs {
+		fn := cli.GhidraFunc{
+			Entry:   cli.GhidraAddr(info.Addr),
+			Name:    info.Name,
+			Library: "n",
+		}
+		f := l.Funcs[info.Addr]
+		if ret, size, ok := ghidraType(f.Sig.Ret, l.Mode); ok {
+			fn.Ret = &cli.GhidraType{DataType: ret, DataTypeNamespace: "/", Size: fmt.Sprintf("0x%x", size)}
+			if proto, ok := ghidraProto(l, f); ok {
+				fn.Proto = proto
+			}
+		}
+		// Merge adjacent basic blocks into address ranges.
+		for _, block := range info.Blocks {
+			if n := len(fn.Ranges); n > 0 && fn.Ranges[n-1].End == cli.GhidraAddr(block.Start-1) {
+				fn.Ranges[n-1].End = cli.GhidraAddr(block.End - 1)
+				continue
+			}
+			fn.Ranges = append(fn.Ranges, cli.GhidraRange{Start: cli.GhidraAddr(block.Start), End: cli.GhidraAddr(block.End - 1)})
+		}
+		prog.Funcs = append(prog.Funcs, fn)
+	}
+	// Global variables and string literals.
+	addSymbol := func(addr bin.Address, name, dataType string, size uint64) {
+		prog.Symbols = append(prog.Symbols, cli.GhidraSymbol{
+			Addr:       cli.GhidraAddr(addr),
+			Name:       name,
+			Type:       "global",
+			SourceType: "USER_DEFINED",
+			Primary:    "y",
+		})
+		if len(dataType) > 0 {
+			prog.Data = append(prog.Data, cli.GhidraData{
+				Addr:              cli.GhidraAddr(addr),
+				DataType:          dataType,
+				DataTypeNamespace: "/",
+				Size:              fmt.Sprintf("0x%x", size),
+			})
+		}
+	}
+	for _, g := range a.Globals {
+		dataType, size, ok := ghidraType(l.Globals[g.Addr].Content, l.Mode)
+		if !ok || size == 0 {
+			dataType = ""
+		}
+		addSymbol(g.Addr, g.Name, dataType, size)
+	}
+	for _, s := range a.Strings {
+		dataType, size := "", uint64(0)
+		if t, ok := l.Strings[s.Addr].Content.(*types.ArrayType); ok {
+			switch {
+			case types.Equal(t.Elem, types.I8):
+				dataType, size = "string", uint64(t.Len)
+			case types.Equal(t.Elem, types.I16):
+				dataType, size = "unicode", 2*uint64(t.Len)
+			}
+		}
+		addSymbol(s.Addr, s.Name, dataType, size)
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString(ghidraHeader)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "    ")
+	if err := enc.Encode(prog); err != nil {
+		return errors.WithStack(err)
+	}
+	buf.WriteString("\n")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ghidraProto returns the prototype of the given function in C syntax, with
+// Ghidra data type names; e.g. "uint __stdcall f_401000(uint param_1)". The
+// boolean return value indicates success.
+func ghidraProto(l *x86.Lifter, f *x86.Func) (string, bool) {
+	ret, _, ok := ghidraType(f.Sig.Ret, l.Mode)
+	if !ok {
+		return "", false
+	}
+	var params []string
+	for i, param := range f.Sig.Params {
+		typ, _, ok := ghidraType(param.Typ, l.Mode)
+		if !ok {
+			return "", false
+		}
+		params = append(params, fmt.Sprintf("%s param_%d", typ, i+1))
+	}
+	switch {
+	case f.Sig.Variadic:
+		params = append(params, "...")
+	case len(params) == 0:
+		params = append(params, "void")
+	}
+	callconv := ""
+	if l.Mode == 32 {
+		if cc, ok := ghidraCallConvs[f.CallConv]; ok {
+			callconv = cc + " "
+		}
+	}
+	return fmt.Sprintf("%s %s%s(%s)", ret, callconv, f.Name, strings.Join(params, ", ")), true
+}
+
+// ghidraType returns the name and size in bytes of the Ghidra built-in data type
+// corresponding to the given LLVM IR type of the specified processor mode. The
+// boolean return value indicates success.
+func ghidraType(t types.Type, mode int) (string, uint64, bool) {
+	switch t := t.(type) {
+	case *types.VoidType:
+		return "void", 0, true
+	case *types.IntType:
+		switch t.Size {
+		case 1:
+			return "bool", 1, true
+		case 8:
+			return "uchar", 1, true
+		case 16:
+			return "ushort", 2, true
+		case 32:
+			return "uint", 4, true
+		case 64:
+			return "ulonglong", 8, true
+		}
+	case *types.FloatType:
+		switch t.Kind {
+		case types.FloatKindIEEE_32:
+			return "float", 4, true
+		case types.FloatKindIEEE_64:
+			return "double", 8, true
+		case types.FloatKindDoubleExtended_80:
+			return "float10", 10, true
+		}
+	case *types.PointerType:
+		return "pointer", uint64(mode / 8), true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,275 @@
+package bin2ll
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/decomp/exp/rpc"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rpcServer implements the gRPC interface of the lifter (see rpc/decomp.proto),
+// sharing the uploaded binary executables of the HTTP API.
+type rpcServer struct {
+	*server
+}
+
+// rpcStates maps from the states of jobs to their protocol buffer enum values.
+var rpcStates = map[string]rpc.State{
+	stateQueued:   rpc.State_STATE_QUEUED,
+	stateDecoding: rpc.State_STATE_DECODING,
+	stateLifting:  rpc.State_STATE_LIFTING,
+	stateDone:     rpc.State_STATE_DONE,
+	stateFailed:   rpc.State_STATE_FAILED,
+}
+
+// rpcFuncStates maps from the states of functions to their protocol buffer enum
+// values.
+var rpcFuncStates = map[string]rpc.FuncState{
+	funcPending: rpc.FuncState_FUNC_PENDING,
+	funcLifted:  rpc.FuncState_FUNC_LIFTED,
+	funcFailed:  rpc.FuncState_FUNC_FAILED,
+}
+
+// Upload uploads a binary executable, and starts lifting it.
+func (s *rpcServer) Upload(ctx context.Context, in *rpc.UploadRequest) (*rpc.Binary, error) {
+	j, err := s.addJob(in.Name, bytes.NewReader(in.Data))
+	if err != nil {
+		code := codes.InvalidArgument
+		if errors.Cause(err) == errQueueFull {
+			code = codes.ResourceExhausted
+		}
+		return nil, status.Errorf(code, "%v", err)
+	}
+	st, _ := j.snapshot()
+	return rpcBinary(st), nil
+}
+
+// ListBinaries lists the uploaded binary executables, in order of upload.
+func (s *rpcServer) ListBinaries(ctx context.Context, in *rpc.ListBinariesRequest) (*rpc.ListBinariesResponse, error) {
+	s.mu.Lock()
+	var jobs []*job
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+	out := &rpc.ListBinariesResponse{}
+	for _, j := range jobs {
+		st, _ := j.snapshot()
+		out.Binaries = append(out.Binaries, rpcBinary(st))
+	}
+	less := func(i, j int) bool {
+		return jobLess(out.Binaries[i].ID, out.Binaries[j].ID)
+	}
+	sort.Slice(out.Binaries, less)
+	return out, nil
+}
+
+// GetBinary returns the status of lifting a binary executable.
+func (s *rpcServer) GetBinary(ctx context.Context, in *rpc.BinaryRequest) (*rpc.Binary, error) {
+	j, err := s.rpcJob(in.ID)
+	if err != nil {
+		return nil, err
+	}
+	st, _ := j.snapshot()
+	return rpcBinary(st), nil
+}
+
+// Progress streams the status of lifting a binary executable, each time the
+// status changes, until lifting is done or failed.
+func (s *rpcServer) Progress(in *rpc.BinaryRequest, stream rpc.Decomp_ProgressServer) error {
+	j, err := s.rpcJob(in.ID)
+	if err != nil {
+		return err
+	}
+	for {
+		st, changed := j.snapshot()
+		if err := stream.Send(rpcBinary(st)); err != nil {
+			return err
+		}
+		if st.State == stateDone || st.State == stateFailed {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// RemoveBinary cancels lifting and removes a binary executable.
+func (s *rpcServer) RemoveBinary(ctx context.Context, in *rpc.BinaryRequest) (*rpc.RemoveBinaryResponse, error) {
+	j, err := s.rpcJob(in.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.removeJob(j)
+	return &rpc.RemoveBinaryResponse{}, nil
+}
+
+// ListFunctions lists the status of lifting the functions of a binary
+// executable, in order of address; without basic blocks and LLVM IR.
+func (s *rpcServer) ListFunctions(ctx context.Context, in *rpc.BinaryRequest) (*rpc.ListFunctionsResponse, error) {
+	j, err := s.rpcJob(in.ID)
+	if err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := &rpc.ListFunctionsResponse{}
+	for _, f := range j.funcs {
+		out.Funcs = append(out.Funcs, &rpc.Function{
+			Addr:  uint64(f.Addr),
+			Name:  f.Name,
+			State: rpcFuncStates[f.State],
+			Error: f.Err,
+		})
+	}
+	return out, nil
+}
+
+// GetFunction returns a lifted function, with basic blocks, instructions and
+// LLVM IR.
+func (s *rpcServer) GetFunction(ctx context.Context, in *rpc.FunctionRequest) (*rpc.Function, error) {
+	j, err := s.rpcReady(in.ID)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := j.lookup(in.Func)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unable to locate lifted function %q", in.Func)
+	}
+	out := &rpc.Function{
+		Addr:   uint64(f.AsmFunc.Addr),
+		Name:   f.Name,
+		State:  rpc.FuncState_FUNC_LIFTED,
+		Sig:    f.Sig.String(),
+		LLVMIR: j.l.FormatModule(j.l.FuncModule(f)),
+		Cfg:    string(llCFG(f.Function)),
+	}
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.AsmFunc.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	for _, blockAddr := range blockAddrs {
+		out.Blocks = append(out.Blocks, rpcBlock(j.l, f, blockAddr))
+	}
+	return out, nil
+}
+
+// GetAnalysis returns the analysis results of a lifted binary executable.
+func (s *rpcServer) GetAnalysis(ctx context.Context, in *rpc.BinaryRequest) (*rpc.Analysis, error) {
+	j, err := s.rpcReady(in.ID)
+	if err != nil {
+		return nil, err
+	}
+	return rpcAnalysis(j.analysis), nil
+}
+
+// rpcJob returns the job of the uploaded binary executable with the given ID.
+func (s *rpcServer) rpcJob(id string) (*job, error) {
+	j, ok := s.job(id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown binary executable %q", id)
+	}
+	return j, nil
+}
+
+// rpcReady returns the job of the uploaded binary executable with the given ID,
+// once lifting is done.
+func (s *rpcServer) rpcReady(id string) (*job, error) {
+	j, err := s.rpcJob(id)
+	if err != nil {
+		return nil, err
+	}
+	st, _ := j.snapshot()
+	if st.State != stateDone {
+		return nil, status.Errorf(codes.FailedPrecondition, "binary executable %q not lifted (state %s)", st.Name, st.State)
+	}
+	return j, nil
+}
+
+// rpcBinary returns the protocol buffer message of the given job status.
+func rpcBinary(st jobStatus) *rpc.Binary {
+	return &rpc.Binary{
+		ID:       st.ID,
+		Name:     st.Name,
+		State:    rpcStates[st.State],
+		Done:     int64(st.Done),
+		Total:    int64(st.Total),
+		Failures: int64(st.Failures),
+		Error:    st.Err,
+	}
+}
+
+// rpcBlock returns the protocol buffer message of the basic block at the given
+// address of the lifted function.
+func rpcBlock(l *x86.Lifter, f *x86.Func, blockAddr bin.Address) *rpc.BasicBlock {
+	block := f.AsmFunc.Blocks[blockAddr]
+	out := &rpc.BasicBlock{
+		Addr: uint64(blockAddr),
+		End:  uint64(block.Term.Addr) + uint64(block.Term.Len),
+	}
+	insts := block.Insts
+	if !block.Term.IsDummyTerm() {
+		insts = append(insts[:len(insts):len(insts)], block.Term)
+	}
+	for _, inst := range insts {
+		code := l.File.Code(inst.Addr)
+		if len(code) > inst.Len {
+			code = code[:inst.Len]
+		}
+		out.Insts = append(out.Insts, &rpc.Instruction{
+			Addr: uint64(inst.Addr),
+			Code: code,
+			Asm:  x86asm.IntelSyntax(inst.Inst, uint64(inst.Addr), nil),
+		})
+	}
+	for _, target := range l.Targets(block.Term, f.AsmFunc.Addr) {
+		out.Succs = append(out.Succs, uint64(target))
+	}
+	return out
+}
+
+// rpcAnalysis returns the protocol buffer message of the given analysis
+// results.
+func rpcAnalysis(a *analysis) *rpc.Analysis {
+	out := &rpc.Analysis{}
+	for _, info := range a.Funcs {
+		f := &rpc.FuncInfo{
+			Addr:   uint64(info.Addr),
+			Name:   info.Name,
+			Sig:    info.Sig,
+			Crypto: info.Crypto,
+			Yara:   info.Yara,
+		}
+		for _, block := range info.Blocks {
+			f.Blocks = append(f.Blocks, &rpc.BlockRange{Start: uint64(block.Start), End: uint64(block.End)})
+		}
+		for _, imbalance := range info.Imbalances {
+			f.Imbalances = append(f.Imbalances, &rpc.StackImbalance{Addr: uint64(imbalance.Addr), Kind: imbalance.Kind, Got: imbalance.Got, Want: imbalance.Want})
+		}
+		out.Funcs = append(out.Funcs, f)
+	}
+	for _, call := range a.Calls {
+		out.Calls = append(out.Calls, &rpc.CallEdge{Caller: uint64(call.Caller), Site: uint64(call.Site), Callee: uint64(call.Callee), Name: call.Name})
+	}
+	for _, g := range a.Globals {
+		out.Globals = append(out.Globals, &rpc.Global{Addr: uint64(g.Addr), Name: g.Name, Type: g.Type, Const: g.Const, Yara: g.Yara})
+	}
+	for _, s := range a.Strings {
+		out.Strings = append(out.Strings, &rpc.StringLiteral{Addr: uint64(s.Addr), Name: s.Name, Text: s.Text})
+	}
+	for _, fail := range a.Failures {
+		out.Failures = append(out.Failures, &rpc.Failure{Addr: uint64(fail.Addr), Name: fail.Name, Stage: fail.Stage, Error: fail.Err})
+	}
+	return out
+}
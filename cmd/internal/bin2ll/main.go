@@ -0,0 +1,585 @@
+// Package bin2ll implements the bin2ll tool, which lifts binary executables to
+// equivalent LLVM IR assembly (*.exe -> *.ll).
+package bin2ll
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/cmd/internal/cli"
+	disasm "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/decomp/exp/logging"
+	"github.com/llir/llvm/ir"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "bin2ll:" prefix, which logs debug
+	// messages to standard error.
+	dbg = logging.New("bin2ll", logging.LevelDebug, term.MagentaBold("bin2ll:")+" ")
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = logging.New("bin2ll", logging.LevelInfo, term.RedBold("warning:")+" ")
+	// trace represents a logger with the "bin2ll:" prefix, which logs detailed
+	// tracing messages to standard error.
+	trace = logging.New("bin2ll", logging.LevelTrace, term.MagentaBold("bin2ll:")+" ")
+)
+
+// Main runs the bin2ll tool with the given command name (e.g. "decomp lift")
+// and command line arguments.
+func Main(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// app specifies whether to skip functions of the startup code of the C
+		// runtime, and only lift application code.
+		app bool
+		// sel specifies the functions to lift.
+		sel cli.FuncSelector
+		// loader specifies how to parse the binary executable.
+		loader cli.Loader
+		// mem2reg specifies whether to promote registers and status flags into
+		// SSA values.
+		mem2reg bool
+		// output specifies the output path.
+		output string
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+		// logLevel specifies the verbosity level of log messages.
+		logLevel = logging.LevelInfo
+		// logFormat specifies the output format of log messages.
+		logFormat string
+		// strict specifies whether to panic on unsupported instructions.
+		strict bool
+		// cont specifies whether to continue lifting when a function fails to
+		// be decoded or lifted.
+		cont bool
+		// debugAddrs specifies whether to attach machine code address metadata
+		// to lifted instructions.
+		debugAddrs bool
+		// asmAnnotations specifies whether to attach the original disassembly
+		// to lifted instructions.
+		asmAnnotations bool
+		// aliasAnnotations specifies whether to attach alias scope metadata to
+		// lifted loads and stores.
+		aliasAnnotations bool
+		// constGlobals specifies whether to mark write-once global variables
+		// constant.
+		constGlobals bool
+		// cfgDir specifies the output directory of control flow graphs.
+		cfgDir string
+		// jsonPath specifies the output path of JSON analysis results.
+		jsonPath string
+		// ghidraOut specifies the output path of the Ghidra XML export of
+		// recovered functions, names and types.
+		ghidraOut string
+		// coveragePath specifies the output path of the coverage report.
+		coveragePath string
+		// cacheDir specifies the directory of the on-disk cache of lifted
+		// functions.
+		cacheDir string
+		// timeout specifies the time budget of decoding and lifting each
+		// function; or 0 if unlimited.
+		timeout time.Duration
+		// hybrid specifies whether to locate functions by recursive traversal of
+		// call targets and linear sweep of unclaimed code.
+		hybrid bool
+		// headers specifies C header files of function prototypes.
+		headers cli.StringList
+		// triple specifies the target triple of the output; or empty to use the
+		// target triple of the binary executable.
+		triple string
+		// dataLayout specifies the data layout of the output; or empty to use
+		// the data layout of the binary executable.
+		dataLayout string
+		// prune specifies whether to prune infeasible edges of conditional
+		// branches, as determined by symbolic execution.
+		prune bool
+		// splitDir specifies the output directory of one LLVM IR file per
+		// lifted function; or empty to output a single LLVM IR module.
+		splitDir string
+		// yaraPath specifies the YARA rules file to scan sections with; or empty
+		// to skip YARA scanning.
+		yaraPath string
+		// shimPath specifies the output path of the C runtime shim of the lifted
+		// module; or empty to skip shim generation.
+		shimPath string
+		// fuzzFunc specifies the name or address of the lifted function to
+		// generate a libFuzzer harness for; or empty to skip harness generation.
+		fuzzFunc string
+		// diffInputs specifies the number of generated inputs per function for
+		// differential testing; or 0 to skip differential testing.
+		diffInputs int
+		// taintSources specifies the comma-separated taint sources of taint
+		// analysis; or empty to skip taint analysis.
+		taintSources string
+		// trapDiv specifies whether to check the divisor of divisions, trapping
+		// if zero.
+		trapDiv bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+Lift binary executables to equivalent LLVM IR assembly (*.exe -> *.ll).
+
+Usage:
+
+	%s [OPTION]... FILE
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&aliasAnnotations, "alias", false, "attach alias scope metadata distinguishing stack slots, global variables and external memory to lifted loads and stores")
+	flags.BoolVar(&app, "app", false, "only lift application code; skip functions of the startup code of the C runtime (reachable from the entry point but not from main)")
+	flags.BoolVar(&asmAnnotations, "asm", false, "attach original x86 disassembly as metadata to lifted instructions")
+	flags.StringVar(&cacheDir, "cache", "", "directory of on-disk cache of lifted functions; only functions with changed machine code, signatures or settings are re-lifted")
+	flags.StringVar(&cfgDir, "cfg", "", "output directory of control flow graphs (*.dot) before and after translation")
+	flags.StringVar(&coveragePath, "coverage", "", "output path of coverage report of executable sections claimed by decoded instructions, and unclaimed gaps (JSON for *.json output paths, text otherwise)")
+	flags.BoolVar(&cont, "continue", false, "continue on failure to decode or lift a function; failures are reported in failures.json")
+	flags.BoolVar(&constGlobals, "constglobals", false, "mark global variables written exactly once with a constant by initialization code (startup code before main, or the entry block of the entry point or main before any call) as constant")
+	flags.IntVar(&diffInputs, "difftest", 0, "number of generated inputs per function for differential testing of lifted leaf functions with integer parameters, compiled using llc and clang, against emulation of the original code; mismatches of return values and global variables are reported in difftest.json")
+	flags.StringVar(&dataLayout, "datalayout", "", "data layout of output (default based on architecture and file format of the binary executable)")
+	flags.StringVar(&fuzzFunc, "fuzz", "", "name or address of lifted function to generate a libFuzzer harness for (fuzz.c), marshalling fuzz input into the recovered parameters of the function")
+	flags.BoolVar(&debugAddrs, "g", false, "attach machine code address and length metadata (!addr and !len; not DWARF debug information) to lifted instructions")
+	flags.StringVar(&ghidraOut, "ghidra-out", "", "output path of Ghidra XML export of recovered functions, names and types, to add to the Ghidra program of the executable (File -> Add To Program...) or to import using -ghidra")
+	flags.Var(&headers, "header", "C header file of function prototypes of imported and known functions (may be repeated)")
+	flags.BoolVar(&hybrid, "hybrid", false, "locate functions by recursive traversal of call targets and linear sweep of unclaimed bytes of executable sections; conflicting decodings (overlapping instructions and data decoded as code) are resolved by weighted heuristics and reported in the -json analysis results")
+	flags.StringVar(&jsonPath, "json", "", "output path of JSON analysis results (functions, basic blocks, call graph, globals, strings, stack imbalances, failures, conflicting decodings and thunks)")
+	flags.BoolVar(&mem2reg, "mem2reg", false, "promote registers and status flags into SSA values")
+	flags.StringVar(&output, "o", "", "output path; LLVM bitcode is emitted for *.bc output paths (using llvm-as)")
+	flags.Var(&logLevel, "log-level", "verbosity level of log messages (quiet, info, debug or trace)")
+	flags.StringVar(&logFormat, "log-format", "text", "output format of log messages (text or json)")
+	flags.BoolVar(&prune, "prune", false, "prune infeasible edges of conditional branches (e.g. opaque predicates) and unreachable basic blocks, as determined by symbolic execution")
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flags.StringVar(&taintSources, "taint", "", fmt.Sprintf("comma-separated taint sources (%s) of forward taint analysis; untrusted data reaching parameters, return values and sinks (e.g. strcpy and system) is reported per function in taint.json", strings.Join(x86.TaintSources(), ", ")))
+	flags.DurationVar(&timeout, "timeout", 0, "time budget of decoding and lifting each function (e.g. 10s); functions exceeding the budget are skipped and reported in failures.json")
+	flags.StringVar(&shimPath, "shim", "", "output path of C runtime shim providing stubs of imported functions, and the system call and segment helpers referenced by the lifted module (e.g. shim.c)")
+	flags.StringVar(&splitDir, "split", "", "output directory of one LLVM IR file per lifted function (f_XXXXXX.ll), global variables (globals.ll) and module index (index.json)")
+	flags.BoolVar(&strict, "strict", false, "panic on unsupported instructions, rather than emitting stub calls")
+	flags.StringVar(&triple, "triple", "", "target triple of output (default based on architecture and file format of the binary executable; e.g. i686-pc-windows-msvc)")
+	flags.BoolVar(&trapDiv, "trapdiv", false, "check DIV and IDIV instructions for division by zero and quotient overflow, calling llvm.trap if so; models the divide error exception rather than the undefined behaviour of division by zero in LLVM IR")
+	flags.StringVar(&yaraPath, "yara", "", "YARA rules file to scan sections with (using yara); names of matching rules are attached as metadata to the overlapping functions and global variables")
+	sel.RegisterFlags(flags, "lift")
+	loader.RegisterFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	binPath := flags.Arg(0)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		logLevel = logging.LevelQuiet
+	}
+	logging.SetLevel(logLevel)
+	if err := logging.SetFormat(logFormat); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Prepare x86 to LLVM IR lifter for the binary executable.
+	file, err := loader.ParseFile(binPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if loader.Project != nil {
+		sel.Exclude = loader.Project.Exclude
+	}
+	l, err := x86.NewLifter(file)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	l.Strict = strict
+	l.DebugAddrs = debugAddrs
+	l.AsmAnnotations = asmAnnotations
+	l.AliasAnnotations = aliasAnnotations
+	l.ConstGlobals = constGlobals
+	l.TrapDiv = trapDiv
+	l.Prune = prune
+	l.KnownStrings = loader.Strings
+
+	// Refine function signatures based on prototypes of C headers.
+	for _, headerPath := range headers {
+		protos, err := l.ParseHeaderFile(headerPath)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		l.AddProtos(protos)
+	}
+
+	// Apply function signatures and names of the project file.
+	if loader.Project != nil {
+		if err := loader.Project.ApplyLifter(l); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Lift basic block.
+	if sel.Block != 0 {
+		block, err := l.DecodeBlock(sel.Block)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		_ = block
+		return
+	}
+
+	// Cancel decoding and lifting on interrupt.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		<-sigs
+		warn.Printf("interrupted; cancelling")
+		cancel()
+	}()
+
+	// Locate functions by recursive traversal and linear sweep.
+	var conflicts []*disasm.Conflict
+	if hybrid && sel.Func == 0 {
+		if conflicts, err = l.DiscoverFuncs(ctx, timeout); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		if len(conflicts) > 0 {
+			warn.Printf("%d conflicting decodings resolved; see conflicts of -json analysis results", len(conflicts))
+		}
+	}
+
+	// Lift function specified by `-func` flag.
+	var funcAddrs bin.Addresses
+	if sel.Func != 0 {
+		funcAddrs = []bin.Address{sel.Func}
+	} else {
+		var startup map[bin.Address]bool
+		if app {
+			if l.Main != 0 {
+				startup = l.StartupFuncs()
+				dbg.Printf("skipping %d functions of startup code", len(startup))
+			} else {
+				warn.Printf("unable to locate main function; lifting startup code")
+			}
+		}
+		for _, funcAddr := range sel.Select(l.FuncAddrs) {
+			if startup[funcAddr] {
+				// skip functions of startup code.
+				continue
+			}
+			if _, ok := l.Thunks[funcAddr]; ok {
+				// skip thunks; references resolve to their targets.
+				continue
+			}
+			funcAddrs = append(funcAddrs, funcAddr)
+		}
+	}
+
+	// Create output directory of control flow graphs.
+	if len(cfgDir) > 0 {
+		if err := os.MkdirAll(cfgDir, 0755); err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	// funcContext returns the context of decoding or lifting a single function,
+	// limited by the per-function time budget.
+	funcContext := func() (context.Context, context.CancelFunc) {
+		if timeout > 0 {
+			return context.WithTimeout(ctx, timeout)
+		}
+		return context.WithCancel(ctx)
+	}
+	// skip reports whether to skip a function which failed to be decoded or
+	// lifted; i.e. if running in continue mode, or if the function exceeded its
+	// time budget.
+	skip := func(err error) bool {
+		if ctx.Err() != nil {
+			log.Fatalf("%+v", errors.WithStack(ctx.Err()))
+		}
+		return cont || errors.Cause(err) == context.DeadlineExceeded
+	}
+
+	// Create function lifters.
+	var failures []failure
+	failed := make(map[bin.Address]bool)
+	for _, funcAddr := range funcAddrs {
+		fctx, fcancel := funcContext()
+		var asmFunc *disasm.Func
+		if cont {
+			asmFunc, err = decodeFunc(fctx, l, funcAddr)
+		} else {
+			asmFunc, err = l.DecodeFuncContext(fctx, funcAddr)
+		}
+		fcancel()
+		if err != nil {
+			if !skip(err) {
+				log.Fatalf("%+v", err)
+			}
+			failures = append(failures, failFunc(l, funcAddr, "decode", err))
+			failed[funcAddr] = true
+			continue
+		}
+		f := l.NewFunc(asmFunc)
+		l.Funcs[funcAddr] = f
+	}
+	// Exclude functions modified by self-modifying code.
+	modified := l.DetectSMC()
+	for _, funcAddr := range funcAddrs {
+		if ws, ok := modified[funcAddr]; ok && !failed[funcAddr] {
+			failures = append(failures, failFunc(l, funcAddr, "lift", smcError(ws)))
+			failed[funcAddr] = true
+		}
+	}
+
+	// Prepare on-disk cache of lifted functions.
+	var cache *x86.Cache
+	if len(cacheDir) > 0 {
+		if cache, err = x86.NewCache(cacheDir); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		cache.Config = fmt.Sprintf("mem2reg=%v", mem2reg)
+	}
+
+	// Lift functions.
+	imbalances := make(map[bin.Address][]x86.StackImbalance)
+	for _, funcAddr := range funcAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || failed[funcAddr] {
+			continue
+		}
+		// Load lifted function from cache.
+		var key string
+		hit := false
+		if cache != nil {
+			key = cache.Key(f)
+			if hit, err = cache.Load(f, key); err != nil {
+				warn.Printf("unable to load function %q from cache; %v", f.Name, err)
+			}
+		}
+		if !hit {
+			fctx, fcancel := funcContext()
+			if cont {
+				err = liftFunc(fctx, f)
+			} else {
+				err = f.LiftContext(fctx)
+			}
+			fcancel()
+			if err != nil {
+				if !skip(err) {
+					log.Fatalf("%+v", err)
+				}
+				failures = append(failures, failFunc(l, funcAddr, "lift", err))
+				continue
+			}
+			if mem2reg {
+				f.PromoteRegs()
+			}
+			if cache != nil {
+				if err := cache.Store(f, key); err != nil {
+					log.Fatalf("%+v", err)
+				}
+			}
+		}
+		// Verify stack pointer deltas of the function.
+		imbalances[funcAddr] = f.CheckStack()
+		if len(cfgDir) > 0 {
+			if err := dumpCFGs(cfgDir, l, f); err != nil {
+				log.Fatalf("%+v", err)
+			}
+		}
+		trace.Println(f)
+	}
+
+	// Store report of failed functions.
+	if cont || len(failures) > 0 {
+		if len(failures) > 0 {
+			warn.Printf("%d of %d functions failed; see failures.json", len(failures), len(funcAddrs))
+		}
+		if err := storeReport("failures.json", failures); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Scan sections using YARA rules.
+	if len(yaraPath) > 0 {
+		matches, err := scanYara(yaraPath, l.File)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		dbg.Printf("%d matches of YARA rules %q", len(matches), yaraPath)
+		attachYara(l, funcAddrs, matches)
+	}
+
+	// Store coverage report of executable sections.
+	if len(coveragePath) > 0 {
+		r := newCoverage(l, funcAddrs)
+		dbg.Printf("%d of %d bytes of executable sections claimed by decoded instructions (%.2f%%)", r.Claimed, r.Size, 100*r.Coverage)
+		if err := storeCoverage(coveragePath, r); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Store JSON analysis results.
+	if len(jsonPath) > 0 {
+		a := newAnalysis(l, funcAddrs, imbalances, failures)
+		a.addConflicts(conflicts)
+		if err := storeAnalysis(jsonPath, a); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Store Ghidra XML export of recovered functions, names and types.
+	if len(ghidraOut) > 0 {
+		a := newAnalysis(l, funcAddrs, imbalances, failures)
+		if err := storeGhidra(ghidraOut, l, binPath, a); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Store LLVM IR output.
+	var funcs []*ir.Function
+	sort.Sort(funcAddrs)
+	for _, funcAddr := range funcAddrs {
+		f := l.Funcs[funcAddr]
+		funcs = append(funcs, f.Function)
+	}
+	// Define forwarding functions of named thunks (e.g. exports), as references
+	// to thunks resolve to their targets.
+	funcs = append(funcs, l.Forwarders()...)
+	// Declare stub functions of unsupported instructions.
+	var stubNames []string
+	for name := range l.Stubs {
+		stubNames = append(stubNames, name)
+	}
+	sort.Strings(stubNames)
+	for _, name := range stubNames {
+		funcs = append(funcs, l.Stubs[name])
+	}
+	// Declare external functions referenced by lifted functions (e.g. imports).
+	funcs = append(funcs, x86.ExternFuncs(funcs)...)
+	var globals []*ir.Global
+	var globalAddrs bin.Addresses
+	for globalAddr := range l.Globals {
+		globalAddrs = append(globalAddrs, globalAddr)
+	}
+	sort.Sort(globalAddrs)
+	for _, globalAddr := range globalAddrs {
+		g := l.Globals[globalAddr]
+		globals = append(globals, g)
+	}
+	// Declare external global variables of data imports.
+	var externNames []string
+	for name := range l.Externs {
+		externNames = append(externNames, name)
+	}
+	sort.Strings(externNames)
+	for _, name := range externNames {
+		globals = append(globals, l.Externs[name])
+	}
+	// Infer function attributes of lifted functions.
+	var lifted []*x86.Func
+	for _, funcAddr := range funcAddrs {
+		lifted = append(lifted, l.Funcs[funcAddr])
+	}
+	l.InferAttrs(lifted)
+	// Propagate untrusted data of taint sources through lifted functions.
+	if len(taintSources) > 0 {
+		sources := strings.Split(taintSources, ",")
+		results, err := l.Taint(lifted, sources)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		nflows := 0
+		for _, r := range results {
+			nflows += len(r.Flows)
+		}
+		if nflows > 0 {
+			warn.Printf("%d flows of untrusted data to sinks in %d functions; see taint.json", nflows, len(results))
+		}
+		r := &taintReport{
+			Sources: sources,
+			Funcs:   results,
+		}
+		if err := storeTaintReport("taint.json", r); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	if len(triple) == 0 {
+		triple = l.TargetTriple()
+	}
+	if len(dataLayout) == 0 {
+		dataLayout = l.DataLayout()
+	}
+	// Store C runtime shim of lifted module.
+	if len(shimPath) > 0 {
+		if err := writeShim(shimPath, funcs); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	if len(splitDir) > 0 {
+		if err := writeSplit(splitDir, l, funcAddrs, globals, triple, dataLayout); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	}
+	m := &ir.Module{
+		DataLayout:   dataLayout,
+		TargetTriple: triple,
+		Types:        l.Types,
+		Globals:      globals,
+		Funcs:        funcs,
+	}
+	// Store libFuzzer harness of lifted function.
+	if len(fuzzFunc) > 0 {
+		if err := writeFuzz("fuzz.c", l, m, fuzzFunc); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Run differential tests of lifted functions.
+	if diffInputs > 0 {
+		r, err := diffTest(l, m, funcAddrs, diffInputs)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		if len(r.Mismatches) > 0 {
+			warn.Printf("%d mismatches in differential tests of %d functions; see difftest.json", len(r.Mismatches), r.Funcs)
+		}
+		if err := storeDiffReport("difftest.json", r); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	if isBitcode(output) {
+		if err := writeBitcode(output, l, m); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	}
+	w := os.Stdout
+	if len(output) > 0 {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := fmt.Fprintln(w, l.FormatModule(m)); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Create call graph.
+	//if err := genCallGraph(l.Funcs); err != nil {
+	//	log.Fatalf("%+v", err)
+	//}
+}
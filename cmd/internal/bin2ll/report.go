@@ -0,0 +1,110 @@
+package bin2ll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	disasm "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"github.com/pkg/errors"
+)
+
+// A failure records a function which failed to be decoded or lifted.
+type failure struct {
+	// Entry address of the function.
+	Addr bin.Address `json:"addr"`
+	// Function name.
+	Name string `json:"name"`
+	// Stage of failure; either "decode" or "lift".
+	Stage string `json:"stage"`
+	// Error message.
+	Err string `json:"error"`
+}
+
+// decodeFunc decodes the function at the given address, recovering from
+// panics. An error is returned if the context is cancelled before decoding
+// completes.
+func decodeFunc(ctx context.Context, l *x86.Lifter, funcAddr bin.Address) (asmFunc *disasm.Func, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("%v", e)
+		}
+	}()
+	return l.DecodeFuncContext(ctx, funcAddr)
+}
+
+// liftFunc lifts the given function, recovering from panics. An error is
+// returned if the context is cancelled before lifting completes.
+func liftFunc(ctx context.Context, f *x86.Func) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("%v", e)
+		}
+	}()
+	return f.LiftContext(ctx)
+}
+
+// failFunc records the failure of the function at the given address, and marks
+// the function as external by removing its function body.
+func failFunc(l *x86.Lifter, funcAddr bin.Address, stage string, err error) failure {
+	f, ok := l.Funcs[funcAddr]
+	if !ok {
+		name := fmt.Sprintf("f_%06X", uint64(funcAddr))
+		sig := types.NewFunc(types.Void)
+		f = &x86.Func{
+			Function: &ir.Function{
+				Name: name,
+				Typ:  types.NewPointer(sig),
+				Sig:  sig,
+				Metadata: map[string]*metadata.Metadata{
+					"addr": {
+						Nodes: []metadata.Node{&metadata.String{Val: funcAddr.String()}},
+					},
+				},
+			},
+		}
+		l.Funcs[funcAddr] = f
+	}
+	f.Blocks = nil
+	warn.Printf("unable to %s function %q at %v; %v", stage, f.Name, funcAddr, err)
+	return failure{
+		Addr:  funcAddr,
+		Name:  f.Name,
+		Stage: stage,
+		Err:   err.Error(),
+	}
+}
+
+// smcError returns the error of a function modified by the given writes into
+// executable sections.
+func smcError(ws []x86.CodeWrite) error {
+	var sites []string
+	for _, w := range ws {
+		sites = append(sites, fmt.Sprintf("%v (%v)", w.Addr, x86.AddrRange{Start: w.Start, End: w.End}))
+	}
+	return errors.Errorf("self-modifying code; function modified by instructions at %s", strings.Join(sites, ", "))
+}
+
+// storeReport stores a JSON encoded representation of the failures to the
+// given file.
+func storeReport(path string, failures []failure) error {
+	if failures == nil {
+		failures = []failure{}
+	}
+	buf, err := json.MarshalIndent(failures, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,822 @@
+package bin2ll
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/cmd/internal/cli"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/decomp/exp/logging"
+	"github.com/decomp/exp/rpc"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// Serve runs the serve subcommand with the given command name and command line
+// arguments. The serve subcommand exposes the lifter as an HTTP service, for
+// use by web front-ends and CI pipelines. Uploaded binary executables are
+// lifted in the background, one at a time, in order of upload; and removed once
+// the retention time of the -ttl flag has passed since lifting was done.
+//
+// REST endpoints.
+//
+//    POST   /api/binaries                      upload binary executable (raw body with ?name=FILE, or multipart form field "file")
+//    GET    /api/binaries                      list uploaded binary executables
+//    GET    /api/binaries/ID                   status of lifting
+//    DELETE /api/binaries/ID                   cancel lifting and remove binary executable
+//    GET    /api/binaries/ID/progress          stream status of lifting (server-sent events)
+//    GET    /api/binaries/ID/funcs             list functions
+//    GET    /api/binaries/ID/analysis          analysis results (as stored by the -json flag of lift)
+//    GET    /api/binaries/ID/funcs/FUNC/ll     LLVM IR of lifted function (name or address)
+//    GET    /api/binaries/ID/funcs/FUNC/cfg    control flow graph of function in DOT format (?kind=ll or asm)
+//
+// The analysis results, LLVM IR and control flow graphs are available once
+// lifting is done. The same binary executables are optionally served over gRPC
+// (see rpc/decomp.proto), as specified by the -grpc flag.
+func Serve(name string, args []string) {
+	// Parse command line arguments.
+	var (
+		// loader specifies how to parse the binary executables.
+		loader cli.Loader
+		// addr specifies the TCP address to listen on.
+		addr string
+		// grpcAddr specifies the TCP address to serve the gRPC interface on; or
+		// empty to only serve the HTTP API.
+		grpcAddr string
+		// origin specifies the allowed origin of cross-origin requests; or
+		// empty to disallow cross-origin requests.
+		origin string
+		// maxSize specifies the maximum size in bytes of uploaded binary
+		// executables.
+		maxSize int64
+		// maxQueue specifies the maximum number of uploaded binary executables
+		// waiting to be lifted.
+		maxQueue int
+		// ttl specifies the time to retain binary executables after lifting is
+		// done; or 0 to retain them until removed.
+		ttl time.Duration
+		// mem2reg specifies whether to promote registers and status flags into
+		// SSA values.
+		mem2reg bool
+		// timeout specifies the time budget of decoding and lifting each
+		// function; or 0 if unlimited.
+		timeout time.Duration
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		const use = `
+Serve the lifter over an HTTP API; upload binary executables, query functions,
+fetch the LLVM IR and control flow graphs of functions, and stream the progress
+of lifting.
+
+Usage:
+
+	%s [OPTION]...
+
+Flags:
+`
+		fmt.Fprintf(os.Stderr, use[1:], name)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&addr, "addr", "localhost:8080", "TCP address to listen on")
+	flags.StringVar(&grpcAddr, "grpc", "", "TCP address to serve the gRPC interface on (see rpc/decomp.proto; e.g. localhost:9090)")
+	flags.Int64Var(&maxSize, "max-size", 64<<20, "maximum size in bytes of uploaded binary executables")
+	flags.IntVar(&maxQueue, "max-queue", 64, "maximum number of uploaded binary executables waiting to be lifted")
+	flags.DurationVar(&ttl, "ttl", time.Hour, "time to retain binary executables after lifting is done (e.g. 30m); or 0 to retain them until removed")
+	flags.BoolVar(&mem2reg, "mem2reg", false, "promote registers and status flags into SSA values")
+	flags.StringVar(&origin, "origin", "", "allowed origin of cross-origin requests of web front-ends (e.g. http://localhost:3000, or * for any)")
+	flags.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flags.DurationVar(&timeout, "timeout", 0, "time budget of decoding and lifting each function (e.g. 10s); functions exceeding the budget are reported as failed")
+	loader.RegisterFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		logging.SetLevel(logging.LevelQuiet)
+	}
+
+	// Store uploaded binary executables in a temporary directory, which is
+	// removed on interrupt.
+	dir, err := ioutil.TempDir("", "decomp-serve-")
+	if err != nil {
+		log.Fatalf("%+v", errors.WithStack(err))
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		<-sigs
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}()
+	s := &server{
+		loader:  loader,
+		dir:     dir,
+		origin:  origin,
+		maxSize: maxSize,
+		mem2reg: mem2reg,
+		timeout: timeout,
+		ttl:     ttl,
+		jobs:    make(map[string]*job),
+		queue:   make(chan *job, maxQueue),
+	}
+	go s.work()
+	if ttl > 0 {
+		go s.evictLoop()
+	}
+	if len(grpcAddr) > 0 {
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			os.RemoveAll(dir)
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+		// Allow for the encoding overhead of uploaded binary executables.
+		gs := grpc.NewServer(grpc.MaxRecvMsgSize(int(maxSize) + 1<<20))
+		rpc.RegisterDecompServer(gs, &rpcServer{server: s})
+		dbg.Printf("serving gRPC on %s", grpcAddr)
+		go func() {
+			if err := gs.Serve(lis); err != nil {
+				os.RemoveAll(dir)
+				log.Fatalf("%+v", errors.WithStack(err))
+			}
+		}()
+	}
+	dbg.Printf("serving on http://%s/api/binaries", addr)
+	if err := http.ListenAndServe(addr, s); err != nil {
+		os.RemoveAll(dir)
+		log.Fatalf("%+v", errors.WithStack(err))
+	}
+}
+
+// ### [ Server ] ##############################################################
+
+// server serves the lifter over an HTTP API.
+type server struct {
+	// Loader of binary executables, as specified by command line flags; copied
+	// by each job.
+	loader cli.Loader
+	// Directory of uploaded binary executables.
+	dir string
+	// Allowed origin of cross-origin requests; or empty to disallow
+	// cross-origin requests.
+	origin string
+	// Maximum size in bytes of uploaded binary executables.
+	maxSize int64
+	// Promote registers and status flags into SSA values.
+	mem2reg bool
+	// Time budget of decoding and lifting each function; or 0 if unlimited.
+	timeout time.Duration
+	// Time to retain jobs after lifting is done; or 0 to retain them until
+	// removed.
+	ttl time.Duration
+	// Jobs waiting to be lifted, in order of upload; received by a single
+	// worker (see server.work).
+	queue chan *job
+
+	// Protects jobs and nextID.
+	mu sync.Mutex
+	// Jobs of uploaded binary executables, indexed by ID.
+	jobs map[string]*job
+	// ID of the next uploaded binary executable.
+	nextID int
+}
+
+// errQueueFull is returned by addJob when the maximum number of binary
+// executables are waiting to be lifted.
+var errQueueFull = errors.New("too many binary executables waiting to be lifted; try again later")
+
+// ServeHTTP dispatches the request to the handler of the REST endpoint.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(s.origin) > 0 {
+		w.Header().Set("Access-Control-Allow-Origin", s.origin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+		if r.Method == http.MethodOptions {
+			return
+		}
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "api" || parts[1] != "binaries" {
+		httpError(w, http.StatusNotFound, "unknown endpoint %q", r.URL.Path)
+		return
+	}
+	parts = parts[2:]
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w)
+		case http.MethodPost:
+			s.upload(w, r)
+		default:
+			httpError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		}
+		return
+	}
+	j, ok := s.job(parts[0])
+	if !ok {
+		httpError(w, http.StatusNotFound, "unknown binary executable %q", parts[0])
+		return
+	}
+	parts = parts[1:]
+	if r.Method == http.MethodDelete && len(parts) == 0 {
+		s.remove(w, j)
+		return
+	}
+	if r.Method != http.MethodGet {
+		httpError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+	switch {
+	case len(parts) == 0:
+		status, _ := j.snapshot()
+		writeJSON(w, status)
+	case len(parts) == 1 && parts[0] == "progress":
+		j.stream(w, r)
+	case len(parts) == 1 && parts[0] == "funcs":
+		j.mu.Lock()
+		funcs := append([]jobFunc{}, j.funcs...)
+		j.mu.Unlock()
+		writeJSON(w, funcs)
+	case len(parts) == 1 && parts[0] == "analysis":
+		if !j.ready(w) {
+			return
+		}
+		writeJSON(w, j.analysis)
+	case len(parts) == 3 && parts[0] == "funcs" && (parts[2] == "ll" || parts[2] == "cfg"):
+		if !j.ready(w) {
+			return
+		}
+		f, ok := j.lookup(parts[1])
+		if !ok {
+			httpError(w, http.StatusNotFound, "unable to locate lifted function %q", parts[1])
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if parts[2] == "ll" {
+			fmt.Fprintln(w, j.l.FormatModule(j.l.FuncModule(f)))
+			return
+		}
+		switch kind := r.URL.Query().Get("kind"); kind {
+		case "", "ll":
+			w.Write(llCFG(f.Function))
+		case "asm":
+			w.Write(asmCFG(j.l, f))
+		default:
+			httpError(w, http.StatusBadRequest, "invalid control flow graph kind %q; expected ll or asm", kind)
+		}
+	default:
+		httpError(w, http.StatusNotFound, "unknown endpoint %q", r.URL.Path)
+	}
+}
+
+// job returns the job of the uploaded binary executable with the given ID.
+func (s *server) job(id string) (*job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok
+}
+
+// list writes the status of each job, in order of upload.
+func (s *server) list(w http.ResponseWriter) {
+	s.mu.Lock()
+	var jobs []*job
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+	statuses := []jobStatus{}
+	for _, j := range jobs {
+		status, _ := j.snapshot()
+		statuses = append(statuses, status)
+	}
+	less := func(i, j int) bool {
+		return jobLess(statuses[i].ID, statuses[j].ID)
+	}
+	sort.Slice(statuses, less)
+	writeJSON(w, statuses)
+}
+
+// jobLess reports whether the binary executable with ID a was uploaded before
+// the one with ID b.
+func jobLess(a, b string) bool {
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+	return x < y
+}
+
+// upload stores the uploaded binary executable of the request, and starts
+// lifting it in the background. The binary executable is either the raw body
+// of the request, named by the "name" query parameter, or the "file" field of
+// a multipart form.
+func (s *server) upload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxSize)
+	var src io.Reader = r.Body
+	name := r.URL.Query().Get("name")
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			httpError(w, http.StatusBadRequest, "unable to locate form field %q; %v", "file", err)
+			return
+		}
+		defer file.Close()
+		src = file
+		name = header.Filename
+	}
+	j, err := s.addJob(name, src)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Cause(err) == errQueueFull {
+			code = http.StatusServiceUnavailable
+		}
+		httpError(w, code, "%v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	status, _ := j.snapshot()
+	writeJSON(w, status)
+}
+
+// addJob stores the uploaded binary executable with the given file name read
+// from src, and queues it for lifting in the background.
+func (s *server) addJob(name string, src io.Reader) (*job, error) {
+	// Only retain the base name (e.g. for the file extension of .COM
+	// executables).
+	name = filepath.Base(name)
+	if name == "." || name == "/" || name == ".." {
+		name = "binary"
+	}
+	s.mu.Lock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.mu.Unlock()
+	binPath := filepath.Join(s.dir, id, name)
+	if err := storeUpload(binPath, src); err != nil {
+		os.RemoveAll(filepath.Dir(binPath))
+		return nil, errors.Errorf("unable to store binary executable; %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		status: jobStatus{
+			ID:    id,
+			Name:  name,
+			State: stateQueued,
+		},
+		changed: make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		binPath: binPath,
+	}
+	s.mu.Lock()
+	s.jobs[id] = j
+	s.mu.Unlock()
+	select {
+	case s.queue <- j:
+	default:
+		s.removeJob(j)
+		return nil, errors.WithStack(errQueueFull)
+	}
+	dbg.Printf("received binary executable %q (ID %s)", name, id)
+	return j, nil
+}
+
+// storeUpload stores the uploaded binary executable read from src to the given
+// path.
+func storeUpload(binPath string, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(binPath), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.Create(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, src); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// remove cancels the lifting of the given job, and removes its binary
+// executable.
+func (s *server) remove(w http.ResponseWriter, j *job) {
+	s.removeJob(j)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeJob cancels the lifting of the given job, and removes its binary
+// executable.
+func (s *server) removeJob(j *job) {
+	j.cancel()
+	s.mu.Lock()
+	delete(s.jobs, j.status.ID)
+	s.mu.Unlock()
+	if err := os.RemoveAll(filepath.Join(s.dir, j.status.ID)); err != nil {
+		warn.Printf("unable to remove binary executable %q; %v", j.status.Name, err)
+	}
+}
+
+// work lifts the queued jobs, one at a time, in order of upload.
+func (s *server) work() {
+	for j := range s.queue {
+		s.run(j)
+	}
+}
+
+// run lifts the binary executable of the given job.
+func (s *server) run(j *job) {
+	err := s.lift(j.ctx, j, j.binPath)
+	if err != nil {
+		warn.Printf("unable to lift binary executable %q (ID %s); %v", j.status.Name, j.status.ID, err)
+	}
+	j.update(func() {
+		if err != nil {
+			j.status.State = stateFailed
+			j.status.Err = err.Error()
+		}
+		j.finished = time.Now()
+	})
+}
+
+// evictLoop periodically evicts the jobs for which lifting was done more than
+// s.ttl ago.
+func (s *server) evictLoop() {
+	interval := s.ttl
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	for now := range time.Tick(interval) {
+		s.evict(now.Add(-s.ttl))
+	}
+}
+
+// evict removes the jobs for which lifting was done before the given time, and
+// their binary executables.
+func (s *server) evict(before time.Time) {
+	s.mu.Lock()
+	var expired []*job
+	for _, j := range s.jobs {
+		j.mu.Lock()
+		if !j.finished.IsZero() && j.finished.Before(before) {
+			expired = append(expired, j)
+		}
+		j.mu.Unlock()
+	}
+	s.mu.Unlock()
+	for _, j := range expired {
+		dbg.Printf("evicting binary executable %q (ID %s)", j.status.Name, j.status.ID)
+		s.removeJob(j)
+	}
+}
+
+// lift decodes and lifts the functions of the given binary executable,
+// recording progress in the given job. Functions which fail to be decoded or
+// lifted are recorded as failed, and skipped.
+func (s *server) lift(ctx context.Context, j *job, binPath string) error {
+	if ctx.Err() != nil {
+		return errors.WithStack(ctx.Err())
+	}
+	loader := s.loader
+	file, err := loader.ParseFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	l, err := x86.NewLifter(file)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	l.KnownStrings = loader.Strings
+	if loader.Project != nil {
+		if err := loader.Project.ApplyLifter(l); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	var funcAddrs bin.Addresses
+	for _, funcAddr := range l.FuncAddrs {
+		if _, ok := l.Thunks[funcAddr]; ok {
+			// skip thunks; references resolve to their targets.
+			continue
+		}
+		funcAddrs = append(funcAddrs, funcAddr)
+	}
+	funcs := make([]jobFunc, len(funcAddrs))
+	index := make(map[bin.Address]int)
+	for i, funcAddr := range funcAddrs {
+		funcs[i] = jobFunc{Addr: funcAddr, State: funcPending}
+		if f, ok := l.Funcs[funcAddr]; ok {
+			funcs[i].Name = f.Name
+		}
+		index[funcAddr] = i
+	}
+	j.update(func() {
+		j.status.State = stateDecoding
+		j.status.Total = len(funcAddrs)
+		j.funcs = funcs
+	})
+	// funcContext returns the context of decoding or lifting a single function,
+	// limited by the per-function time budget.
+	funcContext := func() (context.Context, context.CancelFunc) {
+		if s.timeout > 0 {
+			return context.WithTimeout(ctx, s.timeout)
+		}
+		return context.WithCancel(ctx)
+	}
+	// fail records the failure of the given function.
+	var failures []failure
+	fail := func(funcAddr bin.Address, stage string, err error) error {
+		if ctx.Err() != nil {
+			return errors.WithStack(ctx.Err())
+		}
+		rec := failFunc(l, funcAddr, stage, err)
+		failures = append(failures, rec)
+		j.update(func() {
+			f := &j.funcs[index[funcAddr]]
+			f.Name = rec.Name
+			f.State = funcFailed
+			f.Err = fmt.Sprintf("%s: %s", stage, rec.Err)
+			j.status.Failures++
+			j.status.Done++
+		})
+		return nil
+	}
+
+	// Create function lifters.
+	failed := make(map[bin.Address]bool)
+	for _, funcAddr := range funcAddrs {
+		fctx, fcancel := funcContext()
+		asmFunc, err := decodeFunc(fctx, l, funcAddr)
+		fcancel()
+		if err != nil {
+			if err := fail(funcAddr, "decode", err); err != nil {
+				return errors.WithStack(err)
+			}
+			failed[funcAddr] = true
+			continue
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	// Exclude functions modified by self-modifying code.
+	modified := l.DetectSMC()
+	for _, funcAddr := range funcAddrs {
+		if ws, ok := modified[funcAddr]; ok && !failed[funcAddr] {
+			if err := fail(funcAddr, "lift", smcError(ws)); err != nil {
+				return errors.WithStack(err)
+			}
+			failed[funcAddr] = true
+		}
+	}
+
+	// Lift functions.
+	j.update(func() {
+		j.status.State = stateLifting
+	})
+	imbalances := make(map[bin.Address][]x86.StackImbalance)
+	var lifted []*x86.Func
+	for _, funcAddr := range funcAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || failed[funcAddr] {
+			continue
+		}
+		fctx, fcancel := funcContext()
+		err := liftFunc(fctx, f)
+		fcancel()
+		if err != nil {
+			if err := fail(funcAddr, "lift", err); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+		if s.mem2reg {
+			f.PromoteRegs()
+		}
+		imbalances[funcAddr] = f.CheckStack()
+		lifted = append(lifted, f)
+		j.update(func() {
+			jf := &j.funcs[index[funcAddr]]
+			jf.Name = f.Name
+			jf.State = funcLifted
+			jf.Blocks = len(f.Blocks)
+			j.status.Done++
+		})
+	}
+	l.InferAttrs(lifted)
+	a := newAnalysis(l, funcAddrs, imbalances, failures)
+	j.update(func() {
+		j.l = l
+		j.analysis = a
+		j.status.State = stateDone
+	})
+	dbg.Printf("lifted %d of %d functions of %q (ID %s)", len(lifted), len(funcAddrs), j.status.Name, j.status.ID)
+	return nil
+}
+
+// ### [ Jobs ] ################################################################
+
+// States of jobs.
+const (
+	// The binary executable is waiting for the lifting of previously uploaded
+	// binary executables.
+	stateQueued = "queued"
+	// The functions of the binary executable are being decoded.
+	stateDecoding = "decoding"
+	// The functions of the binary executable are being lifted.
+	stateLifting = "lifting"
+	// Lifting is done.
+	stateDone = "done"
+	// The binary executable failed to be parsed or lifting was cancelled.
+	stateFailed = "failed"
+)
+
+// States of functions.
+const (
+	// The function is waiting to be lifted.
+	funcPending = "pending"
+	// The function has been lifted.
+	funcLifted = "lifted"
+	// The function failed to be decoded or lifted.
+	funcFailed = "failed"
+)
+
+// A job records the lifting of an uploaded binary executable.
+type job struct {
+	// Protects the fields below.
+	mu sync.Mutex
+	// Status of lifting.
+	status jobStatus
+	// Functions of the binary executable, in order of address.
+	funcs []jobFunc
+	// Closed and replaced when the status changes.
+	changed chan struct{}
+	// Context of lifting.
+	ctx context.Context
+	// Cancels lifting.
+	cancel context.CancelFunc
+	// Path to the uploaded binary executable.
+	binPath string
+	// Time at which lifting was done or failed; or zero if not yet finished.
+	finished time.Time
+	// Lifter of the binary executable; set once lifting is done.
+	l *x86.Lifter
+	// Analysis results; set once lifting is done.
+	analysis *analysis
+}
+
+// A jobStatus records the status of lifting an uploaded binary executable.
+type jobStatus struct {
+	// ID of the binary executable.
+	ID string `json:"id"`
+	// File name of the binary executable.
+	Name string `json:"name"`
+	// State of lifting; queued, decoding, lifting, done or failed.
+	State string `json:"state"`
+	// Number of functions lifted or failed.
+	Done int `json:"done"`
+	// Number of functions.
+	Total int `json:"total"`
+	// Number of functions which failed to be decoded or lifted.
+	Failures int `json:"failures"`
+	// Error message if the binary executable failed to be lifted.
+	Err string `json:"error,omitempty"`
+}
+
+// A jobFunc records the status of lifting a function.
+type jobFunc struct {
+	// Entry address of the function.
+	Addr bin.Address `json:"addr"`
+	// Function name.
+	Name string `json:"name"`
+	// State of lifting; pending, lifted or failed.
+	State string `json:"state"`
+	// Number of basic blocks of the lifted function.
+	Blocks int `json:"blocks,omitempty"`
+	// Error message if the function failed to be decoded or lifted.
+	Err string `json:"error,omitempty"`
+}
+
+// update applies the given change to the status of the job, and notifies
+// streams of progress.
+func (j *job) update(change func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	change()
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// snapshot returns the status of the job, and a channel which is closed when
+// the status changes.
+func (j *job) snapshot() (jobStatus, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status, j.changed
+}
+
+// stream writes the status of the job as server-sent events, each time the
+// status changes, until lifting is done or failed; e.g.
+//
+//    data: {"id":"1","name":"foo.exe","state":"lifting","done":12,"total":40,"failures":0}
+func (j *job) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	for {
+		status, changed := j.snapshot()
+		buf, err := json.Marshal(status)
+		if err != nil {
+			warn.Printf("unable to encode status of binary executable %q; %v", status.Name, err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", buf); err != nil {
+			return
+		}
+		flusher.Flush()
+		if status.State == stateDone || status.State == stateFailed {
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// ready reports whether lifting of the job is done, writing an error response
+// otherwise.
+func (j *job) ready(w http.ResponseWriter) bool {
+	status, _ := j.snapshot()
+	if status.State != stateDone {
+		httpError(w, http.StatusConflict, "binary executable %q not lifted (state %s)", status.Name, status.State)
+		return false
+	}
+	return true
+}
+
+// lookup returns the lifted function with the given name or address (e.g.
+// "f_401000" or "0x401000").
+//
+// Pre-condition: lifting of the job is done.
+func (j *job) lookup(funcName string) (*x86.Func, bool) {
+	var funcAddr bin.Address
+	if err := funcAddr.Set(funcName); err != nil {
+		for _, f := range j.funcs {
+			if f.Name == funcName {
+				funcAddr = f.Addr
+				break
+			}
+		}
+	}
+	f, ok := j.l.Funcs[funcAddr]
+	if !ok || len(f.Blocks) == 0 {
+		return nil, false
+	}
+	return f, true
+}
+
+// ### [ Helper functions ] ####################################################
+
+// writeJSON writes the JSON encoding of v as response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	buf, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "unable to encode response; %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(buf, '\n'))
+}
+
+// httpError writes an error response with the given status code and error
+// message, in JSON format; e.g.
+//
+//    {"error": "unknown binary executable \"3\""}
+func httpError(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	buf, _ := json.Marshal(map[string]string{"error": fmt.Sprintf(format, args...)})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(append(buf, '\n'))
+}
@@ -0,0 +1,210 @@
+package bin2ll
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	s, dir := newTestServer(t, 1)
+	defer os.RemoveAll(dir)
+	go s.work()
+	defer close(s.queue)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	id := uploadTest(t, ts.URL, "testdata/x86_32/serve/serve.so", http.StatusAccepted)
+	status := waitTest(t, ts.URL, id)
+	if status.State != stateDone {
+		t.Fatalf("state mismatch; expected %q, got %q (%s)", stateDone, status.State, status.Err)
+	}
+	if status.Total != 2 || status.Done != 2 || status.Failures != 0 {
+		t.Errorf("progress mismatch; expected 2 of 2 functions without failures, got %d of %d with %d failures", status.Done, status.Total, status.Failures)
+	}
+
+	// List functions.
+	var funcs []jobFunc
+	if body := getTest(t, ts.URL+"/api/binaries/"+id+"/funcs", http.StatusOK); body != nil {
+		if err := json.Unmarshal(body, &funcs); err != nil {
+			t.Fatalf("unable to decode functions; %v", err)
+		}
+	}
+	var names []string
+	for _, f := range funcs {
+		if f.State != funcLifted {
+			t.Errorf("%q: state mismatch; expected %q, got %q", f.Name, funcLifted, f.State)
+		}
+		names = append(names, f.Name)
+	}
+	if got, want := strings.Join(names, ","), "f,g"; got != want {
+		t.Errorf("functions mismatch; expected %q, got %q", want, got)
+	}
+
+	golden := []struct {
+		// Path of the request, relative to the binary executable.
+		path string
+		// Expected status code.
+		code int
+		// Expected substring of the response; or empty if not checked.
+		want string
+	}{
+		// LLVM IR of function, by name and address.
+		{path: "/funcs/g/ll", code: http.StatusOK, want: "@g("},
+		{path: "/funcs/0x10000000/ll", code: http.StatusOK, want: "@f("},
+		// Control flow graph of function.
+		{path: "/funcs/f/cfg", code: http.StatusOK, want: `digraph "f"`},
+		{path: "/funcs/f/cfg?kind=asm", code: http.StatusOK, want: `digraph "f"`},
+		{path: "/funcs/f/cfg?kind=foo", code: http.StatusBadRequest},
+		// Unknown function and endpoint.
+		{path: "/funcs/h/ll", code: http.StatusNotFound},
+		{path: "/foo", code: http.StatusNotFound},
+	}
+	for _, g := range golden {
+		body := getTest(t, ts.URL+"/api/binaries/"+id+g.path, g.code)
+		if body == nil || len(g.want) == 0 {
+			continue
+		}
+		if !bytes.Contains(body, []byte(g.want)) {
+			t.Errorf("%q: expected %q in response, got:\n%s", g.path, g.want, body)
+		}
+	}
+
+	// Remove binary executable.
+	req, err := http.NewRequest(http.MethodDelete, ts.URL+"/api/binaries/"+id, nil)
+	if err != nil {
+		t.Fatalf("unable to create request; %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unable to remove binary executable; %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("status code mismatch of DELETE; expected %d, got %d", http.StatusNoContent, resp.StatusCode)
+	}
+	getTest(t, ts.URL+"/api/binaries/"+id, http.StatusNotFound)
+	if _, err := os.Stat(filepath.Join(dir, id)); !os.IsNotExist(err) {
+		t.Errorf("expected directory of removed binary executable to be removed; %v", err)
+	}
+}
+
+func TestServeQueue(t *testing.T) {
+	// Upload without a worker, so that the first binary executable stays queued.
+	s, dir := newTestServer(t, 1)
+	defer os.RemoveAll(dir)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	first := uploadTest(t, ts.URL, "testdata/x86_32/serve/serve.so", http.StatusAccepted)
+	uploadTest(t, ts.URL, "testdata/x86_32/serve/serve.so", http.StatusServiceUnavailable)
+	if _, err := os.Stat(filepath.Join(dir, "2")); !os.IsNotExist(err) {
+		t.Errorf("expected directory of rejected binary executable to be removed; %v", err)
+	}
+
+	// Lift and evict the queued binary executable.
+	go s.work()
+	defer close(s.queue)
+	if status := waitTest(t, ts.URL, first); status.State != stateDone {
+		t.Fatalf("state mismatch; expected %q, got %q (%s)", stateDone, status.State, status.Err)
+	}
+	s.evict(time.Now().Add(-time.Hour))
+	if _, ok := s.job(first); !ok {
+		t.Errorf("expected binary executable finished within the retention time to be kept")
+	}
+	s.evict(time.Now().Add(time.Second))
+	if _, ok := s.job(first); ok {
+		t.Errorf("expected binary executable finished before the retention time to be evicted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, first)); !os.IsNotExist(err) {
+		t.Errorf("expected directory of evicted binary executable to be removed; %v", err)
+	}
+}
+
+// newTestServer returns a new server with the given maximum number of queued
+// binary executables, storing uploads in a new temporary directory.
+func newTestServer(t *testing.T, maxQueue int) (*server, string) {
+	dir, err := ioutil.TempDir("", "bin2ll")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory; %+v", err)
+	}
+	s := &server{
+		dir:     dir,
+		maxSize: 1 << 20,
+		jobs:    make(map[string]*job),
+		queue:   make(chan *job, maxQueue),
+	}
+	return s, dir
+}
+
+// uploadTest uploads the given binary executable, and returns its ID.
+func uploadTest(t *testing.T, url, binPath string, code int) string {
+	buf, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		t.Fatalf("unable to read file; %+v", err)
+	}
+	resp, err := http.Post(url+"/api/binaries?name="+filepath.Base(binPath), "application/octet-stream", bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("unable to upload %q; %v", binPath, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != code {
+		t.Fatalf("status code mismatch of upload; expected %d, got %d", code, resp.StatusCode)
+	}
+	var status jobStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("unable to decode status; %v", err)
+	}
+	return status.ID
+}
+
+// waitTest streams the progress of lifting the given binary executable, and
+// returns its final status.
+func waitTest(t *testing.T, url, id string) jobStatus {
+	resp, err := http.Get(url + "/api/binaries/" + id + "/progress")
+	if err != nil {
+		t.Fatalf("unable to stream progress; %v", err)
+	}
+	defer resp.Body.Close()
+	var status jobStatus
+	s := bufio.NewScanner(resp.Body)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		if err := json.Unmarshal([]byte(line[len("data: "):]), &status); err != nil {
+			t.Fatalf("unable to decode status; %v", err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unable to read progress; %v", err)
+	}
+	return status
+}
+
+// getTest sends a GET request to the given URL, and returns the response body;
+// or nil if the status code does not match.
+func getTest(t *testing.T, url string, code int) []byte {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("unable to send request; %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unable to read response; %v", err)
+	}
+	if resp.StatusCode != code {
+		t.Errorf("%q: status code mismatch; expected %d, got %d", url, code, resp.StatusCode)
+		return nil
+	}
+	return body
+}
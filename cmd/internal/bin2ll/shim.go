@@ -0,0 +1,239 @@
+package bin2ll
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/pkg/errors"
+)
+
+// shimHeader is the header comment of runtime shims.
+const shimHeader = `// Runtime shim of lifted module; generated by bin2ll.
+//
+// Imported functions are stubbed, reporting calls on standard error, and the
+// system call, segment and trap helpers of the lifter are implemented for Linux.
+// Definitions are weak, and may thus be overridden by other objects linked
+// with the lifted module.
+
+`
+
+// shimPrelude is the prelude of runtime shims, providing the system call,
+// segment and stub helpers used by the definitions of the shim.
+const shimPrelude = `#define SHIM_WEAK __attribute__((weak))
+#define SHIM_UNUSED __attribute__((unused))
+
+#if defined(__x86_64__)
+#define SHIM_SYS_WRITE 1
+
+SHIM_UNUSED static long shim_syscall(long nr, long a1, long a2, long a3, long a4, long a5, long a6) {
+	long ret;
+	register long r10 __asm__("r10") = a4;
+	register long r8 __asm__("r8") = a5;
+	register long r9 __asm__("r9") = a6;
+	__asm__ volatile ("syscall" : "=a"(ret) : "a"(nr), "D"(a1), "S"(a2), "d"(a3), "r"(r10), "r"(r8), "r"(r9) : "rcx", "r11", "memory");
+	return ret;
+}
+#elif defined(__i386__)
+#define SHIM_SYS_WRITE 4
+
+SHIM_UNUSED static long shim_syscall(long nr, long a1, long a2, long a3, long a4, long a5, long a6) {
+	long ret;
+	// EBP may not be used as operand; pass the sixth argument on the stack.
+	__asm__ volatile ("pushl %7; push %%ebp; mov 4(%%esp), %%ebp; int $0x80; pop %%ebp; add $4, %%esp" : "=a"(ret) : "a"(nr), "b"(a1), "c"(a2), "d"(a3), "S"(a4), "D"(a5), "g"(a6) : "memory");
+	return ret;
+}
+#else
+#error "runtime shim only supports x86 Linux"
+#endif
+
+// shim_unresolved reports a call to the given unresolved function on standard
+// error.
+SHIM_UNUSED static void shim_unresolved(const char *name) {
+	static const char prefix[] = "shim: call to unresolved function ";
+	unsigned long n = 0;
+	while (name[n] != 0) {
+		n++;
+	}
+	shim_syscall(SHIM_SYS_WRITE, 2, (long)prefix, sizeof(prefix) - 1, 0, 0, 0);
+	shim_syscall(SHIM_SYS_WRITE, 2, (long)name, n, 0, 0, 0);
+	shim_syscall(SHIM_SYS_WRITE, 2, (long)"\n", 1, 0, 0, 0);
+}
+
+// Contents of the FS and GS segments.
+SHIM_UNUSED static unsigned char shim_fs[0x1000] __attribute__((aligned(16)));
+SHIM_UNUSED static unsigned char shim_gs[0x1000] __attribute__((aligned(16)));
+
+// shim_seg returns the base address of the given segment. Self-pointers are
+// stored at offset 0 (Linux thread control block) and at offset 0x18 or 0x30
+// (32- and 64-bit Windows thread information block).
+SHIM_UNUSED static void *shim_seg(unsigned char *seg) {
+	*(void **)seg = seg;
+	*(void **)(seg + (sizeof(void *) == 4 ? 0x18 : 0x30)) = seg;
+	return seg;
+}
+`
+
+// reIdent matches valid C identifiers.
+var reIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// writeShim writes a C runtime shim of the given LLVM IR functions to the
+// specified output path, providing definitions of the functions declared but
+// not defined (e.g. imports); i.e. implementations of the system call and
+// segment helpers of the lifter (e.g. @sys.syscall), and stubs of remaining
+// functions, which report calls on standard error and return zero. The shim is
+// compiled and linked with the lifted module, so that it may be executed for
+// validation; e.g.
+//
+//    SHIM_WEAK unsigned int puts(void *a0);
+//    unsigned int puts(void *a0) {
+//    	shim_unresolved("puts");
+//    	return 0;
+//    }
+func writeShim(path string, funcs []*ir.Function) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString(shimHeader)
+	if err := writeShimDefs(buf, funcs, true); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// writeShimDefs writes the prelude of runtime shims to w, followed by
+// definitions of the functions declared but not defined of the given LLVM IR
+// functions. Functions other than the system call and segment helpers of the
+// lifter are only stubbed if stubs is set; e.g. stubs are omitted when linking
+// against the C standard library, which provides the imported functions.
+func writeShimDefs(w *bytes.Buffer, funcs []*ir.Function, stubs bool) error {
+	w.WriteString(shimPrelude)
+	for i, fn := range funcs {
+		if len(fn.Blocks) > 0 {
+			continue
+		}
+		// LLVM intrinsics (e.g. @llvm.trap) are provided by the compiler.
+		if strings.HasPrefix(fn.Name, "llvm.") {
+			continue
+		}
+		var body string
+		switch fn.Name {
+		case x86.RuntimeSyscall, x86.RuntimeInt80:
+			if len(fn.Sig.Params) != 7 {
+				return errors.Errorf("invalid number of parameters of system call helper %q; expected 7, got %d", fn.Name, len(fn.Sig.Params))
+			}
+			body = "\treturn shim_syscall(a0, a1, a2, a3, a4, a5, a6);\n"
+		case x86.RuntimeFSBase:
+			body = "\treturn shim_seg(shim_fs);\n"
+		case x86.RuntimeGSBase:
+			body = "\treturn shim_seg(shim_gs);\n"
+		case x86.RuntimeFastFail:
+			body = "\t__builtin_trap();\n"
+		default:
+			if !stubs {
+				continue
+			}
+			body = fmt.Sprintf("\tshim_unresolved(%q);\n", fn.Name)
+			if !types.Equal(fn.Sig.Ret, types.Void) {
+				body += "\treturn 0;\n"
+			}
+		}
+		proto, label, err := shimProto(fn, i)
+		if err != nil {
+			warn.Printf("unable to generate shim of function %q; %v", fn.Name, err)
+			continue
+		}
+		fmt.Fprintf(w, "\nSHIM_WEAK %s%s;\n", proto, label)
+		fmt.Fprintf(w, "%s {\n%s}\n", proto, body)
+	}
+	return nil
+}
+
+// shimProto returns the C function prototype of the given LLVM IR function,
+// with parameters named a0, a1, etc. Functions with names which are not valid C
+// identifiers (e.g. @sys.syscall) are named shim_fN, where N is the given
+// index, and the returned assembler label (e.g. ` __asm__("sys.syscall")`)
+// specifies the symbol name.
+func shimProto(fn *ir.Function, index int) (proto, label string, err error) {
+	name := fn.Name
+	if !reIdent.MatchString(name) || strings.HasPrefix(name, "shim_") {
+		label = fmt.Sprintf(" __asm__(%q)", name)
+		name = fmt.Sprintf("shim_f%d", index)
+	}
+	ret, err := cType(fn.Sig.Ret)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	var params []string
+	for i, param := range fn.Sig.Params {
+		typ, err := cType(param.Typ)
+		if err != nil {
+			return "", "", errors.WithStack(err)
+		}
+		params = append(params, cDecl(typ, fmt.Sprintf("a%d", i)))
+	}
+	switch {
+	case fn.Sig.Variadic && len(params) == 0:
+		// Unspecified parameters.
+	case fn.Sig.Variadic:
+		params = append(params, "...")
+	case len(params) == 0:
+		params = append(params, "void")
+	}
+	return cDecl(ret, fmt.Sprintf("%s(%s)", name, strings.Join(params, ", "))), label, nil
+}
+
+// cDecl returns the C declaration of the given name of the specified C type;
+// e.g. `void *a0`.
+func cDecl(typ, name string) string {
+	if strings.HasSuffix(typ, "*") {
+		return typ + name
+	}
+	return typ + " " + name
+}
+
+// cType returns the C type corresponding to the given LLVM IR type of a
+// parameter or return value. Integers are unsigned, and pointers are untyped.
+func cType(t types.Type) (string, error) {
+	switch t := t.(type) {
+	case *types.VoidType:
+		return "void", nil
+	case *types.IntType:
+		switch t.Size {
+		case 1:
+			return "_Bool", nil
+		case 8:
+			return "unsigned char", nil
+		case 16:
+			return "unsigned short", nil
+		case 32:
+			return "unsigned int", nil
+		case 64:
+			return "unsigned long long", nil
+		default:
+			return fmt.Sprintf("unsigned _BitInt(%d)", t.Size), nil
+		}
+	case *types.FloatType:
+		switch t.Kind {
+		case types.FloatKindIEEE_16:
+			return "_Float16", nil
+		case types.FloatKindIEEE_32:
+			return "float", nil
+		case types.FloatKindIEEE_64:
+			return "double", nil
+		case types.FloatKindDoubleExtended_80:
+			return "long double", nil
+		case types.FloatKindIEEE_128:
+			return "__float128", nil
+		}
+	case *types.PointerType:
+		return "void *", nil
+	}
+	return "", errors.Errorf("support for type %v not yet implemented", t)
+}
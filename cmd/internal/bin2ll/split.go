@@ -0,0 +1,152 @@
+package bin2ll
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/mewkiz/pkg/jsonutil"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/pkg/errors"
+)
+
+// A splitIndex is the module index of a split output directory, which maps
+// lifted functions to the LLVM IR files containing them.
+//
+// Example index.json contents.
+//
+//    {
+//       "globals": "globals.ll",
+//       "funcs": [
+//          {"addr": "0x401000", "name": "main", "path": "f_401000.ll"}
+//       ]
+//    }
+type splitIndex struct {
+	// Path of the LLVM IR file of global variables, relative to the output
+	// directory.
+	Globals string `json:"globals"`
+	// Lifted functions, sorted by address.
+	Funcs []*splitFunc `json:"funcs"`
+}
+
+// A splitFunc records the LLVM IR file of a lifted function.
+type splitFunc struct {
+	// Function address.
+	Addr bin.Address `json:"addr"`
+	// Function name.
+	Name string `json:"name"`
+	// Path of the LLVM IR file of the function, relative to the output
+	// directory.
+	Path string `json:"path"`
+}
+
+// writeSplit writes the lifted functions at the given addresses to separate
+// LLVM IR files of the output directory, one per function, the global variables
+// to globals.ll, and the module index to index.json.
+//
+// Entries of functions not lifted by this invocation are retained from the
+// existing module index, so that single functions may be re-lifted in place
+// (e.g. using -func).
+//
+//    dir/index.json
+//    dir/globals.ll
+//    dir/f_401000.ll
+func writeSplit(dir string, l *x86.Lifter, funcAddrs []bin.Address, globals []*ir.Global, triple, dataLayout string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	indexPath := filepath.Join(dir, "index.json")
+	index := &splitIndex{}
+	if osutil.Exists(indexPath) {
+		if err := jsonutil.ParseFile(indexPath, index); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	entries := make(map[bin.Address]*splitFunc)
+	for _, entry := range index.Funcs {
+		entries[entry.Addr] = entry
+	}
+
+	// Store LLVM IR files of functions.
+	for _, funcAddr := range funcAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || len(f.Blocks) == 0 {
+			// Skip functions which failed to be lifted.
+			continue
+		}
+		m := l.FuncModule(f)
+		m.TargetTriple = triple
+		m.DataLayout = dataLayout
+		name := fmt.Sprintf("f_%06X.ll", uint64(funcAddr))
+		if err := writeModule(filepath.Join(dir, name), l, m); err != nil {
+			return errors.WithStack(err)
+		}
+		entries[funcAddr] = &splitFunc{Addr: funcAddr, Name: f.Name, Path: name}
+	}
+
+	// Store LLVM IR file of global variables, with declarations of the
+	// functions referenced from initializers (e.g. vtables).
+	var addrs bin.Addresses
+	for addr := range l.Funcs {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	var decls []*ir.Function
+	declared := make(map[*ir.Function]bool)
+	for _, addr := range addrs {
+		fn := l.Funcs[addr].Function
+		if declared[fn] {
+			// Functions shared by several addresses (e.g. imports).
+			continue
+		}
+		declared[fn] = true
+		decls = append(decls, x86.DeclFunc(fn))
+	}
+	m := &ir.Module{
+		DataLayout:   dataLayout,
+		TargetTriple: triple,
+		Types:        l.Types,
+		Globals:      globals,
+		Funcs:        decls,
+	}
+	index.Globals = "globals.ll"
+	if err := writeModule(filepath.Join(dir, index.Globals), l, m); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Store module index.
+	addrs = addrs[:0]
+	for addr := range entries {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	index.Funcs = nil
+	for _, addr := range addrs {
+		index.Funcs = append(index.Funcs, entries[addr])
+	}
+	buf, err := json.MarshalIndent(index, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	dbg.Printf("creating %q", indexPath)
+	if err := ioutil.WriteFile(indexPath, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// writeModule writes the given LLVM IR module to the output path, with the
+// function attributes inferred by the lifter.
+func writeModule(path string, l *x86.Lifter, m *ir.Module) error {
+	if err := ioutil.WriteFile(path, []byte(l.FormatModule(m)+"\n"), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
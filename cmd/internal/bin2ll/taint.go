@@ -0,0 +1,33 @@
+package bin2ll
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/decomp/exp/lift/x86"
+	"github.com/pkg/errors"
+)
+
+// A taintReport records the untrusted data of the selected taint sources
+// reaching the lifted functions, for vulnerability triage.
+type taintReport struct {
+	// Selected taint sources.
+	Sources []string `json:"sources"`
+	// Untrusted data reaching the parameters, return values and sinks of each
+	// function; functions not reached by untrusted data are omitted.
+	Funcs []x86.TaintResult `json:"funcs"`
+}
+
+// storeTaintReport stores the given taint analysis report to the specified
+// path in JSON format.
+func storeTaintReport(path string, r *taintReport) error {
+	buf, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
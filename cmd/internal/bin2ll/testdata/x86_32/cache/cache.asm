@@ -0,0 +1,30 @@
+[BITS 32]
+
+global f:function
+global g:function
+global h:function
+
+section .data
+
+x:
+	dd      42
+
+section .text
+
+; === [ Cached functions ] =====================================================
+
+; Calls to functions which are lifted from the cache.
+f:
+	call    g
+	call    h
+	ret
+
+; Unsupported instruction; lifted as a call to the @asm.cpuid stub.
+g:
+	mov     eax, [x]
+	cpuid
+	ret
+
+; Trap; lifted as a call to the @llvm.trap intrinsic.
+h:
+	ud2
@@ -0,0 +1,22 @@
+[BITS 32]
+
+global f:function
+global g:function
+
+section .text
+
+; === [ Served functions ] =====================================================
+
+; Function with a conditional branch.
+f:
+	mov     eax, [esp+4]
+	test    eax, eax
+	jz      .zero
+	mov     eax, 1
+.zero:
+	ret
+
+; Straight-line function.
+g:
+	xor     eax, eax
+	ret
@@ -0,0 +1,192 @@
+package bin2ll
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/pkg/errors"
+)
+
+// A yaraMatch records a match of a string of a YARA rule in a section of the
+// binary executable.
+type yaraMatch struct {
+	// Name of the matching rule.
+	rule string
+	// Address of the matching string.
+	addr bin.Address
+}
+
+// scanYara scans the sections of the given binary executable using the YARA
+// rules of the specified rules file, and returns the matches of the strings of
+// the rules. The contents of each section are scanned separately, using the
+// yara command line tool.
+func scanYara(rulesPath string, file *bin.File) ([]yaraMatch, error) {
+	yaraPath, err := exec.LookPath("yara")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	tmpDir, err := ioutil.TempDir("", "decomp-yara-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	var matches []yaraMatch
+	for i, sect := range file.Sections {
+		if len(sect.Name) == 0 && len(file.Segments) > 0 {
+			// Skip segments, as their contents are included in sections.
+			continue
+		}
+		if len(sect.Data) == 0 {
+			continue
+		}
+		sectPath := filepath.Join(tmpDir, strconv.Itoa(i))
+		if err := ioutil.WriteFile(sectPath, sect.Data, 0644); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		cmd := exec.Command(yaraPath, "-s", rulesPath, sectPath)
+		stderr := &bytes.Buffer{}
+		cmd.Stderr = stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Errorf("unable to scan section %q using yara; %v\n%s", sect.Name, err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		ms, err := parseYaraOutput(out, sect.Addr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		matches = append(matches, ms...)
+	}
+	return matches, nil
+}
+
+// parseYaraOutput parses the output of `yara -s` of a section starting at the
+// given address; e.g.
+//
+//    aes_sbox /tmp/decomp-yara-123/1
+//    0x1a0:$sbox: c|w{\xF2ko\xC50\x01g+\xFE\xD7\xABv
+func parseYaraOutput(out []byte, sectAddr bin.Address) ([]yaraMatch, error) {
+	var matches []yaraMatch
+	rule := ""
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "0x") {
+			// Matching rule.
+			if fields := strings.Fields(line); len(fields) > 0 {
+				rule = fields[0]
+			}
+			continue
+		}
+		// Matching string of rule.
+		pos := strings.Index(line, ":")
+		if pos == -1 || len(rule) == 0 {
+			return nil, errors.Errorf("invalid yara output line %q", line)
+		}
+		off, err := strconv.ParseUint(line[len("0x"):pos], 16, 64)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		matches = append(matches, yaraMatch{rule: rule, addr: sectAddr + bin.Address(off)})
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return matches, nil
+}
+
+// attachYara attaches the names of the YARA rules matching within the lifted
+// functions at the given addresses and the global variables of the lifter as
+// "yara" metadata; e.g.
+//
+//    @g_404000 = global [256 x i8] ..., !yara !{!"aes_sbox"}
+func attachYara(l *x86.Lifter, funcAddrs []bin.Address, matches []yaraMatch) {
+	// Rules matching within functions and global variables, indexed by address.
+	funcRules := make(map[bin.Address]map[string]bool)
+	globalRules := make(map[bin.Address]map[string]bool)
+	add := func(m map[bin.Address]map[string]bool, addr bin.Address, rule string) {
+		if m[addr] == nil {
+			m[addr] = make(map[string]bool)
+		}
+		m[addr][rule] = true
+	}
+	for _, match := range matches {
+		for _, funcAddr := range funcAddrs {
+			f, ok := l.Funcs[funcAddr]
+			if !ok || f.AsmFunc == nil {
+				continue
+			}
+			for blockAddr, block := range f.AsmFunc.Blocks {
+				end := block.Term.Addr + bin.Address(block.Term.Len)
+				if blockAddr <= match.addr && match.addr < end {
+					add(funcRules, funcAddr, match.rule)
+					break
+				}
+			}
+		}
+		for globalAddr, g := range l.Globals {
+			end := globalAddr + bin.Address(l.SizeOf(g.Content))
+			if globalAddr <= match.addr && match.addr < end {
+				add(globalRules, globalAddr, match.rule)
+			}
+		}
+	}
+	for funcAddr, rules := range funcRules {
+		f := l.Funcs[funcAddr]
+		dbg.Printf("function %q matched by YARA rules %q", f.Name, sortedKeys(rules))
+		setYaraMetadata(&f.Metadata, sortedKeys(rules))
+	}
+	for globalAddr, rules := range globalRules {
+		g := l.Globals[globalAddr]
+		dbg.Printf("global variable %q matched by YARA rules %q", g.Name, sortedKeys(rules))
+		setYaraMetadata(&g.Metadata, sortedKeys(rules))
+	}
+}
+
+// setYaraMetadata records the names of the given YARA rules in the "yara" entry
+// of the metadata attachments.
+func setYaraMetadata(md *map[string]*metadata.Metadata, rules []string) {
+	if *md == nil {
+		*md = make(map[string]*metadata.Metadata)
+	}
+	node := &metadata.Metadata{}
+	for _, rule := range rules {
+		node.Nodes = append(node.Nodes, &metadata.String{Val: rule})
+	}
+	(*md)["yara"] = node
+}
+
+// yaraRules returns the names of the YARA rules recorded in the "yara" entry of
+// the given metadata attachments; or nil if not present.
+func yaraRules(md map[string]*metadata.Metadata) []string {
+	node, ok := md["yara"]
+	if !ok {
+		return nil
+	}
+	var rules []string
+	for _, n := range node.Nodes {
+		if s, ok := n.(*metadata.String); ok {
+			rules = append(rules, s.Val)
+		}
+	}
+	return rules
+}
+
+// sortedKeys returns the keys of the given set, sorted in ascending order.
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,284 @@
+// Package cli provides the command line flags and binary executable loaders
+// shared by the decomp tools and subcommands.
+package cli
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	_ "github.com/decomp/exp/bin/elf" // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"  // register LE/LX decoder
+	"github.com/decomp/exp/bin/mz"    // register MZ decoder
+	_ "github.com/decomp/exp/bin/ne"  // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"  // register PE decoder
+	_ "github.com/decomp/exp/bin/pef" // register PEF decoder
+	"github.com/decomp/exp/bin/raw"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/decomp/exp/logging"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "cli:" prefix, which logs debug messages
+	// to standard error.
+	dbg = logging.New("cli", logging.LevelDebug, term.CyanBold("cli:")+" ")
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = logging.New("cli", logging.LevelInfo, term.RedBold("warning:")+" ")
+)
+
+// ### [ Loader ] ##############################################################
+
+// A Loader parses binary executables, as specified by command line flags.
+// Parsed binary executables are cached by path, and shared by the users of the
+// loader.
+type Loader struct {
+	// Machine architecture of raw binary executables.
+	RawArch bin.Arch
+	// Entry point of raw binary executables.
+	RawEntry bin.Address
+	// Base address of raw binary executables.
+	RawBase bin.Address
+	// Image base address to rebase executables to; or 0 to use the image base
+	// of the executable.
+	Base bin.Address
+	// Path of the project file; or the empty string to use the project file
+	// next to the binary executable (e.g. "foo.exe.project.json"), if present.
+	ProjectPath string
+	// Project settings of the last parsed binary executable; or nil if neither
+	// a project file nor an export of external analysis (e.g. Ghidra or IDA) is
+	// used.
+	Project *Project
+	// Unpack specifies whether to emulate the unpacking stub of packed
+	// executables up to the original entry point, and to process the unpacked
+	// executable. UPX-packed executables are unpacked using `upx -d` instead,
+	// if present.
+	Unpack bool
+	// Path of the Ghidra XML export of function boundaries, names and
+	// prototypes to import; or the empty string to not import Ghidra analysis.
+	// Names and prototypes of the project file take precedence.
+	GhidraPath string
+	// Path of the IDA export of function boundaries, names and prototypes to
+	// import (see ImportIDA); or the empty string to not import IDA analysis.
+	// Names and prototypes of the project file take precedence.
+	IDAPath string
+	// R2 specifies whether to locate function boundaries and strings using
+	// radare2 (r2), if present in PATH.
+	R2 bool
+	// Strings located by radare2 of the last parsed binary executable, indexed
+	// by address; or nil if radare2 is not used.
+	Strings map[bin.Address]x86.KnownString
+	// Parsed binary executables, indexed by path.
+	files map[string]*bin.File
+}
+
+// RegisterFlags registers the command line flags of the loader with the given
+// flag set.
+func (l *Loader) RegisterFlags(fs *flag.FlagSet) {
+	fs.Var(&l.Base, "base", "rebase executable to image base address (e.g. 0x400000 for position independent executables); addresses of associated JSON files are relative to the new image base")
+	fs.Var(&l.RawArch, "raw", "machine architecture of raw binary executable (x86_16, x86_32, x86_64, PowerPC_32, ...)")
+	fs.Var(&l.RawEntry, "rawentry", "entry point of raw binary executable")
+	fs.Var(&l.RawBase, "rawbase", "base address of raw binary executable")
+	fs.BoolVar(&l.Unpack, "unpack", false, "emulate the unpacking stub of packed executables up to the original entry point, and process the unpacked executable; UPX-packed executables are unpacked using upx -d, if present in PATH")
+	fs.StringVar(&l.GhidraPath, "ghidra", "", "Ghidra XML export (File -> Export Program... -> XML) of function boundaries, names and prototypes to import")
+	fs.StringVar(&l.IDAPath, "ida", "", "IDA export of function boundaries, names and prototypes to import; IDC database dump (File -> Produce file -> Dump database to IDC file...) or JSON export (*.json) of IDAPython")
+	fs.StringVar(&l.ProjectPath, "project", "", "project file of base address, architecture, entry points, function signatures, names and exclusions (default FILE.project.json, if present)")
+	fs.BoolVar(&l.R2, "r2", false, "locate function boundaries and strings using radare2 (aflj and izj), if present in PATH")
+}
+
+// ParseFile parses the given binary executable. Raw binary executables are
+// parsed if a raw machine architecture is specified, and DOS .COM executables
+// are identified by file extension as they have no magic number. Settings of
+// the project file are applied, unless specified through command line flags.
+func (l *Loader) ParseFile(binPath string) (*bin.File, error) {
+	if file, ok := l.files[binPath]; ok {
+		return file, nil
+	}
+	projectPath := l.ProjectPath
+	if len(projectPath) == 0 {
+		projectPath = findProjectFile(binPath)
+	}
+	if len(projectPath) > 0 {
+		p, err := ParseProject(projectPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		p.apply(l)
+		l.Project = p
+	}
+	file, err := l.parseFile(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	packer := file.DetectPacker()
+	if l.Unpack {
+		if file, err = l.unpack(binPath, file); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	} else if packer != nil {
+		warn.Printf("%q appears packed with %v; only the unpacking stub is analyzable without unpacking (use -unpack to unpack the executable)", binPath, packer)
+	}
+	if len(l.GhidraPath) > 0 {
+		p, err := ImportGhidra(l.GhidraPath, file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		l.mergeProject(p)
+	}
+	if len(l.IDAPath) > 0 {
+		p, err := ImportIDA(l.IDAPath, file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		l.mergeProject(p)
+	}
+	if l.R2 {
+		switch {
+		case l.RawArch != 0:
+			warn.Printf("skipping radare2 analysis of raw binary executable %q", binPath)
+		case l.Unpack && packer != nil:
+			// radare2 would analyze the packed executable on disk.
+			warn.Printf("skipping radare2 analysis of unpacked executable %q", binPath)
+		default:
+			strs, err := analyzeR2(binPath, file)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			l.Strings = strs
+		}
+	}
+	if l.Project != nil {
+		l.Project.addEntries(file)
+	}
+	if l.files == nil {
+		l.files = make(map[string]*bin.File)
+	}
+	l.files[binPath] = file
+	return file, nil
+}
+
+// mergeProject merges the function names and prototypes of the given project
+// settings of external analysis (e.g. Ghidra or IDA) into the project settings
+// of the loader.
+func (l *Loader) mergeProject(p *Project) {
+	if l.Project == nil {
+		l.Project = &Project{}
+	}
+	l.Project.merge(p)
+}
+
+// parseFile parses the given binary executable, without caching.
+func (l *Loader) parseFile(binPath string) (*bin.File, error) {
+	// Parse raw binary executable.
+	if l.RawArch != 0 {
+		file, err := raw.ParseFile(binPath, l.RawArch)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		file.Entry = l.RawEntry
+		file.Sections[0].Addr = l.RawBase
+		return file, nil
+	}
+	// Parse binary executable.
+	parse := bin.ParseFile
+	if strings.EqualFold(filepath.Ext(binPath), ".com") {
+		parse = mz.ParseCOMFile
+	}
+	file, err := parse(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if l.Base != 0 {
+		if err := file.Rebase(l.Base); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return file, nil
+}
+
+// ### [ Function selection ] ##################################################
+
+// A FuncSelector selects the functions to process, as specified by command
+// line flags.
+type FuncSelector struct {
+	// Basic block address to process; or 0 to process functions.
+	Block bin.Address
+	// TODO: Remove -first flag and First.
+	// First function address to process; or 0 to start at the first function.
+	First bin.Address
+	// Function address to process; or 0 to process all functions.
+	Func bin.Address
+	// TODO: Remove -last flag and Last.
+	// Last function address to process (exclusive); or 0 to stop at the last
+	// function.
+	Last bin.Address
+	// Function addresses to exclude from processing (e.g. as specified by the
+	// project file).
+	Exclude []bin.Address
+}
+
+// RegisterFlags registers the command line flags of the function selector with
+// the given flag set. The verb describes the processing of functions (e.g.
+// "lift" or "disassemble").
+func (s *FuncSelector) RegisterFlags(fs *flag.FlagSet, verb string) {
+	fs.Var(&s.Block, "block", "basic block address to "+verb)
+	fs.Var(&s.First, "first", "first function address to "+verb)
+	fs.Var(&s.Func, "func", "function address to "+verb)
+	fs.Var(&s.Last, "last", "last function address to "+verb)
+}
+
+// Select returns the selected function addresses of the given sorted function
+// addresses.
+func (s *FuncSelector) Select(funcAddrs []bin.Address) []bin.Address {
+	if s.Func != 0 {
+		return []bin.Address{s.Func}
+	}
+	var selected []bin.Address
+	for _, funcAddr := range funcAddrs {
+		if s.First != 0 && funcAddr < s.First {
+			// skip functions before first address.
+			continue
+		}
+		if s.Last != 0 && funcAddr >= s.Last {
+			// skip functions after last address.
+			break
+		}
+		if s.excluded(funcAddr) {
+			continue
+		}
+		selected = append(selected, funcAddr)
+	}
+	return selected
+}
+
+// excluded reports whether the given function address is excluded from
+// processing.
+func (s *FuncSelector) excluded(funcAddr bin.Address) bool {
+	for _, addr := range s.Exclude {
+		if addr == funcAddr {
+			return true
+		}
+	}
+	return false
+}
+
+// ### [ Helper types ] ########################################################
+
+// StringList is a list of strings, which implements the flag.Value interface;
+// each occurrence of the flag appends to the list.
+type StringList []string
+
+// String returns the string representation of the list.
+func (list *StringList) String() string {
+	return strings.Join(*list, ",")
+}
+
+// Set appends the given string to the list.
+func (list *StringList) Set(s string) error {
+	*list = append(*list, s)
+	return nil
+}
@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// ### [ Ghidra XML ] ##########################################################
+
+// A GhidraProgram is the root element of a Ghidra XML export (File -> Export
+// Program... -> XML). Only the elements of functions, symbols and defined data
+// are represented; other elements are ignored on import.
+//
+// Example Ghidra XML contents.
+//
+//    <PROGRAM NAME="foo.exe" EXE_FORMAT="Portable Executable (PE)" IMAGE_BASE="00400000">
+//       <PROCESSOR NAME="x86" ENDIAN="little" ADDRESS_MODEL="32-bit" />
+//       <FUNCTIONS>
+//          <FUNCTION ENTRY_POINT="00401000" NAME="main" LIBRARY_FUNCTION="n">
+//             <RETURN_TYPE DATATYPE="int" DATATYPE_NAMESPACE="/" SIZE="0x4" />
+//             <ADDRESS_RANGE START="00401000" END="0040104f" />
+//             <TYPEINFO_CMT>int __cdecl main(int _Argc, char * * _Argv)</TYPEINFO_CMT>
+//          </FUNCTION>
+//       </FUNCTIONS>
+//    </PROGRAM>
+type GhidraProgram struct {
+	XMLName xml.Name `xml:"PROGRAM"`
+	// Program name.
+	Name string `xml:"NAME,attr"`
+	// Path of the binary executable.
+	ExePath string `xml:"EXE_PATH,attr,omitempty"`
+	// Binary executable format (e.g. "Portable Executable (PE)").
+	ExeFormat string `xml:"EXE_FORMAT,attr,omitempty"`
+	// Image base address.
+	ImageBase string `xml:"IMAGE_BASE,attr,omitempty"`
+	// Processor of the program.
+	Processor *GhidraProcessor `xml:"PROCESSOR"`
+	// Defined data.
+	Data []GhidraData `xml:"DATA>DEFINED_DATA"`
+	// Symbols (e.g. labels of global variables).
+	Symbols []GhidraSymbol `xml:"SYMBOL_TABLE>SYMBOL"`
+	// Functions.
+	Funcs []GhidraFunc `xml:"FUNCTIONS>FUNCTION"`
+}
+
+// A GhidraProcessor specifies the processor of a Ghidra program.
+type GhidraProcessor struct {
+	// Processor name (e.g. "x86").
+	Name string `xml:"NAME,attr"`
+	// Endianness ("little" or "big").
+	Endian string `xml:"ENDIAN,attr"`
+	// Address model (e.g. "32-bit").
+	AddressModel string `xml:"ADDRESS_MODEL,attr"`
+}
+
+// A GhidraData is a defined data item of a Ghidra program.
+type GhidraData struct {
+	// Address of the data item.
+	Addr string `xml:"ADDRESS,attr"`
+	// Data type name (e.g. "dword" or "string").
+	DataType string `xml:"DATATYPE,attr"`
+	// Category path of the data type (e.g. "/").
+	DataTypeNamespace string `xml:"DATATYPE_NAMESPACE,attr"`
+	// Size in bytes of the data item (e.g. "0x4").
+	Size string `xml:"SIZE,attr"`
+}
+
+// A GhidraSymbol is a symbol of a Ghidra program.
+type GhidraSymbol struct {
+	// Address of the symbol.
+	Addr string `xml:"ADDRESS,attr"`
+	// Symbol name.
+	Name string `xml:"NAME,attr"`
+	// Namespace of the symbol; or empty for the global namespace.
+	Namespace string `xml:"NAMESPACE,attr"`
+	// Symbol type (e.g. "global").
+	Type string `xml:"TYPE,attr"`
+	// Source of the symbol (e.g. "USER_DEFINED" or "ANALYSIS").
+	SourceType string `xml:"SOURCE_TYPE,attr"`
+	// Primary specifies whether the symbol is the primary symbol at its
+	// address ("y" or "n").
+	Primary string `xml:"PRIMARY,attr"`
+}
+
+// A GhidraFunc is a function of a Ghidra program.
+type GhidraFunc struct {
+	// Entry address of the function; e.g. "00401000", or "EXTERNAL:00000001"
+	// for external functions.
+	Entry string `xml:"ENTRY_POINT,attr"`
+	// Function name.
+	Name string `xml:"NAME,attr"`
+	// Namespace of the function; or empty for the global namespace.
+	Namespace string `xml:"NAMESPACE,attr,omitempty"`
+	// Library function ("y" or "n").
+	Library string `xml:"LIBRARY_FUNCTION,attr,omitempty"`
+	// Return type of the function.
+	Ret *GhidraType `xml:"RETURN_TYPE"`
+	// Address ranges of the function body (inclusive end address).
+	Ranges []GhidraRange `xml:"ADDRESS_RANGE"`
+	// Prototype of the function in C syntax, with Ghidra data type names; e.g.
+	// "undefined4 __stdcall FUN_00401000(int param_1)".
+	Proto string `xml:"TYPEINFO_CMT,omitempty"`
+}
+
+// A GhidraType refers to a data type of a Ghidra program.
+type GhidraType struct {
+	// Data type name (e.g. "uint").
+	DataType string `xml:"DATATYPE,attr"`
+	// Category path of the data type (e.g. "/").
+	DataTypeNamespace string `xml:"DATATYPE_NAMESPACE,attr"`
+	// Size in bytes of the data type (e.g. "0x4").
+	Size string `xml:"SIZE,attr"`
+}
+
+// A GhidraRange is an address range of a Ghidra program.
+type GhidraRange struct {
+	// Start address.
+	Start string `xml:"START,attr"`
+	// End address (inclusive).
+	End string `xml:"END,attr"`
+}
+
+// GhidraAddr returns the Ghidra XML representation of the given address; e.g.
+// "00401000".
+func GhidraAddr(addr bin.Address) string {
+	return fmt.Sprintf("%08x", uint64(addr))
+}
+
+// parseGhidraAddr parses the given Ghidra XML address; e.g. "00401000",
+// "ram:00401000" or "1000:0010" (segmented address of 16-bit real mode). The
+// boolean return value indicates success; addresses of other address spaces
+// (e.g. "EXTERNAL:00000001") are not supported.
+func parseGhidraAddr(s string) (bin.Address, bool) {
+	parts := strings.Split(s, ":")
+	switch len(parts) {
+	case 1:
+		v, err := strconv.ParseUint(s, 16, 64)
+		if err != nil {
+			return 0, false
+		}
+		return bin.Address(v), true
+	case 2:
+		if strings.EqualFold(parts[0], "ram") {
+			return parseGhidraAddr(parts[1])
+		}
+		seg, err := strconv.ParseUint(parts[0], 16, 16)
+		if err != nil {
+			return 0, false
+		}
+		off, err := strconv.ParseUint(parts[1], 16, 16)
+		if err != nil {
+			return 0, false
+		}
+		return bin.LinearAddr(uint16(seg), uint16(off)), true
+	}
+	return 0, false
+}
+
+// ### [ Ghidra XML import ] ###################################################
+
+// ghidraTypeWords maps from the names of Ghidra built-in data types to C types.
+var ghidraTypeWords = map[string]string{
+	"undefined":  "unsigned char",
+	"undefined1": "unsigned char",
+	"undefined2": "unsigned short",
+	"undefined4": "unsigned int",
+	"undefined8": "unsigned long long",
+	"byte":       "unsigned char",
+	"word":       "unsigned short",
+	"dword":      "unsigned int",
+	"qword":      "unsigned long long",
+	"sbyte":      "signed char",
+	"sword":      "short",
+	"sdword":     "int",
+	"sqword":     "long long",
+	"uchar":      "unsigned char",
+	"ushort":     "unsigned short",
+	"uint":       "unsigned int",
+	"ulong":      "unsigned long",
+	"longlong":   "long long",
+	"ulonglong":  "unsigned long long",
+	"wchar16":    "unsigned short",
+	"wchar32":    "unsigned int",
+	"float10":    "long double",
+	"code":       "void",
+	"pointer":    "void *",
+	// Calling convention of entry points.
+	"processEntry": "",
+}
+
+// ImportGhidra imports the function boundaries, names and prototypes of the
+// given Ghidra XML export of the binary executable. Addresses are relocated
+// from the image base of the Ghidra program to the image base of the binary
+// executable.
+//
+// Function boundaries are added to the function bounds and chunks of the
+// binary executable, unless already present (e.g. from exception handling
+// metadata). Function names and prototypes are returned as project settings;
+// default names of functions (e.g. "FUN_00401000") and prototypes of functions
+// with undefined signatures are omitted.
+func ImportGhidra(xmlPath string, file *bin.File) (*Project, error) {
+	f, err := os.Open(xmlPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	prog := &GhidraProgram{}
+	if err := xml.NewDecoder(f).Decode(prog); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse Ghidra XML %q", xmlPath)
+	}
+	var delta bin.Address
+	if len(prog.ImageBase) > 0 {
+		base, ok := parseGhidraAddr(prog.ImageBase)
+		if !ok {
+			return nil, errors.Errorf("invalid image base %q of Ghidra XML %q", prog.ImageBase, xmlPath)
+		}
+		delta = file.ImageBase - base
+	}
+	p := &Project{
+		Names:  make(map[bin.Address]string),
+		Protos: make(map[bin.Address]string),
+	}
+	nfuncs := 0
+	for _, fn := range prog.Funcs {
+		entry, ok := parseGhidraAddr(fn.Entry)
+		if !ok {
+			// skip external functions.
+			continue
+		}
+		entry += delta
+		if !file.IsCode(entry) {
+			warn.Printf("skipping Ghidra function %q at %v outside of executable sections", fn.Name, entry)
+			continue
+		}
+		nfuncs++
+		// Function boundaries.
+		var bodyEnd bin.Address
+		for _, r := range fn.Ranges {
+			start, ok1 := parseGhidraAddr(r.Start)
+			end, ok2 := parseGhidraAddr(r.End)
+			if !ok1 || !ok2 || end < start {
+				warn.Printf("skipping invalid address range %s-%s of Ghidra function %q", r.Start, r.End, fn.Name)
+				continue
+			}
+			start += delta
+			end += delta + 1
+			if start <= entry && entry < end {
+				bodyEnd = end
+				continue
+			}
+			addFuncChunk(file, start, entry)
+		}
+		addFunc(file, entry, bodyEnd)
+		// Function name.
+		name := fn.Name
+		if len(fn.Namespace) > 0 && fn.Namespace != "Global" {
+			name = fn.Namespace + "::" + name
+		}
+		if !defaultName.MatchString(fn.Name) {
+			p.Names[entry] = name
+		}
+		// Function prototype.
+		if proto, ok := ghidraProto(fn.Proto); ok {
+			p.Protos[entry] = proto
+		}
+	}
+	dbg.Printf("imported %d functions (%d names and %d prototypes) of Ghidra XML %q", nfuncs, len(p.Names), len(p.Protos), xmlPath)
+	return p, nil
+}
+
+// ghidraProto returns the C function prototype corresponding to the given
+// prototype of a Ghidra function, with Ghidra data type names replaced by C
+// types, and the function name replaced by a valid C identifier. The boolean
+// return value is false for undefined signatures (e.g. "undefined
+// FUN_00401000()"), as committed by neither users nor the decompiler.
+func ghidraProto(proto string) (string, bool) {
+	proto = strings.TrimSpace(proto)
+	lparen := strings.Index(proto, "(")
+	if lparen == -1 {
+		return "", false
+	}
+	head, params := strings.TrimSpace(proto[:lparen]), proto[lparen:]
+	pos := strings.LastIndexAny(head, " *") + 1
+	spec, name := head[:pos], head[pos:]
+	if strings.TrimSpace(spec) == "undefined" && strings.TrimSpace(params) == "()" {
+		return "", false
+	}
+	return cProto(spec, name, params, ghidraTypeWords), true
+}
@@ -0,0 +1,285 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/jsonutil"
+	"github.com/pkg/errors"
+)
+
+// ### [ IDA export ] ##########################################################
+
+// An idaExport records the functions of an IDA database, as exported by the
+// following IDAPython script.
+//
+//    import idaapi, idautils, idc, json
+//
+//    funcs = []
+//    for ea in idautils.Functions():
+//        f = idaapi.get_func(ea)
+//        chunks = [["0x%X" % start, "0x%X" % end] for start, end in idautils.Chunks(ea) if start != f.start_ea]
+//        funcs.append({"start": "0x%X" % ea, "end": "0x%X" % f.end_ea, "name": idc.get_func_name(ea), "type": idc.get_type(ea) or "", "chunks": chunks})
+//    with open(idc.get_idb_path() + ".json", "w") as f:
+//        json.dump({"imagebase": "0x%X" % idaapi.get_imagebase(), "funcs": funcs}, f, indent=1)
+//
+// Example IDA export contents.
+//
+//    {
+//       "imagebase": "0x400000",
+//       "funcs": [
+//          {"start": "0x401000", "end": "0x40104F", "name": "main", "type": "int __cdecl(int argc, const char **argv, const char **envp)", "chunks": [["0x402000", "0x402010"]]}
+//       ]
+//    }
+type idaExport struct {
+	// Image base address of the IDA database; or 0 if not specified.
+	ImageBase bin.Address `json:"imagebase"`
+	// Functions.
+	Funcs []*idaFunc `json:"funcs"`
+}
+
+// An idaFunc records a function of an IDA database.
+type idaFunc struct {
+	// Entry address of the function.
+	Start bin.Address `json:"start"`
+	// End address of the function (exclusive); or 0 (or BADADDR) if unknown.
+	End bin.Address `json:"end"`
+	// Function name.
+	Name string `json:"name"`
+	// Function type in IDA syntax; e.g. "int __cdecl(int argc, char **argv)".
+	Type string `json:"type"`
+	// Address ranges (start and exclusive end) of function chunks, excluding the
+	// function body.
+	Chunks [][2]bin.Address `json:"chunks"`
+}
+
+// idaTypeWords maps from the names of IDA built-in data types and type
+// attributes to C types.
+var idaTypeWords = map[string]string{
+	"_BYTE":    "unsigned char",
+	"_WORD":    "unsigned short",
+	"_DWORD":   "unsigned int",
+	"_QWORD":   "unsigned long long",
+	"_BOOL1":   "unsigned char",
+	"_BOOL2":   "unsigned short",
+	"_BOOL4":   "unsigned int",
+	"_BOOL8":   "unsigned long long",
+	"_UNKNOWN": "void",
+	// Type attributes.
+	"__noreturn":   "",
+	"__hidden":     "",
+	"__return_ptr": "",
+	"__struct_ptr": "",
+}
+
+// Regular expressions of the IDC functions of IDC database dumps (File ->
+// Produce file -> Dump database to IDC file...); IDA 7.x and legacy names.
+const idcAddr = `(0[xX][0-9a-fA-F]+|[0-9]+)`
+
+var (
+	// add_func(start, end); e.g. `add_func(0X401000,0X40104F);`
+	idcFunc = regexp.MustCompile(`\b(?:add_func|MakeFunction)\s*\(\s*` + idcAddr + `\s*(?:,\s*` + idcAddr + `\s*)?\)`)
+	// append_func_tail(func, start, end)
+	idcChunk = regexp.MustCompile(`\b(?:append_func_tail|AppendFchunk)\s*\(\s*` + idcAddr + `\s*,\s*` + idcAddr + `\s*,\s*` + idcAddr + `\s*\)`)
+	// set_name(addr, name); e.g. `set_name(0X401000, "main");`
+	idcName = regexp.MustCompile(`\b(?:set_name|MakeNameEx|MakeName)\s*\(\s*` + idcAddr + `\s*,\s*"((?:[^"\\]|\\.)*)"`)
+	// SetType(addr, type); e.g. `SetType(0X401000, "int __cdecl(int argc)");`
+	idcType = regexp.MustCompile(`\bSetType\s*\(\s*` + idcAddr + `\s*,\s*"((?:[^"\\]|\\.)*)"`)
+)
+
+// ImportIDA imports the function boundaries, names and prototypes of the given
+// IDA export of the binary executable; either an IDC database dump, or a JSON
+// export (*.json) as produced by the IDAPython script of idaExport. Addresses of
+// JSON exports are relocated from the image base of the IDA database to the
+// image base of the binary executable; addresses of IDC database dumps are
+// assumed to be relative to the image base of the binary executable (see -base
+// flag).
+//
+// Function boundaries are added to the function bounds and chunks of the
+// binary executable, unless already present (e.g. from exception handling
+// metadata). Function names and prototypes are returned as project settings;
+// default names of functions (e.g. "sub_401000") are omitted.
+func ImportIDA(idaPath string, file *bin.File) (*Project, error) {
+	exp := &idaExport{}
+	if strings.EqualFold(filepath.Ext(idaPath), ".json") {
+		if err := jsonutil.ParseFile(idaPath, exp); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	} else {
+		buf, err := ioutil.ReadFile(idaPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if exp, err = parseIDC(string(buf)); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse IDC file %q", idaPath)
+		}
+	}
+	var delta bin.Address
+	if exp.ImageBase != 0 {
+		delta = file.ImageBase - exp.ImageBase
+	}
+	p := &Project{
+		Names:  make(map[bin.Address]string),
+		Protos: make(map[bin.Address]string),
+	}
+	nfuncs := 0
+	for _, fn := range exp.Funcs {
+		entry := fn.Start + delta
+		if !file.IsCode(entry) {
+			warn.Printf("skipping IDA function %q at %v outside of executable sections", fn.Name, entry)
+			continue
+		}
+		nfuncs++
+		// Function boundaries.
+		var end bin.Address
+		if fn.End > fn.Start && fn.End != 0xFFFFFFFF && fn.End != ^bin.Address(0) {
+			end = fn.End + delta
+		}
+		addFunc(file, entry, end)
+		for _, chunk := range fn.Chunks {
+			addFuncChunk(file, chunk[0]+delta, entry)
+		}
+		// Function name.
+		if len(fn.Name) > 0 && !defaultName.MatchString(fn.Name) {
+			p.Names[entry] = fn.Name
+		}
+		// Function prototype.
+		if len(fn.Type) > 0 {
+			name := fn.Name
+			if len(name) == 0 {
+				name = fmt.Sprintf("f_%06X", uint64(entry))
+			}
+			if proto, ok := idaProto(fn.Type, name); ok {
+				p.Protos[entry] = proto
+			}
+		}
+	}
+	dbg.Printf("imported %d functions (%d names and %d prototypes) of IDA export %q", nfuncs, len(p.Names), len(p.Protos), idaPath)
+	return p, nil
+}
+
+// parseIDC parses the functions, function chunks, names and types of the given
+// IDC database dump. Names and types of addresses other than function entry
+// points are ignored.
+func parseIDC(src string) (*idaExport, error) {
+	parseAddr := func(s string) (bin.Address, error) {
+		var addr bin.Address
+		if err := addr.Set(s); err != nil {
+			return 0, errors.WithStack(err)
+		}
+		return addr, nil
+	}
+	unquote := func(s string) string {
+		if v, err := strconv.Unquote(`"` + s + `"`); err == nil {
+			return v
+		}
+		return s
+	}
+	funcs := make(map[bin.Address]*idaFunc)
+	for _, m := range idcFunc.FindAllStringSubmatch(src, -1) {
+		start, err := parseAddr(m[1])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		fn := &idaFunc{Start: start}
+		if len(m[2]) > 0 {
+			if fn.End, err = parseAddr(m[2]); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		funcs[start] = fn
+	}
+	for _, m := range idcChunk.FindAllStringSubmatch(src, -1) {
+		parent, err := parseAddr(m[1])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		start, err := parseAddr(m[2])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		end, err := parseAddr(m[3])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if fn, ok := funcs[parent]; ok {
+			fn.Chunks = append(fn.Chunks, [2]bin.Address{start, end})
+		}
+	}
+	for _, m := range idcName.FindAllStringSubmatch(src, -1) {
+		addr, err := parseAddr(m[1])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if fn, ok := funcs[addr]; ok {
+			fn.Name = unquote(m[2])
+		}
+	}
+	for _, m := range idcType.FindAllStringSubmatch(src, -1) {
+		addr, err := parseAddr(m[1])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if fn, ok := funcs[addr]; ok {
+			fn.Type = unquote(m[2])
+		}
+	}
+	exp := &idaExport{}
+	for _, fn := range funcs {
+		exp.Funcs = append(exp.Funcs, fn)
+	}
+	less := func(i, j int) bool {
+		return exp.Funcs[i].Start < exp.Funcs[j].Start
+	}
+	sort.Slice(exp.Funcs, less)
+	return exp, nil
+}
+
+// idaProto returns the C function prototype corresponding to the given IDA
+// function type, and function name. The function type is either nameless (e.g.
+// "int __cdecl(int argc)") or named (e.g. "int __cdecl main(int argc)"). The
+// boolean return value indicates success.
+func idaProto(typ, name string) (string, bool) {
+	typ = strings.TrimSpace(typ)
+	lparen := strings.Index(typ, "(")
+	if lparen == -1 {
+		return "", false
+	}
+	head, params := strings.TrimSpace(typ[:lparen]), typ[lparen:]
+	pos := strings.LastIndexAny(head, " *") + 1
+	if last := head[pos:]; len(last) > 0 && !strings.HasPrefix(last, "__") && !basicTypeWords[last] && !isIDATypeWord(last) {
+		// Named function type.
+		head, name = head[:pos], last
+	} else {
+		head += " "
+	}
+	return cProto(head, name, params, idaTypeWords), true
+}
+
+// basicTypeWords specifies the keywords of basic C types.
+var basicTypeWords = map[string]bool{
+	"void":     true,
+	"char":     true,
+	"short":    true,
+	"int":      true,
+	"long":     true,
+	"signed":   true,
+	"unsigned": true,
+	"float":    true,
+	"double":   true,
+	"_Bool":    true,
+	"bool":     true,
+}
+
+// isIDATypeWord reports whether the given identifier is the name of an IDA
+// built-in data type.
+func isIDATypeWord(s string) bool {
+	_, ok := idaTypeWords[s]
+	return ok
+}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/mewkiz/pkg/jsonutil"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/pkg/errors"
+)
+
+// A Project records the settings of a binary executable, which would otherwise
+// have to be repeated as command line flags of each invocation. Command line
+// flags take precedence over the settings of the project file.
+//
+// Example project file contents.
+//
+//    {
+//       "arch": "x86_32",
+//       "base": "0x400000",
+//       "entries": ["0x401000", "0x401200"],
+//       "sigs": {
+//          "0x401200": {"name": "parse_args", "callconv": "cdecl", "params": [{"type": "i32"}, {"type": "i8**"}], "ret": "i32"}
+//       },
+//       "protos": {"0x401400": "int __stdcall check_key(const char *key, unsigned int len)"},
+//       "names": {"0x401000": "start"},
+//       "exclude": ["0x401800"]
+//    }
+type Project struct {
+	// Machine architecture of raw binary executables (-raw flag).
+	Arch bin.Arch `json:"arch"`
+	// Image base address to rebase the executable to (-base flag).
+	Base bin.Address `json:"base"`
+	// Entry point of raw binary executables (-rawentry flag).
+	RawEntry bin.Address `json:"rawentry"`
+	// Base address of raw binary executables (-rawbase flag).
+	RawBase bin.Address `json:"rawbase"`
+	// Seed entry points of functions, in addition to those located by the
+	// parser of the binary executable.
+	Entries []bin.Address `json:"entries"`
+	// Function signatures, indexed by function address.
+	Sigs map[bin.Address]*x86.FuncSig `json:"sigs"`
+	// C function prototypes, indexed by function address; see
+	// x86.Lifter.ParseHeader for the supported subset of C. Function signatures
+	// of Sigs take precedence.
+	Protos map[bin.Address]string `json:"protos"`
+	// Function names, indexed by function address; overrides the names of
+	// functions located by other means (e.g. exports and symbols).
+	Names map[bin.Address]string `json:"names"`
+	// Function addresses to exclude from processing.
+	Exclude []bin.Address `json:"exclude"`
+}
+
+// ParseProject parses the given project file.
+func ParseProject(projectPath string) (*Project, error) {
+	p := &Project{}
+	if err := jsonutil.ParseFile(projectPath, p); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return p, nil
+}
+
+// findProjectFile returns the path of the project file of the given binary
+// executable (e.g. "foo.exe.project.json"); or the empty string if not present.
+func findProjectFile(binPath string) string {
+	path := binPath + ".project.json"
+	if !osutil.Exists(path) {
+		return ""
+	}
+	return path
+}
+
+// apply applies the settings of the project to the loader, unless specified
+// through command line flags.
+func (p *Project) apply(l *Loader) {
+	if l.RawArch == 0 {
+		l.RawArch = p.Arch
+	}
+	if l.RawEntry == 0 {
+		l.RawEntry = p.RawEntry
+	}
+	if l.RawBase == 0 {
+		l.RawBase = p.RawBase
+	}
+	if l.Base == 0 {
+		l.Base = p.Base
+	}
+}
+
+// addEntries adds the seed entry points of the project to the given binary
+// executable.
+func (p *Project) addEntries(file *bin.File) {
+	if len(p.Entries) == 0 {
+		return
+	}
+	if file.Roots == nil {
+		file.Roots = make(map[bin.Address]string)
+	}
+	for _, entry := range p.Entries {
+		if _, ok := file.Roots[entry]; ok {
+			continue
+		}
+		name, ok := p.Names[entry]
+		if !ok {
+			name = fmt.Sprintf("f_%06X", uint64(entry))
+		}
+		file.Roots[entry] = name
+	}
+}
+
+// merge merges the function names and prototypes of q into the project,
+// unless specified by the project.
+func (p *Project) merge(q *Project) {
+	if p.Names == nil {
+		p.Names = make(map[bin.Address]string)
+	}
+	for entry, name := range q.Names {
+		if _, ok := p.Names[entry]; !ok {
+			p.Names[entry] = name
+		}
+	}
+	if p.Protos == nil {
+		p.Protos = make(map[bin.Address]string)
+	}
+	for entry, proto := range q.Protos {
+		if _, ok := p.Protos[entry]; !ok {
+			p.Protos[entry] = proto
+		}
+	}
+}
+
+// ApplyLifter applies the function signatures, prototypes and names of the
+// project to the given lifter.
+func (p *Project) ApplyLifter(l *x86.Lifter) error {
+	protos := make(map[bin.Address]*x86.Proto)
+	for entry, src := range p.Protos {
+		ps, err := l.ParseHeader(src + ";")
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse prototype %q of function at %v", src, entry)
+		}
+		if len(ps) != 1 {
+			// Unsupported prototypes are skipped with a warning by ParseHeader.
+			continue
+		}
+		protos[entry] = ps[0]
+	}
+	l.SetProtos(protos)
+	if err := l.AddSigs(p.Sigs); err != nil {
+		return errors.WithStack(err)
+	}
+	l.RenameFuncs(p.Names)
+	return nil
+}
+
+// ### [ External analysis ] ###################################################
+
+// defaultName matches the default names of functions assigned by Ghidra, IDA
+// and the lifter; e.g. "FUN_00401000", "sub_401000" and "f_401000".
+var defaultName = regexp.MustCompile(`^((thunk_)?FUN_[0-9a-fA-F]+|(j_)*(sub|nullsub|unknown_libname)_[0-9a-fA-F]+|f_[0-9A-F]+)$`)
+
+// addFunc adds the function at the given entry point, as located by external
+// analysis (e.g. Ghidra or IDA), to the binary executable. The end address
+// (exclusive) of the function is added to the function bounds, unless already
+// present (e.g. from exception handling metadata); or the function is added as
+// a root if its end address is unknown (0).
+func addFunc(file *bin.File, entry, end bin.Address) {
+	if end > entry {
+		if file.FuncBounds == nil {
+			file.FuncBounds = make(map[bin.Address]bin.Address)
+		}
+		if _, ok := file.FuncBounds[entry]; !ok {
+			file.FuncBounds[entry] = end
+		}
+		return
+	}
+	if file.Roots == nil {
+		file.Roots = make(map[bin.Address]string)
+	}
+	if _, ok := file.Roots[entry]; !ok {
+		file.Roots[entry] = fmt.Sprintf("f_%06X", uint64(entry))
+	}
+}
+
+// addFuncChunk adds the function chunk at the given address of the specified
+// parent function, as located by external analysis, to the binary executable;
+// unless already present.
+func addFuncChunk(file *bin.File, start, parent bin.Address) {
+	if file.FuncChunks == nil {
+		file.FuncChunks = make(map[bin.Address]bin.Address)
+	}
+	if _, ok := file.FuncChunks[start]; !ok {
+		file.FuncChunks[start] = parent
+	}
+}
+
+// cWord matches identifiers of C prototypes.
+var cWord = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// cProto returns the C function prototype of the given declaration specifiers,
+// function name and parameter list (including parentheses) of a prototype of
+// external analysis. Data type names of the external tool are replaced by C
+// types as specified by typeWords, and the function name is replaced by a valid
+// C identifier.
+func cProto(spec, name, params string, typeWords map[string]string) string {
+	rewrite := func(s string) string {
+		return cWord.ReplaceAllStringFunc(s, func(word string) string {
+			if t, ok := typeWords[word]; ok {
+				return t
+			}
+			return word
+		})
+	}
+	ident := strings.Map(func(r rune) rune {
+		if r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	// Retain parameter names (the last of two or more identifiers of a
+	// parameter declaration), which may coincide with data type names.
+	params = strings.TrimSpace(params)
+	params = strings.TrimPrefix(params, "(")
+	params = strings.TrimSuffix(params, ")")
+	var decls []string
+	for _, decl := range strings.Split(params, ",") {
+		locs := cWord.FindAllStringIndex(decl, -1)
+		if len(locs) < 2 {
+			decls = append(decls, rewrite(decl))
+			continue
+		}
+		pos := locs[len(locs)-1][0]
+		decls = append(decls, rewrite(decl[:pos])+decl[pos:])
+	}
+	return rewrite(spec) + ident + "(" + strings.Join(decls, ",") + ")"
+}
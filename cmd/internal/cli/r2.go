@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os/exec"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/pkg/errors"
+)
+
+// ### [ radare2 ] #############################################################
+
+// r2Info records the binary information of radare2 (ij).
+type r2Info struct {
+	Bin struct {
+		// Base address of the binary executable.
+		BAddr uint64 `json:"baddr"`
+	} `json:"bin"`
+}
+
+// r2Func records a function located by radare2 (aflj).
+type r2Func struct {
+	// Entry address of the function.
+	Offset uint64 `json:"offset"`
+	// Function name (e.g. "sym.main" or "fcn.00401000").
+	Name string `json:"name"`
+	// Lowest address of the basic blocks of the function.
+	MinBound uint64 `json:"minbound"`
+	// Highest address (exclusive) of the basic blocks of the function.
+	MaxBound uint64 `json:"maxbound"`
+}
+
+// r2String records a string located by radare2 (izj).
+type r2String struct {
+	// Address of the string.
+	VAddr uint64 `json:"vaddr"`
+	// Encoding of the string (e.g. "ascii", "utf8" or "utf16le").
+	Type string `json:"type"`
+	// Contents of the string.
+	String string `json:"string"`
+}
+
+// analyzeR2 locates the functions and strings of the given binary executable
+// using radare2 (aaa, aflj and izj), through the r2pipe protocol. Function
+// boundaries are added to the function bounds of the binary executable, unless
+// already present (e.g. from exception handling metadata), and the strings are
+// returned. Addresses are relocated from the base address of radare2 to the
+// image base of the binary executable.
+func analyzeR2(binPath string, file *bin.File) (map[bin.Address]x86.KnownString, error) {
+	r2, err := openR2(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer r2.close()
+	var info r2Info
+	if err := r2.cmdj("ij", &info); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if _, err := r2.cmd("aaa"); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var funcs []r2Func
+	if err := r2.cmdj("aflj", &funcs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var strs []r2String
+	if err := r2.cmdj("izj", &strs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := r2.close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	delta := file.ImageBase - bin.Address(info.Bin.BAddr)
+	nfuncs := 0
+	for _, fn := range funcs {
+		entry := bin.Address(fn.Offset) + delta
+		if !file.IsCode(entry) {
+			continue
+		}
+		nfuncs++
+		// Only contiguous function bodies starting at the entry point are
+		// recorded as function bounds.
+		var end bin.Address
+		if fn.MinBound == fn.Offset && fn.MaxBound > fn.Offset {
+			end = bin.Address(fn.MaxBound) + delta
+		}
+		addFunc(file, entry, end)
+	}
+	knownStrs := make(map[bin.Address]x86.KnownString)
+	for _, s := range strs {
+		known := x86.KnownString{Text: s.String}
+		switch s.Type {
+		case "ascii", "utf8":
+		case "utf16le", "wide":
+			known.Wide = true
+		default:
+			// skip UTF-32 and big-endian strings.
+			continue
+		}
+		knownStrs[bin.Address(s.VAddr)+delta] = known
+	}
+	dbg.Printf("located %d functions and %d strings of %q using radare2", nfuncs, len(knownStrs), binPath)
+	return knownStrs, nil
+}
+
+// An r2Pipe is a radare2 process spawned for the r2pipe protocol; commands are
+// written to standard input, one per line, and the output of each command is
+// terminated by a NUL byte.
+type r2Pipe struct {
+	// radare2 process.
+	proc *exec.Cmd
+	// Standard input of the process.
+	stdin io.WriteCloser
+	// Standard output of the process.
+	stdout *bufio.Reader
+	// Standard error of the process.
+	stderr *bytes.Buffer
+	// closed specifies whether the process has been closed.
+	closed bool
+}
+
+// openR2 spawns radare2 for the given binary executable.
+func openR2(binPath string) (*r2Pipe, error) {
+	r2Path, err := exec.LookPath("r2")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	r2 := &r2Pipe{
+		proc:   exec.Command(r2Path, "-q0", "-e", "scr.color=0", "-e", "scr.interactive=false", binPath),
+		stderr: &bytes.Buffer{},
+	}
+	r2.proc.Stderr = r2.stderr
+	if r2.stdin, err = r2.proc.StdinPipe(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	stdout, err := r2.proc.StdoutPipe()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	r2.stdout = bufio.NewReader(stdout)
+	if err := r2.proc.Start(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// radare2 signals that it is ready by a NUL byte.
+	if _, err := r2.stdout.ReadBytes(0); err != nil {
+		r2.close()
+		return nil, errors.Errorf("unable to spawn %q; %v\n%s", r2Path, err, bytes.TrimSpace(r2.stderr.Bytes()))
+	}
+	return r2, nil
+}
+
+// cmd runs the given radare2 command, and returns its output.
+func (r2 *r2Pipe) cmd(cmd string) ([]byte, error) {
+	if _, err := io.WriteString(r2.stdin, cmd+"\n"); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	out, err := r2.stdout.ReadBytes(0)
+	if err != nil {
+		return nil, errors.Errorf("unable to run radare2 command %q; %v\n%s", cmd, err, bytes.TrimSpace(r2.stderr.Bytes()))
+	}
+	return out[:len(out)-1], nil
+}
+
+// cmdj runs the given radare2 command, and decodes its JSON output into v.
+func (r2 *r2Pipe) cmdj(cmd string, v interface{}) error {
+	out, err := r2.cmd(cmd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := json.Unmarshal(out, v); err != nil {
+		return errors.Wrapf(err, "unable to parse output of radare2 command %q", cmd)
+	}
+	return nil
+}
+
+// close terminates the radare2 process.
+func (r2 *r2Pipe) close() error {
+	if r2.closed {
+		return nil
+	}
+	r2.closed = true
+	r2.stdin.Close()
+	if err := r2.proc.Wait(); err != nil {
+		return errors.Errorf("unable to terminate radare2; %v\n%s", err, bytes.TrimSpace(r2.stderr.Bytes()))
+	}
+	return nil
+}
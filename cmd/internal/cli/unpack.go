@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+)
+
+// maxUnpackSteps specifies the maximum number of instructions to emulate when
+// unpacking executables.
+const maxUnpackSteps = 50000000
+
+// unpack unpacks the given packed executable, parsed from binPath, and returns
+// the unpacked executable. UPX-packed executables are unpacked using `upx -d`,
+// if present; other packed executables, and UPX-packed executables which fail
+// to be unpacked by upx (e.g. due to tampered UPX headers), are unpacked by
+// emulating the unpacking stub.
+func (l *Loader) unpack(binPath string, file *bin.File) (*bin.File, error) {
+	if p := file.DetectPacker(); p != nil && p.Name == bin.PackerUPX {
+		unpacked, err := l.unpackUPX(binPath)
+		if err == nil {
+			dbg.Printf("unpacked %q using upx; original entry point at %v", binPath, unpacked.Entry)
+			return unpacked, nil
+		}
+		warn.Printf("unable to unpack %q using upx; emulating unpacking stub at %v; %v", binPath, p.Stub, err)
+	}
+	if err := emulateUnpack(file); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return file, nil
+}
+
+// unpackUPX unpacks the given UPX-packed executable using `upx -d`, and returns
+// the parsed unpacked executable. The unpacked executable is written to a
+// temporary directory, which is removed once parsed into memory.
+func (l *Loader) unpackUPX(binPath string) (*bin.File, error) {
+	upxPath, err := exec.LookPath("upx")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	tmpDir, err := ioutil.TempDir("", "decomp-upx-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	// Retain the file name, as DOS .COM executables are identified by file
+	// extension.
+	unpackedPath := filepath.Join(tmpDir, filepath.Base(binPath))
+	cmd := exec.Command(upxPath, "-d", "-q", "-o", unpackedPath, binPath)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Errorf("unable to run %q; %v\n%s", upxPath, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	file, err := l.parseFile(unpackedPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return file, nil
+}
+
+// emulateUnpack emulates the entry point code of the given packed executable up
+// to the original entry point; i.e. the first instruction written by the
+// unpacking stub. The memory writes and dynamically resolved imports of the
+// emulation are applied to the executable, and the entry point is updated to
+// the original entry point.
+func emulateUnpack(file *bin.File) error {
+	var mode int
+	switch file.Arch {
+	case bin.ArchX86_32:
+		mode = 32
+	case bin.ArchX86_64:
+		mode = 64
+	default:
+		return errors.Errorf("support for unpacking executables of machine architecture %v not yet implemented", file.Arch)
+	}
+	e := x86.NewEmulator(file, mode)
+	oep, err := e.Unpack(file.Entry, maxUnpackSteps)
+	if err != nil {
+		return errors.Wrap(err, "unable to unpack executable")
+	}
+	e.Apply()
+	file.Entry = oep
+	return nil
+}
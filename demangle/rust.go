@@ -0,0 +1,786 @@
+// Package demangle provides demanglers of symbol names, as mangled by
+// compilers.
+package demangle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rust returns the demangled name of the given Rust symbol name, mangled using
+// either the legacy or the v0 mangling scheme; e.g. "core::fmt::write" for
+// "_ZN4core3fmt5write17h0123456789abcdefE" and "_RNvNtCs1234_4core3fmt5write".
+// The boolean return value indicates success.
+//
+// Hashes of legacy symbol names and crate disambiguators of v0 symbol names are
+// omitted.
+func Rust(name string) (string, bool) {
+	// Strip leading underscore of Mach-O symbol names.
+	switch {
+	case strings.HasPrefix(name, "__ZN"), strings.HasPrefix(name, "__R"):
+		name = name[1:]
+	}
+	switch {
+	case strings.HasPrefix(name, "_ZN"):
+		return rustLegacy(name[len("_ZN"):])
+	case strings.HasPrefix(name, "_R"):
+		return rustV0(name[len("_R"):])
+	}
+	return "", false
+}
+
+// ### [ Legacy mangling ] #####################################################
+
+// rustLegacyEscapes maps from escape sequences of legacy Rust symbol names to
+// their unescaped representation.
+var rustLegacyEscapes = map[string]string{
+	"$SP$": "@",
+	"$BP$": "*",
+	"$RF$": "&",
+	"$LT$": "<",
+	"$GT$": ">",
+	"$LP$": "(",
+	"$RP$": ")",
+	"$C$":  ",",
+}
+
+// rustLegacy returns the demangled name of the given legacy Rust symbol name,
+// after the "_ZN" prefix; e.g. "4core3fmt5write17h0123456789abcdefE". The
+// boolean return value indicates success.
+//
+// Legacy Rust symbol names follow the Itanium C++ ABI for nested names, with a
+// trailing hash component; the hash distinguishes them from C++ symbol names.
+func rustLegacy(s string) (string, bool) {
+	var parts []string
+	for !strings.HasPrefix(s, "E") {
+		i := 0
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil || i+n > len(s) {
+			return "", false
+		}
+		parts = append(parts, s[i:i+n])
+		s = s[i+n:]
+	}
+	if len(parts) < 2 || !isRustHash(parts[len(parts)-1]) {
+		return "", false
+	}
+	parts = parts[:len(parts)-1]
+	for i, part := range parts {
+		part, ok := unescapeRustLegacy(part)
+		if !ok {
+			return "", false
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "::"), true
+}
+
+// isRustHash reports whether the given name component is the hash of a legacy
+// Rust symbol name; e.g. "h0123456789abcdef".
+func isRustHash(s string) bool {
+	if len(s) != 17 || s[0] != 'h' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// unescapeRustLegacy unescapes the given name component of a legacy Rust symbol
+// name; e.g. "<impl Foo>" for "_$LT$impl$u20$Foo$GT$". The boolean return
+// value indicates success.
+func unescapeRustLegacy(s string) (string, bool) {
+	if strings.HasPrefix(s, "_$") {
+		s = s[1:]
+	}
+	var sb strings.Builder
+	for len(s) > 0 {
+		switch {
+		case s[0] == '$':
+			end := strings.IndexByte(s[1:], '$')
+			if end == -1 {
+				return "", false
+			}
+			esc := s[:end+2]
+			s = s[end+2:]
+			if v, ok := rustLegacyEscapes[esc]; ok {
+				sb.WriteString(v)
+				continue
+			}
+			// Unicode escape; e.g. "$u20$".
+			if !strings.HasPrefix(esc, "$u") {
+				return "", false
+			}
+			r, err := strconv.ParseUint(esc[2:len(esc)-1], 16, 32)
+			if err != nil {
+				return "", false
+			}
+			sb.WriteRune(rune(r))
+		case strings.HasPrefix(s, ".."):
+			sb.WriteString("::")
+			s = s[2:]
+		default:
+			sb.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+	return sb.String(), true
+}
+
+// ### [ v0 mangling ] #########################################################
+
+// maxRustDepth specifies the maximum recursion depth of the v0 demangler.
+const maxRustDepth = 256
+
+// rustBasicTypes maps from basic type tags of v0 Rust symbol names to type
+// names.
+var rustBasicTypes = map[byte]string{
+	'a': "i8",
+	'b': "bool",
+	'c': "char",
+	'd': "f64",
+	'e': "str",
+	'f': "f32",
+	'h': "u8",
+	'i': "isize",
+	'j': "usize",
+	'l': "i32",
+	'm': "u32",
+	'n': "i128",
+	'o': "u128",
+	's': "i16",
+	't': "u16",
+	'u': "()",
+	'v': "...",
+	'x': "i64",
+	'y': "u64",
+	'z': "!",
+	'p': "_",
+}
+
+// rustV0 returns the demangled name of the given v0 Rust symbol name, after the
+// "_R" prefix. The boolean return value indicates success.
+//
+// ref: https://doc.rust-lang.org/rustc/symbol-mangling/v0.html
+func rustV0(s string) (name string, ok bool) {
+	p := &rustParser{s: s}
+	defer func() {
+		if e := recover(); e != nil {
+			if _, isRustError := e.(rustError); !isRustError {
+				panic(e)
+			}
+			name, ok = "", false
+		}
+	}()
+	// Encoding version.
+	if p.pos < len(p.s) && '0' <= p.s[p.pos] && p.s[p.pos] <= '9' {
+		p.decimal()
+	}
+	p.path(true)
+	// The instantiating crate and vendor-specific suffix are omitted.
+	return p.out.String(), true
+}
+
+// rustError is the error of malformed v0 Rust symbol names, as raised by panics
+// of the v0 demangler.
+type rustError string
+
+// A rustParser is a demangler of v0 Rust symbol names.
+type rustParser struct {
+	// Symbol name, after the "_R" prefix.
+	s string
+	// Current position in s.
+	pos int
+	// Demangled output.
+	out strings.Builder
+	// Recursion depth.
+	depth int
+	// Number of lifetimes bound by binders in scope.
+	boundLifetimes uint64
+}
+
+// fail aborts demangling.
+func (p *rustParser) fail(format string, args ...interface{}) {
+	panic(rustError(fmt.Sprintf(format, args...)))
+}
+
+// peek returns the next byte of the symbol name; or 0 at the end.
+func (p *rustParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// eat consumes the next byte of the symbol name if equal to c, and reports
+// whether it was consumed.
+func (p *rustParser) eat(c byte) bool {
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// next consumes and returns the next byte of the symbol name.
+func (p *rustParser) next() byte {
+	if p.pos >= len(p.s) {
+		p.fail("unexpected end of symbol name")
+	}
+	c := p.s[p.pos]
+	p.pos++
+	return c
+}
+
+// enter increments the recursion depth.
+func (p *rustParser) enter() {
+	p.depth++
+	if p.depth > maxRustDepth {
+		p.fail("recursion limit exceeded")
+	}
+}
+
+// leave decrements the recursion depth.
+func (p *rustParser) leave() {
+	p.depth--
+}
+
+// decimal parses a decimal number.
+//
+//    decimal-number = "0" | <nonzero-digit> {<digit>}
+func (p *rustParser) decimal() uint64 {
+	start := p.pos
+	for '0' <= p.peek() && p.peek() <= '9' {
+		p.pos++
+	}
+	if start == p.pos || (p.s[start] == '0' && p.pos-start > 1) {
+		p.fail("invalid decimal number at offset %d", start)
+	}
+	v, err := strconv.ParseUint(p.s[start:p.pos], 10, 64)
+	if err != nil {
+		p.fail("invalid decimal number at offset %d; %v", start, err)
+	}
+	return v
+}
+
+// base62 parses a base-62 number.
+//
+//    base-62-number = {<digit> | <lower> | <upper>} "_"
+func (p *rustParser) base62() uint64 {
+	if p.eat('_') {
+		return 0
+	}
+	var v uint64
+	for {
+		c := p.next()
+		var d uint64
+		switch {
+		case c == '_':
+			return v + 1
+		case '0' <= c && c <= '9':
+			d = uint64(c - '0')
+		case 'a' <= c && c <= 'z':
+			d = 10 + uint64(c-'a')
+		case 'A' <= c && c <= 'Z':
+			d = 36 + uint64(c-'A')
+		default:
+			p.fail("invalid base-62 number at offset %d", p.pos-1)
+		}
+		v = v*62 + d
+	}
+}
+
+// optBase62 parses an optional base-62 number with the given tag; e.g. the
+// disambiguator "s" <base-62-number>. The value is 0 if absent, and 1 more than
+// the base-62 number otherwise.
+func (p *rustParser) optBase62(tag byte) uint64 {
+	if !p.eat(tag) {
+		return 0
+	}
+	return p.base62() + 1
+}
+
+// ident parses an identifier.
+//
+//    identifier = [<disambiguator>] <undisambiguated-identifier>
+//    undisambiguated-identifier = ["u"] <decimal-number> ["_"] <bytes>
+func (p *rustParser) ident() (name string, dis uint64) {
+	dis = p.optBase62('s')
+	return p.undisambiguatedIdent(), dis
+}
+
+// undisambiguatedIdent parses an undisambiguated identifier.
+func (p *rustParser) undisambiguatedIdent() string {
+	puny := p.eat('u')
+	n := p.decimal()
+	p.eat('_')
+	if uint64(len(p.s)-p.pos) < n {
+		p.fail("invalid identifier length %d", n)
+	}
+	s := p.s[p.pos : p.pos+int(n)]
+	p.pos += int(n)
+	if puny {
+		v, ok := decodePunycode(s)
+		if !ok {
+			p.fail("invalid punycode identifier %q", s)
+		}
+		return v
+	}
+	return s
+}
+
+// backref parses a backreference, and invokes f at the referenced position.
+//
+//    backref = "B" <base-62-number>
+func (p *rustParser) backref(f func()) {
+	start := p.pos - 1
+	target := p.base62()
+	if target >= uint64(start) {
+		p.fail("invalid forward backreference at offset %d", start)
+	}
+	p.enter()
+	saved := p.pos
+	p.pos = int(target)
+	f()
+	p.pos = saved
+	p.leave()
+}
+
+// path parses a path. Generic arguments of value paths (e.g. functions) are
+// printed using turbofish syntax; e.g. "foo::<u32>".
+//
+//    path = "C" <identifier>                    // crate root
+//         | "M" <impl-path> <type>              // <T> (inherent impl)
+//         | "X" <impl-path> <type> <path>       // <T as Trait> (trait impl)
+//         | "Y" <type> <path>                   // <T as Trait> (trait definition)
+//         | "N" <namespace> <path> <identifier> // ...::ident (nested path)
+//         | "I" <path> {<generic-arg>} "E"      // ...<T, U> (generic args)
+//         | <backref>
+func (p *rustParser) path(inValue bool) {
+	p.enter()
+	defer p.leave()
+	switch tag := p.next(); tag {
+	case 'C':
+		name, _ := p.ident()
+		p.out.WriteString(name)
+	case 'M':
+		p.implPath()
+		p.out.WriteString("<")
+		p.typ()
+		p.out.WriteString(">")
+	case 'X':
+		p.implPath()
+		fallthrough
+	case 'Y':
+		p.out.WriteString("<")
+		p.typ()
+		p.out.WriteString(" as ")
+		p.path(false)
+		p.out.WriteString(">")
+	case 'N':
+		ns := p.next()
+		if !('a' <= ns && ns <= 'z' || 'A' <= ns && ns <= 'Z') {
+			p.fail("invalid namespace %q", ns)
+		}
+		p.path(inValue)
+		name, dis := p.ident()
+		switch {
+		case 'A' <= ns && ns <= 'Z':
+			// Special namespace; e.g. closures and shims.
+			p.out.WriteString("::{")
+			switch ns {
+			case 'C':
+				p.out.WriteString("closure")
+			case 'S':
+				p.out.WriteString("shim")
+			default:
+				p.out.WriteByte(ns)
+			}
+			if len(name) > 0 {
+				p.out.WriteString(":")
+				p.out.WriteString(name)
+			}
+			fmt.Fprintf(&p.out, "#%d}", dis)
+		case len(name) > 0:
+			// Internal namespace.
+			p.out.WriteString("::")
+			p.out.WriteString(name)
+		}
+	case 'I':
+		p.path(inValue)
+		if inValue {
+			p.out.WriteString("::")
+		}
+		p.out.WriteString("<")
+		for i := 0; !p.eat('E'); i++ {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			p.genericArg()
+		}
+		p.out.WriteString(">")
+	case 'B':
+		p.backref(func() { p.path(inValue) })
+	default:
+		p.fail("invalid path tag %q", tag)
+	}
+}
+
+// implPath parses and discards an impl path.
+//
+//    impl-path = [<disambiguator>] <path>
+func (p *rustParser) implPath() {
+	p.optBase62('s')
+	// Discard the output of the impl path.
+	saved := p.out.String()
+	p.path(false)
+	p.out.Reset()
+	p.out.WriteString(saved)
+}
+
+// genericArg parses a generic argument.
+//
+//    generic-arg = <lifetime> | <type> | "K" <const>
+func (p *rustParser) genericArg() {
+	switch {
+	case p.eat('L'):
+		p.lifetime(p.base62())
+	case p.eat('K'):
+		p.constant()
+	default:
+		p.typ()
+	}
+}
+
+// lifetime prints the lifetime of the given de Bruijn index.
+func (p *rustParser) lifetime(i uint64) {
+	if i == 0 {
+		p.out.WriteString("'_")
+		return
+	}
+	if i > p.boundLifetimes {
+		p.fail("invalid lifetime index %d", i)
+	}
+	depth := p.boundLifetimes - i
+	if depth < 26 {
+		fmt.Fprintf(&p.out, "'%c", 'a'+rune(depth))
+	} else {
+		fmt.Fprintf(&p.out, "'_%d", depth)
+	}
+}
+
+// binder parses an optional binder of lifetimes, and invokes f with the
+// lifetimes in scope.
+//
+//    binder = "G" <base-62-number>
+func (p *rustParser) binder(f func()) {
+	n := p.optBase62('G')
+	if n > 0 {
+		p.out.WriteString("for<")
+		for i := uint64(0); i < n; i++ {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			p.boundLifetimes++
+			p.lifetime(1)
+		}
+		p.out.WriteString("> ")
+	}
+	f()
+	p.boundLifetimes -= n
+}
+
+// typ parses a type.
+func (p *rustParser) typ() {
+	p.enter()
+	defer p.leave()
+	c := p.peek()
+	if name, ok := rustBasicTypes[c]; ok {
+		p.pos++
+		p.out.WriteString(name)
+		return
+	}
+	switch c {
+	case 'R', 'Q':
+		// &T and &mut T.
+		p.pos++
+		p.out.WriteString("&")
+		if p.eat('L') {
+			if i := p.base62(); i != 0 {
+				p.lifetime(i)
+				p.out.WriteString(" ")
+			}
+		}
+		if c == 'Q' {
+			p.out.WriteString("mut ")
+		}
+		p.typ()
+	case 'P':
+		p.pos++
+		p.out.WriteString("*const ")
+		p.typ()
+	case 'O':
+		p.pos++
+		p.out.WriteString("*mut ")
+		p.typ()
+	case 'A':
+		p.pos++
+		p.out.WriteString("[")
+		p.typ()
+		p.out.WriteString("; ")
+		p.constant()
+		p.out.WriteString("]")
+	case 'S':
+		p.pos++
+		p.out.WriteString("[")
+		p.typ()
+		p.out.WriteString("]")
+	case 'T':
+		p.pos++
+		p.out.WriteString("(")
+		n := 0
+		for ; !p.eat('E'); n++ {
+			if n > 0 {
+				p.out.WriteString(", ")
+			}
+			p.typ()
+		}
+		if n == 1 {
+			p.out.WriteString(",")
+		}
+		p.out.WriteString(")")
+	case 'F':
+		p.pos++
+		p.binder(p.fnSig)
+	case 'D':
+		p.pos++
+		p.out.WriteString("dyn ")
+		p.binder(func() {
+			for i := 0; !p.eat('E'); i++ {
+				if i > 0 {
+					p.out.WriteString(" + ")
+				}
+				p.dynTrait()
+			}
+		})
+		if !p.eat('L') {
+			p.fail("missing lifetime of dyn trait object")
+		}
+		if i := p.base62(); i != 0 {
+			p.out.WriteString(" + ")
+			p.lifetime(i)
+		}
+	case 'B':
+		p.pos++
+		p.backref(p.typ)
+	default:
+		p.path(false)
+	}
+}
+
+// fnSig parses a function signature.
+//
+//    fn-sig = [<binder>] ["U"] ["K" <abi>] {<type>} "E" <type>
+func (p *rustParser) fnSig() {
+	if p.eat('U') {
+		p.out.WriteString("unsafe ")
+	}
+	if p.eat('K') {
+		p.out.WriteString(`extern "`)
+		if p.eat('C') {
+			p.out.WriteString("C")
+		} else {
+			p.out.WriteString(strings.Replace(p.undisambiguatedIdent(), "_", "-", -1))
+		}
+		p.out.WriteString(`" `)
+	}
+	p.out.WriteString("fn(")
+	for i := 0; !p.eat('E'); i++ {
+		if i > 0 {
+			p.out.WriteString(", ")
+		}
+		p.typ()
+	}
+	p.out.WriteString(")")
+	if p.eat('u') {
+		// Unit return type is omitted.
+		return
+	}
+	p.out.WriteString(" -> ")
+	p.typ()
+}
+
+// dynTrait parses a trait of a dyn trait object, and its associated type
+// bindings.
+//
+//    dyn-trait = <path> {<dyn-trait-assoc-binding>}
+//    dyn-trait-assoc-binding = "p" <undisambiguated-identifier> <type>
+func (p *rustParser) dynTrait() {
+	p.path(false)
+	for i := 0; p.eat('p'); i++ {
+		// Associated type bindings are printed within the generic arguments of
+		// the trait; e.g. "Iterator<Item = u32>".
+		if i == 0 {
+			p.out.WriteString("<")
+		} else {
+			p.out.WriteString(", ")
+		}
+		p.out.WriteString(p.undisambiguatedIdent())
+		p.out.WriteString(" = ")
+		p.typ()
+		if p.peek() != 'p' {
+			p.out.WriteString(">")
+		}
+	}
+}
+
+// constant parses a constant generic argument.
+//
+//    const = <type> <const-data> | "p" | <backref>
+//    const-data = ["n"] {<hex-digit>} "_"
+func (p *rustParser) constant() {
+	p.enter()
+	defer p.leave()
+	switch c := p.next(); c {
+	case 'p':
+		p.out.WriteString("_")
+	case 'B':
+		p.backref(p.constant)
+	case 'a', 's', 'l', 'x', 'n', 'i':
+		// Signed integers.
+		neg := p.eat('n')
+		v := p.hex()
+		if neg {
+			p.out.WriteString("-")
+		}
+		p.out.WriteString(v)
+	case 'h', 't', 'm', 'y', 'o', 'j':
+		// Unsigned integers.
+		p.out.WriteString(p.hex())
+	case 'b':
+		switch v := p.hex(); v {
+		case "0":
+			p.out.WriteString("false")
+		case "1":
+			p.out.WriteString("true")
+		default:
+			p.fail("invalid bool constant %q", v)
+		}
+	case 'c':
+		v, err := strconv.ParseUint(p.hex(), 10, 32)
+		if err != nil || !utf8.ValidRune(rune(v)) {
+			p.fail("invalid char constant")
+		}
+		p.out.WriteString(strconv.QuoteRune(rune(v)))
+	default:
+		p.fail("support for constant of type %q not yet implemented", c)
+	}
+}
+
+// hex parses the hexadecimal digits of constant data, and returns the decimal
+// representation of the value.
+func (p *rustParser) hex() string {
+	start := p.pos
+	for p.peek() != '_' {
+		p.next()
+	}
+	digits := p.s[start:p.pos]
+	p.pos++
+	if len(digits) == 0 {
+		return "0"
+	}
+	v, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		// Values exceeding 64 bits are printed in hexadecimal.
+		return "0x" + digits
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// decodePunycode decodes the given Punycode encoded identifier of a v0 Rust
+// symbol name, in which "_" separates the basic code points from the encoded
+// deltas. The boolean return value indicates success.
+//
+// ref: https://tools.ietf.org/html/rfc3492
+func decodePunycode(s string) (string, bool) {
+	const (
+		base        = 36
+		tmin        = 1
+		tmax        = 26
+		skew        = 38
+		damp        = 700
+		initialBias = 72
+		initialN    = 128
+	)
+	var out []rune
+	if pos := strings.LastIndexByte(s, '_'); pos != -1 {
+		out = []rune(s[:pos])
+		s = s[pos+1:]
+	}
+	n, bias, i := rune(initialN), initialBias, 0
+	// adapt returns the bias adaptation of the given delta.
+	adapt := func(delta, numPoints int, first bool) int {
+		if first {
+			delta /= damp
+		} else {
+			delta /= 2
+		}
+		delta += delta / numPoints
+		k := 0
+		for delta > ((base-tmin)*tmax)/2 {
+			delta /= base - tmin
+			k += base
+		}
+		return k + (base-tmin+1)*delta/(delta+skew)
+	}
+	for len(s) > 0 {
+		oldi, w := i, 1
+		for k := base; ; k += base {
+			if len(s) == 0 {
+				return "", false
+			}
+			c := s[0]
+			s = s[1:]
+			var digit int
+			switch {
+			case 'a' <= c && c <= 'z':
+				digit = int(c - 'a')
+			case '0' <= c && c <= '9':
+				digit = int(c-'0') + 26
+			default:
+				return "", false
+			}
+			i += digit * w
+			t := k - bias
+			if t < tmin {
+				t = tmin
+			} else if t > tmax {
+				t = tmax
+			}
+			if digit < t {
+				break
+			}
+			w *= base - t
+			if w > utf8.MaxRune {
+				return "", false
+			}
+		}
+		bias = adapt(i-oldi, len(out)+1, oldi == 0)
+		n += rune(i / (len(out) + 1))
+		i %= len(out) + 1
+		if !utf8.ValidRune(n) {
+			return "", false
+		}
+		out = append(out[:i], append([]rune{n}, out[i:]...)...)
+		i++
+	}
+	return string(out), true
+}
@@ -0,0 +1,40 @@
+package demangle
+
+import "testing"
+
+func TestRust(t *testing.T) {
+	golden := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		// Legacy mangling.
+		{in: "_ZN4core3fmt5write17h0123456789abcdefE", want: "core::fmt::write", ok: true},
+		{in: "__ZN4core3fmt5write17h0123456789abcdefE", want: "core::fmt::write", ok: true},
+		{in: "_ZN71_$LT$Test$u20$$u2b$$u20$$u27$static$u20$as$u20$foo..Bar$LT$Test$GT$$GT$3bar17h930b740aa94f1d3aE", want: "<Test + 'static as foo::Bar<Test>>::bar", ok: true},
+		{in: "_ZN4test1a2bc17habcdefabcdefabcdE", want: "test::a::bc", ok: true},
+		// C++ symbol name without hash.
+		{in: "_ZN4test1a2bcE", ok: false},
+		// v0 mangling.
+		{in: "_RNvNtCs1234_4core3fmt5write", want: "core::fmt::write", ok: true},
+		{in: "_RNvCsdvG4jz1q9AS_7mycrate3foo", want: "mycrate::foo", ok: true},
+		{in: "_RINvCs1234_7mycrate3fooKj2a_EB2_", want: "mycrate::foo::<42>", ok: true},
+		{in: "_RNvMNtCs1234_7mycrate3fooNtB2_3Bar3new", want: "<mycrate::foo::Bar>::new", ok: true},
+		{in: "_RNCNvCs1234_7mycrate4main0B3_", want: "mycrate::main::{closure#0}", ok: true},
+		{in: "_RINvCs1234_7mycrate3barRShE", want: "mycrate::bar::<&[u8]>", ok: true},
+		{in: "_RNvCs1234_7mycrateu7caf_dma", want: "mycrate::café", ok: true},
+		// Malformed.
+		{in: "_RNvC", ok: false},
+		{in: "main", ok: false},
+	}
+	for _, g := range golden {
+		got, ok := Rust(g.in)
+		if ok != g.ok {
+			t.Errorf("%q: success mismatch; expected %v, got %v", g.in, g.ok, ok)
+			continue
+		}
+		if got != g.want {
+			t.Errorf("%q: demangled name mismatch; expected %q, got %q", g.in, g.want, got)
+		}
+	}
+}
@@ -2,11 +2,10 @@
 package disasm
 
 import (
-	"log"
-	"os"
 	"sort"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/logging"
 	"github.com/mewkiz/pkg/jsonutil"
 	"github.com/mewkiz/pkg/osutil"
 	"github.com/mewkiz/pkg/term"
@@ -19,10 +18,10 @@ import (
 var (
 	// dbg represents a logger with the "disasm:" prefix, which logs debug
 	// messages to standard error.
-	dbg = log.New(os.Stderr, term.BlueBold("disasm:")+" ", 0)
+	dbg = logging.New("disasm", logging.LevelDebug, term.BlueBold("disasm:")+" ")
 	// warn represents a logger with the "warning:" prefix, which logs warning
 	// messages to standard error.
-	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+	warn = logging.New("disasm", logging.LevelInfo, term.RedBold("warning:")+" ")
 )
 
 // A Disasm tracks information required to disassemble a binary executable.
@@ -44,6 +43,10 @@ type Disasm struct {
 	Chunks map[bin.Address]map[bin.Address]bool
 	// Fragments; sequences of bytes.
 	Frags []*Fragment
+	// Addresses of functions which do not return to their caller (e.g. exit).
+	NoReturn map[bin.Address]bool
+	// User-supplied symbol names and comments, indexed by address.
+	Names map[bin.Address]*Name
 }
 
 // New creates a new Disasm for accessing the assembly instructions of the given
@@ -57,12 +60,17 @@ type Disasm struct {
 //    tables.json
 //    chunks.json
 //    data.json
+//    noreturn.json
+//    names.json
+//    ordinals.json
 func New(file *bin.File) (*Disasm, error) {
 	// Prepare generic disassembler.
 	dis := &Disasm{
-		File:   file,
-		Tables: make(map[bin.Address][]bin.Address),
-		Chunks: make(map[bin.Address]map[bin.Address]bool),
+		File:     file,
+		Tables:   make(map[bin.Address][]bin.Address),
+		Chunks:   make(map[bin.Address]map[bin.Address]bool),
+		NoReturn: make(map[bin.Address]bool),
+		Names:    make(map[bin.Address]*Name),
 	}
 
 	// Parse function addresses.
@@ -87,6 +95,31 @@ func New(file *bin.File) (*Disasm, error) {
 		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, addr)
 	}
 
+	// Add additional entry points (e.g. TLS callbacks) to function and basic
+	// block addresses.
+	for addr := range dis.File.Roots {
+		dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, addr)
+		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, addr)
+	}
+
+	// Add functions, exception handlers and function chunks of exception
+	// handling metadata to function and basic block addresses.
+	for addr := range dis.File.FuncBounds {
+		dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, addr)
+		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, addr)
+	}
+	for addr := range dis.File.Handlers {
+		dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, addr)
+		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, addr)
+	}
+	for addr := range dis.File.FuncChunks {
+		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, addr)
+	}
+
+	// Refuse to treat addresses outside of executable sections as code.
+	dis.FuncAddrs = dis.codeAddrs(dis.FuncAddrs, "function")
+	dis.BlockAddrs = dis.codeAddrs(dis.BlockAddrs, "basic block")
+
 	// Parse jump table targets.
 	if err := parseJSON("tables.json", &dis.Tables); err != nil {
 		return nil, errors.WithStack(err)
@@ -96,6 +129,22 @@ func New(file *bin.File) (*Disasm, error) {
 	if err := parseJSON("chunks.json", &dis.Chunks); err != nil {
 		return nil, errors.WithStack(err)
 	}
+	dis.addFuncChunks()
+
+	// Rename functions imported by ordinal.
+	if err := dis.parseOrdinals(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Parse noreturn functions.
+	if err := dis.parseNoReturn(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Parse user-supplied symbol names and comments.
+	if err := dis.parseNames(); err != nil {
+		return nil, errors.WithStack(err)
+	}
 
 	// Compute fragments of the binary; distinct byte sequences of either code or
 	// data.
@@ -174,3 +223,38 @@ func parseJSON(jsonPath string, v interface{}) error {
 	}
 	return jsonutil.ParseFile(jsonPath, v)
 }
+
+// codeAddrs returns the subset of the given addresses contained within
+// executable sections. Remaining addresses are skipped with a warning; kind
+// specifies the kind of addresses (e.g. "function").
+func (dis *Disasm) codeAddrs(addrs []bin.Address, kind string) []bin.Address {
+	var code []bin.Address
+	for _, addr := range addrs {
+		if !dis.File.IsCode(addr) {
+			warn.Printf("skipping %s at %v; address not contained within executable section", kind, addr)
+			continue
+		}
+		code = append(code, addr)
+	}
+	return code
+}
+
+// addFuncChunks adds the function chunks of exception handling metadata to the
+// function chunks of the disassembler. Chunks of chunks are attributed to the
+// root parent function.
+func (dis *Disasm) addFuncChunks() {
+	for chunk, parent := range dis.File.FuncChunks {
+		// Guard against cycles.
+		for i := 0; i < len(dis.File.FuncChunks); i++ {
+			p, ok := dis.File.FuncChunks[parent]
+			if !ok {
+				break
+			}
+			parent = p
+		}
+		if _, ok := dis.Chunks[chunk]; !ok {
+			dis.Chunks[chunk] = make(map[bin.Address]bool)
+		}
+		dis.Chunks[chunk][parent] = true
+	}
+}
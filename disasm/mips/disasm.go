@@ -3,11 +3,10 @@ package mips
 
 import (
 	"fmt"
-	"log"
-	"os"
 
 	"github.com/decomp/exp/bin"
 	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/logging"
 	"github.com/mewkiz/pkg/jsonutil"
 	"github.com/mewkiz/pkg/osutil"
 	"github.com/mewkiz/pkg/term"
@@ -20,10 +19,13 @@ import (
 var (
 	// dbg represents a logger with the "mips:" prefix, which logs debug messages
 	// to standard error.
-	dbg = log.New(os.Stderr, term.BlueBold("mips:")+" ", 0)
+	dbg = logging.New("mips", logging.LevelDebug, term.BlueBold("mips:")+" ")
 	// warn represents a logger with the "warning:" prefix, which logs warning
 	// messages to standard error.
-	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+	warn = logging.New("mips", logging.LevelInfo, term.RedBold("warning:")+" ")
+	// trace represents a logger with the "mips:" prefix, which logs detailed
+	// tracing messages to standard error.
+	trace = logging.New("mips", logging.LevelTrace, term.BlueBold("mips:")+" ")
 )
 
 // A Disasm tracks information required to disassemble a binary executable.
@@ -111,8 +111,8 @@ func (dis *Disasm) Targets(term *Inst, funcEntry bin.Address) []bin.Address {
 	// Unconditional indirect jump instructions.
 	case "JALR", "JR":
 		reg := term.Registers[len(term.Registers)-1]
-		fmt.Println("term:", term)
-		fmt.Println("   reg:", reg)
+		trace.Println("term:", term)
+		trace.Println("   reg:", reg)
 		if reg == mipsRegRA {
 			return nil
 		}
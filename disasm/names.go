@@ -0,0 +1,59 @@
+package disasm
+
+import (
+	"github.com/decomp/exp/bin"
+)
+
+// A Name is a user-supplied symbol name and comment of an address, as specified
+// by the names.json sidecar file.
+//
+// Example names.json contents.
+//
+//    {
+//       "0x401000": {"name": "start", "comment": "entry point of the C runtime"},
+//       "0x401052": {"comment": "argc is always at least 1"},
+//       "0x403010": {"name": "g_config"}
+//    }
+type Name struct {
+	// Symbol name of the function or global variable at the address (optional).
+	Name string `json:"name"`
+	// Free-text comment of the address (optional).
+	Comment string `json:"comment"`
+}
+
+// parseNames parses the user-supplied symbol names and comments of names.json.
+//
+// Associated files of the generic disassembler.
+//
+//    names.json
+func (dis *Disasm) parseNames() error {
+	if err := parseJSON("names.json", &dis.Names); err != nil {
+		return err
+	}
+	for addr, n := range dis.Names {
+		if n == nil {
+			delete(dis.Names, addr)
+		}
+	}
+	return nil
+}
+
+// SymbolName returns the user-supplied symbol name of the given address. The
+// boolean return value indicates success.
+func (dis *Disasm) SymbolName(addr bin.Address) (string, bool) {
+	n, ok := dis.Names[addr]
+	if !ok || len(n.Name) == 0 {
+		return "", false
+	}
+	return n.Name, true
+}
+
+// Comment returns the user-supplied comment of the given address. The boolean
+// return value indicates success.
+func (dis *Disasm) Comment(addr bin.Address) (string, bool) {
+	n, ok := dis.Names[addr]
+	if !ok || len(n.Comment) == 0 {
+		return "", false
+	}
+	return n.Comment, true
+}
@@ -0,0 +1,102 @@
+package disasm
+
+import (
+	"strings"
+
+	"github.com/decomp/exp/bin"
+)
+
+// noReturnFuncs specifies the names of well-known functions which do not
+// return to their caller.
+var noReturnFuncs = []string{
+	// C standard library.
+	"abort",
+	"exit",
+	"_exit",
+	"_Exit",
+	"quick_exit",
+	"longjmp",
+	"siglongjmp",
+	"__longjmp_chk",
+	// POSIX and glibc.
+	"pthread_exit",
+	"err",
+	"errx",
+	"verr",
+	"verrx",
+	"__assert_fail",
+	"__stack_chk_fail",
+	"__chk_fail",
+	"__cxa_throw",
+	"__cxa_rethrow",
+	"_Unwind_Resume",
+	// Windows API.
+	"ExitProcess",
+	"ExitThread",
+	"FatalExit",
+	"FatalAppExitA",
+	"FatalAppExitW",
+	"RaiseException",
+	"_CxxThrowException",
+	"_invalid_parameter_noinfo_noreturn",
+}
+
+// parseNoReturn records the addresses of imported and exported functions which
+// do not return to their caller; as identified by the names of well-known
+// functions, and the function names specified by noreturn.json.
+//
+// Associated files of the generic disassembler.
+//
+//    noreturn.json
+func (dis *Disasm) parseNoReturn() error {
+	names := make(map[string]bool)
+	for _, name := range noReturnFuncs {
+		names[name] = true
+	}
+	var extra []string
+	if err := parseJSON("noreturn.json", &extra); err != nil {
+		return err
+	}
+	for _, name := range extra {
+		names[name] = true
+	}
+	add := func(funcs map[bin.Address]string) {
+		for addr, name := range funcs {
+			if names[name] || names[baseName(name)] {
+				dbg.Printf("noreturn function %q at %v", name, addr)
+				dis.NoReturn[addr] = true
+			}
+		}
+	}
+	add(dis.File.Imports)
+	add(dis.File.Exports)
+	return nil
+}
+
+// baseName returns the undecorated name of the given function; e.g.
+// "ExitProcess" for "_ExitProcess@4" and "exit" for "exit@GLIBC_2.0".
+func baseName(name string) string {
+	pos := strings.IndexByte(name, '@')
+	if pos <= 0 {
+		return name
+	}
+	if name[0] == '_' && !strings.Contains(name, "@@") && isStdcallSuffix(name[pos+1:]) {
+		// Name decoration of stdcall functions; e.g. "_ExitProcess@4".
+		return name[1:pos]
+	}
+	return name[:pos]
+}
+
+// isStdcallSuffix reports whether the given string is the decimal argument
+// size suffix of a stdcall decorated function name.
+func isStdcallSuffix(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package disasm
+
+// parseOrdinals renames functions imported by ordinal, as specified by
+// ordinals.json. The binary file format loaders synthesize stable names for
+// imports by ordinal which lack names (e.g. "ws2_32_ord_23" for ordinal 23 of
+// WS2_32.dll), and ordinals.json maps these to the names of the imported
+// functions; thus enabling type information of well-known functions to be
+// applied.
+//
+// Example ordinals.json contents.
+//
+//    {
+//       "ws2_32_ord_23": "socket",
+//       "ws2_32_ord_115": "WSAStartup"
+//    }
+//
+// Associated files of the generic disassembler.
+//
+//    ordinals.json
+func (dis *Disasm) parseOrdinals() error {
+	names := make(map[string]string)
+	if err := parseJSON("ordinals.json", &names); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	for addr, old := range dis.File.Imports {
+		name, ok := names[old]
+		if !ok || len(name) == 0 {
+			continue
+		}
+		dbg.Printf("renaming import %q at %v to %q", old, addr, name)
+		dis.File.Imports[addr] = name
+	}
+	return nil
+}
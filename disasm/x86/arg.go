@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/decomp/exp/bin"
-	"github.com/kr/pretty"
 	"golang.org/x/arch/x86/x86asm"
 )
 
@@ -164,7 +163,7 @@ func (dis *Disasm) Addrs(arg x86asm.Arg, addr, next bin.Address) []bin.Address {
 			return nil
 		}
 
-		pretty.Println("mem:", arg)
+		trace.Dump("mem:", arg)
 		panic("x86.Disasm.Addrs: not yet implemented")
 	//case x86asm.Imm:
 	case x86asm.Rel:
@@ -1,6 +1,7 @@
 package x86
 
 import (
+	"context"
 	"sort"
 
 	"github.com/decomp/exp/bin"
@@ -33,10 +34,24 @@ type Inst struct {
 	Addr bin.Address
 	// x86 instruction.
 	x86asm.Inst
+	// Target of indirect jump, as resolved by emulation; or 0 if unresolved.
+	EmuTarget bin.Address
+	// Targets of indirect jump, as resolved by symbolic execution; or nil if
+	// unresolved.
+	SymTargets []bin.Address
+	// Infeasible targets of conditional branch, as determined by symbolic
+	// execution.
+	Infeasible []bin.Address
 }
 
 // DecodeFunc decodes and returns the function at the given address.
 func (dis *Disasm) DecodeFunc(entry bin.Address) (*Func, error) {
+	return dis.DecodeFuncContext(context.Background(), entry)
+}
+
+// DecodeFuncContext decodes and returns the function at the given address. An
+// error is returned if the context is cancelled before decoding completes.
+func (dis *Disasm) DecodeFuncContext(ctx context.Context, entry bin.Address) (*Func, error) {
 	dbg.Printf("decoding function at %v", entry)
 	f := &Func{
 		Addr:   entry,
@@ -44,30 +59,241 @@ func (dis *Disasm) DecodeFunc(entry bin.Address) (*Func, error) {
 	}
 	queue := newQueue()
 	queue.push(entry)
-	for !queue.empty() {
-		blockAddr := queue.pop()
-		if _, ok := f.Blocks[blockAddr]; ok {
-			// skip basic block if already decoded.
+	// Indirect jumps deferred until the rest of the function is decoded.
+	var pending []*Inst
+	for {
+		for !queue.empty() {
+			if err := ctx.Err(); err != nil {
+				return nil, errors.Wrapf(err, "unable to decode function at %v", entry)
+			}
+			blockAddr := queue.pop()
+			if _, ok := f.Blocks[blockAddr]; ok {
+				// skip basic block if already decoded.
+				continue
+			}
+			// Split basic block if the address is located in the middle of an
+			// already decoded basic block.
+			if f.splitBlock(blockAddr) {
+				continue
+			}
+			block, err := dis.DecodeBlock(blockAddr)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			// Truncate basic block if it falls through into an already decoded
+			// basic block.
+			for _, b := range f.Blocks {
+				if tail := block.split(b.Addr); tail != nil {
+					dbg.Printf("basic block at %v falls through into basic block at %v", blockAddr, b.Addr)
+				}
+			}
+			f.Blocks[blockAddr] = block
+			dis.resolveJump(f, block)
+			if dis.isUnresolvedJump(block.Term) {
+				pending = append(pending, block.Term)
+				continue
+			}
+			dis.pushTargets(queue, block.Term, entry)
+		}
+		if len(pending) == 0 {
+			break
+		}
+		// Resolve the targets of indirect jumps by symbolic execution of the
+		// basic blocks decoded so far.
+		res := dis.SymExec(f)
+		for _, term := range pending {
+			if targets, ok := res.Targets[term.Addr]; ok {
+				dbg.Printf("resolved targets %v of indirect jump at %v by symbolic execution", targets, term.Addr)
+				term.SymTargets = targets
+			}
+			dis.pushTargets(queue, term, entry)
+		}
+		pending = nil
+	}
+	if dis.Prune {
+		dis.prune(f)
+	}
+	return f, nil
+}
+
+// pushTargets adds the targets of the given terminator instruction to the
+// queue. Entry denotes the entry address of the function containing the
+// terminator instruction.
+func (dis *Disasm) pushTargets(queue *queue, term *Inst, entry bin.Address) {
+	for _, target := range dis.Targets(term, entry) {
+		if !dis.File.IsCode(target) {
+			warn.Printf("skipping branch target %v of instruction at %v; address not contained within executable section", target, term.Addr)
 			continue
 		}
-		block, err := dis.DecodeBlock(blockAddr)
+		dbg.Printf("adding basic block address %v to queue", target)
+		queue.push(target)
+	}
+}
+
+// maxEmuSteps specifies the maximum number of instructions to emulate when
+// resolving the target of an indirect jump.
+const maxEmuSteps = 10000
+
+// resolveJump tries to resolve the target of the indirect jump terminating the
+// given basic block, by emulating the instructions of the basic block, and if
+// unsuccessful, the instructions from the entry of the function; e.g. computed
+// jumps of obfuscated code or of position independent code. The resolved
+// target is recorded in the EmuTarget field of the terminator.
+func (dis *Disasm) resolveJump(f *Func, block *BasicBlock) {
+	term := block.Term
+	if term.Op != x86asm.JMP || dis.Mode == 16 || dis.isStaticJump(term) {
+		return
+	}
+	for _, start := range []bin.Address{block.Addr, f.Addr} {
+		e := dis.NewEmulator()
+		if err := e.Run(start, term.Addr, maxEmuSteps); err != nil {
+			dbg.Printf("unable to emulate indirect jump at %v from %v; %v", term.Addr, start, err)
+			continue
+		}
+		target, err := e.Target(term)
 		if err != nil {
-			return nil, errors.WithStack(err)
+			dbg.Printf("unable to resolve target of indirect jump at %v from %v; %v", term.Addr, start, err)
+			continue
+		}
+		if !dis.File.IsCode(target) {
+			// E.g. tail call to imported function.
+			continue
 		}
-		f.Blocks[blockAddr] = block
-		// Add block targets to queue.
-		targets := dis.Targets(block.Term, entry)
-		for _, target := range targets {
-			dbg.Printf("adding basic block address %v to queue", target)
+		dbg.Printf("resolved target %v of indirect jump at %v by emulation from %v", target, term.Addr, start)
+		term.EmuTarget = target
+		return
+	}
+}
+
+// isStaticJump reports whether the target of the given JMP instruction is
+// resolved without emulation; i.e. direct jumps, jumps through absolute memory
+// references and jump tables.
+func (dis *Disasm) isStaticJump(term *Inst) bool {
+	switch arg := term.Args[0].(type) {
+	case x86asm.Rel:
+		return true
+	case x86asm.Mem:
+		if arg.Base == 0 && arg.Index == 0 {
+			return true
+		}
+		_, ok := dis.Tables[bin.Address(arg.Disp)]
+		return ok
+	}
+	return false
+}
+
+// isUnresolvedJump reports whether the given terminator instruction is an
+// indirect jump, the target of which is neither resolved statically nor by
+// emulation.
+func (dis *Disasm) isUnresolvedJump(term *Inst) bool {
+	return term.Op == x86asm.JMP && dis.Mode != 16 && term.EmuTarget == 0 && !dis.isStaticJump(term)
+}
+
+// prune removes the infeasible edges of conditional branches of the given
+// function, as determined by symbolic execution, and the basic blocks no
+// longer reachable from the function entry. The function is left unchanged if
+// not every path could be explored.
+func (dis *Disasm) prune(f *Func) {
+	res := dis.SymExec(f)
+	if !res.Complete {
+		dbg.Printf("unable to prune edges of function at %v; incomplete symbolic execution", f.Addr)
+		return
+	}
+	for _, block := range f.Blocks {
+		term := block.Term
+		if !isCondJump(term.Op) {
+			continue
+		}
+		feasible, ok := res.Feasible[term.Addr]
+		if !ok {
+			// Unreachable basic block.
+			continue
+		}
+		for _, target := range dis.Targets(term, f.Addr) {
+			if !feasible[target] {
+				dbg.Printf("pruning infeasible edge from %v to %v", term.Addr, target)
+				term.Infeasible = append(term.Infeasible, target)
+			}
+		}
+	}
+	// Remove unreachable basic blocks.
+	reachable := make(map[bin.Address]bool)
+	queue := newQueue()
+	queue.push(f.Addr)
+	for !queue.empty() {
+		addr := queue.pop()
+		block, ok := f.Blocks[addr]
+		if !ok || reachable[addr] {
+			continue
+		}
+		reachable[addr] = true
+		for _, target := range dis.Targets(block.Term, f.Addr) {
 			queue.push(target)
 		}
 	}
-	return f, nil
+	for addr := range f.Blocks {
+		if !reachable[addr] {
+			dbg.Printf("pruning unreachable basic block at %v", addr)
+			delete(f.Blocks, addr)
+		}
+	}
+}
+
+// splitBlock splits the basic block containing the given address, if the
+// address is located at an instruction boundary in the middle of an already
+// decoded basic block. The boolean return value indicates whether the basic
+// block was split.
+func (f *Func) splitBlock(addr bin.Address) bool {
+	for _, block := range f.Blocks {
+		if tail := block.split(addr); tail != nil {
+			dbg.Printf("splitting basic block at %v; branch target %v", block.Addr, addr)
+			f.Blocks[addr] = tail
+			return true
+		}
+	}
+	return false
+}
+
+// split splits the basic block at the given address, and returns the tail of
+// the basic block starting at the address. The head of the basic block falls
+// through into the tail. A nil value is returned if the address is not located
+// at an instruction boundary in the middle of the basic block.
+func (block *BasicBlock) split(addr bin.Address) *BasicBlock {
+	for i, inst := range block.Insts {
+		if inst.Addr != addr || i == 0 {
+			continue
+		}
+		tail := &BasicBlock{
+			Addr:  addr,
+			Insts: block.Insts[i:],
+			Term:  block.Term,
+		}
+		block.Insts = block.Insts[:i:i]
+		// Add dummy terminator to fall through into the tail.
+		block.Term = &Inst{
+			Addr: addr,
+		}
+		return tail
+	}
+	if !block.Term.IsDummyTerm() && block.Term.Addr == addr && block.Addr != addr {
+		tail := &BasicBlock{
+			Addr: addr,
+			Term: block.Term,
+		}
+		block.Term = &Inst{
+			Addr: addr,
+		}
+		return tail
+	}
+	return nil
 }
 
 // DecodeBlock decodes and returns the basic block at the given address.
 func (dis *Disasm) DecodeBlock(entry bin.Address) (*BasicBlock, error) {
 	dbg.Printf("decoding basic block at %v", entry)
+	if !dis.File.IsCode(entry) {
+		return nil, errors.Errorf("unable to decode basic block at %v; address not contained within executable section", entry)
+	}
 	// Compute end address of the basic block.
 	maxLen := dis.maxBlockLen(entry)
 	addr := entry
@@ -83,7 +309,7 @@ func (dis *Disasm) DecodeBlock(entry bin.Address) (*BasicBlock, error) {
 		}
 		dbg.Printf("   instruction at %v: %v", addr, inst)
 		addr += bin.Address(inst.Len)
-		if inst.isTerm() {
+		if inst.isTerm() || dis.isNoReturnCall(inst) || isNoReturnInt(block.Insts, inst) || IsTrap(inst, dis.Mode) {
 			block.Term = inst
 			break
 		}
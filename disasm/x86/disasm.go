@@ -3,11 +3,10 @@ package x86
 
 import (
 	"fmt"
-	"log"
-	"os"
 
 	"github.com/decomp/exp/bin"
 	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/logging"
 	"github.com/mewkiz/pkg/jsonutil"
 	"github.com/mewkiz/pkg/osutil"
 	"github.com/mewkiz/pkg/term"
@@ -20,10 +19,13 @@ import (
 var (
 	// dbg represents a logger with the "x86:" prefix, which logs debug messages
 	// to standard error.
-	dbg = log.New(os.Stderr, term.BlueBold("x86:")+" ", 0)
+	dbg = logging.New("x86", logging.LevelDebug, term.BlueBold("x86:")+" ")
 	// warn represents a logger with the "warning:" prefix, which logs warning
 	// messages to standard error.
-	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+	warn = logging.New("x86", logging.LevelInfo, term.RedBold("warning:")+" ")
+	// trace represents a logger with the "x86:" prefix, which logs detailed
+	// tracing messages to standard error.
+	trace = logging.New("x86", logging.LevelTrace, term.BlueBold("x86:")+" ")
 )
 
 // A Disasm tracks information required to disassemble a binary executable.
@@ -37,6 +39,15 @@ type Disasm struct {
 	Mode int
 	// CPU contexts.
 	Contexts Contexts
+	// Prune specifies whether to remove infeasible edges of conditional
+	// branches, and the basic blocks only reachable through them, as determined
+	// by symbolic execution of decoded functions.
+	Prune bool
+
+	// End addresses of functions accepted by function discovery, indexed by
+	// entry address; retained as subsequently located functions would
+	// otherwise truncate their bodies.
+	bounds map[bin.Address]bin.Address
 }
 
 // NewDisasm creates a new Disasm for accessing the assembly instructions of the
@@ -66,6 +77,8 @@ func NewDisasm(file *bin.File) (*Disasm, error) {
 
 	// Parse processor mode.
 	switch dis.File.Arch {
+	case bin.ArchX86_16:
+		dis.Mode = 16
 	case bin.ArchX86_32:
 		dis.Mode = 32
 	case bin.ArchX86_64:
@@ -0,0 +1,664 @@
+package x86
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Function discovery ] ##################################################
+
+// Kinds of conflicting decodings.
+const (
+	// Instructions of two decodings overlap at different instruction
+	// boundaries.
+	ConflictOverlap = "overlap"
+	// Instructions overlap data; i.e. jump tables or data addresses (data.json).
+	ConflictData = "data"
+)
+
+// Resolutions of conflicting decodings.
+const (
+	// The candidate decoding was dropped.
+	ResolveDrop = "drop"
+	// The conflicting decoding was dropped in favour of the candidate decoding.
+	ResolveReplace = "replace"
+	// Both decodings were kept; e.g. conflicting functions of symbols or
+	// exception handling metadata.
+	ResolveKeep = "keep"
+)
+
+// A Conflict records a conflict between the decoding of a candidate function
+// and the decoding of a previously accepted function, or known data.
+type Conflict struct {
+	// Address of the first conflicting instruction of the candidate function.
+	Addr bin.Address
+	// Kind of conflict; ConflictOverlap or ConflictData.
+	Kind string
+	// Entry address of the candidate function.
+	Func bin.Address
+	// Score of the candidate function.
+	Score int
+	// Entry address of the conflicting function; or 0 if conflicting with data.
+	Other bin.Address
+	// Score of the conflicting function; or 0 if conflicting with data.
+	OtherScore int
+	// Resolution of the conflict; ResolveDrop, ResolveReplace or ResolveKeep.
+	Resolution string
+}
+
+// Weights of the heuristics scoring decodings of functions. Functions of
+// symbols, exports and exception handling metadata (seeds) are never dropped;
+// candidates of the linear sweep require a score of at least minSweepScore.
+const (
+	// Function of symbols, exports, exception handling metadata or
+	// user-supplied function addresses.
+	weightSeed = 100
+	// Target of a direct call from an accepted function.
+	weightCall = 40
+	// Start of a run of code located by the linear sweep.
+	weightSweep = 0
+	// Entry starts with a function prologue (e.g. `push ebp; mov ebp, esp`).
+	weightPrologue = 20
+	// Entry aligned to 16 bytes.
+	weightAligned = 5
+	// Entry preceded by padding (e.g. int3 or nop) or at the start of a section.
+	weightPadded = 5
+	// Per privileged or rarely used instruction (e.g. `in`, `hlt` or `arpl`);
+	// typical of data decoded as code.
+	weightUnlikely = -15
+	// Instructions overlapping data.
+	weightData = -50
+	// Minimum score of candidates of the linear sweep.
+	minSweepScore = 10
+)
+
+// A decoding records the decoded instructions of an accepted function.
+type decoding struct {
+	// Entry address of the function.
+	entry bin.Address
+	// Score of the decoding.
+	score int
+	// Seed specifies whether the function is a seed.
+	seed bool
+	// Instruction lengths, indexed by instruction address.
+	insts map[bin.Address]int
+}
+
+// A discovery tracks the state of function discovery.
+type discovery struct {
+	// Accepted decodings, indexed by entry address.
+	funcs map[bin.Address]*decoding
+	// Accepted decodings claiming each instruction, indexed by instruction
+	// address.
+	claims map[bin.Address][]*decoding
+	// Addresses of data bytes; jump tables and data addresses.
+	data map[bin.Address]bool
+	// Candidate addresses tried so far.
+	tried map[bin.Address]bool
+	// Conflicting decodings.
+	conflicts []*Conflict
+}
+
+// DiscoverFuncs locates functions by a hybrid of recursive traversal and
+// linear sweep. Starting from the known functions (seeds), the targets of
+// direct calls are followed recursively; the unclaimed bytes of executable
+// sections are then swept linearly for runs of code, and the procedure is
+// repeated until no further functions are located. Each function is decoded
+// within the given time budget (or unlimited if 0).
+//
+// Candidate functions conflicting with accepted functions (overlapping
+// instructions) or known data (jump tables and data.json) are resolved by
+// weighted heuristics; the decoding with the lower score is dropped. Located
+// functions are added to the function addresses of the disassembler, and the
+// conflicts are returned, sorted by address.
+//
+// DiscoverFuncs should be invoked during initialization, before functions are
+// decoded for lifting.
+func (dis *Disasm) DiscoverFuncs(ctx context.Context, timeout time.Duration) ([]*Conflict, error) {
+	d := &discovery{
+		funcs:  make(map[bin.Address]*decoding),
+		claims: make(map[bin.Address][]*decoding),
+		data:   dis.dataBytes(),
+		tried:  make(map[bin.Address]bool),
+	}
+	if dis.bounds == nil {
+		dis.bounds = make(map[bin.Address]bin.Address)
+	}
+	seeds := append([]bin.Address(nil), dis.FuncAddrs...)
+	queue := newQueue()
+	for _, funcAddr := range seeds {
+		if err := dis.tryFunc(ctx, d, queue, funcAddr, weightSeed, timeout); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	for {
+		// Recursive traversal of call targets.
+		for !queue.empty() {
+			if err := dis.tryFunc(ctx, d, queue, queue.pop(), weightCall, timeout); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		// Linear sweep of unclaimed bytes.
+		n := len(d.funcs)
+		for _, sect := range dis.sweepSects() {
+			if err := dis.sweep(ctx, d, queue, sect, timeout); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		if len(d.funcs) == n && queue.empty() {
+			break
+		}
+	}
+	less := func(i, j int) bool {
+		return d.conflicts[i].Addr < d.conflicts[j].Addr
+	}
+	sort.Slice(d.conflicts, less)
+	dbg.Printf("located %d functions (%d seeds) by recursive traversal and linear sweep; %d conflicts", len(dis.FuncAddrs), len(seeds), len(d.conflicts))
+	return d.conflicts, nil
+}
+
+// tryFunc decodes the candidate function at the given address with the
+// specified base weight, and accepts it unless rejected by the heuristics of
+// conflict resolution. The targets of direct calls of accepted functions are
+// added to the queue.
+func (dis *Disasm) tryFunc(ctx context.Context, d *discovery, queue *queue, entry bin.Address, weight int, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "unable to decode function at %v", entry)
+	}
+	if d.tried[entry] {
+		return nil
+	}
+	d.tried[entry] = true
+	seed := weight == weightSeed
+	if !seed {
+		// Add candidate to function addresses during decoding, as tail calls are
+		// identified by function addresses.
+		dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, entry)
+	}
+	end := dis.funcEnd(entry)
+	f, err := dis.decodeCandidate(ctx, entry, timeout)
+	if err != nil {
+		if errors.Cause(err) == context.Canceled {
+			return errors.WithStack(err)
+		}
+		if !seed {
+			dbg.Printf("rejecting candidate function at %v; %v", entry, err)
+			dis.FuncAddrs = removeAddr(dis.FuncAddrs, entry)
+		}
+		return nil
+	}
+	fd := &decoding{
+		entry: entry,
+		seed:  seed,
+		insts: make(map[bin.Address]int),
+	}
+	for _, block := range f.Blocks {
+		for _, inst := range append(block.Insts, block.Term) {
+			if inst.IsDummyTerm() {
+				continue
+			}
+			fd.insts[inst.Addr] = inst.Len
+		}
+	}
+	var dataAddr bin.Address
+	fd.score, dataAddr = dis.score(d, f, weight)
+	if weight == weightSweep && fd.score < minSweepScore && dataAddr == 0 {
+		dbg.Printf("rejecting candidate function at %v; score %d below %d", entry, fd.score, minSweepScore)
+		dis.FuncAddrs = removeAddr(dis.FuncAddrs, entry)
+		return nil
+	}
+	if !dis.accept(d, fd, dataAddr) {
+		dis.FuncAddrs = removeAddr(dis.FuncAddrs, entry)
+		return nil
+	}
+	// Retain the bounds of accepted functions, as subsequently located
+	// functions would otherwise truncate the bodies of preceding functions.
+	if _, ok := dis.File.FuncBounds[entry]; !ok {
+		dis.bounds[entry] = end
+	}
+	d.funcs[entry] = fd
+	for addr := range fd.insts {
+		d.claims[addr] = append(d.claims[addr], fd)
+	}
+	// Follow direct calls.
+	for _, block := range f.Blocks {
+		for _, inst := range append(block.Insts, block.Term) {
+			if inst.Op != x86asm.CALL {
+				continue
+			}
+			if _, ok := inst.Args[0].(x86asm.Rel); !ok {
+				continue
+			}
+			target, _ := CallTarget(inst)
+			if !dis.File.IsCode(target) || dis.IsFunc(target) || d.tried[target] {
+				continue
+			}
+			if _, ok := dis.Chunks[target]; ok {
+				continue
+			}
+			queue.push(target)
+		}
+	}
+	return nil
+}
+
+// accept reports whether to accept the given decoding of a candidate function,
+// resolving conflicts with accepted functions and known data. Conflicting
+// functions with lower scores than the candidate are dropped. Data address
+// denotes the first instruction address overlapping data; or 0 if none.
+func (dis *Disasm) accept(d *discovery, fd *decoding, dataAddr bin.Address) bool {
+	if dataAddr != 0 {
+		c := &Conflict{Addr: dataAddr, Kind: ConflictData, Func: fd.entry, Score: fd.score, Resolution: ResolveKeep}
+		if !fd.seed {
+			c.Resolution = ResolveDrop
+		}
+		d.conflicts = append(d.conflicts, c)
+		if !fd.seed {
+			dbg.Printf("dropping candidate function at %v; instruction at %v overlaps data", fd.entry, dataAddr)
+			return false
+		}
+	}
+	// Locate conflicting functions, and the first conflicting instruction of
+	// each.
+	others := make(map[*decoding]bin.Address)
+	for addr, n := range fd.insts {
+		for _, other := range d.overlaps(addr, n) {
+			if prev, ok := others[other]; !ok || addr < prev {
+				others[other] = addr
+			}
+		}
+	}
+	// Resolve conflicts in order of conflicting function address, for
+	// deterministic output.
+	var order []*decoding
+	for other := range others {
+		order = append(order, other)
+	}
+	less := func(i, j int) bool {
+		return order[i].entry < order[j].entry
+	}
+	sort.Slice(order, less)
+	var dropped []*decoding
+	for _, other := range order {
+		c := &Conflict{Addr: others[other], Kind: ConflictOverlap, Func: fd.entry, Score: fd.score, Other: other.entry, OtherScore: other.score}
+		d.conflicts = append(d.conflicts, c)
+		switch {
+		case fd.seed && other.seed:
+			c.Resolution = ResolveKeep
+		case !other.seed && fd.score > other.score:
+			c.Resolution = ResolveReplace
+			dropped = append(dropped, other)
+		default:
+			c.Resolution = ResolveDrop
+			dbg.Printf("dropping candidate function at %v (score %d); instruction at %v overlaps function at %v (score %d)", fd.entry, fd.score, c.Addr, other.entry, other.score)
+			return false
+		}
+	}
+	for _, other := range dropped {
+		dbg.Printf("dropping function at %v (score %d) in favour of function at %v (score %d)", other.entry, other.score, fd.entry, fd.score)
+		d.drop(other)
+		dis.FuncAddrs = removeAddr(dis.FuncAddrs, other.entry)
+		delete(dis.bounds, other.entry)
+	}
+	return true
+}
+
+// overlaps returns the accepted functions with instructions overlapping the
+// instruction at the given address and of the specified length, at a different
+// instruction boundary.
+func (d *discovery) overlaps(addr bin.Address, n int) []*decoding {
+	var others []*decoding
+	// Instructions starting within the instruction.
+	for a := addr + 1; a < addr+bin.Address(n); a++ {
+		others = append(others, d.claims[a]...)
+	}
+	// Instructions covering the start of the instruction.
+	for a := addr - 1; a+maxInstLen > addr && a < addr; a-- {
+		for _, other := range d.claims[a] {
+			if a+bin.Address(other.insts[a]) > addr {
+				others = append(others, other)
+			}
+		}
+	}
+	return others
+}
+
+// drop drops the given accepted function.
+func (d *discovery) drop(fd *decoding) {
+	delete(d.funcs, fd.entry)
+	for addr := range fd.insts {
+		claims := d.claims[addr][:0]
+		for _, other := range d.claims[addr] {
+			if other != fd {
+				claims = append(claims, other)
+			}
+		}
+		if len(claims) == 0 {
+			delete(d.claims, addr)
+			continue
+		}
+		d.claims[addr] = claims
+	}
+}
+
+// maxInstLen specifies the maximum length in bytes of x86 instructions.
+const maxInstLen = 15
+
+// claimed reports whether the byte at the given address is claimed by an
+// instruction of an accepted function.
+func (d *discovery) claimed(addr bin.Address) bool {
+	for a := addr; a+maxInstLen > addr && a <= addr; a-- {
+		for _, fd := range d.claims[a] {
+			if a+bin.Address(fd.insts[a]) > addr {
+				return true
+			}
+		}
+		if a == 0 {
+			break
+		}
+	}
+	return false
+}
+
+// decodeCandidate decodes the function at the given address within the given
+// time budget, recovering from panics (e.g. jumps to non-function addresses
+// outside of the function body).
+func (dis *Disasm) decodeCandidate(ctx context.Context, entry bin.Address, timeout time.Duration) (f *Func, err error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("unable to decode function at %v; %v", entry, e)
+		}
+	}()
+	return dis.DecodeFuncContext(ctx, entry)
+}
+
+// ### [ Linear sweep ] ########################################################
+
+// sweep sweeps the unclaimed bytes of the given executable section linearly for
+// runs of code. Padding is skipped, and the start of each run of code is tried
+// as a candidate function; runs of rejected candidates are skipped up to and
+// including the first unconditional jump or return instruction.
+func (dis *Disasm) sweep(ctx context.Context, d *discovery, queue *queue, sect *bin.Section, timeout time.Duration) error {
+	end := sect.Addr + bin.Address(len(sect.Data))
+	for addr := sect.Addr; addr < end; {
+		if d.claimed(addr) || d.data[addr] {
+			addr++
+			continue
+		}
+		if n := dis.paddingLen(sect, addr, end); n > 0 {
+			addr += bin.Address(n)
+			continue
+		}
+		if !d.tried[addr] {
+			if err := dis.tryFunc(ctx, d, queue, addr, weightSweep, timeout); err != nil {
+				return errors.WithStack(err)
+			}
+			if _, ok := d.funcs[addr]; ok {
+				continue
+			}
+		}
+		addr += bin.Address(dis.runLen(d, sect, addr, end))
+	}
+	return nil
+}
+
+// paddingLen returns the length in bytes of the padding (int3, nop or alignment
+// zeros) at the given address of the section, not extending past end.
+func (dis *Disasm) paddingLen(sect *bin.Section, addr, end bin.Address) int {
+	n := 0
+	for a := addr; a < end; {
+		off := int(a - sect.Addr)
+		switch sect.Data[off] {
+		case 0xCC, 0x00:
+			n++
+			a++
+			continue
+		}
+		inst, err := x86asm.Decode(sect.Data[off:int(end-sect.Addr)], dis.Mode)
+		if err != nil || inst.Op != x86asm.NOP {
+			break
+		}
+		n += inst.Len
+		a += bin.Address(inst.Len)
+	}
+	return n
+}
+
+// runLen returns the length in bytes of the run of code at the given address of
+// the section, up to and including the first unconditional jump, return or trap
+// instruction, not extending past end or into padding. Bytes which fail to
+// decode terminate the run. The targets of direct branches of the run are
+// marked as tried, as they are part of the rejected code rather than function
+// entries.
+func (dis *Disasm) runLen(d *discovery, sect *bin.Section, addr, end bin.Address) int {
+	n := 0
+	for a := addr; a < end; {
+		off := int(a - sect.Addr)
+		inst, err := x86asm.Decode(sect.Data[off:int(end-sect.Addr)], dis.Mode)
+		if err != nil || (n > 0 && inst.Op == x86asm.NOP) {
+			break
+		}
+		n += inst.Len
+		a += bin.Address(inst.Len)
+		if rel, ok := inst.Args[0].(x86asm.Rel); ok && (inst.Op == x86asm.JMP || isCondJump(inst.Op)) {
+			d.tried[a+bin.Address(rel)] = true
+		}
+		switch inst.Op {
+		case x86asm.RET, x86asm.LRET, x86asm.JMP, x86asm.LJMP:
+			return n
+		}
+		if IsTrap(&Inst{Inst: inst}, dis.Mode) {
+			return n
+		}
+	}
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// sweepSects returns the executable sections of the binary executable to sweep,
+// sorted by address. Memory segments are only included for executables lacking
+// executable sections. PLT sections of ELF files are excluded, as their stubs
+// are resolved as imports through dynamic relocations.
+func (dis *Disasm) sweepSects() []*bin.Section {
+	segs := make(map[*bin.Section]bool)
+	for _, seg := range dis.File.Segments {
+		segs[seg] = true
+	}
+	var sects, segments []*bin.Section
+	for _, sect := range dis.File.Sections {
+		if sect.Perm&bin.PermX == 0 || len(sect.Data) == 0 {
+			continue
+		}
+		switch sect.Name {
+		case ".plt", ".plt.got", ".plt.sec":
+			continue
+		}
+		if segs[sect] {
+			segments = append(segments, sect)
+			continue
+		}
+		sects = append(sects, sect)
+	}
+	if len(sects) == 0 {
+		sects = segments
+	}
+	less := func(i, j int) bool {
+		return sects[i].Addr < sects[j].Addr
+	}
+	sort.Slice(sects, less)
+	return sects
+}
+
+// ### [ Heuristics ] ##########################################################
+
+// score returns the score of the given decoded function with the specified
+// base weight, and the address of the first instruction overlapping known
+// data; or 0 if none.
+func (dis *Disasm) score(d *discovery, f *Func, weight int) (int, bin.Address) {
+	score := weight
+	if dis.hasPrologue(f.Addr) {
+		score += weightPrologue
+	}
+	if f.Addr%16 == 0 {
+		score += weightAligned
+	}
+	if dis.isPadded(f.Addr) {
+		score += weightPadded
+	}
+	var dataAddr bin.Address
+	for _, block := range f.Blocks {
+		for _, inst := range append(block.Insts, block.Term) {
+			if inst.IsDummyTerm() {
+				continue
+			}
+			if dis.isUnlikely(inst) {
+				score += weightUnlikely
+			}
+			for a := inst.Addr; a < inst.Addr+bin.Address(inst.Len); a++ {
+				if d.data[a] && (dataAddr == 0 || inst.Addr < dataAddr) {
+					dataAddr = inst.Addr
+				}
+			}
+		}
+	}
+	if dataAddr != 0 {
+		score += weightData
+	}
+	return score, dataAddr
+}
+
+// Byte sequences of function prologues.
+var (
+	// endbr64 and endbr32 of Intel CET.
+	endbr64 = []byte{0xF3, 0x0F, 0x1E, 0xFA}
+	endbr32 = []byte{0xF3, 0x0F, 0x1E, 0xFB}
+	// `mov edi, edi` of hot-patchable functions of MSVC.
+	hotpatch = []byte{0x8B, 0xFF}
+)
+
+// hasPrologue reports whether the function at the given address starts with a
+// function prologue; i.e. `endbr64`, `mov edi, edi`, `push ebp; mov ebp, esp`,
+// `sub esp, imm`, or (in 64-bit mode) a push of a callee-saved register.
+func (dis *Disasm) hasPrologue(entry bin.Address) bool {
+	code := dis.File.Code(entry)
+	if bytes.HasPrefix(code, endbr64) || bytes.HasPrefix(code, endbr32) || bytes.HasPrefix(code, hotpatch) {
+		return true
+	}
+	inst, err := x86asm.Decode(code, dis.Mode)
+	if err != nil {
+		return false
+	}
+	switch inst.Op {
+	case x86asm.PUSH:
+		reg, ok := inst.Args[0].(x86asm.Reg)
+		if !ok {
+			return false
+		}
+		if dis.Mode == 64 {
+			switch reg {
+			case x86asm.RBX, x86asm.RBP, x86asm.RSI, x86asm.RDI, x86asm.R12, x86asm.R13, x86asm.R14, x86asm.R15:
+				return true
+			}
+			return false
+		}
+		if reg != x86asm.EBP && reg != x86asm.BP {
+			return false
+		}
+		next, err := x86asm.Decode(code[inst.Len:], dis.Mode)
+		if err != nil || next.Op != x86asm.MOV {
+			return false
+		}
+		switch next.Args[0] {
+		case x86asm.EBP, x86asm.BP:
+			return next.Args[1] == x86asm.ESP || next.Args[1] == x86asm.SP
+		}
+	case x86asm.SUB:
+		switch inst.Args[0] {
+		case x86asm.ESP, x86asm.RSP:
+			_, ok := inst.Args[1].(x86asm.Imm)
+			return ok
+		}
+	}
+	return false
+}
+
+// isPadded reports whether the given address is preceded by padding (int3, nop
+// or alignment zeros), or located at the start of an executable section.
+func (dis *Disasm) isPadded(addr bin.Address) bool {
+	for _, sect := range dis.File.Sections {
+		if sect.Perm&bin.PermX == 0 {
+			continue
+		}
+		if addr == sect.Addr {
+			return true
+		}
+		if sect.Addr < addr && addr <= sect.Addr+bin.Address(len(sect.Data)) {
+			switch sect.Data[addr-sect.Addr-1] {
+			case 0xCC, 0x90, 0x00:
+				return true
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// isUnlikely reports whether the given instruction is privileged or rarely
+// used in application code; typical of data decoded as code. Port I/O and
+// interrupt flag instructions are common in 16-bit real mode code.
+func (dis *Disasm) isUnlikely(inst *Inst) bool {
+	switch inst.Op {
+	case x86asm.HLT, x86asm.INTO, x86asm.ARPL, x86asm.BOUND, x86asm.LDS, x86asm.LES,
+		x86asm.AAA, x86asm.AAS, x86asm.AAD, x86asm.AAM, x86asm.DAA, x86asm.DAS,
+		x86asm.ICEBP:
+		return true
+	case x86asm.IN, x86asm.OUT, x86asm.INSB, x86asm.INSW, x86asm.INSD,
+		x86asm.OUTSB, x86asm.OUTSW, x86asm.OUTSD, x86asm.CLI, x86asm.STI:
+		return dis.Mode != 16
+	}
+	return false
+}
+
+// dataBytes returns the addresses of known data bytes; i.e. the entries of
+// jump tables, and data addresses (data.json).
+func (dis *Disasm) dataBytes() map[bin.Address]bool {
+	data := make(map[bin.Address]bool)
+	size := bin.Address(dis.Mode / 8)
+	for addr, targets := range dis.Tables {
+		for a := addr; a < addr+bin.Address(len(targets))*size; a++ {
+			data[a] = true
+		}
+	}
+	for _, frag := range dis.Frags {
+		if frag.Kind == disasm.KindData {
+			data[frag.Addr] = true
+		}
+	}
+	return data
+}
+
+// removeAddr removes the given address from the sorted list of addresses.
+func removeAddr(addrs []bin.Address, addr bin.Address) []bin.Address {
+	less := func(i int) bool {
+		return addr <= addrs[i]
+	}
+	index := sort.Search(len(addrs), less)
+	if index < len(addrs) && addrs[index] == addr {
+		return append(addrs[:index], addrs[index+1:]...)
+	}
+	return addrs
+}
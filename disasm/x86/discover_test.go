@@ -0,0 +1,95 @@
+package x86_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/disasm/x86"
+)
+
+func TestDiscoverFuncs(t *testing.T) {
+	// Code of the .text section, located at 0x401000.
+	//
+	//    sub_401000:
+	//       push    ebp
+	//       mov     ebp, esp
+	//       call    sub_401010
+	//       call    0x401012           ; overlaps the first instruction of sub_401010
+	//       pop     ebp
+	//       ret
+	//       int3
+	//
+	//    sub_401010:
+	//       mov     eax, 0x90C3C031    ; xor eax, eax; ret; nop
+	//       ret
+	//
+	//       ; Data decoded as privileged instructions.
+	//       db      0xE4, 0x60         ; in al, 0x60
+	//       db      0xF4               ; hlt
+	//       times 7 int3
+	//
+	//       ; Data decoded with a function prologue; 0x401023 is a known data
+	//       ; address (data.json).
+	//       db      0x55               ; push ebp
+	//       db      0x8B, 0xEC         ; mov ebp, esp
+	//       db      0x5D               ; pop ebp
+	//       db      0xC3               ; ret
+	//       times 11 int3
+	code := []byte{
+		0x55,
+		0x8B, 0xEC,
+		0xE8, 0x08, 0x00, 0x00, 0x00,
+		0xE8, 0x05, 0x00, 0x00, 0x00,
+		0x5D,
+		0xC3,
+		0xCC,
+		0xB8, 0x31, 0xC0, 0xC3, 0x90,
+		0xC3,
+		0xE4, 0x60,
+		0xF4,
+		0xCC, 0xCC, 0xCC, 0xCC, 0xCC, 0xCC, 0xCC,
+		0x55,
+		0x8B, 0xEC,
+		0x5D,
+		0xC3,
+		0xCC, 0xCC, 0xCC, 0xCC, 0xCC, 0xCC, 0xCC, 0xCC, 0xCC, 0xCC, 0xCC,
+	}
+	file := &bin.File{
+		Arch:  bin.ArchX86_32,
+		Entry: 0x401000,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Data: code, MemSize: len(code), Perm: bin.PermR | bin.PermX},
+		},
+	}
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		t.Fatalf("unable to create disassembler; %+v", err)
+	}
+	dis.Frags = append(dis.Frags, &disasm.Fragment{Addr: 0x401023, Kind: disasm.KindData})
+	conflicts, err := dis.DiscoverFuncs(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("unable to discover functions; %+v", err)
+	}
+	want := []*x86.Conflict{
+		// Call target overlapping the instruction of a function with a higher
+		// score (aligned and preceded by padding).
+		{Addr: 0x401012, Kind: x86.ConflictOverlap, Func: 0x401012, Score: 40, Other: 0x401010, OtherScore: 50, Resolution: x86.ResolveDrop},
+		// Candidate of the linear sweep overlapping known data.
+		{Addr: 0x401023, Kind: x86.ConflictData, Func: 0x401020, Score: -20, Resolution: x86.ResolveDrop},
+	}
+	if !reflect.DeepEqual(conflicts, want) {
+		for _, c := range conflicts {
+			t.Logf("conflict: %+v", *c)
+		}
+		t.Errorf("conflicts mismatch; expected %d conflicts, got %d", len(want), len(conflicts))
+	}
+	// The data decoded as privileged instructions at 0x401016 is rejected by
+	// score, without conflicts.
+	funcAddrs := []bin.Address{0x401000, 0x401010}
+	if !reflect.DeepEqual(dis.FuncAddrs, funcAddrs) {
+		t.Errorf("function addresses mismatch; expected %v, got %v", funcAddrs, dis.FuncAddrs)
+	}
+}
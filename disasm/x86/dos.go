@@ -0,0 +1,87 @@
+package x86
+
+import (
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// DOS interrupts of 16-bit real mode.
+const (
+	// IntTerminate terminates the program (INT 20h).
+	IntTerminate = 0x20
+	// IntDOS invokes the DOS function specified by AH (INT 21h).
+	IntDOS = 0x21
+	// IntKeep terminates the program and stays resident (INT 27h).
+	IntKeep = 0x27
+)
+
+// noReturnDOSFuncs specifies the DOS functions (INT 21h) which do not return to
+// the program, indexed by function number (AH).
+var noReturnDOSFuncs = map[uint8]bool{
+	0x00: true, // terminate program
+	0x31: true, // terminate and stay resident
+	0x4C: true, // terminate with return code
+}
+
+// DOSFunc returns the DOS function number (i.e. the value of AH) of the given
+// INT 21h instruction, as defined by the preceding instructions of its basic
+// block (e.g. `mov ah, 0x09` or `mov ax, 0x4C00`). The boolean return value
+// indicates success.
+func DOSFunc(insts []*Inst, inst *Inst) (uint8, bool) {
+	if inst.Op != x86asm.INT || inst.Args[0] != x86asm.Imm(IntDOS) {
+		return 0, false
+	}
+	for i := len(insts) - 1; i >= 0; i-- {
+		prev := insts[i]
+		switch prev.Op {
+		case x86asm.CALL, x86asm.LCALL, x86asm.INT, x86asm.MUL, x86asm.IMUL,
+			x86asm.DIV, x86asm.IDIV, x86asm.CBW, x86asm.CWDE, x86asm.LAHF,
+			x86asm.LODSW, x86asm.LODSD, x86asm.IN, x86asm.POPA, x86asm.POPAD:
+			// Implicit definition of AH.
+			return 0, false
+		case x86asm.XCHG:
+			if definesAH(prev.Args[0]) || definesAH(prev.Args[1]) {
+				return 0, false
+			}
+			continue
+		}
+		if !definesAH(prev.Args[0]) {
+			continue
+		}
+		if prev.Op != x86asm.MOV {
+			return 0, false
+		}
+		imm, ok := prev.Args[1].(x86asm.Imm)
+		if !ok {
+			return 0, false
+		}
+		if prev.Args[0] == x86asm.AH {
+			return uint8(imm), true
+		}
+		return uint8(imm >> 8), true
+	}
+	return 0, false
+}
+
+// definesAH reports whether the given destination operand defines AH.
+func definesAH(arg x86asm.Arg) bool {
+	switch arg {
+	case x86asm.AH, x86asm.AX, x86asm.EAX:
+		return true
+	}
+	return false
+}
+
+// isNoReturnInt reports whether the given INT instruction, preceded by the given
+// instructions of its basic block, terminates the program (e.g. INT 20h or INT
+// 21h/AH=4Ch). Such interrupts terminate basic blocks.
+func isNoReturnInt(insts []*Inst, inst *Inst) bool {
+	if inst.Op != x86asm.INT {
+		return false
+	}
+	switch inst.Args[0] {
+	case x86asm.Imm(IntTerminate), x86asm.Imm(IntKeep):
+		return true
+	}
+	fn, ok := DOSFunc(insts, inst)
+	return ok && noReturnDOSFuncs[fn]
+}
@@ -0,0 +1,875 @@
+package x86
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// An Emulator is a lightweight concrete emulator of x86 instructions, which
+// interprets instructions over a snapshot of the sections of a binary
+// executable. It is used to resolve computed jump targets, dynamic writes to
+// the import address table, and to run simple unpacking stubs.
+//
+// The emulator tracks which registers, flags and bytes of memory hold known
+// values. Registers are unknown initially, except for the stack pointer, and
+// memory outside of the sections of the executable is unknown until written.
+// Computations on unknown values yield unknown values, and emulation stops with
+// an error when an unknown value determines control flow or a memory address.
+//
+// Calls to imported functions are emulated for the few functions required to
+// resolve imports at runtime (e.g. LoadLibraryA and GetProcAddress); the
+// import address table is initialized with synthetic addresses of imported
+// functions for this purpose.
+type Emulator struct {
+	// Binary executable.
+	File *bin.File
+	// Processor mode (32 or 64).
+	Mode int
+	// Address of the next instruction to execute.
+	PC bin.Address
+	// Number of instructions executed.
+	Steps int
+
+	// Values of the general purpose registers, indexed by register number
+	// (RAX = 0, RCX = 1, ..., R15 = 15).
+	regs [16]uint64
+	// Bit masks of the known bits of the general purpose registers.
+	known [16]uint64
+	// Status flags.
+	flags [nflags]flag
+	// Memory pages, indexed by page number.
+	pages map[bin.Address]*page
+	// Imported functions, indexed by synthetic address.
+	procs map[bin.Address]string
+	// Synthetic addresses of imported functions, indexed by name.
+	procAddrs map[string]bin.Address
+	// DLL names of module handles, indexed by synthetic module handle.
+	modules map[bin.Address]string
+}
+
+// Synthetic addresses of the emulator; chosen so as not to overlap with the
+// sections of typical executables.
+const (
+	// Initial stack pointer.
+	emuStackTop = 0x7FFE0000
+	// Start address of imported functions.
+	emuProcBase = 0xFE000000
+	// Start address of module handles.
+	emuModuleBase = 0xFD000000
+)
+
+// NewEmulator returns a new emulator of the instructions of the given binary
+// executable, in the specified processor mode. The import address table of the
+// emulated memory holds synthetic addresses of imported functions.
+func NewEmulator(file *bin.File, mode int) *Emulator {
+	e := &Emulator{
+		File:      file,
+		Mode:      mode,
+		pages:     make(map[bin.Address]*page),
+		procs:     make(map[bin.Address]string),
+		procAddrs: make(map[string]bin.Address),
+		modules:   make(map[bin.Address]string),
+	}
+	e.setReg(e.sp(), known(emuStackTop))
+	// Clear direction flag, as guaranteed by calling conventions.
+	e.flags[flagDF] = flag{known: true}
+	var addrs bin.Addresses
+	for addr := range file.Imports {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	for _, addr := range addrs {
+		procAddr := e.procAddr(file.Imports[addr])
+		e.poke(addr, e.ptrSize(), known(uint64(procAddr)))
+	}
+	// Treat the snapshot of the import address table as unmodified.
+	for _, p := range e.pages {
+		p.written = [pageSize]bool{}
+	}
+	return e
+}
+
+// NewEmulator returns a new emulator of the instructions of the binary
+// executable.
+func (dis *Disasm) NewEmulator() *Emulator {
+	return NewEmulator(dis.File, dis.Mode)
+}
+
+// Run executes instructions starting at the given address, until the stop
+// address is reached. An error is returned if an instruction cannot be
+// emulated, or if the stop address is not reached within maxSteps instructions.
+func (e *Emulator) Run(start, stop bin.Address, maxSteps int) error {
+	e.PC = start
+	for e.PC != stop {
+		if e.Steps >= maxSteps {
+			return errors.Errorf("unable to reach %v from %v within %d instructions", stop, start, maxSteps)
+		}
+		if err := e.Step(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// Unpack executes instructions starting at the given address (e.g. the entry
+// point of a packed executable), until an instruction written during emulation
+// is about to be executed; i.e. the original entry point of the unpacked code.
+// An error is returned if an instruction cannot be emulated, or if no written
+// instruction is reached within maxSteps instructions.
+func (e *Emulator) Unpack(start bin.Address, maxSteps int) (bin.Address, error) {
+	e.PC = start
+	for !e.written(e.PC) {
+		if e.Steps >= maxSteps {
+			return 0, errors.Errorf("unable to locate original entry point from %v within %d instructions", start, maxSteps)
+		}
+		if err := e.Step(); err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+	return e.PC, nil
+}
+
+// Step executes the instruction at the program counter.
+func (e *Emulator) Step() error {
+	if _, ok := e.procs[e.PC]; ok {
+		// Call or jump to imported function.
+		e.Steps++
+		return e.callProc(e.PC)
+	}
+	inst, err := e.fetch(e.PC)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	next := e.PC + bin.Address(inst.Len)
+	e.PC = next
+	e.Steps++
+	if err := e.exec(inst, next); err != nil {
+		return errors.Wrapf(err, "unable to emulate instruction %v at %v", inst, inst.Addr)
+	}
+	return nil
+}
+
+// Target returns the target address of the given JMP or CALL instruction, as
+// evaluated in the current state of the emulator; e.g. after running to the
+// address of the instruction.
+func (e *Emulator) Target(inst *Inst) (bin.Address, error) {
+	next := inst.Addr + bin.Address(inst.Len)
+	v, err := e.read(inst, inst.Args[0], next)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if !v.known {
+		return 0, errors.Errorf("unknown target of instruction %v at %v", inst, inst.Addr)
+	}
+	return bin.Address(v.v), nil
+}
+
+// Writes returns the addresses and contents of the continuous ranges of memory
+// within the sections of the executable, which have been written during
+// emulation; indexed by start address. Bytes of unknown value are zero.
+func (e *Emulator) Writes() map[bin.Address][]byte {
+	var pageNums bin.Addresses
+	for pageNum := range e.pages {
+		pageNums = append(pageNums, pageNum)
+	}
+	sort.Sort(pageNums)
+	writes := make(map[bin.Address][]byte)
+	var start bin.Address
+	var buf []byte
+	flush := func() {
+		if len(buf) > 0 {
+			writes[start] = buf
+		}
+		buf = nil
+	}
+	for _, pageNum := range pageNums {
+		p := e.pages[pageNum]
+		for off := range p.data {
+			addr := pageNum*pageSize + bin.Address(off)
+			if !p.written[off] || !e.inSection(addr) {
+				flush()
+				continue
+			}
+			if len(buf) > 0 && addr != start+bin.Address(len(buf)) {
+				flush()
+			}
+			if len(buf) == 0 {
+				start = addr
+			}
+			buf = append(buf, p.data[off])
+		}
+	}
+	flush()
+	return writes
+}
+
+// Reg returns the value of the given general purpose register, and a boolean
+// indicating whether the value is known.
+func (e *Emulator) Reg(reg x86asm.Reg) (uint64, bool) {
+	v, err := e.getReg(reg)
+	if err != nil {
+		return 0, false
+	}
+	return v.v, v.known
+}
+
+// SetReg sets the given general purpose register to the specified value.
+func (e *Emulator) SetReg(reg x86asm.Reg, v uint64) error {
+	if err := e.setReg(reg, known(v)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Mem returns the little-endian value of the given size in bytes at the
+// specified address of emulated memory, and a boolean indicating whether the
+// value is known.
+func (e *Emulator) Mem(addr bin.Address, size int) (uint64, bool) {
+	v := e.peek(addr, size)
+	return v.v, v.known
+}
+
+// SetMem stores the little-endian value of the given size in bytes at the
+// specified address of emulated memory.
+func (e *Emulator) SetMem(addr bin.Address, size int, v uint64) {
+	e.poke(addr, size, known(v))
+}
+
+// Imports returns the names of imported functions, the addresses of which have
+// been written to memory during emulation (e.g. dynamic writes to the import
+// address table of addresses resolved by GetProcAddress); indexed by the
+// address of the pointer.
+func (e *Emulator) Imports() map[bin.Address]string {
+	imports := make(map[bin.Address]string)
+	ptrSize := e.ptrSize()
+	for pageNum, p := range e.pages {
+		for off := 0; off+ptrSize <= pageSize; off += ptrSize {
+			if !p.written[off] {
+				continue
+			}
+			addr := pageNum*pageSize + bin.Address(off)
+			v := e.peek(addr, ptrSize)
+			if !v.known {
+				continue
+			}
+			if name, ok := e.procs[bin.Address(v.v)]; ok {
+				imports[addr] = name
+			}
+		}
+	}
+	return imports
+}
+
+// Apply applies the memory writes of the emulation to the sections of the
+// executable, and records the imported functions of dynamic writes to the
+// import address table. The section contents are extended as required to hold
+// written uninitialized data.
+func (e *Emulator) Apply() {
+	for addr, buf := range e.Writes() {
+		for _, sect := range e.File.Sections {
+			if addr < sect.Addr || addr >= sect.Addr+bin.Address(sect.MemSize) {
+				continue
+			}
+			end := int(addr-sect.Addr) + len(buf)
+			if end > len(sect.Data) {
+				data := make([]byte, end)
+				copy(data, sect.Data)
+				sect.Data = data
+			}
+			copy(sect.Data[addr-sect.Addr:], buf)
+			break
+		}
+	}
+	for addr, name := range e.Imports() {
+		dbg.Printf("dynamic import %q at %v", name, addr)
+		e.File.Imports[addr] = name
+	}
+}
+
+// ### [ Values ] ##############################################################
+
+// A value is a possibly unknown value.
+type value struct {
+	// Underlying value; or 0 if unknown.
+	v uint64
+	// Specifies whether the value is known.
+	known bool
+}
+
+// known returns the known value v.
+func known(v uint64) value {
+	return value{v: v, known: true}
+}
+
+// unknown represents an unknown value.
+var unknown = value{}
+
+// A flag is a possibly unknown status flag.
+type flag struct {
+	// Flag value.
+	set bool
+	// Specifies whether the flag value is known.
+	known bool
+}
+
+// Status flags.
+const (
+	flagCF = iota
+	flagPF
+	flagZF
+	flagSF
+	flagOF
+	flagDF
+	nflags
+)
+
+// mask returns the bit mask of values of the given size in bytes.
+func mask(size int) uint64 {
+	if size >= 8 {
+		return ^uint64(0)
+	}
+	return 1<<uint(8*size) - 1
+}
+
+// signBit returns the sign bit of values of the given size in bytes.
+func signBit(size int) uint64 {
+	return 1 << uint(8*size-1)
+}
+
+// signExtend sign-extends the given value of the specified size in bytes.
+func signExtend(v uint64, size int) uint64 {
+	if size >= 8 {
+		return v
+	}
+	shift := uint(64 - 8*size)
+	return uint64(int64(v<<shift) >> shift)
+}
+
+// ### [ Registers ] ###########################################################
+
+// regLoc returns the register number, bit offset and size in bytes of the given
+// register.
+func regLoc(reg x86asm.Reg) (num int, shift uint, size int, ok bool) {
+	switch {
+	case x86asm.AL <= reg && reg <= x86asm.BL:
+		return int(reg - x86asm.AL), 0, 1, true
+	case x86asm.AH <= reg && reg <= x86asm.BH:
+		return int(reg - x86asm.AH), 8, 1, true
+	case x86asm.SPB <= reg && reg <= x86asm.R15B:
+		return 4 + int(reg-x86asm.SPB), 0, 1, true
+	case x86asm.AX <= reg && reg <= x86asm.R15W:
+		return int(reg - x86asm.AX), 0, 2, true
+	case x86asm.EAX <= reg && reg <= x86asm.R15L:
+		return int(reg - x86asm.EAX), 0, 4, true
+	case x86asm.RAX <= reg && reg <= x86asm.R15:
+		return int(reg - x86asm.RAX), 0, 8, true
+	}
+	return 0, 0, 0, false
+}
+
+// regSize returns the size in bytes of the given register; or 0 if not a
+// general purpose register.
+func regSize(reg x86asm.Reg) int {
+	_, _, size, _ := regLoc(reg)
+	return size
+}
+
+// getReg returns the value of the given general purpose register.
+func (e *Emulator) getReg(reg x86asm.Reg) (value, error) {
+	num, shift, size, ok := regLoc(reg)
+	if !ok {
+		return unknown, errors.Errorf("support for register %v not yet implemented", reg)
+	}
+	m := mask(size) << shift
+	if e.known[num]&m != m {
+		return unknown, nil
+	}
+	return known(e.regs[num] & m >> shift), nil
+}
+
+// setReg sets the value of the given general purpose register. Writes to 32-bit
+// registers zero-extend the value to the full register.
+func (e *Emulator) setReg(reg x86asm.Reg, v value) error {
+	num, shift, size, ok := regLoc(reg)
+	if !ok {
+		return errors.Errorf("support for register %v not yet implemented", reg)
+	}
+	m := mask(size) << shift
+	if size == 4 {
+		m = ^uint64(0)
+	}
+	e.regs[num] = e.regs[num]&^m | (v.v&mask(size))<<shift
+	if v.known {
+		e.known[num] |= m
+	} else {
+		e.known[num] &^= m
+	}
+	return nil
+}
+
+// sp returns the stack pointer register of the processor mode.
+func (e *Emulator) sp() x86asm.Reg {
+	if e.Mode == 64 {
+		return x86asm.RSP
+	}
+	return x86asm.ESP
+}
+
+// ptrSize returns the size in bytes of pointers of the processor mode.
+func (e *Emulator) ptrSize() int {
+	return e.Mode / 8
+}
+
+// addrReg returns the general purpose register of the given address size in
+// bits, corresponding to the specified register number.
+func addrReg(num int, addrSize int) x86asm.Reg {
+	if addrSize == 64 {
+		return x86asm.RAX + x86asm.Reg(num)
+	}
+	return x86asm.EAX + x86asm.Reg(num)
+}
+
+// ### [ Memory ] ##############################################################
+
+// pageSize specifies the size in bytes of memory pages.
+const pageSize = 4096
+
+// A page is a page of emulated memory.
+type page struct {
+	// Page contents.
+	data [pageSize]byte
+	// Specifies whether bytes are known.
+	known [pageSize]bool
+	// Specifies whether bytes have been written during emulation.
+	written [pageSize]bool
+}
+
+// page returns the memory page containing the given address, initialized from
+// the sections of the executable on first use.
+func (e *Emulator) page(addr bin.Address) *page {
+	pageNum := addr / pageSize
+	if p, ok := e.pages[pageNum]; ok {
+		return p
+	}
+	p := &page{}
+	start := pageNum * pageSize
+	for _, sect := range e.File.Sections {
+		size := sect.MemSize
+		if size < len(sect.Data) {
+			size = len(sect.Data)
+		}
+		end := sect.Addr + bin.Address(size)
+		if end <= start || sect.Addr >= start+pageSize {
+			continue
+		}
+		for off := 0; off < pageSize; off++ {
+			a := start + bin.Address(off)
+			if a < sect.Addr || a >= end {
+				continue
+			}
+			// Uninitialized data is zero.
+			if i := int(a - sect.Addr); i < len(sect.Data) {
+				p.data[off] = sect.Data[i]
+			}
+			p.known[off] = true
+		}
+	}
+	e.pages[pageNum] = p
+	return p
+}
+
+// inSection reports whether the given address is contained within a section
+// of the executable.
+func (e *Emulator) inSection(addr bin.Address) bool {
+	for _, sect := range e.File.Sections {
+		size := sect.MemSize
+		if size < len(sect.Data) {
+			size = len(sect.Data)
+		}
+		if sect.Addr <= addr && addr < sect.Addr+bin.Address(size) {
+			return true
+		}
+	}
+	return false
+}
+
+// written reports whether the byte at the given address has been written
+// during emulation.
+func (e *Emulator) written(addr bin.Address) bool {
+	p, ok := e.pages[addr/pageSize]
+	return ok && p.written[addr%pageSize]
+}
+
+// peek returns the little-endian value of the given size in bytes at the
+// specified address.
+func (e *Emulator) peek(addr bin.Address, size int) value {
+	var v uint64
+	for i := 0; i < size; i++ {
+		a := addr + bin.Address(i)
+		p := e.page(a)
+		if !p.known[a%pageSize] {
+			return unknown
+		}
+		v |= uint64(p.data[a%pageSize]) << uint(8*i)
+	}
+	return known(v)
+}
+
+// poke stores the little-endian value of the given size in bytes at the
+// specified address.
+func (e *Emulator) poke(addr bin.Address, size int, v value) {
+	for i := 0; i < size; i++ {
+		a := addr + bin.Address(i)
+		p := e.page(a)
+		p.data[a%pageSize] = byte(v.v >> uint(8*i))
+		p.known[a%pageSize] = v.known
+		p.written[a%pageSize] = true
+	}
+}
+
+// fetch decodes the instruction at the given address of emulated memory.
+func (e *Emulator) fetch(addr bin.Address) (*Inst, error) {
+	var code []byte
+	for i := 0; i < 15; i++ {
+		a := addr + bin.Address(i)
+		p := e.page(a)
+		if !p.known[a%pageSize] {
+			break
+		}
+		code = append(code, p.data[a%pageSize])
+	}
+	if len(code) == 0 {
+		return nil, errors.Errorf("unable to fetch instruction at %v; unknown memory contents", addr)
+	}
+	i, err := x86asm.Decode(code, e.Mode)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decode instruction at %v", addr)
+	}
+	return &Inst{Addr: addr, Inst: i}, nil
+}
+
+// cstring returns the NULL-terminated string at the given address; or UTF-16
+// encoded string if wide is set.
+func (e *Emulator) cstring(addr bin.Address, wide bool) (string, error) {
+	charSize := 1
+	if wide {
+		charSize = 2
+	}
+	var buf []rune
+	for i := 0; i < 1024; i++ {
+		c := e.peek(addr+bin.Address(i*charSize), charSize)
+		if !c.known {
+			return "", errors.Errorf("unknown contents of string at %v", addr)
+		}
+		if c.v == 0 {
+			return string(buf), nil
+		}
+		buf = append(buf, rune(c.v))
+	}
+	return "", errors.Errorf("unterminated string at %v", addr)
+}
+
+// ### [ Operands ] ############################################################
+
+// memAddr returns the address of the given memory reference. Next specifies the
+// address of the next instruction, used by RIP-relative memory references. An
+// error is returned if the address is unknown.
+func (e *Emulator) memAddr(inst *Inst, mem x86asm.Mem, next bin.Address) (bin.Address, error) {
+	addr, err := e.effAddr(inst, mem, next)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if !addr.known {
+		return 0, errors.Errorf("unknown address of memory reference %v", mem)
+	}
+	return bin.Address(addr.v), nil
+}
+
+// effAddr returns the effective address of the given memory reference; or an
+// unknown value if the base or index register is unknown.
+func (e *Emulator) effAddr(inst *Inst, mem x86asm.Mem, next bin.Address) (value, error) {
+	switch mem.Segment {
+	case 0, x86asm.CS, x86asm.DS, x86asm.ES, x86asm.SS:
+		// Flat memory model.
+	default:
+		return unknown, errors.Errorf("support for segment register %v not yet implemented", mem.Segment)
+	}
+	addr := known(uint64(mem.Disp))
+	switch {
+	case mem.Base == x86asm.RIP:
+		addr.v += uint64(next)
+	case mem.Base != 0:
+		base, err := e.getReg(mem.Base)
+		if err != nil {
+			return unknown, errors.WithStack(err)
+		}
+		addr.v += base.v
+		addr.known = addr.known && base.known
+	}
+	if mem.Index != 0 {
+		index, err := e.getReg(mem.Index)
+		if err != nil {
+			return unknown, errors.WithStack(err)
+		}
+		addr.v += uint64(mem.Scale) * index.v
+		addr.known = addr.known && index.known
+	}
+	if !addr.known {
+		return unknown, nil
+	}
+	addrSize := inst.AddrSize
+	if addrSize == 0 {
+		addrSize = e.Mode
+	}
+	addr.v &= mask(addrSize / 8)
+	return addr, nil
+}
+
+// argSize returns the size in bytes of the given instruction argument.
+func argSize(inst *Inst, arg x86asm.Arg) int {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		return regSize(arg)
+	case x86asm.Mem:
+		return inst.MemBytes
+	}
+	return inst.DataSize / 8
+}
+
+// read returns the value of the given instruction argument.
+func (e *Emulator) read(inst *Inst, arg x86asm.Arg, next bin.Address) (value, error) {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		return e.getReg(arg)
+	case x86asm.Mem:
+		addr, err := e.memAddr(inst, arg, next)
+		if err != nil {
+			return unknown, errors.WithStack(err)
+		}
+		return e.peek(addr, inst.MemBytes), nil
+	case x86asm.Imm:
+		return known(uint64(arg)), nil
+	case x86asm.Rel:
+		return known(uint64(next + bin.Address(arg))), nil
+	}
+	return unknown, errors.Errorf("support for argument type %T not yet implemented", arg)
+}
+
+// write stores the value to the given instruction argument.
+func (e *Emulator) write(inst *Inst, arg x86asm.Arg, next bin.Address, v value) error {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		return e.setReg(arg, v)
+	case x86asm.Mem:
+		addr, err := e.memAddr(inst, arg, next)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		e.poke(addr, inst.MemBytes, v)
+		return nil
+	}
+	return errors.Errorf("support for destination argument type %T not yet implemented", arg)
+}
+
+// push pushes the value of the given size in bytes to the stack.
+func (e *Emulator) push(v value, size int) error {
+	sp, err := e.getReg(e.sp())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !sp.known {
+		return errors.New("unknown stack pointer")
+	}
+	sp.v -= uint64(size)
+	e.setReg(e.sp(), sp)
+	e.poke(bin.Address(sp.v), size, v)
+	return nil
+}
+
+// pop pops a value of the given size in bytes from the stack.
+func (e *Emulator) pop(size int) (value, error) {
+	sp, err := e.getReg(e.sp())
+	if err != nil {
+		return unknown, errors.WithStack(err)
+	}
+	if !sp.known {
+		return unknown, errors.New("unknown stack pointer")
+	}
+	v := e.peek(bin.Address(sp.v), size)
+	sp.v += uint64(size)
+	e.setReg(e.sp(), sp)
+	return v, nil
+}
+
+// ### [ Imports ] #############################################################
+
+// procAddr returns the synthetic address of the given imported function.
+func (e *Emulator) procAddr(name string) bin.Address {
+	if addr, ok := e.procAddrs[name]; ok {
+		return addr
+	}
+	addr := emuProcBase + bin.Address(16*len(e.procAddrs))
+	e.procAddrs[name] = addr
+	e.procs[addr] = name
+	return addr
+}
+
+// moduleHandle returns the synthetic module handle of the given DLL.
+func (e *Emulator) moduleHandle(dllName string) bin.Address {
+	for h, name := range e.modules {
+		if name == dllName {
+			return h
+		}
+	}
+	h := emuModuleBase + bin.Address(0x10000*len(e.modules))
+	e.modules[h] = dllName
+	return h
+}
+
+// arg returns the i:th argument of a call to an imported function, on entry of
+// the function; stdcall calling convention in 32-bit mode, and Microsoft x64
+// calling convention in 64-bit mode.
+func (e *Emulator) arg(i int) (value, error) {
+	if e.Mode == 64 {
+		regs := []x86asm.Reg{x86asm.RCX, x86asm.RDX, x86asm.R8, x86asm.R9}
+		return e.getReg(regs[i])
+	}
+	sp, err := e.getReg(x86asm.ESP)
+	if err != nil {
+		return unknown, errors.WithStack(err)
+	}
+	if !sp.known {
+		return unknown, errors.New("unknown stack pointer")
+	}
+	return e.peek(bin.Address(sp.v)+bin.Address(4*(i+1)), 4), nil
+}
+
+// emuProcs specifies the number of arguments and the emulation functions of
+// imported functions, which are emulated to resolve imports at runtime.
+var emuProcs = map[string]struct {
+	// Number of arguments.
+	nargs int
+	// Emulation function, returning the return value of the function.
+	emulate func(e *Emulator) (value, error)
+}{
+	"LoadLibraryA":     {1, emuLoadLibrary(false)},
+	"LoadLibraryW":     {1, emuLoadLibrary(true)},
+	"LoadLibraryExA":   {3, emuLoadLibrary(false)},
+	"LoadLibraryExW":   {3, emuLoadLibrary(true)},
+	"GetModuleHandleA": {1, emuLoadLibrary(false)},
+	"GetModuleHandleW": {1, emuLoadLibrary(true)},
+	"GetProcAddress":   {2, emuGetProcAddress},
+	"VirtualProtect":   {4, emuVirtualProtect},
+}
+
+// callProc emulates a call to the imported function at the given synthetic
+// address, on entry of the function.
+func (e *Emulator) callProc(addr bin.Address) error {
+	name := e.procs[addr]
+	proc, ok := emuProcs[name]
+	if !ok {
+		return errors.Errorf("support for emulation of imported function %q not yet implemented", name)
+	}
+	dbg.Printf("emulating call to %q", name)
+	ret, err := proc.emulate(e)
+	if err != nil {
+		return errors.Wrapf(err, "unable to emulate call to %q", name)
+	}
+	// Return to caller.
+	retAddr, err := e.pop(e.ptrSize())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !retAddr.known {
+		return errors.Errorf("unknown return address of call to %q", name)
+	}
+	if e.Mode != 64 {
+		// Callee cleanup of stdcall arguments.
+		sp, _ := e.getReg(x86asm.ESP)
+		sp.v += uint64(4 * proc.nargs)
+		e.setReg(x86asm.ESP, sp)
+	}
+	e.setReg(addrReg(0, e.Mode), ret)
+	e.PC = bin.Address(retAddr.v)
+	return nil
+}
+
+// emuLoadLibrary returns an emulation function of LoadLibrary and
+// GetModuleHandle, returning a synthetic module handle of the DLL. The DLL name
+// is UTF-16 encoded if wide is set.
+func emuLoadLibrary(wide bool) func(e *Emulator) (value, error) {
+	return func(e *Emulator) (value, error) {
+		namePtr, err := e.arg(0)
+		if err != nil {
+			return unknown, errors.WithStack(err)
+		}
+		if !namePtr.known {
+			return unknown, errors.New("unknown DLL name")
+		}
+		if namePtr.v == 0 {
+			// Module handle of the executable.
+			return known(uint64(e.File.ImageBase)), nil
+		}
+		dllName, err := e.cstring(bin.Address(namePtr.v), wide)
+		if err != nil {
+			return unknown, errors.WithStack(err)
+		}
+		return known(uint64(e.moduleHandle(dllName))), nil
+	}
+}
+
+// emuGetProcAddress emulates GetProcAddress, returning the synthetic address
+// of the imported function.
+func emuGetProcAddress(e *Emulator) (value, error) {
+	h, err := e.arg(0)
+	if err != nil {
+		return unknown, errors.WithStack(err)
+	}
+	namePtr, err := e.arg(1)
+	if err != nil {
+		return unknown, errors.WithStack(err)
+	}
+	if !namePtr.known {
+		return unknown, errors.New("unknown function name")
+	}
+	if namePtr.v < 0x10000 {
+		// Import by ordinal.
+		dllName, ok := e.modules[bin.Address(h.v)]
+		if !h.known || !ok {
+			return unknown, errors.Errorf("unknown module handle of import by ordinal %d", namePtr.v)
+		}
+		return known(uint64(e.procAddr(bin.OrdinalName(dllName, namePtr.v)))), nil
+	}
+	name, err := e.cstring(bin.Address(namePtr.v), false)
+	if err != nil {
+		return unknown, errors.WithStack(err)
+	}
+	return known(uint64(e.procAddr(name))), nil
+}
+
+// emuVirtualProtect emulates VirtualProtect, which succeeds without effect.
+func emuVirtualProtect(e *Emulator) (value, error) {
+	oldProtect, err := e.arg(3)
+	if err != nil {
+		return unknown, errors.WithStack(err)
+	}
+	if oldProtect.known && oldProtect.v != 0 {
+		// PAGE_EXECUTE_READWRITE
+		e.poke(bin.Address(oldProtect.v), 4, known(0x40))
+	}
+	return known(1), nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// String returns the string representation of the value.
+func (v value) String() string {
+	if !v.known {
+		return "unknown"
+	}
+	return fmt.Sprintf("0x%X", v.v)
+}
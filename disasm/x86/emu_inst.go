@@ -0,0 +1,830 @@
+package x86
+
+import (
+	"math/bits"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// exec executes the given instruction. Next specifies the address of the next
+// instruction.
+func (e *Emulator) exec(inst *Inst, next bin.Address) error {
+	switch inst.Op {
+	// Data transfer instructions.
+	case x86asm.NOP, x86asm.PAUSE:
+		return nil
+	case x86asm.MOV:
+		v, err := e.read(inst, inst.Args[1], next)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return e.write(inst, inst.Args[0], next, v)
+	case x86asm.MOVZX, x86asm.MOVSX, x86asm.MOVSXD:
+		return e.execMOVX(inst, next)
+	case x86asm.LEA:
+		mem, ok := inst.Args[1].(x86asm.Mem)
+		if !ok {
+			return errors.Errorf("invalid LEA source argument type %T", inst.Args[1])
+		}
+		v, err := e.effAddr(inst, mem, next)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return e.write(inst, inst.Args[0], next, v)
+	case x86asm.XCHG:
+		a, err := e.read(inst, inst.Args[0], next)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		b, err := e.read(inst, inst.Args[1], next)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := e.write(inst, inst.Args[0], next, b); err != nil {
+			return errors.WithStack(err)
+		}
+		return e.write(inst, inst.Args[1], next, a)
+	case x86asm.BSWAP:
+		v, err := e.read(inst, inst.Args[0], next)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if v.known {
+			switch argSize(inst, inst.Args[0]) {
+			case 4:
+				v.v = uint64(bits.ReverseBytes32(uint32(v.v)))
+			case 8:
+				v.v = bits.ReverseBytes64(v.v)
+			}
+		}
+		return e.write(inst, inst.Args[0], next, v)
+	case x86asm.CBW, x86asm.CWDE, x86asm.CDQE:
+		size := inst.DataSize / 8
+		src := addrReg(0, 32)
+		dst := addrReg(0, 64)
+		switch size {
+		case 2:
+			src, dst = x86asm.AL, x86asm.AX
+		case 4:
+			src, dst = x86asm.AX, x86asm.EAX
+		}
+		v, err := e.getReg(src)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v.v = signExtend(v.v, size/2) & mask(size)
+		return e.setReg(dst, v)
+	case x86asm.CWD, x86asm.CDQ, x86asm.CQO:
+		src, dst := x86asm.EAX, x86asm.EDX
+		switch inst.Op {
+		case x86asm.CWD:
+			src, dst = x86asm.AX, x86asm.DX
+		case x86asm.CQO:
+			src, dst = x86asm.RAX, x86asm.RDX
+		}
+		v, err := e.getReg(src)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		size := regSize(src)
+		if v.v&signBit(size) != 0 {
+			v.v = mask(size)
+		} else {
+			v.v = 0
+		}
+		return e.setReg(dst, v)
+	// Stack instructions.
+	case x86asm.PUSH:
+		return e.execPUSH(inst, next)
+	case x86asm.POP:
+		v, err := e.pop(argSize(inst, inst.Args[0]))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return e.write(inst, inst.Args[0], next, v)
+	case x86asm.PUSHAD:
+		sp, err := e.getReg(x86asm.ESP)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, reg := range []x86asm.Reg{x86asm.EAX, x86asm.ECX, x86asm.EDX, x86asm.EBX, x86asm.ESP, x86asm.EBP, x86asm.ESI, x86asm.EDI} {
+			v := sp
+			if reg != x86asm.ESP {
+				if v, err = e.getReg(reg); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+			if err := e.push(v, 4); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	case x86asm.POPAD:
+		for _, reg := range []x86asm.Reg{x86asm.EDI, x86asm.ESI, x86asm.EBP, x86asm.ESP, x86asm.EBX, x86asm.EDX, x86asm.ECX, x86asm.EAX} {
+			v, err := e.pop(4)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if reg != x86asm.ESP {
+				e.setReg(reg, v)
+			}
+		}
+		return nil
+	case x86asm.LEAVE:
+		bp := addrReg(5, e.Mode)
+		v, err := e.getReg(bp)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		e.setReg(e.sp(), v)
+		v, err = e.pop(e.ptrSize())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return e.setReg(bp, v)
+	// Arithmetic and logic instructions.
+	case x86asm.ADD, x86asm.ADC, x86asm.SUB, x86asm.SBB, x86asm.CMP, x86asm.AND, x86asm.OR, x86asm.XOR, x86asm.TEST:
+		return e.execArith(inst, next)
+	case x86asm.INC, x86asm.DEC, x86asm.NEG, x86asm.NOT:
+		return e.execUnary(inst, next)
+	case x86asm.SHL, x86asm.SHR, x86asm.SAR, x86asm.ROL, x86asm.ROR, x86asm.RCL, x86asm.RCR:
+		return e.execShift(inst, next)
+	case x86asm.IMUL:
+		return e.execIMUL(inst, next)
+	case x86asm.MUL, x86asm.DIV, x86asm.IDIV:
+		// Results are not tracked.
+		size := argSize(inst, inst.Args[0])
+		lo, hi := addrReg(0, 8*size), addrReg(2, 8*size)
+		switch size {
+		case 1:
+			lo, hi = x86asm.AX, x86asm.AX
+		case 2:
+			lo, hi = x86asm.AX, x86asm.DX
+		}
+		e.setReg(lo, unknown)
+		e.setReg(hi, unknown)
+		e.clobberFlags()
+		return nil
+	// Flag instructions.
+	case x86asm.CLC:
+		e.setFlag(flagCF, false)
+		return nil
+	case x86asm.STC:
+		e.setFlag(flagCF, true)
+		return nil
+	case x86asm.CMC:
+		if cf := e.flags[flagCF]; cf.known {
+			e.setFlag(flagCF, !cf.set)
+		}
+		return nil
+	case x86asm.CLD:
+		e.setFlag(flagDF, false)
+		return nil
+	case x86asm.STD:
+		e.setFlag(flagDF, true)
+		return nil
+	case x86asm.SETA, x86asm.SETAE, x86asm.SETB, x86asm.SETBE, x86asm.SETE, x86asm.SETG, x86asm.SETGE, x86asm.SETL, x86asm.SETLE, x86asm.SETNE, x86asm.SETNO, x86asm.SETNP, x86asm.SETNS, x86asm.SETO, x86asm.SETP, x86asm.SETS:
+		cond, ok := e.cond(inst.Op)
+		v := unknown
+		if ok {
+			v = known(0)
+			if cond {
+				v = known(1)
+			}
+		}
+		return e.write(inst, inst.Args[0], next, v)
+	case x86asm.CMOVA, x86asm.CMOVAE, x86asm.CMOVB, x86asm.CMOVBE, x86asm.CMOVE, x86asm.CMOVG, x86asm.CMOVGE, x86asm.CMOVL, x86asm.CMOVLE, x86asm.CMOVNE, x86asm.CMOVNO, x86asm.CMOVNP, x86asm.CMOVNS, x86asm.CMOVO, x86asm.CMOVP, x86asm.CMOVS:
+		cond, ok := e.cond(inst.Op)
+		switch {
+		case !ok:
+			return e.write(inst, inst.Args[0], next, unknown)
+		case cond:
+			v, err := e.read(inst, inst.Args[1], next)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return e.write(inst, inst.Args[0], next, v)
+		}
+		return nil
+	// String instructions.
+	case x86asm.MOVSB, x86asm.MOVSW, x86asm.MOVSD, x86asm.MOVSQ, x86asm.STOSB, x86asm.STOSW, x86asm.STOSD, x86asm.STOSQ, x86asm.LODSB, x86asm.LODSW, x86asm.LODSD, x86asm.LODSQ, x86asm.SCASB, x86asm.SCASW, x86asm.SCASD, x86asm.SCASQ, x86asm.CMPSB, x86asm.CMPSW, x86asm.CMPSD, x86asm.CMPSQ:
+		return e.execString(inst)
+	// Control transfer instructions.
+	case x86asm.JMP:
+		target, err := e.Target(inst)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		e.PC = target
+		return nil
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JE, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JS:
+		cond, ok := e.cond(inst.Op)
+		if !ok {
+			return errors.New("unknown branch condition")
+		}
+		if cond {
+			e.PC = next + bin.Address(inst.Args[0].(x86asm.Rel))
+		}
+		return nil
+	case x86asm.JCXZ, x86asm.JECXZ, x86asm.JRCXZ:
+		reg := x86asm.ECX
+		switch inst.Op {
+		case x86asm.JCXZ:
+			reg = x86asm.CX
+		case x86asm.JRCXZ:
+			reg = x86asm.RCX
+		}
+		v, err := e.getReg(reg)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !v.known {
+			return errors.New("unknown branch condition")
+		}
+		if v.v == 0 {
+			e.PC = next + bin.Address(inst.Args[0].(x86asm.Rel))
+		}
+		return nil
+	case x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		return e.execLOOP(inst, next)
+	case x86asm.CALL:
+		target, err := e.Target(inst)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := e.push(known(uint64(next)), e.ptrSize()); err != nil {
+			return errors.WithStack(err)
+		}
+		e.PC = target
+		return nil
+	case x86asm.RET:
+		v, err := e.pop(e.ptrSize())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !v.known {
+			return errors.New("unknown return address")
+		}
+		if imm, ok := inst.Args[0].(x86asm.Imm); ok {
+			sp, _ := e.getReg(e.sp())
+			sp.v += uint64(imm)
+			e.setReg(e.sp(), sp)
+		}
+		e.PC = bin.Address(v.v)
+		return nil
+	}
+	return errors.Errorf("support for instruction %v not yet implemented", inst.Op)
+}
+
+// execMOVX executes the given MOVZX, MOVSX or MOVSXD instruction.
+func (e *Emulator) execMOVX(inst *Inst, next bin.Address) error {
+	v, err := e.read(inst, inst.Args[1], next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	srcSize := argSize(inst, inst.Args[1])
+	dstSize := argSize(inst, inst.Args[0])
+	if inst.Op != x86asm.MOVZX {
+		v.v = signExtend(v.v, srcSize) & mask(dstSize)
+	}
+	return e.write(inst, inst.Args[0], next, v)
+}
+
+// execPUSH executes the given PUSH instruction.
+func (e *Emulator) execPUSH(inst *Inst, next bin.Address) error {
+	size := argSize(inst, inst.Args[0])
+	if _, ok := inst.Args[0].(x86asm.Imm); ok {
+		size = e.ptrSize()
+		if inst.DataSize == 16 {
+			size = 2
+		}
+	}
+	v, err := e.read(inst, inst.Args[0], next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	v.v &= mask(size)
+	return e.push(v, size)
+}
+
+// execArith executes the given binary arithmetic or logic instruction.
+func (e *Emulator) execArith(inst *Inst, next bin.Address) error {
+	dst, src := inst.Args[0], inst.Args[1]
+	size := argSize(inst, dst)
+	m := mask(size)
+	a, err := e.read(inst, dst, next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	b, err := e.read(inst, src, next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Zeroing idioms; e.g. `xor eax, eax`.
+	if reg, ok := dst.(x86asm.Reg); ok && reg == src && (inst.Op == x86asm.XOR || inst.Op == x86asm.SUB) {
+		a, b = known(0), known(0)
+	}
+	var carry uint64
+	if inst.Op == x86asm.ADC || inst.Op == x86asm.SBB {
+		cf := e.flags[flagCF]
+		if !cf.known {
+			b = unknown
+		}
+		if cf.set {
+			carry = 1
+		}
+	}
+	if !a.known || !b.known {
+		e.clobberFlags()
+		switch inst.Op {
+		case x86asm.CMP, x86asm.TEST:
+			return nil
+		}
+		return e.write(inst, dst, next, unknown)
+	}
+	x, y := a.v&m, b.v&m
+	var res uint64
+	switch inst.Op {
+	case x86asm.ADD, x86asm.ADC:
+		res = (x + y + carry) & m
+		cf := res < x || (carry == 1 && res == x)
+		e.setFlag(flagCF, cf)
+		e.setFlag(flagOF, (x^res)&(y^res)&signBit(size) != 0)
+	case x86asm.SUB, x86asm.SBB, x86asm.CMP:
+		res = (x - y - carry) & m
+		cf := x < y || (carry == 1 && x == y)
+		e.setFlag(flagCF, cf)
+		e.setFlag(flagOF, (x^y)&(x^res)&signBit(size) != 0)
+	case x86asm.AND, x86asm.TEST:
+		res = x & y
+		e.setFlag(flagCF, false)
+		e.setFlag(flagOF, false)
+	case x86asm.OR:
+		res = x | y
+		e.setFlag(flagCF, false)
+		e.setFlag(flagOF, false)
+	case x86asm.XOR:
+		res = x ^ y
+		e.setFlag(flagCF, false)
+		e.setFlag(flagOF, false)
+	}
+	e.setResultFlags(res, size)
+	switch inst.Op {
+	case x86asm.CMP, x86asm.TEST:
+		return nil
+	}
+	return e.write(inst, dst, next, known(res))
+}
+
+// execUnary executes the given unary arithmetic or logic instruction.
+func (e *Emulator) execUnary(inst *Inst, next bin.Address) error {
+	dst := inst.Args[0]
+	size := argSize(inst, dst)
+	m := mask(size)
+	a, err := e.read(inst, dst, next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !a.known {
+		if inst.Op != x86asm.NOT {
+			cf := e.flags[flagCF]
+			e.clobberFlags()
+			if inst.Op != x86asm.NEG {
+				// INC and DEC preserve the carry flag.
+				e.flags[flagCF] = cf
+			}
+		}
+		return e.write(inst, dst, next, unknown)
+	}
+	x := a.v & m
+	var res uint64
+	switch inst.Op {
+	case x86asm.INC:
+		res = (x + 1) & m
+		e.setFlag(flagOF, res == signBit(size))
+	case x86asm.DEC:
+		res = (x - 1) & m
+		e.setFlag(flagOF, x == signBit(size))
+	case x86asm.NEG:
+		res = -x & m
+		e.setFlag(flagCF, x != 0)
+		e.setFlag(flagOF, x == signBit(size))
+	case x86asm.NOT:
+		return e.write(inst, dst, next, known(^x&m))
+	}
+	e.setResultFlags(res, size)
+	return e.write(inst, dst, next, known(res))
+}
+
+// execShift executes the given shift or rotate instruction.
+func (e *Emulator) execShift(inst *Inst, next bin.Address) error {
+	dst := inst.Args[0]
+	size := argSize(inst, dst)
+	m := mask(size)
+	nbits := uint64(8 * size)
+	a, err := e.read(inst, dst, next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	count := known(1)
+	if len(inst.Args) > 1 && inst.Args[1] != nil {
+		if count, err = e.read(inst, inst.Args[1], next); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if count.known {
+		if size == 8 {
+			count.v &= 0x3F
+		} else {
+			count.v &= 0x1F
+		}
+		if count.v == 0 {
+			// Flags are unaffected.
+			return nil
+		}
+	}
+	cf := e.flags[flagCF]
+	if !a.known || !count.known || (!cf.known && (inst.Op == x86asm.RCL || inst.Op == x86asm.RCR)) {
+		e.clobberFlags()
+		return e.write(inst, dst, next, unknown)
+	}
+	x, c := a.v&m, count.v
+	msb := func(v uint64) bool { return v&signBit(size) != 0 }
+	var res uint64
+	switch inst.Op {
+	case x86asm.SHL:
+		res = x << c & m
+		carry := c <= nbits && (x>>(nbits-c))&1 != 0
+		e.setFlag(flagCF, carry)
+		e.setShiftOF(c, msb(res) != carry)
+		e.setResultFlags(res, size)
+	case x86asm.SHR:
+		res = x >> c
+		e.setFlag(flagCF, c <= nbits && (x>>(c-1))&1 != 0)
+		e.setShiftOF(c, msb(x))
+		e.setResultFlags(res, size)
+	case x86asm.SAR:
+		sx := int64(signExtend(x, size))
+		if c > 63 {
+			c = 63
+		}
+		res = uint64(sx>>c) & m
+		e.setFlag(flagCF, (sx>>(c-1))&1 != 0)
+		e.setShiftOF(c, false)
+		e.setResultFlags(res, size)
+	case x86asm.ROL:
+		r := c % nbits
+		res = (x<<r | x>>(nbits-r)) & m
+		e.setFlag(flagCF, res&1 != 0)
+		e.setShiftOF(c, msb(res) != (res&1 != 0))
+	case x86asm.ROR:
+		r := c % nbits
+		res = (x>>r | x<<(nbits-r)) & m
+		e.setFlag(flagCF, msb(res))
+		e.setShiftOF(c, msb(res) != msb(res<<1))
+	case x86asm.RCL, x86asm.RCR:
+		res = x
+		carry := cf.set
+		for i := uint64(0); i < c%(nbits+1); i++ {
+			if inst.Op == x86asm.RCL {
+				out := msb(res)
+				res = res << 1 & m
+				if carry {
+					res |= 1
+				}
+				carry = out
+			} else {
+				out := res&1 != 0
+				res >>= 1
+				if carry {
+					res |= signBit(size)
+				}
+				carry = out
+			}
+		}
+		e.setFlag(flagCF, carry)
+		if inst.Op == x86asm.RCL {
+			e.setShiftOF(c, msb(res) != carry)
+		} else {
+			e.setShiftOF(c, msb(res) != msb(res<<1))
+		}
+	}
+	return e.write(inst, dst, next, known(res))
+}
+
+// setShiftOF sets the overflow flag of shift and rotate instructions, which is
+// only defined for 1-bit shifts.
+func (e *Emulator) setShiftOF(count uint64, of bool) {
+	if count != 1 {
+		e.flags[flagOF] = flag{}
+		return
+	}
+	e.setFlag(flagOF, of)
+}
+
+// execIMUL executes the given two- or three-operand IMUL instruction. Results
+// of the one-operand form are not tracked.
+func (e *Emulator) execIMUL(inst *Inst, next bin.Address) error {
+	e.clobberFlags()
+	if len(inst.Args) < 2 || inst.Args[1] == nil {
+		size := argSize(inst, inst.Args[0])
+		lo, hi := addrReg(0, 8*size), addrReg(2, 8*size)
+		switch size {
+		case 1:
+			lo, hi = x86asm.AX, x86asm.AX
+		case 2:
+			lo, hi = x86asm.AX, x86asm.DX
+		}
+		e.setReg(lo, unknown)
+		return e.setReg(hi, unknown)
+	}
+	dst := inst.Args[0]
+	a, b := dst, inst.Args[1]
+	if len(inst.Args) > 2 && inst.Args[2] != nil {
+		a, b = inst.Args[1], inst.Args[2]
+	}
+	x, err := e.read(inst, a, next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	y, err := e.read(inst, b, next)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !x.known || !y.known {
+		return e.write(inst, dst, next, unknown)
+	}
+	m := mask(argSize(inst, dst))
+	return e.write(inst, dst, next, known(x.v*y.v&m))
+}
+
+// execLOOP executes the given LOOP, LOOPE or LOOPNE instruction.
+func (e *Emulator) execLOOP(inst *Inst, next bin.Address) error {
+	reg := x86asm.ECX
+	switch inst.AddrSize {
+	case 16:
+		reg = x86asm.CX
+	case 64:
+		reg = x86asm.RCX
+	}
+	v, err := e.getReg(reg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !v.known {
+		return errors.New("unknown loop counter")
+	}
+	v.v = (v.v - 1) & mask(regSize(reg))
+	e.setReg(reg, v)
+	taken := v.v != 0
+	if inst.Op != x86asm.LOOP {
+		zf := e.flags[flagZF]
+		if !zf.known {
+			return errors.New("unknown branch condition")
+		}
+		taken = taken && zf.set == (inst.Op == x86asm.LOOPE)
+	}
+	if taken {
+		e.PC = next + bin.Address(inst.Args[0].(x86asm.Rel))
+	}
+	return nil
+}
+
+// execString executes the given string instruction, with optional REP, REPE or
+// REPNE prefix.
+func (e *Emulator) execString(inst *Inst) error {
+	var size int
+	switch inst.Op {
+	case x86asm.MOVSB, x86asm.STOSB, x86asm.LODSB, x86asm.SCASB, x86asm.CMPSB:
+		size = 1
+	case x86asm.MOVSW, x86asm.STOSW, x86asm.LODSW, x86asm.SCASW, x86asm.CMPSW:
+		size = 2
+	case x86asm.MOVSD, x86asm.STOSD, x86asm.LODSD, x86asm.SCASD, x86asm.CMPSD:
+		size = 4
+	default:
+		size = 8
+	}
+	addrSize := inst.AddrSize
+	if addrSize == 0 {
+		addrSize = e.Mode
+	}
+	si, di, cx := addrReg(6, addrSize), addrReg(7, addrSize), addrReg(1, addrSize)
+	acc := addrReg(0, 64)
+	switch size {
+	case 1:
+		acc = x86asm.AL
+	case 2:
+		acc = x86asm.AX
+	case 4:
+		acc = x86asm.EAX
+	}
+	var rep, repne bool
+	for _, p := range inst.Prefix {
+		if p == 0 {
+			break
+		}
+		if p&x86asm.PrefixIgnored != 0 {
+			continue
+		}
+		switch p &^ x86asm.PrefixImplicit {
+		case x86asm.PrefixREP:
+			rep = true
+		case x86asm.PrefixREPN:
+			rep, repne = true, true
+		}
+	}
+	df := e.flags[flagDF]
+	if !df.known {
+		return errors.New("unknown direction flag")
+	}
+	delta := uint64(size)
+	if df.set {
+		delta = -delta
+	}
+	// ptr returns the address held by the given index register.
+	ptr := func(reg x86asm.Reg) (bin.Address, error) {
+		v, err := e.getReg(reg)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		if !v.known {
+			return 0, errors.Errorf("unknown index register %v of string instruction", reg)
+		}
+		return bin.Address(v.v), nil
+	}
+	// advance advances the given index register.
+	advance := func(reg x86asm.Reg) {
+		v, _ := e.getReg(reg)
+		v.v = (v.v + delta) & mask(addrSize/8)
+		e.setReg(reg, v)
+	}
+	for {
+		if rep {
+			count, err := e.getReg(cx)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if !count.known {
+				return errors.New("unknown repeat count")
+			}
+			if count.v == 0 {
+				return nil
+			}
+			count.v--
+			e.setReg(cx, count)
+			e.Steps++
+		}
+		var cmp bool
+		switch inst.Op {
+		case x86asm.MOVSB, x86asm.MOVSW, x86asm.MOVSD, x86asm.MOVSQ:
+			src, err := ptr(si)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			dst, err := ptr(di)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			e.poke(dst, size, e.peek(src, size))
+			advance(si)
+			advance(di)
+		case x86asm.STOSB, x86asm.STOSW, x86asm.STOSD, x86asm.STOSQ:
+			dst, err := ptr(di)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			v, _ := e.getReg(acc)
+			e.poke(dst, size, v)
+			advance(di)
+		case x86asm.LODSB, x86asm.LODSW, x86asm.LODSD, x86asm.LODSQ:
+			src, err := ptr(si)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			e.setReg(acc, e.peek(src, size))
+			advance(si)
+		case x86asm.SCASB, x86asm.SCASW, x86asm.SCASD, x86asm.SCASQ:
+			dst, err := ptr(di)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			a, _ := e.getReg(acc)
+			e.compare(a, e.peek(dst, size), size)
+			advance(di)
+			cmp = true
+		case x86asm.CMPSB, x86asm.CMPSW, x86asm.CMPSD, x86asm.CMPSQ:
+			src, err := ptr(si)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			dst, err := ptr(di)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			e.compare(e.peek(src, size), e.peek(dst, size), size)
+			advance(si)
+			advance(di)
+			cmp = true
+		}
+		if !rep {
+			return nil
+		}
+		if cmp {
+			zf := e.flags[flagZF]
+			if !zf.known {
+				return errors.New("unknown repeat condition")
+			}
+			if zf.set == repne {
+				return nil
+			}
+		}
+	}
+}
+
+// compare sets the status flags based on the comparison of the given values of
+// the specified size in bytes; as computed by `cmp a, b`.
+func (e *Emulator) compare(a, b value, size int) {
+	if !a.known || !b.known {
+		e.clobberFlags()
+		return
+	}
+	m := mask(size)
+	x, y := a.v&m, b.v&m
+	res := (x - y) & m
+	e.setFlag(flagCF, x < y)
+	e.setFlag(flagOF, (x^y)&(x^res)&signBit(size) != 0)
+	e.setResultFlags(res, size)
+}
+
+// ### [ Flags ] ###############################################################
+
+// setFlag sets the value of the given status flag.
+func (e *Emulator) setFlag(f int, set bool) {
+	e.flags[f] = flag{set: set, known: true}
+}
+
+// setResultFlags sets the zero, sign and parity flags based on the given result
+// of the specified size in bytes.
+func (e *Emulator) setResultFlags(res uint64, size int) {
+	e.setFlag(flagZF, res&mask(size) == 0)
+	e.setFlag(flagSF, res&signBit(size) != 0)
+	e.setFlag(flagPF, bits.OnesCount8(uint8(res))%2 == 0)
+}
+
+// clobberFlags marks the arithmetic status flags as unknown.
+func (e *Emulator) clobberFlags() {
+	for _, f := range []int{flagCF, flagPF, flagZF, flagSF, flagOF} {
+		e.flags[f] = flag{}
+	}
+}
+
+// cond evaluates the condition of the given conditional jump, SETcc or CMOVcc
+// instruction. The boolean return value indicates whether the condition is
+// known.
+func (e *Emulator) cond(op x86asm.Op) (cond, ok bool) {
+	cf, zf, sf, of, pf := e.flags[flagCF], e.flags[flagZF], e.flags[flagSF], e.flags[flagOF], e.flags[flagPF]
+	switch op {
+	case x86asm.JA, x86asm.SETA, x86asm.CMOVA:
+		// Decided by a set CF or ZF alone.
+		if (cf.known && cf.set) || (zf.known && zf.set) {
+			return false, true
+		}
+		return true, cf.known && zf.known
+	case x86asm.JAE, x86asm.SETAE, x86asm.CMOVAE:
+		return !cf.set, cf.known
+	case x86asm.JB, x86asm.SETB, x86asm.CMOVB:
+		return cf.set, cf.known
+	case x86asm.JBE, x86asm.SETBE, x86asm.CMOVBE:
+		if (cf.known && cf.set) || (zf.known && zf.set) {
+			return true, true
+		}
+		return false, cf.known && zf.known
+	case x86asm.JE, x86asm.SETE, x86asm.CMOVE:
+		return zf.set, zf.known
+	case x86asm.JNE, x86asm.SETNE, x86asm.CMOVNE:
+		return !zf.set, zf.known
+	case x86asm.JG, x86asm.SETG, x86asm.CMOVG:
+		return !zf.set && sf.set == of.set, zf.known && sf.known && of.known
+	case x86asm.JGE, x86asm.SETGE, x86asm.CMOVGE:
+		return sf.set == of.set, sf.known && of.known
+	case x86asm.JL, x86asm.SETL, x86asm.CMOVL:
+		return sf.set != of.set, sf.known && of.known
+	case x86asm.JLE, x86asm.SETLE, x86asm.CMOVLE:
+		return zf.set || sf.set != of.set, zf.known && sf.known && of.known
+	case x86asm.JO, x86asm.SETO, x86asm.CMOVO:
+		return of.set, of.known
+	case x86asm.JNO, x86asm.SETNO, x86asm.CMOVNO:
+		return !of.set, of.known
+	case x86asm.JS, x86asm.SETS, x86asm.CMOVS:
+		return sf.set, sf.known
+	case x86asm.JNS, x86asm.SETNS, x86asm.CMOVNS:
+		return !sf.set, sf.known
+	case x86asm.JP, x86asm.SETP, x86asm.CMOVP:
+		return pf.set, pf.known
+	case x86asm.JNP, x86asm.SETNP, x86asm.CMOVNP:
+		return !pf.set, pf.known
+	}
+	return false, false
+}
@@ -0,0 +1,306 @@
+package x86_test
+
+import (
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+func TestEmulatorRun(t *testing.T) {
+	golden := []struct {
+		// Machine code, located at 0x401000 and executed until its end.
+		code []byte
+		// Processor mode.
+		mode int
+		// Expected register values after emulation.
+		regs map[x86asm.Reg]uint64
+		// Registers expected to hold unknown values after emulation.
+		unknown []x86asm.Reg
+		// Expected memory contents of .data (located at 0x402000) after
+		// emulation.
+		data []byte
+		// Specifies whether emulation is expected to fail.
+		err bool
+	}{
+		// mov eax, 5
+		// add eax, 3
+		{
+			code: []byte{0xB8, 0x05, 0x00, 0x00, 0x00, 0x83, 0xC0, 0x03},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.EAX: 8},
+		},
+		// xor eax, eax
+		// dec eax
+		{
+			code: []byte{0x31, 0xC0, 0x48},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.EAX: 0xFFFFFFFF},
+		},
+		// mov ecx, 0x10
+		// shl ecx, 4
+		{
+			code: []byte{0xB9, 0x10, 0x00, 0x00, 0x00, 0xC1, 0xE1, 0x04},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.ECX: 0x100},
+		},
+		// mov eax, 0x44332211
+		// bswap eax
+		{
+			code: []byte{0xB8, 0x11, 0x22, 0x33, 0x44, 0x0F, 0xC8},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.EAX: 0x11223344},
+		},
+		// mov eax, 0x80000000
+		// cdq
+		{
+			code: []byte{0xB8, 0x00, 0x00, 0x00, 0x80, 0x99},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.EDX: 0xFFFFFFFF},
+		},
+		// mov eax, 6
+		// mov ecx, 7
+		// imul eax, ecx
+		{
+			code: []byte{0xB8, 0x06, 0x00, 0x00, 0x00, 0xB9, 0x07, 0x00, 0x00, 0x00, 0x0F, 0xAF, 0xC1},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.EAX: 42},
+		},
+		// Results of MUL are not tracked.
+		//
+		// mov eax, 0x10000
+		// mov ecx, 0x10000
+		// mul ecx
+		{
+			code:    []byte{0xB8, 0x00, 0x00, 0x01, 0x00, 0xB9, 0x00, 0x00, 0x01, 0x00, 0xF7, 0xE1},
+			mode:    32,
+			regs:    map[x86asm.Reg]uint64{x86asm.ECX: 0x10000},
+			unknown: []x86asm.Reg{x86asm.EAX, x86asm.EDX},
+		},
+		// Partially known register.
+		//
+		// mov al, 0xFF
+		// movsx ebx, al
+		{
+			code:    []byte{0xB0, 0xFF, 0x0F, 0xBE, 0xD8},
+			mode:    32,
+			regs:    map[x86asm.Reg]uint64{x86asm.AL: 0xFF, x86asm.EBX: 0xFFFFFFFF},
+			unknown: []x86asm.Reg{x86asm.EAX},
+		},
+		// Conditional branch.
+		//
+		//    mov eax, 1
+		//    cmp eax, 2
+		//    jb  end
+		//    mov eax, 7
+		// end:
+		{
+			code: []byte{0xB8, 0x01, 0x00, 0x00, 0x00, 0x83, 0xF8, 0x02, 0x72, 0x05, 0xB8, 0x07, 0x00, 0x00, 0x00},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.EAX: 1},
+		},
+		// Status flags.
+		//
+		// xor eax, eax
+		// sete al
+		{
+			code: []byte{0x31, 0xC0, 0x0F, 0x94, 0xC0},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.EAX: 1},
+		},
+		// Loop.
+		//
+		//    mov ecx, 3
+		//    xor eax, eax
+		// loop:
+		//    inc eax
+		//    loop loop
+		{
+			code: []byte{0xB9, 0x03, 0x00, 0x00, 0x00, 0x31, 0xC0, 0x40, 0xE2, 0xFD},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.EAX: 3, x86asm.ECX: 0},
+		},
+		// Stack.
+		//
+		// push 0x2A
+		// pop ecx
+		{
+			code: []byte{0x6A, 0x2A, 0x59},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.ECX: 0x2A, x86asm.ESP: 0x7FFE0000},
+		},
+		// String instructions.
+		//
+		// mov edi, 0x402000
+		// mov ecx, 4
+		// mov al, 0xAA
+		// rep stosb
+		{
+			code: []byte{0xBF, 0x00, 0x20, 0x40, 0x00, 0xB9, 0x04, 0x00, 0x00, 0x00, 0xB0, 0xAA, 0xF3, 0xAA},
+			mode: 32,
+			regs: map[x86asm.Reg]uint64{x86asm.ECX: 0, x86asm.EDI: 0x402004},
+			data: []byte{0xAA, 0xAA, 0xAA, 0xAA},
+		},
+		// Memory store.
+		//
+		// mov dword [0x402000], 0x12345678
+		{
+			code: []byte{0xC7, 0x05, 0x00, 0x20, 0x40, 0x00, 0x78, 0x56, 0x34, 0x12},
+			mode: 32,
+			data: []byte{0x78, 0x56, 0x34, 0x12},
+		},
+		// 32-bit operands zero-extend to 64 bits.
+		//
+		// mov rax, -1
+		// mov eax, eax
+		{
+			code: []byte{0x48, 0xC7, 0xC0, 0xFF, 0xFF, 0xFF, 0xFF, 0x89, 0xC0},
+			mode: 64,
+			regs: map[x86asm.Reg]uint64{x86asm.RAX: 0xFFFFFFFF},
+		},
+		// RIP-relative load.
+		//
+		// mov rax, [rel 0x402000]
+		{
+			code: []byte{0x48, 0x8B, 0x05, 0xF9, 0x0F, 0x00, 0x00},
+			mode: 64,
+			regs: map[x86asm.Reg]uint64{x86asm.RAX: 0},
+		},
+		// Unknown jump target.
+		//
+		// jmp eax
+		{
+			code: []byte{0xFF, 0xE0},
+			mode: 32,
+			err:  true,
+		},
+		// Unknown memory address.
+		//
+		// mov eax, [ecx]
+		{
+			code: []byte{0x8B, 0x01},
+			mode: 32,
+			err:  true,
+		},
+	}
+	for i, g := range golden {
+		file := &bin.File{
+			Sections: []*bin.Section{
+				{Name: ".text", Addr: 0x401000, Data: g.code, MemSize: len(g.code), Perm: bin.PermR | bin.PermX},
+				{Name: ".data", Addr: 0x402000, Data: make([]byte, 8), MemSize: 8, Perm: bin.PermR | bin.PermW},
+			},
+		}
+		e := x86.NewEmulator(file, g.mode)
+		err := e.Run(0x401000, 0x401000+bin.Address(len(g.code)), 100)
+		if g.err {
+			if err == nil {
+				t.Errorf("%d: expected error, got nil", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unable to emulate; %+v", i, err)
+			continue
+		}
+		for reg, want := range g.regs {
+			got, ok := e.Reg(reg)
+			if !ok {
+				t.Errorf("%d: unknown value of %v", i, reg)
+				continue
+			}
+			if got != want {
+				t.Errorf("%d: %v mismatch; expected 0x%X, got 0x%X", i, reg, want, got)
+			}
+		}
+		for _, reg := range g.unknown {
+			if got, ok := e.Reg(reg); ok {
+				t.Errorf("%d: expected unknown value of %v, got 0x%X", i, reg, got)
+			}
+		}
+		for j, want := range g.data {
+			addr := bin.Address(0x402000 + j)
+			got, ok := e.Mem(addr, 1)
+			if !ok {
+				t.Errorf("%d: unknown memory contents at %v", i, addr)
+				continue
+			}
+			if got != uint64(want) {
+				t.Errorf("%d: memory mismatch at %v; expected 0x%02X, got 0x%02X", i, addr, want, got)
+			}
+		}
+	}
+}
+
+func TestEmulatorUnpack(t *testing.T) {
+	// Unpacking stub, which writes a RET instruction to 0x401010 and jumps to
+	// it.
+	//
+	//    mov byte [0x401010], 0xC3
+	//    jmp 0x401010
+	code := []byte{0xC6, 0x05, 0x10, 0x10, 0x40, 0x00, 0xC3, 0xEB, 0x07}
+	file := &bin.File{
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Data: code, MemSize: 0x20, Perm: bin.PermR | bin.PermW | bin.PermX},
+		},
+	}
+	e := x86.NewEmulator(file, 32)
+	oep, err := e.Unpack(0x401000, 100)
+	if err != nil {
+		t.Fatalf("unable to unpack; %+v", err)
+	}
+	if want := bin.Address(0x401010); oep != want {
+		t.Errorf("original entry point mismatch; expected %v, got %v", want, oep)
+	}
+	writes := e.Writes()
+	if len(writes) != 1 {
+		t.Fatalf("number of writes mismatch; expected 1, got %d", len(writes))
+	}
+	if buf := writes[0x401010]; len(buf) != 1 || buf[0] != 0xC3 {
+		t.Errorf("write mismatch at %v; expected [C3], got % X", bin.Address(0x401010), buf)
+	}
+}
+
+func TestEmulatorImports(t *testing.T) {
+	//    push dll_name
+	//    call [LoadLibraryA]
+	//    push proc_name
+	//    push eax
+	//    call [GetProcAddress]
+	//    mov  [0x402028], eax
+	code := []byte{
+		0x68, 0x00, 0x20, 0x40, 0x00,
+		0xFF, 0x15, 0x24, 0x20, 0x40, 0x00,
+		0x68, 0x10, 0x20, 0x40, 0x00,
+		0x50,
+		0xFF, 0x15, 0x20, 0x20, 0x40, 0x00,
+		0xA3, 0x28, 0x20, 0x40, 0x00,
+	}
+	data := make([]byte, 0x2C)
+	copy(data[0x00:], "kernel32.dll\x00")
+	copy(data[0x10:], "Sleep\x00")
+	file := &bin.File{
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Data: code, MemSize: len(code), Perm: bin.PermR | bin.PermX},
+			{Name: ".data", Addr: 0x402000, Data: data, MemSize: len(data), Perm: bin.PermR | bin.PermW},
+		},
+		Imports: map[bin.Address]string{
+			0x402020: "GetProcAddress",
+			0x402024: "LoadLibraryA",
+		},
+	}
+	e := x86.NewEmulator(file, 32)
+	if err := e.Run(0x401000, 0x401000+bin.Address(len(code)), 100); err != nil {
+		t.Fatalf("unable to emulate; %+v", err)
+	}
+	if got, ok := e.Reg(x86asm.ESP); !ok || got != 0x7FFE0000 {
+		t.Errorf("stack pointer mismatch; expected 0x7FFE0000, got 0x%X", got)
+	}
+	imports := e.Imports()
+	if len(imports) != 1 {
+		t.Errorf("number of dynamic imports mismatch; expected 1, got %d", len(imports))
+	}
+	if got, want := imports[0x402028], "Sleep"; got != want {
+		t.Errorf("dynamic import mismatch at %v; expected %q, got %q", bin.Address(0x402028), want, got)
+	}
+}
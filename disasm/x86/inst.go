@@ -26,10 +26,10 @@ func (term *Inst) isTerm() bool {
 	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS:
 		return true
 	// Unconditional jump terminators.
-	case x86asm.JMP:
+	case x86asm.JMP, x86asm.LJMP:
 		return true
 	// Return terminators.
-	case x86asm.RET:
+	case x86asm.RET, x86asm.LRET, x86asm.IRET:
 		return true
 	}
 	return false
@@ -62,10 +62,30 @@ func (dis *Disasm) Targets(term *Inst, funcEntry bin.Address) []bin.Address {
 	// Conditional jump terminators.
 	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS:
 		targets := dis.Addrs(term.Args[0], term.Addr, next)
-		return append(targets, next)
+		targets = append(targets, next)
+		if len(term.Infeasible) == 0 {
+			return targets
+		}
+		// Skip infeasible edges, as determined by symbolic execution.
+		var feasible []bin.Address
+		for _, target := range targets {
+			if !containsAddr(term.Infeasible, target) {
+				feasible = append(feasible, target)
+			}
+		}
+		return feasible
 	// Unconditional jump terminators.
 	case x86asm.JMP:
-		preTargets := dis.Addrs(term.Args[0], term.Addr, next)
+		var preTargets []bin.Address
+		if term.EmuTarget != 0 {
+			// Indirect jump resolved by emulation.
+			preTargets = []bin.Address{term.EmuTarget}
+		} else if len(term.SymTargets) > 0 {
+			// Indirect jump resolved by symbolic execution.
+			preTargets = term.SymTargets
+		} else {
+			preTargets = dis.Addrs(term.Args[0], term.Addr, next)
+		}
 		var targets []bin.Address
 		for _, target := range preTargets {
 			if dis.isTailCall(funcEntry, target) {
@@ -76,14 +96,103 @@ func (dis *Disasm) Targets(term *Inst, funcEntry bin.Address) []bin.Address {
 			}
 		}
 		return targets
+	// Far jump terminators of 16-bit real mode.
+	case x86asm.LJMP:
+		target, ok := FarTarget(term)
+		if !ok {
+			warn.Printf("ignoring indirect targets of far jump at %v", term.Addr)
+			return nil
+		}
+		if dis.isTailCall(funcEntry, target) {
+			dbg.Printf("tail call at %v", term.Addr)
+			return nil
+		}
+		return []bin.Address{target}
 	// Return terminators.
-	case x86asm.RET:
+	case x86asm.RET, x86asm.LRET, x86asm.IRET:
+		// no targets.
+		return nil
+	// Calls to noreturn functions.
+	case x86asm.CALL, x86asm.LCALL:
+		// no targets.
+		return nil
+	// Software interrupts terminating the program (e.g. INT 21h/AH=4Ch), and
+	// traps (e.g. INT3 or INT 29h).
+	case x86asm.INT:
+		// no targets.
+		return nil
+	// Traps raising exceptions (e.g. UD2).
+	case x86asm.UD1, x86asm.UD2, x86asm.HLT:
 		// no targets.
 		return nil
 	}
 	panic(fmt.Errorf("support for terminator instruction %v not yet implemented", term.Op))
 }
 
+// isCondJump reports whether the given operation is a conditional jump.
+func isCondJump(op x86asm.Op) bool {
+	switch op {
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS:
+		return true
+	}
+	return false
+}
+
+// isNoReturnCall reports whether the given instruction is a call to a function
+// which does not return to its caller. Such calls terminate basic blocks.
+func (dis *Disasm) isNoReturnCall(inst *Inst) bool {
+	target, ok := CallTarget(inst)
+	if !ok {
+		return false
+	}
+	return dis.NoReturn[target]
+}
+
+// CallTarget returns the static target address of the given CALL instruction;
+// i.e. the callee of direct calls, or the address of the function pointer of
+// indirect calls through absolute or RIP-relative memory references (e.g. the
+// import address table). The boolean return value indicates success.
+func CallTarget(inst *Inst) (bin.Address, bool) {
+	if inst.Op == x86asm.LCALL {
+		return FarTarget(inst)
+	}
+	if inst.Op != x86asm.CALL {
+		return 0, false
+	}
+	next := inst.Addr + bin.Address(inst.Len)
+	switch arg := inst.Args[0].(type) {
+	case x86asm.Rel:
+		return next + bin.Address(arg), true
+	case x86asm.Mem:
+		switch {
+		case arg.Segment == 0 && arg.Base == 0 && arg.Index == 0:
+			return bin.Address(arg.Disp), true
+		case arg.Base == x86asm.RIP && arg.Index == 0:
+			return next + bin.Address(arg.Disp), true
+		}
+	}
+	return 0, false
+}
+
+// FarTarget returns the static target address of the given far CALL or JMP
+// instruction of 16-bit real mode with an immediate segment and offset (e.g.
+// `call 0x1234:0x0010`); i.e. the linear address of the target. The boolean
+// return value indicates success.
+func FarTarget(inst *Inst) (bin.Address, bool) {
+	if inst.Op != x86asm.LCALL && inst.Op != x86asm.LJMP {
+		return 0, false
+	}
+	seg, ok := inst.Args[0].(x86asm.Imm)
+	if !ok {
+		return 0, false
+	}
+	off, ok := inst.Args[1].(x86asm.Imm)
+	if !ok {
+		return 0, false
+	}
+	return bin.LinearAddr(uint16(seg), uint16(off)), true
+}
+
 // isTailCall reports whether the given JMP instruction is a tail call
 // instruction.
 func (dis *Disasm) isTailCall(funcEntry bin.Address, target bin.Address) bool {
@@ -106,8 +215,15 @@ func (dis *Disasm) isTailCall(funcEntry bin.Address, target bin.Address) bool {
 }
 
 // funcEnd returns the end address of the function, under the assumption that
-// the function is continuous.
+// the function is continuous. Function bounds of exception handling metadata,
+// and of function discovery, take precedence, if present.
 func (dis *Disasm) funcEnd(funcEntry bin.Address) bin.Address {
+	if end, ok := dis.File.FuncBounds[funcEntry]; ok {
+		return end
+	}
+	if end, ok := dis.bounds[funcEntry]; ok {
+		return end
+	}
 	less := func(i int) bool {
 		return funcEntry < dis.FuncAddrs[i]
 	}
@@ -0,0 +1,1006 @@
+package x86
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Limits of symbolic execution.
+const (
+	// maxSymSteps specifies the maximum number of instructions to execute
+	// symbolically per function.
+	maxSymSteps = 200000
+	// maxSymPaths specifies the maximum number of paths to explore per
+	// function.
+	maxSymPaths = 4096
+	// symLoopBound specifies the number of times a basic block is executed per
+	// path, before the state of the path is widened.
+	symLoopBound = 2
+)
+
+// A SymResult records the results of symbolic execution of a function.
+type SymResult struct {
+	// Targets of indirect jumps, indexed by the address of the jump. Only jumps
+	// the targets of which were resolved on every path reaching the jump are
+	// recorded.
+	Targets map[bin.Address][]bin.Address
+	// Feasible targets of branches, indexed by the address of the branch.
+	Feasible map[bin.Address]map[bin.Address]bool
+	// Complete specifies whether every path of the function was explored. Edges
+	// of branches are only known to be infeasible if the exploration is
+	// complete.
+	Complete bool
+}
+
+// SymExec symbolically executes the basic blocks of the given function, to
+// compute the possible targets of indirect jumps and the feasible edges of
+// conditional branches.
+//
+// Values are modelled as linear expressions of symbolic variables, constrained
+// along each path by the conditions of branches taken; e.g. the bounds check
+// of a switch statement constrains the index of its jump table. Memory not
+// written along a path is assumed to hold the contents of the executable, calls
+// are assumed to preserve callee-saved registers, and loops are explored a
+// bounded number of times, after which the state of the path is widened.
+func (dis *Disasm) SymExec(f *Func) *SymResult {
+	s := &symExec{
+		dis:        dis,
+		f:          f,
+		truncs:     make(map[string]int),
+		widened:    make(map[bin.Address]bool),
+		unresolved: make(map[bin.Address]bool),
+		res: &SymResult{
+			Targets:  make(map[bin.Address][]bin.Address),
+			Feasible: make(map[bin.Address]map[bin.Address]bool),
+			Complete: true,
+		},
+	}
+	work := []*symState{s.top(f.Addr)}
+	paths := 1
+	for len(work) > 0 {
+		st := work[len(work)-1]
+		work = work[:len(work)-1]
+		if s.steps >= maxSymSteps || paths >= maxSymPaths {
+			dbg.Printf("symbolic execution of function at %v exceeded limits", f.Addr)
+			s.res.Complete = false
+			break
+		}
+		block, ok := f.Blocks[st.addr]
+		if !ok {
+			continue
+		}
+		if st.visits[block.Addr] >= symLoopBound {
+			// Widen the state of the path, and explore the basic block once more
+			// from an unconstrained state, which covers the states of all
+			// subsequent iterations.
+			if s.widened[block.Addr] {
+				continue
+			}
+			s.widened[block.Addr] = true
+			st = s.top(block.Addr)
+		}
+		st.visits[block.Addr]++
+		for _, inst := range block.Insts {
+			s.exec(st, inst)
+			s.steps++
+		}
+		succs := s.term(st, block.Term)
+		if len(succs) > 1 {
+			paths += len(succs) - 1
+		}
+		work = append(work, succs...)
+	}
+	for addr := range s.unresolved {
+		delete(s.res.Targets, addr)
+	}
+	return s.res
+}
+
+// symExec tracks information required for symbolic execution of a function.
+type symExec struct {
+	// Disassembler.
+	dis *Disasm
+	// Function being executed.
+	f *Func
+	// Symbolic variables, indexed by variable ID.
+	vars []*symVar
+	// Variables of truncated expressions, indexed by expression key and bit
+	// size.
+	truncs map[string]int
+	// Basic blocks explored from a widened state.
+	widened map[bin.Address]bool
+	// Indirect jumps the targets of which were not resolved on some path.
+	unresolved map[bin.Address]bool
+	// Number of executed instructions.
+	steps int
+	// Results of symbolic execution.
+	res *SymResult
+}
+
+// A symState is the symbolic state of a path.
+type symState struct {
+	// Address of the basic block to execute.
+	addr bin.Address
+	// Values of general purpose registers, indexed by register number.
+	regs [16]*symExpr
+	// Condition flags; or nil if unknown.
+	flags *symFlags
+	// Memory cells written or loaded along the path, indexed by cell key.
+	mem map[string]*symCell
+	// Path constraints.
+	cons []symCons
+	// Number of times each basic block has been executed along the path.
+	visits map[bin.Address]int
+}
+
+// clone returns a copy of the symbolic state.
+func (st *symState) clone() *symState {
+	c := &symState{
+		addr:   st.addr,
+		regs:   st.regs,
+		flags:  st.flags,
+		mem:    make(map[string]*symCell, len(st.mem)),
+		cons:   append([]symCons(nil), st.cons...),
+		visits: make(map[bin.Address]int, len(st.visits)),
+	}
+	for key, cell := range st.mem {
+		c.mem[key] = cell
+	}
+	for addr, n := range st.visits {
+		c.visits[addr] = n
+	}
+	return c
+}
+
+// A symCell is a memory cell.
+type symCell struct {
+	// Address of the memory cell.
+	addr *symExpr
+	// Size in bytes of the memory cell.
+	size int
+	// Contents of the memory cell.
+	val *symExpr
+}
+
+// cellKey returns the key of the memory cell at the given address and of the
+// specified size in bytes.
+func cellKey(addr *symExpr, size int) string {
+	return fmt.Sprintf("%s/%d", addr.key(), size)
+}
+
+// symFlagsKind specifies the operation which set the condition flags.
+type symFlagsKind uint8
+
+// Kinds of flag-setting operations.
+const (
+	// Subtraction a - b; e.g. CMP and SUB.
+	symFlagsSub symFlagsKind = iota
+	// Logical operation with result a, which clears CF and OF; e.g. TEST and
+	// AND.
+	symFlagsLogic
+	// Arithmetic operation with result a, the ZF and SF of which are known;
+	// e.g. INC and ADD.
+	symFlagsResult
+)
+
+// symFlags records the operation which set the condition flags.
+type symFlags struct {
+	// Kind of operation.
+	kind symFlagsKind
+	// Operands of the operation.
+	a, b *symExpr
+	// Bit size of the operands.
+	bits int
+}
+
+// ### [ Variables ] ###########################################################
+
+// newVar adds the given symbolic variable, and returns its expression.
+func (s *symExec) newVar(v *symVar) *symExpr {
+	s.vars = append(s.vars, v)
+	return symVarExpr(len(s.vars) - 1)
+}
+
+// fresh returns an unconstrained symbolic variable in the range [0, hi].
+func (s *symExec) fresh(name string, hi uint64) *symExpr {
+	return s.newVar(&symVar{name: name, hi: hi})
+}
+
+// trunc returns the given symbolic expression truncated to the specified bit
+// size. Non-linear truncations are represented by variables, the values of
+// which are derived from the truncated expression.
+func (s *symExec) trunc(x *symExpr, bits int) *symExpr {
+	if bits >= s.dis.Mode {
+		return symNorm(x, s.dis.Mode)
+	}
+	x = symNorm(x, bits)
+	if _, ok := x.isConst(); ok {
+		return x
+	}
+	if id, k, ok := x.single(); ok && k == 1 && x.c == 0 && s.vars[id].hi <= mask(bits/8) {
+		return x
+	}
+	key := fmt.Sprintf("%s/%d", x.key(), bits)
+	if id, ok := s.truncs[key]; ok {
+		return symVarExpr(id)
+	}
+	v := s.newVar(&symVar{name: "trunc", hi: mask(bits / 8), def: x, defBits: bits})
+	id, _, _ := v.single()
+	s.truncs[key] = id
+	return v
+}
+
+// sext returns the given symbolic expression truncated to the specified bit
+// size, and sign-extended to the bit size of the destination. Sign extensions
+// are represented by variables, the values of which are derived from the
+// extended expression.
+func (s *symExec) sext(x *symExpr, bits, dstBits int) *symExpr {
+	x = symNorm(x, bits)
+	if c, ok := x.isConst(); ok {
+		return symConst(signExtend(c, bits/8))
+	}
+	return s.newVar(&symVar{name: "sext", hi: mask(dstBits / 8), def: x, defBits: bits, sext: true})
+}
+
+// top returns an unconstrained symbolic state at the given address.
+func (s *symExec) top(addr bin.Address) *symState {
+	st := &symState{
+		addr:   addr,
+		mem:    make(map[string]*symCell),
+		visits: make(map[bin.Address]int),
+	}
+	for num := range st.regs {
+		st.regs[num] = s.fresh(s.gpr(num).String(), mask(s.dis.Mode/8))
+	}
+	st.regs[s.spNum()] = s.stackVar()
+	return st
+}
+
+// stackVar returns a symbolic variable of the stack pointer.
+func (s *symExec) stackVar() *symExpr {
+	return s.newVar(&symVar{name: "stack", hi: mask(s.dis.Mode / 8), stack: true})
+}
+
+// ### [ Registers ] ###########################################################
+
+// gpr returns the general purpose register of the processor mode, with the
+// given register number.
+func (s *symExec) gpr(num int) x86asm.Reg {
+	return addrReg(num, s.dis.Mode)
+}
+
+// spNum returns the register number of the stack pointer.
+func (s *symExec) spNum() int {
+	num, _, _, _ := regLoc(x86asm.ESP)
+	return num
+}
+
+// getReg returns the value of the given register.
+func (s *symExec) getReg(st *symState, reg x86asm.Reg) *symExpr {
+	num, shift, size, ok := regLoc(reg)
+	if !ok {
+		// E.g. segment registers.
+		return s.fresh(reg.String(), mask(s.dis.Mode/8))
+	}
+	full := st.regs[num]
+	if shift == 0 {
+		return s.trunc(full, 8*size)
+	}
+	// High byte registers.
+	if c, ok := full.isConst(); ok {
+		return symConst(c >> shift & 0xFF)
+	}
+	return s.fresh(reg.String(), 0xFF)
+}
+
+// setReg sets the value of the given register. Writes to 32-bit registers
+// zero-extend the value to the full register.
+func (s *symExec) setReg(st *symState, reg x86asm.Reg, x *symExpr) {
+	num, shift, size, ok := regLoc(reg)
+	if !ok {
+		return
+	}
+	if shift == 0 && size >= 4 {
+		st.regs[num] = s.trunc(x, 8*size)
+		return
+	}
+	// Partial register writes.
+	full, ok1 := st.regs[num].isConst()
+	v, ok2 := x.isConst()
+	if ok1 && ok2 {
+		m := mask(size) << shift
+		st.regs[num] = symConst(full&^m | (v&mask(size))<<shift)
+		return
+	}
+	st.regs[num] = s.fresh(s.gpr(num).String(), mask(s.dis.Mode/8))
+}
+
+// havocRegs sets the given registers to unconstrained values.
+func (s *symExec) havocRegs(st *symState, regs ...x86asm.Reg) {
+	for _, reg := range regs {
+		if num, _, _, ok := regLoc(reg); ok {
+			st.regs[num] = s.fresh(s.gpr(num).String(), mask(s.dis.Mode/8))
+		}
+	}
+}
+
+// ### [ Memory ] ##############################################################
+
+// Memory regions.
+const (
+	// Memory addressed relative to the stack pointer.
+	regionStack = iota
+	// Memory at constant addresses.
+	regionGlobal
+	// Other memory.
+	regionOther
+)
+
+// region returns the memory region of the given address.
+func (s *symExec) region(addr *symExpr) int {
+	if _, ok := addr.isConst(); ok {
+		return regionGlobal
+	}
+	if id, k, ok := addr.single(); ok && k == 1 && s.vars[id].stack {
+		return regionStack
+	}
+	return regionOther
+}
+
+// mayAlias reports whether the memory cells at the given addresses and of the
+// specified sizes in bytes may overlap.
+func (s *symExec) mayAlias(a *symExpr, asize int, b *symExpr, bsize int) bool {
+	if d, ok := symNorm(symSub(a, b), s.dis.Mode).isConst(); ok {
+		off := int64(signExtend(d, s.dis.Mode/8))
+		return off < int64(bsize) && -off < int64(asize)
+	}
+	ra, rb := s.region(a), s.region(b)
+	return !(ra == regionStack && rb == regionGlobal || ra == regionGlobal && rb == regionStack)
+}
+
+// load returns the contents of the memory cell at the given address and of the
+// specified size in bytes.
+func (s *symExec) load(st *symState, addr *symExpr, size int) *symExpr {
+	addr = symNorm(addr, s.dis.Mode)
+	key := cellKey(addr, size)
+	if cell, ok := st.mem[key]; ok {
+		return s.trunc(cell.val, 8*size)
+	}
+	if c, ok := addr.isConst(); ok && s.dis.File.Perm(bin.Address(c))&bin.PermW == 0 {
+		// Read-only memory.
+		if v, ok := s.readFile(c, size); ok {
+			return symConst(v)
+		}
+	}
+	x := s.newVar(&symVar{name: "load", hi: mask(size), load: addr, loadSize: size})
+	st.mem[key] = &symCell{addr: addr, size: size, val: x}
+	return x
+}
+
+// store stores the value to the memory cell at the given address and of the
+// specified size in bytes.
+func (s *symExec) store(st *symState, addr *symExpr, size int, x *symExpr) {
+	addr = symNorm(addr, s.dis.Mode)
+	for key, cell := range st.mem {
+		if s.mayAlias(cell.addr, cell.size, addr, size) {
+			delete(st.mem, key)
+		}
+	}
+	st.mem[cellKey(addr, size)] = &symCell{addr: addr, size: size, val: x}
+}
+
+// havocMem forgets the contents of memory.
+func (s *symExec) havocMem(st *symState) {
+	st.mem = make(map[string]*symCell)
+}
+
+// push pushes the value of the given size in bytes to the stack.
+func (s *symExec) push(st *symState, x *symExpr, size int) {
+	sp := s.gpr(s.spNum())
+	addr := symSub(s.getReg(st, sp), symConst(uint64(size)))
+	s.setReg(st, sp, addr)
+	s.store(st, addr, size, x)
+}
+
+// pop pops a value of the given size in bytes from the stack.
+func (s *symExec) pop(st *symState, size int) *symExpr {
+	sp := s.gpr(s.spNum())
+	addr := s.getReg(st, sp)
+	x := s.load(st, addr, size)
+	s.setReg(st, sp, symAdd(addr, symConst(uint64(size))))
+	return x
+}
+
+// ### [ Operands ] ############################################################
+
+// memAddr returns the address of the given memory reference. The boolean
+// return value indicates success.
+func (s *symExec) memAddr(st *symState, inst *Inst, mem x86asm.Mem) (*symExpr, bool) {
+	switch mem.Segment {
+	case 0, x86asm.CS, x86asm.DS, x86asm.ES, x86asm.SS:
+		// Flat memory model.
+	default:
+		return nil, false
+	}
+	addr := symConst(uint64(mem.Disp))
+	switch {
+	case mem.Base == x86asm.RIP:
+		addr.c += uint64(inst.Addr) + uint64(inst.Len)
+	case mem.Base != 0:
+		addr = symAdd(addr, s.getReg(st, mem.Base))
+	}
+	if mem.Index != 0 {
+		addr = symAdd(addr, symMul(s.getReg(st, mem.Index), uint64(mem.Scale)))
+	}
+	addrSize := inst.AddrSize
+	if addrSize == 0 {
+		addrSize = s.dis.Mode
+	}
+	return symNorm(addr, addrSize), true
+}
+
+// read returns the value of the given instruction argument.
+func (s *symExec) read(st *symState, inst *Inst, arg x86asm.Arg) *symExpr {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		return s.getReg(st, arg)
+	case x86asm.Mem:
+		if addr, ok := s.memAddr(st, inst, arg); ok {
+			return s.load(st, addr, inst.MemBytes)
+		}
+		return s.fresh("mem", mask(inst.MemBytes))
+	case x86asm.Imm:
+		return symConst(uint64(arg))
+	case x86asm.Rel:
+		return symConst(uint64(inst.Addr) + uint64(inst.Len) + uint64(arg))
+	}
+	return s.fresh("arg", mask(s.dis.Mode/8))
+}
+
+// write stores the value to the given instruction argument.
+func (s *symExec) write(st *symState, inst *Inst, arg x86asm.Arg, x *symExpr) {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		s.setReg(st, arg, x)
+	case x86asm.Mem:
+		if addr, ok := s.memAddr(st, inst, arg); ok {
+			s.store(st, addr, inst.MemBytes, x)
+			return
+		}
+		s.havocMem(st)
+	}
+}
+
+// ### [ Instructions ] ########################################################
+
+// symImplicit specifies the registers implicitly written by instructions, the
+// semantics of which are not modelled.
+var symImplicit = map[x86asm.Op][]x86asm.Reg{
+	x86asm.CBW:       {x86asm.EAX},
+	x86asm.CWDE:      {x86asm.EAX},
+	x86asm.CDQE:      {x86asm.EAX},
+	x86asm.CWD:       {x86asm.EDX},
+	x86asm.CDQ:       {x86asm.EDX},
+	x86asm.CQO:       {x86asm.EDX},
+	x86asm.MUL:       {x86asm.EAX, x86asm.EDX},
+	x86asm.DIV:       {x86asm.EAX, x86asm.EDX},
+	x86asm.IDIV:      {x86asm.EAX, x86asm.EDX},
+	x86asm.CPUID:     {x86asm.EAX, x86asm.EBX, x86asm.ECX, x86asm.EDX},
+	x86asm.RDTSC:     {x86asm.EAX, x86asm.EDX},
+	x86asm.RDTSCP:    {x86asm.EAX, x86asm.ECX, x86asm.EDX},
+	x86asm.LAHF:      {x86asm.EAX},
+	x86asm.XLATB:     {x86asm.EAX},
+	x86asm.CMPXCHG:   {x86asm.EAX},
+	x86asm.CMPXCHG8B: {x86asm.EAX, x86asm.EDX},
+	x86asm.FNSTSW:    {x86asm.EAX},
+}
+
+// exec executes the given instruction symbolically.
+func (s *symExec) exec(st *symState, inst *Inst) {
+	args := inst.Args
+	bits := 8 * argSize(inst, args[0])
+	switch inst.Op {
+	case x86asm.NOP, x86asm.CLD, x86asm.STD, x86asm.FNOP, x86asm.PREFETCHT0, x86asm.PREFETCHT1, x86asm.PREFETCHT2, x86asm.PREFETCHNTA:
+		// no effect.
+	case x86asm.CLC, x86asm.STC, x86asm.CMC, x86asm.SAHF:
+		st.flags = nil
+	case x86asm.MOV:
+		s.write(st, inst, args[0], s.read(st, inst, args[1]))
+	case x86asm.MOVZX:
+		x := s.read(st, inst, args[1])
+		s.write(st, inst, args[0], s.trunc(x, 8*argSize(inst, args[1])))
+	case x86asm.MOVSX, x86asm.MOVSXD:
+		size := argSize(inst, args[1])
+		x := s.read(st, inst, args[1])
+		s.write(st, inst, args[0], s.sext(x, 8*size, bits))
+	case x86asm.LEA:
+		mem, ok := args[1].(x86asm.Mem)
+		addr, ok2 := s.memAddr(st, inst, mem)
+		if !ok || !ok2 {
+			addr = s.fresh("lea", mask(bits/8))
+		}
+		s.write(st, inst, args[0], addr)
+	case x86asm.XCHG:
+		a, b := s.read(st, inst, args[0]), s.read(st, inst, args[1])
+		s.write(st, inst, args[0], b)
+		s.write(st, inst, args[1], a)
+	case x86asm.PUSH:
+		size := argSize(inst, args[0])
+		if _, ok := args[0].(x86asm.Imm); ok {
+			size = s.dis.Mode / 8
+			if inst.DataSize == 16 {
+				size = 2
+			}
+		}
+		s.push(st, s.read(st, inst, args[0]), size)
+	case x86asm.POP:
+		s.write(st, inst, args[0], s.pop(st, argSize(inst, args[0])))
+	case x86asm.PUSHF, x86asm.PUSHFD, x86asm.PUSHFQ:
+		s.push(st, s.fresh("flags", mask(inst.DataSize/8)), inst.DataSize/8)
+	case x86asm.POPF, x86asm.POPFD, x86asm.POPFQ:
+		s.pop(st, inst.DataSize/8)
+		st.flags = nil
+	case x86asm.PUSHAD:
+		sp := s.getReg(st, x86asm.ESP)
+		for _, reg := range []x86asm.Reg{x86asm.EAX, x86asm.ECX, x86asm.EDX, x86asm.EBX, x86asm.ESP, x86asm.EBP, x86asm.ESI, x86asm.EDI} {
+			x := sp
+			if reg != x86asm.ESP {
+				x = s.getReg(st, reg)
+			}
+			s.push(st, x, 4)
+		}
+	case x86asm.POPAD:
+		for _, reg := range []x86asm.Reg{x86asm.EDI, x86asm.ESI, x86asm.EBP, x86asm.ESP, x86asm.EBX, x86asm.EDX, x86asm.ECX, x86asm.EAX} {
+			x := s.pop(st, 4)
+			if reg != x86asm.ESP {
+				s.setReg(st, reg, x)
+			}
+		}
+	case x86asm.LEAVE:
+		bp, sp := s.gpr(5), s.gpr(s.spNum())
+		s.setReg(st, sp, s.getReg(st, bp))
+		s.setReg(st, bp, s.pop(st, s.dis.Mode/8))
+	case x86asm.ADD:
+		r := symAdd(s.read(st, inst, args[0]), s.read(st, inst, args[1]))
+		s.write(st, inst, args[0], r)
+		st.flags = &symFlags{kind: symFlagsResult, a: r, bits: bits}
+	case x86asm.SUB, x86asm.CMP:
+		a, b := s.read(st, inst, args[0]), s.read(st, inst, args[1])
+		if inst.Op == x86asm.SUB {
+			s.write(st, inst, args[0], symSub(a, b))
+		}
+		st.flags = &symFlags{kind: symFlagsSub, a: a, b: b, bits: bits}
+	case x86asm.AND, x86asm.TEST, x86asm.OR, x86asm.XOR:
+		r := s.logic(st, inst)
+		if inst.Op != x86asm.TEST {
+			s.write(st, inst, args[0], r)
+		}
+		st.flags = &symFlags{kind: symFlagsLogic, a: r, bits: bits}
+	case x86asm.INC, x86asm.DEC, x86asm.NEG:
+		a := s.read(st, inst, args[0])
+		var r *symExpr
+		switch inst.Op {
+		case x86asm.INC:
+			r = symAdd(a, symConst(1))
+		case x86asm.DEC:
+			r = symSub(a, symConst(1))
+		case x86asm.NEG:
+			r = symSub(symConst(0), a)
+		}
+		s.write(st, inst, args[0], r)
+		st.flags = &symFlags{kind: symFlagsResult, a: r, bits: bits}
+	case x86asm.NOT:
+		// ^a = -a - 1
+		s.write(st, inst, args[0], symSub(symConst(^uint64(0)), s.read(st, inst, args[0])))
+	case x86asm.SHL, x86asm.SHR, x86asm.SAR:
+		r := s.shift(st, inst)
+		s.write(st, inst, args[0], r)
+		st.flags = &symFlags{kind: symFlagsResult, a: r, bits: bits}
+	case x86asm.IMUL:
+		if args[1] == nil {
+			s.havocRegs(st, x86asm.EAX, x86asm.EDX)
+			st.flags = nil
+			break
+		}
+		a, b := s.read(st, inst, args[0]), s.read(st, inst, args[1])
+		if args[2] != nil {
+			a, b = b, s.read(st, inst, args[2])
+		}
+		var r *symExpr
+		if c, ok := b.isConst(); ok {
+			r = symMul(a, c)
+		} else if c, ok := a.isConst(); ok {
+			r = symMul(b, c)
+		} else {
+			r = s.fresh("mul", mask(bits/8))
+		}
+		s.write(st, inst, args[0], r)
+		st.flags = nil
+	case x86asm.SETA, x86asm.SETAE, x86asm.SETB, x86asm.SETBE, x86asm.SETE, x86asm.SETG, x86asm.SETGE, x86asm.SETL, x86asm.SETLE, x86asm.SETNE, x86asm.SETNO, x86asm.SETNP, x86asm.SETNS, x86asm.SETO, x86asm.SETP, x86asm.SETS:
+		s.write(st, inst, args[0], s.fresh("setcc", 1))
+	case x86asm.CMOVA, x86asm.CMOVAE, x86asm.CMOVB, x86asm.CMOVBE, x86asm.CMOVE, x86asm.CMOVG, x86asm.CMOVGE, x86asm.CMOVL, x86asm.CMOVLE, x86asm.CMOVNE, x86asm.CMOVNO, x86asm.CMOVNP, x86asm.CMOVNS, x86asm.CMOVO, x86asm.CMOVP, x86asm.CMOVS:
+		s.write(st, inst, args[0], s.fresh("cmov", mask(bits/8)))
+	case x86asm.CALL, x86asm.LCALL:
+		s.call(st)
+	case x86asm.ENTER, x86asm.PUSHA, x86asm.POPA, x86asm.INT, x86asm.INTO, x86asm.SYSCALL, x86asm.SYSENTER,
+		x86asm.MOVSB, x86asm.MOVSW, x86asm.MOVSD, x86asm.MOVSQ, x86asm.STOSB, x86asm.STOSW, x86asm.STOSD, x86asm.STOSQ, x86asm.LODSB, x86asm.LODSW, x86asm.LODSD, x86asm.LODSQ, x86asm.SCASB, x86asm.SCASW, x86asm.SCASD, x86asm.SCASQ, x86asm.CMPSB, x86asm.CMPSW, x86asm.CMPSD, x86asm.CMPSQ:
+		// Instructions with implicit effects on registers and memory.
+		s.havocAll(st)
+	default:
+		// Unknown instruction; assume the destination argument and the
+		// implicitly written registers are clobbered.
+		switch arg := args[0].(type) {
+		case x86asm.Reg:
+			s.havocRegs(st, arg)
+		case x86asm.Mem:
+			s.havocMem(st)
+		}
+		if inst.Op == x86asm.XADD || inst.Op == x86asm.CMPXCHG {
+			if reg, ok := args[1].(x86asm.Reg); ok {
+				s.havocRegs(st, reg)
+			}
+		}
+		s.havocRegs(st, symImplicit[inst.Op]...)
+		st.flags = nil
+	}
+}
+
+// logic returns the result of the given AND, TEST, OR or XOR instruction.
+func (s *symExec) logic(st *symState, inst *Inst) *symExpr {
+	bits := 8 * argSize(inst, inst.Args[0])
+	a, b := s.read(st, inst, inst.Args[0]), s.read(st, inst, inst.Args[1])
+	same := inst.Args[0] == inst.Args[1]
+	x, ok1 := a.isConst()
+	y, ok2 := b.isConst()
+	switch inst.Op {
+	case x86asm.AND, x86asm.TEST:
+		switch {
+		case ok1 && ok2:
+			return symConst(x & y)
+		case same:
+			return a
+		case ok2:
+			return s.fresh("and", y&mask(bits/8))
+		case ok1:
+			return s.fresh("and", x&mask(bits/8))
+		}
+	case x86asm.OR:
+		switch {
+		case ok1 && ok2:
+			return symConst(x | y)
+		case same:
+			return a
+		}
+	case x86asm.XOR:
+		switch {
+		case ok1 && ok2:
+			return symConst(x ^ y)
+		case same:
+			return symConst(0)
+		}
+	}
+	return s.fresh("logic", mask(bits/8))
+}
+
+// shift returns the result of the given SHL, SHR or SAR instruction.
+func (s *symExec) shift(st *symState, inst *Inst) *symExpr {
+	size := argSize(inst, inst.Args[0])
+	a := s.read(st, inst, inst.Args[0])
+	count, ok := s.read(st, inst, inst.Args[1]).isConst()
+	if !ok {
+		return s.fresh("shift", mask(size))
+	}
+	if size == 8 {
+		count &= 0x3F
+	} else {
+		count &= 0x1F
+	}
+	x, ok := a.isConst()
+	switch inst.Op {
+	case x86asm.SHL:
+		return symMul(a, 1<<count)
+	case x86asm.SHR:
+		if ok {
+			return symConst((x & mask(size)) >> count)
+		}
+		return s.fresh("shr", mask(size)>>count)
+	case x86asm.SAR:
+		if ok {
+			return symConst(uint64(int64(signExtend(x&mask(size), size)) >> count))
+		}
+	}
+	return s.fresh("shift", mask(size))
+}
+
+// call executes a function call symbolically; caller-saved registers, the
+// condition flags and memory are clobbered by the callee.
+func (s *symExec) call(st *symState) {
+	s.havocRegs(st, x86asm.EAX, x86asm.ECX, x86asm.EDX)
+	if s.dis.Mode == 64 {
+		s.havocRegs(st, x86asm.ESI, x86asm.EDI, x86asm.R8L, x86asm.R9L, x86asm.R10L, x86asm.R11L)
+	}
+	// The stack pointer is adjusted by the callee for some calling conventions.
+	st.regs[s.spNum()] = s.stackVar()
+	s.havocMem(st)
+	st.flags = nil
+}
+
+// havocAll clobbers all registers, the condition flags and memory.
+func (s *symExec) havocAll(st *symState) {
+	for num := range st.regs {
+		s.havocRegs(st, s.gpr(num))
+	}
+	st.regs[s.spNum()] = s.stackVar()
+	s.havocMem(st)
+	st.flags = nil
+}
+
+// ### [ Terminators ] #########################################################
+
+// term executes the given terminator instruction symbolically, and returns the
+// states of its feasible successors within the function.
+func (s *symExec) term(st *symState, term *Inst) []*symState {
+	if term.IsDummyTerm() {
+		st.addr = term.Addr
+		return []*symState{st}
+	}
+	next := term.Addr + bin.Address(term.Len)
+	switch term.Op {
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS,
+		x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		rel, ok := term.Args[0].(x86asm.Rel)
+		if !ok {
+			return nil
+		}
+		target := next + bin.Address(rel)
+		cond, ok := s.cond(st, term)
+		if !ok {
+			return s.edges(st, term, target, next)
+		}
+		var succs []*symState
+		if taken := s.assume(st.clone(), cond); taken != nil {
+			succs = append(succs, s.edges(taken, term, target)...)
+		}
+		if fall := s.assume(st, cond.negate()); fall != nil {
+			succs = append(succs, s.edges(fall, term, next)...)
+		}
+		return succs
+	case x86asm.JMP:
+		return s.edges(st, term, s.jumpTargets(st, term)...)
+	}
+	// Returns, calls to noreturn functions and program termination.
+	return nil
+}
+
+// edges records the given targets of the terminator as feasible, and returns
+// the states of the targets within the function.
+func (s *symExec) edges(st *symState, term *Inst, targets ...bin.Address) []*symState {
+	feasible, ok := s.res.Feasible[term.Addr]
+	if !ok {
+		feasible = make(map[bin.Address]bool)
+		s.res.Feasible[term.Addr] = feasible
+	}
+	var succs []*symState
+	for i, target := range targets {
+		feasible[target] = true
+		if _, ok := s.f.Blocks[target]; !ok {
+			// E.g. tail call.
+			continue
+		}
+		succ := st
+		if i < len(targets)-1 {
+			succ = st.clone()
+		}
+		succ.addr = target
+		succs = append(succs, succ)
+	}
+	return succs
+}
+
+// cond returns the branch condition of the given conditional jump or loop
+// instruction. The boolean return value indicates whether the condition is
+// known.
+func (s *symExec) cond(st *symState, term *Inst) (symCond, bool) {
+	addrSize := term.AddrSize
+	if addrSize == 0 {
+		addrSize = s.dis.Mode
+	}
+	counter := x86asm.ECX
+	switch addrSize {
+	case 16:
+		counter = x86asm.CX
+	case 64:
+		counter = x86asm.RCX
+	}
+	zero := symConst(0)
+	switch term.Op {
+	case x86asm.JCXZ, x86asm.JECXZ, x86asm.JRCXZ:
+		return symCond{a: s.getReg(st, counter), b: zero, rel: relEQ, bits: addrSize}, true
+	case x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		c := symSub(s.getReg(st, counter), symConst(1))
+		s.setReg(st, counter, c)
+		if term.Op != x86asm.LOOP {
+			return symCond{}, false
+		}
+		return symCond{a: c, b: zero, rel: relNE, bits: addrSize}, true
+	}
+	fl := st.flags
+	if fl == nil {
+		return symCond{}, false
+	}
+	a, b, bits := fl.a, fl.b, fl.bits
+	rel := func(a, b *symExpr, rel symRel) (symCond, bool) {
+		return symCond{a: a, b: b, rel: rel, bits: bits}, true
+	}
+	switch fl.kind {
+	case symFlagsSub:
+		switch term.Op {
+		case x86asm.JE:
+			return rel(a, b, relEQ)
+		case x86asm.JNE:
+			return rel(a, b, relNE)
+		case x86asm.JB:
+			return rel(a, b, relULT)
+		case x86asm.JBE:
+			return rel(a, b, relULE)
+		case x86asm.JA:
+			return rel(a, b, relUGT)
+		case x86asm.JAE:
+			return rel(a, b, relUGE)
+		case x86asm.JL:
+			return rel(a, b, relSLT)
+		case x86asm.JLE:
+			return rel(a, b, relSLE)
+		case x86asm.JG:
+			return rel(a, b, relSGT)
+		case x86asm.JGE:
+			return rel(a, b, relSGE)
+		case x86asm.JS:
+			return rel(symSub(a, b), zero, relSLT)
+		case x86asm.JNS:
+			return rel(symSub(a, b), zero, relSGE)
+		}
+	case symFlagsLogic:
+		// CF and OF are cleared.
+		switch term.Op {
+		case x86asm.JE, x86asm.JBE:
+			return rel(a, zero, relEQ)
+		case x86asm.JNE, x86asm.JA:
+			return rel(a, zero, relNE)
+		case x86asm.JB, x86asm.JO:
+			return condConst(false), true
+		case x86asm.JAE, x86asm.JNO:
+			return condConst(true), true
+		case x86asm.JS, x86asm.JL:
+			return rel(a, zero, relSLT)
+		case x86asm.JNS, x86asm.JGE:
+			return rel(a, zero, relSGE)
+		case x86asm.JG:
+			return rel(a, zero, relSGT)
+		case x86asm.JLE:
+			return rel(a, zero, relSLE)
+		}
+	case symFlagsResult:
+		switch term.Op {
+		case x86asm.JE:
+			return rel(a, zero, relEQ)
+		case x86asm.JNE:
+			return rel(a, zero, relNE)
+		case x86asm.JS:
+			return rel(a, zero, relSLT)
+		case x86asm.JNS:
+			return rel(a, zero, relSGE)
+		}
+	}
+	return symCond{}, false
+}
+
+// assume adds the given branch condition to the path constraints of the state.
+// The returned state is nil if the condition is infeasible.
+func (s *symExec) assume(st *symState, cond symCond) *symState {
+	bits := cond.bits
+	m := mask(bits / 8)
+	a, b := symNorm(cond.a, bits), symNorm(cond.b, bits)
+	x, ok1 := a.isConst()
+	y, ok2 := b.isConst()
+	rel := cond.rel
+	var e *symExpr
+	var k uint64
+	switch {
+	case ok1 && ok2:
+		if rel.eval(x, y, bits) {
+			return st
+		}
+		return nil
+	case ok2:
+		e, k = a, y
+	case ok1:
+		e, k, rel = b, x, rel.swap()
+	case rel == relEQ || rel == relNE:
+		e, k = symSub(a, b), 0
+	default:
+		// Relations between variables are not tracked.
+		return st
+	}
+	if rel.isSigned() {
+		// Bias signed values to preserve their order as unsigned values.
+		bias := signBit(bits / 8)
+		e, k = symAdd(e, symConst(bias)), (k+bias)&m
+		rel = rel.unsigned()
+	}
+	e = symNorm(e, bits)
+	id, coef, ok := e.single()
+	if !ok || coef != 1 {
+		return st
+	}
+	cons := symCons{v: id, c: e.c, bits: bits}
+	switch rel {
+	case relEQ:
+		cons.lo, cons.hi = k, k
+	case relNE:
+		cons.lo, cons.hi, cons.neg = k, k, true
+	case relULT:
+		if k == 0 {
+			return nil
+		}
+		cons.lo, cons.hi = 0, k-1
+	case relULE:
+		cons.lo, cons.hi = 0, k
+	case relUGT:
+		if k == m {
+			return nil
+		}
+		cons.lo, cons.hi = k+1, m
+	case relUGE:
+		cons.lo, cons.hi = k, m
+	}
+	st.cons = append(st.cons, cons)
+	if vals, ok := s.consValues(st, id, true); ok && len(vals) == 0 {
+		return nil
+	}
+	return st
+}
+
+// jumpTargets returns the targets of the given JMP instruction.
+func (s *symExec) jumpTargets(st *symState, term *Inst) []bin.Address {
+	if term.EmuTarget != 0 || len(term.SymTargets) > 0 || s.dis.isStaticJump(term) {
+		return s.dis.Targets(term, s.f.Addr)
+	}
+	x := s.read(st, term, term.Args[0])
+	vals, ok := s.values(st, x, s.dis.Mode, 0)
+	if !ok {
+		dbg.Printf("unable to resolve targets of indirect jump at %v by symbolic execution", term.Addr)
+		s.unresolved[term.Addr] = true
+		s.res.Complete = false
+		return nil
+	}
+	targets := s.res.Targets[term.Addr]
+	var addrs []bin.Address
+	for _, v := range vals {
+		target := bin.Address(v)
+		if !s.dis.File.IsCode(target) {
+			continue
+		}
+		addrs = append(addrs, target)
+		if !containsAddr(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	sort.Sort(bin.Addresses(targets))
+	s.res.Targets[term.Addr] = targets
+	return addrs
+}
+
+// containsAddr reports whether the list of addresses contains the given
+// address.
+func containsAddr(addrs []bin.Address, addr bin.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package x86_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+)
+
+// symCode returns the .text section of a symbolic execution test, with the
+// given function prologue located at 0x401000 and followed by the switch cases
+//
+//    case_401020:
+//       mov eax, 1
+//       ret
+//    case_401026:
+//       mov eax, 2
+//       ret
+//    case_40102C:
+//       mov eax, 3
+//       ret
+//    default_401032:
+//       xor eax, eax
+//       ret
+func symCode(prologue []byte) []byte {
+	code := make([]byte, 0x35)
+	for i := range code[:0x20] {
+		// int3 padding.
+		code[i] = 0xCC
+	}
+	copy(code, prologue)
+	copy(code[0x20:], []byte{
+		0xB8, 0x01, 0x00, 0x00, 0x00, 0xC3,
+		0xB8, 0x02, 0x00, 0x00, 0x00, 0xC3,
+		0xB8, 0x03, 0x00, 0x00, 0x00, 0xC3,
+		0x31, 0xC0, 0xC3,
+	})
+	return code
+}
+
+// symDisasm returns a disassembler of a 32-bit executable with the given code
+// and a jump table at 0x402000. The jump table has a fourth entry beyond the
+// bounds of the switch statements of the tests.
+func symDisasm(t *testing.T, code []byte) *x86.Disasm {
+	table := []byte{
+		0x20, 0x10, 0x40, 0x00,
+		0x26, 0x10, 0x40, 0x00,
+		0x2C, 0x10, 0x40, 0x00,
+		0x00, 0x10, 0x40, 0x00,
+	}
+	file := &bin.File{
+		Arch:  bin.ArchX86_32,
+		Entry: 0x401000,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Data: code, MemSize: len(code), Perm: bin.PermR | bin.PermX},
+			{Name: ".rdata", Addr: 0x402000, Data: table, MemSize: len(table), Perm: bin.PermR},
+		},
+	}
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		t.Fatalf("unable to create disassembler; %+v", err)
+	}
+	return dis
+}
+
+func TestSymExecTargets(t *testing.T) {
+	golden := []struct {
+		// Function prologue, located at 0x401000.
+		prologue []byte
+		// Address of the indirect jump.
+		jump bin.Address
+		// Expected targets of the indirect jump.
+		want []bin.Address
+	}{
+		// Jump table bounded by the range check of a switch statement.
+		//
+		//    mov eax, [esp+4]
+		//    cmp eax, 2
+		//    ja  default_401032
+		//    jmp [eax*4+0x402000]
+		{
+			prologue: []byte{
+				0x8B, 0x44, 0x24, 0x04,
+				0x83, 0xF8, 0x02,
+				0x77, 0x29,
+				0xFF, 0x24, 0x85, 0x00, 0x20, 0x40, 0x00,
+			},
+			jump: 0x401009,
+			want: []bin.Address{0x401020, 0x401026, 0x40102C},
+		},
+		// Jump table of a switch statement with cases starting at 1.
+		//
+		//    mov eax, [esp+4]
+		//    sub eax, 1
+		//    cmp eax, 1
+		//    ja  default_401032
+		//    jmp [eax*4+0x402000]
+		{
+			prologue: []byte{
+				0x8B, 0x44, 0x24, 0x04,
+				0x83, 0xE8, 0x01,
+				0x83, 0xF8, 0x01,
+				0x77, 0x26,
+				0xFF, 0x24, 0x85, 0x00, 0x20, 0x40, 0x00,
+			},
+			jump: 0x40100C,
+			want: []bin.Address{0x401020, 0x401026},
+		},
+		// Indirect jump through register, loaded from a masked index.
+		//
+		//    mov eax, [esp+4]
+		//    and eax, 1
+		//    mov eax, [eax*4+0x402000]
+		//    jmp eax
+		{
+			prologue: []byte{
+				0x8B, 0x44, 0x24, 0x04,
+				0x83, 0xE0, 0x01,
+				0x8B, 0x04, 0x85, 0x00, 0x20, 0x40, 0x00,
+				0xFF, 0xE0,
+			},
+			jump: 0x40100E,
+			want: []bin.Address{0x401020, 0x401026},
+		},
+	}
+	for _, g := range golden {
+		dis := symDisasm(t, symCode(g.prologue))
+		f, err := dis.DecodeFunc(0x401000)
+		if err != nil {
+			t.Errorf("%v: unable to decode function; %+v", g.jump, err)
+			continue
+		}
+		var term *x86.Inst
+		for _, block := range f.Blocks {
+			if block.Term.Addr == g.jump {
+				term = block.Term
+			}
+		}
+		if term == nil {
+			t.Errorf("%v: unable to locate indirect jump", g.jump)
+			continue
+		}
+		if !reflect.DeepEqual(term.SymTargets, g.want) {
+			t.Errorf("%v: targets mismatch; expected %v, got %v", g.jump, g.want, term.SymTargets)
+		}
+		// Basic blocks of resolved targets are decoded.
+		for _, target := range g.want {
+			if _, ok := f.Blocks[target]; !ok {
+				t.Errorf("%v: unable to locate basic block of target %v", g.jump, target)
+			}
+		}
+		if res := dis.SymExec(f); !res.Complete {
+			t.Errorf("%v: expected complete symbolic execution", g.jump)
+		}
+	}
+}
+
+func TestSymExecUnresolved(t *testing.T) {
+	// Jump table with unbounded index.
+	//
+	//    mov eax, [esp+4]
+	//    jmp [eax*4+0x402000]
+	prologue := []byte{
+		0x8B, 0x44, 0x24, 0x04,
+		0xFF, 0x24, 0x85, 0x00, 0x20, 0x40, 0x00,
+	}
+	dis := symDisasm(t, symCode(prologue))
+	block, err := dis.DecodeBlock(0x401000)
+	if err != nil {
+		t.Fatalf("unable to decode basic block; %+v", err)
+	}
+	f := &x86.Func{
+		Addr:   0x401000,
+		Blocks: map[bin.Address]*x86.BasicBlock{block.Addr: block},
+	}
+	res := dis.SymExec(f)
+	if targets, ok := res.Targets[0x401004]; ok {
+		t.Errorf("expected unresolved targets of indirect jump at %v, got %v", bin.Address(0x401004), targets)
+	}
+	if res.Complete {
+		t.Errorf("expected incomplete symbolic execution")
+	}
+}
+
+func TestSymExecFeasible(t *testing.T) {
+	//    mov eax, 1
+	//    cmp eax, 1
+	//    jne case_401020
+	//    mov eax, 2
+	//    ret
+	prologue := []byte{
+		0xB8, 0x01, 0x00, 0x00, 0x00,
+		0x83, 0xF8, 0x01,
+		0x75, 0x16,
+		0xB8, 0x02, 0x00, 0x00, 0x00,
+		0xC3,
+	}
+	dis := symDisasm(t, symCode(prologue))
+	f, err := dis.DecodeFunc(0x401000)
+	if err != nil {
+		t.Fatalf("unable to decode function; %+v", err)
+	}
+	res := dis.SymExec(f)
+	if !res.Complete {
+		t.Errorf("expected complete symbolic execution")
+	}
+	want := map[bin.Address]bool{0x40100A: true}
+	if got := res.Feasible[0x401008]; !reflect.DeepEqual(got, want) {
+		t.Errorf("feasible targets mismatch; expected %v, got %v", want, got)
+	}
+	// Prune the infeasible edge, and the basic block only reachable through it.
+	dis.Prune = true
+	f, err = dis.DecodeFunc(0x401000)
+	if err != nil {
+		t.Fatalf("unable to decode function; %+v", err)
+	}
+	if _, ok := f.Blocks[0x401020]; ok {
+		t.Errorf("expected basic block at %v to be pruned", bin.Address(0x401020))
+	}
+	if _, ok := f.Blocks[0x40100A]; !ok {
+		t.Errorf("unable to locate basic block at %v", bin.Address(0x40100A))
+	}
+}
@@ -0,0 +1,465 @@
+package x86
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// ### [ Symbolic expressions ] ################################################
+
+// A symExpr is a symbolic expression; a linear combination of symbolic
+// variables plus a constant term, modulo 2^64. Expressions are interpreted
+// modulo 2^n by consumers, where n is the bit size of the operand.
+type symExpr struct {
+	// Constant term.
+	c uint64
+	// Coefficients of symbolic variables, indexed by variable ID.
+	terms map[int]uint64
+}
+
+// symConst returns a constant symbolic expression.
+func symConst(c uint64) *symExpr {
+	return &symExpr{c: c}
+}
+
+// symVarExpr returns the symbolic expression of the given variable.
+func symVarExpr(id int) *symExpr {
+	return &symExpr{terms: map[int]uint64{id: 1}}
+}
+
+// isConst reports whether the symbolic expression is constant, and returns its
+// value.
+func (x *symExpr) isConst() (uint64, bool) {
+	return x.c, len(x.terms) == 0
+}
+
+// single reports whether the symbolic expression is of the form k*v + c, where
+// v is a single variable, and returns the ID and coefficient of the variable.
+func (x *symExpr) single() (id int, k uint64, ok bool) {
+	if len(x.terms) != 1 {
+		return 0, 0, false
+	}
+	for id, k := range x.terms {
+		return id, k, true
+	}
+	panic("unreachable")
+}
+
+// addTerm adds k*v to the symbolic expression, where v is the variable with the
+// given ID.
+func (x *symExpr) addTerm(id int, k uint64) {
+	if k == 0 {
+		return
+	}
+	if x.terms == nil {
+		x.terms = make(map[int]uint64)
+	}
+	x.terms[id] += k
+	if x.terms[id] == 0 {
+		delete(x.terms, id)
+	}
+}
+
+// key returns a canonical string representation of the symbolic expression.
+func (x *symExpr) key() string {
+	ids := make([]int, 0, len(x.terms))
+	for id := range x.terms {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	buf := &bytes.Buffer{}
+	for _, id := range ids {
+		fmt.Fprintf(buf, "%d*v%d+", x.terms[id], id)
+	}
+	fmt.Fprintf(buf, "%d", x.c)
+	return buf.String()
+}
+
+// symAdd returns x + y.
+func symAdd(x, y *symExpr) *symExpr {
+	z := &symExpr{c: x.c + y.c}
+	for id, k := range x.terms {
+		z.addTerm(id, k)
+	}
+	for id, k := range y.terms {
+		z.addTerm(id, k)
+	}
+	return z
+}
+
+// symSub returns x - y.
+func symSub(x, y *symExpr) *symExpr {
+	return symAdd(x, symMul(y, ^uint64(0)))
+}
+
+// symMul returns k*x.
+func symMul(x *symExpr, k uint64) *symExpr {
+	z := &symExpr{c: x.c * k}
+	for id, kk := range x.terms {
+		z.addTerm(id, kk*k)
+	}
+	return z
+}
+
+// symNorm returns the symbolic expression reduced modulo 2^bits.
+func symNorm(x *symExpr, bits int) *symExpr {
+	m := mask(bits / 8)
+	z := &symExpr{c: x.c & m}
+	for id, k := range x.terms {
+		z.addTerm(id, k&m)
+	}
+	return z
+}
+
+// ### [ Symbolic variables ] ##################################################
+
+// A symVar is a symbolic variable.
+type symVar struct {
+	// Variable name, used for debugging; e.g. "ECX" or "load".
+	name string
+	// Unsigned range of values.
+	lo, hi uint64
+	// Specifies whether the variable is the value of the stack pointer on
+	// function entry or after a call.
+	stack bool
+	// Address of the memory load defining the variable; or nil if not loaded
+	// from memory.
+	load *symExpr
+	// Size in bytes of the memory load.
+	loadSize int
+	// Expression truncated to defBits bits, defining the variable; or nil if not
+	// a truncation.
+	def *symExpr
+	// Bit size of the truncation.
+	defBits int
+	// Specifies whether the truncation is sign-extended.
+	sext bool
+}
+
+// ### [ Constraints ] #########################################################
+
+// A symCons is a path constraint of the form (v + c) mod 2^bits ∈ [lo, hi], or
+// ∉ [lo, hi] if neg is set.
+type symCons struct {
+	// Variable ID.
+	v int
+	// Constant offset.
+	c uint64
+	// Bit size of the constrained value.
+	bits int
+	// Unsigned range of the constrained value.
+	lo, hi uint64
+	// Specifies whether the constraint is negated.
+	neg bool
+}
+
+// holds reports whether the constraint holds for the given value of its
+// variable.
+func (cons symCons) holds(x uint64) bool {
+	y := (x + cons.c) & mask(cons.bits/8)
+	in := cons.lo <= y && y <= cons.hi
+	return in != cons.neg
+}
+
+// symRel is a relation between two symbolic expressions.
+type symRel uint8
+
+// Relations.
+const (
+	relEQ symRel = iota
+	relNE
+	// Unsigned relations.
+	relULT
+	relULE
+	relUGT
+	relUGE
+	// Signed relations.
+	relSLT
+	relSLE
+	relSGT
+	relSGE
+)
+
+// negate returns the negation of the relation.
+func (rel symRel) negate() symRel {
+	switch rel {
+	case relEQ:
+		return relNE
+	case relNE:
+		return relEQ
+	case relULT:
+		return relUGE
+	case relULE:
+		return relUGT
+	case relUGT:
+		return relULE
+	case relUGE:
+		return relULT
+	case relSLT:
+		return relSGE
+	case relSLE:
+		return relSGT
+	case relSGT:
+		return relSLE
+	case relSGE:
+		return relSLT
+	}
+	panic(fmt.Errorf("support for relation %d not yet implemented", rel))
+}
+
+// swap returns the relation with swapped operands; i.e. rel' such that a rel b
+// iff b rel' a.
+func (rel symRel) swap() symRel {
+	switch rel {
+	case relULT:
+		return relUGT
+	case relULE:
+		return relUGE
+	case relUGT:
+		return relULT
+	case relUGE:
+		return relULE
+	case relSLT:
+		return relSGT
+	case relSLE:
+		return relSGE
+	case relSGT:
+		return relSLT
+	case relSGE:
+		return relSLE
+	}
+	return rel
+}
+
+// unsigned returns the unsigned counterpart of the relation.
+func (rel symRel) unsigned() symRel {
+	switch rel {
+	case relSLT:
+		return relULT
+	case relSLE:
+		return relULE
+	case relSGT:
+		return relUGT
+	case relSGE:
+		return relUGE
+	}
+	return rel
+}
+
+// isSigned reports whether the relation is signed.
+func (rel symRel) isSigned() bool {
+	return rel >= relSLT
+}
+
+// eval evaluates the relation between the given constants of the specified
+// bit size.
+func (rel symRel) eval(a, b uint64, bits int) bool {
+	m := mask(bits / 8)
+	a, b = a&m, b&m
+	if rel.isSigned() {
+		// Bias signed values to preserve their order as unsigned values.
+		bias := signBit(bits / 8)
+		a, b = (a+bias)&m, (b+bias)&m
+		rel = rel.unsigned()
+	}
+	switch rel {
+	case relEQ:
+		return a == b
+	case relNE:
+		return a != b
+	case relULT:
+		return a < b
+	case relULE:
+		return a <= b
+	case relUGT:
+		return a > b
+	case relUGE:
+		return a >= b
+	}
+	panic(fmt.Errorf("support for relation %d not yet implemented", rel))
+}
+
+// A symCond is a branch condition; i.e. the relation between two symbolic
+// expressions of the given bit size.
+type symCond struct {
+	// Operands.
+	a, b *symExpr
+	// Relation between the operands.
+	rel symRel
+	// Bit size of the operands.
+	bits int
+}
+
+// condConst returns a constant branch condition.
+func condConst(v bool) symCond {
+	if v {
+		return symCond{a: symConst(0), b: symConst(0), rel: relEQ, bits: 8}
+	}
+	return symCond{a: symConst(0), b: symConst(1), rel: relEQ, bits: 8}
+}
+
+// negate returns the negation of the branch condition.
+func (cond symCond) negate() symCond {
+	cond.rel = cond.rel.negate()
+	return cond
+}
+
+// ### [ Values ] ##############################################################
+
+// maxSymValues specifies the maximum number of candidate values enumerated for
+// a symbolic expression.
+const maxSymValues = 1024
+
+// maxSymDepth specifies the maximum depth of variable derivations followed when
+// enumerating the values of a symbolic expression.
+const maxSymDepth = 4
+
+// values returns the possible values of the given symbolic expression modulo
+// 2^bits, under the path constraints of the state. The boolean return value
+// indicates whether the values could be enumerated.
+func (s *symExec) values(st *symState, x *symExpr, bits int, depth int) ([]uint64, bool) {
+	m := mask(bits / 8)
+	if c, ok := x.isConst(); ok {
+		return []uint64{c & m}, true
+	}
+	id, k, ok := x.single()
+	if !ok {
+		return nil, false
+	}
+	vals, ok := s.varValues(st, id, depth)
+	if !ok {
+		return nil, false
+	}
+	var ys []uint64
+	seen := make(map[uint64]bool)
+	for _, v := range vals {
+		y := (k*v + x.c) & m
+		if !seen[y] {
+			seen[y] = true
+			ys = append(ys, y)
+		}
+	}
+	return ys, true
+}
+
+// varValues returns the possible values of the given variable under the path
+// constraints of the state, derived from its constraints, definition or
+// static range, in order of preference. The boolean return value indicates
+// whether the values could be enumerated.
+func (s *symExec) varValues(st *symState, id int, depth int) ([]uint64, bool) {
+	if depth > maxSymDepth {
+		return nil, false
+	}
+	v := s.vars[id]
+	cands, ok := s.consValues(st, id, false)
+	if !ok && v.load != nil {
+		// Values of memory load, as stored in the executable.
+		if addrs, ok2 := s.values(st, v.load, s.dis.Mode, depth+1); ok2 {
+			ok = true
+			for _, addr := range addrs {
+				x, ok3 := s.readFile(addr, v.loadSize)
+				if !ok3 {
+					ok = false
+					break
+				}
+				cands = append(cands, x)
+			}
+		}
+	}
+	if !ok && v.def != nil {
+		// Values of truncated expression.
+		cands, ok = s.values(st, v.def, v.defBits, depth+1)
+		if v.sext {
+			for i, c := range cands {
+				cands[i] = signExtend(c, v.defBits/8) & v.hi
+			}
+		}
+	}
+	if !ok {
+		cands, ok = s.consValues(st, id, true)
+	}
+	if !ok {
+		return nil, false
+	}
+	return s.filter(st, id, cands), true
+}
+
+// consValues returns the candidate values of the given variable, as bounded by
+// its tightest path constraint, or if static is set, by its static range. The
+// boolean return value indicates whether the candidate values could be
+// enumerated.
+func (s *symExec) consValues(st *symState, id int, static bool) ([]uint64, bool) {
+	v := s.vars[id]
+	best := -1
+	var bestSize uint64
+	for i, cons := range st.cons {
+		if cons.v != id || cons.neg || v.hi > mask(cons.bits/8) {
+			continue
+		}
+		if size := cons.hi - cons.lo; best == -1 || size < bestSize {
+			best, bestSize = i, size
+		}
+	}
+	var cands []uint64
+	switch {
+	case best != -1 && bestSize < maxSymValues:
+		cons := st.cons[best]
+		for y := cons.lo; ; y++ {
+			cands = append(cands, (y-cons.c)&mask(cons.bits/8))
+			if y == cons.hi {
+				break
+			}
+		}
+	case static && v.hi-v.lo < maxSymValues:
+		for x := v.lo; ; x++ {
+			cands = append(cands, x)
+			if x == v.hi {
+				break
+			}
+		}
+	default:
+		return nil, false
+	}
+	return s.filter(st, id, cands), true
+}
+
+// filter returns the distinct candidate values of the given variable which are
+// within its static range and satisfy its path constraints.
+func (s *symExec) filter(st *symState, id int, cands []uint64) []uint64 {
+	v := s.vars[id]
+	var vals []uint64
+	seen := make(map[uint64]bool)
+loop:
+	for _, x := range cands {
+		if x < v.lo || x > v.hi || seen[x] {
+			continue
+		}
+		for _, cons := range st.cons {
+			if cons.v == id && !cons.holds(x) {
+				continue loop
+			}
+		}
+		seen[x] = true
+		vals = append(vals, x)
+	}
+	return vals
+}
+
+// readFile returns the little-endian value of the given size in bytes at the
+// specified address of the executable. The boolean return value indicates
+// success.
+func (s *symExec) readFile(addr uint64, size int) (uint64, bool) {
+	for _, sect := range s.dis.File.Sections {
+		start := uint64(sect.Addr)
+		if addr < start || addr+uint64(size) > start+uint64(len(sect.Data)) {
+			continue
+		}
+		var x uint64
+		for i := 0; i < size; i++ {
+			x |= uint64(sect.Data[addr-start+uint64(i)]) << uint(8*i)
+		}
+		return x, true
+	}
+	return 0, false
+}
@@ -0,0 +1,38 @@
+package x86
+
+import (
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Trap interrupts.
+const (
+	// IntBreakpoint raises a breakpoint exception (INT 3); e.g. __debugbreak of
+	// MSVC, or padding following calls to noreturn functions.
+	IntBreakpoint = 0x03
+	// IntFastFail terminates the process with the failure code specified by ECX
+	// (INT 29h); i.e. __fastfail of MSVC on Windows 8 and later.
+	IntFastFail = 0x29
+)
+
+// IsTrap reports whether the given instruction of code in the specified CPU
+// mode raises an exception which does not resume execution of the program
+// (e.g. INT3, UD2 or __fastfail). Such instructions terminate basic blocks.
+func IsTrap(inst *Inst, mode int) bool {
+	switch inst.Op {
+	case x86asm.UD1, x86asm.UD2:
+		return true
+	case x86asm.HLT:
+		// HLT is privileged in 32- and 64-bit user mode code, but halts until the
+		// next interrupt in 16-bit real mode code.
+		return mode != 16
+	case x86asm.INT:
+		switch inst.Args[0] {
+		case x86asm.Imm(IntBreakpoint):
+			return true
+		case x86asm.Imm(IntFastFail):
+			// INT 29h is fast console output in 16-bit real mode code (DOS).
+			return mode != 16
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package x86
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/value"
+)
+
+// ### [ Alias annotations ] ###################################################
+
+// provenance specifies the memory region a pointer is derived from.
+type provenance uint8
+
+// Memory regions of pointers.
+const (
+	// Stack slots, registers and status flags of the function; i.e. local
+	// variables allocated by the lifter.
+	provStack provenance = iota
+	// Global variables.
+	provGlobal
+	// External memory; i.e. memory accessed through pointers loaded from memory
+	// or computed at run time (e.g. heap objects and memory of callers).
+	provExternal
+	// Number of memory regions.
+	nprovs
+)
+
+// String returns the name of the alias scope of the memory region.
+func (prov provenance) String() string {
+	switch prov {
+	case provStack:
+		return "stack"
+	case provGlobal:
+		return "global"
+	}
+	return "external"
+}
+
+// aliasScopes returns the alias scopes of the memory regions, as members of
+// the "decomp" alias domain; e.g.
+//
+//    !{!"decomp.stack", !{!"decomp"}}
+func aliasScopes() [nprovs]*metadata.Metadata {
+	domain := &metadata.Metadata{
+		Nodes: []metadata.Node{&metadata.String{Val: "decomp"}},
+	}
+	var scopes [nprovs]*metadata.Metadata
+	for prov := provenance(0); prov < nprovs; prov++ {
+		scopes[prov] = &metadata.Metadata{
+			Nodes: []metadata.Node{&metadata.String{Val: "decomp." + prov.String()}, domain},
+		}
+	}
+	return scopes
+}
+
+// annotateAliases attaches "alias.scope" and "noalias" metadata to the loads
+// and stores of the lifted function, based on the memory region of the
+// accessed pointer; e.g.
+//
+//    %1 = load i32, i32* %esp_-8, !alias.scope !{!stack}, !noalias !{!global, !external}
+//
+// Memory accessed through pointers computed at run time is assumed not to
+// alias global variables. Stack slots are only considered distinct from
+// external memory if their addresses do not escape the function (e.g. through
+// `lea eax, [esp+8]`).
+func (f *Func) annotateAliases() {
+	// Report whether the address of a local variable escapes.
+	escapes := false
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstPtrToInt:
+				escapes = escapes || ptrProvenance(inst.From) == provStack
+			case *ir.InstStore:
+				escapes = escapes || ptrProvenance(inst.Src) == provStack
+			case *ir.InstCall:
+				for _, arg := range inst.Args {
+					escapes = escapes || ptrProvenance(arg) == provStack
+				}
+			}
+		}
+	}
+	scopes := aliasScopes()
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			var prov provenance
+			switch inst := inst.(type) {
+			case *ir.InstLoad:
+				prov = ptrProvenance(inst.Src)
+			case *ir.InstStore:
+				prov = ptrProvenance(inst.Dst)
+			default:
+				continue
+			}
+			var noalias []metadata.Node
+			for other := provenance(0); other < nprovs; other++ {
+				if other == prov {
+					continue
+				}
+				if escapes && (prov == provStack && other == provExternal || prov == provExternal && other == provStack) {
+					continue
+				}
+				noalias = append(noalias, scopes[other])
+			}
+			setMetadata(inst, "alias.scope", &metadata.Metadata{
+				Nodes: []metadata.Node{scopes[prov]},
+			})
+			setMetadata(inst, "noalias", &metadata.Metadata{
+				Nodes: noalias,
+			})
+		}
+	}
+}
+
+// ptrProvenance returns the memory region the given pointer is derived from,
+// by tracking it through bitcasts and getelementptr instructions.
+func ptrProvenance(v value.Value) provenance {
+	for {
+		switch p := v.(type) {
+		case *ir.InstAlloca:
+			return provStack
+		case *ir.Global:
+			return provGlobal
+		case *ir.InstBitCast:
+			v = p.From
+		case *ir.InstGetElementPtr:
+			v = p.Src
+		case *constant.ExprBitCast:
+			v = p.From
+		case *constant.ExprGetElementPtr:
+			v = p.Src
+		default:
+			return provExternal
+		}
+	}
+}
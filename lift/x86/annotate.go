@@ -0,0 +1,208 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// annotateInst attaches metadata describing the given x86 instruction to the
+// LLVM IR instructions and terminators emitted since the state of the function
+// lifter was saved, as specified by the lifter settings.
+//
+// With DebugAddrs set, the address and length in bytes of the instruction are
+// attached as "addr" and "len" metadata. These are plain metadata strings (as
+// read by the taint analysis), rather than DWARF debug information (!dbg); the
+// metadata of the LLVM IR library is limited to generic metadata tuples. With AsmAnnotations set, the Intel
+// syntax disassembly of the instruction is attached as "asm" metadata. The
+// user-supplied comment of the instruction, if any, is attached as "comment"
+// metadata.
+func (f *Func) annotateInst(s *liftState, inst *x86.Inst) {
+	if inst.IsDummyTerm() {
+		// Implicit fallthrough terminators have no originating instruction.
+		return
+	}
+	mds := make(map[string]*metadata.Metadata)
+	if f.l.DebugAddrs {
+		mds["addr"] = &metadata.Metadata{
+			Nodes: []metadata.Node{&metadata.String{Val: inst.Addr.String()}},
+		}
+		mds["len"] = &metadata.Metadata{
+			Nodes: []metadata.Node{&metadata.String{Val: fmt.Sprint(inst.Len)}},
+		}
+	}
+	if f.l.AsmAnnotations {
+		asm := x86asm.IntelSyntax(inst.Inst, uint64(inst.Addr), nil)
+		mds["asm"] = &metadata.Metadata{
+			Nodes: []metadata.Node{&metadata.String{Val: asm}},
+		}
+	}
+	if comment, ok := f.l.Comment(inst.Addr); ok && inst.Addr != f.AsmFunc.Addr {
+		// Comments of function entry points are attached to the function.
+		mds["comment"] = commentMetadata(comment)
+	}
+	if len(mds) == 0 {
+		return
+	}
+	for _, v := range f.emitted(s) {
+		for kind, md := range mds {
+			setMetadata(v, kind, md)
+		}
+	}
+}
+
+// emitted returns the LLVM IR instructions and terminators emitted since the
+// state of the function lifter was saved.
+func (f *Func) emitted(s *liftState) []interface{} {
+	var vs []interface{}
+	for _, inst := range s.cur.Insts[s.ninsts:] {
+		vs = append(vs, inst)
+	}
+	if s.cur.Term != nil && s.cur.Term != s.term {
+		vs = append(vs, s.cur.Term)
+	}
+	// Basic blocks added while lifting the instruction (e.g. REP loops).
+	for _, block := range f.Blocks[s.nblocks:] {
+		if block == s.cur {
+			continue
+		}
+		for _, inst := range block.Insts {
+			vs = append(vs, inst)
+		}
+		if block.Term != nil {
+			vs = append(vs, block.Term)
+		}
+	}
+	return vs
+}
+
+// setMetadata attaches the metadata of the given kind to the LLVM IR function,
+// global variable, instruction or terminator. Values without metadata
+// attachments are ignored.
+func setMetadata(v interface{}, kind string, md *metadata.Metadata) {
+	mds := metadataOf(v)
+	if mds == nil {
+		return
+	}
+	if *mds == nil {
+		*mds = make(map[string]*metadata.Metadata)
+	}
+	(*mds)[kind] = md
+}
+
+// metadataOf returns a pointer to the metadata attachments of the given LLVM IR
+// function, global variable, instruction or terminator; or nil if not present.
+func metadataOf(v interface{}) *map[string]*metadata.Metadata {
+	switch v := v.(type) {
+	// Functions and global variables.
+	case *ir.Function:
+		return &v.Metadata
+	case *ir.Global:
+		return &v.Metadata
+	// Binary instructions.
+	case *ir.InstAdd:
+		return &v.Metadata
+	case *ir.InstFAdd:
+		return &v.Metadata
+	case *ir.InstSub:
+		return &v.Metadata
+	case *ir.InstFSub:
+		return &v.Metadata
+	case *ir.InstMul:
+		return &v.Metadata
+	case *ir.InstFMul:
+		return &v.Metadata
+	case *ir.InstUDiv:
+		return &v.Metadata
+	case *ir.InstSDiv:
+		return &v.Metadata
+	case *ir.InstFDiv:
+		return &v.Metadata
+	case *ir.InstURem:
+		return &v.Metadata
+	case *ir.InstSRem:
+		return &v.Metadata
+	case *ir.InstFRem:
+		return &v.Metadata
+	// Bitwise instructions.
+	case *ir.InstShl:
+		return &v.Metadata
+	case *ir.InstLShr:
+		return &v.Metadata
+	case *ir.InstAShr:
+		return &v.Metadata
+	case *ir.InstAnd:
+		return &v.Metadata
+	case *ir.InstOr:
+		return &v.Metadata
+	case *ir.InstXor:
+		return &v.Metadata
+	// Aggregate instructions.
+	case *ir.InstExtractValue:
+		return &v.Metadata
+	case *ir.InstInsertValue:
+		return &v.Metadata
+	// Memory instructions.
+	case *ir.InstAlloca:
+		return &v.Metadata
+	case *ir.InstLoad:
+		return &v.Metadata
+	case *ir.InstStore:
+		return &v.Metadata
+	case *ir.InstGetElementPtr:
+		return &v.Metadata
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		return &v.Metadata
+	case *ir.InstZExt:
+		return &v.Metadata
+	case *ir.InstSExt:
+		return &v.Metadata
+	case *ir.InstFPTrunc:
+		return &v.Metadata
+	case *ir.InstFPExt:
+		return &v.Metadata
+	case *ir.InstFPToUI:
+		return &v.Metadata
+	case *ir.InstFPToSI:
+		return &v.Metadata
+	case *ir.InstUIToFP:
+		return &v.Metadata
+	case *ir.InstSIToFP:
+		return &v.Metadata
+	case *ir.InstPtrToInt:
+		return &v.Metadata
+	case *ir.InstIntToPtr:
+		return &v.Metadata
+	case *ir.InstBitCast:
+		return &v.Metadata
+	case *ir.InstAddrSpaceCast:
+		return &v.Metadata
+	// Other instructions.
+	case *ir.InstICmp:
+		return &v.Metadata
+	case *ir.InstFCmp:
+		return &v.Metadata
+	case *ir.InstPhi:
+		return &v.Metadata
+	case *ir.InstSelect:
+		return &v.Metadata
+	case *ir.InstCall:
+		return &v.Metadata
+	// Terminators.
+	case *ir.TermRet:
+		return &v.Metadata
+	case *ir.TermBr:
+		return &v.Metadata
+	case *ir.TermCondBr:
+		return &v.Metadata
+	case *ir.TermSwitch:
+		return &v.Metadata
+	case *ir.TermUnreachable:
+		return &v.Metadata
+	}
+	return nil
+}
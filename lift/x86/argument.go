@@ -7,7 +7,6 @@ import (
 
 	"github.com/decomp/exp/bin"
 	"github.com/decomp/exp/disasm/x86"
-	"github.com/kr/pretty"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/metadata"
@@ -28,6 +27,10 @@ func (f *Func) useArg(arg *x86.Arg) value.Value {
 		mem := x86.NewMem(a, arg.Parent)
 		return f.useMem(mem)
 	case x86asm.Imm:
+		// Address of string literal.
+		if g, ok := f.l.Strings[bin.Address(a)]; ok {
+			return constant.NewPtrToInt(g, types.I32)
+		}
 		return constant.NewInt(int64(a), types.I32)
 	case x86asm.Rel:
 		next := arg.Parent.Addr + bin.Address(arg.Parent.Len)
@@ -92,7 +95,26 @@ func (f *Func) defArgElem(arg *x86.Arg, v value.Value, elem types.Type) {
 
 // useReg loads and returns a value from the given x86 register, emitting code
 // to f.
+//
+// Sub-registers (e.g. AL, AH and AX) are extracted from the storage of their
+// parent register (e.g. EAX), and PSEUDO-registers (e.g. EDX:EAX) are composed
+// from their high and low halves.
 func (f *Func) useReg(reg *x86.Reg) value.Named {
+	if hi, lo, ok := regPair(reg.Reg); ok {
+		typ := regType(reg.Reg).(*types.IntType)
+		shift := regType(lo.Reg).(*types.IntType).Size
+		tmp := f.cur.NewZExt(f.useReg(hi), typ)
+		x := f.cur.NewShl(tmp, constant.NewInt(int64(shift), typ))
+		y := f.cur.NewZExt(f.useReg(lo), typ)
+		return f.cur.NewOr(x, y)
+	}
+	if parent, shift, ok := f.l.subReg(reg.Reg); ok {
+		var v value.Value = f.cur.NewLoad(f.reg(parent))
+		if shift != 0 {
+			v = f.cur.NewLShr(v, constant.NewInt(int64(shift), v.Type()))
+		}
+		return f.cur.NewTrunc(v, regType(reg.Reg))
+	}
 	src := f.reg(reg.Reg)
 	return f.cur.NewLoad(src)
 }
@@ -100,6 +122,9 @@ func (f *Func) useReg(reg *x86.Reg) value.Named {
 // useRegElem loads and returns a value of the specified element type from the
 // given x86 register, emitting code to f.
 func (f *Func) useRegElem(reg *x86.Reg, elem types.Type) value.Value {
+	if elem.Equal(regType(reg.Reg)) {
+		return f.useReg(reg)
+	}
 	src := f.reg(reg.Reg)
 	typ := types.NewPointer(elem)
 	if !typ.Equal(src.Type()) {
@@ -109,19 +134,67 @@ func (f *Func) useRegElem(reg *x86.Reg, elem types.Type) value.Value {
 }
 
 // defReg stores the value to the given x86 register, emitting code to f.
+//
+// Sub-registers (e.g. AL, AH and AX) are inserted into the storage of their
+// parent register (e.g. EAX), leaving the remaining bits unchanged; except for
+// 32-bit sub-registers in 64-bit mode, which are zero-extended into their
+// parent register. PSEUDO-registers (e.g. EDX:EAX) are split into their high
+// and low halves.
 func (f *Func) defReg(reg *x86.Reg, v value.Value) {
+	typ, isInt := regType(reg.Reg).(*types.IntType)
+	if c, ok := v.(*constant.Int); ok && isInt {
+		// Integer constants are typed based on the destination register, and
+		// truncated if needed.
+		v = constant.NewInt(c.X.Int64()&mask(typ.Size), typ)
+	}
+	if hi, lo, ok := regPair(reg.Reg); ok {
+		shift := regType(lo.Reg).(*types.IntType).Size
+		x := f.cur.NewLShr(v, constant.NewInt(int64(shift), typ))
+		f.defReg(hi, f.cur.NewTrunc(x, regType(hi.Reg)))
+		f.defReg(lo, f.cur.NewTrunc(v, regType(lo.Reg)))
+		return
+	}
+	if parent, shift, ok := f.l.subReg(reg.Reg); ok {
+		ptyp := regType(parent).(*types.IntType)
+		dst := f.reg(parent)
+		var x value.Value
+		if c, ok := v.(*constant.Int); ok {
+			x = constant.NewInt(c.X.Int64()<<uint(shift), ptyp)
+		} else {
+			x = f.cur.NewZExt(v, ptyp)
+			if shift != 0 {
+				x = f.cur.NewShl(x, constant.NewInt(int64(shift), ptyp))
+			}
+		}
+		if typ.Size != 32 {
+			// Preserve the remaining bits of the parent register.
+			old := f.cur.NewLoad(dst)
+			m := ^(mask(typ.Size) << uint(shift))
+			tmp := f.cur.NewAnd(old, constant.NewInt(m, ptyp))
+			x = f.cur.NewOr(tmp, x)
+		}
+		f.cur.NewStore(x, dst)
+		return
+	}
 	dst := f.reg(reg.Reg)
 	f.cur.NewStore(v, dst)
-	switch reg.Reg {
-	case x86asm.EAX, x86asm.EDX:
-		// Redefine the PSEUDO-register EDX:EAX based on change in EAX or EDX.
-		f.redefEDX_EAX()
+}
+
+// mask returns a bit mask of the given number of low-order bits.
+func mask(size int) int64 {
+	if size >= 64 {
+		return -1
 	}
+	return 1<<uint(size) - 1
 }
 
 // defRegElem stores the value of the specified element type to the given x86
 // register, emitting code to f.
 func (f *Func) defRegElem(reg *x86.Reg, v value.Value, elem types.Type) {
+	if elem.Equal(regType(reg.Reg)) {
+		f.defReg(reg, v)
+		return
+	}
 	dst := f.reg(reg.Reg)
 	typ := types.NewPointer(elem)
 	if !typ.Equal(dst.Type()) {
@@ -166,9 +239,18 @@ func (f *Func) useMemElem(mem *x86.Mem, elem types.Type) value.Value {
 
 // defMem stores the value to the given memory reference, emitting code to f.
 func (f *Func) defMem(mem *x86.Mem, v value.Value) {
+	if mem.Parent != nil && f.l.constStores[mem.Parent.Addr] {
+		// Store of the initializer of a write-once global variable.
+		return
+	}
 	dst := f.mem(mem)
 	// Bitcast pointer to appropriate size.
 	dst = f.castToPtr(dst, mem.Parent)
+	// Store integer values (e.g. parameters passed on the stack) to stack slots
+	// and global variables of floating-point type.
+	if t, ok := dst.Type().(*types.PointerType); ok && types.IsFloat(t.Elem) && types.IsInt(v.Type()) {
+		dst = f.cur.NewBitCast(dst, types.NewPointer(v.Type()))
+	}
 	f.cur.NewStore(v, dst)
 }
 
@@ -193,12 +275,41 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 		index   value.Value
 		disp    value.Value
 	)
-	if mem.Mem.Segment != 0 {
+	// Parse Segment register.
+	var rel bin.Address
+	if f.l.Mode == 16 {
+		// Resolve memory references of 16-bit real mode to linear addresses,
+		// based on the constant segment registers of the function.
+		if seg, ok := f.l.segBase(f.segRegs, mem.Mem); ok {
+			rel = seg
+		} else if mem.Mem.Segment != 0 {
+			segment = f.useReg(mem.Segment())
+		}
+	} else if mem.Mem.Segment == x86asm.FS || mem.Mem.Segment == x86asm.GS {
+		// Handle memory references relative to the thread information block or
+		// thread-local storage.
+		return f.segMem(mem)
+	} else if mem.Mem.Segment != 0 {
 		segment = f.useReg(mem.Segment())
 	}
 
+	// Handle indirect memory references to global arrays, and memory references
+	// bounded by value-set analysis to stack slots and global variables.
+	if segment == nil && f.isIndirectMem(mem) {
+		// Handle indexed accesses to global arrays.
+		if v, ok := f.arrayElem(mem, rel); ok {
+			return v
+		}
+		if v, ok := f.vsaMem(mem); ok {
+			return v
+		}
+		// Handle struct field accesses relative to base pointers.
+		if v, ok := f.structField(mem); ok {
+			return v
+		}
+	}
+
 	// Parse Base register.
-	var rel bin.Address
 	switch mem.Mem.Base {
 	case 0:
 		// no base register.
@@ -206,6 +317,12 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 		// Handle IP-relative addressing; common in 64-bit x86.
 		rel = mem.Parent.Addr + bin.Address(mem.Parent.Len)
 	default:
+		if addr, ok := f.picRegs[mem.Mem.Base]; ok {
+			// Handle addressing relative to PIC base register; common in 32-bit
+			// position-independent code.
+			rel = addr
+			break
+		}
 		base = f.useReg(mem.Base())
 	}
 	if mem.Mem.Index != 0 {
@@ -223,20 +340,33 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 	if segment == nil && index == nil {
 		// Stack local memory access.
 		switch mem.Mem.Base {
-		case x86asm.ESP, x86asm.EBP:
-			name := fmt.Sprintf("%s_%d", strings.ToLower(x86.Register(mem.Mem.Base).String()), f.espDisp+mem.Disp)
-			if v, ok := f.locals[name]; ok {
-				return v
+		case x86asm.SP, x86asm.BP, x86asm.ESP, x86asm.EBP, x86asm.RSP, x86asm.RBP:
+			base, disp := mem.Mem.Base, f.espDisp+mem.Disp
+			// Name stack slots of 16-bit real mode after the 32-bit registers.
+			switch base {
+			case x86asm.SP:
+				base = x86asm.ESP
+			case x86asm.BP:
+				base = x86asm.EBP
+			}
+			if f.hasFrame && (base == x86asm.EBP || base == x86asm.RBP) {
+				// Locate stack slot relative to the stack pointer at function
+				// entry, based on the frame pointer.
+				base, disp = x86asm.ESP, f.ebpDisp+mem.Disp
+				if mem.Mem.Base == x86asm.RBP {
+					base = x86asm.RSP
+				}
 			}
-			v := ir.NewAlloca(types.I32)
-			v.SetName(name)
-			f.locals[name] = v
+			name := fmt.Sprintf("%s_%d", strings.ToLower(x86.Register(base).String()), disp)
+			v := f.local(name)
+			f.markLocal(v, name, mem.Parent)
 			return v
 		}
 	}
 
-	// Handle disposition.
-	if mem.Disp != 0 {
+	// Handle disposition; direct memory references relative to a segment or
+	// PIC base register may have zero displacement.
+	if mem.Disp != 0 || rel != 0 && base == nil && index == nil {
 		if context, ok := f.l.Contexts[mem.Parent.Addr]; ok {
 			if c, ok := context.Args[mem.OpIndex]; ok {
 				if o, ok := c["Mem.offset"]; ok {
@@ -286,7 +416,6 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 				},
 			}
 			return g
-			panic(fmt.Errorf("unable to locate value at address %v; referenced from %v instruction at %v", addr, mem.Parent.Op, mem.Parent.Addr))
 		}
 		return disp
 	}
@@ -325,7 +454,7 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 
 	// Handle dynamic memory reference.
 	if src == nil {
-		pretty.Println(mem)
+		trace.Dump(mem)
 		panic("unable to locate memory reference")
 	}
 
@@ -335,6 +464,149 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 	return f.castToPtr(src, mem.Parent)
 }
 
+// arrayElem returns a pointer to the element of the global array accessed by
+// the given indexed memory reference (e.g. `[4*ecx+addr]`) relative to the
+// specified segment base, emitting code to f. The boolean return value
+// indicates success.
+//
+// The base register (if any) is assumed to hold a byte offset into the array,
+// which is a multiple of the element size; e.g. the row offset of a
+// two-dimensional array.
+func (f *Func) arrayElem(mem *x86.Mem, rel bin.Address) (value.Value, bool) {
+	if mem.Mem.Index == 0 {
+		return nil, false
+	}
+	addr := rel + bin.Address(mem.Disp)
+	hasBase := mem.Mem.Base != 0
+	if pic, ok := f.picRegs[mem.Mem.Base]; ok {
+		addr += pic
+		hasBase = false
+	}
+	start, g, ok := f.containingGlobal(addr)
+	if !ok {
+		return nil, false
+	}
+	t, ok := g.Typ.Elem.(*types.ArrayType)
+	if !ok {
+		return nil, false
+	}
+	size := f.l.sizeOfType(t.Elem)
+	off, scale := int64(addr-start), int64(mem.Scale)
+	if size == 0 || off%size != 0 || scale%size != 0 {
+		return nil, false
+	}
+	// Element index; (Base+Scale*Index+Disp-start)/size.
+	typ := types.NewInt(f.l.Mode)
+	idx := f.convert(f.useReg(mem.Index()), typ)
+	if k := scale / size; k != 1 {
+		idx = f.cur.NewMul(idx, constant.NewInt(k, typ))
+	}
+	if hasBase {
+		base := f.convert(f.useReg(mem.Base()), typ)
+		idx = f.cur.NewAdd(idx, f.cur.NewSDiv(base, constant.NewInt(size, typ)))
+	}
+	if n := off / size; n != 0 {
+		idx = f.cur.NewAdd(idx, constant.NewInt(n, typ))
+	}
+	indices := []value.Value{
+		constant.NewInt(0, types.I64),
+		idx,
+	}
+	v := f.cur.NewGetElementPtr(g, indices...)
+	return f.castToPtr(v, mem.Parent), true
+}
+
+// local returns a pointer to the stack slot of the given name, allocating it on
+// first use.
+func (f *Func) local(name string) *ir.InstAlloca {
+	if v, ok := f.locals[name]; ok {
+		return v
+	}
+	var typ types.Type = types.I32
+	switch f.l.Mode {
+	case 16:
+		typ = types.I16
+	case 64:
+		typ = types.I64
+	}
+	if t, ok := f.floatSlots[name]; ok {
+		typ = t
+	}
+	v := ir.NewAlloca(typ)
+	v.SetName(name)
+	f.locals[name] = v
+	return v
+}
+
+// isIndirectMem reports whether the given memory argument is an indirect
+// memory reference through an index register or a base register other than
+// the stack, frame, instruction and PIC base registers.
+func (f *Func) isIndirectMem(mem *x86.Mem) bool {
+	if mem.Mem.Index != 0 {
+		return true
+	}
+	switch mem.Mem.Base {
+	case 0, x86asm.IP, x86asm.EIP, x86asm.RIP:
+		return false
+	case x86asm.SP, x86asm.BP, x86asm.ESP, x86asm.EBP, x86asm.RSP, x86asm.RBP:
+		return false
+	}
+	_, ok := f.picRegs[mem.Mem.Base]
+	return !ok
+}
+
+// vsaMem returns a pointer to the stack slot or global variable accessed by the
+// given indirect memory reference, as bounded by value-set analysis, emitting
+// code to f. The boolean return value indicates success.
+//
+// Memory references bounded to a single address are resolved statically, and
+// memory references bounded to the extent of a single global variable (e.g.
+// array element access) are lowered to a byte offset into the global variable.
+func (f *Func) vsaMem(mem *x86.Mem) (value.Value, bool) {
+	v, ok := f.memSets[mem.Parent.Addr]
+	if !ok {
+		return nil, false
+	}
+	if off, ok := v.singleton(); ok {
+		if v.region == vsaStack {
+			name := f.slotName(off)
+			dbg.Printf("memory reference at %v bounded to stack slot %q", mem.Parent.Addr, name)
+			local := f.local(name)
+			f.markLocal(local, name, mem.Parent)
+			return local, true
+		}
+		return f.global(bin.Address(off))
+	}
+	if v.region != vsaGlobal {
+		return nil, false
+	}
+	start, g, ok := f.containingGlobal(bin.Address(v.lo))
+	if !ok {
+		return nil, false
+	}
+	end := start + bin.Address(f.l.sizeOfType(g.Typ.Elem))
+	if bin.Address(v.hi)+bin.Address(mem.Parent.MemBytes) > end {
+		return nil, false
+	}
+	dbg.Printf("memory reference at %v bounded to %v[%d:%d]", mem.Parent.Addr, g.Name, int64(v.lo)-int64(start), int64(v.hi)-int64(start))
+	// Byte offset into the global variable; Base+Scale*Index+Disp-start.
+	typ := types.NewInt(f.l.Mode)
+	offset := value.Value(constant.NewInt(mem.Disp-int64(start), typ))
+	if mem.Mem.Base != 0 {
+		base := f.convert(f.useReg(mem.Base()), typ)
+		offset = f.cur.NewAdd(base, offset)
+	}
+	if mem.Mem.Index != 0 {
+		var index value.Value = f.convert(f.useReg(mem.Index()), typ)
+		if mem.Scale > 1 {
+			index = f.cur.NewMul(index, constant.NewInt(int64(mem.Scale), typ))
+		}
+		offset = f.cur.NewAdd(index, offset)
+	}
+	src := f.cur.NewBitCast(g, types.NewPointer(types.I8))
+	return f.castToPtr(f.cur.NewGetElementPtr(src, offset), mem.Parent), true
+}
+
 // castToPtr casts the given value into a pointer, where the element type is
 // derrived from src and instruction prefixes, with instruction prefix takes
 // precedence.
@@ -360,12 +632,20 @@ func (f *Func) castToPtr(src value.Value, parent *x86.Inst) value.Value {
 			}
 			switch prefix &^ x86asm.PrefixImplicit {
 			case x86asm.PrefixData16:
-				bits = 16
+				// In 16-bit mode, the operand size prefix selects 32-bit operands,
+				// as reflected by MemBytes.
+				if f.l.Mode != 16 {
+					bits = 16
+				}
 			case x86asm.PrefixREP, x86asm.PrefixREPN:
 				// nothing to do.
 			case x86asm.PrefixREX | x86asm.PrefixREXW:
 				// TODO: Implement support for REX.W
 			default:
+				if f.l.isSegOverride(prefix) {
+					// Segment override resolved by f.mem.
+					continue
+				}
 				panic(fmt.Errorf("support for prefix %v (0x%04X) not yet implemented", prefix, uint16(prefix)))
 			}
 		}
@@ -391,6 +671,33 @@ func (f *Func) castToPtr(src value.Value, parent *x86.Inst) value.Value {
 	return src
 }
 
+// convert converts the given value into the specified type, emitting code to f.
+// Integers are truncated or zero-extended, integers and pointers are converted
+// using inttoptr and ptrtoint, and values of remaining types are bitcast.
+func (f *Func) convert(v value.Value, typ types.Type) value.Value {
+	if v.Type().Equal(typ) {
+		return v
+	}
+	switch t := typ.(type) {
+	case *types.IntType:
+		switch from := v.Type().(type) {
+		case *types.IntType:
+			if from.Size > t.Size {
+				return f.cur.NewTrunc(v, t)
+			}
+			return f.cur.NewZExt(v, t)
+		case *types.PointerType:
+			return f.convert(f.cur.NewPtrToInt(v, types.NewInt(f.l.Mode)), t)
+		}
+	case *types.PointerType:
+		switch v.Type().(type) {
+		case *types.IntType:
+			return f.cur.NewIntToPtr(f.convert(v, types.NewInt(f.l.Mode)), t)
+		}
+	}
+	return f.cur.NewBitCast(v, typ)
+}
+
 // === [ status flag ] =========================================================
 
 // StatusFlag represents the set of status flags.
@@ -560,7 +867,7 @@ func (f *Func) addr(addr bin.Address) (value.Named, bool) {
 	if g, ok := f.global(addr); ok {
 		return g, true
 	}
-	if fn, ok := f.l.Funcs[addr]; ok {
+	if fn, ok := f.l.funcAt(addr); ok {
 		return fn.Function, true
 	}
 	// TODO: Add support for lookup of more globally addressable values.
@@ -577,6 +884,17 @@ func (f *Func) global(addr bin.Address) (value.Named, bool) {
 
 	// Use binary search if indirect access to global variable (e.g. struct
 	// field, array element).
+	if start, g, ok := f.containingGlobal(addr); ok {
+		offset := int64(addr - start)
+		return f.getElementPtr(g, offset), true
+	}
+	return nil, false
+}
+
+// containingGlobal returns the start address of the global variable containing
+// the given address, and the global variable. The boolean return value
+// indicates success.
+func (f *Func) containingGlobal(addr bin.Address) (bin.Address, *ir.Global, bool) {
 	var globalAddrs []bin.Address
 	for globalAddr := range f.l.Globals {
 		globalAddrs = append(globalAddrs, globalAddr)
@@ -593,11 +911,10 @@ func (f *Func) global(addr bin.Address) (value.Named, bool) {
 		size := f.l.sizeOfType(g.Typ.Elem)
 		end := start + bin.Address(size)
 		if start <= addr && addr < end {
-			offset := int64(addr - start)
-			return f.getElementPtr(g, offset), true
+			return start, g, true
 		}
 	}
-	return nil, false
+	return 0, nil, false
 }
 
 // ### [ helpers ] #############################################################
@@ -620,8 +937,22 @@ func (f *Func) getAddr(arg *x86.Arg) (bin.Address, bool) {
 		addr := next + bin.Address(a)
 		return addr, true
 	case x86asm.Mem:
-		if a.Segment == 0 && a.Base == 0 && a.Scale == 0 && a.Index == 0 {
-			return bin.Address(a.Disp), true
+		if a.Base == 0 && a.Scale == 0 && a.Index == 0 {
+			// Direct memory reference; resolved to linear address in 16-bit
+			// real mode.
+			if seg, ok := f.l.segBase(f.segRegs, a); ok {
+				return seg + f.l.memDisp(a), true
+			}
+		}
+		// RIP-relative memory reference; e.g. `call [rel printf@GOT]`.
+		if a.Segment == 0 && a.Base == x86asm.RIP && a.Index == 0 {
+			next := arg.Parent.Addr + bin.Address(arg.Parent.Len)
+			return next + bin.Address(a.Disp), true
+		}
+		// Memory reference relative to PIC base register; e.g.
+		// `call [ebx+printf@GOT]`.
+		if addr, ok := f.picRegs[a.Base]; ok && a.Segment == 0 && a.Index == 0 {
+			return addr + bin.Address(a.Disp), true
 		}
 	}
 	return 0, false
@@ -669,7 +1000,8 @@ func (f *Func) getFunc(arg *x86.Arg) (value.Named, *types.FuncType, ir.CallConv,
 	}
 
 	if addr, ok := f.getAddr(arg); ok {
-		if fn, ok := f.l.Funcs[addr]; ok {
+		if fn, ok := f.l.funcAt(addr); ok {
+			fn.resolveRet()
 			v := fn.Function
 			return v, v.Sig, v.CallConv, true
 		}
@@ -690,19 +1022,28 @@ func (f *Func) getFunc(arg *x86.Arg) (value.Named, *types.FuncType, ir.CallConv,
 		panic(fmt.Errorf("unable to locate function at address %v referenced from instruction at address %v", addr, arg.Parent.Addr))
 	}
 
+	// Handle virtual calls resolved through vtables.
+	if target, ok := f.virtCalls[arg.Parent.Addr]; ok {
+		if fn, ok := f.l.funcAt(target); ok {
+			fn.resolveRet()
+			v := fn.Function
+			return v, v.Sig, v.CallConv, true
+		}
+	}
+
 	// Handle function pointers in structures.
 	switch a := arg.Arg.(type) {
 	case x86asm.Mem:
 		if a.Base != 0 {
 			context, ok := f.l.Contexts[arg.Parent.Addr]
 			if !ok {
-				pretty.Println(arg.Arg)
+				trace.Dump(arg.Arg)
 				panic(fmt.Errorf("unable to locate context for %v register used at %v", a.Base, arg.Parent.Addr))
 			}
 			if c, ok := context.Regs[x86.Register(a.Base)]; ok {
 				if typStr, ok := c["type"]; ok {
 					typ := f.l.parseType(typStr.String())
-					fmt.Println("context type:", typ)
+					trace.Println("context type:", typ)
 					reg := f.reg(a.Base)
 					var v value.Named = f.cur.NewBitCast(reg, typ)
 					v = f.cur.NewLoad(v)
@@ -723,8 +1064,8 @@ func (f *Func) getFunc(arg *x86.Arg) (value.Named, *types.FuncType, ir.CallConv,
 					// HACK: Remove once proper type and data flow analysis has been
 					// implemented.
 					if extractvalue, ok := c["extractvalue"]; ok && extractvalue.Bool() {
-						fmt.Println("extractvalue:", v)
-						fmt.Println("extractvalue.Type():", v.Type())
+						trace.Println("extractvalue:", v)
+						trace.Println("extractvalue.Type():", v.Type())
 						// TODO: Handle index based on Index regster if present.
 						v = f.cur.NewExtractValue(v, []int64{0})
 					}
@@ -782,7 +1123,7 @@ func (f *Func) getFunc(arg *x86.Arg) (value.Named, *types.FuncType, ir.CallConv,
 		if a.Index != 0 {
 			context, ok := f.l.Contexts[arg.Parent.Addr]
 			if !ok {
-				pretty.Println(arg.Arg)
+				trace.Dump(arg.Arg)
 				panic(fmt.Errorf("unable to locate context for %v register used at %v", a.Index, arg.Parent.Addr))
 			}
 			if c, ok := context.Regs[x86.Register(a.Index)]; ok {
@@ -816,29 +1157,15 @@ func (f *Func) getFunc(arg *x86.Arg) (value.Named, *types.FuncType, ir.CallConv,
 		}
 	}
 
-	fmt.Printf("unable to locate function for argument %v of instruction at address %v\n", arg.Arg, arg.Parent.Addr)
+	trace.Printf("unable to locate function for argument %v of instruction at address %v", arg.Arg, arg.Parent.Addr)
 	switch a := arg.Arg.(type) {
 	case x86asm.Rel:
 		next := arg.Parent.Addr + bin.Address(arg.Parent.Len)
 		addr := next + bin.Address(a)
-		fmt.Println("   addr:", addr)
+		trace.Println("   addr:", addr)
 	case x86asm.Mem:
 		addr := bin.Address(a.Disp)
-		fmt.Println("   addr:", addr)
+		trace.Println("   addr:", addr)
 	}
 	panic("not yet implemented")
 }
-
-// redefEDX_EAX redefines the 64-bit pseudo-register EDX:EAX based on the value
-// of EAX and EDX.
-func (f *Func) redefEDX_EAX() {
-	if !f.usesEDX_EAX {
-		return
-	}
-	edx := f.useReg(x86.EDX)
-	eax := f.useReg(x86.EAX)
-	tmp1 := f.cur.NewSExt(edx, types.I64)
-	tmp2 := f.cur.NewZExt(eax, types.I64)
-	tmp := f.cur.NewOr(tmp1, tmp2)
-	f.defReg(x86.EDX_EAX, tmp)
-}
@@ -0,0 +1,190 @@
+package x86
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+)
+
+// Function attributes inferred from the bodies of lifted functions.
+const (
+	// The function does not unwind; i.e. it makes no calls to functions which
+	// may raise exceptions.
+	attrNoUnwind = "nounwind"
+	// The function does not write to memory other than its local variables.
+	attrReadOnly = "readonly"
+	// The function does not access memory other than its local variables.
+	attrReadNone = "readnone"
+)
+
+// funcEffects records the side effects of a function.
+type funcEffects struct {
+	// The function may read memory other than its local variables.
+	reads bool
+	// The function may write memory other than its local variables.
+	writes bool
+	// The function may unwind.
+	unwinds bool
+}
+
+// InferAttrs infers the function attributes (nounwind, readonly and readnone)
+// of the given lifted functions, based on the memory accesses and calls of
+// their bodies. The inferred attributes are recorded in l.Attrs, indexed by
+// function name.
+//
+// Calls to functions without bodies (e.g. imports and stubs of unsupported
+// instructions) and indirect calls are assumed to access memory and to unwind.
+// Mutually recursive functions are resolved optimistically; i.e. effects are
+// propagated from callees to callers until a fixed point is reached.
+func (l *Lifter) InferAttrs(fs []*Func) {
+	effects := make(map[*ir.Function]*funcEffects)
+	for _, f := range fs {
+		if len(f.Blocks) == 0 {
+			continue
+		}
+		effects[f.Function] = &funcEffects{}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, f := range fs {
+			e, ok := effects[f.Function]
+			if !ok {
+				continue
+			}
+			var got funcEffects
+			for _, block := range f.Blocks {
+				for _, inst := range block.Insts {
+					instEffects(inst, effects, &got)
+				}
+			}
+			if got != *e {
+				*e = got
+				changed = true
+			}
+		}
+	}
+	if l.Attrs == nil {
+		l.Attrs = make(map[string][]string)
+	}
+	for _, f := range fs {
+		e, ok := effects[f.Function]
+		if !ok {
+			continue
+		}
+		var attrs []string
+		if !e.unwinds {
+			attrs = append(attrs, attrNoUnwind)
+		}
+		switch {
+		case !e.reads && !e.writes:
+			attrs = append(attrs, attrReadNone)
+		case !e.writes:
+			attrs = append(attrs, attrReadOnly)
+		}
+		if len(attrs) == 0 {
+			delete(l.Attrs, f.Name)
+			continue
+		}
+		dbg.Printf("inferred attributes of function %q: %s", f.Name, strings.Join(attrs, " "))
+		l.Attrs[f.Name] = attrs
+	}
+}
+
+// instEffects records the side effects of the given instruction in e, based on
+// the side effects of functions with bodies.
+func instEffects(inst ir.Instruction, effects map[*ir.Function]*funcEffects, e *funcEffects) {
+	switch inst := inst.(type) {
+	case *ir.InstLoad:
+		if _, ok := inst.Src.(*ir.InstAlloca); !ok {
+			e.reads = true
+		}
+	case *ir.InstStore:
+		if _, ok := inst.Dst.(*ir.InstAlloca); !ok {
+			e.writes = true
+		}
+	case *ir.InstCall:
+		callee, ok := inst.Callee.(*ir.Function)
+		if !ok {
+			// Indirect call.
+			e.reads, e.writes, e.unwinds = true, true, true
+			return
+		}
+		ce, ok := effects[callee]
+		if !ok {
+			// Call to function without body.
+			e.reads, e.writes, e.unwinds = true, true, true
+			return
+		}
+		e.reads = e.reads || ce.reads
+		e.writes = e.writes || ce.writes
+		e.unwinds = e.unwinds || ce.unwinds
+	}
+}
+
+// FormatModule returns the LLVM IR assembly of the given module, with the
+// function attributes of l.Attrs attached to the definitions and declarations
+// of functions.
+func (l *Lifter) FormatModule(m *ir.Module) string {
+	s := m.String()
+	if len(l.Attrs) == 0 {
+		return s
+	}
+	// Function definitions and declarations are printed in order, after type
+	// definitions and global variables.
+	lines := strings.Split(s, "\n")
+	i := 0
+	for _, fn := range m.Funcs {
+		for ; i < len(lines); i++ {
+			if strings.HasPrefix(lines[i], "define ") || strings.HasPrefix(lines[i], "declare ") {
+				break
+			}
+		}
+		if i == len(lines) {
+			break
+		}
+		if attrs, ok := l.Attrs[fn.Name]; ok {
+			lines[i] = insertAttrs(lines[i], fn.Ident(), attrs)
+		}
+		i++
+	}
+	return strings.Join(lines, "\n")
+}
+
+// insertAttrs inserts the given function attributes after the parameter list
+// of the function definition or declaration line, the function of which has
+// the specified identifier. The line is returned unchanged if the parameter
+// list could not be located.
+func insertAttrs(line, ident string, attrs []string) string {
+	start := strings.Index(line, ident+"(")
+	if start == -1 {
+		return line
+	}
+	depth := 0
+	quoted := false
+	for i := start + len(ident); i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+			// Skip parentheses of quoted names.
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				sorted := append([]string(nil), attrs...)
+				sort.Strings(sorted)
+				buf := &bytes.Buffer{}
+				buf.WriteString(line[:i+1])
+				for _, attr := range sorted {
+					buf.WriteString(" " + attr)
+				}
+				buf.WriteString(line[i+1:])
+				return buf.String()
+			}
+		}
+	}
+	return line
+}
@@ -0,0 +1,202 @@
+package x86
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/asm"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+)
+
+// cacheVersion specifies the version of the lifted output. Increment to
+// invalidate cached functions when changing how instructions are lifted.
+const cacheVersion = 8
+
+// A Cache is an on-disk cache of lifted functions, keyed by the machine code
+// and signature of the function and its callees, the lifter settings and the
+// lifter version.
+type Cache struct {
+	// Cache directory.
+	Dir string
+	// Config specifies additional settings affecting the cached output (e.g.
+	// whether registers are promoted to SSA values); part of the cache key.
+	Config string
+}
+
+// NewCache returns a new on-disk cache of lifted functions, stored in the
+// given directory.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+// Key returns the cache key of the given function. The key should be computed
+// prior to lifting the function, as lifting may refine the function signature.
+func (c *Cache) Key(f *Func) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "version: %d\n", cacheVersion)
+	fmt.Fprintf(h, "config: %s\n", c.Config)
+	fmt.Fprintf(h, "settings: %v %v %v %v %v %v %v\n", f.l.Strict, f.l.DebugAddrs, f.l.AsmAnnotations, f.l.AliasAnnotations, f.l.ConstGlobals, f.l.Prune, f.l.TrapDiv)
+	fmt.Fprintf(h, "func: %s %v %v %v\n", f.Name, f.CallConv, f.regConv, f.Sig)
+	// Write-once global variables are located when inferring global variables.
+	f.l.inferGlobals()
+	// User-supplied names and comments affect the lifted names and metadata of
+	// functions, global variables and instructions.
+	var nameAddrs bin.Addresses
+	for addr := range f.l.Names {
+		nameAddrs = append(nameAddrs, addr)
+	}
+	sort.Sort(nameAddrs)
+	for _, addr := range nameAddrs {
+		n := f.l.Names[addr]
+		fmt.Fprintf(h, "name: %v %q %q\n", addr, n.Name, n.Comment)
+	}
+	for _, blockAddr := range f.blockAddrs() {
+		block := f.AsmFunc.Blocks[blockAddr]
+		end := block.Term.Addr + bin.Address(block.Term.Len)
+		code := f.l.File.Code(blockAddr)
+		if n := int(end - blockAddr); n < len(code) {
+			code = code[:n]
+		}
+		fmt.Fprintf(h, "block: %v %X\n", blockAddr, code)
+		// Signatures of callees affect the lifted call instructions, and stores
+		// of write-once global variables are omitted.
+		for _, inst := range append(block.Insts, block.Term) {
+			if f.l.constStores[inst.Addr] {
+				fmt.Fprintf(h, "const store: %v\n", inst.Addr)
+			}
+			target, ok := x86.CallTarget(inst)
+			if !ok {
+				continue
+			}
+			if callee, ok := f.l.funcAt(target); ok {
+				fmt.Fprintf(h, "callee: %v %s %v %v %v\n", target, callee.Name, callee.CallConv, callee.regConv, callee.Sig)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Load loads the lifted function with the given cache key from the cache,
+// replacing the body and signature of f. The boolean return value indicates
+// whether the function was present in the cache.
+func (c *Cache) Load(f *Func, key string) (bool, error) {
+	buf, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	dbg.Printf("loading function %q at %v from cache", f.Name, f.AsmFunc.Addr)
+	// Global variables are otherwise inferred the first time a function is
+	// lifted.
+	f.l.inferGlobals()
+	m, err := asm.ParseString(string(buf))
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	for _, fn := range m.Funcs {
+		if fn.Name != f.Name || len(fn.Blocks) == 0 {
+			continue
+		}
+		f.l.relink(f, fn)
+		f.Typ = fn.Typ
+		f.Sig = fn.Sig
+		f.Blocks = fn.Blocks
+		f.sigUnknown = false
+		return true, nil
+	}
+	return false, errors.Errorf("unable to locate function %q in cache entry %q", f.Name, key)
+}
+
+// Store stores the given lifted function to the cache, using the specified
+// cache key. The cache entry is a self-contained module of the function,
+// declaring the global variables and functions referenced from the function.
+func (c *Cache) Store(f *Func, key string) error {
+	if err := ioutil.WriteFile(c.path(key), []byte(f.l.FuncModule(f).String()+"\n"), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// path returns the path of the cache entry with the given key.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".ll")
+}
+
+// relink rebinds the global variables and functions referenced from the body
+// of fn, as parsed from the cache entry of f, to those of the lifter; matched
+// by name. Functions unknown to the lifter (e.g. stubs of unsupported
+// instructions and intrinsics, which are otherwise declared when lifting the
+// function) are added to l.Stubs.
+func (l *Lifter) relink(f *Func, fn *ir.Function) {
+	globals := make(map[string]*ir.Global)
+	for _, g := range l.Globals {
+		globals[g.Name] = g
+	}
+	for name, g := range l.Externs {
+		globals[name] = g
+	}
+	funcs := make(map[string]*ir.Function)
+	for name, callee := range l.FuncByName {
+		funcs[name] = callee
+	}
+	for _, callee := range l.Funcs {
+		funcs[callee.Name] = callee.Function
+	}
+	for name, stub := range l.Stubs {
+		funcs[name] = stub
+	}
+	// Recursive calls refer to f.
+	funcs[f.Name] = f.Function
+	var rebind func(v value.Value) value.Value
+	rebindConst := func(c constant.Constant) constant.Constant {
+		return rebind(c).(constant.Constant)
+	}
+	rebind = func(v value.Value) value.Value {
+		switch v := v.(type) {
+		case *ir.Global:
+			if g, ok := globals[v.Name]; ok {
+				return g
+			}
+		case *ir.Function:
+			if callee, ok := funcs[v.Name]; ok {
+				return callee
+			}
+			dbg.Printf("declaring stub function %q referenced from cached function %q", v.Name, f.Name)
+			funcs[v.Name] = v
+			l.Stubs[v.Name] = v
+		case *constant.ExprGetElementPtr:
+			v.Src = rebindConst(v.Src)
+			for i, index := range v.Indices {
+				v.Indices[i] = rebindConst(index)
+			}
+		case *constant.ExprBitCast:
+			v.From = rebindConst(v.From)
+		case *constant.ExprPtrToInt:
+			v.From = rebindConst(v.From)
+		case *constant.ExprIntToPtr:
+			v.From = rebindConst(v.From)
+		}
+		return v
+	}
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			mapOperands(inst, rebind)
+		}
+		mapTermOperands(block.Term, rebind)
+	}
+}
@@ -0,0 +1,360 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// maxStartupDepth specifies the maximum call depth from the entry point of the
+// startup code of the C runtime to the call of the main function.
+const maxStartupDepth = 4
+
+// maxSweepInsts specifies the maximum number of instructions of startup
+// functions decoded using a linear sweep.
+const maxSweepInsts = 512
+
+// Imported functions of the C runtime, called by startup code.
+var (
+	// Functions retrieving the arguments of main in the Universal CRT, called
+	// immediately before main (invoke_main of MSVC 2015 and later).
+	ucrtArgFuncs = map[string]bool{
+		"__p___argc":                       true,
+		"__p___argv":                       true,
+		"__p___wargv":                      true,
+		"_get_initial_narrow_environment":  true,
+		"_get_initial_wide_environment":    true,
+		"_get_narrow_winmain_command_line": true,
+		"_get_wide_winmain_command_line":   true,
+	}
+	// Functions terminating the process with the return value of main.
+	exitFuncs = map[string]bool{
+		"exit":        true,
+		"_exit":       true,
+		"ExitProcess": true,
+	}
+	// Functions of the C runtime indicating that main takes wide character
+	// arguments (i.e. wmain or wWinMain).
+	wideArgFuncs = map[string]bool{
+		"__wgetmainargs":                 true,
+		"__p___wargv":                    true,
+		"_get_initial_wide_environment":  true,
+		"_get_wide_winmain_command_line": true,
+	}
+)
+
+// locateMain locates the main function (main, wmain, WinMain or wWinMain) of
+// the binary executable, as called from the startup code of the C runtime at
+// the entry point. The boolean return value indicates success.
+//
+// The main function is located based on the following heuristics, in order:
+//
+//    1. the first argument of __libc_start_main (glibc)
+//    2. a call passing the image base as first argument (WinMain of MSVC)
+//    3. the first call following the retrieval of arguments from the Universal
+//       CRT (invoke_main of MSVC 2015 and later)
+//    4. the last call preceding a call to exit (MSVC and MinGW)
+func (l *Lifter) locateMain() (bin.Address, string, bool) {
+	switch l.File.Compiler {
+	case bin.CompilerGo, bin.CompilerDelphi:
+		// Startup code without a C runtime.
+		return 0, "", false
+	}
+	// Locate startup functions in breadth-first order from the entry point.
+	type item struct {
+		addr  bin.Address
+		depth int
+	}
+	queue := []item{{addr: l.File.Entry}}
+	visited := map[bin.Address]bool{l.File.Entry: true}
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		insts := l.startupInsts(it.addr)
+		if entry, name, ok := l.mainCall(insts); ok {
+			dbg.Printf("located %s at %v, called from startup function at %v", name, entry, it.addr)
+			return entry, name, true
+		}
+		if it.depth+1 >= maxStartupDepth {
+			continue
+		}
+		for _, target := range l.funcCallees(insts) {
+			if visited[target] {
+				continue
+			}
+			visited[target] = true
+			queue = append(queue, item{addr: target, depth: it.depth + 1})
+		}
+	}
+	return 0, "", false
+}
+
+// mainCall locates the call to the main function among the given instructions
+// of a startup function, and returns the address and name of the main function.
+// The boolean return value indicates success.
+func (l *Lifter) mainCall(insts []*x86.Inst) (bin.Address, string, bool) {
+	wide := false
+	for _, name := range l.File.Imports {
+		if wideArgFuncs[name] {
+			wide = true
+			break
+		}
+	}
+	mainName := func(winMain bool) string {
+		switch {
+		case winMain && wide:
+			return "wWinMain"
+		case winMain:
+			return "WinMain"
+		case wide:
+			return "wmain"
+		default:
+			return "main"
+		}
+	}
+	// Index of the last call to an argument retrieval function of the UCRT.
+	lastArg := -1
+	for i, inst := range insts {
+		if inst.Op != x86asm.CALL {
+			continue
+		}
+		target, ok := x86.CallTarget(inst)
+		if !ok {
+			continue
+		}
+		name, _ := l.importName(target)
+		switch {
+		case name == "__libc_start_main":
+			// Heuristic 1.
+			if entry, ok := l.firstArg(insts[:i]); ok {
+				return entry, "main", true
+			}
+		case ucrtArgFuncs[name]:
+			lastArg = i
+		case exitFuncs[name]:
+			// Heuristic 4.
+			for j := i - 1; j >= 0; j-- {
+				if entry, ok := l.directTarget(insts[j]); ok && insts[j].Op == x86asm.CALL && l.isInternal(entry) {
+					return entry, mainName(l.passesImageBase(insts[:j])), true
+				}
+			}
+		case l.isInternal(target):
+			// Heuristic 2.
+			if l.passesImageBase(insts[:i]) {
+				return target, mainName(true), true
+			}
+			// Heuristic 3.
+			if lastArg != -1 {
+				return target, mainName(false), true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// firstArg returns the constant address passed as first argument to the call
+// following the given instructions; i.e. the last push of the address in
+// 32-bit mode, and the address stored into RDI in 64-bit mode. The boolean
+// return value indicates success.
+func (l *Lifter) firstArg(insts []*x86.Inst) (bin.Address, bool) {
+	for i := len(insts) - 1; i >= 0; i-- {
+		inst := insts[i]
+		switch {
+		case l.Mode == 32 && inst.Op == x86asm.PUSH:
+			return l.constAddr(inst, inst.Args[0])
+		case l.Mode == 64 && (inst.Op == x86asm.MOV || inst.Op == x86asm.LEA):
+			if reg, ok := inst.Args[0].(x86asm.Reg); ok && (reg == x86asm.RDI || reg == x86asm.EDI) {
+				return l.constAddr(inst, inst.Args[1])
+			}
+		}
+	}
+	return 0, false
+}
+
+// passesImageBase reports whether the image base (i.e. hInstance of WinMain) is
+// passed as first argument to the call following the given instructions; i.e.
+// the last of the four arguments pushed in 32-bit mode, and the address stored
+// into RCX in 64-bit mode.
+func (l *Lifter) passesImageBase(insts []*x86.Inst) bool {
+	if l.File.ImageBase == 0 {
+		return false
+	}
+	const maxDist = 4
+	for i := len(insts) - 1; i >= 0 && i >= len(insts)-maxDist; i-- {
+		inst := insts[i]
+		switch {
+		case l.Mode == 32 && inst.Op == x86asm.PUSH:
+			addr, ok := l.constAddr(inst, inst.Args[0])
+			return ok && addr == l.File.ImageBase
+		case l.Mode == 64 && (inst.Op == x86asm.MOV || inst.Op == x86asm.LEA):
+			if reg, ok := inst.Args[0].(x86asm.Reg); ok && reg == x86asm.RCX {
+				addr, ok := l.constAddr(inst, inst.Args[1])
+				return ok && addr == l.File.ImageBase
+			}
+		}
+	}
+	return false
+}
+
+// constAddr returns the constant address of the given argument of the
+// instruction; an immediate or the effective address of a RIP-relative memory
+// reference of LEA. The boolean return value indicates success.
+func (l *Lifter) constAddr(inst *x86.Inst, arg x86asm.Arg) (bin.Address, bool) {
+	switch arg := arg.(type) {
+	case x86asm.Imm:
+		return bin.Address(arg), true
+	case x86asm.Mem:
+		if inst.Op == x86asm.LEA && arg.Base == x86asm.RIP && arg.Index == 0 {
+			next := inst.Addr + bin.Address(inst.Len)
+			return next + bin.Address(arg.Disp), true
+		}
+	}
+	return 0, false
+}
+
+// directTarget returns the target of the given direct CALL or JMP instruction.
+// The boolean return value indicates success.
+func (l *Lifter) directTarget(inst *x86.Inst) (bin.Address, bool) {
+	switch inst.Op {
+	case x86asm.CALL, x86asm.JMP:
+		if rel, ok := inst.Args[0].(x86asm.Rel); ok {
+			next := inst.Addr + bin.Address(inst.Len)
+			return next + bin.Address(rel), true
+		}
+	}
+	return 0, false
+}
+
+// funcCallees returns the internal functions called by the function of the
+// given instructions (including tail calls), in order of the instructions.
+func (l *Lifter) funcCallees(insts []*x86.Inst) []bin.Address {
+	instAddrs := make(map[bin.Address]bool)
+	for _, inst := range insts {
+		instAddrs[inst.Addr] = true
+	}
+	var callees []bin.Address
+	for _, inst := range insts {
+		target, ok := l.directTarget(inst)
+		if !ok || !l.isInternal(target) {
+			continue
+		}
+		if inst.Op == x86asm.JMP && instAddrs[target] {
+			// Branch within function.
+			continue
+		}
+		callees = append(callees, target)
+	}
+	return callees
+}
+
+// isInternal reports whether the given address is the address of a function of
+// the binary executable; i.e. located in code and neither an import nor an
+// import thunk.
+func (l *Lifter) isInternal(addr bin.Address) bool {
+	if _, ok := l.importName(addr); ok {
+		return false
+	}
+	return l.File.IsCode(addr)
+}
+
+// importName returns the name of the imported function at the given address;
+// either an import (e.g. IAT entry or PLT stub) or a thunk jumping to an import
+// (e.g. `jmp [__imp__exit]` of MinGW). The boolean return value indicates
+// success.
+func (l *Lifter) importName(addr bin.Address) (string, bool) {
+	if target, ok := l.thunkChain(addr); ok {
+		addr = target
+	}
+	name, ok := l.File.Imports[addr]
+	return name, ok
+}
+
+// StartupFuncs returns the functions of the startup code of the C runtime;
+// i.e. the functions reachable through direct calls from the entry point of
+// the executable, but not from the main function. Lifting may be restricted to
+// application code by skipping startup functions.
+//
+// Pre-condition: the main function has been located (i.e. l.Main != 0).
+func (l *Lifter) StartupFuncs() map[bin.Address]bool {
+	// reachable returns the functions reachable from the given root, without
+	// passing through the specified stop function.
+	reachable := func(root, stop bin.Address) map[bin.Address]bool {
+		visited := map[bin.Address]bool{root: true}
+		queue := []bin.Address{root}
+		for len(queue) > 0 {
+			addr := queue[0]
+			queue = queue[1:]
+			for _, target := range l.funcCallees(l.startupInsts(addr)) {
+				if visited[target] || target == stop {
+					continue
+				}
+				visited[target] = true
+				queue = append(queue, target)
+			}
+		}
+		return visited
+	}
+	startup := reachable(l.File.Entry, l.Main)
+	for addr := range reachable(l.Main, 0) {
+		delete(startup, addr)
+	}
+	return startup
+}
+
+// startupInsts returns the instructions of the startup function at the given
+// address, sorted by address. Startup functions which fail to decode (e.g. as
+// control flow continues past calls to non-returning functions such as
+// __libc_start_main) are decoded using a linear sweep, up to the first
+// unconditional control flow transfer.
+func (l *Lifter) startupInsts(addr bin.Address) []*x86.Inst {
+	asmFunc, err := l.tryDecodeFunc(addr)
+	if err == nil {
+		return funcInsts(asmFunc)
+	}
+	dbg.Printf("unable to decode startup function at %v; falling back to linear sweep; %v", addr, err)
+	var insts []*x86.Inst
+	for len(insts) < maxSweepInsts {
+		inst, err := l.DecodeInst(addr)
+		if err != nil {
+			break
+		}
+		insts = append(insts, inst)
+		switch inst.Op {
+		case x86asm.RET, x86asm.JMP, x86asm.HLT, x86asm.INT, x86asm.UD2:
+			return insts
+		}
+		addr += bin.Address(inst.Len)
+	}
+	return insts
+}
+
+// tryDecodeFunc decodes the function at the given address, recovering from
+// panics of the disassembler (e.g. unsupported indirect jumps of startup code).
+func (l *Lifter) tryDecodeFunc(addr bin.Address) (f *x86.Func, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("unable to decode function at %v; %v", addr, e)
+		}
+	}()
+	return l.DecodeFunc(addr)
+}
+
+// funcInsts returns the instructions (including terminators) of the given
+// function, sorted by address.
+func funcInsts(f *x86.Func) []*x86.Inst {
+	var insts []*x86.Inst
+	for _, block := range f.Blocks {
+		insts = append(insts, block.Insts...)
+		if !block.Term.IsDummyTerm() {
+			insts = append(insts, block.Term)
+		}
+	}
+	less := func(i, j int) bool {
+		return insts[i].Addr < insts[j].Addr
+	}
+	sort.Slice(insts, less)
+	return insts
+}
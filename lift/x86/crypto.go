@@ -0,0 +1,200 @@
+package x86
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/metadata"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Cryptographic constants ] #############################################
+
+// A cryptoSig is a signature of a well-known table of a cryptographic, checksum
+// or compression algorithm.
+type cryptoSig struct {
+	// Name of the table; e.g. "AES S-box".
+	name string
+	// Leading bytes of the table (little-endian).
+	sig []byte
+	// Size in bytes of the table.
+	size int
+}
+
+// cryptoSigs specifies the signatures of well-known tables. Signatures sharing
+// a prefix are ordered from longest to shortest.
+var cryptoSigs = []cryptoSig{
+	{name: "AES S-box", sig: []byte{0x63, 0x7C, 0x77, 0x7B, 0xF2, 0x6B, 0x6F, 0xC5, 0x30, 0x01, 0x67, 0x2B, 0xFE, 0xD7, 0xAB, 0x76}, size: 256},
+	{name: "AES inverse S-box", sig: []byte{0x52, 0x09, 0x6A, 0xD5, 0x30, 0x36, 0xA5, 0x38, 0xBF, 0x40, 0xA3, 0x9E, 0x81, 0xF3, 0xD7, 0xFB}, size: 256},
+	// Te0: 0xC66363A5, 0xF87C7C84, ...
+	{name: "AES T-table", sig: []byte{0xA5, 0x63, 0x63, 0xC6, 0x84, 0x7C, 0x7C, 0xF8, 0x99, 0x77, 0x77, 0xEE}, size: 1024},
+	// 0x00000000, 0x77073096, 0xEE0E612C, 0x990951BA, ...
+	{name: "CRC-32 table", sig: []byte{0x00, 0x00, 0x00, 0x00, 0x96, 0x30, 0x07, 0x77, 0x2C, 0x61, 0x0E, 0xEE, 0xBA, 0x51, 0x09, 0x99}, size: 1024},
+	// 0x428A2F98, 0x71374491, 0xB5C0FBCF, 0xE9B5DBA5, ...
+	{name: "SHA-256 round constants", sig: []byte{0x98, 0x2F, 0x8A, 0x42, 0x91, 0x44, 0x37, 0x71, 0xCF, 0xFB, 0xC0, 0xB5, 0xA5, 0xDB, 0xB5, 0xE9}, size: 256},
+	// 0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A, ...
+	{name: "SHA-256 IV", sig: []byte{0x67, 0xE6, 0x09, 0x6A, 0x85, 0xAE, 0x67, 0xBB, 0x72, 0xF3, 0x6E, 0x3C, 0x3A, 0xF5, 0x4F, 0xA5}, size: 32},
+	// 0x67452301, 0xEFCDAB89, 0x98BADCFE, 0x10325476, 0xC3D2E1F0
+	{name: "SHA-1 IV", sig: []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF, 0xFE, 0xDC, 0xBA, 0x98, 0x76, 0x54, 0x32, 0x10, 0xF0, 0xE1, 0xD2, 0xC3}, size: 20},
+	// 0x67452301, 0xEFCDAB89, 0x98BADCFE, 0x10325476
+	{name: "MD5 IV", sig: []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF, 0xFE, 0xDC, 0xBA, 0x98, 0x76, 0x54, 0x32, 0x10}, size: 16},
+	// 0xD76AA478, 0xE8C7B756, 0x242070DB, 0xC1BDCEEE, ...
+	{name: "MD5 sine table", sig: []byte{0x78, 0xA4, 0x6A, 0xD7, 0x56, 0xB7, 0xC7, 0xE8, 0xDB, 0x70, 0x20, 0x24, 0xEE, 0xCE, 0xBD, 0xC1}, size: 256},
+	// lbase of inflate: 3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, ...
+	{name: "zlib length base table", sig: []byte{0x03, 0x00, 0x04, 0x00, 0x05, 0x00, 0x06, 0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0A, 0x00, 0x0B, 0x00, 0x0D, 0x00, 0x0F, 0x00, 0x11, 0x00}, size: 62},
+	// dbase of inflate: 1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, ...
+	{name: "zlib distance base table", sig: []byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05, 0x00, 0x07, 0x00, 0x09, 0x00, 0x0D, 0x00, 0x11, 0x00, 0x19, 0x00, 0x21, 0x00, 0x31, 0x00}, size: 60},
+}
+
+// cryptoImms maps from well-known 32-bit immediates of cryptographic, checksum
+// and compression algorithms to their names.
+var cryptoImms = map[uint32]string{
+	0x67452301: "MD5/SHA-1 IV",
+	0xEFCDAB89: "MD5/SHA-1 IV",
+	0xC3D2E1F0: "SHA-1 IV",
+	0x5A827999: "SHA-1 round constant",
+	0x6ED9EBA1: "SHA-1 round constant",
+	0x8F1BBCDC: "SHA-1 round constant",
+	0xCA62C1D6: "SHA-1 round constant",
+	0x6A09E667: "SHA-256 IV",
+	0xBB67AE85: "SHA-256 IV",
+	0x428A2F98: "SHA-256 round constants",
+	0xD76AA478: "MD5 sine table",
+	0xEDB88320: "CRC-32 polynomial",
+	0x04C11DB7: "CRC-32 polynomial",
+	0x82F63B78: "CRC-32C polynomial",
+	0x9E3779B9: "TEA delta",
+	65521:      "Adler-32 modulus",
+}
+
+// A cryptoTable is a well-known table located in the sections of the binary
+// executable.
+type cryptoTable struct {
+	// Name of the table.
+	name string
+	// Start address of the table.
+	start bin.Address
+	// End address of the table (exclusive).
+	end bin.Address
+}
+
+// scanCrypto locates the well-known tables of cryptographic, checksum and
+// compression algorithms in the sections of the binary executable, and records
+// them in l.cryptoTables. Tables are located once, the first time a function
+// is analyzed.
+func (l *Lifter) scanCrypto() {
+	if l.cryptoScanned {
+		return
+	}
+	l.cryptoScanned = true
+	located := make(map[bin.Address]bool)
+	for _, sect := range l.File.Sections {
+		if len(sect.Name) == 0 && len(l.File.Segments) > 0 {
+			// Skip segments, as their contents are included in sections.
+			continue
+		}
+		for _, sig := range cryptoSigs {
+			for off := 0; off < len(sect.Data); {
+				i := bytes.Index(sect.Data[off:], sig.sig)
+				if i == -1 {
+					break
+				}
+				start := sect.Addr + bin.Address(off+i)
+				off += i + len(sig.sig)
+				if located[start] {
+					// Prefix of a longer signature; e.g. MD5 IV of SHA-1 IV.
+					continue
+				}
+				located[start] = true
+				dbg.Printf("located %s at %v", sig.name, start)
+				t := cryptoTable{name: sig.name, start: start, end: start + bin.Address(sig.size)}
+				l.cryptoTables = append(l.cryptoTables, t)
+			}
+		}
+	}
+}
+
+// cryptoTableAt returns the name of the well-known table containing the given
+// address, and a boolean indicating success.
+func (l *Lifter) cryptoTableAt(addr bin.Address) (string, bool) {
+	for _, t := range l.cryptoTables {
+		if t.start <= addr && addr < t.end {
+			return t.name, true
+		}
+	}
+	return "", false
+}
+
+// detectCrypto identifies the well-known constants of cryptographic, checksum
+// and compression algorithms referenced by the function; i.e. immediates (e.g.
+// the SHA-1 IV 0x67452301) and addresses of well-known tables (e.g. the AES
+// S-box) used as immediates or memory operands. The names of the constants are
+// recorded in f.Crypto, and attached as "crypto" metadata to the function;
+// e.g.
+//
+//    define void @f_401000() !crypto !{!"AES S-box", !"AES inverse S-box"}
+func (f *Func) detectCrypto() {
+	f.l.scanCrypto()
+	names := make(map[string]bool)
+	for _, block := range f.AsmFunc.Blocks {
+		insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+		for _, inst := range insts {
+			for _, name := range f.cryptoRefs(inst) {
+				names[name] = true
+			}
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	f.Crypto = nil
+	for name := range names {
+		f.Crypto = append(f.Crypto, name)
+	}
+	sort.Strings(f.Crypto)
+	dbg.Printf("function %q references constants of %q", f.Name, f.Crypto)
+	md := &metadata.Metadata{}
+	for _, name := range f.Crypto {
+		md.Nodes = append(md.Nodes, &metadata.String{Val: name})
+	}
+	setMetadata(f.Function, "crypto", md)
+}
+
+// cryptoRefs returns the names of the well-known constants referenced by the
+// operands of the given instruction.
+func (f *Func) cryptoRefs(inst *x86.Inst) []string {
+	var names []string
+	for _, arg := range inst.Args {
+		switch arg := arg.(type) {
+		case x86asm.Imm:
+			if -1<<31 <= arg && arg < 1<<32 {
+				if name, ok := cryptoImms[uint32(arg)]; ok {
+					names = append(names, name)
+					continue
+				}
+			}
+			if name, ok := f.l.cryptoTableAt(bin.Address(arg)); ok {
+				names = append(names, name)
+			}
+		case x86asm.Mem:
+			var addr bin.Address
+			switch {
+			case arg.Base == x86asm.RIP && arg.Index == 0:
+				next := inst.Addr + bin.Address(inst.Len)
+				addr = next + bin.Address(arg.Disp)
+			case f.picRegs[arg.Base] != 0:
+				addr = f.picRegs[arg.Base] + bin.Address(arg.Disp)
+			default:
+				// Absolute address, or table indexed by base or index register;
+				// e.g. `[eax+sbox]`.
+				addr = f.l.memDisp(arg)
+			}
+			if name, ok := f.l.cryptoTableAt(addr); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
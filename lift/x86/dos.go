@@ -0,0 +1,140 @@
+package x86
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// dosFunc specifies the name, register arguments and register result of a DOS
+// function (INT 21h).
+type dosFunc struct {
+	// Function name; e.g. "write_string".
+	name string
+	// Registers holding the arguments of the function, as set up prior to INT
+	// 21h.
+	params []x86asm.Reg
+	// Register holding the result of the function; or 0 if void.
+	ret x86asm.Reg
+}
+
+// dosFuncs maps from DOS function number (AH) to DOS function.
+var dosFuncs = map[uint8]dosFunc{
+	0x00: {name: "terminate"},
+	0x01: {name: "read_char_echo", ret: x86asm.AL},
+	0x02: {name: "write_char", params: []x86asm.Reg{x86asm.DL}},
+	0x06: {name: "direct_console_io", params: []x86asm.Reg{x86asm.DL}, ret: x86asm.AL},
+	0x07: {name: "read_char_raw", ret: x86asm.AL},
+	0x08: {name: "read_char", ret: x86asm.AL},
+	0x09: {name: "write_string", params: []x86asm.Reg{x86asm.DS, x86asm.DX}},
+	0x0A: {name: "read_line", params: []x86asm.Reg{x86asm.DS, x86asm.DX}},
+	0x0B: {name: "input_status", ret: x86asm.AL},
+	0x0E: {name: "select_drive", params: []x86asm.Reg{x86asm.DL}, ret: x86asm.AL},
+	0x19: {name: "current_drive", ret: x86asm.AL},
+	0x1A: {name: "set_dta", params: []x86asm.Reg{x86asm.DS, x86asm.DX}},
+	0x25: {name: "set_int_vector", params: []x86asm.Reg{x86asm.AL, x86asm.DS, x86asm.DX}},
+	0x2A: {name: "get_date", ret: x86asm.AL},
+	0x2C: {name: "get_time"},
+	0x30: {name: "get_version", ret: x86asm.AX},
+	0x31: {name: "keep", params: []x86asm.Reg{x86asm.AL, x86asm.DX}},
+	0x35: {name: "get_int_vector", params: []x86asm.Reg{x86asm.AL}, ret: x86asm.BX},
+	0x39: {name: "mkdir", params: []x86asm.Reg{x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x3A: {name: "rmdir", params: []x86asm.Reg{x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x3B: {name: "chdir", params: []x86asm.Reg{x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x3C: {name: "create", params: []x86asm.Reg{x86asm.CX, x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x3D: {name: "open", params: []x86asm.Reg{x86asm.AL, x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x3E: {name: "close", params: []x86asm.Reg{x86asm.BX}, ret: x86asm.AX},
+	0x3F: {name: "read", params: []x86asm.Reg{x86asm.BX, x86asm.CX, x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x40: {name: "write", params: []x86asm.Reg{x86asm.BX, x86asm.CX, x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x41: {name: "delete", params: []x86asm.Reg{x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x42: {name: "seek", params: []x86asm.Reg{x86asm.AL, x86asm.BX, x86asm.CX, x86asm.DX}, ret: x86asm.AX},
+	0x43: {name: "file_attributes", params: []x86asm.Reg{x86asm.AL, x86asm.CX, x86asm.DS, x86asm.DX}, ret: x86asm.CX},
+	0x44: {name: "ioctl", params: []x86asm.Reg{x86asm.AL, x86asm.BX, x86asm.CX, x86asm.DX}, ret: x86asm.AX},
+	0x47: {name: "getcwd", params: []x86asm.Reg{x86asm.DL, x86asm.DS, x86asm.SI}, ret: x86asm.AX},
+	0x48: {name: "alloc", params: []x86asm.Reg{x86asm.BX}, ret: x86asm.AX},
+	0x49: {name: "free", params: []x86asm.Reg{x86asm.ES}, ret: x86asm.AX},
+	0x4A: {name: "resize", params: []x86asm.Reg{x86asm.ES, x86asm.BX}, ret: x86asm.AX},
+	0x4B: {name: "exec", params: []x86asm.Reg{x86asm.AL, x86asm.DS, x86asm.DX, x86asm.ES, x86asm.BX}, ret: x86asm.AX},
+	0x4C: {name: "exit", params: []x86asm.Reg{x86asm.AL}},
+	0x4D: {name: "get_return_code", ret: x86asm.AX},
+	0x4E: {name: "find_first", params: []x86asm.Reg{x86asm.CX, x86asm.DS, x86asm.DX}, ret: x86asm.AX},
+	0x4F: {name: "find_next", ret: x86asm.AX},
+	0x56: {name: "rename", params: []x86asm.Reg{x86asm.DS, x86asm.DX, x86asm.ES, x86asm.DI}, ret: x86asm.AX},
+	0x62: {name: "get_psp", ret: x86asm.BX},
+}
+
+// intFuncs maps from interrupt number to the DOS function invoked by interrupts
+// other than INT 21h.
+var intFuncs = map[uint8]dosFunc{
+	x86.IntTerminate: {name: "terminate"},
+	x86.IntKeep:      {name: "keep_resident", params: []x86asm.Reg{x86asm.DX}},
+}
+
+// liftDOSCall lifts the given INT 21h instruction, invoking the specified DOS
+// function, to LLVM IR as a call to a function named after the DOS function
+// (e.g. @dos.write_string), emitting code to f. The register arguments of the
+// DOS function are passed as arguments, and the result is stored in the result
+// register. The interrupt and function number are preserved as metadata of the
+// call instruction (e.g. !int !{!"21h/09h"}); the function number is omitted
+// for interrupts other than INT 21h (e.g. !int !{!"20h"}).
+func (f *Func) liftDOSCall(inst *x86.Inst, fn uint8, dos dosFunc) {
+	name := "dos." + dos.name
+	callee, ok := f.l.Stubs[name]
+	if !ok {
+		var params []*types.Param
+		for _, reg := range dos.params {
+			name := strings.ToLower(x86.Register(reg).String())
+			param := types.NewParam(name, regType(reg))
+			params = append(params, param)
+		}
+		ret := types.Type(types.Void)
+		if dos.ret != 0 {
+			ret = regType(dos.ret)
+		}
+		sig := types.NewFunc(ret, params...)
+		callee = &ir.Function{
+			Name: name,
+			Typ:  types.NewPointer(sig),
+			Sig:  sig,
+		}
+		f.l.Stubs[name] = callee
+	}
+	var args []value.Value
+	for _, reg := range dos.params {
+		args = append(args, f.useReg(x86.NewReg(reg, inst)))
+	}
+	call := f.cur.NewCall(callee, args...)
+	intr := fmt.Sprintf("%02Xh", uint8(inst.Args[0].(x86asm.Imm)))
+	if inst.Args[0] == x86asm.Imm(x86.IntDOS) {
+		intr += fmt.Sprintf("/%02Xh", fn)
+	}
+	call.Metadata = map[string]*metadata.Metadata{
+		"int": {
+			Nodes: []metadata.Node{&metadata.String{Val: intr}},
+		},
+	}
+	if dos.ret != 0 {
+		f.defReg(x86.NewReg(dos.ret, inst), call)
+	}
+}
+
+// precedingInsts returns the instructions preceding the given instruction
+// within the basic block currently being lifted.
+func (f *Func) precedingInsts(inst *x86.Inst) []*x86.Inst {
+	if f.asmBlock == nil {
+		return nil
+	}
+	for i, v := range f.asmBlock.Insts {
+		if v == inst {
+			return f.asmBlock.Insts[:i]
+		}
+	}
+	// Terminator of the basic block.
+	return f.asmBlock.Insts
+}
@@ -0,0 +1,64 @@
+package x86
+
+import (
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// flagBits maps from status flag to bit position in the EFLAGS register.
+var flagBits = map[StatusFlag]int{
+	CF: 0,
+	PF: 2,
+	AF: 4,
+	ZF: 6,
+	SF: 7,
+	OF: 11,
+}
+
+// eflagsFixed specifies the bits of the EFLAGS register which are not
+// represented by status flags, as set by a PUSHF instruction. Bit 1 is reserved
+// and always set, and the interrupt enable flag (bit 9) is assumed to be set.
+const eflagsFixed = 0x202
+
+// useFlags packs the status flags into an integer of the given type using the
+// bit layout of the EFLAGS register, emitting code to f. Status flags with bit
+// positions outside of the integer type are omitted, and so is the interrupt
+// enable flag for integer types smaller than 16 bits.
+func (f *Func) useFlags(typ *types.IntType) value.Value {
+	fixed := int64(eflagsFixed)
+	if typ.Size < 16 {
+		fixed &= 1<<typ.Size - 1
+	}
+	var v value.Value = constant.NewInt(fixed, typ)
+	for status := firstStatusFlag; status <= lastStatusFlag; status++ {
+		bit := flagBits[status]
+		if bit >= typ.Size {
+			continue
+		}
+		var x value.Value = f.cur.NewZExt(f.useStatus(status), typ)
+		if bit != 0 {
+			x = f.cur.NewShl(x, constant.NewInt(int64(bit), typ))
+		}
+		v = f.cur.NewOr(v, x)
+	}
+	return v
+}
+
+// defFlags unpacks the status flags from the given integer value using the bit
+// layout of the EFLAGS register, emitting code to f. Status flags with bit
+// positions outside of the integer type are left unchanged.
+func (f *Func) defFlags(v value.Value) {
+	typ := v.Type().(*types.IntType)
+	for status := firstStatusFlag; status <= lastStatusFlag; status++ {
+		bit := flagBits[status]
+		if bit >= typ.Size {
+			continue
+		}
+		x := v
+		if bit != 0 {
+			x = f.cur.NewLShr(x, constant.NewInt(int64(bit), typ))
+		}
+		f.defStatus(status, f.cur.NewTrunc(x, types.I1))
+	}
+}
@@ -0,0 +1,301 @@
+package x86
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/metadata"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Enum recovery ] #######################################################
+
+// minEnumCases specifies the minimum number of case constants of if-else chains
+// recovered as enums.
+const minEnumCases = 3
+
+// A recoveredEnum is an enumeration of the case constants a value is compared
+// against by a switch statement; i.e. a jump table or an if-else chain.
+type recoveredEnum struct {
+	// Enum name; e.g. "enum_0".
+	name string
+	// Case constants in increasing order.
+	vals []int64
+}
+
+// enum returns the recovered enum of the given case constants. Identical sets
+// of case constants share the same enum.
+func (l *Lifter) enum(vals []int64) *recoveredEnum {
+	less := func(i, j int) bool {
+		return vals[i] < vals[j]
+	}
+	sort.Slice(vals, less)
+	buf := &bytes.Buffer{}
+	for _, val := range vals {
+		fmt.Fprintf(buf, "%d,", val)
+	}
+	key := buf.String()
+	if enum, ok := l.enums[key]; ok {
+		return enum
+	}
+	enum := &recoveredEnum{
+		name: fmt.Sprintf("enum_%d", len(l.enums)),
+		vals: vals,
+	}
+	dbg.Printf("recovered enum %q with case constants %v", enum.name, vals)
+	l.enums[key] = enum
+	return enum
+}
+
+// isDense reports whether the given distinct case constants are dense; i.e.
+// covering at least half of their range.
+func isDense(vals []int64) bool {
+	if len(vals) == 0 {
+		return false
+	}
+	min, max := vals[0], vals[0]
+	for _, val := range vals {
+		if val < min {
+			min = val
+		}
+		if val > max {
+			max = val
+		}
+	}
+	return max-min+1 <= 2*int64(len(vals))
+}
+
+// recoverEnums recovers enums from the switch statements of the function; i.e.
+// the case constants of jump tables (e.g. `cmp eax, 5; ja default; jmp
+// [4*eax+table]`), and of if-else chains comparing a value against a dense set
+// of constants (e.g. `cmp eax, 1; je case_1; cmp eax, 2; je case_2; ...`).
+//
+// Enums are attached to the values compared; i.e. the stack slots and global
+// variables from which the compared values are loaded. Enums of stack slots are
+// recorded in f.enums, indexed by address of the instruction accessing the
+// stack slot, and enums of global variables are recorded in l.globalEnums,
+// indexed by global variable address.
+func (f *Func) recoverEnums() {
+	f.enums = make(map[bin.Address]*recoveredEnum)
+	// Basic blocks of already recovered if-else chains.
+	done := make(map[bin.Address]bool)
+	for _, blockAddr := range f.blockAddrs() {
+		block := f.AsmFunc.Blocks[blockAddr]
+		f.tableEnum(block)
+		if !done[blockAddr] {
+			f.chainEnum(block, done)
+		}
+	}
+}
+
+// tableEnum recovers the enum of the index of the jump table of the given basic
+// block, as bounded by the range check of its predecessor.
+func (f *Func) tableEnum(block *x86.BasicBlock) {
+	term := block.Term
+	if term.Op != x86asm.JMP || len(block.Insts) != 0 {
+		return
+	}
+	mem, ok := term.Args[0].(x86asm.Mem)
+	if !ok || mem.Base != 0 || mem.Index == 0 {
+		return
+	}
+	targets, ok := f.l.Tables[f.l.memDisp(mem)]
+	if !ok {
+		return
+	}
+	// Locate range check of predecessor; e.g. `cmp eax, 5; ja default`.
+	for _, pred := range f.AsmFunc.Blocks {
+		t := pred.Term
+		n := len(pred.Insts)
+		if n == 0 || t.Addr+bin.Address(t.Len) != block.Addr || (t.Op != x86asm.JA && t.Op != x86asm.JAE) {
+			continue
+		}
+		cmp := pred.Insts[n-1]
+		imm, ok := cmp.Args[1].(x86asm.Imm)
+		if cmp.Op != x86asm.CMP || !ok {
+			continue
+		}
+		rel, ok := t.Args[0].(x86asm.Rel)
+		if !ok {
+			continue
+		}
+		def := t.Addr + bin.Address(t.Len) + bin.Address(rel)
+		max := int64(imm)
+		if t.Op == x86asm.JAE {
+			max--
+		}
+		// Locate value compared, and the bias subtracted from the value before
+		// the range check; e.g. `sub eax, 1`.
+		var (
+			inst *x86.Inst
+			m    x86asm.Mem
+			bias int64
+		)
+		switch arg := cmp.Args[0].(type) {
+		case x86asm.Mem:
+			inst, m = cmp, arg
+		case x86asm.Reg:
+			if f.l.parentReg(arg) != f.l.parentReg(mem.Index) {
+				continue
+			}
+			if inst, bias, ok = f.regLoadBias(pred.Insts[:n-1], arg); !ok {
+				continue
+			}
+			m = inst.Args[1].(x86asm.Mem)
+		default:
+			continue
+		}
+		var vals []int64
+		for i := int64(0); i <= max && i < int64(len(targets)); i++ {
+			if targets[i] != def {
+				vals = append(vals, i+bias)
+			}
+		}
+		if len(vals) > 0 {
+			f.recordEnum(inst, m, vals)
+		}
+		return
+	}
+}
+
+// chainEnum recovers the enum of the value compared by the if-else chain
+// starting at the given basic block. The basic blocks of the if-else chain are
+// recorded in done.
+func (f *Func) chainEnum(block *x86.BasicBlock, done map[bin.Address]bool) {
+	n := len(block.Insts)
+	if n == 0 {
+		return
+	}
+	first := block.Insts[n-1]
+	if first.Op != x86asm.CMP {
+		return
+	}
+	x := first.Args[0]
+	var vals []int64
+	seen := make(map[int64]bool)
+	for cur := block; ; {
+		cmp := cur.Insts[len(cur.Insts)-1]
+		imm, ok := cmp.Args[1].(x86asm.Imm)
+		if !ok || cmp.Args[0] != x {
+			break
+		}
+		rel, ok := cur.Term.Args[0].(x86asm.Rel)
+		if !ok {
+			break
+		}
+		if cur.Term.Op != x86asm.JE && cur.Term.Op != x86asm.JNE {
+			break
+		}
+		// Address of the basic block of the else branch.
+		elseAddr := cur.Term.Addr + bin.Address(cur.Term.Len)
+		if cur.Term.Op == x86asm.JNE {
+			elseAddr += bin.Address(rel)
+		}
+		if !seen[int64(imm)] {
+			seen[int64(imm)] = true
+			vals = append(vals, int64(imm))
+		}
+		done[cur.Addr] = true
+		// The if-else chain continues with basic blocks consisting of a
+		// comparison of the same value.
+		elseBlock, ok := f.AsmFunc.Blocks[elseAddr]
+		if !ok || len(elseBlock.Insts) != 1 || done[elseAddr] {
+			break
+		}
+		cur = elseBlock
+	}
+	if len(vals) < minEnumCases || !isDense(vals) {
+		return
+	}
+	switch arg := x.(type) {
+	case x86asm.Mem:
+		f.recordEnum(first, arg, vals)
+	case x86asm.Reg:
+		if load, ok := f.regLoad(block.Insts[:n-1], arg); ok {
+			f.recordEnum(load, load.Args[1].(x86asm.Mem), vals)
+		}
+	}
+}
+
+// regLoadBias returns the instruction of the given instructions which loads the
+// specified register from memory (i.e. MOV, MOVZX or MOVSX), and the bias
+// subtracted from the register since (e.g. `sub eax, 1`). The boolean return
+// value indicates success.
+func (f *Func) regLoadBias(insts []*x86.Inst, reg x86asm.Reg) (*x86.Inst, int64, bool) {
+	bias := int64(0)
+	for i := len(insts) - 1; i >= 0; i-- {
+		inst := insts[i]
+		if inst.Args[0] == reg {
+			imm, ok := inst.Args[1].(x86asm.Imm)
+			switch {
+			case inst.Op == x86asm.SUB && ok:
+				bias += int64(imm)
+				continue
+			case inst.Op == x86asm.ADD && ok:
+				bias -= int64(imm)
+				continue
+			case inst.Op == x86asm.DEC:
+				bias++
+				continue
+			case inst.Op == x86asm.INC:
+				bias--
+				continue
+			}
+		}
+		load, ok := f.regLoad(insts[:i+1], reg)
+		return load, bias, ok
+	}
+	return nil, 0, false
+}
+
+// recordEnum records the enum of the case constants the integer value of the
+// given memory operand of the specified instruction is compared against.
+func (f *Func) recordEnum(inst *x86.Inst, mem x86asm.Mem, vals []int64) {
+	addr, ok := f.globalAddr(inst, mem)
+	if !ok {
+		// Stack slot or indirect memory reference.
+		if prev, ok := f.enums[inst.Addr]; ok {
+			vals = append(vals, prev.vals...)
+		}
+		f.enums[inst.Addr] = f.l.enum(uniqueVals(vals))
+		return
+	}
+	if prev, ok := f.l.globalEnums[addr]; ok {
+		vals = append(vals, prev.vals...)
+	}
+	f.l.globalEnums[addr] = f.l.enum(uniqueVals(vals))
+}
+
+// uniqueVals returns the distinct values of the given values.
+func uniqueVals(vals []int64) []int64 {
+	var unique []int64
+	seen := make(map[int64]bool)
+	for _, val := range vals {
+		if !seen[val] {
+			seen[val] = true
+			unique = append(unique, val)
+		}
+	}
+	return unique
+}
+
+// setEnumMetadata records the given recovered enum in the "enum" entry of the
+// metadata; i.e. the enum name followed by its case constants; e.g.
+//
+//    %esp_4 = alloca i32, !enum !{!"enum_0", !"0", !"1", !"2"}
+func setEnumMetadata(md *map[string]*metadata.Metadata, enum *recoveredEnum) {
+	if *md == nil {
+		*md = make(map[string]*metadata.Metadata)
+	}
+	nodes := []metadata.Node{&metadata.String{Val: enum.name}}
+	for _, val := range enum.vals {
+		nodes = append(nodes, &metadata.String{Val: strconv.FormatInt(val, 10)})
+	}
+	(*md)["enum"] = &metadata.Metadata{
+		Nodes: nodes,
+	}
+}
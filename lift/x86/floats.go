@@ -0,0 +1,134 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/types"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Floating-point retyping ] #############################################
+
+// isFloatAccess reports whether the memory operand of the given instruction is
+// accessed as a floating-point value; i.e. by x87 and SSE scalar floating-point
+// instructions. Integer loads and stores of the x87 FPU (e.g. FILD and FIST)
+// are integer accesses.
+func isFloatAccess(inst *x86.Inst) bool {
+	switch inst.Op {
+	// x87 FPU instructions.
+	case x86asm.FLD, x86asm.FST, x86asm.FSTP, x86asm.FADD, x86asm.FSUB,
+		x86asm.FSUBR, x86asm.FMUL, x86asm.FDIV, x86asm.FDIVR, x86asm.FCOM,
+		x86asm.FCOMP:
+		return true
+	// SSE scalar floating-point instructions.
+	case x86asm.MOVSS, x86asm.MOVSD_XMM, x86asm.ADDSS, x86asm.ADDSD,
+		x86asm.SUBSS, x86asm.SUBSD, x86asm.MULSS, x86asm.MULSD, x86asm.DIVSS,
+		x86asm.DIVSD, x86asm.SQRTSS, x86asm.SQRTSD, x86asm.MINSS, x86asm.MINSD,
+		x86asm.MAXSS, x86asm.MAXSD, x86asm.COMISS, x86asm.COMISD, x86asm.UCOMISS,
+		x86asm.UCOMISD, x86asm.CVTSS2SD, x86asm.CVTSD2SS, x86asm.CVTSS2SI,
+		x86asm.CVTSD2SI, x86asm.CVTTSS2SI, x86asm.CVTTSD2SI:
+		return true
+	}
+	return false
+}
+
+// floatType returns the floating-point type of the given size in bytes, and a
+// boolean indicating success; i.e. float for 4 bytes and double for 8 bytes.
+func floatType(size int) (*types.FloatType, bool) {
+	switch size {
+	case 4:
+		return types.Float, true
+	case 8:
+		return types.Double, true
+	}
+	return nil, false
+}
+
+// A floatUse records whether a memory location is accessed as a floating-point
+// value, an integer value, or both.
+type floatUse struct {
+	// Access width in bytes of floating-point accesses; or -1 if accessed as
+	// floating-point values of different widths.
+	size int
+	// Accessed as a floating-point value.
+	isFloat bool
+	// Accessed as an integer value.
+	isInt bool
+}
+
+// record records the access to the memory operand of the given instruction.
+func (use *floatUse) record(inst *x86.Inst) {
+	if !isFloatAccess(inst) {
+		use.isInt = true
+		return
+	}
+	switch {
+	case !use.isFloat:
+		use.size = inst.MemBytes
+	case use.size != inst.MemBytes:
+		use.size = -1
+	}
+	use.isFloat = true
+}
+
+// typ returns the floating-point type of memory locations only accessed as
+// floating-point values of a single width, and a boolean indicating success.
+func (use *floatUse) typ() (*types.FloatType, bool) {
+	if !use.isFloat || use.isInt {
+		return nil, false
+	}
+	return floatType(use.size)
+}
+
+// inferFloats infers the stack slots of the function only accessed as
+// floating-point values, as located by value-set analysis. Such stack slots are
+// recorded in f.floatSlots, indexed by stack slot name, and are allocated with
+// floating-point type (i.e. float or double) rather than integer type.
+//
+// Global variables only accessed as floating-point values are inferred from
+// the accesses to data sections recorded by recordAccesses.
+func (f *Func) inferFloats() {
+	f.floatSlots = make(map[string]*types.FloatType)
+	slots := make(map[string]*floatUse)
+	for _, block := range f.AsmFunc.Blocks {
+		insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+		for _, inst := range insts {
+			if inst.Op == x86asm.LEA || inst.MemBytes == 0 {
+				// Memory operand of LEA is not accessed.
+				continue
+			}
+			v, ok := f.memSets[inst.Addr]
+			if !ok || v.region != vsaStack {
+				continue
+			}
+			off, ok := v.singleton()
+			if !ok {
+				continue
+			}
+			name := f.slotName(off)
+			use, ok := slots[name]
+			if !ok {
+				use = &floatUse{}
+				slots[name] = use
+			}
+			use.record(inst)
+		}
+	}
+	for name, use := range slots {
+		if typ, ok := use.typ(); ok {
+			dbg.Printf("inferred floating-point stack slot %q of function %q: %v", name, f.Name, typ)
+			f.floatSlots[name] = typ
+		}
+	}
+}
+
+// slotName returns the name of the stack slot at the given offset relative to
+// the stack pointer at function entry; e.g. "esp_-8".
+func (f *Func) slotName(off int64) string {
+	base := "esp"
+	if f.l.Mode == 64 {
+		base = "rsp"
+	}
+	return fmt.Sprintf("%s_%d", base, off)
+}
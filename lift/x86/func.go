@@ -1,6 +1,7 @@
 package x86
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/metadata"
 	"github.com/llir/llvm/ir/types"
+	"github.com/pkg/errors"
 	"golang.org/x/arch/x86/x86asm"
 )
 
@@ -31,21 +33,84 @@ type Func struct {
 	fstatusFlags map[FStatusFlag]*ir.InstAlloca
 	// Local varialbes used within the function.
 	locals map[string]*ir.InstAlloca
-	// usesEDX_EAX specifies whether any instruction of the function uses
-	// EDX:EAX.
-	usesEDX_EAX bool
 	// usesFPU specifies whether any instruction of the function uses the FPU.
 	usesFPU bool
+	// sigUnknown specifies whether the function signature is a placeholder,
+	// which may be refined by type analysis.
+	sigUnknown bool
+	// bundledSig specifies whether the function signature originates from a
+	// bundled signature database, and may be overridden by user-supplied
+	// prototypes.
+	bundledSig bool
+	// retState specifies the state of return type inference of functions with
+	// unknown signature.
+	retState int
 
 	// TODO: Move espDisp from Func to BasicBlock, and propagate symbolic
 	// execution information through context.json.
 
 	// ESP disposition; used for shadow stack.
 	espDisp int64
+	// ESP disposition at the time EBP was set up as frame pointer (i.e. `mov
+	// ebp, esp`); valid if hasFrame is set.
+	ebpDisp int64
+	// hasFrame specifies whether EBP is used as frame pointer.
+	hasFrame bool
 
 	// FPU register stack top; integer value in range [0, 7].
 	st *ir.InstAlloca
 
+	// Virtual calls resolved through vtables; maps from call instruction
+	// address to target function address.
+	virtCalls map[bin.Address]bin.Address
+	// C++ class of constructors, as located through the vtable stored into the
+	// this pointer; or nil if not a constructor.
+	class *Class
+	// PIC base registers of 32-bit position-independent code; maps from
+	// register to the constant address held by the register.
+	picRegs map[x86asm.Reg]bin.Address
+	// Value sets of the addresses of indirect memory references, as bounded by
+	// value-set analysis; maps from instruction address to value set.
+	memSets map[bin.Address]valueSet
+	// Struct fields accessed relative to base pointers, as recovered by struct
+	// layout recovery; maps from instruction address to struct field.
+	fieldAccesses map[bin.Address]fieldAccess
+	// Signedness of the stack slots accessed by instructions, as inferred from
+	// comparisons and shifts; maps from instruction address to signedness.
+	signs map[bin.Address]signedness
+	// Signedness of the stack slots of the function; maps from stack slot name
+	// to signedness.
+	localSigns map[string]signedness
+	// Floating-point types of the stack slots only accessed as floating-point
+	// values; maps from stack slot name to floating-point type.
+	floatSlots map[string]*types.FloatType
+	// Enums of the stack slots accessed by instructions, as recovered from
+	// switch statements; maps from instruction address to enum.
+	enums map[bin.Address]*recoveredEnum
+	// Constant segment registers of 16-bit real mode code; maps from segment
+	// register to the segment held by the register.
+	segRegs map[x86asm.Reg]uint16
+	// far specifies whether the function is a far procedure of 16-bit real mode
+	// (i.e. called with CALL FAR and returning with RETF).
+	far bool
+	// Register calling convention of the function; or nil if the calling
+	// convention is specified by CallConv.
+	regConv *RegConv
+
+	// Names of well-known constants of cryptographic, checksum and compression
+	// algorithms referenced by the function (e.g. "AES S-box"), sorted by name;
+	// hinting at the purpose of the function.
+	Crypto []string
+	// Address ranges of overlapping instructions of the function (e.g. `jmp`
+	// into the middle of a preceding instruction), sorted by address.
+	Overlaps []AddrRange
+	// Address ranges of executable sections written by the function (i.e.
+	// self-modifying code), sorted by address; as located by DetectSMC.
+	SMC []AddrRange
+
+	// Basic block of the input assembly currently being lifted.
+	asmBlock *x86.BasicBlock
+
 	// Read-only global lifter state.
 	l *Lifter
 }
@@ -56,8 +121,6 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 	entry := asmFunc.Addr
 	f, ok := l.Funcs[entry]
 	if !ok {
-		// TODO: Add proper support for type signatures once type analysis has
-		// been conducted.
 		name := fmt.Sprintf("f_%06X", uint64(entry))
 		sig := types.NewFunc(types.Void)
 		typ := types.NewPointer(sig)
@@ -72,6 +135,7 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 					},
 				},
 			},
+			sigUnknown: true,
 		}
 	}
 	f.AsmFunc = asmFunc
@@ -80,6 +144,8 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 	f.statusFlags = make(map[StatusFlag]*ir.InstAlloca)
 	f.fstatusFlags = make(map[FStatusFlag]*ir.InstAlloca)
 	f.locals = make(map[string]*ir.InstAlloca)
+	f.virtCalls = make(map[bin.Address]bin.Address)
+	f.picRegs = make(map[x86asm.Reg]bin.Address)
 	f.l = l
 	// Prepare output LLVM IR basic blocks.
 	for addr := range asmFunc.Blocks {
@@ -89,8 +155,8 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 		}
 		f.blocks[addr] = block
 	}
-	// Preprocess the function to assess if any instruction makes use of EDX:EAX
-	// (e.g. IDIV).
+	// Preprocess the function to assess if any instruction makes use of the FPU
+	// register stack.
 	for _, bb := range asmFunc.Blocks {
 		for _, inst := range bb.Insts {
 			switch inst.Op {
@@ -118,39 +184,118 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 				x86asm.FXRSTOR64, x86asm.FXSAVE, x86asm.FXSAVE64, x86asm.FXTRACT,
 				x86asm.FYL2X, x86asm.FYL2XP1:
 				f.usesFPU = true
-			// TODO: Identify more instructions which makes use of EDX:EAX.
-			case x86asm.IDIV:
-				f.usesEDX_EAX = true
 			}
 		}
 	}
+	// Locate string literals referenced from the function.
+	l.detectStrings(asmFunc)
+	// Locate PIC base registers of the function.
+	f.detectPIC()
+	// Locate constant segment registers of 16-bit real mode code.
+	f.far = isFar(asmFunc)
+	f.detectSegs()
+	// Bound indirect memory references of the function by value-set analysis.
+	f.analyzeValueSets()
+	// Record writes into executable sections and locate overlapping
+	// instructions of the function.
+	f.detectCodeWrites()
+	f.detectOverlaps()
+	// Infer stack slots only accessed as floating-point values.
+	f.inferFloats()
+	// Infer signedness of stack slots and global variables.
+	f.inferSignedness()
+	// Recover enums from switch statements.
+	f.recoverEnums()
+	// Recover struct layouts from accesses relative to base pointers.
+	f.recoverStructs()
+	// Record accesses to data sections from the function.
+	l.recordAccesses(asmFunc, f.picRegs, f.segRegs)
+	// Locate vtables and resolve virtual calls of the function.
+	f.detectVTables()
+	// Identify constants of cryptographic, checksum and compression algorithms
+	// referenced by the function.
+	f.detectCrypto()
+	// Infer parameters of functions without known signature.
+	if f.sigUnknown {
+		f.inferParams()
+	}
 	return f
 }
 
 // Lift lifts the function from input assembly to LLVM IR.
 func (f *Func) Lift() {
+	if err := f.LiftContext(context.Background()); err != nil {
+		panic(err)
+	}
+}
+
+// LiftContext lifts the function from input assembly to LLVM IR. An error is
+// returned if the context is cancelled before lifting completes, in which case
+// the function is left partially lifted.
+func (f *Func) LiftContext(ctx context.Context) error {
 	dbg.Printf("lifting function %q at %v", f.Name, f.AsmFunc.Addr)
+	// Infer global variables from accesses to data sections.
+	f.l.inferGlobals()
+	// Infer return type of functions without known signature.
+	f.resolveRet()
 	// Allocate a local variable for the FPU stack top used within the function.
 	if f.usesFPU {
 		v := ir.NewAlloca(types.I8)
 		v.SetName("st")
 		f.st = v
 	}
-	var blockAddrs bin.Addresses
-	for blockAddr := range f.AsmFunc.Blocks {
-		blockAddrs = append(blockAddrs, blockAddr)
-	}
-	sort.Sort(blockAddrs)
+	blockAddrs := f.blockAddrs()
 	if len(blockAddrs) == 0 {
 		panic(fmt.Errorf("invalid function definition at %v; missing function body", f.AsmFunc.Addr))
 	}
 	for _, blockAddr := range blockAddrs {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrapf(err, "unable to lift function %q at %v", f.Name, f.AsmFunc.Addr)
+		}
 		bb := f.AsmFunc.Blocks[blockAddr]
 		f.liftBlock(bb)
 	}
+	// Define parameters of the function. Parameters are defined before the
+	// local variables are allocated, as parameters passed on the stack may give
+	// rise to new local variables.
+	//
+	// TODO: Initialize parameter initialization in entry block prior to basic
+	// block translation. Move this code to before f.translateBlock, and remove
+	// f.espDisp = 0.
+	params := &ir.BasicBlock{}
+	f.cur = params
+	f.espDisp = 0
+	f.hasFrame = false
+	total := 0
+	for i := range f.Sig.Params {
+		if _, ok := f.paramReg(i); !ok {
+			total++
+		}
+	}
+	nstack := 0
+	for i, param := range f.Sig.Params {
+		// Use parameter in register.
+		if reg, ok := f.paramReg(i); ok {
+			f.defReg(reg, param)
+			continue
+		}
+		// Use parameter on stack.
+		slot := nstack
+		if f.regConv != nil && f.regConv.LeftToRight {
+			// The last argument is pushed last.
+			slot = total - 1 - nstack
+		}
+		mem := f.l.stackSlot(f.CallConv, slot)
+		if f.far {
+			// Skip segment of return address.
+			mem.Mem.Disp += 2
+		}
+		f.defMem(mem, param)
+		nstack++
+	}
 	// Add new entry basic block to define registers and status flags used within
 	// the function.
-	if len(f.regs) > 0 || len(f.statusFlags) > 0 || len(f.fstatusFlags) > 0 || f.usesFPU {
+	if len(f.regs) > 0 || len(f.statusFlags) > 0 || len(f.fstatusFlags) > 0 || f.usesFPU || len(params.Insts) > 0 {
 		entry := &ir.BasicBlock{}
 		// Allocate local variables for each register used within the function.
 		for reg := x86.FirstReg; reg <= x86.LastReg; reg++ {
@@ -190,47 +335,68 @@ func (f *Func) Lift() {
 			entry.AppendInst(inst)
 		}
 		// Handle calling conventions.
-		f.cur = entry
-		// TODO: Initialize parameter initialization in entry block prior to basic
-		// block translation. Move this code to before f.translateBlock, and remove
-		// f.espDisp = 0.
-		f.espDisp = 0
-		for i, param := range f.Sig.Params {
-			// Use parameter in register.
-			switch f.CallConv {
-			case ir.CallConvX86_FastCall:
-				switch i {
-				case 0:
-					f.defReg(x86.ECX, param)
-					continue
-				case 1:
-					f.defReg(x86.EDX, param)
-					continue
-				}
-			default:
-				// TODO: Add support for more calling conventions.
-			}
-			// Use parameter on stack.
-			m := x86asm.Mem{
-				Base: x86asm.ESP,
-				Disp: 4,
-			}
-			mem := x86.NewMem(m, nil)
-			f.defMem(mem, param)
+		for _, inst := range params.Insts {
+			entry.AppendInst(inst)
 		}
 		target := f.Blocks[0]
 		entry.NewBr(target)
 		f.Blocks = append([]*ir.BasicBlock{entry}, f.Blocks...)
 	}
+	// Distinguish stack slots, global variables and external memory accessed by
+	// loads and stores.
+	if f.l.AliasAnnotations {
+		f.annotateAliases()
+	}
+	return nil
+}
+
+// blockAddrs returns the addresses of the basic blocks of the function, sorted
+// in ascending order.
+func (f *Func) blockAddrs() bin.Addresses {
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.AsmFunc.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	return blockAddrs
 }
 
 // liftBlock lifts the basic block from input assembly to LLVM IR.
 func (f *Func) liftBlock(bb *x86.BasicBlock) {
 	dbg.Printf("lifting basic block at %v", bb.Addr)
 	f.cur = f.blocks[bb.Addr]
+	f.asmBlock = bb
 	f.Blocks = append(f.Blocks, f.cur)
-	for _, inst := range bb.Insts {
-		f.liftInst(inst)
+	// Fuse CMP and TEST instructions with succeeding conditional jumps.
+	if f.canFuse(bb) {
+		n := len(bb.Insts) - 1
+		f.liftInsts(bb, n)
+		s := f.saveState()
+		f.liftFused(bb.Insts[n], bb.Term)
+		f.annotateInst(s, bb.Term)
+		return
 	}
+	f.liftInsts(bb, len(bb.Insts))
+	s := f.saveState()
 	f.liftTerm(bb.Term)
+	f.annotateInst(s, bb.Term)
+}
+
+// liftInsts lifts the first n instructions of the given basic block to LLVM IR,
+// emitting code to f. Divisions by constants lowered to multiplications by
+// magic numbers are folded back into divisions.
+func (f *Func) liftInsts(bb *x86.BasicBlock, n int) {
+	for i := 0; i < n; i++ {
+		inst := bb.Insts[i]
+		if div, ok := f.matchMagicDiv(bb, i); ok && i+div.n <= n {
+			s := f.saveState()
+			f.liftMagicDiv(div)
+			f.annotateInst(s, div.inst)
+			i += div.n - 1
+			continue
+		}
+		s := f.saveState()
+		f.liftInst(inst)
+		f.annotateInst(s, inst)
+	}
 }
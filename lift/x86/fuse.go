@@ -0,0 +1,126 @@
+package x86
+
+import (
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// cmpPreds maps from conditional jump to integer comparison predicate of the
+// operands of a preceding CMP instruction.
+var cmpPreds = map[x86asm.Op]ir.IntPred{
+	x86asm.JA:  ir.IntUGT,
+	x86asm.JAE: ir.IntUGE,
+	x86asm.JB:  ir.IntULT,
+	x86asm.JBE: ir.IntULE,
+	x86asm.JE:  ir.IntEQ,
+	x86asm.JG:  ir.IntSGT,
+	x86asm.JGE: ir.IntSGE,
+	x86asm.JL:  ir.IntSLT,
+	x86asm.JLE: ir.IntSLE,
+	x86asm.JNE: ir.IntNE,
+}
+
+// testPreds maps from conditional jump to integer comparison predicate of the
+// result of a preceding TEST instruction and zero.
+var testPreds = map[x86asm.Op]ir.IntPred{
+	x86asm.JE:  ir.IntEQ,
+	x86asm.JNE: ir.IntNE,
+	x86asm.JS:  ir.IntSLT,
+	x86asm.JNS: ir.IntSGE,
+}
+
+// canFuse reports whether the last instruction of the given basic block is a
+// CMP or TEST instruction whose status flags are only used by the conditional
+// jump terminating the basic block.
+func (f *Func) canFuse(bb *x86.BasicBlock) bool {
+	if len(bb.Insts) == 0 {
+		return false
+	}
+	inst := bb.Insts[len(bb.Insts)-1]
+	switch inst.Op {
+	case x86asm.CMP:
+		if _, ok := cmpPreds[bb.Term.Op]; !ok {
+			return false
+		}
+	case x86asm.TEST:
+		if _, ok := testPreds[bb.Term.Op]; !ok {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, succ := range f.succs(bb) {
+		if f.flagsLiveIn(f.AsmFunc.Blocks[succ]) {
+			return false
+		}
+	}
+	return true
+}
+
+// flagsLiveIn reports whether the status flags may be read at the start of the
+// given basic block before being defined. The analysis is conservative, and
+// only considers status flags dead if defined by CMP or TEST, or if the basic
+// block returns without reading the status flags.
+func (f *Func) flagsLiveIn(bb *x86.BasicBlock) bool {
+	for _, inst := range bb.Insts {
+		switch inst.Op {
+		case x86asm.CMP, x86asm.TEST:
+			return false
+		}
+		if readsFlags(inst) {
+			return true
+		}
+	}
+	return !isReturn(bb.Term)
+}
+
+// readsFlags reports whether the given instruction may read the status flags.
+// All instructions except for a known set of instructions which do not read
+// the status flags are considered readers.
+func readsFlags(inst *x86.Inst) bool {
+	switch inst.Op {
+	case x86asm.MOV, x86asm.MOVZX, x86asm.MOVSX, x86asm.MOVSXD, x86asm.LEA,
+		x86asm.PUSH, x86asm.POP, x86asm.ADD, x86asm.SUB, x86asm.AND, x86asm.OR,
+		x86asm.XOR, x86asm.INC, x86asm.DEC, x86asm.NEG, x86asm.NOT, x86asm.SHL,
+		x86asm.SHR, x86asm.SAR, x86asm.IMUL, x86asm.MUL, x86asm.IDIV, x86asm.DIV,
+		x86asm.CDQ, x86asm.CALL, x86asm.NOP:
+		return false
+	}
+	return true
+}
+
+// liftFused lifts the given CMP or TEST instruction and the succeeding
+// conditional jump terminator to LLVM IR, emitting code to f. The branch
+// condition is computed directly from the operands of the CMP or TEST
+// instruction, without defining status flags.
+//
+// Pre-condition: f.canFuse holds for the basic block of inst and term.
+func (f *Func) liftFused(inst, term *x86.Inst) error {
+	x := f.useArg(inst.Arg(0))
+	y := f.useOperand(inst.Arg(1), x.Type())
+	var cond value.Value
+	switch inst.Op {
+	case x86asm.CMP:
+		cond = f.cur.NewICmp(cmpPreds[term.Op], x, y)
+	case x86asm.TEST:
+		result := f.cur.NewAnd(x, y)
+		zero := constant.NewInt(0, x.Type())
+		cond = f.cur.NewICmp(testPreds[term.Op], result, zero)
+	}
+	return f.liftTermJcc(term.Arg(0), cond)
+}
+
+// useOperand returns the value held by the given argument, emitting code to f.
+// Immediates are represented using the given integer type.
+func (f *Func) useOperand(arg *x86.Arg, typ types.Type) value.Value {
+	if imm, ok := arg.Arg.(x86asm.Imm); ok {
+		if t, ok := typ.(*types.IntType); ok {
+			return constant.NewInt(int64(imm), t)
+		}
+	}
+	return f.useArg(arg)
+}
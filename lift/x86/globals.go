@@ -0,0 +1,449 @@
+package x86
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// A dataAccess records how a data address is accessed from code.
+type dataAccess struct {
+	// Maximum access width in bytes.
+	size int
+	// Element size in bytes of indexed accesses (e.g. 4 for `[4*ecx+addr]`); or
+	// 0 if not accessed through an index register.
+	stride int
+	// Floating-point and integer accesses.
+	use floatUse
+	// Number of instructions writing to the address.
+	writes int
+	// Instruction storing a constant to the address (e.g. `mov [addr], 5`); or
+	// nil if not present.
+	constStore *x86.Inst
+	// The address is read by a function storing a constant to it (e.g. lazy
+	// initialization).
+	selfRead bool
+	// Entry address of the function containing constStore.
+	storeFunc bin.Address
+}
+
+// recordAccesses records the direct and indexed memory accesses to data
+// sections of the instructions of the given function. Accesses relative to the
+// given PIC base registers are resolved to absolute addresses, and accesses of
+// 16-bit real mode are resolved to linear addresses based on the given constant
+// segment registers.
+//
+// Addresses of data sections taken by immediate operands and LEA instructions
+// are recorded in l.addrTaken.
+func (l *Lifter) recordAccesses(asmFunc *x86.Func, picRegs map[x86asm.Reg]bin.Address, segRegs map[x86asm.Reg]uint16) {
+	// Addresses read by the function, and addresses stored constants to by the
+	// function.
+	reads := make(map[bin.Address]bool)
+	stores := make(map[bin.Address]bool)
+	for _, block := range asmFunc.Blocks {
+		for _, inst := range block.Insts {
+			for _, arg := range inst.Args {
+				if imm, ok := arg.(x86asm.Imm); ok {
+					if _, ok := l.sectionAt(bin.Address(imm)); ok {
+						l.addrTaken[bin.Address(imm)] = true
+					}
+				}
+			}
+			if inst.Op != x86asm.LEA && inst.MemBytes == 0 {
+				continue
+			}
+			for i, arg := range inst.Args {
+				if arg == nil {
+					break
+				}
+				mem, ok := arg.(x86asm.Mem)
+				if !ok {
+					continue
+				}
+				seg, ok := l.segBase(segRegs, mem)
+				if !ok {
+					continue
+				}
+				var addr bin.Address
+				stride := 0
+				switch {
+				case mem.Base == 0 && mem.Index == 0:
+					addr = seg + l.memDisp(mem)
+				case mem.Base == 0:
+					// Array element; e.g. `[4*ecx+addr]`.
+					addr = seg + l.memDisp(mem)
+					stride = elemSize(mem.Scale, inst.MemBytes)
+				case mem.Base == x86asm.RIP && mem.Index == 0:
+					next := inst.Addr + bin.Address(inst.Len)
+					addr = next + bin.Address(mem.Disp)
+				case picRegs[mem.Base] != 0 && mem.Index == 0:
+					addr = picRegs[mem.Base] + bin.Address(mem.Disp)
+				case picRegs[mem.Base] != 0:
+					// Array element relative to PIC base register; e.g.
+					// `[ebx+4*ecx+offset]`.
+					addr = picRegs[mem.Base] + bin.Address(mem.Disp)
+					stride = elemSize(mem.Scale, inst.MemBytes)
+				case mem.Index != 0:
+					// Array element with byte offset in base register; e.g.
+					// `[ebx+4*ecx+addr]`.
+					addr = seg + l.memDisp(mem)
+					stride = elemSize(mem.Scale, inst.MemBytes)
+				default:
+					continue
+				}
+				if _, ok := l.sectionAt(addr); !ok {
+					continue
+				}
+				if _, ok := l.Tables[addr]; ok {
+					// Skip jump tables.
+					continue
+				}
+				if inst.Op == x86asm.LEA {
+					// Memory operand of LEA is not accessed.
+					l.addrTaken[addr] = true
+					continue
+				}
+				acc, ok := l.accesses[addr]
+				if !ok {
+					acc = &dataAccess{}
+					l.accesses[addr] = acc
+				}
+				if inst.MemBytes > acc.size {
+					acc.size = inst.MemBytes
+				}
+				if stride > acc.stride {
+					acc.stride = stride
+				}
+				acc.use.record(inst)
+				recordWrite(acc, addr, inst, i, reads, stores)
+				if acc.constStore == inst {
+					acc.storeFunc = asmFunc.Addr
+				}
+			}
+		}
+	}
+	for addr := range stores {
+		if reads[addr] {
+			l.accesses[addr].selfRead = true
+		}
+	}
+}
+
+// elemSize returns the element size in bytes of arrays accessed through an
+// index register of the given scale and access width in bytes; e.g. 4 for
+// `mov eax, [4*ecx+addr]`, and 2 for `mov ax, [ecx+addr]`, where the index
+// register holds a byte offset.
+func elemSize(scale uint8, width int) int {
+	if int(scale) > width {
+		return int(scale)
+	}
+	return width
+}
+
+// inferGlobals infers global variables based on the recorded accesses to data
+// sections, and adds them to the global variables of the lifter. Addresses
+// covered by known global variables are skipped.
+//
+// Global variables are inferred once, after all function lifters have been
+// created; i.e. the first time a function is lifted.
+//
+// Global variables accessed through a base pointer holding their address are
+// given the struct layout recovered from the accesses.
+func (l *Lifter) inferGlobals() {
+	if l.globalsInferred {
+		return
+	}
+	l.globalsInferred = true
+	// Add vtables before inferring global variables, as they have more precise
+	// types.
+	l.emitVTables()
+	for _, f := range l.Funcs {
+		if f.class != nil {
+			f.nameThis()
+		}
+	}
+	var addrs bin.Addresses
+	for addr := range l.accesses {
+		addrs = append(addrs, addr)
+	}
+	for addr := range l.structGlobals {
+		if _, ok := l.accesses[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Sort(addrs)
+	// Address ranges of known global variables.
+	known := l.globalRanges()
+	// End address of the previously inferred global variable.
+	var end bin.Address
+	for i, addr := range addrs {
+		if addr < end || inRanges(known, addr) {
+			continue
+		}
+		var content types.Type
+		var size int
+		if layout, ok := l.structGlobals[addr]; ok {
+			// Struct accessed through base pointer holding its address.
+			content = l.structType(layout).typ
+			size = int(l.sizeOfType(content))
+		} else if acc := l.accesses[addr]; acc.stride != 0 {
+			// The array extends up to the next accessed address or the end of the
+			// section.
+			sect, _ := l.sectionAt(addr)
+			limit := sect.Addr + bin.Address(sectSize(sect))
+			if i+1 < len(addrs) && addrs[i+1] < limit {
+				limit = addrs[i+1]
+			}
+			n := int64(limit-addr) / int64(acc.stride)
+			if n < 1 {
+				n = 1
+			}
+			var elem types.Type = intType(acc.stride)
+			if typ, ok := acc.use.typ(); ok && int(l.sizeOfType(typ)) == acc.stride {
+				// Array only accessed as floating-point values.
+				elem = typ
+			}
+			content = types.NewArray(elem, n)
+			size = int(n) * acc.stride
+		} else if typ, ok := acc.use.typ(); ok {
+			// Global variable only accessed as floating-point values.
+			content = typ
+			size = int(l.sizeOfType(typ))
+		} else {
+			content = intType(acc.size)
+			size = acc.size
+		}
+		g := &ir.Global{
+			Name:    fmt.Sprintf("g_%06X", uint64(addr)),
+			Typ:     types.NewPointer(content),
+			Content: content,
+			Init:    l.initData(addr, content),
+			// Global variables of read-only sections are constant.
+			IsConst: l.File.Perm(addr)&bin.PermW == 0,
+			Metadata: map[string]*metadata.Metadata{
+				"addr": {
+					Nodes: []metadata.Node{&metadata.String{Val: addr.String()}},
+				},
+			},
+		}
+		dbg.Printf("inferred global variable at %v: %v", addr, content)
+		if l.ConstGlobals {
+			l.markWriteOnce(addr, g)
+		}
+		l.Globals[addr] = g
+		end = addr + bin.Address(size)
+	}
+	l.nameGlobals()
+	l.markGlobals()
+}
+
+// addDataImport adds the GOT entry at the given address, holding the address of
+// the imported variable of the specified name, as a global variable to the
+// lifter; e.g.
+//
+//    @stdout = external global i8
+//    @got.stdout = constant i8* @stdout
+func (l *Lifter) addDataImport(addr bin.Address, name string) {
+	ext, ok := l.Externs[name]
+	if !ok {
+		ext = &ir.Global{
+			Name:    name,
+			Typ:     types.NewPointer(types.I8),
+			Content: types.I8,
+		}
+		l.Externs[name] = ext
+	}
+	content := ext.Typ
+	g := &ir.Global{
+		Name:    "got." + name,
+		Typ:     types.NewPointer(content),
+		Content: content,
+		Init:    ext,
+		IsConst: true,
+		Metadata: map[string]*metadata.Metadata{
+			"addr": {
+				Nodes: []metadata.Node{&metadata.String{Val: addr.String()}},
+			},
+		},
+	}
+	dbg.Printf("data import %q at %v", name, addr)
+	l.Globals[addr] = g
+}
+
+// globalRanges returns the address ranges covered by the known global
+// variables, sorted by start address, with overlapping and adjacent ranges
+// merged.
+func (l *Lifter) globalRanges() []AddrRange {
+	var rs []AddrRange
+	for start, g := range l.Globals {
+		end := start + bin.Address(l.sizeOfType(g.Content))
+		if start < end {
+			rs = append(rs, AddrRange{Start: start, End: end})
+		}
+	}
+	return mergeRanges(rs)
+}
+
+// inRanges reports whether the given address is contained within the given
+// non-overlapping address ranges, sorted by start address.
+func inRanges(rs []AddrRange, addr bin.Address) bool {
+	less := func(i int) bool {
+		return addr < rs[i].Start
+	}
+	index := sort.Search(len(rs), less) - 1
+	return index >= 0 && addr < rs[index].End
+}
+
+// intType returns an integer type of the given size in bytes; or an array of
+// bytes for sizes without corresponding integer type (e.g. 10 for x87 extended
+// precision).
+func intType(size int) types.Type {
+	switch size {
+	case 1:
+		return types.I8
+	case 2:
+		return types.I16
+	case 4:
+		return types.I32
+	case 8:
+		return types.I64
+	default:
+		return types.NewArray(types.I8, int64(size))
+	}
+}
+
+// initData returns the initializer of the global variable of the given type
+// located at the specified address, based on the contents of the section.
+func (l *Lifter) initData(addr bin.Address, content types.Type) constant.Constant {
+	if _, ok := l.sectionAt(addr); !ok {
+		return constant.NewZeroInitializer(content)
+	}
+	buf, err := l.File.ReadData(addr, int(l.sizeOfType(content)))
+	if err != nil {
+		warn.Printf("unable to read initializer of global variable at %v; %v", addr, err)
+		return constant.NewZeroInitializer(content)
+	}
+	zero := true
+	for _, b := range buf {
+		if b != 0 {
+			zero = false
+			break
+		}
+	}
+	if zero {
+		// Uninitialized data (e.g. .bss) or zero initialized data.
+		return constant.NewZeroInitializer(content)
+	}
+	return initConst(l.File.Arch.ByteOrder(), buf, content)
+}
+
+// initConst returns a constant of the given type based on the encoding in buf,
+// using the specified byte order.
+func initConst(order binary.ByteOrder, buf []byte, typ types.Type) constant.Constant {
+	switch t := typ.(type) {
+	case *types.IntType:
+		var v uint64
+		switch t.Size {
+		case 8:
+			v = uint64(buf[0])
+		case 16:
+			v = uint64(order.Uint16(buf))
+		case 32:
+			v = uint64(order.Uint32(buf))
+		case 64:
+			v = order.Uint64(buf)
+		default:
+			panic(fmt.Errorf("support for integer type %v not yet implemented", t))
+		}
+		return constant.NewInt(int64(v), t)
+	case *types.FloatType:
+		switch t.Kind {
+		case types.FloatKindIEEE_32:
+			return constant.NewFloat(float64(math.Float32frombits(order.Uint32(buf))), t)
+		case types.FloatKindIEEE_64:
+			return constant.NewFloat(math.Float64frombits(order.Uint64(buf)), t)
+		default:
+			panic(fmt.Errorf("support for floating-point type %v not yet implemented", t))
+		}
+	case *types.ArrayType:
+		elemSize := len(buf) / int(t.Len)
+		var elems []constant.Constant
+		for i := 0; i < int(t.Len); i++ {
+			elem := initConst(order, buf[i*elemSize:(i+1)*elemSize], t.Elem)
+			elems = append(elems, elem)
+		}
+		return constant.NewArray(elems...)
+	case *types.StructType:
+		var fields []constant.Constant
+		off := 0
+		for _, field := range t.Fields {
+			n := constSize(field)
+			fields = append(fields, initConst(order, buf[off:off+n], field))
+			off += n
+		}
+		return constant.NewStruct(fields...)
+	default:
+		panic(fmt.Errorf("support for type %T not yet implemented", typ))
+	}
+}
+
+// constSize returns the size in bytes of the given type of initConst.
+func constSize(typ types.Type) int {
+	switch t := typ.(type) {
+	case *types.IntType:
+		return int(t.Size / 8)
+	case *types.FloatType:
+		switch t.Kind {
+		case types.FloatKindIEEE_32:
+			return 4
+		case types.FloatKindIEEE_64:
+			return 8
+		default:
+			panic(fmt.Errorf("support for floating-point type %v not yet implemented", t))
+		}
+	case *types.ArrayType:
+		return int(t.Len) * constSize(t.Elem)
+	case *types.StructType:
+		n := 0
+		for _, field := range t.Fields {
+			n += constSize(field)
+		}
+		return n
+	default:
+		panic(fmt.Errorf("support for type %T not yet implemented", typ))
+	}
+}
+
+// sectionAt returns the non-executable section containing the given address,
+// and a boolean indicating success. Executables of 16-bit real mode are loaded
+// as unprotected memory holding both code and data, and their sections are thus
+// considered regardless of permissions.
+func (l *Lifter) sectionAt(addr bin.Address) (*bin.Section, bool) {
+	for _, sect := range l.File.Sections {
+		if sect.Perm&bin.PermX != 0 && l.File.RealMode == nil {
+			continue
+		}
+		if sect.Addr <= addr && addr < sect.Addr+bin.Address(sectSize(sect)) {
+			return sect, true
+		}
+	}
+	return nil, false
+}
+
+// sectSize returns the size in bytes of the given section when loaded into
+// memory.
+func sectSize(sect *bin.Section) int {
+	if sect.MemSize > len(sect.Data) {
+		return sect.MemSize
+	}
+	return len(sect.Data)
+}
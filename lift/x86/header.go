@@ -0,0 +1,836 @@
+package x86
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/pkg/errors"
+)
+
+// A Proto is a function prototype, as declared by a C header.
+type Proto struct {
+	// Function name.
+	Name string
+	// Calling convention.
+	CallConv ir.CallConv
+	// Register calling convention; or nil if the calling convention is
+	// specified by CallConv.
+	RegConv *RegConv
+	// Function signature.
+	Sig *types.FuncType
+
+	// bundled specifies whether the prototype originates from a bundled
+	// signature database.
+	bundled bool
+}
+
+// ParseHeaderFile parses the function prototypes declared by the given C
+// header file. See ParseHeader for the supported subset of C.
+func (l *Lifter) ParseHeaderFile(headerPath string) ([]*Proto, error) {
+	buf, err := ioutil.ReadFile(headerPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	protos, err := l.ParseHeader(string(buf))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse C header %q", headerPath)
+	}
+	return protos, nil
+}
+
+// ParseHeader parses the function prototypes declared by the given C header
+// source.
+//
+// Only a restricted subset of C declarations is supported; function prototypes
+// and typedefs of integer, floating-point, pointer, enum and (opaque) struct
+// types. Preprocessor directives are ignored, and so are struct and enum
+// bodies. Declarations outside of the supported subset are skipped with a
+// warning.
+func (l *Lifter) ParseHeader(src string) ([]*Proto, error) {
+	p := &headerParser{
+		l:        l,
+		typedefs: make(map[string]types.Type),
+	}
+	stmts, err := splitStmts(lexHeader(src))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var protos []*Proto
+	for _, stmt := range stmts {
+		proto, err := p.parseStmt(stmt)
+		if err != nil {
+			warn.Printf("skipping C declaration %q; %v", strings.Join(stmt, " "), err)
+			continue
+		}
+		if proto != nil {
+			protos = append(protos, proto)
+		}
+	}
+	return protos, nil
+}
+
+// AddProtos refines the placeholder signatures of functions (e.g. imports and
+// exports) based on the given function prototypes, matched by undecorated
+// function name. Functions with known signatures are left unchanged, except
+// for signatures of bundled signature databases, which are overridden.
+func (l *Lifter) AddProtos(protos []*Proto) {
+	byName := make(map[string]*Proto)
+	for _, proto := range protos {
+		byName[proto.Name] = proto
+	}
+	for entry, f := range l.Funcs {
+		if !f.sigUnknown && !f.bundledSig {
+			continue
+		}
+		proto, ok := byName[undecorate(f.Name)]
+		if !ok {
+			continue
+		}
+		dbg.Printf("using prototype of function %q at %v", f.Name, entry)
+		f.setProto(proto)
+	}
+}
+
+// SetProtos sets the signatures of the functions at the given addresses based
+// on the given function prototypes, overriding signatures located by other
+// means (e.g. C headers and bundled signature databases). The names of the
+// functions are left unchanged.
+func (l *Lifter) SetProtos(protos map[bin.Address]*Proto) {
+	for entry, proto := range protos {
+		f, ok := l.Funcs[entry]
+		if !ok {
+			warn.Printf("unable to locate function at %v of prototype %q", entry, proto.Name)
+			continue
+		}
+		dbg.Printf("using prototype %q of function %q at %v", proto.Name, f.Name, entry)
+		f.setProto(proto)
+	}
+}
+
+// setProto sets the signature of the function based on the given function
+// prototype.
+func (f *Func) setProto(proto *Proto) {
+	f.Sig = proto.Sig
+	f.Typ = types.NewPointer(proto.Sig)
+	f.CallConv = proto.CallConv
+	f.regConv = proto.RegConv
+	f.sigUnknown = false
+	f.bundledSig = proto.bundled
+}
+
+// undecorate returns the undecorated name of the given function name; e.g.
+// "CreateFileA" for "__imp__CreateFileA@28".
+func undecorate(name string) string {
+	name = strings.TrimPrefix(name, "__imp_")
+	if pos := strings.LastIndex(name, "@"); pos > 0 {
+		name = name[:pos]
+		name = strings.TrimPrefix(name, "_")
+	}
+	return name
+}
+
+// --- [ Lexer ] ---------------------------------------------------------------
+
+// lexHeader splits the given C header source into tokens. Comments,
+// preprocessor directives and string literals are discarded.
+func lexHeader(src string) []string {
+	var toks []string
+	lineStart := true
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			lineStart = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+		case c == '#' && lineStart:
+			// Skip preprocessor directive, including line continuations.
+			for i < len(src) && src[i] != '\n' {
+				if src[i] == '\\' && i+1 < len(src) && src[i+1] == '\n' {
+					i++
+				}
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return toks
+			}
+			i += 2 + end + 2
+			continue
+		}
+		lineStart = false
+		switch {
+		case c == '"' || c == '\'':
+			// Skip string and character literals.
+			i++
+			for i < len(src) && src[i] != c {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case isIdentChar(c):
+			j := i
+			for j < len(src) && isIdentChar(src[j]) {
+				j++
+			}
+			toks = append(toks, src[i:j])
+			i = j
+		case strings.HasPrefix(src[i:], "..."):
+			toks = append(toks, "...")
+			i += 3
+		default:
+			toks = append(toks, string(c))
+			i++
+		}
+	}
+	return toks
+}
+
+// isIdentChar reports whether the given character may be part of an
+// identifier or numeric literal.
+func isIdentChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_'
+}
+
+// splitStmts splits the given tokens into top-level declarations, separated by
+// semicolons. The bodies of struct, union and enum definitions are discarded,
+// and `extern "C" { ... }` blocks are flattened.
+func splitStmts(toks []string) ([][]string, error) {
+	var stmts [][]string
+	var cur []string
+	// Number of open `extern "C"` blocks.
+	externs := 0
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		switch tok {
+		case ";":
+			if len(cur) > 0 {
+				stmts = append(stmts, cur)
+			}
+			cur = nil
+		case "{":
+			if len(cur) == 1 && cur[0] == "extern" {
+				externs++
+				cur = nil
+				continue
+			}
+			// Inline function definitions are treated as prototypes.
+			isDef := len(cur) > 0 && cur[len(cur)-1] == ")"
+			// Skip struct, union or enum body.
+			depth := 1
+			for i++; i < len(toks) && depth > 0; i++ {
+				switch toks[i] {
+				case "{":
+					depth++
+				case "}":
+					depth--
+				}
+			}
+			if depth > 0 {
+				return nil, errors.New("unbalanced braces; missing '}'")
+			}
+			i--
+			if isDef {
+				stmts = append(stmts, cur)
+				cur = nil
+				continue
+			}
+			cur = append(cur, "{}")
+		case "}":
+			if externs == 0 {
+				return nil, errors.New("unbalanced braces; unexpected '}'")
+			}
+			externs--
+			cur = nil
+		default:
+			cur = append(cur, tok)
+		}
+	}
+	return stmts, nil
+}
+
+// --- [ Parser ] --------------------------------------------------------------
+
+// headerParser tracks the typedefs of a C header being parsed.
+type headerParser struct {
+	// Read-only global lifter state.
+	l *Lifter
+	// Map from typedef name to type.
+	typedefs map[string]types.Type
+}
+
+// opaqueType represents struct and union types, the layout of which is not
+// tracked. Pointers to opaque types are represented as i8*.
+var opaqueType = types.NewStruct()
+
+// ignoredWords specifies storage class specifiers, type qualifiers, and common
+// Windows SDK annotation macros (including parameterless SAL annotations)
+// without effect on function signatures.
+var ignoredWords = map[string]bool{
+	"auto":            true,
+	"const":           true,
+	"extern":          true,
+	"inline":          true,
+	"register":        true,
+	"restrict":        true,
+	"static":          true,
+	"volatile":        true,
+	"__extension__":   true,
+	"__inline":        true,
+	"__restrict":      true,
+	"DECLSPEC_IMPORT": true,
+	"WINADVAPI":       true,
+	"WINBASEAPI":      true,
+	"WINGDIAPI":       true,
+	"WINUSERAPI":      true,
+	"NTSYSAPI":        true,
+	"CONST":           true,
+	"IN":              true,
+	"OUT":             true,
+	"OPTIONAL":        true,
+	"_In_":            true,
+	"_In_opt_":        true,
+	"_Out_":           true,
+	"_Out_opt_":       true,
+	"_Inout_":         true,
+	"_Inout_opt_":     true,
+	"_Reserved_":      true,
+}
+
+// callConvWords maps from calling convention keyword or macro to LLVM IR
+// calling convention.
+var callConvWords = map[string]ir.CallConv{
+	"__cdecl":    ir.CallConvNone,
+	"_cdecl":     ir.CallConvNone,
+	"cdecl":      ir.CallConvNone,
+	"WINAPIV":    ir.CallConvNone,
+	"EFIAPI":     ir.CallConvNone,
+	"__stdcall":  ir.CallConvX86_StdCall,
+	"_stdcall":   ir.CallConvX86_StdCall,
+	"WINAPI":     ir.CallConvX86_StdCall,
+	"APIENTRY":   ir.CallConvX86_StdCall,
+	"CALLBACK":   ir.CallConvX86_StdCall,
+	"NTAPI":      ir.CallConvX86_StdCall,
+	"PASCAL":     ir.CallConvX86_StdCall,
+	"__fastcall": ir.CallConvX86_FastCall,
+	"_fastcall":  ir.CallConvX86_FastCall,
+	"__thiscall": ir.CallConvX86_ThisCall,
+}
+
+// regConvWords maps from calling convention keyword to register calling
+// convention.
+var regConvWords = map[string]*RegConv{
+	"__watcall": regConvs["watcom"],
+	"_watcall":  regConvs["watcom"],
+	"__pascal":  regConvs["pascal"],
+	"_pascal":   regConvs["pascal"],
+	"pascal":    regConvs["pascal"],
+}
+
+// parseStmt parses the given top-level declaration. A function prototype is
+// returned if the declaration declares a function, and nil otherwise.
+func (p *headerParser) parseStmt(stmt []string) (*Proto, error) {
+	stmt = stripAnnotations(stmt)
+	if len(stmt) == 0 {
+		return nil, nil
+	}
+	if stmt[0] == "typedef" {
+		return nil, p.parseTypedef(stmt[1:])
+	}
+	// Function prototype; the function name is followed by the parameter list.
+	lparen := indexOf(stmt, "(")
+	if lparen < 1 {
+		// Ignore variable declarations and forward declarations of structs.
+		return nil, nil
+	}
+	name := stmt[lparen-1]
+	if !isIdent(name) {
+		return nil, errors.Errorf("support for declaration not yet implemented")
+	}
+	ret, callconv, err := p.parseSpec(stmt[:lparen-1])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	rparen := matchParen(stmt, lparen)
+	if rparen == -1 {
+		return nil, errors.New("unbalanced parentheses; missing ')'")
+	}
+	sig, err := p.parseParams(ret, stmt[lparen+1:rparen])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	proto := &Proto{
+		Name:     name,
+		CallConv: p.callConv(callconv),
+		RegConv:  p.regConv(stmt[:lparen-1]),
+		Sig:      sig,
+	}
+	return proto, nil
+}
+
+// regConv returns the register calling convention of functions declared with
+// the given declaration specifiers; or nil if not present or not supported in
+// the processor mode of the lifter.
+func (p *headerParser) regConv(toks []string) *RegConv {
+	for _, tok := range toks {
+		if rc, ok := regConvWords[tok]; ok && p.l.supportsRegConv(rc) {
+			return rc
+		}
+	}
+	return nil
+}
+
+// callConv returns the calling convention of functions declared with the given
+// calling convention, taking the processor mode into account.
+func (p *headerParser) callConv(callconv ir.CallConv) ir.CallConv {
+	if p.l.Mode == 64 {
+		// Calling convention keywords are ignored on 64-bit.
+		return p.l.defaultCallConv()
+	}
+	return callconv
+}
+
+// parseTypedef parses the given typedef declaration (excluding the leading
+// "typedef" keyword).
+func (p *headerParser) parseTypedef(toks []string) error {
+	// Function pointer typedef; e.g.
+	//
+	//    typedef int (WINAPI *FARPROC)();
+	if lparen := indexOf(toks, "("); lparen != -1 {
+		name, ok := funcPtrName(toks[lparen:])
+		if !ok {
+			// Ignore function typedefs.
+			return nil
+		}
+		p.typedefs[name] = types.NewPointer(types.I8)
+		return nil
+	}
+	// Split base type from the trailing declarators; e.g.
+	//
+	//    typedef struct _FOO {} FOO, *PFOO;
+	n := len(toks)
+	for n > 1 && isIdent(toks[n-1]) {
+		n--
+		for n > 0 && toks[n-1] == "*" {
+			n--
+		}
+		if n == 0 || toks[n-1] != "," {
+			break
+		}
+		n--
+	}
+	base, _, err := p.parseSpec(toks[:n])
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, decl := range splitList(toks[n:]) {
+		t := base
+		name := ""
+		for _, tok := range decl {
+			switch tok {
+			case "*":
+				t = ptrTo(t)
+			default:
+				name = tok
+			}
+		}
+		if len(name) == 0 {
+			return errors.New("missing typedef name")
+		}
+		p.typedefs[name] = t
+	}
+	return nil
+}
+
+// hasBaseType reports whether the given declaration specifiers contain a type
+// specifier.
+func (p *headerParser) hasBaseType(toks []string) bool {
+	for _, tok := range toks {
+		switch tok {
+		case "struct", "union", "enum", "{}":
+			return true
+		}
+		if p.isTypeWord(tok) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseParams parses the given parameter list of a function prototype
+// (excluding parentheses), and returns the corresponding function signature.
+func (p *headerParser) parseParams(ret types.Type, toks []string) (*types.FuncType, error) {
+	var params []*types.Param
+	variadic := false
+	list := splitList(toks)
+	if len(list) == 1 && len(list[0]) == 1 && list[0][0] == "void" {
+		list = nil
+	}
+	for i, decl := range list {
+		if len(decl) == 1 && decl[0] == "..." {
+			variadic = true
+			continue
+		}
+		t, name, err := p.parseParam(decl)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(name) == 0 {
+			name = fmt.Sprintf("arg_%d", i)
+		}
+		params = append(params, types.NewParam(name, t))
+	}
+	if ret == opaqueType {
+		return nil, errors.New("support for struct return value not yet implemented")
+	}
+	sig := types.NewFunc(ret, params...)
+	sig.Variadic = variadic
+	return sig, nil
+}
+
+// parseParam parses the given parameter declaration, and returns its type and
+// name (if any).
+func (p *headerParser) parseParam(decl []string) (types.Type, string, error) {
+	// Function pointer parameter; e.g.
+	//
+	//    int (*cmp)(const void *, const void *)
+	if lparen := indexOf(decl, "("); lparen != -1 {
+		name, _ := funcPtrName(decl[lparen:])
+		return types.NewPointer(types.I8), name, nil
+	}
+	// Array parameters decay into pointers.
+	array := false
+	if lbrack := indexOf(decl, "["); lbrack != -1 {
+		decl = decl[:lbrack]
+		array = true
+	}
+	var name string
+	if n := len(decl); n > 1 && isIdent(decl[n-1]) && !p.isTypeWord(decl[n-1]) && p.hasBaseType(decl[:n-1]) {
+		name = decl[n-1]
+		decl = decl[:n-1]
+	}
+	// Separate pointer declarators from the base type.
+	nptrs := 0
+	for len(decl) > 0 && decl[len(decl)-1] == "*" {
+		nptrs++
+		decl = decl[:len(decl)-1]
+	}
+	t, _, err := p.parseSpec(decl)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	for i := 0; i < nptrs; i++ {
+		t = ptrTo(t)
+	}
+	if array {
+		t = ptrTo(t)
+	}
+	switch t {
+	case types.Void:
+		return nil, "", errors.New("invalid parameter type; void")
+	case opaqueType:
+		return nil, "", errors.New("support for struct parameter passed by value not yet implemented")
+	}
+	return t, name, nil
+}
+
+// parseSpec parses the given declaration specifiers, optionally followed by
+// pointer declarators and a calling convention; e.g. "unsigned long *" or
+// "HANDLE WINAPI".
+func (p *headerParser) parseSpec(toks []string) (types.Type, ir.CallConv, error) {
+	var (
+		words    []string
+		nptrs    int
+		callconv ir.CallConv
+		t        types.Type
+	)
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		if cc, ok := callConvWords[tok]; ok {
+			callconv = cc
+			continue
+		}
+		if _, ok := regConvWords[tok]; ok {
+			// Register calling conventions are located by regConv.
+			continue
+		}
+		switch tok {
+		case "*":
+			nptrs++
+		case "struct", "union":
+			// Skip struct tag, if present.
+			if i+1 < len(toks) && isIdent(toks[i+1]) {
+				i++
+			}
+			t = opaqueType
+		case "enum":
+			if i+1 < len(toks) && isIdent(toks[i+1]) {
+				i++
+			}
+			t = types.I32
+		case "{}":
+			// Discarded struct, union or enum body.
+		default:
+			if typ, ok := p.typedefs[tok]; ok && t == nil && len(words) == 0 {
+				t = typ
+				continue
+			}
+			if typ, ok := p.builtinTypedef(tok); ok && t == nil && len(words) == 0 {
+				t = typ
+				continue
+			}
+			if !basicTypeWords[tok] {
+				return nil, "", errors.Errorf("support for type %q not yet implemented", tok)
+			}
+			words = append(words, tok)
+		}
+	}
+	if t == nil {
+		if len(words) == 0 {
+			return nil, "", errors.New("missing type specifier")
+		}
+		typ, err := p.basicType(words)
+		if err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+		t = typ
+	}
+	for i := 0; i < nptrs; i++ {
+		t = ptrTo(t)
+	}
+	return t, callconv, nil
+}
+
+// basicTypeWords specifies the keywords of basic C types.
+var basicTypeWords = map[string]bool{
+	"void":     true,
+	"char":     true,
+	"short":    true,
+	"int":      true,
+	"long":     true,
+	"signed":   true,
+	"unsigned": true,
+	"float":    true,
+	"double":   true,
+	"_Bool":    true,
+	"bool":     true,
+	"__int8":   true,
+	"__int16":  true,
+	"__int32":  true,
+	"__int64":  true,
+}
+
+// isTypeWord reports whether the given token is the name of a type.
+func (p *headerParser) isTypeWord(tok string) bool {
+	if basicTypeWords[tok] {
+		return true
+	}
+	if _, ok := p.typedefs[tok]; ok {
+		return true
+	}
+	_, ok := p.builtinTypedef(tok)
+	return ok
+}
+
+// basicType returns the LLVM IR type of the basic C type specified by the
+// given keywords; e.g. "unsigned long long".
+func (p *headerParser) basicType(words []string) (types.Type, error) {
+	counts := make(map[string]int)
+	for _, word := range words {
+		counts[word]++
+	}
+	switch {
+	case counts["void"] > 0:
+		return types.Void, nil
+	case counts["float"] > 0:
+		return types.Float, nil
+	case counts["double"] > 0:
+		if counts["long"] > 0 {
+			return types.X86_FP80, nil
+		}
+		return types.Double, nil
+	case counts["char"] > 0, counts["_Bool"] > 0, counts["bool"] > 0, counts["__int8"] > 0:
+		return types.I8, nil
+	case counts["short"] > 0, counts["__int16"] > 0:
+		return types.I16, nil
+	case counts["__int32"] > 0:
+		return types.I32, nil
+	case counts["__int64"] > 0, counts["long"] >= 2:
+		return types.I64, nil
+	case counts["long"] == 1:
+		return p.longType(), nil
+	case counts["int"] > 0, counts["signed"] > 0, counts["unsigned"] > 0:
+		return types.I32, nil
+	}
+	return nil, errors.Errorf("support for type %q not yet implemented", strings.Join(words, " "))
+}
+
+// longType returns the LLVM IR type of the C long type; 64-bit on 64-bit
+// non-PE binary executables (LP64), and 32-bit otherwise (ILP32 and LLP64).
+func (p *headerParser) longType() types.Type {
+	if p.l.Mode == 64 && p.l.File.Format != "pe" {
+		return types.I64
+	}
+	return types.I32
+}
+
+// builtinTypedef returns the LLVM IR type of the given standard C typedef
+// (e.g. size_t or uint32_t). The boolean return value indicates success.
+func (p *headerParser) builtinTypedef(name string) (types.Type, bool) {
+	switch name {
+	case "int8_t", "uint8_t":
+		return types.I8, true
+	case "int16_t", "uint16_t":
+		return types.I16, true
+	case "int32_t", "uint32_t":
+		return types.I32, true
+	case "int64_t", "uint64_t":
+		return types.I64, true
+	case "size_t", "ssize_t", "ptrdiff_t", "intptr_t", "uintptr_t":
+		return types.NewInt(p.l.Mode), true
+	case "wchar_t":
+		if p.l.File.Format == "pe" {
+			return types.I16, true
+		}
+		return types.I32, true
+	case "va_list":
+		return types.NewPointer(types.I8), true
+	}
+	return nil, false
+}
+
+// ptrTo returns a pointer to the given type. Pointers to void and opaque types
+// are represented as i8*.
+func ptrTo(t types.Type) types.Type {
+	if t == types.Void || t == opaqueType {
+		return types.NewPointer(types.I8)
+	}
+	return types.NewPointer(t)
+}
+
+// --- [ Helper functions ] ----------------------------------------------------
+
+// stripAnnotations returns the given tokens without ignored words, and without
+// __declspec(...) and __attribute__((...)) annotations.
+func stripAnnotations(toks []string) []string {
+	var out []string
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		switch {
+		case ignoredWords[tok]:
+			continue
+		case tok == "__declspec" || tok == "__attribute__":
+			if i+1 < len(toks) && toks[i+1] == "(" {
+				if end := matchParen(toks, i+1); end != -1 {
+					i = end
+				}
+			}
+			continue
+		}
+		out = append(out, tok)
+	}
+	return out
+}
+
+// splitList splits the given tokens on top-level commas.
+func splitList(toks []string) [][]string {
+	var list [][]string
+	var cur []string
+	depth := 0
+	for _, tok := range toks {
+		switch tok {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case ",":
+			if depth == 0 {
+				list = append(list, cur)
+				cur = nil
+				continue
+			}
+		}
+		cur = append(cur, tok)
+	}
+	if len(cur) > 0 {
+		list = append(list, cur)
+	}
+	return list
+}
+
+// funcPtrName returns the name of the function pointer declarator starting at
+// the given parenthesis; e.g. "cmp" of "(*cmp)(void)". The boolean return value
+// indicates whether the declarator is a function pointer.
+func funcPtrName(toks []string) (string, bool) {
+	end := matchParen(toks, 0)
+	if end == -1 || indexOf(toks[:end], "*") == -1 {
+		return "", false
+	}
+	name := ""
+	for _, tok := range toks[1:end] {
+		if isIdent(tok) {
+			if _, ok := callConvWords[tok]; !ok {
+				name = tok
+			}
+		}
+	}
+	return name, true
+}
+
+// matchParen returns the index of the parenthesis matching the opening
+// parenthesis at the given index, or -1 if not found.
+func matchParen(toks []string, start int) int {
+	depth := 0
+	for i := start; i < len(toks); i++ {
+		switch toks[i] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// indexOf returns the index of the first occurrence of the given token, or -1
+// if not present.
+func indexOf(toks []string, tok string) int {
+	for i, t := range toks {
+		if t == tok {
+			return i
+		}
+	}
+	return -1
+}
+
+// isIdent reports whether the given token is an identifier.
+func isIdent(tok string) bool {
+	if len(tok) == 0 || '0' <= tok[0] && tok[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(tok); i++ {
+		if !isIdentChar(tok[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package x86
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+)
+
+func TestParseHeader(t *testing.T) {
+	const src = `
+#ifndef _WINDOWS_H
+#define _WINDOWS_H
+
+typedef unsigned long DWORD;
+typedef void *HANDLE, *PVOID;
+typedef const char *LPCSTR;
+typedef struct _SECURITY_ATTRIBUTES {
+	DWORD nLength;
+} SECURITY_ATTRIBUTES, *LPSECURITY_ATTRIBUTES;
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* Creates or opens a file. */
+WINBASEAPI HANDLE WINAPI CreateFileA(
+	_In_ LPCSTR lpFileName,
+	DWORD dwDesiredAccess,
+	DWORD dwShareMode,
+	LPSECURITY_ATTRIBUTES lpSecurityAttributes,
+	DWORD dwCreationDisposition,
+	DWORD dwFlagsAndAttributes,
+	HANDLE hTemplateFile);
+int __cdecl printf(const char *format, ...);
+void __fastcall f(int a[], int (*cb)(void));
+DWORD WINAPI GetLastError(void);
+int __watcall w(int a, int b);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
+`
+	l := &Lifter{
+		Disasm: &x86.Disasm{
+			Disasm: &disasm.Disasm{
+				File: &bin.File{Format: "pe"},
+			},
+			Mode: 32,
+		},
+	}
+	protos, err := l.ParseHeader(src)
+	if err != nil {
+		t.Fatalf("unable to parse C header; %v", err)
+	}
+	golden := []struct {
+		name     string
+		callconv ir.CallConv
+		regconv  string
+		params   []string
+		ret      string
+		variadic bool
+	}{
+		{name: "CreateFileA", callconv: ir.CallConvX86_StdCall, params: []string{"i8*", "i32", "i32", "i8*", "i32", "i32", "i8*"}, ret: "i8*"},
+		{name: "printf", callconv: ir.CallConvNone, params: []string{"i8*"}, ret: "i32", variadic: true},
+		{name: "f", callconv: ir.CallConvX86_FastCall, params: []string{"i32*", "i8*"}, ret: "void"},
+		{name: "GetLastError", callconv: ir.CallConvX86_StdCall, ret: "i32"},
+		{name: "w", callconv: ir.CallConvNone, regconv: "watcom", params: []string{"i32", "i32"}, ret: "i32"},
+	}
+	if len(protos) != len(golden) {
+		t.Fatalf("number of prototypes mismatch; expected %d, got %d", len(golden), len(protos))
+	}
+	for i, g := range golden {
+		proto := protos[i]
+		if proto.Name != g.name {
+			t.Errorf("%d: name mismatch; expected %q, got %q", i, g.name, proto.Name)
+			continue
+		}
+		if proto.CallConv != g.callconv {
+			t.Errorf("%s: calling convention mismatch; expected %q, got %q", g.name, g.callconv, proto.CallConv)
+		}
+		if got := proto.RegConv.String(); got != g.regconv {
+			t.Errorf("%s: register calling convention mismatch; expected %q, got %q", g.name, g.regconv, got)
+		}
+		if got := proto.Sig.Ret.String(); got != g.ret {
+			t.Errorf("%s: return type mismatch; expected %q, got %q", g.name, g.ret, got)
+		}
+		if proto.Sig.Variadic != g.variadic {
+			t.Errorf("%s: variadic mismatch; expected %v, got %v", g.name, g.variadic, proto.Sig.Variadic)
+		}
+		if len(proto.Sig.Params) != len(g.params) {
+			t.Errorf("%s: number of parameters mismatch; expected %d, got %d", g.name, len(g.params), len(proto.Sig.Params))
+			continue
+		}
+		for j, param := range proto.Sig.Params {
+			if got := param.Typ.String(); got != g.params[j] {
+				t.Errorf("%s: type mismatch of parameter %d; expected %q, got %q", g.name, j, g.params[j], got)
+			}
+		}
+	}
+}
+
+func TestParseHeader16(t *testing.T) {
+	const src = `
+short __pascal p(short a, short b);
+short __watcall w(short a, short b);
+`
+	l := &Lifter{
+		Disasm: &x86.Disasm{
+			Disasm: &disasm.Disasm{
+				File: &bin.File{Format: "mz"},
+			},
+			Mode: 16,
+		},
+	}
+	protos, err := l.ParseHeader(src)
+	if err != nil {
+		t.Fatalf("unable to parse C header; %v", err)
+	}
+	golden := []struct {
+		name    string
+		regconv string
+	}{
+		{name: "p", regconv: "pascal"},
+		// Register calling conventions passing arguments in 32-bit registers
+		// are not supported in 16-bit mode.
+		{name: "w", regconv: ""},
+	}
+	if len(protos) != len(golden) {
+		t.Fatalf("number of prototypes mismatch; expected %d, got %d", len(golden), len(protos))
+	}
+	for i, g := range golden {
+		proto := protos[i]
+		if proto.Name != g.name {
+			t.Errorf("%d: name mismatch; expected %q, got %q", i, g.name, proto.Name)
+			continue
+		}
+		if got := proto.RegConv.String(); got != g.regconv {
+			t.Errorf("%s: register calling convention mismatch; expected %q, got %q", g.name, g.regconv, got)
+		}
+		for j, param := range proto.Sig.Params {
+			if got := param.Typ.String(); got != "i16" {
+				t.Errorf("%s: type mismatch of parameter %d; expected %q, got %q", g.name, j, "i16", got)
+			}
+		}
+	}
+}
+
+func TestUndecorate(t *testing.T) {
+	golden := []struct {
+		in   string
+		want string
+	}{
+		{in: "CreateFileA", want: "CreateFileA"},
+		{in: "_CreateFileA@28", want: "CreateFileA"},
+		{in: "__imp__CreateFileA@28", want: "CreateFileA"},
+		{in: "_printf", want: "_printf"},
+	}
+	for _, g := range golden {
+		if got := undecorate(g.in); got != g.want {
+			t.Errorf("%q: undecorated name mismatch; expected %q, got %q", g.in, g.want, got)
+		}
+	}
+}
+
+func TestBundledHeaders(t *testing.T) {
+	golden := []struct {
+		format string
+		src    string
+	}{
+		{format: "pe", src: win32Header},
+		{format: "elf", src: libcHeader},
+		{format: "pe", src: libcHeader + msvcrtHeader},
+	}
+	for _, g := range golden {
+		for _, mode := range []int{32, 64} {
+			l := &Lifter{
+				Disasm: &x86.Disasm{
+					Disasm: &disasm.Disasm{
+						File: &bin.File{Format: g.format},
+					},
+					Mode: mode,
+				},
+			}
+			protos, err := l.ParseHeader(g.src)
+			if err != nil {
+				t.Errorf("%s (%d-bit): unable to parse bundled C header; %v", g.format, mode, err)
+				continue
+			}
+			// Each prototype is declared on a line of its own.
+			want := 0
+			for _, line := range strings.Split(g.src, "\n") {
+				if strings.HasSuffix(line, ");") && !strings.HasPrefix(line, "typedef") {
+					want++
+				}
+			}
+			if len(protos) != want {
+				t.Errorf("%s (%d-bit): number of prototypes mismatch; expected %d, got %d", g.format, mode, want, len(protos))
+			}
+		}
+	}
+}
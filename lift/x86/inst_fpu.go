@@ -10,7 +10,6 @@ import (
 	"math"
 
 	"github.com/decomp/exp/disasm/x86"
-	"github.com/kr/pretty"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
@@ -37,7 +36,7 @@ func (f *Func) liftInstFLD(inst *x86.Inst) error {
 	// operand is in single-precision or double-precision floating-point format,
 	// it is automatically converted to the double extended-precision floating-
 	// point format before being pushed on the stack.
-	src := f.useArg(inst.Arg(0))
+	src := f.useFloatArg(inst.Arg(0))
 	// TODO: Verify that FLD ST(i) is handled correctly.
 	if !types.Equal(src.Type(), types.X86_FP80) {
 		src = f.cur.NewFPExt(src, types.X86_FP80)
@@ -76,7 +75,7 @@ func (f *Func) liftInstFST(inst *x86.Inst) error {
 	default:
 		panic(fmt.Errorf("support for operand type %T not yet implemented", arg))
 	}
-	f.defArg(inst.Arg(0), src)
+	f.defFloatArg(inst.Arg(0), src)
 	return nil
 }
 
@@ -111,7 +110,7 @@ func (f *Func) liftInstFSTP(inst *x86.Inst) error {
 	default:
 		panic(fmt.Errorf("support for operand type %T not yet implemented", arg))
 	}
-	f.defArg(inst.Arg(0), src)
+	f.defFloatArg(inst.Arg(0), src)
 	f.pop()
 	return nil
 }
@@ -141,7 +140,7 @@ func (f *Func) liftInstFILD(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFIST(inst *x86.Inst) error {
 	// FIST - Store integer.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFIST: not yet implemented")
 }
 
@@ -151,7 +150,7 @@ func (f *Func) liftInstFIST(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFISTP(inst *x86.Inst) error {
 	// FISTP - Store integer and pop.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFISTP: not yet implemented")
 }
 
@@ -161,7 +160,7 @@ func (f *Func) liftInstFISTP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFBLD(inst *x86.Inst) error {
 	// FBLD - Load BCD.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFBLD: not yet implemented")
 }
 
@@ -171,7 +170,7 @@ func (f *Func) liftInstFBLD(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFBSTP(inst *x86.Inst) error {
 	// FBSTP - Store BCD and pop.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFBSTP: not yet implemented")
 }
 
@@ -181,7 +180,7 @@ func (f *Func) liftInstFBSTP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFXCH(inst *x86.Inst) error {
 	// FXCH - Exchange registers.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFXCH: not yet implemented")
 }
 
@@ -208,7 +207,7 @@ func (f *Func) liftInstFXCH(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVE(inst *x86.Inst) error {
 	// FCMOVE - Floating-point conditional move if equal.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCMOVE: not yet implemented")
 }
 
@@ -218,7 +217,7 @@ func (f *Func) liftInstFCMOVE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVNE(inst *x86.Inst) error {
 	// FCMOVNE - Floating-point conditional move if not equal.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCMOVNE: not yet implemented")
 }
 
@@ -228,7 +227,7 @@ func (f *Func) liftInstFCMOVNE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVB(inst *x86.Inst) error {
 	// FCMOVB - Floating-point conditional move if below.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCMOVB: not yet implemented")
 }
 
@@ -238,7 +237,7 @@ func (f *Func) liftInstFCMOVB(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVBE(inst *x86.Inst) error {
 	// FCMOVBE - Floating-point conditional move if below or equal.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCMOVBE: not yet implemented")
 }
 
@@ -248,7 +247,7 @@ func (f *Func) liftInstFCMOVBE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVNB(inst *x86.Inst) error {
 	// FCMOVNB - Floating-point conditional move if not below.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCMOVNB: not yet implemented")
 }
 
@@ -258,7 +257,7 @@ func (f *Func) liftInstFCMOVNB(inst *x86.Inst) error {
 // emitting code to f.
 func (f *Func) liftInstFCMOVNBE(inst *x86.Inst) error {
 	// FCMOVNBE - Floating-point conditional move if not below or equal.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCMOVNBE: not yet implemented")
 }
 
@@ -268,7 +267,7 @@ func (f *Func) liftInstFCMOVNBE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVU(inst *x86.Inst) error {
 	// FCMOVU - Floating-point conditional move if unordered.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCMOVU: not yet implemented")
 }
 
@@ -278,7 +277,7 @@ func (f *Func) liftInstFCMOVU(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVNU(inst *x86.Inst) error {
 	// FCMOVNU - Floating-point conditional move if not unordered.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCMOVNU: not yet implemented")
 }
 
@@ -307,7 +306,7 @@ func (f *Func) liftInstFADD(inst *x86.Inst) error {
 		return nil
 	}
 	// One-operand form.
-	src := f.useArg(inst.Arg(0))
+	src := f.useFloatArg(inst.Arg(0))
 	v := f.cur.NewFPExt(src, types.X86_FP80)
 	st0 := f.fload()
 	result := f.cur.NewFAdd(st0, v)
@@ -360,7 +359,7 @@ func (f *Func) liftInstFIADD(inst *x86.Inst) error {
 	//
 	// Adds the destination and source operands and stores the sum in the
 	// destination location.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFIADD: not yet implemented")
 }
 
@@ -378,7 +377,7 @@ func (f *Func) liftInstFSUB(inst *x86.Inst) error {
 	//
 	// Subtracts the source operand from the destination operand and stores the
 	// difference in the destination location.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSUB: not yet implemented")
 }
 
@@ -394,7 +393,7 @@ func (f *Func) liftInstFSUBP(inst *x86.Inst) error {
 	//
 	// Subtracts the source operand from the destination operand and stores the
 	// difference in the destination location.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSUBP: not yet implemented")
 }
 
@@ -424,7 +423,7 @@ func (f *Func) liftInstFISUB(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFSUBR(inst *x86.Inst) error {
 	// FSUBR - Subtract floating-point reverse.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSUBR: not yet implemented")
 }
 
@@ -434,7 +433,7 @@ func (f *Func) liftInstFSUBR(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFSUBRP(inst *x86.Inst) error {
 	// FSUBRP - Subtract floating-point reverse and pop.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSUBRP: not yet implemented")
 }
 
@@ -444,7 +443,7 @@ func (f *Func) liftInstFSUBRP(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFISUBR(inst *x86.Inst) error {
 	// FISUBR - Subtract integer reverse.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFISUBR: not yet implemented")
 }
 
@@ -471,7 +470,7 @@ func (f *Func) liftInstFMUL(inst *x86.Inst) error {
 		return nil
 	}
 	// One-operand form.
-	arg := f.useArg(inst.Arg(0))
+	arg := f.useFloatArg(inst.Arg(0))
 	src := f.cur.NewFPExt(arg, types.X86_FP80)
 	st0 := f.fload()
 	result := f.cur.NewFMul(st0, src)
@@ -491,7 +490,7 @@ func (f *Func) liftInstFMULP(inst *x86.Inst) error {
 	//
 	// Multiplies the destination and source operands and stores the product in
 	// the destination location.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFMULP: not yet implemented")
 }
 
@@ -538,7 +537,7 @@ func (f *Func) liftInstFDIV(inst *x86.Inst) error {
 		return nil
 	}
 	// One-operand form.
-	arg := f.useArg(inst.Arg(0))
+	arg := f.useFloatArg(inst.Arg(0))
 	src := f.cur.NewFPExt(arg, types.X86_FP80)
 	st0 := f.fload()
 	result := f.cur.NewFDiv(st0, src)
@@ -611,7 +610,7 @@ func (f *Func) liftInstFDIVR(inst *x86.Inst) error {
 	// Divides the source operand by the destination operand and stores the
 	// result in the destination location.
 
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFDIVR: not yet implemented")
 }
 
@@ -658,7 +657,7 @@ func (f *Func) liftInstFIDIVR(inst *x86.Inst) error {
 	// Divides the source operand by the destination operand and stores the
 	// result in the destination location.
 
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFIDIVR: not yet implemented")
 }
 
@@ -668,7 +667,7 @@ func (f *Func) liftInstFIDIVR(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFPREM(inst *x86.Inst) error {
 	// FPREM - Partial remainder.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFPREM: not yet implemented")
 }
 
@@ -678,7 +677,7 @@ func (f *Func) liftInstFPREM(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFPREM1(inst *x86.Inst) error {
 	// FPREM1 - IEEE Partial remainder.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFPREM1: not yet implemented")
 }
 
@@ -688,7 +687,7 @@ func (f *Func) liftInstFPREM1(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFABS(inst *x86.Inst) error {
 	// FABS - Absolute value.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFABS: not yet implemented")
 }
 
@@ -698,7 +697,7 @@ func (f *Func) liftInstFABS(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFCHS(inst *x86.Inst) error {
 	// FCHS - Change sign.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCHS: not yet implemented")
 }
 
@@ -708,7 +707,7 @@ func (f *Func) liftInstFCHS(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFRNDINT(inst *x86.Inst) error {
 	// FRNDINT - Round to integer.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFRNDINT: not yet implemented")
 }
 
@@ -718,7 +717,7 @@ func (f *Func) liftInstFRNDINT(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFSCALE(inst *x86.Inst) error {
 	// FSCALE - Scale by power of two.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSCALE: not yet implemented")
 }
 
@@ -728,7 +727,7 @@ func (f *Func) liftInstFSCALE(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFSQRT(inst *x86.Inst) error {
 	// FSQRT - Square root.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSQRT: not yet implemented")
 }
 
@@ -738,7 +737,7 @@ func (f *Func) liftInstFSQRT(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFXTRACT(inst *x86.Inst) error {
 	// FXTRACT - Extract exponent and significand.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFXTRACT: not yet implemented")
 }
 
@@ -769,7 +768,7 @@ func (f *Func) liftInstFCOM(inst *x86.Inst) error {
 	if inst.Args[0] == nil {
 		panic(fmt.Errorf("support for zero-operand FCOM not yet implemented; instruction %v at address %v", inst, inst.Addr))
 	}
-	src := f.useArg(inst.Arg(0))
+	src := f.useFloatArg(inst.Arg(0))
 	if !types.Equal(src.Type(), types.X86_FP80) {
 		src = f.cur.NewFPExt(src, types.X86_FP80)
 	}
@@ -888,7 +887,7 @@ func (f *Func) liftInstFCOMPP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFUCOM(inst *x86.Inst) error {
 	// FUCOM - Unordered compare floating-point.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFUCOM: not yet implemented")
 }
 
@@ -898,7 +897,7 @@ func (f *Func) liftInstFUCOM(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFUCOMP(inst *x86.Inst) error {
 	// FUCOMP - Unordered compare floating-point and pop.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFUCOMP: not yet implemented")
 }
 
@@ -908,7 +907,7 @@ func (f *Func) liftInstFUCOMP(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFUCOMPP(inst *x86.Inst) error {
 	// FUCOMPP - Unordered compare floating-point and pop twice.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFUCOMPP: not yet implemented")
 }
 
@@ -918,7 +917,7 @@ func (f *Func) liftInstFUCOMPP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFICOM(inst *x86.Inst) error {
 	// FICOM - Compare integer.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFICOM: not yet implemented")
 }
 
@@ -928,7 +927,7 @@ func (f *Func) liftInstFICOM(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFICOMP(inst *x86.Inst) error {
 	// FICOMP - Compare integer and pop.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFICOMP: not yet implemented")
 }
 
@@ -938,7 +937,7 @@ func (f *Func) liftInstFICOMP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFCOMI(inst *x86.Inst) error {
 	// FCOMI - Compare floating-point and set EFLAGS.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCOMI: not yet implemented")
 }
 
@@ -948,7 +947,7 @@ func (f *Func) liftInstFCOMI(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFUCOMI(inst *x86.Inst) error {
 	// FUCOMI - Unordered compare floating-point and set EFLAGS.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFUCOMI: not yet implemented")
 }
 
@@ -958,7 +957,7 @@ func (f *Func) liftInstFUCOMI(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCOMIP(inst *x86.Inst) error {
 	// FCOMIP - Compare floating-point, set EFLAGS, and pop.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCOMIP: not yet implemented")
 }
 
@@ -968,7 +967,7 @@ func (f *Func) liftInstFCOMIP(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFUCOMIP(inst *x86.Inst) error {
 	// FUCOMIP - Unordered compare floating-point, set EFLAGS, and pop.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFUCOMIP: not yet implemented")
 }
 
@@ -978,7 +977,7 @@ func (f *Func) liftInstFUCOMIP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFTST(inst *x86.Inst) error {
 	// FTST - Test floating-point (compare with 0.0).
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFTST: not yet implemented")
 }
 
@@ -988,7 +987,7 @@ func (f *Func) liftInstFTST(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFXAM(inst *x86.Inst) error {
 	// FXAM - Examine floating-point.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFXAM: not yet implemented")
 }
 
@@ -1000,7 +999,7 @@ func (f *Func) liftInstFXAM(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFSIN(inst *x86.Inst) error {
 	// FSIN - Sine.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSIN: not yet implemented")
 }
 
@@ -1010,7 +1009,7 @@ func (f *Func) liftInstFSIN(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFCOS(inst *x86.Inst) error {
 	// FCOS - Cosine.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCOS: not yet implemented")
 }
 
@@ -1020,7 +1019,7 @@ func (f *Func) liftInstFCOS(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFSINCOS(inst *x86.Inst) error {
 	// FSINCOS - Sine and cosine.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSINCOS: not yet implemented")
 }
 
@@ -1030,7 +1029,7 @@ func (f *Func) liftInstFSINCOS(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFPTAN(inst *x86.Inst) error {
 	// FPTAN - Partial tangent.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFPTAN: not yet implemented")
 }
 
@@ -1040,7 +1039,7 @@ func (f *Func) liftInstFPTAN(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFPATAN(inst *x86.Inst) error {
 	// FPATAN - Partial arctangent.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFPATAN: not yet implemented")
 }
 
@@ -1050,7 +1049,7 @@ func (f *Func) liftInstFPATAN(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstF2XM1(inst *x86.Inst) error {
 	// F2XM1 - 2^x - 1.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstF2XM1: not yet implemented")
 }
 
@@ -1060,7 +1059,7 @@ func (f *Func) liftInstF2XM1(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFYL2X(inst *x86.Inst) error {
 	// FYL2X - y*log_2(x).
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFYL2X: not yet implemented")
 }
 
@@ -1070,7 +1069,7 @@ func (f *Func) liftInstFYL2X(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFYL2XP1(inst *x86.Inst) error {
 	// FYL2XP1 - y*log_2(x+1).
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFYL2XP1: not yet implemented")
 }
 
@@ -1196,7 +1195,7 @@ func (f *Func) liftInstFLDLG2(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFINCSTP(inst *x86.Inst) error {
 	// FINCSTP - Increment FPU register stack pointer.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFINCSTP: not yet implemented")
 }
 
@@ -1206,7 +1205,7 @@ func (f *Func) liftInstFINCSTP(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFDECSTP(inst *x86.Inst) error {
 	// FDECSTP - Decrement FPU register stack pointer.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFDECSTP: not yet implemented")
 }
 
@@ -1216,7 +1215,7 @@ func (f *Func) liftInstFDECSTP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFFREE(inst *x86.Inst) error {
 	// FFREE - Free floating-point register.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFFREE: not yet implemented")
 }
 
@@ -1226,7 +1225,7 @@ func (f *Func) liftInstFFREE(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFINIT(inst *x86.Inst) error {
 	// FINIT - Initialize FPU after checking error conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFINIT: not yet implemented")
 }
 
@@ -1236,7 +1235,7 @@ func (f *Func) liftInstFINIT(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFNINIT(inst *x86.Inst) error {
 	// FNINIT - Initialize FPU without checking error conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFNINIT: not yet implemented")
 }
 
@@ -1247,7 +1246,7 @@ func (f *Func) liftInstFNINIT(inst *x86.Inst) error {
 func (f *Func) liftInstFCLEX(inst *x86.Inst) error {
 	// FCLEX - Clear floating-point exception flags after checking for error
 	// conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFCLEX: not yet implemented")
 }
 
@@ -1258,7 +1257,7 @@ func (f *Func) liftInstFCLEX(inst *x86.Inst) error {
 func (f *Func) liftInstFNCLEX(inst *x86.Inst) error {
 	// FNCLEX - Clear floating-point exception flags without checking for error
 	// conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFNCLEX: not yet implemented")
 }
 
@@ -1268,7 +1267,7 @@ func (f *Func) liftInstFNCLEX(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFSTCW(inst *x86.Inst) error {
 	// FSTCW - Store FPU control word after checking error conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSTCW: not yet implemented")
 }
 
@@ -1278,7 +1277,7 @@ func (f *Func) liftInstFSTCW(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFNSTCW(inst *x86.Inst) error {
 	// FNSTCW - Store FPU control word without checking error conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFNSTCW: not yet implemented")
 }
 
@@ -1288,7 +1287,7 @@ func (f *Func) liftInstFNSTCW(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFLDCW(inst *x86.Inst) error {
 	// FLDCW - Load FPU control word.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFLDCW: not yet implemented")
 }
 
@@ -1298,7 +1297,7 @@ func (f *Func) liftInstFLDCW(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFSTENV(inst *x86.Inst) error {
 	// FSTENV - Store FPU environment after checking error conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSTENV: not yet implemented")
 }
 
@@ -1308,7 +1307,7 @@ func (f *Func) liftInstFSTENV(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFNSTENV(inst *x86.Inst) error {
 	// FNSTENV - Store FPU environment without checking error conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFNSTENV: not yet implemented")
 }
 
@@ -1318,7 +1317,7 @@ func (f *Func) liftInstFNSTENV(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFLDENV(inst *x86.Inst) error {
 	// FLDENV - Load FPU environment.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFLDENV: not yet implemented")
 }
 
@@ -1328,7 +1327,7 @@ func (f *Func) liftInstFLDENV(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFSAVE(inst *x86.Inst) error {
 	// FSAVE - Save FPU state after checking error conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFSAVE: not yet implemented")
 }
 
@@ -1338,7 +1337,7 @@ func (f *Func) liftInstFSAVE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFNSAVE(inst *x86.Inst) error {
 	// FNSAVE - Save FPU state without checking error conditions.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFNSAVE: not yet implemented")
 }
 
@@ -1348,7 +1347,7 @@ func (f *Func) liftInstFNSAVE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFRSTOR(inst *x86.Inst) error {
 	// FRSTOR - Restore FPU state.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFRSTOR: not yet implemented")
 }
 
@@ -1476,7 +1475,7 @@ func (f *Func) liftInstFNSTSW(inst *x86.Inst) error {
 // emitting code to f.
 func (f *Func) liftInstFWAIT(inst *x86.Inst) error {
 	// FWAIT - Wait for FPU.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFWAIT: not yet implemented")
 }
 
@@ -1486,7 +1485,7 @@ func (f *Func) liftInstFWAIT(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFNOP(inst *x86.Inst) error {
 	// FNOP - FPU no operation.
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("liftInstFNOP: not yet implemented")
 }
 
@@ -1614,3 +1613,31 @@ func (f *Func) fload() value.Value {
 	f.AppendBlock(end)
 	return f.cur.NewPhi(incs...)
 }
+
+// useFloatArg returns the floating-point value held by the given argument,
+// emitting code to f. Memory arguments are loaded as float or double values
+// based on the access width of the parent instruction; e.g. m32fp and m64fp.
+func (f *Func) useFloatArg(arg *x86.Arg) value.Value {
+	mem, ok := arg.Arg.(x86asm.Mem)
+	if !ok {
+		return f.useArg(arg)
+	}
+	typ, ok := floatType(arg.Parent.MemBytes)
+	if !ok {
+		// E.g. m80fp.
+		return f.useArg(arg)
+	}
+	return f.useMemElem(x86.NewMem(mem, arg.Parent), typ)
+}
+
+// defFloatArg stores the floating-point value to the given argument, emitting
+// code to f. Memory arguments are stored as float or double values based on the
+// type of the value; e.g. m32fp and m64fp.
+func (f *Func) defFloatArg(arg *x86.Arg, v value.Value) {
+	mem, ok := arg.Arg.(x86asm.Mem)
+	if !ok || types.Equal(v.Type(), types.X86_FP80) {
+		f.defArg(arg, v)
+		return
+	}
+	f.defMemElem(x86.NewMem(mem, arg.Parent), v, v.Type())
+}
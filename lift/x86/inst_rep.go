@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/decomp/exp/disasm/x86"
-	"github.com/kr/pretty"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
@@ -78,6 +77,6 @@ func (f *Func) liftREPInst(inst *x86.Inst) error {
 // liftREPNInst lifts the given REPN prefixed x86 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftREPNInst(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitREPNInst: not yet implemented")
 }
@@ -3,8 +3,8 @@ package x86
 import (
 	"fmt"
 
+	"github.com/decomp/exp/bin"
 	"github.com/decomp/exp/disasm/x86"
-	"github.com/kr/pretty"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
@@ -17,6 +17,19 @@ import (
 func (f *Func) liftInst(inst *x86.Inst) error {
 	dbg.Println("lifting instruction:", inst.Inst)
 
+	// Lift unsupported instructions as calls to stub functions, unless in
+	// strict mode.
+	if !f.l.Strict {
+		s := f.saveState()
+		defer func() {
+			if e := recover(); e != nil {
+				warn.Printf("unable to lift %v instruction at %v; emitting stub call: %v", inst.Op, inst.Addr, e)
+				f.restoreState(s)
+				f.liftStub(inst)
+			}
+		}()
+	}
+
 	// Check if prefix is present.
 	var (
 		hasREP  bool
@@ -37,7 +50,11 @@ func (f *Func) liftInst(inst *x86.Inst) error {
 		case x86asm.PrefixREX | x86asm.PrefixREXW:
 			// TODO: Implement support for REX.W
 		default:
-			pretty.Println("instruction with prefix:", inst)
+			if f.l.isSegOverride(prefix) {
+				// Segment override resolved by f.mem.
+				continue
+			}
+			trace.Dump("instruction with prefix:", inst)
 			panic(fmt.Errorf("support for %v instruction with prefix %v (0x%04X) not yet implemented", inst.Op, prefix, uint16(prefix)))
 		}
 	}
@@ -1238,7 +1255,7 @@ func (f *Func) liftInst(inst *x86.Inst) error {
 // liftInstAAA lifts the given x86 AAA instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstAAA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAAA: not yet implemented")
 }
 
@@ -1247,7 +1264,7 @@ func (f *Func) liftInstAAA(inst *x86.Inst) error {
 // liftInstAAD lifts the given x86 AAD instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstAAD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAAD: not yet implemented")
 }
 
@@ -1256,7 +1273,7 @@ func (f *Func) liftInstAAD(inst *x86.Inst) error {
 // liftInstAAM lifts the given x86 AAM instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstAAM(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAAM: not yet implemented")
 }
 
@@ -1265,7 +1282,7 @@ func (f *Func) liftInstAAM(inst *x86.Inst) error {
 // liftInstAAS lifts the given x86 AAS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstAAS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAAS: not yet implemented")
 }
 
@@ -1326,7 +1343,7 @@ func (f *Func) liftInstADD(inst *x86.Inst) error {
 // liftInstADDPD lifts the given x86 ADDPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstADDPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstADDPD: not yet implemented")
 }
 
@@ -1335,7 +1352,7 @@ func (f *Func) liftInstADDPD(inst *x86.Inst) error {
 // liftInstADDPS lifts the given x86 ADDPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstADDPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstADDPS: not yet implemented")
 }
 
@@ -1344,7 +1361,7 @@ func (f *Func) liftInstADDPS(inst *x86.Inst) error {
 // liftInstADDSD lifts the given x86 ADDSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstADDSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstADDSD: not yet implemented")
 }
 
@@ -1353,7 +1370,7 @@ func (f *Func) liftInstADDSD(inst *x86.Inst) error {
 // liftInstADDSS lifts the given x86 ADDSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstADDSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstADDSS: not yet implemented")
 }
 
@@ -1362,7 +1379,7 @@ func (f *Func) liftInstADDSS(inst *x86.Inst) error {
 // liftInstADDSUBPD lifts the given x86 ADDSUBPD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstADDSUBPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstADDSUBPD: not yet implemented")
 }
 
@@ -1371,7 +1388,7 @@ func (f *Func) liftInstADDSUBPD(inst *x86.Inst) error {
 // liftInstADDSUBPS lifts the given x86 ADDSUBPS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstADDSUBPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstADDSUBPS: not yet implemented")
 }
 
@@ -1380,7 +1397,7 @@ func (f *Func) liftInstADDSUBPS(inst *x86.Inst) error {
 // liftInstAESDEC lifts the given x86 AESDEC instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstAESDEC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAESDEC: not yet implemented")
 }
 
@@ -1389,7 +1406,7 @@ func (f *Func) liftInstAESDEC(inst *x86.Inst) error {
 // liftInstAESDECLAST lifts the given x86 AESDECLAST instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstAESDECLAST(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAESDECLAST: not yet implemented")
 }
 
@@ -1398,7 +1415,7 @@ func (f *Func) liftInstAESDECLAST(inst *x86.Inst) error {
 // liftInstAESENC lifts the given x86 AESENC instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstAESENC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAESENC: not yet implemented")
 }
 
@@ -1407,7 +1424,7 @@ func (f *Func) liftInstAESENC(inst *x86.Inst) error {
 // liftInstAESENCLAST lifts the given x86 AESENCLAST instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstAESENCLAST(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAESENCLAST: not yet implemented")
 }
 
@@ -1416,7 +1433,7 @@ func (f *Func) liftInstAESENCLAST(inst *x86.Inst) error {
 // liftInstAESIMC lifts the given x86 AESIMC instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstAESIMC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAESIMC: not yet implemented")
 }
 
@@ -1425,7 +1442,7 @@ func (f *Func) liftInstAESIMC(inst *x86.Inst) error {
 // liftInstAESKEYGENASSIST lifts the given x86 AESKEYGENASSIST instruction to
 // LLVM IR, emitting code to f.
 func (f *Func) liftInstAESKEYGENASSIST(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstAESKEYGENASSIST: not yet implemented")
 }
 
@@ -1445,7 +1462,7 @@ func (f *Func) liftInstAND(inst *x86.Inst) error {
 // liftInstANDNPD lifts the given x86 ANDNPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstANDNPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstANDNPD: not yet implemented")
 }
 
@@ -1454,7 +1471,7 @@ func (f *Func) liftInstANDNPD(inst *x86.Inst) error {
 // liftInstANDNPS lifts the given x86 ANDNPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstANDNPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstANDNPS: not yet implemented")
 }
 
@@ -1463,7 +1480,7 @@ func (f *Func) liftInstANDNPS(inst *x86.Inst) error {
 // liftInstANDPD lifts the given x86 ANDPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstANDPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstANDPD: not yet implemented")
 }
 
@@ -1472,7 +1489,7 @@ func (f *Func) liftInstANDPD(inst *x86.Inst) error {
 // liftInstANDPS lifts the given x86 ANDPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstANDPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstANDPS: not yet implemented")
 }
 
@@ -1481,7 +1498,7 @@ func (f *Func) liftInstANDPS(inst *x86.Inst) error {
 // liftInstARPL lifts the given x86 ARPL instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstARPL(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstARPL: not yet implemented")
 }
 
@@ -1490,7 +1507,7 @@ func (f *Func) liftInstARPL(inst *x86.Inst) error {
 // liftInstBLENDPD lifts the given x86 BLENDPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstBLENDPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBLENDPD: not yet implemented")
 }
 
@@ -1499,7 +1516,7 @@ func (f *Func) liftInstBLENDPD(inst *x86.Inst) error {
 // liftInstBLENDPS lifts the given x86 BLENDPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstBLENDPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBLENDPS: not yet implemented")
 }
 
@@ -1508,7 +1525,7 @@ func (f *Func) liftInstBLENDPS(inst *x86.Inst) error {
 // liftInstBLENDVPD lifts the given x86 BLENDVPD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstBLENDVPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBLENDVPD: not yet implemented")
 }
 
@@ -1517,7 +1534,7 @@ func (f *Func) liftInstBLENDVPD(inst *x86.Inst) error {
 // liftInstBLENDVPS lifts the given x86 BLENDVPS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstBLENDVPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBLENDVPS: not yet implemented")
 }
 
@@ -1526,7 +1543,7 @@ func (f *Func) liftInstBLENDVPS(inst *x86.Inst) error {
 // liftInstBOUND lifts the given x86 BOUND instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstBOUND(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBOUND: not yet implemented")
 }
 
@@ -1535,7 +1552,7 @@ func (f *Func) liftInstBOUND(inst *x86.Inst) error {
 // liftInstBSF lifts the given x86 BSF instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstBSF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBSF: not yet implemented")
 }
 
@@ -1544,7 +1561,7 @@ func (f *Func) liftInstBSF(inst *x86.Inst) error {
 // liftInstBSR lifts the given x86 BSR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstBSR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBSR: not yet implemented")
 }
 
@@ -1553,7 +1570,7 @@ func (f *Func) liftInstBSR(inst *x86.Inst) error {
 // liftInstBSWAP lifts the given x86 BSWAP instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstBSWAP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBSWAP: not yet implemented")
 }
 
@@ -1561,7 +1578,7 @@ func (f *Func) liftInstBSWAP(inst *x86.Inst) error {
 
 // liftInstBT lifts the given x86 BT instruction to LLVM IR, emitting code to f.
 func (f *Func) liftInstBT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBT: not yet implemented")
 }
 
@@ -1570,7 +1587,7 @@ func (f *Func) liftInstBT(inst *x86.Inst) error {
 // liftInstBTC lifts the given x86 BTC instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstBTC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBTC: not yet implemented")
 }
 
@@ -1579,7 +1596,7 @@ func (f *Func) liftInstBTC(inst *x86.Inst) error {
 // liftInstBTR lifts the given x86 BTR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstBTR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBTR: not yet implemented")
 }
 
@@ -1588,7 +1605,7 @@ func (f *Func) liftInstBTR(inst *x86.Inst) error {
 // liftInstBTS lifts the given x86 BTS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstBTS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstBTS: not yet implemented")
 }
 
@@ -1597,39 +1614,81 @@ func (f *Func) liftInstBTS(inst *x86.Inst) error {
 // liftInstCALL lifts the given x86 CALL instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCALL(inst *x86.Inst) error {
+	// Handle get-PC thunks of position-independent code.
+	if reg, ok := f.l.pcThunk(inst); ok {
+		next := inst.Addr + bin.Address(inst.Len)
+		pc := constant.NewInt(int64(next), types.I32)
+		if reg == 0 {
+			// call $+5
+			f.push(pc)
+			return nil
+		}
+		f.defReg(x86.NewReg(reg, inst), pc)
+		return nil
+	}
+
 	// Locate callee information.
 	callee, sig, callconv, ok := f.getFunc(inst.Arg(0))
 	if !ok {
 		panic(fmt.Errorf("unable to locate function for argument %v of instruction at address %v", inst.Arg(0), inst.Addr))
 	}
 
+	// Locate register calling convention of callee.
+	var rc *RegConv
+	if fn, ok := f.callee(inst); ok {
+		rc = fn.regConv
+	}
+
 	// Handle function arguments.
-	var args []value.Value
-	purge := int64(0)
-	for i := range sig.Params {
+	args := make([]value.Value, len(sig.Params))
+	var stackArgs []int
+	for i, param := range sig.Params {
 		// Pass argument in register.
-		switch callconv {
-		case ir.CallConvX86_FastCall:
-			switch i {
-			case 0:
-				arg := f.useReg(x86.ECX)
-				args = append(args, arg)
-				continue
-			case 1:
-				arg := f.useReg(x86.EDX)
-				args = append(args, arg)
-				continue
-			}
-		default:
-			// TODO: Add support for more calling conventions.
+		var (
+			reg *x86.Reg
+			ok  bool
+		)
+		if rc != nil {
+			reg, ok = rc.paramReg(i)
+		} else {
+			reg, ok = f.l.paramReg(callconv, i)
+		}
+		if ok {
+			args[i] = f.convert(f.useReg(reg), param.Typ)
+			continue
+		}
+		stackArgs = append(stackArgs, i)
+	}
+	if rc != nil && rc.LeftToRight {
+		// Arguments pushed from left to right are popped from right to left.
+		for i, j := 0, len(stackArgs)-1; i < j; i, j = i+1, j-1 {
+			stackArgs[i], stackArgs[j] = stackArgs[j], stackArgs[i]
 		}
+	}
+	purge := int64(0)
+	for k, i := range stackArgs {
 		// Pass argument on stack.
-		arg := f.pop()
-		args = append(args, arg)
+		if f.l.Mode == 64 {
+			// Arguments are stored in the stack slots of 64-bit code, rather
+			// than pushed; as seen from the callee, the stack slots are located
+			// above the return address pushed by CALL.
+			mem := f.l.stackSlot(callconv, k)
+			mem.Mem.Disp -= 8
+			args[i] = f.convert(f.useMem(mem), sig.Params[i].Typ)
+			continue
+		}
+		arg, size := f.popArg(sig.Params[i].Typ)
+		args[i] = arg
+		if rc != nil {
+			if rc.CalleePurge {
+				purge += size
+			}
+			continue
+		}
 		switch callconv {
-		case ir.CallConvX86_FastCall, ir.CallConvX86_StdCall:
+		case ir.CallConvX86_FastCall, ir.CallConvX86_StdCall, ir.CallConvX86_ThisCall:
 			// callee purge.
-			purge += 4
+			purge += size
 		case ir.CallConvC:
 			// caller purge; nothing to do.
 		default:
@@ -1645,7 +1704,7 @@ func (f *Func) liftInstCALL(inst *x86.Inst) error {
 
 	// Handle return value.
 	if !types.Equal(sig.Ret, types.Void) {
-		f.defReg(x86.EAX, result)
+		f.defRet(sig, result)
 	}
 	return nil
 }
@@ -1655,7 +1714,7 @@ func (f *Func) liftInstCALL(inst *x86.Inst) error {
 // liftInstCBW lifts the given x86 CBW instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstCBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCBW: not yet implemented")
 }
 
@@ -1690,7 +1749,7 @@ func (f *Func) liftInstCDQ(inst *x86.Inst) error {
 // liftInstCDQE lifts the given x86 CDQE instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCDQE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCDQE: not yet implemented")
 }
 
@@ -1699,7 +1758,7 @@ func (f *Func) liftInstCDQE(inst *x86.Inst) error {
 // liftInstCLC lifts the given x86 CLC instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstCLC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCLC: not yet implemented")
 }
 
@@ -1708,7 +1767,7 @@ func (f *Func) liftInstCLC(inst *x86.Inst) error {
 // liftInstCLD lifts the given x86 CLD instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstCLD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCLD: not yet implemented")
 }
 
@@ -1717,7 +1776,7 @@ func (f *Func) liftInstCLD(inst *x86.Inst) error {
 // liftInstCLFLUSH lifts the given x86 CLFLUSH instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCLFLUSH(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCLFLUSH: not yet implemented")
 }
 
@@ -1726,7 +1785,7 @@ func (f *Func) liftInstCLFLUSH(inst *x86.Inst) error {
 // liftInstCLI lifts the given x86 CLI instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstCLI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCLI: not yet implemented")
 }
 
@@ -1735,7 +1794,7 @@ func (f *Func) liftInstCLI(inst *x86.Inst) error {
 // liftInstCLTS lifts the given x86 CLTS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCLTS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCLTS: not yet implemented")
 }
 
@@ -1744,7 +1803,7 @@ func (f *Func) liftInstCLTS(inst *x86.Inst) error {
 // liftInstCMC lifts the given x86 CMC instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstCMC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMC: not yet implemented")
 }
 
@@ -1753,7 +1812,7 @@ func (f *Func) liftInstCMC(inst *x86.Inst) error {
 // liftInstCMOVA lifts the given x86 CMOVA instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMOVA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVA: not yet implemented")
 }
 
@@ -1762,7 +1821,7 @@ func (f *Func) liftInstCMOVA(inst *x86.Inst) error {
 // liftInstCMOVAE lifts the given x86 CMOVAE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMOVAE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVAE: not yet implemented")
 }
 
@@ -1771,7 +1830,7 @@ func (f *Func) liftInstCMOVAE(inst *x86.Inst) error {
 // liftInstCMOVB lifts the given x86 CMOVB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMOVB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVB: not yet implemented")
 }
 
@@ -1780,7 +1839,7 @@ func (f *Func) liftInstCMOVB(inst *x86.Inst) error {
 // liftInstCMOVBE lifts the given x86 CMOVBE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMOVBE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVBE: not yet implemented")
 }
 
@@ -1789,7 +1848,7 @@ func (f *Func) liftInstCMOVBE(inst *x86.Inst) error {
 // liftInstCMOVE lifts the given x86 CMOVE instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMOVE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVE: not yet implemented")
 }
 
@@ -1798,7 +1857,7 @@ func (f *Func) liftInstCMOVE(inst *x86.Inst) error {
 // liftInstCMOVG lifts the given x86 CMOVG instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMOVG(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVG: not yet implemented")
 }
 
@@ -1807,7 +1866,7 @@ func (f *Func) liftInstCMOVG(inst *x86.Inst) error {
 // liftInstCMOVGE lifts the given x86 CMOVGE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMOVGE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVGE: not yet implemented")
 }
 
@@ -1816,7 +1875,7 @@ func (f *Func) liftInstCMOVGE(inst *x86.Inst) error {
 // liftInstCMOVL lifts the given x86 CMOVL instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMOVL(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVL: not yet implemented")
 }
 
@@ -1825,7 +1884,7 @@ func (f *Func) liftInstCMOVL(inst *x86.Inst) error {
 // liftInstCMOVLE lifts the given x86 CMOVLE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMOVLE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVLE: not yet implemented")
 }
 
@@ -1834,7 +1893,7 @@ func (f *Func) liftInstCMOVLE(inst *x86.Inst) error {
 // liftInstCMOVNE lifts the given x86 CMOVNE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMOVNE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVNE: not yet implemented")
 }
 
@@ -1843,7 +1902,7 @@ func (f *Func) liftInstCMOVNE(inst *x86.Inst) error {
 // liftInstCMOVNO lifts the given x86 CMOVNO instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMOVNO(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVNO: not yet implemented")
 }
 
@@ -1852,7 +1911,7 @@ func (f *Func) liftInstCMOVNO(inst *x86.Inst) error {
 // liftInstCMOVNP lifts the given x86 CMOVNP instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMOVNP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVNP: not yet implemented")
 }
 
@@ -1861,7 +1920,7 @@ func (f *Func) liftInstCMOVNP(inst *x86.Inst) error {
 // liftInstCMOVNS lifts the given x86 CMOVNS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMOVNS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVNS: not yet implemented")
 }
 
@@ -1870,7 +1929,7 @@ func (f *Func) liftInstCMOVNS(inst *x86.Inst) error {
 // liftInstCMOVO lifts the given x86 CMOVO instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMOVO(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVO: not yet implemented")
 }
 
@@ -1879,7 +1938,7 @@ func (f *Func) liftInstCMOVO(inst *x86.Inst) error {
 // liftInstCMOVP lifts the given x86 CMOVP instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMOVP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVP: not yet implemented")
 }
 
@@ -1888,7 +1947,7 @@ func (f *Func) liftInstCMOVP(inst *x86.Inst) error {
 // liftInstCMOVS lifts the given x86 CMOVS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMOVS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMOVS: not yet implemented")
 }
 
@@ -1945,7 +2004,7 @@ func (f *Func) liftInstCMP(inst *x86.Inst) error {
 // liftInstCMPPD lifts the given x86 CMPPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMPPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPPD: not yet implemented")
 }
 
@@ -1954,7 +2013,7 @@ func (f *Func) liftInstCMPPD(inst *x86.Inst) error {
 // liftInstCMPPS lifts the given x86 CMPPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMPPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPPS: not yet implemented")
 }
 
@@ -1963,7 +2022,7 @@ func (f *Func) liftInstCMPPS(inst *x86.Inst) error {
 // liftInstCMPSB lifts the given x86 CMPSB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMPSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPSB: not yet implemented")
 }
 
@@ -1972,7 +2031,7 @@ func (f *Func) liftInstCMPSB(inst *x86.Inst) error {
 // liftInstCMPSD lifts the given x86 CMPSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMPSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPSD: not yet implemented")
 }
 
@@ -1981,7 +2040,7 @@ func (f *Func) liftInstCMPSD(inst *x86.Inst) error {
 // liftInstCMPSD_XMM lifts the given x86 CMPSD_XMM instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCMPSD_XMM(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPSD_XMM: not yet implemented")
 }
 
@@ -1990,7 +2049,7 @@ func (f *Func) liftInstCMPSD_XMM(inst *x86.Inst) error {
 // liftInstCMPSQ lifts the given x86 CMPSQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMPSQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPSQ: not yet implemented")
 }
 
@@ -1999,7 +2058,7 @@ func (f *Func) liftInstCMPSQ(inst *x86.Inst) error {
 // liftInstCMPSS lifts the given x86 CMPSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMPSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPSS: not yet implemented")
 }
 
@@ -2008,7 +2067,7 @@ func (f *Func) liftInstCMPSS(inst *x86.Inst) error {
 // liftInstCMPSW lifts the given x86 CMPSW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCMPSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPSW: not yet implemented")
 }
 
@@ -2017,7 +2076,7 @@ func (f *Func) liftInstCMPSW(inst *x86.Inst) error {
 // liftInstCMPXCHG lifts the given x86 CMPXCHG instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCMPXCHG(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPXCHG: not yet implemented")
 }
 
@@ -2026,7 +2085,7 @@ func (f *Func) liftInstCMPXCHG(inst *x86.Inst) error {
 // liftInstCMPXCHG16B lifts the given x86 CMPXCHG16B instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCMPXCHG16B(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPXCHG16B: not yet implemented")
 }
 
@@ -2035,7 +2094,7 @@ func (f *Func) liftInstCMPXCHG16B(inst *x86.Inst) error {
 // liftInstCMPXCHG8B lifts the given x86 CMPXCHG8B instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCMPXCHG8B(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCMPXCHG8B: not yet implemented")
 }
 
@@ -2044,7 +2103,7 @@ func (f *Func) liftInstCMPXCHG8B(inst *x86.Inst) error {
 // liftInstCOMISD lifts the given x86 COMISD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCOMISD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCOMISD: not yet implemented")
 }
 
@@ -2053,7 +2112,7 @@ func (f *Func) liftInstCOMISD(inst *x86.Inst) error {
 // liftInstCOMISS lifts the given x86 COMISS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstCOMISS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCOMISS: not yet implemented")
 }
 
@@ -2062,7 +2121,7 @@ func (f *Func) liftInstCOMISS(inst *x86.Inst) error {
 // liftInstCPUID lifts the given x86 CPUID instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCPUID(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCPUID: not yet implemented")
 }
 
@@ -2071,7 +2130,7 @@ func (f *Func) liftInstCPUID(inst *x86.Inst) error {
 // liftInstCQO lifts the given x86 CQO instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstCQO(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCQO: not yet implemented")
 }
 
@@ -2080,7 +2139,7 @@ func (f *Func) liftInstCQO(inst *x86.Inst) error {
 // liftInstCRC32 lifts the given x86 CRC32 instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCRC32(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCRC32: not yet implemented")
 }
 
@@ -2089,7 +2148,7 @@ func (f *Func) liftInstCRC32(inst *x86.Inst) error {
 // liftInstCVTDQ2PD lifts the given x86 CVTDQ2PD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTDQ2PD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTDQ2PD: not yet implemented")
 }
 
@@ -2098,7 +2157,7 @@ func (f *Func) liftInstCVTDQ2PD(inst *x86.Inst) error {
 // liftInstCVTDQ2PS lifts the given x86 CVTDQ2PS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTDQ2PS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTDQ2PS: not yet implemented")
 }
 
@@ -2107,7 +2166,7 @@ func (f *Func) liftInstCVTDQ2PS(inst *x86.Inst) error {
 // liftInstCVTPD2DQ lifts the given x86 CVTPD2DQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTPD2DQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTPD2DQ: not yet implemented")
 }
 
@@ -2116,7 +2175,7 @@ func (f *Func) liftInstCVTPD2DQ(inst *x86.Inst) error {
 // liftInstCVTPD2PI lifts the given x86 CVTPD2PI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTPD2PI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTPD2PI: not yet implemented")
 }
 
@@ -2125,7 +2184,7 @@ func (f *Func) liftInstCVTPD2PI(inst *x86.Inst) error {
 // liftInstCVTPD2PS lifts the given x86 CVTPD2PS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTPD2PS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTPD2PS: not yet implemented")
 }
 
@@ -2134,7 +2193,7 @@ func (f *Func) liftInstCVTPD2PS(inst *x86.Inst) error {
 // liftInstCVTPI2PD lifts the given x86 CVTPI2PD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTPI2PD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTPI2PD: not yet implemented")
 }
 
@@ -2143,7 +2202,7 @@ func (f *Func) liftInstCVTPI2PD(inst *x86.Inst) error {
 // liftInstCVTPI2PS lifts the given x86 CVTPI2PS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTPI2PS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTPI2PS: not yet implemented")
 }
 
@@ -2152,7 +2211,7 @@ func (f *Func) liftInstCVTPI2PS(inst *x86.Inst) error {
 // liftInstCVTPS2DQ lifts the given x86 CVTPS2DQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTPS2DQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTPS2DQ: not yet implemented")
 }
 
@@ -2161,7 +2220,7 @@ func (f *Func) liftInstCVTPS2DQ(inst *x86.Inst) error {
 // liftInstCVTPS2PD lifts the given x86 CVTPS2PD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTPS2PD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTPS2PD: not yet implemented")
 }
 
@@ -2170,7 +2229,7 @@ func (f *Func) liftInstCVTPS2PD(inst *x86.Inst) error {
 // liftInstCVTPS2PI lifts the given x86 CVTPS2PI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTPS2PI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTPS2PI: not yet implemented")
 }
 
@@ -2179,7 +2238,7 @@ func (f *Func) liftInstCVTPS2PI(inst *x86.Inst) error {
 // liftInstCVTSD2SI lifts the given x86 CVTSD2SI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTSD2SI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTSD2SI: not yet implemented")
 }
 
@@ -2188,7 +2247,7 @@ func (f *Func) liftInstCVTSD2SI(inst *x86.Inst) error {
 // liftInstCVTSD2SS lifts the given x86 CVTSD2SS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTSD2SS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTSD2SS: not yet implemented")
 }
 
@@ -2197,7 +2256,7 @@ func (f *Func) liftInstCVTSD2SS(inst *x86.Inst) error {
 // liftInstCVTSI2SD lifts the given x86 CVTSI2SD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTSI2SD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTSI2SD: not yet implemented")
 }
 
@@ -2206,7 +2265,7 @@ func (f *Func) liftInstCVTSI2SD(inst *x86.Inst) error {
 // liftInstCVTSI2SS lifts the given x86 CVTSI2SS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTSI2SS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTSI2SS: not yet implemented")
 }
 
@@ -2215,7 +2274,7 @@ func (f *Func) liftInstCVTSI2SS(inst *x86.Inst) error {
 // liftInstCVTSS2SD lifts the given x86 CVTSS2SD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTSS2SD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTSS2SD: not yet implemented")
 }
 
@@ -2224,7 +2283,7 @@ func (f *Func) liftInstCVTSS2SD(inst *x86.Inst) error {
 // liftInstCVTSS2SI lifts the given x86 CVTSS2SI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTSS2SI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTSS2SI: not yet implemented")
 }
 
@@ -2233,7 +2292,7 @@ func (f *Func) liftInstCVTSS2SI(inst *x86.Inst) error {
 // liftInstCVTTPD2DQ lifts the given x86 CVTTPD2DQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTTPD2DQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTTPD2DQ: not yet implemented")
 }
 
@@ -2242,7 +2301,7 @@ func (f *Func) liftInstCVTTPD2DQ(inst *x86.Inst) error {
 // liftInstCVTTPD2PI lifts the given x86 CVTTPD2PI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTTPD2PI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTTPD2PI: not yet implemented")
 }
 
@@ -2251,7 +2310,7 @@ func (f *Func) liftInstCVTTPD2PI(inst *x86.Inst) error {
 // liftInstCVTTPS2DQ lifts the given x86 CVTTPS2DQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTTPS2DQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTTPS2DQ: not yet implemented")
 }
 
@@ -2260,7 +2319,7 @@ func (f *Func) liftInstCVTTPS2DQ(inst *x86.Inst) error {
 // liftInstCVTTPS2PI lifts the given x86 CVTTPS2PI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTTPS2PI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTTPS2PI: not yet implemented")
 }
 
@@ -2269,7 +2328,7 @@ func (f *Func) liftInstCVTTPS2PI(inst *x86.Inst) error {
 // liftInstCVTTSD2SI lifts the given x86 CVTTSD2SI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTTSD2SI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTTSD2SI: not yet implemented")
 }
 
@@ -2278,7 +2337,7 @@ func (f *Func) liftInstCVTTSD2SI(inst *x86.Inst) error {
 // liftInstCVTTSS2SI lifts the given x86 CVTTSS2SI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstCVTTSS2SI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCVTTSS2SI: not yet implemented")
 }
 
@@ -2287,7 +2346,7 @@ func (f *Func) liftInstCVTTSS2SI(inst *x86.Inst) error {
 // liftInstCWD lifts the given x86 CWD instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstCWD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCWD: not yet implemented")
 }
 
@@ -2296,7 +2355,7 @@ func (f *Func) liftInstCWD(inst *x86.Inst) error {
 // liftInstCWDE lifts the given x86 CWDE instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCWDE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstCWDE: not yet implemented")
 }
 
@@ -2305,7 +2364,7 @@ func (f *Func) liftInstCWDE(inst *x86.Inst) error {
 // liftInstDAA lifts the given x86 DAA instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstDAA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstDAA: not yet implemented")
 }
 
@@ -2314,7 +2373,7 @@ func (f *Func) liftInstDAA(inst *x86.Inst) error {
 // liftInstDAS lifts the given x86 DAS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstDAS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstDAS: not yet implemented")
 }
 
@@ -2349,6 +2408,10 @@ func (f *Func) liftInstDIV(inst *x86.Inst) error {
 	if !ok {
 		return errors.Errorf("invalid argument type in instruction %v; expected *types.IntType, got %T", inst, arg.Type())
 	}
+	// Raise the divide error exception (#DE) if the divisor is zero, or if the
+	// quotient overflows.
+	f.trapDivZero(inst, arg)
+	f.trapDivOverflow(inst, arg, typ)
 	switch typ.Size {
 	case 8:
 		// Unsigned divide AX by r/m8, with result stored in:
@@ -2359,8 +2422,8 @@ func (f *Func) liftInstDIV(inst *x86.Inst) error {
 		arg = f.cur.NewZExt(arg, types.I16)
 		quo := f.cur.NewUDiv(ax, arg)
 		rem := f.cur.NewURem(ax, arg)
-		f.defReg(x86.AL, quo)
-		f.defReg(x86.AH, rem)
+		f.defReg(x86.AL, f.cur.NewTrunc(quo, types.I8))
+		f.defReg(x86.AH, f.cur.NewTrunc(rem, types.I8))
 	case 16:
 		// Unsigned divide DX:AX by r/m16, with result stored in:
 		//
@@ -2370,8 +2433,8 @@ func (f *Func) liftInstDIV(inst *x86.Inst) error {
 		arg = f.cur.NewZExt(arg, types.I32)
 		quo := f.cur.NewUDiv(dx_ax, arg)
 		rem := f.cur.NewURem(dx_ax, arg)
-		f.defReg(x86.AX, quo)
-		f.defReg(x86.DX, rem)
+		f.defReg(x86.AX, f.cur.NewTrunc(quo, types.I16))
+		f.defReg(x86.DX, f.cur.NewTrunc(rem, types.I16))
 	case 32:
 		// Unsigned divide EDX:EAX by r/m32, with result stored in:
 		//
@@ -2381,8 +2444,8 @@ func (f *Func) liftInstDIV(inst *x86.Inst) error {
 		arg = f.cur.NewZExt(arg, types.I64)
 		quo := f.cur.NewUDiv(edx_eax, arg)
 		rem := f.cur.NewURem(edx_eax, arg)
-		f.defReg(x86.EAX, quo)
-		f.defReg(x86.EDX, rem)
+		f.defReg(x86.EAX, f.cur.NewTrunc(quo, types.I32))
+		f.defReg(x86.EDX, f.cur.NewTrunc(rem, types.I32))
 	case 64:
 		// Unsigned divide RDX:RAX by r/m64, with result stored in:
 		//
@@ -2392,8 +2455,8 @@ func (f *Func) liftInstDIV(inst *x86.Inst) error {
 		arg = f.cur.NewZExt(arg, types.I128)
 		quo := f.cur.NewUDiv(rdx_rax, arg)
 		rem := f.cur.NewURem(rdx_rax, arg)
-		f.defReg(x86.RAX, quo)
-		f.defReg(x86.RDX, rem)
+		f.defReg(x86.RAX, f.cur.NewTrunc(quo, types.I64))
+		f.defReg(x86.RDX, f.cur.NewTrunc(rem, types.I64))
 	default:
 		panic(fmt.Errorf("support for argument bit size %d not yet implemented", typ.Size))
 	}
@@ -2405,7 +2468,7 @@ func (f *Func) liftInstDIV(inst *x86.Inst) error {
 // liftInstDIVPD lifts the given x86 DIVPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstDIVPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstDIVPD: not yet implemented")
 }
 
@@ -2414,7 +2477,7 @@ func (f *Func) liftInstDIVPD(inst *x86.Inst) error {
 // liftInstDIVPS lifts the given x86 DIVPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstDIVPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstDIVPS: not yet implemented")
 }
 
@@ -2423,7 +2486,7 @@ func (f *Func) liftInstDIVPS(inst *x86.Inst) error {
 // liftInstDIVSD lifts the given x86 DIVSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstDIVSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstDIVSD: not yet implemented")
 }
 
@@ -2432,7 +2495,7 @@ func (f *Func) liftInstDIVSD(inst *x86.Inst) error {
 // liftInstDIVSS lifts the given x86 DIVSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstDIVSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstDIVSS: not yet implemented")
 }
 
@@ -2441,7 +2504,7 @@ func (f *Func) liftInstDIVSS(inst *x86.Inst) error {
 // liftInstDPPD lifts the given x86 DPPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstDPPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstDPPD: not yet implemented")
 }
 
@@ -2450,7 +2513,7 @@ func (f *Func) liftInstDPPD(inst *x86.Inst) error {
 // liftInstDPPS lifts the given x86 DPPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstDPPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstDPPS: not yet implemented")
 }
 
@@ -2459,7 +2522,7 @@ func (f *Func) liftInstDPPS(inst *x86.Inst) error {
 // liftInstEMMS lifts the given x86 EMMS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstEMMS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstEMMS: not yet implemented")
 }
 
@@ -2468,7 +2531,7 @@ func (f *Func) liftInstEMMS(inst *x86.Inst) error {
 // liftInstENTER lifts the given x86 ENTER instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstENTER(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstENTER: not yet implemented")
 }
 
@@ -2477,7 +2540,7 @@ func (f *Func) liftInstENTER(inst *x86.Inst) error {
 // liftInstEXTRACTPS lifts the given x86 EXTRACTPS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstEXTRACTPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstEXTRACTPS: not yet implemented")
 }
 
@@ -2486,7 +2549,7 @@ func (f *Func) liftInstEXTRACTPS(inst *x86.Inst) error {
 // liftInstFFREEP lifts the given x86 FFREEP instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstFFREEP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstFFREEP: not yet implemented")
 }
 
@@ -2495,7 +2558,7 @@ func (f *Func) liftInstFFREEP(inst *x86.Inst) error {
 // liftInstFISTTP lifts the given x86 FISTTP instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstFISTTP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstFISTTP: not yet implemented")
 }
 
@@ -2504,7 +2567,7 @@ func (f *Func) liftInstFISTTP(inst *x86.Inst) error {
 // liftInstFXRSTOR lifts the given x86 FXRSTOR instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstFXRSTOR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstFXRSTOR: not yet implemented")
 }
 
@@ -2513,7 +2576,7 @@ func (f *Func) liftInstFXRSTOR(inst *x86.Inst) error {
 // liftInstFXRSTOR64 lifts the given x86 FXRSTOR64 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstFXRSTOR64(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstFXRSTOR64: not yet implemented")
 }
 
@@ -2522,7 +2585,7 @@ func (f *Func) liftInstFXRSTOR64(inst *x86.Inst) error {
 // liftInstFXSAVE lifts the given x86 FXSAVE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstFXSAVE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstFXSAVE: not yet implemented")
 }
 
@@ -2531,7 +2594,7 @@ func (f *Func) liftInstFXSAVE(inst *x86.Inst) error {
 // liftInstFXSAVE64 lifts the given x86 FXSAVE64 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstFXSAVE64(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstFXSAVE64: not yet implemented")
 }
 
@@ -2540,7 +2603,7 @@ func (f *Func) liftInstFXSAVE64(inst *x86.Inst) error {
 // liftInstHADDPD lifts the given x86 HADDPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstHADDPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstHADDPD: not yet implemented")
 }
 
@@ -2549,7 +2612,7 @@ func (f *Func) liftInstHADDPD(inst *x86.Inst) error {
 // liftInstHADDPS lifts the given x86 HADDPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstHADDPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstHADDPS: not yet implemented")
 }
 
@@ -2558,7 +2621,7 @@ func (f *Func) liftInstHADDPS(inst *x86.Inst) error {
 // liftInstHLT lifts the given x86 HLT instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstHLT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstHLT: not yet implemented")
 }
 
@@ -2567,7 +2630,7 @@ func (f *Func) liftInstHLT(inst *x86.Inst) error {
 // liftInstHSUBPD lifts the given x86 HSUBPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstHSUBPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstHSUBPD: not yet implemented")
 }
 
@@ -2576,7 +2639,7 @@ func (f *Func) liftInstHSUBPD(inst *x86.Inst) error {
 // liftInstHSUBPS lifts the given x86 HSUBPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstHSUBPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstHSUBPS: not yet implemented")
 }
 
@@ -2585,7 +2648,7 @@ func (f *Func) liftInstHSUBPS(inst *x86.Inst) error {
 // liftInstICEBP lifts the given x86 ICEBP instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstICEBP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstICEBP: not yet implemented")
 }
 
@@ -2595,20 +2658,41 @@ func (f *Func) liftInstICEBP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstIDIV(inst *x86.Inst) error {
 	// IDIV - Signed Divide
-
-	// Signed divide EDX:EAX by r/m32, with result stored in:
 	//
-	//    EAX = Quotient
-	//    EDX = Remainder
-	x := f.useArg(inst.Arg(0))
-	edx_eax := f.useReg(x86.EDX_EAX)
-	if !types.Equal(x.Type(), types.I64) {
-		x = f.cur.NewSExt(x, types.I64)
+	//    idiv arg
+	arg := f.useArg(inst.Arg(0))
+	typ, ok := arg.Type().(*types.IntType)
+	if !ok {
+		return errors.Errorf("invalid argument type in instruction %v; expected *types.IntType, got %T", inst, arg.Type())
 	}
-	quo := f.cur.NewSDiv(edx_eax, x)
-	rem := f.cur.NewSRem(edx_eax, x)
-	f.defReg(x86.EAX, quo)
-	f.defReg(x86.EDX, rem)
+	// Raise the divide error exception (#DE) if the divisor is zero.
+	f.trapDivZero(inst, arg)
+	// Signed divide the dividend by r/m, with result stored in:
+	//
+	//    AL, AX, EAX or RAX = quotient
+	//    AH, DX, EDX or RDX = remainder
+	var dividend, quoReg, remReg *x86.Reg
+	switch typ.Size {
+	case 8:
+		dividend, quoReg, remReg = x86.AX, x86.AL, x86.AH
+	case 16:
+		dividend, quoReg, remReg = x86.DX_AX, x86.AX, x86.DX
+	case 32:
+		dividend, quoReg, remReg = x86.EDX_EAX, x86.EAX, x86.EDX
+	case 64:
+		dividend, quoReg, remReg = x86.RDX_RAX, x86.RAX, x86.RDX
+	default:
+		panic(fmt.Errorf("support for argument bit size %d not yet implemented", typ.Size))
+	}
+	x := f.useReg(dividend)
+	y := f.cur.NewSExt(arg, x.Type())
+	// Raise the divide error exception (#DE) if the quotient overflows (e.g.
+	// INT_MIN / -1).
+	f.trapIDivOverflow(inst, x, y, typ)
+	quo := f.cur.NewSDiv(x, y)
+	rem := f.cur.NewSRem(x, y)
+	f.defReg(quoReg, f.cur.NewTrunc(quo, typ))
+	f.defReg(remReg, f.cur.NewTrunc(rem, typ))
 	return nil
 }
 
@@ -2646,25 +2730,24 @@ func (f *Func) liftInstIMUL(inst *x86.Inst) error {
 		// One-operand form.
 		y := f.useArg(inst.Arg(0))
 		size := f.l.sizeOfType(y.Type())
-		var dst value.Value
+		var src, dst *x86.Reg
 		switch size {
 		case 1:
-			x = f.useReg(x86.AL)
-			dst = f.reg(x86asm.AX)
+			src, dst = x86.AL, x86.AX
 		case 2:
-			x = f.useReg(x86.AX)
-			dst = f.reg(x86.X86asm_DX_AX)
-		case 3:
-			x = f.useReg(x86.EAX)
-			dst = f.reg(x86.X86asm_EDX_EAX)
+			src, dst = x86.AX, x86.DX_AX
 		case 4:
-			x = f.useReg(x86.RAX)
-			dst = f.reg(x86.X86asm_RDX_RAX)
+			src, dst = x86.EAX, x86.EDX_EAX
+		case 8:
+			src, dst = x86.RAX, x86.RDX_RAX
 		default:
 			panic(fmt.Errorf("support for operand type of byte size %d not yet implemented", size))
 		}
+		typ := regType(dst.Reg)
+		x = f.cur.NewSExt(f.useReg(src), typ)
+		y = f.cur.NewSExt(y, typ)
 		result := f.cur.NewMul(x, y)
-		f.cur.NewStore(result, dst)
+		f.defReg(dst, result)
 		return nil
 	}
 	result := f.cur.NewMul(x, y)
@@ -2676,7 +2759,7 @@ func (f *Func) liftInstIMUL(inst *x86.Inst) error {
 
 // liftInstIN lifts the given x86 IN instruction to LLVM IR, emitting code to f.
 func (f *Func) liftInstIN(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstIN: not yet implemented")
 }
 
@@ -2697,7 +2780,7 @@ func (f *Func) liftInstINC(inst *x86.Inst) error {
 // liftInstINSB lifts the given x86 INSB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstINSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstINSB: not yet implemented")
 }
 
@@ -2706,7 +2789,7 @@ func (f *Func) liftInstINSB(inst *x86.Inst) error {
 // liftInstINSD lifts the given x86 INSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstINSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstINSD: not yet implemented")
 }
 
@@ -2715,7 +2798,7 @@ func (f *Func) liftInstINSD(inst *x86.Inst) error {
 // liftInstINSERTPS lifts the given x86 INSERTPS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstINSERTPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstINSERTPS: not yet implemented")
 }
 
@@ -2724,7 +2807,7 @@ func (f *Func) liftInstINSERTPS(inst *x86.Inst) error {
 // liftInstINSW lifts the given x86 INSW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstINSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstINSW: not yet implemented")
 }
 
@@ -2733,7 +2816,22 @@ func (f *Func) liftInstINSW(inst *x86.Inst) error {
 // liftInstINT lifts the given x86 INT instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstINT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	// DOS function call; e.g. `mov ah, 0x09; int 0x21`.
+	if f.l.Mode == 16 {
+		if fn, ok := x86.DOSFunc(f.precedingInsts(inst), inst); ok {
+			if dos, ok := dosFuncs[fn]; ok {
+				f.liftDOSCall(inst, fn, dos)
+				return nil
+			}
+			warn.Printf("unknown DOS function 0x%02X at %v", fn, inst.Addr)
+		}
+	}
+	// Linux system call; e.g. `mov eax, 4; int 0x80`.
+	if f.l.Mode == 32 && inst.Args[0] == x86asm.Imm(intLinux) {
+		f.liftSyscall(inst, RuntimeInt80, syscallConv32)
+		return nil
+	}
+	trace.Dump("inst:", inst)
 	panic("emitInstINT: not yet implemented")
 }
 
@@ -2742,8 +2840,13 @@ func (f *Func) liftInstINT(inst *x86.Inst) error {
 // liftInstINTO lifts the given x86 INTO instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstINTO(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstINTO: not yet implemented")
+	// INTO - Call to Interrupt Procedure if Overflow
+	//
+	// Raises the overflow exception (#OF) if OF=1; lifted as a conditional
+	// call to @llvm.trap.
+	of := f.useStatus(OF)
+	f.trapIf(inst, of)
+	return nil
 }
 
 // --- [ INVD ] ----------------------------------------------------------------
@@ -2751,7 +2854,7 @@ func (f *Func) liftInstINTO(inst *x86.Inst) error {
 // liftInstINVD lifts the given x86 INVD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstINVD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstINVD: not yet implemented")
 }
 
@@ -2760,7 +2863,7 @@ func (f *Func) liftInstINVD(inst *x86.Inst) error {
 // liftInstINVLPG lifts the given x86 INVLPG instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstINVLPG(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstINVLPG: not yet implemented")
 }
 
@@ -2769,7 +2872,7 @@ func (f *Func) liftInstINVLPG(inst *x86.Inst) error {
 // liftInstINVPCID lifts the given x86 INVPCID instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstINVPCID(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstINVPCID: not yet implemented")
 }
 
@@ -2778,7 +2881,7 @@ func (f *Func) liftInstINVPCID(inst *x86.Inst) error {
 // liftInstIRET lifts the given x86 IRET instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstIRET(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstIRET: not yet implemented")
 }
 
@@ -2787,7 +2890,7 @@ func (f *Func) liftInstIRET(inst *x86.Inst) error {
 // liftInstIRETD lifts the given x86 IRETD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstIRETD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstIRETD: not yet implemented")
 }
 
@@ -2796,7 +2899,7 @@ func (f *Func) liftInstIRETD(inst *x86.Inst) error {
 // liftInstIRETQ lifts the given x86 IRETQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstIRETQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstIRETQ: not yet implemented")
 }
 
@@ -2805,8 +2908,10 @@ func (f *Func) liftInstIRETQ(inst *x86.Inst) error {
 // liftInstLAHF lifts the given x86 LAHF instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLAHF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstLAHF: not yet implemented")
+	// AH = SF:ZF:0:AF:0:PF:1:CF
+	v := f.useFlags(types.I8)
+	f.defReg(x86.NewReg(x86asm.AH, inst), v)
+	return nil
 }
 
 // --- [ LAR ] -----------------------------------------------------------------
@@ -2814,7 +2919,7 @@ func (f *Func) liftInstLAHF(inst *x86.Inst) error {
 // liftInstLAR lifts the given x86 LAR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLAR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLAR: not yet implemented")
 }
 
@@ -2823,7 +2928,11 @@ func (f *Func) liftInstLAR(inst *x86.Inst) error {
 // liftInstLCALL lifts the given x86 LCALL instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLCALL(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	// Direct far call of 16-bit real mode; e.g. `call 0x1234:0x0010`.
+	if near, ok := nearInst(inst); ok {
+		return f.liftInstCALL(near)
+	}
+	trace.Dump("inst:", inst)
 	panic("emitInstLCALL: not yet implemented")
 }
 
@@ -2832,7 +2941,7 @@ func (f *Func) liftInstLCALL(inst *x86.Inst) error {
 // liftInstLDDQU lifts the given x86 LDDQU instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLDDQU(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLDDQU: not yet implemented")
 }
 
@@ -2841,7 +2950,7 @@ func (f *Func) liftInstLDDQU(inst *x86.Inst) error {
 // liftInstLDMXCSR lifts the given x86 LDMXCSR instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstLDMXCSR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLDMXCSR: not yet implemented")
 }
 
@@ -2850,7 +2959,7 @@ func (f *Func) liftInstLDMXCSR(inst *x86.Inst) error {
 // liftInstLDS lifts the given x86 LDS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLDS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLDS: not yet implemented")
 }
 
@@ -2880,11 +2989,12 @@ func (f *Func) liftInstLEAVE(inst *x86.Inst) error {
 	// TODO: Explicitly setting espDisp to -4 should not be needed once espDisp
 	// is stored per basic block and its changes tracked through the CFG. Remove
 	// when handling of espDisp has matured.
-	f.espDisp = -4
+	f.espDisp = -f.l.stackWord()
 
 	//    pop ebp
 	ebp = f.pop()
 	f.defReg(x86.EBP, ebp)
+	f.hasFrame = false
 
 	return nil
 }
@@ -2894,7 +3004,7 @@ func (f *Func) liftInstLEAVE(inst *x86.Inst) error {
 // liftInstLES lifts the given x86 LES instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLES(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLES: not yet implemented")
 }
 
@@ -2903,7 +3013,7 @@ func (f *Func) liftInstLES(inst *x86.Inst) error {
 // liftInstLFENCE lifts the given x86 LFENCE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstLFENCE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLFENCE: not yet implemented")
 }
 
@@ -2912,7 +3022,7 @@ func (f *Func) liftInstLFENCE(inst *x86.Inst) error {
 // liftInstLFS lifts the given x86 LFS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLFS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLFS: not yet implemented")
 }
 
@@ -2921,7 +3031,7 @@ func (f *Func) liftInstLFS(inst *x86.Inst) error {
 // liftInstLGDT lifts the given x86 LGDT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLGDT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLGDT: not yet implemented")
 }
 
@@ -2930,7 +3040,7 @@ func (f *Func) liftInstLGDT(inst *x86.Inst) error {
 // liftInstLGS lifts the given x86 LGS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLGS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLGS: not yet implemented")
 }
 
@@ -2939,7 +3049,7 @@ func (f *Func) liftInstLGS(inst *x86.Inst) error {
 // liftInstLIDT lifts the given x86 LIDT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLIDT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLIDT: not yet implemented")
 }
 
@@ -2948,7 +3058,7 @@ func (f *Func) liftInstLIDT(inst *x86.Inst) error {
 // liftInstLJMP lifts the given x86 LJMP instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLJMP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLJMP: not yet implemented")
 }
 
@@ -2957,7 +3067,7 @@ func (f *Func) liftInstLJMP(inst *x86.Inst) error {
 // liftInstLLDT lifts the given x86 LLDT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLLDT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLLDT: not yet implemented")
 }
 
@@ -2966,7 +3076,7 @@ func (f *Func) liftInstLLDT(inst *x86.Inst) error {
 // liftInstLMSW lifts the given x86 LMSW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLMSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLMSW: not yet implemented")
 }
 
@@ -3015,7 +3125,7 @@ func (f *Func) liftInstLODSW(inst *x86.Inst) error {
 // liftInstLRET lifts the given x86 LRET instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLRET(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLRET: not yet implemented")
 }
 
@@ -3024,7 +3134,7 @@ func (f *Func) liftInstLRET(inst *x86.Inst) error {
 // liftInstLSL lifts the given x86 LSL instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLSL(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLSL: not yet implemented")
 }
 
@@ -3033,7 +3143,7 @@ func (f *Func) liftInstLSL(inst *x86.Inst) error {
 // liftInstLSS lifts the given x86 LSS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLSS: not yet implemented")
 }
 
@@ -3042,7 +3152,7 @@ func (f *Func) liftInstLSS(inst *x86.Inst) error {
 // liftInstLTR lifts the given x86 LTR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLTR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLTR: not yet implemented")
 }
 
@@ -3051,7 +3161,7 @@ func (f *Func) liftInstLTR(inst *x86.Inst) error {
 // liftInstLZCNT lifts the given x86 LZCNT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstLZCNT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstLZCNT: not yet implemented")
 }
 
@@ -3060,7 +3170,7 @@ func (f *Func) liftInstLZCNT(inst *x86.Inst) error {
 // liftInstMASKMOVDQU lifts the given x86 MASKMOVDQU instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstMASKMOVDQU(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMASKMOVDQU: not yet implemented")
 }
 
@@ -3069,7 +3179,7 @@ func (f *Func) liftInstMASKMOVDQU(inst *x86.Inst) error {
 // liftInstMASKMOVQ lifts the given x86 MASKMOVQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstMASKMOVQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMASKMOVQ: not yet implemented")
 }
 
@@ -3078,7 +3188,7 @@ func (f *Func) liftInstMASKMOVQ(inst *x86.Inst) error {
 // liftInstMAXPD lifts the given x86 MAXPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMAXPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMAXPD: not yet implemented")
 }
 
@@ -3087,7 +3197,7 @@ func (f *Func) liftInstMAXPD(inst *x86.Inst) error {
 // liftInstMAXPS lifts the given x86 MAXPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMAXPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMAXPS: not yet implemented")
 }
 
@@ -3096,7 +3206,7 @@ func (f *Func) liftInstMAXPS(inst *x86.Inst) error {
 // liftInstMAXSD lifts the given x86 MAXSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMAXSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMAXSD: not yet implemented")
 }
 
@@ -3105,7 +3215,7 @@ func (f *Func) liftInstMAXSD(inst *x86.Inst) error {
 // liftInstMAXSS lifts the given x86 MAXSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMAXSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMAXSS: not yet implemented")
 }
 
@@ -3114,7 +3224,7 @@ func (f *Func) liftInstMAXSS(inst *x86.Inst) error {
 // liftInstMFENCE lifts the given x86 MFENCE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMFENCE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMFENCE: not yet implemented")
 }
 
@@ -3123,7 +3233,7 @@ func (f *Func) liftInstMFENCE(inst *x86.Inst) error {
 // liftInstMINPD lifts the given x86 MINPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMINPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMINPD: not yet implemented")
 }
 
@@ -3132,7 +3242,7 @@ func (f *Func) liftInstMINPD(inst *x86.Inst) error {
 // liftInstMINPS lifts the given x86 MINPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMINPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMINPS: not yet implemented")
 }
 
@@ -3141,7 +3251,7 @@ func (f *Func) liftInstMINPS(inst *x86.Inst) error {
 // liftInstMINSD lifts the given x86 MINSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMINSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMINSD: not yet implemented")
 }
 
@@ -3150,7 +3260,7 @@ func (f *Func) liftInstMINSD(inst *x86.Inst) error {
 // liftInstMINSS lifts the given x86 MINSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMINSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMINSS: not yet implemented")
 }
 
@@ -3159,7 +3269,7 @@ func (f *Func) liftInstMINSS(inst *x86.Inst) error {
 // liftInstMONITOR lifts the given x86 MONITOR instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMONITOR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMONITOR: not yet implemented")
 }
 
@@ -3170,6 +3280,14 @@ func (f *Func) liftInstMONITOR(inst *x86.Inst) error {
 func (f *Func) liftInstMOV(inst *x86.Inst) error {
 	src := f.useArg(inst.Arg(1))
 	f.defArg(inst.Arg(0), src)
+	// Track frame pointer; e.g. `mov ebp, esp`.
+	switch {
+	case inst.Args[0] == x86asm.EBP && inst.Args[1] == x86asm.ESP, inst.Args[0] == x86asm.RBP && inst.Args[1] == x86asm.RSP, inst.Args[0] == x86asm.BP && inst.Args[1] == x86asm.SP:
+		f.ebpDisp = f.espDisp
+		f.hasFrame = true
+	case inst.Args[0] == x86asm.EBP, inst.Args[0] == x86asm.RBP, inst.Args[0] == x86asm.BP:
+		f.hasFrame = false
+	}
 	return nil
 }
 
@@ -3178,7 +3296,7 @@ func (f *Func) liftInstMOV(inst *x86.Inst) error {
 // liftInstMOVAPD lifts the given x86 MOVAPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVAPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVAPD: not yet implemented")
 }
 
@@ -3187,7 +3305,7 @@ func (f *Func) liftInstMOVAPD(inst *x86.Inst) error {
 // liftInstMOVAPS lifts the given x86 MOVAPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVAPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVAPS: not yet implemented")
 }
 
@@ -3196,7 +3314,7 @@ func (f *Func) liftInstMOVAPS(inst *x86.Inst) error {
 // liftInstMOVBE lifts the given x86 MOVBE instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMOVBE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVBE: not yet implemented")
 }
 
@@ -3205,7 +3323,7 @@ func (f *Func) liftInstMOVBE(inst *x86.Inst) error {
 // liftInstMOVD lifts the given x86 MOVD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMOVD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVD: not yet implemented")
 }
 
@@ -3214,7 +3332,7 @@ func (f *Func) liftInstMOVD(inst *x86.Inst) error {
 // liftInstMOVDDUP lifts the given x86 MOVDDUP instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVDDUP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVDDUP: not yet implemented")
 }
 
@@ -3223,7 +3341,7 @@ func (f *Func) liftInstMOVDDUP(inst *x86.Inst) error {
 // liftInstMOVDQ2Q lifts the given x86 MOVDQ2Q instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVDQ2Q(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVDQ2Q: not yet implemented")
 }
 
@@ -3232,7 +3350,7 @@ func (f *Func) liftInstMOVDQ2Q(inst *x86.Inst) error {
 // liftInstMOVDQA lifts the given x86 MOVDQA instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVDQA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVDQA: not yet implemented")
 }
 
@@ -3241,7 +3359,7 @@ func (f *Func) liftInstMOVDQA(inst *x86.Inst) error {
 // liftInstMOVDQU lifts the given x86 MOVDQU instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVDQU(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVDQU: not yet implemented")
 }
 
@@ -3250,7 +3368,7 @@ func (f *Func) liftInstMOVDQU(inst *x86.Inst) error {
 // liftInstMOVHLPS lifts the given x86 MOVHLPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVHLPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVHLPS: not yet implemented")
 }
 
@@ -3259,7 +3377,7 @@ func (f *Func) liftInstMOVHLPS(inst *x86.Inst) error {
 // liftInstMOVHPD lifts the given x86 MOVHPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVHPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVHPD: not yet implemented")
 }
 
@@ -3268,7 +3386,7 @@ func (f *Func) liftInstMOVHPD(inst *x86.Inst) error {
 // liftInstMOVHPS lifts the given x86 MOVHPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVHPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVHPS: not yet implemented")
 }
 
@@ -3277,7 +3395,7 @@ func (f *Func) liftInstMOVHPS(inst *x86.Inst) error {
 // liftInstMOVLHPS lifts the given x86 MOVLHPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVLHPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVLHPS: not yet implemented")
 }
 
@@ -3286,7 +3404,7 @@ func (f *Func) liftInstMOVLHPS(inst *x86.Inst) error {
 // liftInstMOVLPD lifts the given x86 MOVLPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVLPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVLPD: not yet implemented")
 }
 
@@ -3295,7 +3413,7 @@ func (f *Func) liftInstMOVLPD(inst *x86.Inst) error {
 // liftInstMOVLPS lifts the given x86 MOVLPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVLPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVLPS: not yet implemented")
 }
 
@@ -3304,7 +3422,7 @@ func (f *Func) liftInstMOVLPS(inst *x86.Inst) error {
 // liftInstMOVMSKPD lifts the given x86 MOVMSKPD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstMOVMSKPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVMSKPD: not yet implemented")
 }
 
@@ -3313,7 +3431,7 @@ func (f *Func) liftInstMOVMSKPD(inst *x86.Inst) error {
 // liftInstMOVMSKPS lifts the given x86 MOVMSKPS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstMOVMSKPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVMSKPS: not yet implemented")
 }
 
@@ -3322,7 +3440,7 @@ func (f *Func) liftInstMOVMSKPS(inst *x86.Inst) error {
 // liftInstMOVNTDQ lifts the given x86 MOVNTDQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVNTDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVNTDQ: not yet implemented")
 }
 
@@ -3331,7 +3449,7 @@ func (f *Func) liftInstMOVNTDQ(inst *x86.Inst) error {
 // liftInstMOVNTDQA lifts the given x86 MOVNTDQA instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstMOVNTDQA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVNTDQA: not yet implemented")
 }
 
@@ -3340,7 +3458,7 @@ func (f *Func) liftInstMOVNTDQA(inst *x86.Inst) error {
 // liftInstMOVNTI lifts the given x86 MOVNTI instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVNTI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVNTI: not yet implemented")
 }
 
@@ -3349,7 +3467,7 @@ func (f *Func) liftInstMOVNTI(inst *x86.Inst) error {
 // liftInstMOVNTPD lifts the given x86 MOVNTPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVNTPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVNTPD: not yet implemented")
 }
 
@@ -3358,7 +3476,7 @@ func (f *Func) liftInstMOVNTPD(inst *x86.Inst) error {
 // liftInstMOVNTPS lifts the given x86 MOVNTPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVNTPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVNTPS: not yet implemented")
 }
 
@@ -3367,7 +3485,7 @@ func (f *Func) liftInstMOVNTPS(inst *x86.Inst) error {
 // liftInstMOVNTQ lifts the given x86 MOVNTQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVNTQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVNTQ: not yet implemented")
 }
 
@@ -3376,7 +3494,7 @@ func (f *Func) liftInstMOVNTQ(inst *x86.Inst) error {
 // liftInstMOVNTSD lifts the given x86 MOVNTSD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVNTSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVNTSD: not yet implemented")
 }
 
@@ -3385,7 +3503,7 @@ func (f *Func) liftInstMOVNTSD(inst *x86.Inst) error {
 // liftInstMOVNTSS lifts the given x86 MOVNTSS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVNTSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVNTSS: not yet implemented")
 }
 
@@ -3394,7 +3512,7 @@ func (f *Func) liftInstMOVNTSS(inst *x86.Inst) error {
 // liftInstMOVQ lifts the given x86 MOVQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMOVQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVQ: not yet implemented")
 }
 
@@ -3403,7 +3521,7 @@ func (f *Func) liftInstMOVQ(inst *x86.Inst) error {
 // liftInstMOVQ2DQ lifts the given x86 MOVQ2DQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVQ2DQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVQ2DQ: not yet implemented")
 }
 
@@ -3432,7 +3550,7 @@ func (f *Func) liftInstMOVSD(inst *x86.Inst) error {
 // liftInstMOVSD_XMM lifts the given x86 MOVSD_XMM instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstMOVSD_XMM(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVSD_XMM: not yet implemented")
 }
 
@@ -3441,7 +3559,7 @@ func (f *Func) liftInstMOVSD_XMM(inst *x86.Inst) error {
 // liftInstMOVSHDUP lifts the given x86 MOVSHDUP instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstMOVSHDUP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVSHDUP: not yet implemented")
 }
 
@@ -3450,7 +3568,7 @@ func (f *Func) liftInstMOVSHDUP(inst *x86.Inst) error {
 // liftInstMOVSLDUP lifts the given x86 MOVSLDUP instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstMOVSLDUP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVSLDUP: not yet implemented")
 }
 
@@ -3459,7 +3577,7 @@ func (f *Func) liftInstMOVSLDUP(inst *x86.Inst) error {
 // liftInstMOVSQ lifts the given x86 MOVSQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMOVSQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVSQ: not yet implemented")
 }
 
@@ -3468,7 +3586,7 @@ func (f *Func) liftInstMOVSQ(inst *x86.Inst) error {
 // liftInstMOVSS lifts the given x86 MOVSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMOVSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVSS: not yet implemented")
 }
 
@@ -3501,7 +3619,7 @@ func (f *Func) liftInstMOVSX(inst *x86.Inst) error {
 // liftInstMOVSXD lifts the given x86 MOVSXD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVSXD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVSXD: not yet implemented")
 }
 
@@ -3510,7 +3628,7 @@ func (f *Func) liftInstMOVSXD(inst *x86.Inst) error {
 // liftInstMOVUPD lifts the given x86 MOVUPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVUPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVUPD: not yet implemented")
 }
 
@@ -3519,7 +3637,7 @@ func (f *Func) liftInstMOVUPD(inst *x86.Inst) error {
 // liftInstMOVUPS lifts the given x86 MOVUPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMOVUPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMOVUPS: not yet implemented")
 }
 
@@ -3542,7 +3660,7 @@ func (f *Func) liftInstMOVZX(inst *x86.Inst) error {
 // liftInstMPSADBW lifts the given x86 MPSADBW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstMPSADBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMPSADBW: not yet implemented")
 }
 
@@ -3565,26 +3683,24 @@ func (f *Func) liftInstMUL(inst *x86.Inst) error {
 	// One-operand form.
 	y := f.useArg(inst.Arg(0))
 	size := f.l.sizeOfType(y.Type())
-	var x value.Value
-	var dst value.Value
+	var src, dst *x86.Reg
 	switch size {
 	case 1:
-		x = f.useReg(x86.AL)
-		dst = f.reg(x86asm.AX)
+		src, dst = x86.AL, x86.AX
 	case 2:
-		x = f.useReg(x86.AX)
-		dst = f.reg(x86.X86asm_DX_AX)
-	case 3:
-		x = f.useReg(x86.EAX)
-		dst = f.reg(x86.X86asm_EDX_EAX)
+		src, dst = x86.AX, x86.DX_AX
 	case 4:
-		x = f.useReg(x86.RAX)
-		dst = f.reg(x86.X86asm_RDX_RAX)
+		src, dst = x86.EAX, x86.EDX_EAX
+	case 8:
+		src, dst = x86.RAX, x86.RDX_RAX
 	default:
 		panic(fmt.Errorf("support for operand type of byte size %d not yet implemented", size))
 	}
+	typ := regType(dst.Reg)
+	x := f.cur.NewZExt(f.useReg(src), typ)
+	y = f.cur.NewZExt(y, typ)
 	result := f.cur.NewMul(x, y)
-	f.cur.NewStore(result, dst)
+	f.defReg(dst, result)
 	return nil
 }
 
@@ -3593,7 +3709,7 @@ func (f *Func) liftInstMUL(inst *x86.Inst) error {
 // liftInstMULPD lifts the given x86 MULPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMULPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMULPD: not yet implemented")
 }
 
@@ -3602,7 +3718,7 @@ func (f *Func) liftInstMULPD(inst *x86.Inst) error {
 // liftInstMULPS lifts the given x86 MULPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMULPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMULPS: not yet implemented")
 }
 
@@ -3611,7 +3727,7 @@ func (f *Func) liftInstMULPS(inst *x86.Inst) error {
 // liftInstMULSD lifts the given x86 MULSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMULSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMULSD: not yet implemented")
 }
 
@@ -3620,7 +3736,7 @@ func (f *Func) liftInstMULSD(inst *x86.Inst) error {
 // liftInstMULSS lifts the given x86 MULSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMULSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMULSS: not yet implemented")
 }
 
@@ -3629,7 +3745,7 @@ func (f *Func) liftInstMULSS(inst *x86.Inst) error {
 // liftInstMWAIT lifts the given x86 MWAIT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMWAIT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstMWAIT: not yet implemented")
 }
 
@@ -3696,7 +3812,7 @@ func (f *Func) liftInstOR(inst *x86.Inst) error {
 // liftInstORPD lifts the given x86 ORPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstORPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstORPD: not yet implemented")
 }
 
@@ -3705,7 +3821,7 @@ func (f *Func) liftInstORPD(inst *x86.Inst) error {
 // liftInstORPS lifts the given x86 ORPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstORPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstORPS: not yet implemented")
 }
 
@@ -3714,7 +3830,7 @@ func (f *Func) liftInstORPS(inst *x86.Inst) error {
 // liftInstOUT lifts the given x86 OUT instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstOUT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstOUT: not yet implemented")
 }
 
@@ -3723,7 +3839,7 @@ func (f *Func) liftInstOUT(inst *x86.Inst) error {
 // liftInstOUTSB lifts the given x86 OUTSB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstOUTSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstOUTSB: not yet implemented")
 }
 
@@ -3732,7 +3848,7 @@ func (f *Func) liftInstOUTSB(inst *x86.Inst) error {
 // liftInstOUTSD lifts the given x86 OUTSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstOUTSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstOUTSD: not yet implemented")
 }
 
@@ -3741,7 +3857,7 @@ func (f *Func) liftInstOUTSD(inst *x86.Inst) error {
 // liftInstOUTSW lifts the given x86 OUTSW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstOUTSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstOUTSW: not yet implemented")
 }
 
@@ -3750,7 +3866,7 @@ func (f *Func) liftInstOUTSW(inst *x86.Inst) error {
 // liftInstPABSB lifts the given x86 PABSB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPABSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPABSB: not yet implemented")
 }
 
@@ -3759,7 +3875,7 @@ func (f *Func) liftInstPABSB(inst *x86.Inst) error {
 // liftInstPABSD lifts the given x86 PABSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPABSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPABSD: not yet implemented")
 }
 
@@ -3768,7 +3884,7 @@ func (f *Func) liftInstPABSD(inst *x86.Inst) error {
 // liftInstPABSW lifts the given x86 PABSW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPABSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPABSW: not yet implemented")
 }
 
@@ -3777,7 +3893,7 @@ func (f *Func) liftInstPABSW(inst *x86.Inst) error {
 // liftInstPACKSSDW lifts the given x86 PACKSSDW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPACKSSDW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPACKSSDW: not yet implemented")
 }
 
@@ -3786,7 +3902,7 @@ func (f *Func) liftInstPACKSSDW(inst *x86.Inst) error {
 // liftInstPACKSSWB lifts the given x86 PACKSSWB instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPACKSSWB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPACKSSWB: not yet implemented")
 }
 
@@ -3795,7 +3911,7 @@ func (f *Func) liftInstPACKSSWB(inst *x86.Inst) error {
 // liftInstPACKUSDW lifts the given x86 PACKUSDW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPACKUSDW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPACKUSDW: not yet implemented")
 }
 
@@ -3804,7 +3920,7 @@ func (f *Func) liftInstPACKUSDW(inst *x86.Inst) error {
 // liftInstPACKUSWB lifts the given x86 PACKUSWB instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPACKUSWB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPACKUSWB: not yet implemented")
 }
 
@@ -3813,7 +3929,7 @@ func (f *Func) liftInstPACKUSWB(inst *x86.Inst) error {
 // liftInstPADDB lifts the given x86 PADDB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPADDB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPADDB: not yet implemented")
 }
 
@@ -3822,7 +3938,7 @@ func (f *Func) liftInstPADDB(inst *x86.Inst) error {
 // liftInstPADDD lifts the given x86 PADDD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPADDD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPADDD: not yet implemented")
 }
 
@@ -3831,7 +3947,7 @@ func (f *Func) liftInstPADDD(inst *x86.Inst) error {
 // liftInstPADDQ lifts the given x86 PADDQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPADDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPADDQ: not yet implemented")
 }
 
@@ -3840,7 +3956,7 @@ func (f *Func) liftInstPADDQ(inst *x86.Inst) error {
 // liftInstPADDSB lifts the given x86 PADDSB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPADDSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPADDSB: not yet implemented")
 }
 
@@ -3849,7 +3965,7 @@ func (f *Func) liftInstPADDSB(inst *x86.Inst) error {
 // liftInstPADDSW lifts the given x86 PADDSW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPADDSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPADDSW: not yet implemented")
 }
 
@@ -3858,7 +3974,7 @@ func (f *Func) liftInstPADDSW(inst *x86.Inst) error {
 // liftInstPADDUSB lifts the given x86 PADDUSB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPADDUSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPADDUSB: not yet implemented")
 }
 
@@ -3867,7 +3983,7 @@ func (f *Func) liftInstPADDUSB(inst *x86.Inst) error {
 // liftInstPADDUSW lifts the given x86 PADDUSW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPADDUSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPADDUSW: not yet implemented")
 }
 
@@ -3876,7 +3992,7 @@ func (f *Func) liftInstPADDUSW(inst *x86.Inst) error {
 // liftInstPADDW lifts the given x86 PADDW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPADDW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPADDW: not yet implemented")
 }
 
@@ -3885,7 +4001,7 @@ func (f *Func) liftInstPADDW(inst *x86.Inst) error {
 // liftInstPALIGNR lifts the given x86 PALIGNR instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPALIGNR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPALIGNR: not yet implemented")
 }
 
@@ -3894,7 +4010,7 @@ func (f *Func) liftInstPALIGNR(inst *x86.Inst) error {
 // liftInstPAND lifts the given x86 PAND instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPAND(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPAND: not yet implemented")
 }
 
@@ -3903,7 +4019,7 @@ func (f *Func) liftInstPAND(inst *x86.Inst) error {
 // liftInstPANDN lifts the given x86 PANDN instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPANDN(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPANDN: not yet implemented")
 }
 
@@ -3912,7 +4028,7 @@ func (f *Func) liftInstPANDN(inst *x86.Inst) error {
 // liftInstPAUSE lifts the given x86 PAUSE instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPAUSE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPAUSE: not yet implemented")
 }
 
@@ -3921,7 +4037,7 @@ func (f *Func) liftInstPAUSE(inst *x86.Inst) error {
 // liftInstPAVGB lifts the given x86 PAVGB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPAVGB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPAVGB: not yet implemented")
 }
 
@@ -3930,7 +4046,7 @@ func (f *Func) liftInstPAVGB(inst *x86.Inst) error {
 // liftInstPAVGW lifts the given x86 PAVGW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPAVGW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPAVGW: not yet implemented")
 }
 
@@ -3939,7 +4055,7 @@ func (f *Func) liftInstPAVGW(inst *x86.Inst) error {
 // liftInstPBLENDVB lifts the given x86 PBLENDVB instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPBLENDVB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPBLENDVB: not yet implemented")
 }
 
@@ -3948,7 +4064,7 @@ func (f *Func) liftInstPBLENDVB(inst *x86.Inst) error {
 // liftInstPBLENDW lifts the given x86 PBLENDW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPBLENDW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPBLENDW: not yet implemented")
 }
 
@@ -3957,7 +4073,7 @@ func (f *Func) liftInstPBLENDW(inst *x86.Inst) error {
 // liftInstPCLMULQDQ lifts the given x86 PCLMULQDQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPCLMULQDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCLMULQDQ: not yet implemented")
 }
 
@@ -3966,7 +4082,7 @@ func (f *Func) liftInstPCLMULQDQ(inst *x86.Inst) error {
 // liftInstPCMPEQB lifts the given x86 PCMPEQB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPCMPEQB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPEQB: not yet implemented")
 }
 
@@ -3975,7 +4091,7 @@ func (f *Func) liftInstPCMPEQB(inst *x86.Inst) error {
 // liftInstPCMPEQD lifts the given x86 PCMPEQD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPCMPEQD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPEQD: not yet implemented")
 }
 
@@ -3984,7 +4100,7 @@ func (f *Func) liftInstPCMPEQD(inst *x86.Inst) error {
 // liftInstPCMPEQQ lifts the given x86 PCMPEQQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPCMPEQQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPEQQ: not yet implemented")
 }
 
@@ -3993,7 +4109,7 @@ func (f *Func) liftInstPCMPEQQ(inst *x86.Inst) error {
 // liftInstPCMPEQW lifts the given x86 PCMPEQW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPCMPEQW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPEQW: not yet implemented")
 }
 
@@ -4002,7 +4118,7 @@ func (f *Func) liftInstPCMPEQW(inst *x86.Inst) error {
 // liftInstPCMPESTRI lifts the given x86 PCMPESTRI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPCMPESTRI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPESTRI: not yet implemented")
 }
 
@@ -4011,7 +4127,7 @@ func (f *Func) liftInstPCMPESTRI(inst *x86.Inst) error {
 // liftInstPCMPESTRM lifts the given x86 PCMPESTRM instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPCMPESTRM(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPESTRM: not yet implemented")
 }
 
@@ -4020,7 +4136,7 @@ func (f *Func) liftInstPCMPESTRM(inst *x86.Inst) error {
 // liftInstPCMPGTB lifts the given x86 PCMPGTB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPCMPGTB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPGTB: not yet implemented")
 }
 
@@ -4029,7 +4145,7 @@ func (f *Func) liftInstPCMPGTB(inst *x86.Inst) error {
 // liftInstPCMPGTD lifts the given x86 PCMPGTD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPCMPGTD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPGTD: not yet implemented")
 }
 
@@ -4038,7 +4154,7 @@ func (f *Func) liftInstPCMPGTD(inst *x86.Inst) error {
 // liftInstPCMPGTQ lifts the given x86 PCMPGTQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPCMPGTQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPGTQ: not yet implemented")
 }
 
@@ -4047,7 +4163,7 @@ func (f *Func) liftInstPCMPGTQ(inst *x86.Inst) error {
 // liftInstPCMPGTW lifts the given x86 PCMPGTW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPCMPGTW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPGTW: not yet implemented")
 }
 
@@ -4056,7 +4172,7 @@ func (f *Func) liftInstPCMPGTW(inst *x86.Inst) error {
 // liftInstPCMPISTRI lifts the given x86 PCMPISTRI instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPCMPISTRI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPISTRI: not yet implemented")
 }
 
@@ -4065,7 +4181,7 @@ func (f *Func) liftInstPCMPISTRI(inst *x86.Inst) error {
 // liftInstPCMPISTRM lifts the given x86 PCMPISTRM instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPCMPISTRM(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPCMPISTRM: not yet implemented")
 }
 
@@ -4074,7 +4190,7 @@ func (f *Func) liftInstPCMPISTRM(inst *x86.Inst) error {
 // liftInstPEXTRB lifts the given x86 PEXTRB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPEXTRB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPEXTRB: not yet implemented")
 }
 
@@ -4083,7 +4199,7 @@ func (f *Func) liftInstPEXTRB(inst *x86.Inst) error {
 // liftInstPEXTRD lifts the given x86 PEXTRD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPEXTRD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPEXTRD: not yet implemented")
 }
 
@@ -4092,7 +4208,7 @@ func (f *Func) liftInstPEXTRD(inst *x86.Inst) error {
 // liftInstPEXTRQ lifts the given x86 PEXTRQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPEXTRQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPEXTRQ: not yet implemented")
 }
 
@@ -4101,7 +4217,7 @@ func (f *Func) liftInstPEXTRQ(inst *x86.Inst) error {
 // liftInstPEXTRW lifts the given x86 PEXTRW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPEXTRW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPEXTRW: not yet implemented")
 }
 
@@ -4110,7 +4226,7 @@ func (f *Func) liftInstPEXTRW(inst *x86.Inst) error {
 // liftInstPHADDD lifts the given x86 PHADDD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPHADDD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPHADDD: not yet implemented")
 }
 
@@ -4119,7 +4235,7 @@ func (f *Func) liftInstPHADDD(inst *x86.Inst) error {
 // liftInstPHADDSW lifts the given x86 PHADDSW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPHADDSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPHADDSW: not yet implemented")
 }
 
@@ -4128,7 +4244,7 @@ func (f *Func) liftInstPHADDSW(inst *x86.Inst) error {
 // liftInstPHADDW lifts the given x86 PHADDW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPHADDW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPHADDW: not yet implemented")
 }
 
@@ -4137,7 +4253,7 @@ func (f *Func) liftInstPHADDW(inst *x86.Inst) error {
 // liftInstPHMINPOSUW lifts the given x86 PHMINPOSUW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPHMINPOSUW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPHMINPOSUW: not yet implemented")
 }
 
@@ -4146,7 +4262,7 @@ func (f *Func) liftInstPHMINPOSUW(inst *x86.Inst) error {
 // liftInstPHSUBD lifts the given x86 PHSUBD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPHSUBD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPHSUBD: not yet implemented")
 }
 
@@ -4155,7 +4271,7 @@ func (f *Func) liftInstPHSUBD(inst *x86.Inst) error {
 // liftInstPHSUBSW lifts the given x86 PHSUBSW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPHSUBSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPHSUBSW: not yet implemented")
 }
 
@@ -4164,7 +4280,7 @@ func (f *Func) liftInstPHSUBSW(inst *x86.Inst) error {
 // liftInstPHSUBW lifts the given x86 PHSUBW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPHSUBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPHSUBW: not yet implemented")
 }
 
@@ -4173,7 +4289,7 @@ func (f *Func) liftInstPHSUBW(inst *x86.Inst) error {
 // liftInstPINSRB lifts the given x86 PINSRB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPINSRB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPINSRB: not yet implemented")
 }
 
@@ -4182,7 +4298,7 @@ func (f *Func) liftInstPINSRB(inst *x86.Inst) error {
 // liftInstPINSRD lifts the given x86 PINSRD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPINSRD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPINSRD: not yet implemented")
 }
 
@@ -4191,7 +4307,7 @@ func (f *Func) liftInstPINSRD(inst *x86.Inst) error {
 // liftInstPINSRQ lifts the given x86 PINSRQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPINSRQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPINSRQ: not yet implemented")
 }
 
@@ -4200,7 +4316,7 @@ func (f *Func) liftInstPINSRQ(inst *x86.Inst) error {
 // liftInstPINSRW lifts the given x86 PINSRW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPINSRW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPINSRW: not yet implemented")
 }
 
@@ -4209,7 +4325,7 @@ func (f *Func) liftInstPINSRW(inst *x86.Inst) error {
 // liftInstPMADDUBSW lifts the given x86 PMADDUBSW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMADDUBSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMADDUBSW: not yet implemented")
 }
 
@@ -4218,7 +4334,7 @@ func (f *Func) liftInstPMADDUBSW(inst *x86.Inst) error {
 // liftInstPMADDWD lifts the given x86 PMADDWD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMADDWD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMADDWD: not yet implemented")
 }
 
@@ -4227,7 +4343,7 @@ func (f *Func) liftInstPMADDWD(inst *x86.Inst) error {
 // liftInstPMAXSB lifts the given x86 PMAXSB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMAXSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMAXSB: not yet implemented")
 }
 
@@ -4236,7 +4352,7 @@ func (f *Func) liftInstPMAXSB(inst *x86.Inst) error {
 // liftInstPMAXSD lifts the given x86 PMAXSD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMAXSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMAXSD: not yet implemented")
 }
 
@@ -4245,7 +4361,7 @@ func (f *Func) liftInstPMAXSD(inst *x86.Inst) error {
 // liftInstPMAXSW lifts the given x86 PMAXSW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMAXSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMAXSW: not yet implemented")
 }
 
@@ -4254,7 +4370,7 @@ func (f *Func) liftInstPMAXSW(inst *x86.Inst) error {
 // liftInstPMAXUB lifts the given x86 PMAXUB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMAXUB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMAXUB: not yet implemented")
 }
 
@@ -4263,7 +4379,7 @@ func (f *Func) liftInstPMAXUB(inst *x86.Inst) error {
 // liftInstPMAXUD lifts the given x86 PMAXUD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMAXUD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMAXUD: not yet implemented")
 }
 
@@ -4272,7 +4388,7 @@ func (f *Func) liftInstPMAXUD(inst *x86.Inst) error {
 // liftInstPMAXUW lifts the given x86 PMAXUW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMAXUW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMAXUW: not yet implemented")
 }
 
@@ -4281,7 +4397,7 @@ func (f *Func) liftInstPMAXUW(inst *x86.Inst) error {
 // liftInstPMINSB lifts the given x86 PMINSB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMINSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMINSB: not yet implemented")
 }
 
@@ -4290,7 +4406,7 @@ func (f *Func) liftInstPMINSB(inst *x86.Inst) error {
 // liftInstPMINSD lifts the given x86 PMINSD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMINSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMINSD: not yet implemented")
 }
 
@@ -4299,7 +4415,7 @@ func (f *Func) liftInstPMINSD(inst *x86.Inst) error {
 // liftInstPMINSW lifts the given x86 PMINSW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMINSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMINSW: not yet implemented")
 }
 
@@ -4308,7 +4424,7 @@ func (f *Func) liftInstPMINSW(inst *x86.Inst) error {
 // liftInstPMINUB lifts the given x86 PMINUB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMINUB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMINUB: not yet implemented")
 }
 
@@ -4317,7 +4433,7 @@ func (f *Func) liftInstPMINUB(inst *x86.Inst) error {
 // liftInstPMINUD lifts the given x86 PMINUD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMINUD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMINUD: not yet implemented")
 }
 
@@ -4326,7 +4442,7 @@ func (f *Func) liftInstPMINUD(inst *x86.Inst) error {
 // liftInstPMINUW lifts the given x86 PMINUW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMINUW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMINUW: not yet implemented")
 }
 
@@ -4335,7 +4451,7 @@ func (f *Func) liftInstPMINUW(inst *x86.Inst) error {
 // liftInstPMOVMSKB lifts the given x86 PMOVMSKB instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVMSKB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVMSKB: not yet implemented")
 }
 
@@ -4344,7 +4460,7 @@ func (f *Func) liftInstPMOVMSKB(inst *x86.Inst) error {
 // liftInstPMOVSXBD lifts the given x86 PMOVSXBD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVSXBD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVSXBD: not yet implemented")
 }
 
@@ -4353,7 +4469,7 @@ func (f *Func) liftInstPMOVSXBD(inst *x86.Inst) error {
 // liftInstPMOVSXBQ lifts the given x86 PMOVSXBQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVSXBQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVSXBQ: not yet implemented")
 }
 
@@ -4362,7 +4478,7 @@ func (f *Func) liftInstPMOVSXBQ(inst *x86.Inst) error {
 // liftInstPMOVSXBW lifts the given x86 PMOVSXBW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVSXBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVSXBW: not yet implemented")
 }
 
@@ -4371,7 +4487,7 @@ func (f *Func) liftInstPMOVSXBW(inst *x86.Inst) error {
 // liftInstPMOVSXDQ lifts the given x86 PMOVSXDQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVSXDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVSXDQ: not yet implemented")
 }
 
@@ -4380,7 +4496,7 @@ func (f *Func) liftInstPMOVSXDQ(inst *x86.Inst) error {
 // liftInstPMOVSXWD lifts the given x86 PMOVSXWD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVSXWD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVSXWD: not yet implemented")
 }
 
@@ -4389,7 +4505,7 @@ func (f *Func) liftInstPMOVSXWD(inst *x86.Inst) error {
 // liftInstPMOVSXWQ lifts the given x86 PMOVSXWQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVSXWQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVSXWQ: not yet implemented")
 }
 
@@ -4398,7 +4514,7 @@ func (f *Func) liftInstPMOVSXWQ(inst *x86.Inst) error {
 // liftInstPMOVZXBD lifts the given x86 PMOVZXBD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVZXBD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVZXBD: not yet implemented")
 }
 
@@ -4407,7 +4523,7 @@ func (f *Func) liftInstPMOVZXBD(inst *x86.Inst) error {
 // liftInstPMOVZXBQ lifts the given x86 PMOVZXBQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVZXBQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVZXBQ: not yet implemented")
 }
 
@@ -4416,7 +4532,7 @@ func (f *Func) liftInstPMOVZXBQ(inst *x86.Inst) error {
 // liftInstPMOVZXBW lifts the given x86 PMOVZXBW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVZXBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVZXBW: not yet implemented")
 }
 
@@ -4425,7 +4541,7 @@ func (f *Func) liftInstPMOVZXBW(inst *x86.Inst) error {
 // liftInstPMOVZXDQ lifts the given x86 PMOVZXDQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVZXDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVZXDQ: not yet implemented")
 }
 
@@ -4434,7 +4550,7 @@ func (f *Func) liftInstPMOVZXDQ(inst *x86.Inst) error {
 // liftInstPMOVZXWD lifts the given x86 PMOVZXWD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVZXWD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVZXWD: not yet implemented")
 }
 
@@ -4443,7 +4559,7 @@ func (f *Func) liftInstPMOVZXWD(inst *x86.Inst) error {
 // liftInstPMOVZXWQ lifts the given x86 PMOVZXWQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMOVZXWQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMOVZXWQ: not yet implemented")
 }
 
@@ -4452,7 +4568,7 @@ func (f *Func) liftInstPMOVZXWQ(inst *x86.Inst) error {
 // liftInstPMULDQ lifts the given x86 PMULDQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMULDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMULDQ: not yet implemented")
 }
 
@@ -4461,7 +4577,7 @@ func (f *Func) liftInstPMULDQ(inst *x86.Inst) error {
 // liftInstPMULHRSW lifts the given x86 PMULHRSW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPMULHRSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMULHRSW: not yet implemented")
 }
 
@@ -4470,7 +4586,7 @@ func (f *Func) liftInstPMULHRSW(inst *x86.Inst) error {
 // liftInstPMULHUW lifts the given x86 PMULHUW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMULHUW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMULHUW: not yet implemented")
 }
 
@@ -4479,7 +4595,7 @@ func (f *Func) liftInstPMULHUW(inst *x86.Inst) error {
 // liftInstPMULHW lifts the given x86 PMULHW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMULHW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMULHW: not yet implemented")
 }
 
@@ -4488,7 +4604,7 @@ func (f *Func) liftInstPMULHW(inst *x86.Inst) error {
 // liftInstPMULLD lifts the given x86 PMULLD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMULLD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMULLD: not yet implemented")
 }
 
@@ -4497,7 +4613,7 @@ func (f *Func) liftInstPMULLD(inst *x86.Inst) error {
 // liftInstPMULLW lifts the given x86 PMULLW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMULLW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMULLW: not yet implemented")
 }
 
@@ -4506,7 +4622,7 @@ func (f *Func) liftInstPMULLW(inst *x86.Inst) error {
 // liftInstPMULUDQ lifts the given x86 PMULUDQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPMULUDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPMULUDQ: not yet implemented")
 }
 
@@ -4517,6 +4633,10 @@ func (f *Func) liftInstPMULUDQ(inst *x86.Inst) error {
 func (f *Func) liftInstPOP(inst *x86.Inst) error {
 	v := f.pop()
 	f.defArg(inst.Arg(0), v)
+	switch inst.Args[0] {
+	case x86asm.EBP, x86asm.RBP, x86asm.BP:
+		f.hasFrame = false
+	}
 	return nil
 }
 
@@ -4528,16 +4648,36 @@ func (f *Func) pop() value.Named {
 	}
 	mem := x86.NewMem(m, nil)
 	v := f.useMem(mem)
-	f.espDisp += 4
+	f.espDisp += f.l.stackWord()
 	return v
 }
 
+// popArg pops a function argument of the given type from the stack, emitting
+// code to f. The number of bytes popped is returned.
+func (f *Func) popArg(typ types.Type) (value.Value, int64) {
+	word := f.l.stackWord()
+	if f.l.Mode != 64 && f.l.sizeOfType(typ) == 2*word {
+		// Arguments of twice the stack word size occupy two stack slots; low
+		// half first.
+		wide := types.I64
+		if word == 2 {
+			wide = types.I32
+		}
+		lo := f.cur.NewZExt(f.pop(), wide)
+		hi := f.cur.NewZExt(f.pop(), wide)
+		tmp := f.cur.NewShl(hi, constant.NewInt(8*word, wide))
+		v := f.cur.NewOr(tmp, lo)
+		return f.convert(v, typ), 2 * word
+	}
+	return f.convert(f.pop(), typ), word
+}
+
 // --- [ POPA ] ----------------------------------------------------------------
 
 // liftInstPOPA lifts the given x86 POPA instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPOPA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPOPA: not yet implemented")
 }
 
@@ -4546,7 +4686,7 @@ func (f *Func) liftInstPOPA(inst *x86.Inst) error {
 // liftInstPOPAD lifts the given x86 POPAD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPOPAD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPOPAD: not yet implemented")
 }
 
@@ -4555,7 +4695,7 @@ func (f *Func) liftInstPOPAD(inst *x86.Inst) error {
 // liftInstPOPCNT lifts the given x86 POPCNT instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPOPCNT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPOPCNT: not yet implemented")
 }
 
@@ -4564,8 +4704,14 @@ func (f *Func) liftInstPOPCNT(inst *x86.Inst) error {
 // liftInstPOPF lifts the given x86 POPF instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPOPF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPOPF: not yet implemented")
+	m := x86asm.Mem{
+		Base: x86asm.ESP,
+	}
+	mem := x86.NewMem(m, nil)
+	v := f.useMemElem(mem, types.I16)
+	f.espDisp += 2
+	f.defFlags(v)
+	return nil
 }
 
 // --- [ POPFD ] ---------------------------------------------------------------
@@ -4573,8 +4719,9 @@ func (f *Func) liftInstPOPF(inst *x86.Inst) error {
 // liftInstPOPFD lifts the given x86 POPFD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPOPFD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPOPFD: not yet implemented")
+	v := f.pop()
+	f.defFlags(v)
+	return nil
 }
 
 // --- [ POPFQ ] ---------------------------------------------------------------
@@ -4582,8 +4729,11 @@ func (f *Func) liftInstPOPFD(inst *x86.Inst) error {
 // liftInstPOPFQ lifts the given x86 POPFQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPOPFQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPOPFQ: not yet implemented")
+	// The upper 32 bits of RFLAGS are reserved and ignored.
+	v := f.pop()
+	f.pop()
+	f.defFlags(v)
+	return nil
 }
 
 // --- [ POR ] -----------------------------------------------------------------
@@ -4591,7 +4741,7 @@ func (f *Func) liftInstPOPFQ(inst *x86.Inst) error {
 // liftInstPOR lifts the given x86 POR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstPOR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPOR: not yet implemented")
 }
 
@@ -4600,7 +4750,7 @@ func (f *Func) liftInstPOR(inst *x86.Inst) error {
 // liftInstPREFETCHNTA lifts the given x86 PREFETCHNTA instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPREFETCHNTA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPREFETCHNTA: not yet implemented")
 }
 
@@ -4609,7 +4759,7 @@ func (f *Func) liftInstPREFETCHNTA(inst *x86.Inst) error {
 // liftInstPREFETCHT0 lifts the given x86 PREFETCHT0 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPREFETCHT0(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPREFETCHT0: not yet implemented")
 }
 
@@ -4618,7 +4768,7 @@ func (f *Func) liftInstPREFETCHT0(inst *x86.Inst) error {
 // liftInstPREFETCHT1 lifts the given x86 PREFETCHT1 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPREFETCHT1(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPREFETCHT1: not yet implemented")
 }
 
@@ -4627,7 +4777,7 @@ func (f *Func) liftInstPREFETCHT1(inst *x86.Inst) error {
 // liftInstPREFETCHT2 lifts the given x86 PREFETCHT2 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPREFETCHT2(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPREFETCHT2: not yet implemented")
 }
 
@@ -4636,7 +4786,7 @@ func (f *Func) liftInstPREFETCHT2(inst *x86.Inst) error {
 // liftInstPREFETCHW lifts the given x86 PREFETCHW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPREFETCHW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPREFETCHW: not yet implemented")
 }
 
@@ -4645,7 +4795,7 @@ func (f *Func) liftInstPREFETCHW(inst *x86.Inst) error {
 // liftInstPSADBW lifts the given x86 PSADBW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSADBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSADBW: not yet implemented")
 }
 
@@ -4654,7 +4804,7 @@ func (f *Func) liftInstPSADBW(inst *x86.Inst) error {
 // liftInstPSHUFB lifts the given x86 PSHUFB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSHUFB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSHUFB: not yet implemented")
 }
 
@@ -4663,7 +4813,7 @@ func (f *Func) liftInstPSHUFB(inst *x86.Inst) error {
 // liftInstPSHUFD lifts the given x86 PSHUFD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSHUFD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSHUFD: not yet implemented")
 }
 
@@ -4672,7 +4822,7 @@ func (f *Func) liftInstPSHUFD(inst *x86.Inst) error {
 // liftInstPSHUFHW lifts the given x86 PSHUFHW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSHUFHW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSHUFHW: not yet implemented")
 }
 
@@ -4681,7 +4831,7 @@ func (f *Func) liftInstPSHUFHW(inst *x86.Inst) error {
 // liftInstPSHUFLW lifts the given x86 PSHUFLW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSHUFLW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSHUFLW: not yet implemented")
 }
 
@@ -4690,7 +4840,7 @@ func (f *Func) liftInstPSHUFLW(inst *x86.Inst) error {
 // liftInstPSHUFW lifts the given x86 PSHUFW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSHUFW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSHUFW: not yet implemented")
 }
 
@@ -4699,7 +4849,7 @@ func (f *Func) liftInstPSHUFW(inst *x86.Inst) error {
 // liftInstPSIGNB lifts the given x86 PSIGNB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSIGNB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSIGNB: not yet implemented")
 }
 
@@ -4708,7 +4858,7 @@ func (f *Func) liftInstPSIGNB(inst *x86.Inst) error {
 // liftInstPSIGND lifts the given x86 PSIGND instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSIGND(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSIGND: not yet implemented")
 }
 
@@ -4717,7 +4867,7 @@ func (f *Func) liftInstPSIGND(inst *x86.Inst) error {
 // liftInstPSIGNW lifts the given x86 PSIGNW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSIGNW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSIGNW: not yet implemented")
 }
 
@@ -4726,7 +4876,7 @@ func (f *Func) liftInstPSIGNW(inst *x86.Inst) error {
 // liftInstPSLLD lifts the given x86 PSLLD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSLLD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSLLD: not yet implemented")
 }
 
@@ -4735,7 +4885,7 @@ func (f *Func) liftInstPSLLD(inst *x86.Inst) error {
 // liftInstPSLLDQ lifts the given x86 PSLLDQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSLLDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSLLDQ: not yet implemented")
 }
 
@@ -4744,7 +4894,7 @@ func (f *Func) liftInstPSLLDQ(inst *x86.Inst) error {
 // liftInstPSLLQ lifts the given x86 PSLLQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSLLQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSLLQ: not yet implemented")
 }
 
@@ -4753,7 +4903,7 @@ func (f *Func) liftInstPSLLQ(inst *x86.Inst) error {
 // liftInstPSLLW lifts the given x86 PSLLW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSLLW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSLLW: not yet implemented")
 }
 
@@ -4762,7 +4912,7 @@ func (f *Func) liftInstPSLLW(inst *x86.Inst) error {
 // liftInstPSRAD lifts the given x86 PSRAD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSRAD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSRAD: not yet implemented")
 }
 
@@ -4771,7 +4921,7 @@ func (f *Func) liftInstPSRAD(inst *x86.Inst) error {
 // liftInstPSRAW lifts the given x86 PSRAW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSRAW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSRAW: not yet implemented")
 }
 
@@ -4780,7 +4930,7 @@ func (f *Func) liftInstPSRAW(inst *x86.Inst) error {
 // liftInstPSRLD lifts the given x86 PSRLD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSRLD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSRLD: not yet implemented")
 }
 
@@ -4789,7 +4939,7 @@ func (f *Func) liftInstPSRLD(inst *x86.Inst) error {
 // liftInstPSRLDQ lifts the given x86 PSRLDQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSRLDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSRLDQ: not yet implemented")
 }
 
@@ -4798,7 +4948,7 @@ func (f *Func) liftInstPSRLDQ(inst *x86.Inst) error {
 // liftInstPSRLQ lifts the given x86 PSRLQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSRLQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSRLQ: not yet implemented")
 }
 
@@ -4807,7 +4957,7 @@ func (f *Func) liftInstPSRLQ(inst *x86.Inst) error {
 // liftInstPSRLW lifts the given x86 PSRLW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSRLW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSRLW: not yet implemented")
 }
 
@@ -4816,7 +4966,7 @@ func (f *Func) liftInstPSRLW(inst *x86.Inst) error {
 // liftInstPSUBB lifts the given x86 PSUBB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSUBB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSUBB: not yet implemented")
 }
 
@@ -4825,7 +4975,7 @@ func (f *Func) liftInstPSUBB(inst *x86.Inst) error {
 // liftInstPSUBD lifts the given x86 PSUBD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSUBD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSUBD: not yet implemented")
 }
 
@@ -4834,7 +4984,7 @@ func (f *Func) liftInstPSUBD(inst *x86.Inst) error {
 // liftInstPSUBQ lifts the given x86 PSUBQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSUBQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSUBQ: not yet implemented")
 }
 
@@ -4843,7 +4993,7 @@ func (f *Func) liftInstPSUBQ(inst *x86.Inst) error {
 // liftInstPSUBSB lifts the given x86 PSUBSB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSUBSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSUBSB: not yet implemented")
 }
 
@@ -4852,7 +5002,7 @@ func (f *Func) liftInstPSUBSB(inst *x86.Inst) error {
 // liftInstPSUBSW lifts the given x86 PSUBSW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSUBSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSUBSW: not yet implemented")
 }
 
@@ -4861,7 +5011,7 @@ func (f *Func) liftInstPSUBSW(inst *x86.Inst) error {
 // liftInstPSUBUSB lifts the given x86 PSUBUSB instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSUBUSB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSUBUSB: not yet implemented")
 }
 
@@ -4870,7 +5020,7 @@ func (f *Func) liftInstPSUBUSB(inst *x86.Inst) error {
 // liftInstPSUBUSW lifts the given x86 PSUBUSW instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPSUBUSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSUBUSW: not yet implemented")
 }
 
@@ -4879,7 +5029,7 @@ func (f *Func) liftInstPSUBUSW(inst *x86.Inst) error {
 // liftInstPSUBW lifts the given x86 PSUBW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPSUBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPSUBW: not yet implemented")
 }
 
@@ -4888,7 +5038,7 @@ func (f *Func) liftInstPSUBW(inst *x86.Inst) error {
 // liftInstPTEST lifts the given x86 PTEST instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPTEST(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPTEST: not yet implemented")
 }
 
@@ -4897,7 +5047,7 @@ func (f *Func) liftInstPTEST(inst *x86.Inst) error {
 // liftInstPUNPCKHBW lifts the given x86 PUNPCKHBW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPUNPCKHBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUNPCKHBW: not yet implemented")
 }
 
@@ -4906,7 +5056,7 @@ func (f *Func) liftInstPUNPCKHBW(inst *x86.Inst) error {
 // liftInstPUNPCKHDQ lifts the given x86 PUNPCKHDQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPUNPCKHDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUNPCKHDQ: not yet implemented")
 }
 
@@ -4915,7 +5065,7 @@ func (f *Func) liftInstPUNPCKHDQ(inst *x86.Inst) error {
 // liftInstPUNPCKHQDQ lifts the given x86 PUNPCKHQDQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPUNPCKHQDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUNPCKHQDQ: not yet implemented")
 }
 
@@ -4924,7 +5074,7 @@ func (f *Func) liftInstPUNPCKHQDQ(inst *x86.Inst) error {
 // liftInstPUNPCKHWD lifts the given x86 PUNPCKHWD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPUNPCKHWD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUNPCKHWD: not yet implemented")
 }
 
@@ -4933,7 +5083,7 @@ func (f *Func) liftInstPUNPCKHWD(inst *x86.Inst) error {
 // liftInstPUNPCKLBW lifts the given x86 PUNPCKLBW instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPUNPCKLBW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUNPCKLBW: not yet implemented")
 }
 
@@ -4942,7 +5092,7 @@ func (f *Func) liftInstPUNPCKLBW(inst *x86.Inst) error {
 // liftInstPUNPCKLDQ lifts the given x86 PUNPCKLDQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPUNPCKLDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUNPCKLDQ: not yet implemented")
 }
 
@@ -4951,7 +5101,7 @@ func (f *Func) liftInstPUNPCKLDQ(inst *x86.Inst) error {
 // liftInstPUNPCKLQDQ lifts the given x86 PUNPCKLQDQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPUNPCKLQDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUNPCKLQDQ: not yet implemented")
 }
 
@@ -4960,7 +5110,7 @@ func (f *Func) liftInstPUNPCKLQDQ(inst *x86.Inst) error {
 // liftInstPUNPCKLWD lifts the given x86 PUNPCKLWD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstPUNPCKLWD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUNPCKLWD: not yet implemented")
 }
 
@@ -4977,13 +5127,14 @@ func (f *Func) liftInstPUSH(inst *x86.Inst) error {
 // push pushes the given value onto the top of the stack of the function,
 // emitting code to f.
 func (f *Func) push(v value.Value) {
+	word := f.l.stackWord()
 	m := x86asm.Mem{
 		Base: x86asm.ESP,
-		Disp: -4,
+		Disp: -word,
 	}
 	mem := x86.NewMem(m, nil)
 	f.defMem(mem, v)
-	f.espDisp -= 4
+	f.espDisp -= word
 }
 
 // --- [ PUSHA ] ---------------------------------------------------------------
@@ -4991,7 +5142,7 @@ func (f *Func) push(v value.Value) {
 // liftInstPUSHA lifts the given x86 PUSHA instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPUSHA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUSHA: not yet implemented")
 }
 
@@ -5000,7 +5151,7 @@ func (f *Func) liftInstPUSHA(inst *x86.Inst) error {
 // liftInstPUSHAD lifts the given x86 PUSHAD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPUSHAD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPUSHAD: not yet implemented")
 }
 
@@ -5009,8 +5160,15 @@ func (f *Func) liftInstPUSHAD(inst *x86.Inst) error {
 // liftInstPUSHF lifts the given x86 PUSHF instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPUSHF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPUSHF: not yet implemented")
+	v := f.useFlags(types.I16)
+	m := x86asm.Mem{
+		Base: x86asm.ESP,
+		Disp: -2,
+	}
+	mem := x86.NewMem(m, nil)
+	f.defMemElem(mem, v, types.I16)
+	f.espDisp -= 2
+	return nil
 }
 
 // --- [ PUSHFD ] --------------------------------------------------------------
@@ -5018,8 +5176,9 @@ func (f *Func) liftInstPUSHF(inst *x86.Inst) error {
 // liftInstPUSHFD lifts the given x86 PUSHFD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPUSHFD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPUSHFD: not yet implemented")
+	v := f.useFlags(types.I32)
+	f.push(v)
+	return nil
 }
 
 // --- [ PUSHFQ ] --------------------------------------------------------------
@@ -5027,8 +5186,11 @@ func (f *Func) liftInstPUSHFD(inst *x86.Inst) error {
 // liftInstPUSHFQ lifts the given x86 PUSHFQ instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstPUSHFQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPUSHFQ: not yet implemented")
+	// The upper 32 bits of RFLAGS are reserved and always zero.
+	f.push(constant.NewInt(0, types.I32))
+	v := f.useFlags(types.I32)
+	f.push(v)
+	return nil
 }
 
 // --- [ PXOR ] ----------------------------------------------------------------
@@ -5036,7 +5198,7 @@ func (f *Func) liftInstPUSHFQ(inst *x86.Inst) error {
 // liftInstPXOR lifts the given x86 PXOR instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstPXOR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstPXOR: not yet implemented")
 }
 
@@ -5045,7 +5207,7 @@ func (f *Func) liftInstPXOR(inst *x86.Inst) error {
 // liftInstRCL lifts the given x86 RCL instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstRCL(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRCL: not yet implemented")
 }
 
@@ -5054,7 +5216,7 @@ func (f *Func) liftInstRCL(inst *x86.Inst) error {
 // liftInstRCPPS lifts the given x86 RCPPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstRCPPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRCPPS: not yet implemented")
 }
 
@@ -5063,7 +5225,7 @@ func (f *Func) liftInstRCPPS(inst *x86.Inst) error {
 // liftInstRCPSS lifts the given x86 RCPSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstRCPSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRCPSS: not yet implemented")
 }
 
@@ -5072,7 +5234,7 @@ func (f *Func) liftInstRCPSS(inst *x86.Inst) error {
 // liftInstRCR lifts the given x86 RCR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstRCR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRCR: not yet implemented")
 }
 
@@ -5081,7 +5243,7 @@ func (f *Func) liftInstRCR(inst *x86.Inst) error {
 // liftInstRDFSBASE lifts the given x86 RDFSBASE instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstRDFSBASE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRDFSBASE: not yet implemented")
 }
 
@@ -5090,7 +5252,7 @@ func (f *Func) liftInstRDFSBASE(inst *x86.Inst) error {
 // liftInstRDGSBASE lifts the given x86 RDGSBASE instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstRDGSBASE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRDGSBASE: not yet implemented")
 }
 
@@ -5099,7 +5261,7 @@ func (f *Func) liftInstRDGSBASE(inst *x86.Inst) error {
 // liftInstRDMSR lifts the given x86 RDMSR instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstRDMSR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRDMSR: not yet implemented")
 }
 
@@ -5108,7 +5270,7 @@ func (f *Func) liftInstRDMSR(inst *x86.Inst) error {
 // liftInstRDPMC lifts the given x86 RDPMC instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstRDPMC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRDPMC: not yet implemented")
 }
 
@@ -5117,7 +5279,7 @@ func (f *Func) liftInstRDPMC(inst *x86.Inst) error {
 // liftInstRDRAND lifts the given x86 RDRAND instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstRDRAND(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRDRAND: not yet implemented")
 }
 
@@ -5126,7 +5288,7 @@ func (f *Func) liftInstRDRAND(inst *x86.Inst) error {
 // liftInstRDTSC lifts the given x86 RDTSC instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstRDTSC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRDTSC: not yet implemented")
 }
 
@@ -5135,7 +5297,7 @@ func (f *Func) liftInstRDTSC(inst *x86.Inst) error {
 // liftInstRDTSCP lifts the given x86 RDTSCP instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstRDTSCP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRDTSCP: not yet implemented")
 }
 
@@ -5184,7 +5346,7 @@ func (f *Func) liftInstROR(inst *x86.Inst) error {
 // liftInstROUNDPD lifts the given x86 ROUNDPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstROUNDPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstROUNDPD: not yet implemented")
 }
 
@@ -5193,7 +5355,7 @@ func (f *Func) liftInstROUNDPD(inst *x86.Inst) error {
 // liftInstROUNDPS lifts the given x86 ROUNDPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstROUNDPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstROUNDPS: not yet implemented")
 }
 
@@ -5202,7 +5364,7 @@ func (f *Func) liftInstROUNDPS(inst *x86.Inst) error {
 // liftInstROUNDSD lifts the given x86 ROUNDSD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstROUNDSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstROUNDSD: not yet implemented")
 }
 
@@ -5211,7 +5373,7 @@ func (f *Func) liftInstROUNDSD(inst *x86.Inst) error {
 // liftInstROUNDSS lifts the given x86 ROUNDSS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstROUNDSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstROUNDSS: not yet implemented")
 }
 
@@ -5220,7 +5382,7 @@ func (f *Func) liftInstROUNDSS(inst *x86.Inst) error {
 // liftInstRSM lifts the given x86 RSM instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstRSM(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRSM: not yet implemented")
 }
 
@@ -5229,7 +5391,7 @@ func (f *Func) liftInstRSM(inst *x86.Inst) error {
 // liftInstRSQRTPS lifts the given x86 RSQRTPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstRSQRTPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRSQRTPS: not yet implemented")
 }
 
@@ -5238,7 +5400,7 @@ func (f *Func) liftInstRSQRTPS(inst *x86.Inst) error {
 // liftInstRSQRTSS lifts the given x86 RSQRTSS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstRSQRTSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstRSQRTSS: not yet implemented")
 }
 
@@ -5247,8 +5409,10 @@ func (f *Func) liftInstRSQRTSS(inst *x86.Inst) error {
 // liftInstSAHF lifts the given x86 SAHF instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSAHF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstSAHF: not yet implemented")
+	// SF:ZF:0:AF:0:PF:1:CF = AH
+	v := f.useReg(x86.NewReg(x86asm.AH, inst))
+	f.defFlags(v)
+	return nil
 }
 
 // --- [ SAR ] -----------------------------------------------------------------
@@ -5310,7 +5474,7 @@ func (f *Func) liftInstSBB(inst *x86.Inst) error {
 // liftInstSCASB lifts the given x86 SCASB instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSCASB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSCASB: not yet implemented")
 }
 
@@ -5319,7 +5483,7 @@ func (f *Func) liftInstSCASB(inst *x86.Inst) error {
 // liftInstSCASD lifts the given x86 SCASD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSCASD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSCASD: not yet implemented")
 }
 
@@ -5328,7 +5492,7 @@ func (f *Func) liftInstSCASD(inst *x86.Inst) error {
 // liftInstSCASQ lifts the given x86 SCASQ instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSCASQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSCASQ: not yet implemented")
 }
 
@@ -5337,7 +5501,7 @@ func (f *Func) liftInstSCASQ(inst *x86.Inst) error {
 // liftInstSCASW lifts the given x86 SCASW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSCASW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSCASW: not yet implemented")
 }
 
@@ -5346,7 +5510,7 @@ func (f *Func) liftInstSCASW(inst *x86.Inst) error {
 // liftInstSFENCE lifts the given x86 SFENCE instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSFENCE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSFENCE: not yet implemented")
 }
 
@@ -5355,7 +5519,7 @@ func (f *Func) liftInstSFENCE(inst *x86.Inst) error {
 // liftInstSGDT lifts the given x86 SGDT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSGDT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSGDT: not yet implemented")
 }
 
@@ -5411,7 +5575,7 @@ func (f *Func) liftInstSHR(inst *x86.Inst) error {
 // liftInstSHRD lifts the given x86 SHRD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSHRD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSHRD: not yet implemented")
 }
 
@@ -5420,7 +5584,7 @@ func (f *Func) liftInstSHRD(inst *x86.Inst) error {
 // liftInstSHUFPD lifts the given x86 SHUFPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSHUFPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSHUFPD: not yet implemented")
 }
 
@@ -5429,7 +5593,7 @@ func (f *Func) liftInstSHUFPD(inst *x86.Inst) error {
 // liftInstSHUFPS lifts the given x86 SHUFPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSHUFPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSHUFPS: not yet implemented")
 }
 
@@ -5438,7 +5602,7 @@ func (f *Func) liftInstSHUFPS(inst *x86.Inst) error {
 // liftInstSIDT lifts the given x86 SIDT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSIDT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSIDT: not yet implemented")
 }
 
@@ -5447,7 +5611,7 @@ func (f *Func) liftInstSIDT(inst *x86.Inst) error {
 // liftInstSLDT lifts the given x86 SLDT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSLDT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSLDT: not yet implemented")
 }
 
@@ -5456,7 +5620,7 @@ func (f *Func) liftInstSLDT(inst *x86.Inst) error {
 // liftInstSMSW lifts the given x86 SMSW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSMSW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSMSW: not yet implemented")
 }
 
@@ -5465,7 +5629,7 @@ func (f *Func) liftInstSMSW(inst *x86.Inst) error {
 // liftInstSQRTPD lifts the given x86 SQRTPD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSQRTPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSQRTPD: not yet implemented")
 }
 
@@ -5474,7 +5638,7 @@ func (f *Func) liftInstSQRTPD(inst *x86.Inst) error {
 // liftInstSQRTPS lifts the given x86 SQRTPS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSQRTPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSQRTPS: not yet implemented")
 }
 
@@ -5483,7 +5647,7 @@ func (f *Func) liftInstSQRTPS(inst *x86.Inst) error {
 // liftInstSQRTSD lifts the given x86 SQRTSD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSQRTSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSQRTSD: not yet implemented")
 }
 
@@ -5492,7 +5656,7 @@ func (f *Func) liftInstSQRTSD(inst *x86.Inst) error {
 // liftInstSQRTSS lifts the given x86 SQRTSS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSQRTSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSQRTSS: not yet implemented")
 }
 
@@ -5501,7 +5665,7 @@ func (f *Func) liftInstSQRTSS(inst *x86.Inst) error {
 // liftInstSTC lifts the given x86 STC instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstSTC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSTC: not yet implemented")
 }
 
@@ -5510,7 +5674,7 @@ func (f *Func) liftInstSTC(inst *x86.Inst) error {
 // liftInstSTD lifts the given x86 STD instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstSTD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSTD: not yet implemented")
 }
 
@@ -5519,7 +5683,7 @@ func (f *Func) liftInstSTD(inst *x86.Inst) error {
 // liftInstSTI lifts the given x86 STI instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstSTI(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSTI: not yet implemented")
 }
 
@@ -5528,7 +5692,7 @@ func (f *Func) liftInstSTI(inst *x86.Inst) error {
 // liftInstSTMXCSR lifts the given x86 STMXCSR instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSTMXCSR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSTMXCSR: not yet implemented")
 }
 
@@ -5577,7 +5741,7 @@ func (f *Func) liftInstSTOSW(inst *x86.Inst) error {
 // liftInstSTR lifts the given x86 STR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstSTR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSTR: not yet implemented")
 }
 
@@ -5597,7 +5761,7 @@ func (f *Func) liftInstSUB(inst *x86.Inst) error {
 // liftInstSUBPD lifts the given x86 SUBPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSUBPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSUBPD: not yet implemented")
 }
 
@@ -5606,7 +5770,7 @@ func (f *Func) liftInstSUBPD(inst *x86.Inst) error {
 // liftInstSUBPS lifts the given x86 SUBPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSUBPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSUBPS: not yet implemented")
 }
 
@@ -5615,7 +5779,7 @@ func (f *Func) liftInstSUBPS(inst *x86.Inst) error {
 // liftInstSUBSD lifts the given x86 SUBSD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSUBSD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSUBSD: not yet implemented")
 }
 
@@ -5624,7 +5788,7 @@ func (f *Func) liftInstSUBSD(inst *x86.Inst) error {
 // liftInstSUBSS lifts the given x86 SUBSS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSUBSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSUBSS: not yet implemented")
 }
 
@@ -5633,7 +5797,7 @@ func (f *Func) liftInstSUBSS(inst *x86.Inst) error {
 // liftInstSWAPGS lifts the given x86 SWAPGS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSWAPGS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSWAPGS: not yet implemented")
 }
 
@@ -5642,7 +5806,12 @@ func (f *Func) liftInstSWAPGS(inst *x86.Inst) error {
 // liftInstSYSCALL lifts the given x86 SYSCALL instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSYSCALL(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	// Linux system call; e.g. `mov eax, 1; syscall`.
+	if f.l.Mode == 64 {
+		f.liftSyscall(inst, RuntimeSyscall, syscallConv64)
+		return nil
+	}
+	trace.Dump("inst:", inst)
 	panic("emitInstSYSCALL: not yet implemented")
 }
 
@@ -5651,7 +5820,7 @@ func (f *Func) liftInstSYSCALL(inst *x86.Inst) error {
 // liftInstSYSENTER lifts the given x86 SYSENTER instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstSYSENTER(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSYSENTER: not yet implemented")
 }
 
@@ -5660,7 +5829,7 @@ func (f *Func) liftInstSYSENTER(inst *x86.Inst) error {
 // liftInstSYSEXIT lifts the given x86 SYSEXIT instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSYSEXIT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSYSEXIT: not yet implemented")
 }
 
@@ -5669,7 +5838,7 @@ func (f *Func) liftInstSYSEXIT(inst *x86.Inst) error {
 // liftInstSYSRET lifts the given x86 SYSRET instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstSYSRET(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstSYSRET: not yet implemented")
 }
 
@@ -5707,7 +5876,7 @@ func (f *Func) liftInstTEST(inst *x86.Inst) error {
 // liftInstTZCNT lifts the given x86 TZCNT instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstTZCNT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstTZCNT: not yet implemented")
 }
 
@@ -5716,7 +5885,7 @@ func (f *Func) liftInstTZCNT(inst *x86.Inst) error {
 // liftInstUCOMISD lifts the given x86 UCOMISD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstUCOMISD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstUCOMISD: not yet implemented")
 }
 
@@ -5725,7 +5894,7 @@ func (f *Func) liftInstUCOMISD(inst *x86.Inst) error {
 // liftInstUCOMISS lifts the given x86 UCOMISS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstUCOMISS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstUCOMISS: not yet implemented")
 }
 
@@ -5734,7 +5903,7 @@ func (f *Func) liftInstUCOMISS(inst *x86.Inst) error {
 // liftInstUD1 lifts the given x86 UD1 instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstUD1(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstUD1: not yet implemented")
 }
 
@@ -5743,7 +5912,7 @@ func (f *Func) liftInstUD1(inst *x86.Inst) error {
 // liftInstUD2 lifts the given x86 UD2 instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstUD2(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstUD2: not yet implemented")
 }
 
@@ -5752,7 +5921,7 @@ func (f *Func) liftInstUD2(inst *x86.Inst) error {
 // liftInstUNPCKHPD lifts the given x86 UNPCKHPD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstUNPCKHPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstUNPCKHPD: not yet implemented")
 }
 
@@ -5761,7 +5930,7 @@ func (f *Func) liftInstUNPCKHPD(inst *x86.Inst) error {
 // liftInstUNPCKHPS lifts the given x86 UNPCKHPS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstUNPCKHPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstUNPCKHPS: not yet implemented")
 }
 
@@ -5770,7 +5939,7 @@ func (f *Func) liftInstUNPCKHPS(inst *x86.Inst) error {
 // liftInstUNPCKLPD lifts the given x86 UNPCKLPD instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstUNPCKLPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstUNPCKLPD: not yet implemented")
 }
 
@@ -5779,7 +5948,7 @@ func (f *Func) liftInstUNPCKLPD(inst *x86.Inst) error {
 // liftInstUNPCKLPS lifts the given x86 UNPCKLPS instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstUNPCKLPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstUNPCKLPS: not yet implemented")
 }
 
@@ -5788,7 +5957,7 @@ func (f *Func) liftInstUNPCKLPS(inst *x86.Inst) error {
 // liftInstVERR lifts the given x86 VERR instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstVERR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstVERR: not yet implemented")
 }
 
@@ -5797,7 +5966,7 @@ func (f *Func) liftInstVERR(inst *x86.Inst) error {
 // liftInstVERW lifts the given x86 VERW instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstVERW(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstVERW: not yet implemented")
 }
 
@@ -5806,7 +5975,7 @@ func (f *Func) liftInstVERW(inst *x86.Inst) error {
 // liftInstVMOVDQA lifts the given x86 VMOVDQA instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstVMOVDQA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstVMOVDQA: not yet implemented")
 }
 
@@ -5815,7 +5984,7 @@ func (f *Func) liftInstVMOVDQA(inst *x86.Inst) error {
 // liftInstVMOVDQU lifts the given x86 VMOVDQU instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstVMOVDQU(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstVMOVDQU: not yet implemented")
 }
 
@@ -5824,7 +5993,7 @@ func (f *Func) liftInstVMOVDQU(inst *x86.Inst) error {
 // liftInstVMOVNTDQ lifts the given x86 VMOVNTDQ instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstVMOVNTDQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstVMOVNTDQ: not yet implemented")
 }
 
@@ -5833,7 +6002,7 @@ func (f *Func) liftInstVMOVNTDQ(inst *x86.Inst) error {
 // liftInstVMOVNTDQA lifts the given x86 VMOVNTDQA instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstVMOVNTDQA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstVMOVNTDQA: not yet implemented")
 }
 
@@ -5842,7 +6011,7 @@ func (f *Func) liftInstVMOVNTDQA(inst *x86.Inst) error {
 // liftInstVZEROUPPER lifts the given x86 VZEROUPPER instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstVZEROUPPER(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstVZEROUPPER: not yet implemented")
 }
 
@@ -5851,7 +6020,7 @@ func (f *Func) liftInstVZEROUPPER(inst *x86.Inst) error {
 // liftInstWBINVD lifts the given x86 WBINVD instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstWBINVD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstWBINVD: not yet implemented")
 }
 
@@ -5860,7 +6029,7 @@ func (f *Func) liftInstWBINVD(inst *x86.Inst) error {
 // liftInstWRFSBASE lifts the given x86 WRFSBASE instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstWRFSBASE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstWRFSBASE: not yet implemented")
 }
 
@@ -5869,7 +6038,7 @@ func (f *Func) liftInstWRFSBASE(inst *x86.Inst) error {
 // liftInstWRGSBASE lifts the given x86 WRGSBASE instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstWRGSBASE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstWRGSBASE: not yet implemented")
 }
 
@@ -5878,7 +6047,7 @@ func (f *Func) liftInstWRGSBASE(inst *x86.Inst) error {
 // liftInstWRMSR lifts the given x86 WRMSR instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstWRMSR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstWRMSR: not yet implemented")
 }
 
@@ -5887,7 +6056,7 @@ func (f *Func) liftInstWRMSR(inst *x86.Inst) error {
 // liftInstXABORT lifts the given x86 XABORT instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXABORT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXABORT: not yet implemented")
 }
 
@@ -5896,7 +6065,7 @@ func (f *Func) liftInstXABORT(inst *x86.Inst) error {
 // liftInstXADD lifts the given x86 XADD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstXADD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXADD: not yet implemented")
 }
 
@@ -5905,7 +6074,7 @@ func (f *Func) liftInstXADD(inst *x86.Inst) error {
 // liftInstXBEGIN lifts the given x86 XBEGIN instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXBEGIN(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXBEGIN: not yet implemented")
 }
 
@@ -5914,7 +6083,7 @@ func (f *Func) liftInstXBEGIN(inst *x86.Inst) error {
 // liftInstXCHG lifts the given x86 XCHG instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstXCHG(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXCHG: not yet implemented")
 }
 
@@ -5923,7 +6092,7 @@ func (f *Func) liftInstXCHG(inst *x86.Inst) error {
 // liftInstXEND lifts the given x86 XEND instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstXEND(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXEND: not yet implemented")
 }
 
@@ -5932,7 +6101,7 @@ func (f *Func) liftInstXEND(inst *x86.Inst) error {
 // liftInstXGETBV lifts the given x86 XGETBV instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXGETBV(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXGETBV: not yet implemented")
 }
 
@@ -5969,7 +6138,7 @@ func (f *Func) liftInstXOR(inst *x86.Inst) error {
 // liftInstXORPD lifts the given x86 XORPD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstXORPD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXORPD: not yet implemented")
 }
 
@@ -5978,7 +6147,7 @@ func (f *Func) liftInstXORPD(inst *x86.Inst) error {
 // liftInstXORPS lifts the given x86 XORPS instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstXORPS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXORPS: not yet implemented")
 }
 
@@ -5987,7 +6156,7 @@ func (f *Func) liftInstXORPS(inst *x86.Inst) error {
 // liftInstXRSTOR lifts the given x86 XRSTOR instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXRSTOR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXRSTOR: not yet implemented")
 }
 
@@ -5996,7 +6165,7 @@ func (f *Func) liftInstXRSTOR(inst *x86.Inst) error {
 // liftInstXRSTOR64 lifts the given x86 XRSTOR64 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstXRSTOR64(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXRSTOR64: not yet implemented")
 }
 
@@ -6005,7 +6174,7 @@ func (f *Func) liftInstXRSTOR64(inst *x86.Inst) error {
 // liftInstXRSTORS lifts the given x86 XRSTORS instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXRSTORS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXRSTORS: not yet implemented")
 }
 
@@ -6014,7 +6183,7 @@ func (f *Func) liftInstXRSTORS(inst *x86.Inst) error {
 // liftInstXRSTORS64 lifts the given x86 XRSTORS64 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstXRSTORS64(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXRSTORS64: not yet implemented")
 }
 
@@ -6023,7 +6192,7 @@ func (f *Func) liftInstXRSTORS64(inst *x86.Inst) error {
 // liftInstXSAVE lifts the given x86 XSAVE instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstXSAVE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSAVE: not yet implemented")
 }
 
@@ -6032,7 +6201,7 @@ func (f *Func) liftInstXSAVE(inst *x86.Inst) error {
 // liftInstXSAVE64 lifts the given x86 XSAVE64 instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXSAVE64(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSAVE64: not yet implemented")
 }
 
@@ -6041,7 +6210,7 @@ func (f *Func) liftInstXSAVE64(inst *x86.Inst) error {
 // liftInstXSAVEC lifts the given x86 XSAVEC instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXSAVEC(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSAVEC: not yet implemented")
 }
 
@@ -6050,7 +6219,7 @@ func (f *Func) liftInstXSAVEC(inst *x86.Inst) error {
 // liftInstXSAVEC64 lifts the given x86 XSAVEC64 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstXSAVEC64(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSAVEC64: not yet implemented")
 }
 
@@ -6059,7 +6228,7 @@ func (f *Func) liftInstXSAVEC64(inst *x86.Inst) error {
 // liftInstXSAVEOPT lifts the given x86 XSAVEOPT instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstXSAVEOPT(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSAVEOPT: not yet implemented")
 }
 
@@ -6068,7 +6237,7 @@ func (f *Func) liftInstXSAVEOPT(inst *x86.Inst) error {
 // liftInstXSAVEOPT64 lifts the given x86 XSAVEOPT64 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstXSAVEOPT64(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSAVEOPT64: not yet implemented")
 }
 
@@ -6077,7 +6246,7 @@ func (f *Func) liftInstXSAVEOPT64(inst *x86.Inst) error {
 // liftInstXSAVES lifts the given x86 XSAVES instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXSAVES(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSAVES: not yet implemented")
 }
 
@@ -6086,7 +6255,7 @@ func (f *Func) liftInstXSAVES(inst *x86.Inst) error {
 // liftInstXSAVES64 lifts the given x86 XSAVES64 instruction to LLVM IR,
 // emitting code to f.
 func (f *Func) liftInstXSAVES64(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSAVES64: not yet implemented")
 }
 
@@ -6095,7 +6264,7 @@ func (f *Func) liftInstXSAVES64(inst *x86.Inst) error {
 // liftInstXSETBV lifts the given x86 XSETBV instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstXSETBV(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXSETBV: not yet implemented")
 }
 
@@ -6104,6 +6273,6 @@ func (f *Func) liftInstXSETBV(inst *x86.Inst) error {
 // liftInstXTEST lifts the given x86 XTEST instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstXTEST(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
+	trace.Dump("inst:", inst)
 	panic("emitInstXTEST: not yet implemented")
 }
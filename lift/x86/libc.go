@@ -0,0 +1,202 @@
+package x86
+
+// libcHeader specifies the function prototypes of common functions of the C
+// standard library and POSIX, as exported by libc.so (and msvcrt.dll).
+const libcHeader = `
+// --- [ Types ] ---------------------------------------------------------------
+
+typedef struct _IO_FILE FILE;
+typedef struct __dirstream DIR;
+typedef struct __jmp_buf_tag *jmp_buf;
+typedef long off_t;
+typedef long time_t;
+typedef long clock_t;
+typedef int pid_t;
+typedef unsigned int mode_t;
+typedef unsigned int uid_t;
+typedef unsigned int socklen_t;
+typedef unsigned long pthread_t;
+typedef struct pthread_mutex pthread_mutex_t;
+typedef struct pthread_attr pthread_attr_t;
+typedef void (*sighandler_t)(int);
+
+// --- [ stdio.h ] -------------------------------------------------------------
+
+int printf(const char *format, ...);
+int fprintf(FILE *stream, const char *format, ...);
+int sprintf(char *str, const char *format, ...);
+int snprintf(char *str, size_t size, const char *format, ...);
+int vprintf(const char *format, va_list ap);
+int vfprintf(FILE *stream, const char *format, va_list ap);
+int vsprintf(char *str, const char *format, va_list ap);
+int vsnprintf(char *str, size_t size, const char *format, va_list ap);
+int scanf(const char *format, ...);
+int fscanf(FILE *stream, const char *format, ...);
+int sscanf(const char *str, const char *format, ...);
+int puts(const char *s);
+int putchar(int c);
+int getchar(void);
+int fputs(const char *s, FILE *stream);
+int fputc(int c, FILE *stream);
+int fgetc(FILE *stream);
+char *fgets(char *s, int size, FILE *stream);
+FILE *fopen(const char *pathname, const char *mode);
+FILE *fdopen(int fd, const char *mode);
+int fclose(FILE *stream);
+size_t fread(void *ptr, size_t size, size_t nmemb, FILE *stream);
+size_t fwrite(const void *ptr, size_t size, size_t nmemb, FILE *stream);
+int fseek(FILE *stream, long offset, int whence);
+long ftell(FILE *stream);
+void rewind(FILE *stream);
+int fflush(FILE *stream);
+int feof(FILE *stream);
+int ferror(FILE *stream);
+void perror(const char *s);
+int remove(const char *pathname);
+int rename(const char *oldpath, const char *newpath);
+int setvbuf(FILE *stream, char *buf, int mode, size_t size);
+
+// --- [ stdlib.h ] ------------------------------------------------------------
+
+void *malloc(size_t size);
+void *calloc(size_t nmemb, size_t size);
+void *realloc(void *ptr, size_t size);
+void free(void *ptr);
+void exit(int status);
+void _exit(int status);
+void abort(void);
+int atexit(void (*function)(void));
+int atoi(const char *nptr);
+long atol(const char *nptr);
+double atof(const char *nptr);
+long strtol(const char *nptr, char **endptr, int base);
+unsigned long strtoul(const char *nptr, char **endptr, int base);
+double strtod(const char *nptr, char **endptr);
+char *getenv(const char *name);
+int setenv(const char *name, const char *value, int overwrite);
+int system(const char *command);
+void qsort(void *base, size_t nmemb, size_t size, int (*compar)(const void *, const void *));
+void *bsearch(const void *key, const void *base, size_t nmemb, size_t size, int (*compar)(const void *, const void *));
+int rand(void);
+void srand(unsigned int seed);
+int abs(int j);
+long labs(long j);
+
+// --- [ string.h ] ------------------------------------------------------------
+
+void *memcpy(void *dest, const void *src, size_t n);
+void *memmove(void *dest, const void *src, size_t n);
+void *memset(void *s, int c, size_t n);
+int memcmp(const void *s1, const void *s2, size_t n);
+void *memchr(const void *s, int c, size_t n);
+size_t strlen(const char *s);
+char *strcpy(char *dest, const char *src);
+char *strncpy(char *dest, const char *src, size_t n);
+char *strcat(char *dest, const char *src);
+char *strncat(char *dest, const char *src, size_t n);
+int strcmp(const char *s1, const char *s2);
+int strncmp(const char *s1, const char *s2, size_t n);
+int strcasecmp(const char *s1, const char *s2);
+char *strchr(const char *s, int c);
+char *strrchr(const char *s, int c);
+char *strstr(const char *haystack, const char *needle);
+char *strdup(const char *s);
+char *strtok(char *str, const char *delim);
+char *strerror(int errnum);
+
+// --- [ ctype.h ] -------------------------------------------------------------
+
+int isalnum(int c);
+int isalpha(int c);
+int isdigit(int c);
+int isspace(int c);
+int isupper(int c);
+int islower(int c);
+int isprint(int c);
+int isxdigit(int c);
+int toupper(int c);
+int tolower(int c);
+
+// --- [ math.h ] --------------------------------------------------------------
+
+double sin(double x);
+double cos(double x);
+double tan(double x);
+double atan2(double y, double x);
+double sqrt(double x);
+double pow(double x, double y);
+double exp(double x);
+double log(double x);
+double floor(double x);
+double ceil(double x);
+double fabs(double x);
+double fmod(double x, double y);
+
+// --- [ time.h ] --------------------------------------------------------------
+
+time_t time(time_t *tloc);
+clock_t clock(void);
+
+// --- [ setjmp.h and signal.h ] -----------------------------------------------
+
+int setjmp(jmp_buf env);
+void longjmp(jmp_buf env, int val);
+sighandler_t signal(int signum, sighandler_t handler);
+int raise(int sig);
+
+// --- [ POSIX ] ---------------------------------------------------------------
+
+int open(const char *pathname, int flags, ...);
+int close(int fd);
+ssize_t read(int fd, void *buf, size_t count);
+ssize_t write(int fd, const void *buf, size_t count);
+off_t lseek(int fd, off_t offset, int whence);
+int unlink(const char *pathname);
+int access(const char *pathname, int mode);
+int dup(int oldfd);
+int dup2(int oldfd, int newfd);
+int pipe(int pipefd[2]);
+pid_t fork(void);
+pid_t getpid(void);
+uid_t getuid(void);
+int execve(const char *pathname, char **argv, char **envp);
+int execvp(const char *file, char **argv);
+pid_t waitpid(pid_t pid, int *wstatus, int options);
+int kill(pid_t pid, int sig);
+unsigned int sleep(unsigned int seconds);
+int usleep(unsigned int usec);
+int chdir(const char *path);
+char *getcwd(char *buf, size_t size);
+int mkdir(const char *pathname, mode_t mode);
+DIR *opendir(const char *name);
+int closedir(DIR *dirp);
+void *mmap(void *addr, size_t length, int prot, int flags, int fd, off_t offset);
+int munmap(void *addr, size_t length);
+int mprotect(void *addr, size_t len, int prot);
+int ioctl(int fd, unsigned long request, ...);
+int fcntl(int fd, int cmd, ...);
+int *__errno_location(void);
+
+// Sockets.
+int socket(int domain, int type, int protocol);
+int bind(int sockfd, const struct sockaddr *addr, socklen_t addrlen);
+int listen(int sockfd, int backlog);
+int accept(int sockfd, struct sockaddr *addr, socklen_t *addrlen);
+int connect(int sockfd, const struct sockaddr *addr, socklen_t addrlen);
+ssize_t send(int sockfd, const void *buf, size_t len, int flags);
+ssize_t recv(int sockfd, void *buf, size_t len, int flags);
+
+// Threads.
+int pthread_create(pthread_t *thread, const pthread_attr_t *attr, void *(*start_routine)(void *), void *arg);
+int pthread_join(pthread_t thread, void **retval);
+int pthread_mutex_lock(pthread_mutex_t *mutex);
+int pthread_mutex_unlock(pthread_mutex_t *mutex);
+
+// --- [ Runtime support ] -----------------------------------------------------
+
+int main(int argc, char **argv, char **envp);
+int __libc_start_main(int (*main)(int, char **, char **), int argc, char **argv, void (*init)(void), void (*fini)(void), void (*rtld_fini)(void), void *stack_end);
+void __stack_chk_fail(void);
+int __cxa_atexit(void (*func)(void *), void *arg, void *dso_handle);
+void __cxa_finalize(void *d);
+`
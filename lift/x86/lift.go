@@ -1,12 +1,20 @@
 // Package x86 implements x86 to LLVM IR lifting.
+//
+// A Lifter is created for a binary executable using NewLifter, after which
+// functions may be lifted individually using Lifter.Lift. For whole program
+// lifting, function lifters should be created for every function (using
+// Lifter.NewFunc) before any function is lifted (using Func.Lift), as the
+// signatures of functions are refined based on their callers.
 package x86
 
 import (
-	"log"
-	"os"
+	"context"
+	"sort"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/demangle"
 	"github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/logging"
 	"github.com/llir/llvm/asm"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/metadata"
@@ -14,6 +22,7 @@ import (
 	"github.com/mewkiz/pkg/osutil"
 	"github.com/mewkiz/pkg/term"
 	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
 )
 
 // TODO: Remove loggers once the library matures.
@@ -22,10 +31,13 @@ import (
 var (
 	// dbg represents a logger with the "lift:" prefix, which logs debug
 	// messages to standard error.
-	dbg = log.New(os.Stderr, term.CyanBold("lift:")+" ", 0)
+	dbg = logging.New("lift", logging.LevelDebug, term.CyanBold("lift:")+" ")
 	// warn represents a logger with the "warning:" prefix, which logs warning
 	// messages to standard error.
-	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+	warn = logging.New("lift", logging.LevelInfo, term.RedBold("warning:")+" ")
+	// trace represents a logger with the "lift:" prefix, which logs detailed
+	// tracing messages to standard error.
+	trace = logging.New("lift", logging.LevelTrace, term.CyanBold("lift:")+" ")
 )
 
 // A Lifter tracks information required to lift the assembly of a binary
@@ -45,6 +57,100 @@ type Lifter struct {
 	FuncByName map[string]*ir.Function
 	// Global variables.
 	Globals map[bin.Address]*ir.Global
+	// String literals (ASCII or UTF-16LE) referenced from code; also present in
+	// Globals.
+	Strings map[bin.Address]*ir.Global
+	// C++ vtables, located from constructors.
+	VTables map[bin.Address]*VTable
+	// Stub functions of unsupported instructions, lifted in non-strict mode;
+	// indexed by function name (e.g. "asm.cpuid").
+	Stubs map[string]*ir.Function
+	// External global variables of data imports, referenced through the
+	// initializers of GOT entries in Globals; indexed by name.
+	Externs map[string]*ir.Global
+	// Thunk functions consisting of a single JMP instruction (e.g. incremental
+	// linking thunks and import thunks), mapped to their ultimate targets.
+	// Thunks are not lifted; references to thunks resolve to the functions of
+	// their targets.
+	Thunks map[bin.Address]bin.Address
+	// Names of named thunks (e.g. exports) differing from the names of their
+	// targets, indexed by thunk address. Named thunks are emitted as forwarding
+	// functions of their targets (see Forwarders), to retain their names.
+	NamedThunks map[bin.Address]string
+	// Entry address of the main function (e.g. main or WinMain), as located
+	// from the startup code of the C runtime; or 0 if not located.
+	Main bin.Address
+	// Strict specifies whether to panic on unsupported instructions, rather
+	// than lifting them as calls to stub functions.
+	Strict bool
+	// DebugAddrs specifies whether to attach the address and length of the
+	// originating machine code instruction as "addr" and "len" metadata to each
+	// lifted LLVM IR instruction.
+	DebugAddrs bool
+	// AsmAnnotations specifies whether to attach the disassembly of the
+	// originating machine code instruction as "asm" metadata to each lifted
+	// LLVM IR instruction.
+	AsmAnnotations bool
+	// AliasAnnotations specifies whether to attach "alias.scope" and "noalias"
+	// metadata to lifted loads and stores, distinguishing stack slots, global
+	// variables and external memory.
+	AliasAnnotations bool
+	// ConstGlobals specifies whether to mark global variables written exactly
+	// once with a constant by initialization code (e.g. startup code of the C
+	// runtime) as constant, with the constant as initializer.
+	ConstGlobals bool
+	// TrapDiv specifies whether to lift DIV and IDIV instructions with checks
+	// of division by zero and quotient overflow, calling @llvm.trap if either;
+	// modelling the divide error exception of x86 rather than the undefined
+	// behaviour of division by zero in LLVM IR.
+	TrapDiv bool
+	// String literals located by external analysis (e.g. radare2); used for
+	// data referenced from code which is not recognized as string literals by
+	// heuristics (e.g. short or non-ASCII strings).
+	KnownStrings map[bin.Address]KnownString
+	// Function attributes (e.g. "nounwind" and "readonly") inferred from the
+	// bodies of lifted functions; indexed by function name.
+	Attrs map[string][]string
+
+	// Default values of the segment registers of 16-bit real mode code; or nil
+	// if not applicable.
+	segs map[x86asm.Reg]uint16
+	// Accesses to data sections from code, as recorded by function lifters.
+	accesses map[bin.Address]*dataAccess
+	// globalsInferred specifies whether global variables have been inferred
+	// from accesses to data sections.
+	globalsInferred bool
+	// Struct types recovered from accesses relative to base pointers; indexed
+	// by struct layout key.
+	structs map[string]*recoveredStruct
+	// Struct layouts of global variables accessed through base pointers holding
+	// their address; indexed by global variable address.
+	structGlobals map[bin.Address]structLayout
+	// Signedness of global variables, as inferred from comparisons and shifts;
+	// indexed by global variable address.
+	globalSigns map[bin.Address]signedness
+	// Enums recovered from switch statements; indexed by case constants key.
+	enums map[string]*recoveredEnum
+	// Enums of global variables, as recovered from switch statements; indexed
+	// by global variable address.
+	globalEnums map[bin.Address]*recoveredEnum
+	// Addresses of data sections taken by immediate operands and LEA
+	// instructions.
+	addrTaken map[bin.Address]bool
+	// Stores of constants to write-once global variables, omitted when lifted;
+	// indexed by instruction address.
+	constStores map[bin.Address]bool
+	// Functions of the startup code of the C runtime, executed before main;
+	// located once, the first time write-once global variables are marked.
+	startup map[bin.Address]bool
+	// Well-known tables of cryptographic, checksum and compression algorithms
+	// located in the sections of the binary executable.
+	cryptoTables []cryptoTable
+	// cryptoScanned specifies whether the sections of the binary executable
+	// have been scanned for well-known tables.
+	cryptoScanned bool
+	// Writes into executable sections, as recorded by function lifters.
+	codeWrites []CodeWrite
 }
 
 // NewLifter creates a new Lifter for accessing the assembly instructions of the
@@ -53,19 +159,20 @@ type Lifter struct {
 //
 // Associated files of the generic disassembler.
 //
-//    funcs.json
-//    blocks.json
-//    tables.json
-//    chunks.json
-//    data.json
+//	funcs.json
+//	blocks.json
+//	tables.json
+//	chunks.json
+//	data.json
 //
 // Associated files of the x86 disassembler.
 //
-//    contexts.json
+//	contexts.json
 //
 // Associated files of the x86 to LLVM IR lifter.
 //
-//    info.ll
+//	info.ll
+//	sigs.json
 func NewLifter(file *bin.File) (*Lifter, error) {
 	// Prepare x86 to LLVM IR lifter.
 	dis, err := x86.NewDisasm(file)
@@ -73,10 +180,23 @@ func NewLifter(file *bin.File) (*Lifter, error) {
 		return nil, errors.WithStack(err)
 	}
 	l := &Lifter{
-		Disasm:     dis,
-		Funcs:      make(map[bin.Address]*Func),
-		FuncByName: make(map[string]*ir.Function),
-		Globals:    make(map[bin.Address]*ir.Global),
+		Disasm:        dis,
+		Funcs:         make(map[bin.Address]*Func),
+		FuncByName:    make(map[string]*ir.Function),
+		Globals:       make(map[bin.Address]*ir.Global),
+		Strings:       make(map[bin.Address]*ir.Global),
+		VTables:       make(map[bin.Address]*VTable),
+		Stubs:         make(map[string]*ir.Function),
+		Externs:       make(map[string]*ir.Global),
+		NamedThunks:   make(map[bin.Address]string),
+		accesses:      make(map[bin.Address]*dataAccess),
+		structs:       make(map[string]*recoveredStruct),
+		structGlobals: make(map[bin.Address]structLayout),
+		globalSigns:   make(map[bin.Address]signedness),
+		enums:         make(map[string]*recoveredEnum),
+		globalEnums:   make(map[bin.Address]*recoveredEnum),
+		addrTaken:     make(map[bin.Address]bool),
+		constStores:   make(map[bin.Address]bool),
 	}
 
 	// Parse associated LLVM IR information.
@@ -122,8 +242,9 @@ func NewLifter(file *bin.File) (*Lifter, error) {
 
 	// Parse imports.
 	addFunc := func(entry bin.Address, name string) {
-		// TODO: Mark function signature as unknown (using metadata), so that type
-		// analysis may replace it.
+		// Placeholder signatures of imports are refined using signature
+		// databases; parameter inference is only conducted for functions with
+		// bodies.
 		sig := types.NewFunc(types.Void)
 		typ := types.NewPointer(sig)
 		f := &ir.Function{
@@ -137,32 +258,184 @@ func NewLifter(file *bin.File) (*Lifter, error) {
 			},
 		}
 		fn := &Func{
-			Function: f,
+			Function:   f,
+			sigUnknown: true,
 		}
 		l.Funcs[entry] = fn
 	}
-	for entry, fname := range l.File.Imports {
+	imports := make(map[string]*Func)
+	for _, entry := range sortedAddrs(l.File.Imports) {
+		fname := l.File.Imports[entry]
 		if _, ok := l.Funcs[entry]; ok {
 			// Skip import if already specified through function signature.
 			continue
 		}
+		// Imports located at several addresses (e.g. PLT stub and GOT entry)
+		// share the same function.
+		if fn, ok := imports[fname]; ok {
+			l.Funcs[entry] = fn
+			continue
+		}
 		addFunc(entry, fname)
+		imports[fname] = l.Funcs[entry]
+	}
+
+	// Parse data imports.
+	for _, entry := range sortedAddrs(l.File.DataImports) {
+		name := l.File.DataImports[entry]
+		if _, ok := l.Globals[entry]; ok {
+			// Skip data import if already specified through global variable.
+			continue
+		}
+		l.addDataImport(entry, name)
+	}
+
+	// Demangle names of Rust functions; mangled names are retained if the
+	// demangled name is not unique (e.g. generic instantiations).
+	demangled := make(map[string]bool)
+	funcName := func(fname string) string {
+		name, ok := demangle.Rust(fname)
+		if !ok || demangled[name] {
+			return fname
+		}
+		demangled[name] = true
+		return name
 	}
 
 	// Parse exports.
-	for entry, fname := range dis.File.Exports {
+	for _, entry := range sortedAddrs(dis.File.Exports) {
+		fname := dis.File.Exports[entry]
 		if _, ok := l.Funcs[entry]; ok {
 			// Skip export if already specified through function signature.
 			continue
 		}
+		addFunc(entry, funcName(fname))
+	}
+
+	// Parse names of functions of the symbol table (e.g. recovered from the
+	// pclntab of Go executables).
+	for _, entry := range sortedAddrs(dis.File.Symbols) {
+		fname := dis.File.Symbols[entry]
+		if _, ok := l.Funcs[entry]; ok || !l.File.IsCode(entry) {
+			continue
+		}
+		addFunc(entry, funcName(fname))
+	}
+
+	// Parse additional entry points (e.g. TLS callbacks and DllMain).
+	for _, entry := range sortedAddrs(dis.File.Roots) {
+		fname := dis.File.Roots[entry]
+		if _, ok := l.Funcs[entry]; ok {
+			continue
+		}
 		addFunc(entry, fname)
 	}
 
+	// Locate thunks; names of thunks (e.g. exports) are propagated to unnamed
+	// targets, and otherwise recorded in NamedThunks.
+	l.Thunks = l.findThunks()
+	var thunks bin.Addresses
+	for thunk := range l.Thunks {
+		thunks = append(thunks, thunk)
+	}
+	sort.Sort(thunks)
+	for _, thunk := range thunks {
+		target := l.Thunks[thunk]
+		fn, ok := l.Funcs[thunk]
+		if !ok {
+			continue
+		}
+		delete(l.Funcs, thunk)
+		if tf, ok := l.Funcs[target]; !ok {
+			addFunc(target, fn.Name)
+		} else if tf.Name != fn.Name {
+			l.NamedThunks[thunk] = fn.Name
+		}
+	}
+
+	// Locate main function called from the startup code of the C runtime.
+	if entry, fname, ok := l.locateMain(); ok {
+		entry = l.ResolveThunk(entry)
+		l.Main = entry
+		l.FuncAddrs = bin.InsertAddr(l.FuncAddrs, entry)
+		if _, ok := l.Funcs[entry]; !ok {
+			addFunc(entry, fname)
+		}
+	}
+
+	// Locate default segments of 16-bit real mode code.
+	if l.Mode == 16 && l.File.RealMode != nil {
+		l.segs = l.realModeSegs()
+	}
+
+	// Refine placeholder signatures of imports based on bundled signature
+	// databases.
+	if err := l.addBundledProtos(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Parse user-supplied function signatures.
+	sigs, err := parseSigs("sigs.json")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := l.AddSigs(sigs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Apply user-supplied symbol names and comments.
+	l.applyNames()
+
 	return l, nil
 }
 
+// Lift decodes and lifts the function at the given address to LLVM IR. A
+// function lifter is created for the function, unless already present.
+//
+// Signatures of functions without known signature are inferred based on the
+// function lifters created prior to the call; to lift several functions, create
+// function lifters for each of them first (using Lifter.NewFunc).
+func (l *Lifter) Lift(addr bin.Address) (*ir.Function, error) {
+	return l.LiftContext(context.Background(), addr)
+}
+
+// LiftContext decodes and lifts the function at the given address to LLVM IR.
+// An error is returned if the context is cancelled before lifting completes.
+func (l *Lifter) LiftContext(ctx context.Context, addr bin.Address) (fn *ir.Function, err error) {
+	f, ok := l.Funcs[addr]
+	if !ok || f.AsmFunc == nil {
+		asmFunc, err := l.DecodeFuncContext(ctx, addr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		f = l.NewFunc(asmFunc)
+		l.Funcs[addr] = f
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("unable to lift function at %v; %v", addr, e)
+		}
+	}()
+	if err := f.LiftContext(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return f.Function, nil
+}
+
 // ### [ Helper functions ] ####################################################
 
+// sortedAddrs returns the addresses of the given symbols, sorted in ascending
+// order. Symbols are processed in address order to ensure deterministic
+// output; e.g. when several symbols share a name.
+func sortedAddrs(syms map[bin.Address]string) bin.Addresses {
+	var addrs bin.Addresses
+	for addr := range syms {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	return addrs
+}
+
 // parseModule parses and returns the given LLVM IR module.
 func parseModule(llPath string) (*ir.Module, error) {
 	if !osutil.Exists(llPath) {
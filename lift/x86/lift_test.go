@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/decomp/exp/bin"
@@ -13,6 +14,7 @@ import (
 	_ "github.com/decomp/exp/bin/pef" // register PEF decoder
 	"github.com/decomp/exp/bin/raw"
 	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
 	"github.com/pkg/errors"
 )
 
@@ -26,6 +28,10 @@ func TestLift(t *testing.T) {
 		out string
 		// Raw machine architecture; or 0 if any format other than raw.
 		arch bin.Arch
+		// Lift DIV and IDIV instructions with checks of divide error exceptions.
+		trapDiv bool
+		// Infer function attributes of the lifted functions.
+		attrs bool
 	}{
 		// File formats.
 		//
@@ -53,6 +59,29 @@ func TestLift(t *testing.T) {
 		{dir: "testdata/x86_32/import", in: "import.out", out: "import.ll"},
 		{dir: "testdata/x86_64/import", in: "import.out", out: "import.ll"},
 
+		// Parameter inference.
+		{dir: "testdata/x86_32/params", in: "params.so", out: "params.ll"},
+		{dir: "testdata/x86_64/params", in: "params.so", out: "params.ll"},
+		{dir: "testdata/x86_16/pascal", in: "pascal.bin", out: "pascal.ll", arch: bin.ArchX86_16},
+
+		// Return type inference.
+		{dir: "testdata/x86_32/ret", in: "ret.so", out: "ret.ll"},
+
+		// CMP+Jcc fusion.
+		{dir: "testdata/x86_32/fuse", in: "fuse.so", out: "fuse.ll"},
+
+		// Basic block splitting.
+		{dir: "testdata/x86_32/split", in: "split.so", out: "split.ll"},
+
+		// Value-set analysis.
+		{dir: "testdata/x86_32/vsa", in: "vsa.so", out: "vsa.ll"},
+
+		// Traps and divide error exceptions.
+		{dir: "testdata/x86_32/trap", in: "trap.so", out: "trap.ll", trapDiv: true},
+
+		// Function attribute inference.
+		{dir: "testdata/x86_32/attrs", in: "attrs.so", out: "attrs.ll", attrs: true},
+
 		// === [ FPU instructions ] ==============================================
 		//
 		// --- [ x87 FPU Data Transfer Instructions ] ----------------------------
@@ -126,6 +155,7 @@ func TestLift(t *testing.T) {
 			t.Errorf("%q: unable to prepare lifter; %+v", in, err)
 			continue
 		}
+		l.TrapDiv = g.trapDiv
 
 		// Create function lifters.
 		for _, funcAddr := range l.FuncAddrs {
@@ -140,6 +170,7 @@ func TestLift(t *testing.T) {
 
 		// Lift functions.
 		module := &ir.Module{}
+		var funcs []*Func
 		for _, funcAddr := range l.FuncAddrs {
 			f, ok := l.Funcs[funcAddr]
 			if !ok {
@@ -147,6 +178,7 @@ func TestLift(t *testing.T) {
 			}
 			f.Lift()
 			module.Funcs = append(module.Funcs, f.Function)
+			funcs = append(funcs, f)
 		}
 		buf, err := ioutil.ReadFile(g.out)
 		if err != nil {
@@ -154,6 +186,10 @@ func TestLift(t *testing.T) {
 			continue
 		}
 		got := module.String()
+		if g.attrs {
+			l.InferAttrs(funcs)
+			got = l.FormatModule(module)
+		}
 		want := string(buf)
 		if got != want {
 			t.Errorf("%q: module mismatch; expected `%v`, got `%v`", in, want, got)
@@ -162,6 +198,243 @@ func TestLift(t *testing.T) {
 	}
 }
 
+func TestCheckStack(t *testing.T) {
+	const dir = "testdata/x86_32/stack"
+	l, err := newFuncs(dir, "stack.so")
+	if err != nil {
+		t.Fatalf("%q: unable to create function lifters; %+v", dir, err)
+	}
+	golden := []struct {
+		// Function entry address.
+		entry bin.Address
+		// Expected stack imbalances.
+		want []StackImbalance
+	}{
+		// balanced
+		{entry: 0x10000000},
+		// imbalance; `push ebx` followed by `ret`.
+		{entry: 0x10000007, want: []StackImbalance{{Addr: 0x10000008, Kind: ImbalanceReturn, Got: -4, Want: 0}}},
+		// purge; `ret 4` of stdcall function with two parameters.
+		{entry: 0x10000009, want: []StackImbalance{{Addr: 0x1000000D, Kind: ImbalancePurge, Got: 4, Want: 8}}},
+		// merge; `push eax` along one of the paths joining at .skip.
+		{entry: 0x10000010, want: []StackImbalance{{Addr: 0x10000015, Kind: ImbalanceMerge, Got: -4, Want: 0}}},
+	}
+	for _, g := range golden {
+		f, ok := l.Funcs[g.entry]
+		if !ok {
+			t.Errorf("%q: unable to locate function at %v", dir, g.entry)
+			continue
+		}
+		if got := f.CheckStack(); !reflect.DeepEqual(got, g.want) {
+			t.Errorf("%q: stack imbalances of function %q mismatch; expected %v, got %v", dir, f.Name, g.want, got)
+		}
+	}
+}
+
+func TestRecoverStructs(t *testing.T) {
+	const dir = "testdata/x86_32/structs"
+	l, err := newFuncs(dir, "structs.so")
+	if err != nil {
+		t.Fatalf("%q: unable to create function lifters; %+v", dir, err)
+	}
+	const (
+		object = bin.Address(0x10000000)
+		point  = bin.Address(0x30000000)
+	)
+
+	// Heap object; fields at offsets 4 and 8 of ECX, preceded by padding.
+	f := l.Funcs[object]
+	want := map[bin.Address]int64{0x10000000: 4, 0x10000003: 8}
+	if len(f.fieldAccesses) != len(want) {
+		t.Errorf("%q: field accesses mismatch; expected %d accesses, got %d", dir, len(want), len(f.fieldAccesses))
+	}
+	for addr, off := range want {
+		fa, ok := f.fieldAccesses[addr]
+		if !ok {
+			t.Errorf("%q: unable to locate field access at %v", dir, addr)
+			continue
+		}
+		if fa.off != off {
+			t.Errorf("%q: field offset mismatch at %v; expected %d, got %d", dir, addr, off, fa.off)
+		}
+		if got := fa.rs.typ.Name; got != "struct.obj_0" {
+			t.Errorf("%q: struct type mismatch at %v; expected %q, got %q", dir, addr, "struct.obj_0", got)
+		}
+		// Field indices of { [4 x i8], i32, i32 }.
+		fields := map[int64]int{4: 1, 8: 2}
+		if !reflect.DeepEqual(fa.rs.fields, fields) || len(fa.rs.typ.Fields) != 3 {
+			t.Errorf("%q: struct fields mismatch at %v; expected %v of 3 fields, got %v of %d fields", dir, addr, fields, fa.rs.fields, len(fa.rs.typ.Fields))
+		}
+	}
+
+	// Global variable; fields at offsets 0 and 4 of point.
+	layout := structLayout{0: 4, 4: 2}
+	if got := l.structGlobals[point]; !reflect.DeepEqual(got, layout) {
+		t.Errorf("%q: struct layout of global variable mismatch; expected %v, got %v", dir, layout, got)
+	}
+	l.inferGlobals()
+	g, ok := l.Globals[point]
+	if !ok {
+		t.Fatalf("%q: unable to locate global variable at %v", dir, point)
+	}
+	// { i32, i16 }
+	st, ok := g.Content.(*types.StructType)
+	if !ok || len(st.Fields) != 2 || !types.Equal(st.Fields[0], types.I32) || !types.Equal(st.Fields[1], types.I16) {
+		t.Errorf("%q: type of global variable mismatch; expected %v, got %v", dir, "{ i32, i16 }", g.Content)
+	}
+}
+
+func TestArrayStrides(t *testing.T) {
+	const dir = "testdata/x86_32/arrays"
+	l, err := newFuncs(dir, "arrays.so")
+	if err != nil {
+		t.Fatalf("%q: unable to create function lifters; %+v", dir, err)
+	}
+	l.inferGlobals()
+	golden := []struct {
+		// Global variable address.
+		addr bin.Address
+		// Expected element type.
+		elem types.Type
+		// Expected number of elements.
+		n int64
+	}{
+		// table; `mov eax, [4*ecx+table]`, up to words.
+		{addr: 0x20000000, elem: types.I32, n: 4},
+		// words; `movzx eax, word [2*ecx+words]`, up to the end of .data.
+		{addr: 0x20000010, elem: types.I16, n: 3},
+	}
+	for _, g := range golden {
+		global, ok := l.Globals[g.addr]
+		if !ok {
+			t.Errorf("%q: unable to locate global variable at %v", dir, g.addr)
+			continue
+		}
+		arr, ok := global.Content.(*types.ArrayType)
+		if !ok || !types.Equal(arr.Elem, g.elem) || arr.Len != g.n {
+			t.Errorf("%q: type of global variable at %v mismatch; expected [%d x %v], got %v", dir, g.addr, g.n, g.elem, global.Content)
+		}
+	}
+}
+
+func TestInferSignedness(t *testing.T) {
+	const dir = "testdata/x86_32/signedness"
+	l, err := newFuncs(dir, "signedness.so")
+	if err != nil {
+		t.Fatalf("%q: unable to create function lifters; %+v", dir, err)
+	}
+	const signs = bin.Address(0x10000000)
+	globalSigns := map[bin.Address]signedness{
+		// count; `cmp dword [count], 10` followed by `jg`.
+		0x30000000: signSigned,
+		// limit; `mov eax, [limit]` followed by `cmp eax, 10` and `ja`.
+		0x30000004: signUnsigned,
+		// flags; `jl` and `shr`.
+		0x30000008: signConflict,
+	}
+	if !reflect.DeepEqual(l.globalSigns, globalSigns) {
+		t.Errorf("%q: signedness of global variables mismatch; expected %v, got %v", dir, globalSigns, l.globalSigns)
+	}
+	// Stack slot; `mov ecx, [esp+4]` followed by `sar ecx, 1`.
+	slotSigns := map[bin.Address]signedness{0x10000013: signSigned}
+	if got := l.Funcs[signs].signs; !reflect.DeepEqual(got, slotSigns) {
+		t.Errorf("%q: signedness of stack slots mismatch; expected %v, got %v", dir, slotSigns, got)
+	}
+}
+
+func TestRecoverEnums(t *testing.T) {
+	const dir = "testdata/x86_32/enums"
+	l, err := newFuncs(dir, "enums.so")
+	if err != nil {
+		t.Fatalf("%q: unable to create function lifters; %+v", dir, err)
+	}
+	const (
+		classify = bin.Address(0x10000029)
+		state    = bin.Address(0x30000000)
+	)
+
+	// Global variable; if-else chain of dispatch.
+	want := &recoveredEnum{name: "enum_0", vals: []int64{1, 2, 3}}
+	if got := l.globalEnums[state]; !reflect.DeepEqual(got, want) {
+		t.Errorf("%q: enum of global variable mismatch; expected %v, got %v", dir, want, got)
+	}
+
+	// Stack slot; if-else chain of classify, recorded at the first comparison.
+	enums := map[bin.Address]*recoveredEnum{
+		0x10000029: {name: "enum_1", vals: []int64{0, 1, 2}},
+	}
+	if got := l.Funcs[classify].enums; !reflect.DeepEqual(got, enums) {
+		t.Errorf("%q: enums of stack slots mismatch; expected %v, got %v", dir, enums, got)
+	}
+}
+
+func TestInferFloats(t *testing.T) {
+	const dir = "testdata/x86_32/floats"
+	l, err := newFuncs(dir, "floats.so")
+	if err != nil {
+		t.Fatalf("%q: unable to create function lifters; %+v", dir, err)
+	}
+	const scale = bin.Address(0x10000000)
+
+	// Stack slot; `fmul dword [esp+4]`.
+	floatSlots := map[string]*types.FloatType{"esp_4": types.Float}
+	if got := l.Funcs[scale].floatSlots; !reflect.DeepEqual(got, floatSlots) {
+		t.Errorf("%q: floating-point stack slots mismatch; expected %v, got %v", dir, floatSlots, got)
+	}
+
+	// Global variables.
+	l.inferGlobals()
+	golden := []struct {
+		// Global variable address.
+		addr bin.Address
+		// Expected type.
+		want types.Type
+	}{
+		// factor; `fld dword [factor]` and `fstp dword [factor]`.
+		{addr: 0x20000000, want: types.Float},
+		// ratio; `fmul qword [ratio]`.
+		{addr: 0x20000004, want: types.Double},
+		// count; `mov eax, [count]`.
+		{addr: 0x2000000C, want: types.I32},
+	}
+	for _, g := range golden {
+		global, ok := l.Globals[g.addr]
+		if !ok {
+			t.Errorf("%q: unable to locate global variable at %v", dir, g.addr)
+			continue
+		}
+		if !types.Equal(global.Content, g.want) {
+			t.Errorf("%q: type of global variable at %v mismatch; expected %v, got %v", dir, g.addr, g.want, global.Content)
+		}
+	}
+}
+
+// newFuncs returns a new x86 to LLVM IR lifter for the given binary executable
+// of the specified base directory, with function lifters created for each
+// function of the executable.
+func newFuncs(dir, path string) (*Lifter, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer os.Chdir(wd)
+	l, err := newLifter(path, 0)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	return l, nil
+}
+
 // newLifter returns a new x86 to LLVM IR lifter for the given binary
 // executable.
 func newLifter(path string, arch bin.Arch) (*Lifter, error) {
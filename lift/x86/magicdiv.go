@@ -0,0 +1,901 @@
+package x86
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// maxMagicInsts specifies the maximum number of instructions of division
+// idioms.
+const maxMagicInsts = 12
+
+// A magicDiv is a division by a constant, as lowered by compilers to a
+// multiplication by a magic number and shifts; e.g. unsigned x / 10 lowered to
+//
+//    mov  eax, 0xCCCCCCCD
+//    mul  ecx
+//    shr  edx, 3
+type magicDiv struct {
+	// Number of instructions of the idiom.
+	n int
+	// First instruction of the idiom.
+	inst *x86.Inst
+	// Final values of the registers defined by the idiom, in terms of the
+	// values of registers and memory at the start of the idiom.
+	regs map[x86asm.Reg]*sym
+}
+
+// matchMagicDiv reports whether the instructions of the given basic block,
+// starting at index i, contain a division by a constant, as lowered by MSVC,
+// GCC and Clang to a multiplication by a magic number.
+//
+// The instructions are evaluated symbolically, and the values of registers
+// simplified using rewrite rules which are exact (e.g. floor(floor(x*m/2^k)/2^s)
+// = floor(x*m/2^(k+s))), until a register holds floor(x*m/2^k) (unsigned) or
+// floor(x*m/2^k) plus the sign bit of x (signed); and m is verified to compute
+// the exact quotient x/d for every dividend x (see magicDivisor). The idiom
+// spans until the last instruction defining a quotient, and is only folded if
+// the status flags defined by the idiom are dead, as they are not reproduced.
+func (f *Func) matchMagicDiv(bb *x86.BasicBlock, i int) (*magicDiv, bool) {
+	s := newSymState(f.l)
+	var (
+		// Registers read and defined by each instruction.
+		uses, defs []map[x86asm.Reg]bool
+		// Index of the last instruction defining a quotient.
+		end = -1
+		// Register values after the last instruction defining a quotient.
+		regs map[x86asm.Reg]*sym
+	)
+	for j := i; j < len(bb.Insts) && j < i+maxMagicInsts; j++ {
+		s.uses, s.defs = make(map[x86asm.Reg]bool), make(map[x86asm.Reg]bool)
+		if !s.step(bb.Insts[j]) {
+			break
+		}
+		uses = append(uses, s.uses)
+		defs = append(defs, s.defs)
+		for reg := range s.defs {
+			if isQuot(s.regs[reg]) {
+				end = j
+				regs = make(map[x86asm.Reg]*sym)
+				for reg, v := range s.regs {
+					regs[reg] = v
+				}
+				break
+			}
+		}
+	}
+	if end == -1 {
+		return nil, false
+	}
+	// The first instruction is part of the idiom if a register it defines is
+	// used by the idiom; e.g. the magic number or dividend of MUL.
+	related := false
+	for reg := range defs[0] {
+		for _, u := range uses[1 : end-i+1] {
+			if u[reg] {
+				related = true
+			}
+		}
+	}
+	if !related || f.flagsLiveAfter(bb, end+1) {
+		return nil, false
+	}
+	return &magicDiv{n: end - i + 1, inst: bb.Insts[i], regs: regs}, true
+}
+
+// liftMagicDiv lifts the given division idiom to LLVM IR, emitting code to f.
+// The registers defined by the idiom are assigned their final values.
+func (f *Func) liftMagicDiv(div *magicDiv) {
+	var regs []x86asm.Reg
+	for reg := range div.regs {
+		regs = append(regs, reg)
+	}
+	less := func(i, j int) bool {
+		return regs[i] < regs[j]
+	}
+	sort.Slice(regs, less)
+	// Compute the final values of all registers before defining any, as the
+	// values are expressed in terms of the registers at the start of the idiom.
+	lowered := make(map[*sym]value.Value)
+	var vs []value.Value
+	for _, reg := range regs {
+		vs = append(vs, f.lowerSym(div.regs[reg], div.inst, lowered))
+	}
+	for i, reg := range regs {
+		f.defReg(x86.NewReg(reg, div.inst), vs[i])
+	}
+}
+
+// flagsLiveAfter reports whether the status flags may be read after the i:th
+// instruction of the given basic block, before being defined.
+func (f *Func) flagsLiveAfter(bb *x86.BasicBlock, i int) bool {
+	for _, inst := range bb.Insts[i:] {
+		switch inst.Op {
+		case x86asm.CMP, x86asm.TEST:
+			return false
+		}
+		if readsFlags(inst) {
+			return true
+		}
+	}
+	switch {
+	case isReturn(bb.Term):
+		return false
+	case bb.Term.IsDummyTerm(), bb.Term.Op == x86asm.JMP:
+		for _, succ := range f.succs(bb) {
+			if f.flagsLiveIn(f.AsmFunc.Blocks[succ]) {
+				return true
+			}
+		}
+		return false
+	}
+	// Conditional terminator.
+	return true
+}
+
+// magicDivisor returns the divisor of the division of w-bit integers by the
+// given magic number followed by a right shift of k bits. The boolean return
+// value indicates whether the magic number computes the exact quotient for
+// every dividend.
+//
+// Given m*d = 2^k + e, the product x*m/2^k equals x/d + x*e/(d*2^k); the
+// quotient is exact for every unsigned x < 2^w if 0 <= e and (2^w-1)*e < 2^k,
+// and for every signed x (rounding towards zero by adding the sign bit) if
+// 0 < e and 2^(w-1)*e < 2^k.
+func magicDivisor(m *big.Int, k, w uint, signed bool) (uint64, bool) {
+	if m.Sign() <= 0 {
+		return 0, false
+	}
+	pow := new(big.Int).Lsh(big.NewInt(1), k)
+	// d = ceil(2^k / m)
+	d := new(big.Int).Sub(pow, big.NewInt(1))
+	d.Div(d, m)
+	d.Add(d, big.NewInt(1))
+	if d.Cmp(big.NewInt(2)) < 0 || d.BitLen() >= int(w) || d.BitLen() > 63 {
+		return 0, false
+	}
+	e := new(big.Int).Mul(m, d)
+	e.Sub(e, pow)
+	xmax := new(big.Int).Lsh(big.NewInt(1), w)
+	if signed {
+		if e.Sign() <= 0 {
+			return 0, false
+		}
+		xmax.Rsh(xmax, 1)
+	} else {
+		if e.Sign() < 0 {
+			return 0, false
+		}
+		xmax.Sub(xmax, big.NewInt(1))
+	}
+	if xmax.Mul(xmax, e).Cmp(pow) >= 0 {
+		return 0, false
+	}
+	return d.Uint64(), true
+}
+
+// === [ Symbolic values ] =====================================================
+
+// symOp is the operation of a symbolic value.
+type symOp uint8
+
+// Operations of symbolic values.
+const (
+	// Value of register at the start of the idiom (reg).
+	symIn symOp = iota
+	// Value of memory operand at the start of the idiom (arg).
+	symMem
+	// Constant (c).
+	symConst
+	// Conversions (a).
+	symZExt
+	symSExt
+	symTrunc
+	// Binary operations (a, b).
+	symAdd
+	symSub
+	symMul
+	// Shifts (a, s).
+	symShl
+	symLShr
+	symAShr
+	// floor(x*m/2^k), where x is a (signed or unsigned), m is c and k is s.
+	symMulShr
+	// x/d rounded towards zero, where x is a (signed or unsigned) and d is d.
+	symQuot
+	// Sign bit of a (0 or 1).
+	symSign
+	// Sign mask of a (0 or -1).
+	symMask
+)
+
+// A sym is a symbolic value of an integer of w bits.
+type sym struct {
+	// Operation.
+	op symOp
+	// Size in number of bits.
+	w uint
+	// Operands.
+	a, b *sym
+	// Register of symIn.
+	reg x86asm.Reg
+	// Memory operand of symMem.
+	arg *x86.Arg
+	// Constant of symConst (unsigned), and magic number of symMulShr (signed).
+	c *big.Int
+	// Shift amount.
+	s uint
+	// Divisor of symQuot.
+	d uint64
+	// Signed operands of symMulShr and symQuot.
+	signed bool
+}
+
+// isQuot reports whether the given symbolic value is a quotient, or an
+// extension of a quotient.
+func isQuot(v *sym) bool {
+	if v == nil {
+		return false
+	}
+	switch v.op {
+	case symZExt, symSExt:
+		return isQuot(v.a)
+	case symQuot:
+		return true
+	case symMulShr:
+		_, ok := v.quot()
+		return ok
+	}
+	return false
+}
+
+// quot returns the divisor of the unsigned division computed by the given
+// symMulShr value. The boolean return value indicates success.
+func (v *sym) quot() (uint64, bool) {
+	if v.signed {
+		return 0, false
+	}
+	return magicDivisor(v.c, v.s, v.a.w, false)
+}
+
+// symEqual reports whether the given symbolic values are structurally equal.
+func symEqual(v, u *sym) bool {
+	if v == u {
+		return true
+	}
+	if v == nil || u == nil || v.op != u.op || v.w != u.w || v.reg != u.reg || v.s != u.s || v.d != u.d || v.signed != u.signed {
+		return false
+	}
+	if (v.c == nil) != (u.c == nil) || v.c != nil && v.c.Cmp(u.c) != 0 {
+		return false
+	}
+	if (v.arg == nil) != (u.arg == nil) || v.arg != nil && v.arg.Arg != u.arg.Arg {
+		return false
+	}
+	return symEqual(v.a, u.a) && symEqual(v.b, u.b)
+}
+
+// fits reports whether the given symMulShr value fits within the size of its
+// operand x; i.e. |m| < 2^k, and thus |floor(x*m/2^k)| <= |x|.
+func (v *sym) fits() bool {
+	abs := new(big.Int).Abs(v.c)
+	return abs.BitLen() <= int(v.s)
+}
+
+// signOf returns the value with the same sign as the given value; the operand x
+// of signed symMulShr values with positive m, and the value itself otherwise.
+func signOf(v *sym) *sym {
+	if v.op == symMulShr && v.signed && v.c.Sign() > 0 && v.fits() && v.w == v.a.w {
+		return v.a
+	}
+	return v
+}
+
+// --- [ Constructors ] --------------------------------------------------------
+
+// symConstant returns a constant of w bits.
+func symConstant(c *big.Int, w uint) *sym {
+	mod := new(big.Int).Lsh(big.NewInt(1), w)
+	x := new(big.Int).Mod(c, mod)
+	return &sym{op: symConst, w: w, c: x}
+}
+
+// signedConst returns the signed value of the given constant.
+func (v *sym) signedConst() *big.Int {
+	x := new(big.Int).Set(v.c)
+	if x.Bit(int(v.w)-1) == 1 {
+		x.Sub(x, new(big.Int).Lsh(big.NewInt(1), v.w))
+	}
+	return x
+}
+
+// symZExtTo returns v zero-extended to w bits.
+func symZExtTo(v *sym, w uint) *sym {
+	switch {
+	case v.w == w:
+		return v
+	case v.w > w:
+		return symTruncTo(v, w)
+	case v.op == symConst:
+		return symConstant(v.c, w)
+	}
+	return &sym{op: symZExt, w: w, a: v}
+}
+
+// symSExtTo returns v sign-extended to w bits.
+func symSExtTo(v *sym, w uint) *sym {
+	switch {
+	case v.w == w:
+		return v
+	case v.w > w:
+		return symTruncTo(v, w)
+	case v.op == symConst:
+		return symConstant(v.signedConst(), w)
+	}
+	return &sym{op: symSExt, w: w, a: v}
+}
+
+// symTruncTo returns v truncated to w bits.
+func symTruncTo(v *sym, w uint) *sym {
+	switch {
+	case v.w == w:
+		return v
+	case v.op == symConst:
+		return symConstant(v.c, w)
+	case v.op == symZExt || v.op == symSExt:
+		switch {
+		case v.a.w == w:
+			return v.a
+		case v.a.w > w:
+			return symTruncTo(v.a, w)
+		}
+		ext := *v
+		ext.w = w
+		return &ext
+	case v.op == symMulShr && v.fits() && v.a.w <= w,
+		v.op == symQuot && v.a.w <= w,
+		v.op == symSign, v.op == symMask:
+		// The value fits within the size of the operand x.
+		t := *v
+		t.w = w
+		return &t
+	case v.op == symLShr && v.a.op == symMul && v.a.a.op == symSExt && v.a.b.op == symConst && v.a.a.a.w == w:
+		// The low w bits of the high part of a signed product of x, which fits
+		// within the product; trunc(lshr(sext(x)*c, s)) = floor(x*c/2^s) if
+		// |c| < 2^s.
+		c := v.a.b.signedConst()
+		if c.Sign() != 0 && c.CmpAbs(new(big.Int).Lsh(big.NewInt(1), v.s)) < 0 && int(w)+c.BitLen()+1 <= int(v.w) {
+			return &sym{op: symMulShr, w: w, a: v.a.a.a, c: c, s: v.s, signed: true}
+		}
+	}
+	return &sym{op: symTrunc, w: w, a: v}
+}
+
+// symBinary returns the binary operation of the given operands.
+func symBinary(op symOp, a, b *sym) *sym {
+	if a.op == symConst && b.op == symConst {
+		x := new(big.Int)
+		switch op {
+		case symAdd:
+			x.Add(a.c, b.c)
+		case symSub:
+			x.Sub(a.c, b.c)
+		case symMul:
+			x.Mul(a.c, b.c)
+		}
+		return symConstant(x, a.w)
+	}
+	switch {
+	case b.op == symConst && b.c.Sign() == 0 && (op == symAdd || op == symSub):
+		return a
+	case b.op == symConst && b.c.Cmp(big.NewInt(1)) == 0 && op == symMul:
+		return a
+	case a.op == symConst && a.c.Cmp(big.NewInt(1)) == 0 && op == symMul:
+		return b
+	}
+	switch op {
+	case symAdd:
+		if v, ok := symAddRule(a, b); ok {
+			return v
+		}
+		if v, ok := symAddRule(b, a); ok {
+			return v
+		}
+	case symSub:
+		// floor(x*m/2^k) - mask(x) = x/d
+		if a.op == symMulShr && a.signed && b.op == symMask && symEqual(b.a, a.a) {
+			if v, ok := symSignedQuot(a); ok {
+				return v
+			}
+		}
+	case symMul:
+		if a.op == symConst {
+			a, b = b, a
+		}
+	}
+	return &sym{op: op, w: a.w, a: a, b: b}
+}
+
+// symAddRule applies the rewrite rules of additions to a + b.
+func symAddRule(a, b *sym) (*sym, bool) {
+	if a.op != symMulShr && a.op != symLShr {
+		return nil, false
+	}
+	switch {
+	case a.op == symMulShr && symEqual(b, a.a) && a.w == b.w:
+		// floor(x*m/2^k) + x = floor(x*(m+2^k)/2^k)
+		m := new(big.Int).Add(a.c, new(big.Int).Lsh(big.NewInt(1), a.s))
+		v := &sym{op: symMulShr, w: a.w, a: a.a, c: m, s: a.s, signed: a.signed}
+		if v.fits() {
+			return v, true
+		}
+	case a.op == symMulShr && a.signed && b.op == symSign && symEqual(b.a, a.a):
+		// floor(x*m/2^k) + sign(x) = x/d
+		return symSignedQuot(a)
+	case a.op == symLShr && a.s == 1 && a.a.op == symSub && b.op == symMulShr && !b.signed && b.w == b.a.w:
+		// floor((x - h)/2) + h = floor(x*(m+2^k)/2^(k+1)), where
+		// h = floor(x*m/2^k)
+		sub := a.a
+		if symEqual(sub.a, b.a) && symEqual(sub.b, b) && b.fits() {
+			m := new(big.Int).Add(b.c, new(big.Int).Lsh(big.NewInt(1), b.s))
+			return &sym{op: symMulShr, w: b.w, a: b.a, c: m, s: b.s + 1}, true
+		}
+	}
+	return nil, false
+}
+
+// symSignedQuot returns the signed quotient computed by adding the sign bit of
+// x to the given signed symMulShr value. The boolean return value indicates
+// success.
+func symSignedQuot(v *sym) (*sym, bool) {
+	if v.w != v.a.w {
+		return nil, false
+	}
+	d, ok := magicDivisor(v.c, v.s, v.a.w, true)
+	if !ok {
+		return nil, false
+	}
+	return &sym{op: symQuot, w: v.w, a: v.a, d: d, signed: true}, true
+}
+
+// symShift returns the shift of v by the given constant amount.
+func symShift(op symOp, v *sym, s uint) *sym {
+	switch {
+	case s == 0:
+		return v
+	case s >= v.w:
+		if op == symAShr {
+			s = v.w - 1
+		} else {
+			return symConstant(big.NewInt(0), v.w)
+		}
+	}
+	switch op {
+	case symShl:
+		if v.op == symConst {
+			return symConstant(new(big.Int).Lsh(v.c, s), v.w)
+		}
+	case symLShr:
+		switch {
+		case v.op == symConst:
+			return symConstant(new(big.Int).Rsh(v.c, s), v.w)
+		case v.op == symMulShr && !v.signed && v.w == v.a.w:
+			// floor(floor(x*m/2^k)/2^s) = floor(x*m/2^(k+s))
+			t := *v
+			t.s += s
+			return &t
+		case v.op == symMul && v.a.op == symZExt && v.b.op == symConst && int(v.a.a.w)+v.b.c.BitLen() <= int(v.w):
+			// The unsigned product of x fits within v.
+			return &sym{op: symMulShr, w: v.w, a: v.a.a, c: new(big.Int).Set(v.b.c), s: s}
+		case s == v.w-1 && signOf(v).w == v.w:
+			return &sym{op: symSign, w: v.w, a: signOf(v)}
+		}
+	case symAShr:
+		switch {
+		case v.op == symConst:
+			return symConstant(new(big.Int).Rsh(v.signedConst(), s), v.w)
+		case v.op == symMulShr && v.signed && v.w == v.a.w:
+			t := *v
+			t.s += s
+			return &t
+		case v.op == symMul && v.a.op == symSExt && v.b.op == symConst:
+			// The signed product of x fits within v.
+			c := v.b.signedConst()
+			if int(v.a.a.w)+c.BitLen()+1 <= int(v.w) {
+				return &sym{op: symMulShr, w: v.w, a: v.a.a, c: c, s: s, signed: true}
+			}
+		case s == v.w-1 && signOf(v).w == v.w:
+			return &sym{op: symMask, w: v.w, a: signOf(v)}
+		}
+	}
+	return &sym{op: op, w: v.w, a: v, s: s}
+}
+
+// --- [ Symbolic evaluation ] -------------------------------------------------
+
+// symState is the state of the symbolic evaluation of instructions.
+type symState struct {
+	// Lifter.
+	l *Lifter
+	// Values of general purpose registers (e.g. EAX, or RAX in 64-bit mode)
+	// defined by the evaluated instructions.
+	regs map[x86asm.Reg]*sym
+	// Registers read and defined by the current instruction.
+	uses, defs map[x86asm.Reg]bool
+}
+
+// newSymState returns a new state for symbolic evaluation.
+func newSymState(l *Lifter) *symState {
+	return &symState{l: l, regs: make(map[x86asm.Reg]*sym)}
+}
+
+// step evaluates the given instruction. The boolean return value reports
+// whether the instruction is supported.
+func (s *symState) step(inst *x86.Inst) bool {
+	switch inst.Op {
+	case x86asm.MOV, x86asm.MOVZX, x86asm.MOVSX, x86asm.MOVSXD:
+		dst, ok := inst.Args[0].(x86asm.Reg)
+		if !ok {
+			return false
+		}
+		w := regBits(dst)
+		v, ok := s.read(inst, inst.Args[1], w)
+		if !ok {
+			return false
+		}
+		if inst.Op == x86asm.MOVSX || inst.Op == x86asm.MOVSXD {
+			v = symSExtTo(v, w)
+		} else {
+			v = symZExtTo(v, w)
+		}
+		return s.write(dst, v)
+	case x86asm.LEA:
+		dst, ok := inst.Args[0].(x86asm.Reg)
+		mem, ok2 := inst.Args[1].(x86asm.Mem)
+		if !ok || !ok2 || mem.Base == x86asm.RIP || mem.Segment != 0 {
+			return false
+		}
+		// The effective address is computed using the address size, and
+		// truncated to the size of the destination.
+		w := uint(s.l.Mode)
+		v := symConstant(big.NewInt(mem.Disp), w)
+		if mem.Index != 0 {
+			index, ok := s.readReg(mem.Index)
+			if !ok {
+				return false
+			}
+			scale := symConstant(big.NewInt(int64(mem.Scale)), w)
+			v = symBinary(symAdd, symBinary(symMul, symZExtTo(index, w), scale), v)
+		}
+		if mem.Base != 0 {
+			base, ok := s.readReg(mem.Base)
+			if !ok {
+				return false
+			}
+			v = symBinary(symAdd, symZExtTo(base, w), v)
+		}
+		return s.write(dst, symTruncTo(v, regBits(dst)))
+	case x86asm.ADD, x86asm.SUB:
+		dst, ok := inst.Args[0].(x86asm.Reg)
+		if !ok {
+			return false
+		}
+		w := regBits(dst)
+		x, ok := s.readReg(dst)
+		if !ok {
+			return false
+		}
+		y, ok := s.read(inst, inst.Args[1], w)
+		if !ok {
+			return false
+		}
+		op := symAdd
+		if inst.Op == x86asm.SUB {
+			op = symSub
+		}
+		return s.write(dst, symBinary(op, x, y))
+	case x86asm.SHR, x86asm.SAR, x86asm.SHL:
+		dst, ok := inst.Args[0].(x86asm.Reg)
+		if !ok {
+			return false
+		}
+		amount, ok := shiftAmount(inst)
+		if !ok {
+			return false
+		}
+		x, ok := s.readReg(dst)
+		if !ok {
+			return false
+		}
+		op := map[x86asm.Op]symOp{x86asm.SHR: symLShr, x86asm.SAR: symAShr, x86asm.SHL: symShl}[inst.Op]
+		return s.write(dst, symShift(op, x, amount))
+	case x86asm.MUL, x86asm.IMUL:
+		switch {
+		case inst.Args[2] != nil:
+			// IMUL r, r/m, imm
+			dst, ok := inst.Args[0].(x86asm.Reg)
+			if !ok {
+				return false
+			}
+			w := regBits(dst)
+			x, ok := s.read(inst, inst.Args[1], w)
+			if !ok {
+				return false
+			}
+			y, ok := s.read(inst, inst.Args[2], w)
+			if !ok {
+				return false
+			}
+			return s.write(dst, symBinary(symMul, x, y))
+		case inst.Args[1] != nil:
+			// IMUL r, r/m
+			dst, ok := inst.Args[0].(x86asm.Reg)
+			if !ok {
+				return false
+			}
+			w := regBits(dst)
+			x, ok := s.readReg(dst)
+			if !ok {
+				return false
+			}
+			y, ok := s.read(inst, inst.Args[1], w)
+			if !ok {
+				return false
+			}
+			return s.write(dst, symBinary(symMul, x, y))
+		}
+		// MUL r/m and IMUL r/m; EDX:EAX = EAX * r/m
+		var a, d x86asm.Reg
+		var w uint
+		switch {
+		case inst.MemBytes == 4 || isReg(inst.Args[0], 32):
+			a, d, w = x86asm.EAX, x86asm.EDX, 32
+		case inst.MemBytes == 8 || isReg(inst.Args[0], 64):
+			a, d, w = x86asm.RAX, x86asm.RDX, 64
+		default:
+			return false
+		}
+		x, ok := s.readReg(a)
+		if !ok {
+			return false
+		}
+		y, ok := s.read(inst, inst.Args[0], w)
+		if !ok {
+			return false
+		}
+		ext := symZExtTo
+		if inst.Op == x86asm.IMUL {
+			ext = symSExtTo
+		}
+		prod := symBinary(symMul, ext(x, 2*w), ext(y, 2*w))
+		hi := symTruncTo(symShift(symLShr, prod, w), w)
+		lo := symBinary(symMul, x, y)
+		return s.write(a, lo) && s.write(d, hi)
+	}
+	return false
+}
+
+// read returns the value of the given argument of w bits; immediates are
+// represented using w bits. The boolean return value indicates success.
+func (s *symState) read(inst *x86.Inst, arg x86asm.Arg, w uint) (*sym, bool) {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		return s.readReg(arg)
+	case x86asm.Imm:
+		return symConstant(big.NewInt(int64(arg)), w), true
+	case x86asm.Mem:
+		// Memory operands are only supported if the registers of the address are
+		// not redefined. RIP-relative memory operands are not supported, as they
+		// are relative to the instruction.
+		if arg.Base == x86asm.RIP {
+			return nil, false
+		}
+		for _, reg := range []x86asm.Reg{arg.Base, arg.Index} {
+			if p := s.l.parentReg(reg); p != 0 {
+				if _, ok := s.regs[p]; ok {
+					return nil, false
+				}
+			}
+		}
+		if inst.MemBytes == 0 {
+			return nil, false
+		}
+		return &sym{op: symMem, w: uint(inst.MemBytes) * 8, arg: x86.NewArg(arg, inst)}, true
+	}
+	return nil, false
+}
+
+// readReg returns the value of the given register. The boolean return value
+// indicates success.
+func (s *symState) readReg(reg x86asm.Reg) (*sym, bool) {
+	parent := s.l.parentReg(reg)
+	if parent == 0 || regBits(parent) == 0 {
+		return nil, false
+	}
+	switch reg {
+	case x86asm.AH, x86asm.CH, x86asm.DH, x86asm.BH:
+		return nil, false
+	}
+	s.uses[parent] = true
+	v, ok := s.regs[parent]
+	if !ok {
+		v = &sym{op: symIn, w: regBits(parent), reg: parent}
+	}
+	return symTruncTo(v, regBits(reg)), true
+}
+
+// write stores the given value to the specified register. The boolean return
+// value indicates success.
+func (s *symState) write(reg x86asm.Reg, v *sym) bool {
+	parent := s.l.parentReg(reg)
+	if parent == 0 || regBits(parent) == 0 {
+		return false
+	}
+	w, pw := regBits(reg), regBits(parent)
+	switch {
+	case w == pw:
+	case w == 32 && pw == 64:
+		// Writes to 32-bit registers zero-extend into 64-bit registers.
+		v = symZExtTo(v, pw)
+	default:
+		// Partial writes of 8- and 16-bit registers.
+		return false
+	}
+	s.defs[parent] = true
+	s.regs[parent] = v
+	return true
+}
+
+// --- [ Lowering ] ------------------------------------------------------------
+
+// lowerSym lowers the given symbolic value to LLVM IR, emitting code to f.
+// Registers and memory are read at the current position of f, and values
+// already lowered are reused.
+func (f *Func) lowerSym(v *sym, inst *x86.Inst, lowered map[*sym]value.Value) value.Value {
+	if x, ok := lowered[v]; ok {
+		return x
+	}
+	typ := types.NewInt(int(v.w))
+	var x value.Value
+	switch v.op {
+	case symIn:
+		x = f.useReg(x86.NewReg(v.reg, inst))
+	case symMem:
+		x = f.useArg(v.arg)
+	case symConst:
+		x = symIntConst(v.c, v.w)
+	case symZExt:
+		x = f.cur.NewZExt(f.lowerSym(v.a, inst, lowered), typ)
+	case symSExt:
+		x = f.cur.NewSExt(f.lowerSym(v.a, inst, lowered), typ)
+	case symTrunc:
+		x = f.cur.NewTrunc(f.lowerSym(v.a, inst, lowered), typ)
+	case symAdd:
+		x = f.cur.NewAdd(f.lowerSym(v.a, inst, lowered), f.lowerSym(v.b, inst, lowered))
+	case symSub:
+		x = f.cur.NewSub(f.lowerSym(v.a, inst, lowered), f.lowerSym(v.b, inst, lowered))
+	case symMul:
+		x = f.cur.NewMul(f.lowerSym(v.a, inst, lowered), f.lowerSym(v.b, inst, lowered))
+	case symShl:
+		x = f.cur.NewShl(f.lowerSym(v.a, inst, lowered), constant.NewInt(int64(v.s), typ))
+	case symLShr:
+		x = f.cur.NewLShr(f.lowerSym(v.a, inst, lowered), constant.NewInt(int64(v.s), typ))
+	case symAShr:
+		x = f.cur.NewAShr(f.lowerSym(v.a, inst, lowered), constant.NewInt(int64(v.s), typ))
+	case symMulShr:
+		if d, ok := v.quot(); ok {
+			// Unsigned division.
+			q := &sym{op: symQuot, w: v.w, a: v.a, d: d}
+			x = f.lowerSym(q, inst, lowered)
+			break
+		}
+		// Compute the product using an integer type large enough to hold it.
+		n := int(v.a.w) + v.c.BitLen() + 1
+		wide := types.I64
+		switch {
+		case n > 128:
+			wide = types.NewInt(256)
+		case n > 64:
+			wide = types.I128
+		}
+		a := f.lowerSym(v.a, inst, lowered)
+		var prod value.Value
+		m := symIntConst(v.c, uint(wide.Size))
+		if v.signed {
+			prod = f.cur.NewMul(f.cur.NewSExt(a, wide), m)
+			prod = f.cur.NewAShr(prod, constant.NewInt(int64(v.s), wide))
+		} else {
+			prod = f.cur.NewMul(f.cur.NewZExt(a, wide), m)
+			prod = f.cur.NewLShr(prod, constant.NewInt(int64(v.s), wide))
+		}
+		x = f.cur.NewTrunc(prod, typ)
+	case symQuot:
+		a := f.lowerSym(v.a, inst, lowered)
+		d := constant.NewInt(int64(v.d), a.Type())
+		if v.signed {
+			x = f.extSym(f.cur.NewSDiv(a, d), v, typ)
+		} else {
+			x = f.extSym(f.cur.NewUDiv(a, d), v, typ)
+		}
+	case symSign:
+		a := f.lowerSym(v.a, inst, lowered)
+		amount := constant.NewInt(int64(v.a.w-1), a.Type())
+		x = f.extSym(f.cur.NewLShr(a, amount), v, typ)
+	case symMask:
+		a := f.lowerSym(v.a, inst, lowered)
+		amount := constant.NewInt(int64(v.a.w-1), a.Type())
+		x = f.extSym(f.cur.NewAShr(a, amount), v, typ)
+	}
+	lowered[v] = x
+	return x
+}
+
+// extSym extends the given value of the size of the operand x of v to the
+// specified type; sign-extending signed values.
+func (f *Func) extSym(x value.Value, v *sym, typ *types.IntType) value.Value {
+	if v.w == v.a.w {
+		return x
+	}
+	if v.signed || v.op == symMask {
+		return f.cur.NewSExt(x, typ)
+	}
+	return f.cur.NewZExt(x, typ)
+}
+
+// symIntConst returns an LLVM IR integer constant of w bits with the given
+// value.
+func symIntConst(c *big.Int, w uint) *constant.Int {
+	typ := types.NewInt(int(w))
+	if w <= 64 {
+		return constant.NewInt(signExtend(new(big.Int).And(c, new(big.Int).SetUint64(^uint64(0))).Uint64(), w), typ)
+	}
+	return constant.NewIntFromString(c.String(), typ)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// regBits returns the size in bits of the given general purpose register; or 0
+// for other registers.
+func regBits(reg x86asm.Reg) uint {
+	switch regType(reg) {
+	case types.I8:
+		return 8
+	case types.I16:
+		return 16
+	case types.I32:
+		return 32
+	case types.I64:
+		return 64
+	}
+	return 0
+}
+
+// isReg reports whether the given argument is a register of w bits.
+func isReg(arg x86asm.Arg, w uint) bool {
+	reg, ok := arg.(x86asm.Reg)
+	return ok && regBits(reg) == w
+}
+
+// shiftAmount returns the constant shift amount of the given shift instruction.
+// The boolean return value indicates success.
+func shiftAmount(inst *x86.Inst) (uint, bool) {
+	switch arg := inst.Args[1].(type) {
+	case x86asm.Imm:
+		return uint(arg), true
+	case nil:
+		return 1, true
+	}
+	return 0, false
+}
+
+// signExtend sign-extends the given w-bit integer to 64 bits.
+func signExtend(v uint64, w uint) int64 {
+	shift := 64 - w
+	return int64(v<<shift) >> shift
+}
@@ -0,0 +1,201 @@
+package x86
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+func TestMagicDivisor(t *testing.T) {
+	golden := []struct {
+		m      string
+		k      uint
+		w      uint
+		signed bool
+		want   uint64
+		ok     bool
+	}{
+		// x / 10 (unsigned); shr edx, 3
+		{m: "0xCCCCCCCD", k: 35, w: 32, want: 10, ok: true},
+		// x / 3 (unsigned); shr edx, 1
+		{m: "0xAAAAAAAB", k: 33, w: 32, want: 3, ok: true},
+		// x / 7 (unsigned); 33-bit magic number
+		{m: "0x124924925", k: 35, w: 32, want: 7, ok: true},
+		// x / 10 (signed); sar edx, 2
+		{m: "0x66666667", k: 34, w: 32, signed: true, want: 10, ok: true},
+		// x / 7 (signed); add edx, ecx; sar edx, 2
+		{m: "0x92492493", k: 34, w: 32, signed: true, want: 7, ok: true},
+		// x / 10 (unsigned, 64-bit); shr rdx, 3
+		{m: "0xCCCCCCCCCCCCCCCD", k: 67, w: 64, want: 10, ok: true},
+		// x / 10 (signed, 64-bit); sar rdx, 2
+		{m: "0x6666666666666667", k: 66, w: 64, signed: true, want: 10, ok: true},
+		// Not a magic number.
+		{m: "0x12345678", k: 35, w: 32, ok: false},
+		// Inexact for large dividends.
+		{m: "0xCCCCCCCC", k: 35, w: 32, ok: false},
+	}
+	for _, g := range golden {
+		m, ok := new(big.Int).SetString(g.m, 0)
+		if !ok {
+			t.Errorf("%s: unable to parse magic number", g.m)
+			continue
+		}
+		got, ok := magicDivisor(m, g.k, g.w, g.signed)
+		if ok != g.ok {
+			t.Errorf("%s: success mismatch; expected %v, got %v", g.m, g.ok, ok)
+			continue
+		}
+		if got != g.want {
+			t.Errorf("%s: divisor mismatch; expected %d, got %d", g.m, g.want, got)
+		}
+	}
+}
+
+func TestMatchMagicDiv(t *testing.T) {
+	// Division idioms of GCC 12 (-O2).
+	golden := []struct {
+		name   string
+		mode   int
+		code   []byte
+		reg    x86asm.Reg
+		want   uint64
+		signed bool
+	}{
+		{
+			// mov eax, 0xCCCCCCCD; mul dword [esp+4]; mov eax, edx; shr eax, 3
+			name: "u10_32",
+			mode: 32,
+			code: []byte{0xB8, 0xCD, 0xCC, 0xCC, 0xCC, 0xF7, 0x64, 0x24, 0x04, 0x89, 0xD0, 0xC1, 0xE8, 0x03},
+			reg:  x86asm.EAX,
+			want: 10,
+		},
+		{
+			// mov eax, 0x24924925; mul ecx; sub ecx, edx; shr ecx, 1;
+			// lea eax, [edx+ecx]; shr eax, 2
+			name: "u7_32",
+			mode: 32,
+			code: []byte{0xB8, 0x25, 0x49, 0x92, 0x24, 0xF7, 0xE1, 0x29, 0xD1, 0xD1, 0xE9, 0x8D, 0x04, 0x0A, 0xC1, 0xE8, 0x02},
+			reg:  x86asm.EAX,
+			want: 7,
+		},
+		{
+			// mov eax, 0x66666667; imul ecx; sar ecx, 31; sar edx, 2;
+			// mov eax, edx; sub eax, ecx
+			name:   "s10_32",
+			mode:   32,
+			code:   []byte{0xB8, 0x67, 0x66, 0x66, 0x66, 0xF7, 0xE9, 0xC1, 0xF9, 0x1F, 0xC1, 0xFA, 0x02, 0x89, 0xD0, 0x29, 0xC8},
+			reg:    x86asm.EAX,
+			want:   10,
+			signed: true,
+		},
+		{
+			// mov eax, 0x92492493; imul ecx; lea eax, [edx+ecx]; sar ecx, 31;
+			// sar eax, 2; sub eax, ecx
+			name:   "s7_32",
+			mode:   32,
+			code:   []byte{0xB8, 0x93, 0x24, 0x49, 0x92, 0xF7, 0xE9, 0x8D, 0x04, 0x0A, 0xC1, 0xF9, 0x1F, 0xC1, 0xF8, 0x02, 0x29, 0xC8},
+			reg:    x86asm.EAX,
+			want:   7,
+			signed: true,
+		},
+		{
+			// mov eax, edi; mov edx, 0xCCCCCCCD; imul rax, rdx; shr rax, 35
+			name: "u10_64",
+			mode: 64,
+			code: []byte{0x89, 0xF8, 0xBA, 0xCD, 0xCC, 0xCC, 0xCC, 0x48, 0x0F, 0xAF, 0xC2, 0x48, 0xC1, 0xE8, 0x23},
+			reg:  x86asm.RAX,
+			want: 10,
+		},
+		{
+			// mov eax, edi; imul rax, rax, 0x24924925; shr rax, 32;
+			// sub edi, eax; shr edi, 1; add eax, edi; shr eax, 2
+			name: "u7_64",
+			mode: 64,
+			code: []byte{0x89, 0xF8, 0x48, 0x69, 0xC0, 0x25, 0x49, 0x92, 0x24, 0x48, 0xC1, 0xE8, 0x20, 0x29, 0xC7, 0xD1, 0xEF, 0x01, 0xF8, 0xC1, 0xE8, 0x02},
+			reg:  x86asm.RAX,
+			want: 7,
+		},
+		{
+			// movsxd rax, edi; sar edi, 31; imul rax, rax, 0x66666667;
+			// sar rax, 34; sub eax, edi
+			name:   "s10_64",
+			mode:   64,
+			code:   []byte{0x48, 0x63, 0xC7, 0xC1, 0xFF, 0x1F, 0x48, 0x69, 0xC0, 0x67, 0x66, 0x66, 0x66, 0x48, 0xC1, 0xF8, 0x22, 0x29, 0xF8},
+			reg:    x86asm.RAX,
+			want:   10,
+			signed: true,
+		},
+		{
+			// movsxd rax, edi; imul rax, rax, -0x6DB6DB6D; shr rax, 32;
+			// add eax, edi; sar edi, 31; sar eax, 2; sub eax, edi
+			name:   "s7_64",
+			mode:   64,
+			code:   []byte{0x48, 0x63, 0xC7, 0x48, 0x69, 0xC0, 0x93, 0x24, 0x49, 0x92, 0x48, 0xC1, 0xE8, 0x20, 0x01, 0xF8, 0xC1, 0xFF, 0x1F, 0xC1, 0xF8, 0x02, 0x29, 0xF8},
+			reg:    x86asm.RAX,
+			want:   7,
+			signed: true,
+		},
+		{
+			// mov rax, 0x6666666666666667; imul rdi; sar rdi, 63; sar rdx, 2;
+			// mov rax, rdx; sub rax, rdi
+			name:   "ls10_64",
+			mode:   64,
+			code:   []byte{0x48, 0xB8, 0x67, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x48, 0xF7, 0xEF, 0x48, 0xC1, 0xFF, 0x3F, 0x48, 0xC1, 0xFA, 0x02, 0x48, 0x89, 0xD0, 0x48, 0x29, 0xF8},
+			reg:    x86asm.RAX,
+			want:   10,
+			signed: true,
+		},
+	}
+	for _, g := range golden {
+		l := &Lifter{Disasm: &x86.Disasm{Mode: g.mode}}
+		f := &Func{l: l}
+		bb := &x86.BasicBlock{}
+		for addr := 0; addr < len(g.code); {
+			inst, err := x86asm.Decode(g.code[addr:], g.mode)
+			if err != nil {
+				t.Fatalf("%s: unable to decode instruction at offset %d; %v", g.name, addr, err)
+			}
+			bb.Insts = append(bb.Insts, &x86.Inst{Addr: bin.Address(addr), Inst: inst})
+			addr += inst.Len
+		}
+		ret, _ := x86asm.Decode([]byte{0xC3}, g.mode)
+		bb.Term = &x86.Inst{Addr: bin.Address(len(g.code)), Inst: ret}
+		div, ok := f.matchMagicDiv(bb, 0)
+		if !ok {
+			t.Errorf("%s: unable to match division idiom", g.name)
+			continue
+		}
+		if div.n != len(bb.Insts) {
+			t.Errorf("%s: instruction count mismatch; expected %d, got %d", g.name, len(bb.Insts), div.n)
+		}
+		got, signed, ok := divisorOf(div.regs[g.reg])
+		if !ok {
+			t.Errorf("%s: %v does not hold a quotient", g.name, g.reg)
+			continue
+		}
+		if got != g.want || signed != g.signed {
+			t.Errorf("%s: divisor mismatch; expected %d (signed %v), got %d (signed %v)", g.name, g.want, g.signed, got, signed)
+		}
+	}
+}
+
+// divisorOf returns the divisor and signedness of the given quotient. The
+// boolean return value indicates success.
+func divisorOf(v *sym) (uint64, bool, bool) {
+	if v == nil {
+		return 0, false, false
+	}
+	switch v.op {
+	case symZExt, symSExt:
+		return divisorOf(v.a)
+	case symQuot:
+		return v.d, v.signed, true
+	case symMulShr:
+		d, ok := v.quot()
+		return d, false, ok
+	}
+	return 0, false, false
+}
@@ -0,0 +1,384 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+)
+
+// PromoteRegs promotes the local variables allocated for registers and status
+// flags of the function into SSA values (i.e. mem2reg), inserting phi
+// instructions where needed. Local variables which are accessed other than
+// through direct load and store instructions (e.g. through bitcast) are left
+// intact.
+//
+// Pre-condition: the function has been lifted.
+func (f *Func) PromoteRegs() {
+	if len(f.Blocks) == 0 {
+		return
+	}
+	vars := make(map[*ir.InstAlloca]bool)
+	for _, v := range f.regs {
+		vars[v] = true
+	}
+	for _, v := range f.statusFlags {
+		vars[v] = true
+	}
+	for _, v := range f.fstatusFlags {
+		vars[v] = true
+	}
+	if f.st != nil {
+		vars[f.st] = true
+	}
+	// Only promote local variables accessed through direct load and store
+	// instructions.
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstLoad:
+				// Direct load; nothing to do.
+			case *ir.InstStore:
+				// Stored value escapes.
+				if v, ok := inst.Src.(*ir.InstAlloca); ok {
+					delete(vars, v)
+				}
+			default:
+				mapOperands(inst, func(x value.Value) value.Value {
+					if v, ok := x.(*ir.InstAlloca); ok {
+						delete(vars, v)
+					}
+					return x
+				})
+			}
+		}
+		mapTermOperands(block.Term, func(x value.Value) value.Value {
+			if v, ok := x.(*ir.InstAlloca); ok {
+				delete(vars, v)
+			}
+			return x
+		})
+	}
+	if len(vars) == 0 {
+		return
+	}
+	p := &promoter{
+		vars:    vars,
+		preds:   make(map[*ir.BasicBlock][]*ir.BasicBlock),
+		defs:    make(map[*ir.BasicBlock]map[*ir.InstAlloca]value.Value),
+		entries: make(map[*ir.BasicBlock]map[*ir.InstAlloca]value.Value),
+		repl:    make(map[value.Value]value.Value),
+		entry:   f.Blocks[0],
+	}
+	for _, block := range f.Blocks {
+		for _, succ := range succs(block.Term) {
+			p.preds[succ] = append(p.preds[succ], block)
+		}
+	}
+	// Record the value of each variable at the end of each basic block, as
+	// defined within the basic block.
+	for _, block := range f.Blocks {
+		defs := make(map[*ir.InstAlloca]value.Value)
+		for _, inst := range block.Insts {
+			if inst, ok := inst.(*ir.InstStore); ok {
+				if v, ok := inst.Dst.(*ir.InstAlloca); ok && vars[v] {
+					defs[v] = inst.Src
+				}
+			}
+		}
+		p.defs[block] = defs
+	}
+	// Replace loads with the reaching definition of the variable, and remove
+	// promoted loads, stores and allocas.
+	for _, block := range f.Blocks {
+		cur := make(map[*ir.InstAlloca]value.Value)
+		var insts []ir.Instruction
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstAlloca:
+				if vars[inst] {
+					continue
+				}
+			case *ir.InstLoad:
+				if v, ok := inst.Src.(*ir.InstAlloca); ok && vars[v] {
+					x, ok := cur[v]
+					if !ok {
+						x = p.readEntry(v, block)
+					}
+					p.repl[inst] = x
+					continue
+				}
+			case *ir.InstStore:
+				if v, ok := inst.Dst.(*ir.InstAlloca); ok && vars[v] {
+					cur[v] = inst.Src
+					continue
+				}
+			}
+			insts = append(insts, inst)
+		}
+		block.Insts = insts
+	}
+	// Insert phi instructions at the start of basic blocks.
+	for _, block := range f.Blocks {
+		var phis []ir.Instruction
+		for _, phi := range p.phis[block] {
+			if _, ok := p.repl[phi]; ok {
+				// Trivial phi instruction.
+				continue
+			}
+			phi.SetParent(block)
+			phis = append(phis, phi)
+		}
+		block.Insts = append(phis, block.Insts...)
+	}
+	// Update uses of replaced values.
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			mapOperands(inst, p.resolve)
+		}
+		mapTermOperands(block.Term, p.resolve)
+	}
+}
+
+// A promoter tracks the state of promoting local variables into SSA values.
+type promoter struct {
+	// Local variables to promote.
+	vars map[*ir.InstAlloca]bool
+	// Predecessors of each basic block.
+	preds map[*ir.BasicBlock][]*ir.BasicBlock
+	// Value of each variable at the end of each basic block, as defined within
+	// the basic block.
+	defs map[*ir.BasicBlock]map[*ir.InstAlloca]value.Value
+	// Value of each variable at the start of each basic block.
+	entries map[*ir.BasicBlock]map[*ir.InstAlloca]value.Value
+	// Phi instructions inserted in each basic block.
+	phis map[*ir.BasicBlock][]*ir.InstPhi
+	// Replacement of promoted loads and trivial phi instructions.
+	repl map[value.Value]value.Value
+	// Entry basic block.
+	entry *ir.BasicBlock
+}
+
+// readEntry returns the value of the given variable at the start of the basic
+// block.
+func (p *promoter) readEntry(v *ir.InstAlloca, block *ir.BasicBlock) value.Value {
+	entries, ok := p.entries[block]
+	if !ok {
+		entries = make(map[*ir.InstAlloca]value.Value)
+		p.entries[block] = entries
+	}
+	if x, ok := entries[v]; ok {
+		return x
+	}
+	preds := p.preds[block]
+	if block == p.entry || len(preds) == 0 {
+		// Undefined value on function entry.
+		x := constant.NewUndef(v.Elem)
+		entries[v] = x
+		return x
+	}
+	if len(preds) == 1 {
+		x := p.readExit(v, preds[0])
+		entries[v] = x
+		return x
+	}
+	// Insert phi instruction before reading the value of predecessors to break
+	// cycles.
+	phi := &ir.InstPhi{}
+	phi.Typ = v.Elem
+	entries[v] = phi
+	if p.phis == nil {
+		p.phis = make(map[*ir.BasicBlock][]*ir.InstPhi)
+	}
+	p.phis[block] = append(p.phis[block], phi)
+	var same value.Value
+	trivial := true
+	for _, pred := range preds {
+		x := p.readExit(v, pred)
+		phi.Incs = append(phi.Incs, ir.NewIncoming(x, pred))
+		if x == phi || x == same {
+			continue
+		}
+		if same != nil {
+			trivial = false
+		}
+		same = x
+	}
+	if trivial && same != nil {
+		p.repl[phi] = same
+	}
+	return phi
+}
+
+// readExit returns the value of the given variable at the end of the basic
+// block.
+func (p *promoter) readExit(v *ir.InstAlloca, block *ir.BasicBlock) value.Value {
+	if x, ok := p.defs[block][v]; ok {
+		return x
+	}
+	return p.readEntry(v, block)
+}
+
+// resolve returns the replacement of the given value.
+func (p *promoter) resolve(x value.Value) value.Value {
+	seen := make(map[value.Value]bool)
+	for !seen[x] {
+		seen[x] = true
+		y, ok := p.repl[x]
+		if !ok {
+			return x
+		}
+		x = y
+	}
+	// Cyclic replacement; only reachable from unreachable basic blocks.
+	return x
+}
+
+// succs returns the successor basic blocks of the given terminator.
+func succs(term ir.Terminator) []*ir.BasicBlock {
+	switch term := term.(type) {
+	case *ir.TermBr:
+		return []*ir.BasicBlock{term.Target}
+	case *ir.TermCondBr:
+		return []*ir.BasicBlock{term.TargetTrue, term.TargetFalse}
+	case *ir.TermSwitch:
+		targets := []*ir.BasicBlock{term.TargetDefault}
+		for _, c := range term.Cases {
+			targets = append(targets, c.Target)
+		}
+		return targets
+	case *ir.TermRet, *ir.TermUnreachable:
+		return nil
+	default:
+		panic(fmt.Errorf("support for terminator %T not yet implemented", term))
+	}
+}
+
+// mapOperands replaces each operand of the given instruction with the result
+// of applying fn to the operand.
+func mapOperands(inst ir.Instruction, fn func(value.Value) value.Value) {
+	switch inst := inst.(type) {
+	// Binary instructions.
+	case *ir.InstAdd:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstFAdd:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstSub:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstFSub:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstMul:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstFMul:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstUDiv:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstSDiv:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstFDiv:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstURem:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstSRem:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstFRem:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	// Bitwise instructions.
+	case *ir.InstShl:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstLShr:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstAShr:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstAnd:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstOr:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstXor:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	// Aggregate instructions.
+	case *ir.InstExtractValue:
+		inst.X = fn(inst.X)
+	case *ir.InstInsertValue:
+		inst.X, inst.Elem = fn(inst.X), fn(inst.Elem)
+	// Memory instructions.
+	case *ir.InstAlloca:
+		if inst.NElems != nil {
+			inst.NElems = fn(inst.NElems)
+		}
+	case *ir.InstLoad:
+		inst.Src = fn(inst.Src)
+	case *ir.InstStore:
+		inst.Src, inst.Dst = fn(inst.Src), fn(inst.Dst)
+	case *ir.InstGetElementPtr:
+		inst.Src = fn(inst.Src)
+		for i, index := range inst.Indices {
+			inst.Indices[i] = fn(index)
+		}
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		inst.From = fn(inst.From)
+	case *ir.InstZExt:
+		inst.From = fn(inst.From)
+	case *ir.InstSExt:
+		inst.From = fn(inst.From)
+	case *ir.InstFPTrunc:
+		inst.From = fn(inst.From)
+	case *ir.InstFPExt:
+		inst.From = fn(inst.From)
+	case *ir.InstFPToUI:
+		inst.From = fn(inst.From)
+	case *ir.InstFPToSI:
+		inst.From = fn(inst.From)
+	case *ir.InstUIToFP:
+		inst.From = fn(inst.From)
+	case *ir.InstSIToFP:
+		inst.From = fn(inst.From)
+	case *ir.InstPtrToInt:
+		inst.From = fn(inst.From)
+	case *ir.InstIntToPtr:
+		inst.From = fn(inst.From)
+	case *ir.InstBitCast:
+		inst.From = fn(inst.From)
+	case *ir.InstAddrSpaceCast:
+		inst.From = fn(inst.From)
+	// Other instructions.
+	case *ir.InstICmp:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstFCmp:
+		inst.X, inst.Y = fn(inst.X), fn(inst.Y)
+	case *ir.InstPhi:
+		for _, inc := range inst.Incs {
+			inc.X = fn(inc.X)
+		}
+	case *ir.InstSelect:
+		inst.Cond, inst.X, inst.Y = fn(inst.Cond), fn(inst.X), fn(inst.Y)
+	case *ir.InstCall:
+		inst.Callee = fn(inst.Callee)
+		for i, arg := range inst.Args {
+			inst.Args[i] = fn(arg)
+		}
+	default:
+		panic(fmt.Errorf("support for instruction %T not yet implemented", inst))
+	}
+}
+
+// mapTermOperands replaces each operand of the given terminator with the
+// result of applying fn to the operand.
+func mapTermOperands(term ir.Terminator, fn func(value.Value) value.Value) {
+	switch term := term.(type) {
+	case *ir.TermRet:
+		if term.X != nil {
+			term.X = fn(term.X)
+		}
+	case *ir.TermCondBr:
+		term.Cond = fn(term.Cond)
+	case *ir.TermSwitch:
+		term.X = fn(term.X)
+	case *ir.TermBr, *ir.TermUnreachable:
+		// No value operands.
+	default:
+		panic(fmt.Errorf("support for terminator %T not yet implemented", term))
+	}
+}
@@ -0,0 +1,101 @@
+package x86
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+)
+
+// FuncModule returns a self-contained LLVM IR module of the given lifted
+// function, containing the type definitions of the lifter, and external
+// declarations of the global variables and functions referenced from the
+// function; in order of first use.
+//
+// Pre-condition: the function has been lifted.
+func (l *Lifter) FuncModule(f *Func) *ir.Module {
+	m := &ir.Module{
+		Types: l.Types,
+	}
+	globals := make(map[*ir.Global]bool)
+	funcs := make(map[*ir.Function]bool)
+	visitRefs(f.Function, func(v value.Value) {
+		switch v := v.(type) {
+		case *ir.Global:
+			if !globals[v] {
+				globals[v] = true
+				m.Globals = append(m.Globals, declGlobal(v))
+			}
+		case *ir.Function:
+			if v != f.Function && !funcs[v] {
+				funcs[v] = true
+				m.Funcs = append(m.Funcs, DeclFunc(v))
+			}
+		}
+	})
+	m.Funcs = append(m.Funcs, f.Function)
+	return m
+}
+
+// ExternFuncs returns declarations of the functions referenced from the given
+// functions which are not among them (e.g. imports and functions not selected
+// for lifting); in order of first use.
+func ExternFuncs(funcs []*ir.Function) []*ir.Function {
+	declared := make(map[*ir.Function]bool)
+	for _, fn := range funcs {
+		declared[fn] = true
+	}
+	var decls []*ir.Function
+	for _, fn := range funcs {
+		visitRefs(fn, func(v value.Value) {
+			if v, ok := v.(*ir.Function); ok && !declared[v] {
+				declared[v] = true
+				decls = append(decls, DeclFunc(v))
+			}
+		})
+	}
+	return decls
+}
+
+// visitRefs invokes visit for each value used as operand by the instructions
+// and terminators of the given function, including the operands of constant
+// expressions.
+func visitRefs(fn *ir.Function, visit func(v value.Value)) {
+	var walk func(v value.Value) value.Value
+	walk = func(v value.Value) value.Value {
+		visit(v)
+		switch v := v.(type) {
+		case *constant.ExprGetElementPtr:
+			walk(v.Src)
+			for _, index := range v.Indices {
+				walk(index)
+			}
+		case *constant.ExprBitCast:
+			walk(v.From)
+		case *constant.ExprPtrToInt:
+			walk(v.From)
+		case *constant.ExprIntToPtr:
+			walk(v.From)
+		}
+		return v
+	}
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			mapOperands(inst, walk)
+		}
+		mapTermOperands(block.Term, walk)
+	}
+}
+
+// declGlobal returns an external declaration of the given global variable.
+func declGlobal(g *ir.Global) *ir.Global {
+	decl := *g
+	decl.Init = nil
+	return &decl
+}
+
+// DeclFunc returns a declaration of the given function.
+func DeclFunc(fn *ir.Function) *ir.Function {
+	decl := *fn
+	decl.Blocks = nil
+	return &decl
+}
@@ -0,0 +1,54 @@
+package x86
+
+// msvcrtHeader specifies the function prototypes of the startup functions of
+// the Microsoft C runtime, as exported by msvcrt.dll and the Universal CRT
+// (ucrtbase.dll and api-ms-win-crt-runtime-l1-1-0.dll); used by the startup
+// code of MSVC and MinGW executables.
+const msvcrtHeader = `
+// --- [ Types ] ---------------------------------------------------------------
+
+typedef void (*_PVFV)(void);
+typedef int (*_PIFV)(void);
+typedef int (*_onexit_t)(void);
+typedef struct _startupinfo _startupinfo;
+typedef struct _onexit_table_t _onexit_table_t;
+
+// --- [ Startup ] -------------------------------------------------------------
+
+int __getmainargs(int *argc, char ***argv, char ***envp, int expand_wildcards, _startupinfo *startup_info);
+int __wgetmainargs(int *argc, wchar_t ***argv, wchar_t ***envp, int expand_wildcards, _startupinfo *startup_info);
+int wmain(int argc, wchar_t **argv, wchar_t **envp);
+void __set_app_type(int app_type);
+void _set_app_type(int app_type);
+void _initterm(_PVFV *first, _PVFV *last);
+int _initterm_e(_PIFV *first, _PIFV *last);
+int _configure_narrow_argv(int mode);
+int _configure_wide_argv(int mode);
+int _initialize_narrow_environment(void);
+int _initialize_wide_environment(void);
+int *__p___argc(void);
+char ***__p___argv(void);
+wchar_t ***__p___wargv(void);
+char **_get_initial_narrow_environment(void);
+wchar_t **_get_initial_wide_environment(void);
+int *__p__fmode(void);
+int *__p__commode(void);
+int _set_fmode(int mode);
+unsigned int _controlfp(unsigned int new_value, unsigned int mask);
+int _controlfp_s(unsigned int *current_value, unsigned int new_value, unsigned int mask);
+int _XcptFilter(unsigned long xcptnum, void *pxcptinfoptrs);
+int _seh_filter_exe(unsigned long xcptnum, void *pxcptinfoptrs);
+void __setusermatherr(int (*pf)(void *));
+
+// --- [ Termination ] ---------------------------------------------------------
+
+void _cexit(void);
+void _c_exit(void);
+void _amsg_exit(int rterrnum);
+_onexit_t _onexit(_onexit_t func);
+_onexit_t __dllonexit(_onexit_t func, _PVFV **begin, _PVFV **end);
+int _crt_atexit(_PVFV func);
+int _register_onexit_function(_onexit_table_t *table, _onexit_t func);
+int _initialize_onexit_table(_onexit_table_t *table);
+int _execute_onexit_table(_onexit_table_t *table);
+`
@@ -0,0 +1,58 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir/metadata"
+)
+
+// applyNames applies the user-supplied symbol names and comments of names.json
+// to the functions of the lifter, overriding the names of functions located by
+// other means. Comments are attached as "comment" metadata.
+//
+// Names and comments of global variables are applied as global variables are
+// inferred, and comments of instructions as instructions are lifted.
+func (l *Lifter) applyNames() {
+	names := make(map[bin.Address]string)
+	for addr, n := range l.Names {
+		if len(n.Name) == 0 {
+			continue
+		}
+		if _, ok := l.Funcs[addr]; ok || l.IsFunc(addr) {
+			names[addr] = n.Name
+		}
+	}
+	l.RenameFuncs(names)
+	for addr, n := range l.Names {
+		if len(n.Comment) == 0 {
+			continue
+		}
+		if f, ok := l.Funcs[addr]; ok {
+			setMetadata(f.Function, "comment", commentMetadata(n.Comment))
+		}
+	}
+}
+
+// nameGlobals applies the user-supplied symbol names and comments of names.json
+// to the global variables of the lifter.
+func (l *Lifter) nameGlobals() {
+	for addr, n := range l.Names {
+		g, ok := l.Globals[addr]
+		if !ok {
+			continue
+		}
+		if len(n.Name) > 0 {
+			dbg.Printf("renaming global variable %q at %v to %q", g.Name, addr, n.Name)
+			g.Name = n.Name
+		}
+		if len(n.Comment) > 0 {
+			setMetadata(g, "comment", commentMetadata(n.Comment))
+		}
+	}
+}
+
+// commentMetadata returns the metadata of the given user-supplied comment.
+func commentMetadata(comment string) *metadata.Metadata {
+	return &metadata.Metadata{
+		Nodes: []metadata.Node{&metadata.String{Val: comment}},
+	}
+}
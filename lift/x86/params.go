@@ -0,0 +1,822 @@
+package x86
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// inferParams infers the parameters of the function based on the registers and
+// stack slots read before being defined, and updates the function signature
+// accordingly.
+//
+// Pre-condition: the function signature is unknown.
+func (f *Func) inferParams() {
+	liveIn := f.liveInRegs()
+	var params []*types.Param
+	switch f.l.Mode {
+	case 16:
+		// Arguments are passed on the stack in 16-bit mode; functions purging
+		// their arguments (e.g. Pascal calling convention) are lifted as stdcall.
+		if f.calleePurge() > 0 {
+			f.CallConv = ir.CallConvX86_StdCall
+		}
+		nstack := f.stackParams()
+		for i := 0; i < nstack; i++ {
+			offset := f.retAddrSize() + 2*int64(i)
+			if f.regConv != nil && f.regConv.LeftToRight {
+				// The first argument is pushed first, and is thus located at the
+				// highest stack offset.
+				offset = f.retAddrSize() + 2*int64(nstack-1-i)
+			}
+			name := fmt.Sprintf("arg_%d", offset)
+			params = append(params, types.NewParam(name, types.I16))
+		}
+	case 32:
+		// Registers used to pass arguments in the default register calling
+		// convention of the compiler; e.g. EAX, EDX and ECX in the register
+		// calling convention of Delphi.
+		if rc := f.l.defaultRegConv(); rc != nil {
+			n := 0
+			for i, reg := range rc.Regs {
+				if liveIn[reg] {
+					n = i + 1
+				}
+			}
+			if n > 0 {
+				f.regConv = rc
+			}
+			for _, reg := range rc.Regs[:n] {
+				params = append(params, regParam(reg, types.I32))
+			}
+		}
+		// Registers used to pass arguments; ECX and EDX in fastcall, and ECX in
+		// thiscall.
+		switch {
+		case f.regConv != nil:
+			// Register calling convention.
+		case liveIn[x86asm.EDX]:
+			f.CallConv = ir.CallConvX86_FastCall
+			params = append(params, regParam(x86asm.ECX, types.I32))
+			params = append(params, regParam(x86asm.EDX, types.I32))
+		case liveIn[x86asm.ECX]:
+			f.CallConv = ir.CallConvX86_ThisCall
+			params = append(params, regParam(x86asm.ECX, types.I32))
+		case f.calleePurge() > 0:
+			f.CallConv = ir.CallConvX86_StdCall
+		}
+		nstack := f.stackParams()
+		for i := 0; i < nstack; i++ {
+			offset := 4 + 4*i
+			if f.regConv != nil && f.regConv.LeftToRight {
+				// The first argument is pushed first, and is thus located at the
+				// highest stack offset.
+				offset = 4 + 4*(nstack-1-i)
+			}
+			name := fmt.Sprintf("arg_%d", offset)
+			params = append(params, types.NewParam(name, types.I32))
+		}
+	case 64:
+		callconv := f.l.defaultCallConv()
+		regs := paramRegs[callconv]
+		n := 0
+		for i, reg := range regs {
+			if liveIn[reg] {
+				n = i + 1
+			}
+		}
+		// Arguments following the register arguments are passed on the stack;
+		// above the shadow space of the register arguments in Microsoft x64.
+		nstack := f.stackParams()
+		if nstack > 0 {
+			n = len(regs)
+		}
+		if n > 0 {
+			f.CallConv = callconv
+		}
+		for _, reg := range regs[:n] {
+			params = append(params, regParam(reg, types.I64))
+		}
+		for i := 0; i < nstack; i++ {
+			offset := f.l.stackSlot(callconv, i).Disp
+			name := fmt.Sprintf("arg_%d", offset)
+			params = append(params, types.NewParam(name, types.I64))
+		}
+	default:
+		panic(fmt.Errorf("support for processor mode %d not yet implemented", f.l.Mode))
+	}
+	if len(params) == 0 {
+		return
+	}
+	sig := types.NewFunc(f.Sig.Ret, params...)
+	f.Sig = sig
+	f.Typ = types.NewPointer(sig)
+	dbg.Printf("inferred signature of function %q at %v: %v", f.Name, f.AsmFunc.Addr, sig)
+}
+
+// regParam returns a new function parameter of the given type, passed in the
+// specified register.
+func regParam(reg x86asm.Reg, typ types.Type) *types.Param {
+	name := fmt.Sprintf("arg_%s", strings.ToLower(x86.Register(reg).String()))
+	return types.NewParam(name, typ)
+}
+
+// paramRegs maps from 64-bit calling convention to the registers used to pass
+// integer arguments.
+var paramRegs = map[ir.CallConv][]x86asm.Reg{
+	ir.CallConvX86_64_SysV:  {x86asm.RDI, x86asm.RSI, x86asm.RDX, x86asm.RCX, x86asm.R8, x86asm.R9},
+	ir.CallConvX86_64_Win64: {x86asm.RCX, x86asm.RDX, x86asm.R8, x86asm.R9},
+}
+
+// A RegConv is a register calling convention of 32-bit x86 functions without
+// an LLVM IR counterpart (e.g. the register calling convention of Delphi).
+// Functions using register calling conventions are emitted using the C calling
+// convention; the lifter passes arguments in the registers of the register
+// calling convention.
+type RegConv struct {
+	// Calling convention name, as used by sigs.json; e.g. "register".
+	Name string
+	// Registers used to pass the leading integer arguments, in order.
+	Regs []x86asm.Reg
+	// LeftToRight specifies whether arguments on the stack are pushed from left
+	// to right.
+	LeftToRight bool
+	// CalleePurge specifies whether arguments on the stack are purged by the
+	// callee.
+	CalleePurge bool
+}
+
+// regConvs maps from calling convention name of sigs.json to register calling
+// convention.
+var regConvs = map[string]*RegConv{
+	// Borland register calling convention (Delphi and C++Builder __fastcall).
+	"register": {
+		Name:        "register",
+		Regs:        []x86asm.Reg{x86asm.EAX, x86asm.EDX, x86asm.ECX},
+		LeftToRight: true,
+		CalleePurge: true,
+	},
+	// Watcom register calling convention (__watcall); the default calling
+	// convention of Open Watcom C/C++.
+	"watcom": {
+		Name:        "watcom",
+		Regs:        []x86asm.Reg{x86asm.EAX, x86asm.EDX, x86asm.EBX, x86asm.ECX},
+		CalleePurge: true,
+	},
+	// Pascal calling convention (__pascal) of Win16 and Turbo Pascal, which
+	// passes all arguments on the stack.
+	"pascal": {
+		Name:        "pascal",
+		LeftToRight: true,
+		CalleePurge: true,
+	},
+}
+
+// compilerRegConvs maps from compiler to the default register calling
+// convention of 32-bit functions.
+var compilerRegConvs = map[string]*RegConv{
+	bin.CompilerDelphi: regConvs["register"],
+	bin.CompilerWatcom: regConvs["watcom"],
+}
+
+// defaultRegConv returns the default register calling convention of 32-bit
+// functions of the binary executable, based on its compiler; or nil if the
+// compiler passes arguments on the stack by default.
+func (l *Lifter) defaultRegConv() *RegConv {
+	if l.Mode != 32 {
+		return nil
+	}
+	return compilerRegConvs[l.File.Compiler]
+}
+
+// regConv returns the register calling convention of the given name, or nil if
+// not present or not supported in the processor mode of the lifter. Register
+// calling conventions are supported in 32-bit mode, and in 16-bit mode if all
+// arguments are passed on the stack (e.g. Pascal).
+func (l *Lifter) regConv(name string) *RegConv {
+	rc, ok := regConvs[name]
+	if !ok || !l.supportsRegConv(rc) {
+		return nil
+	}
+	return rc
+}
+
+// supportsRegConv reports whether the given register calling convention is
+// supported in the processor mode of the lifter.
+func (l *Lifter) supportsRegConv(rc *RegConv) bool {
+	switch l.Mode {
+	case 16:
+		return len(rc.Regs) == 0
+	case 32:
+		return true
+	}
+	return false
+}
+
+// String returns the name of the register calling convention; or an empty
+// string if nil.
+func (rc *RegConv) String() string {
+	if rc == nil {
+		return ""
+	}
+	return rc.Name
+}
+
+// paramReg returns the register used to pass the i:th argument of a function
+// with the register calling convention. The boolean return value indicates
+// whether the argument is passed in a register.
+func (rc *RegConv) paramReg(i int) (*x86.Reg, bool) {
+	if i < len(rc.Regs) {
+		return x86.NewReg(rc.Regs[i], nil), true
+	}
+	return nil, false
+}
+
+// paramReg returns the register used to pass the i:th argument of the function.
+// The boolean return value indicates whether the argument is passed in a
+// register.
+func (f *Func) paramReg(i int) (*x86.Reg, bool) {
+	if f.regConv != nil {
+		return f.regConv.paramReg(i)
+	}
+	return f.l.paramReg(f.CallConv, i)
+}
+
+// defaultCallConv returns the default calling convention of 64-bit functions
+// of the binary executable; Microsoft x64 for PE files and System V AMD64
+// otherwise.
+func (l *Lifter) defaultCallConv() ir.CallConv {
+	if l.File.Format == "pe" {
+		return ir.CallConvX86_64_Win64
+	}
+	return ir.CallConvX86_64_SysV
+}
+
+// paramReg returns the register used to pass the i:th argument of a function
+// with the given calling convention. The boolean return value indicates whether
+// the argument is passed in a register.
+func (l *Lifter) paramReg(callconv ir.CallConv, i int) (*x86.Reg, bool) {
+	switch l.Mode {
+	case 16:
+		// Arguments are passed on the stack.
+		return nil, false
+	case 32:
+		switch callconv {
+		case ir.CallConvX86_FastCall:
+			switch i {
+			case 0:
+				return x86.ECX, true
+			case 1:
+				return x86.EDX, true
+			}
+		case ir.CallConvX86_ThisCall:
+			if i == 0 {
+				return x86.ECX, true
+			}
+		}
+		// TODO: Add support for more calling conventions.
+		return nil, false
+	case 64:
+		if callconv == ir.CallConvNone {
+			callconv = l.defaultCallConv()
+		}
+		regs, ok := paramRegs[callconv]
+		if !ok {
+			panic(fmt.Errorf("support for calling convention %v in 64-bit mode not yet implemented", callconv))
+		}
+		if i < len(regs) {
+			return x86.NewReg(regs[i], nil), true
+		}
+		return nil, false
+	default:
+		panic(fmt.Errorf("support for processor mode %d not yet implemented", l.Mode))
+	}
+}
+
+// stackSlot returns the memory reference of the i:th stack slot used to pass
+// arguments to a function with the given calling convention, as seen from the
+// entry of the function. Far procedures of 16-bit mode have an additional 2
+// bytes of return address, as accounted for by the caller.
+func (l *Lifter) stackSlot(callconv ir.CallConv, i int) *x86.Mem {
+	var m x86asm.Mem
+	switch l.Mode {
+	case 16:
+		// Skip return address.
+		m = x86asm.Mem{Base: x86asm.ESP, Disp: 2 + 2*int64(i)}
+	case 32:
+		// Skip return address.
+		m = x86asm.Mem{Base: x86asm.ESP, Disp: 4 + 4*int64(i)}
+	case 64:
+		// Skip return address.
+		m = x86asm.Mem{Base: x86asm.RSP, Disp: 8 + 8*int64(i)}
+		if callconv == ir.CallConvNone {
+			callconv = l.defaultCallConv()
+		}
+		if callconv == ir.CallConvX86_64_Win64 {
+			// Skip shadow space of register arguments.
+			m.Disp += 32
+		}
+	default:
+		panic(fmt.Errorf("support for processor mode %d not yet implemented", l.Mode))
+	}
+	return x86.NewMem(m, nil)
+}
+
+// liveInRegs returns the set of registers read before being defined on some
+// path from the entry of the function. Registers are identified by their
+// largest enclosing register (e.g. EAX for AL).
+func (f *Func) liveInRegs() map[x86asm.Reg]bool {
+	return f.liveRegs(nil)[f.AsmFunc.Addr]
+}
+
+// liveRegs returns the set of registers live at the entry of each basic block
+// of the function; i.e. read before being defined on some path from the start
+// of the basic block. Registers holding the return value of the function are
+// specified by retRegs, and are considered read by RET.
+func (f *Func) liveRegs(retRegs []x86asm.Reg) map[bin.Address]map[x86asm.Reg]bool {
+	type useDef struct {
+		use, def map[x86asm.Reg]bool
+	}
+	blocks := f.AsmFunc.Blocks
+	saved := f.savedRegs()
+	uds := make(map[bin.Address]*useDef)
+	for addr, block := range blocks {
+		ud := &useDef{
+			use: make(map[x86asm.Reg]bool),
+			def: make(map[x86asm.Reg]bool),
+		}
+		insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+		for _, inst := range insts {
+			uses, defs := f.l.regUseDef(inst)
+			switch inst.Op {
+			case x86asm.PUSH:
+				if reg, ok := inst.Args[0].(x86asm.Reg); ok && saved[f.l.parentReg(reg)] {
+					// Pushing a callee-saved register preserves its value, rather
+					// than using it.
+					uses = nil
+				}
+			case x86asm.RET, x86asm.LRET:
+				uses = retRegs
+			}
+			for _, reg := range uses {
+				if !ud.def[reg] {
+					ud.use[reg] = true
+				}
+			}
+			for _, reg := range defs {
+				ud.def[reg] = true
+			}
+		}
+		uds[addr] = ud
+	}
+	// Backward data flow analysis; iterate until fixed point.
+	liveIn := make(map[bin.Address]map[x86asm.Reg]bool)
+	for addr := range blocks {
+		liveIn[addr] = make(map[x86asm.Reg]bool)
+	}
+	for changed := true; changed; {
+		changed = false
+		for addr, block := range blocks {
+			ud := uds[addr]
+			in := liveIn[addr]
+			for _, succ := range f.succs(block) {
+				for reg := range liveIn[succ] {
+					if !ud.def[reg] && !in[reg] {
+						in[reg] = true
+						changed = true
+					}
+				}
+			}
+			for reg := range ud.use {
+				if !in[reg] {
+					in[reg] = true
+					changed = true
+				}
+			}
+		}
+	}
+	return liveIn
+}
+
+// savedRegs returns the set of callee-saved registers of the function; i.e.
+// registers restored using POP in a basic block terminated by RET.
+func (f *Func) savedRegs() map[x86asm.Reg]bool {
+	saved := make(map[x86asm.Reg]bool)
+	for _, block := range f.AsmFunc.Blocks {
+		if !isReturn(block.Term) {
+			continue
+		}
+		for _, inst := range block.Insts {
+			if inst.Op != x86asm.POP {
+				continue
+			}
+			if reg, ok := inst.Args[0].(x86asm.Reg); ok {
+				saved[f.l.parentReg(reg)] = true
+			}
+		}
+	}
+	return saved
+}
+
+// succs returns the addresses of the successor basic blocks of the given basic
+// block within the function.
+func (f *Func) succs(block *x86.BasicBlock) []bin.Address {
+	var succs []bin.Address
+	for _, target := range f.l.Targets(block.Term, f.AsmFunc.Addr) {
+		if _, ok := f.AsmFunc.Blocks[target]; ok {
+			succs = append(succs, target)
+		}
+	}
+	return succs
+}
+
+// stackParams returns the number of stack slots above the return address read
+// by the function; as tracked through ESP and EBP-based memory references (SP
+// and BP-based in 16-bit mode, and RSP and RBP-based in 64-bit mode). The
+// shadow space of register arguments in Microsoft x64 is not counted.
+func (f *Func) stackParams() int {
+	word, ret := f.l.stackWord(), f.retAddrSize()
+	sp, bp := x86asm.ESP, x86asm.EBP
+	switch f.l.Mode {
+	case 16:
+		sp, bp = x86asm.SP, x86asm.BP
+	case 64:
+		// Offset of the first stack slot of arguments.
+		word, ret = 8, f.l.stackSlot(f.CallConv, 0).Disp
+		sp, bp = x86asm.RSP, x86asm.RBP
+	}
+	max := int64(0)
+	// Stack pointer and frame pointer displacement at entry of each basic block,
+	// relative to ESP at function entry.
+	type frame struct {
+		esp, ebp int64
+		hasESP   bool
+		hasEBP   bool
+	}
+	frames := map[bin.Address]frame{f.AsmFunc.Addr: {hasESP: true}}
+	queue := []bin.Address{f.AsmFunc.Addr}
+	for len(queue) > 0 {
+		addr := queue[0]
+		queue = queue[1:]
+		block := f.AsmFunc.Blocks[addr]
+		fr := frames[addr]
+		for _, inst := range block.Insts {
+			// Record stack slots accessed by the instruction.
+			for _, arg := range inst.Args {
+				mem, ok := arg.(x86asm.Mem)
+				if !ok || mem.Index != 0 {
+					continue
+				}
+				var off int64
+				switch {
+				case mem.Base == sp && fr.hasESP:
+					off = fr.esp + mem.Disp
+				case mem.Base == bp && fr.hasEBP:
+					off = fr.ebp + mem.Disp
+				default:
+					continue
+				}
+				// Skip return address.
+				if off >= ret && off > max {
+					max = off
+				}
+			}
+			// Track stack pointer and frame pointer.
+			switch inst.Op {
+			case x86asm.PUSH:
+				fr.esp -= word
+			case x86asm.POP:
+				fr.esp += word
+				if inst.Args[0] == bp {
+					fr.hasEBP = false
+				}
+			case x86asm.CALL, x86asm.LCALL:
+				purge, ok := f.stackPurge(inst)
+				if !ok {
+					// Unknown number of arguments purged by callee.
+					fr.hasESP = false
+				}
+				fr.esp += purge
+			case x86asm.MOV:
+				switch {
+				case inst.Args[0] == bp && inst.Args[1] == sp:
+					fr.ebp = fr.esp
+					fr.hasEBP = fr.hasESP
+				case inst.Args[0] == sp && inst.Args[1] == bp:
+					fr.esp = fr.ebp
+					fr.hasESP = fr.hasEBP
+				case inst.Args[0] == sp:
+					fr.hasESP = false
+				}
+			case x86asm.LEAVE:
+				fr.esp = fr.ebp + word
+				fr.hasESP = fr.hasEBP
+				fr.hasEBP = false
+			default:
+				if inst.Args[0] != sp {
+					break
+				}
+				imm, ok := inst.Args[1].(x86asm.Imm)
+				switch {
+				case inst.Op == x86asm.ADD && ok:
+					fr.esp += int64(imm)
+				case inst.Op == x86asm.SUB && ok:
+					fr.esp -= int64(imm)
+				default:
+					// E.g. stack alignment; `and esp, 0xFFFFFFF0`.
+					fr.hasESP = false
+				}
+			}
+		}
+		for _, succ := range f.succs(block) {
+			if _, ok := frames[succ]; ok {
+				continue
+			}
+			frames[succ] = fr
+			queue = append(queue, succ)
+		}
+	}
+	n := 0
+	if max >= ret {
+		n = int((max-ret)/word) + 1
+	}
+	// Callee purged arguments (e.g. RET 8) are parameters on the stack.
+	if purge := int(f.calleePurge() / word); purge > n {
+		n = purge
+	}
+	return n
+}
+
+// calleePurge returns the number of bytes of arguments purged by the function
+// on return (e.g. 8 for RET 8). Return instructions are inspected in address
+// order.
+func (f *Func) calleePurge() int64 {
+	for _, blockAddr := range f.blockAddrs() {
+		term := f.AsmFunc.Blocks[blockAddr].Term
+		if !isReturn(term) {
+			continue
+		}
+		if imm, ok := term.Args[0].(x86asm.Imm); ok {
+			return int64(imm)
+		}
+	}
+	return 0
+}
+
+// stackPurge returns the number of bytes of arguments purged by the callee of
+// the given CALL instruction. The boolean return value indicates whether the
+// callee and its signature are known.
+func (f *Func) stackPurge(inst *x86.Inst) (int64, bool) {
+	callee, ok := f.callee(inst)
+	if !ok {
+		return 0, false
+	}
+	if callee.sigUnknown {
+		if callee.AsmFunc == nil {
+			return 0, false
+		}
+		return callee.calleePurge(), true
+	}
+	return f.l.sigPurge(callee), true
+}
+
+// sigPurge returns the number of bytes of arguments purged by the given
+// function on return, as specified by the calling convention of its signature.
+func (l *Lifter) sigPurge(f *Func) int64 {
+	if rc := f.regConv; rc != nil {
+		purge := int64(0)
+		if rc.CalleePurge {
+			for i := range f.Sig.Params {
+				if _, ok := rc.paramReg(i); !ok {
+					purge += l.stackWord()
+				}
+			}
+		}
+		return purge
+	}
+	switch f.CallConv {
+	case ir.CallConvX86_StdCall, ir.CallConvX86_FastCall, ir.CallConvX86_ThisCall:
+		purge := int64(0)
+		for i := range f.Sig.Params {
+			if _, ok := l.paramReg(f.CallConv, i); !ok {
+				purge += l.stackWord()
+			}
+		}
+		return purge
+	}
+	return 0
+}
+
+// callee returns the function called by the given CALL instruction (or JMP
+// instruction in case of tail calls). The boolean return value indicates
+// whether the callee is known.
+func (f *Func) callee(inst *x86.Inst) (*Func, bool) {
+	target, ok := f.getAddr(inst.Arg(0))
+	if !ok {
+		return nil, false
+	}
+	return f.l.funcAt(target)
+}
+
+// regUseDef returns the registers read and written by the given instruction.
+// Registers are identified by their largest enclosing register (e.g. EAX for
+// AL); the stack and instruction pointers are omitted.
+func (l *Lifter) regUseDef(inst *x86.Inst) (uses, defs []x86asm.Reg) {
+	if inst.IsDummyTerm() {
+		return nil, nil
+	}
+	use := func(regs ...x86asm.Reg) {
+		for _, reg := range regs {
+			if reg = l.parentReg(reg); reg != 0 {
+				uses = append(uses, reg)
+			}
+		}
+	}
+	def := func(regs ...x86asm.Reg) {
+		for _, reg := range regs {
+			if reg = l.parentReg(reg); reg != 0 {
+				defs = append(defs, reg)
+			}
+		}
+	}
+	var eax, ecx, edx, esi, edi x86asm.Reg = x86asm.EAX, x86asm.ECX, x86asm.EDX, x86asm.ESI, x86asm.EDI
+	// Registers of memory operands are always read.
+	for _, arg := range inst.Args {
+		if mem, ok := arg.(x86asm.Mem); ok {
+			use(mem.Base, mem.Index)
+		}
+	}
+	// Implicit operands.
+	switch inst.Op {
+	case x86asm.CALL, x86asm.LCALL:
+		// Indirect call through register.
+		if reg, ok := inst.Args[0].(x86asm.Reg); ok {
+			use(reg)
+		}
+		// Caller-saved registers are clobbered by the callee.
+		def(eax, ecx, edx)
+		if l.Mode == 64 {
+			def(esi, edi, x86asm.R8, x86asm.R9, x86asm.R10, x86asm.R11)
+			def(x86asm.X0, x86asm.X1, x86asm.X2, x86asm.X3, x86asm.X4, x86asm.X5)
+		}
+		return uses, defs
+	case x86asm.PUSH:
+		if reg, ok := inst.Args[0].(x86asm.Reg); ok {
+			use(reg)
+		}
+		return uses, defs
+	case x86asm.POP:
+		if reg, ok := inst.Args[0].(x86asm.Reg); ok {
+			def(reg)
+		}
+		return uses, defs
+	case x86asm.CDQ, x86asm.CWD, x86asm.CQO:
+		use(eax)
+		def(edx)
+		return uses, defs
+	case x86asm.CBW, x86asm.CWDE, x86asm.CDQE:
+		use(eax)
+		def(eax)
+		return uses, defs
+	case x86asm.DIV, x86asm.IDIV:
+		use(eax, edx)
+		def(eax, edx)
+	case x86asm.MUL:
+		use(eax)
+		def(eax, edx)
+	case x86asm.IMUL:
+		if inst.Args[1] == nil {
+			use(eax)
+			def(eax, edx)
+		}
+	case x86asm.MOVSB, x86asm.MOVSW, x86asm.MOVSD, x86asm.MOVSQ, x86asm.CMPSB, x86asm.CMPSW, x86asm.CMPSD, x86asm.CMPSQ:
+		use(esi, edi)
+		def(esi, edi)
+	case x86asm.STOSB, x86asm.STOSW, x86asm.STOSD, x86asm.STOSQ, x86asm.SCASB, x86asm.SCASW, x86asm.SCASD, x86asm.SCASQ:
+		use(eax, edi)
+		def(edi)
+	case x86asm.LODSB, x86asm.LODSW, x86asm.LODSD, x86asm.LODSQ:
+		use(esi)
+		def(eax, esi)
+	case x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		use(ecx)
+		def(ecx)
+	case x86asm.JCXZ, x86asm.JECXZ, x86asm.JRCXZ:
+		use(ecx)
+	case x86asm.INT:
+		// DOS function calls; e.g. AH=09h and DX in `int 0x21`.
+		if l.Mode == 16 {
+			use(eax, x86asm.EBX, ecx, edx, esi, edi)
+			def(eax)
+			return uses, defs
+		}
+		// Failure code of __fastfail in ECX; i.e. `int 0x29`.
+		if inst.Args[0] == x86asm.Imm(x86.IntFastFail) {
+			use(ecx)
+			return uses, defs
+		}
+	case x86asm.RET, x86asm.LRET:
+		return uses, defs
+	}
+	for _, prefix := range inst.Prefix[:] {
+		if prefix == 0 {
+			break
+		}
+		switch prefix &^ x86asm.PrefixImplicit {
+		case x86asm.PrefixREP, x86asm.PrefixREPN:
+			use(ecx)
+			def(ecx)
+		}
+	}
+	// Explicit register operands.
+	for i, arg := range inst.Args {
+		reg, ok := arg.(x86asm.Reg)
+		if !ok {
+			continue
+		}
+		if i != 0 {
+			use(reg)
+			switch inst.Op {
+			case x86asm.XCHG, x86asm.XADD:
+				def(reg)
+			}
+			continue
+		}
+		// Destination operand.
+		switch inst.Op {
+		case x86asm.CMP, x86asm.TEST, x86asm.BT:
+			// Read only.
+			use(reg)
+		case x86asm.MOV, x86asm.MOVZX, x86asm.MOVSX, x86asm.MOVSXD, x86asm.LEA,
+			x86asm.SETA, x86asm.SETAE, x86asm.SETB, x86asm.SETBE, x86asm.SETE,
+			x86asm.SETG, x86asm.SETGE, x86asm.SETL, x86asm.SETLE, x86asm.SETNE,
+			x86asm.SETNO, x86asm.SETNP, x86asm.SETNS, x86asm.SETO, x86asm.SETP,
+			x86asm.SETS, x86asm.MOVD, x86asm.MOVQ, x86asm.MOVSD_XMM, x86asm.MOVSS,
+			x86asm.MOVAPD, x86asm.MOVAPS, x86asm.MOVUPD, x86asm.MOVUPS,
+			x86asm.CVTSI2SD, x86asm.CVTSI2SS, x86asm.CVTSS2SD, x86asm.CVTSD2SS:
+			// Write only.
+			def(reg)
+		case x86asm.XOR, x86asm.SUB, x86asm.SBB:
+			// Zeroing idiom; e.g. `xor eax, eax`.
+			if inst.Args[1] == reg && inst.Op != x86asm.SBB {
+				def(reg)
+				return uses, defs
+			}
+			use(reg)
+			def(reg)
+		default:
+			use(reg)
+			def(reg)
+		}
+	}
+	return uses, defs
+}
+
+// parentReg returns the largest enclosing general purpose register of the
+// given register (e.g. EAX for AL in 32-bit mode, and RAX in 64-bit mode). XMM
+// registers are returned as is. The zero register is returned for the stack
+// and instruction pointers, and for other registers.
+func (l *Lifter) parentReg(reg x86asm.Reg) x86asm.Reg {
+	// Index of the register within the sequence of general purpose registers
+	// (AX, CX, DX, BX, SP, BP, SI, DI, R8-R15).
+	var i int
+	switch {
+	case x86asm.X0 <= reg && reg <= x86asm.X15:
+		return reg
+	case reg == x86asm.AH, reg == x86asm.CH, reg == x86asm.DH, reg == x86asm.BH:
+		i = int(reg - x86asm.AH)
+	case x86asm.AL <= reg && reg <= x86asm.R15B:
+		i = regIndex(reg - x86asm.AL)
+	case x86asm.AX <= reg && reg <= x86asm.R15W:
+		i = int(reg - x86asm.AX)
+	case x86asm.EAX <= reg && reg <= x86asm.R15L:
+		i = int(reg - x86asm.EAX)
+	case x86asm.RAX <= reg && reg <= x86asm.R15:
+		i = int(reg - x86asm.RAX)
+	default:
+		return 0
+	}
+	// ESP is tracked separately.
+	if i == 4 {
+		return 0
+	}
+	if l.Mode == 64 {
+		return x86asm.RAX + x86asm.Reg(i)
+	}
+	return x86asm.EAX + x86asm.Reg(i)
+}
+
+// regIndex maps the offset of an 8-bit register from AL to the index of its
+// enclosing general purpose register.
+func regIndex(off x86asm.Reg) int {
+	// AL, CL, DL, BL, (AH, CH, DH, BH,) SPB, BPB, SIB, DIB, R8B-R15B.
+	if off < 4 {
+		return int(off)
+	}
+	return int(off - 4)
+}
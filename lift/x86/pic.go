@@ -0,0 +1,132 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// pcThunk reports whether the given CALL instruction retrieves the program
+// counter in 32-bit position-independent code; either through a call to the
+// next instruction (e.g. `call $+5` followed by `pop ebx`), or through a call to
+// a get-PC thunk (e.g. `call __x86.get_pc_thunk.bx`), which loads the return
+// address into a register.
+//
+//    __x86.get_pc_thunk.bx:
+//       mov     ebx, [esp]       ; 8B 1C 24
+//       ret                      ; C3
+//
+// The register defined by the get-PC thunk is returned; or 0 if the return
+// address is left on the stack.
+func (l *Lifter) pcThunk(inst *x86.Inst) (x86asm.Reg, bool) {
+	if l.Mode != 32 {
+		return 0, false
+	}
+	rel, ok := inst.Args[0].(x86asm.Rel)
+	if !ok {
+		return 0, false
+	}
+	next := inst.Addr + bin.Address(inst.Len)
+	target := next + bin.Address(rel)
+	if target == next {
+		return 0, true
+	}
+	code, err := l.File.ReadData(target, 4)
+	if err != nil || !l.File.IsCode(target) {
+		return 0, false
+	}
+	// mov reg, [esp]; ret
+	modrm := code[1]
+	if code[0] != 0x8B || modrm&0xC7 != 0x04 || code[2] != 0x24 || code[3] != 0xC3 {
+		return 0, false
+	}
+	regs := []x86asm.Reg{x86asm.EAX, x86asm.ECX, x86asm.EDX, x86asm.EBX, x86asm.ESP, x86asm.EBP, x86asm.ESI, x86asm.EDI}
+	reg := regs[modrm>>3&0x7]
+	if reg == x86asm.ESP {
+		return 0, false
+	}
+	return reg, true
+}
+
+// detectPIC locates the PIC base registers of the function; registers holding
+// a constant address (e.g. the address of the GOT in EBX) computed from the
+// program counter by a get-PC thunk, optionally followed by an ADD.
+//
+//    call    __x86.get_pc_thunk.bx
+//    add     ebx, _GLOBAL_OFFSET_TABLE_ - $
+//
+// Registers defined elsewhere in the function are disregarded. Memory accesses
+// relative to PIC base registers (e.g. `mov eax, [ebx+stdout@GOT]`) are
+// resolved to absolute addresses.
+func (f *Func) detectPIC() {
+	l := f.l
+	if l.Mode != 32 {
+		return
+	}
+	bases := make(map[x86asm.Reg]bin.Address)
+	invalid := make(map[x86asm.Reg]bool)
+	// Instructions of PIC base register computations.
+	seq := make(map[bin.Address]bool)
+	for _, block := range f.AsmFunc.Blocks {
+		insts := block.Insts
+		for i := 0; i < len(insts); i++ {
+			inst := insts[i]
+			if inst.Op != x86asm.CALL {
+				continue
+			}
+			reg, ok := l.pcThunk(inst)
+			if !ok {
+				continue
+			}
+			seq[inst.Addr] = true
+			base := inst.Addr + bin.Address(inst.Len)
+			if reg == 0 {
+				// call $+5; pop reg
+				if i+1 >= len(insts) || insts[i+1].Op != x86asm.POP {
+					continue
+				}
+				r, ok := insts[i+1].Args[0].(x86asm.Reg)
+				if !ok {
+					continue
+				}
+				i++
+				reg = r
+				seq[insts[i].Addr] = true
+			}
+			if i+1 < len(insts) && insts[i+1].Op == x86asm.ADD && insts[i+1].Args[0] == reg {
+				if imm, ok := insts[i+1].Args[1].(x86asm.Imm); ok {
+					i++
+					base += bin.Address(int32(imm))
+					seq[insts[i].Addr] = true
+				}
+			}
+			if prev, ok := bases[reg]; ok && prev != base {
+				invalid[reg] = true
+			}
+			bases[reg] = base
+		}
+	}
+	if len(bases) == 0 {
+		return
+	}
+	// Disregard registers defined outside of PIC base register computations.
+	for _, block := range f.AsmFunc.Blocks {
+		insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+		for _, inst := range insts {
+			if seq[inst.Addr] {
+				continue
+			}
+			_, defs := l.regUseDef(inst)
+			for _, reg := range defs {
+				invalid[reg] = true
+			}
+		}
+	}
+	for reg, base := range bases {
+		if invalid[reg] {
+			continue
+		}
+		dbg.Printf("PIC base register %v = %v in function at %v", reg, base, f.AsmFunc.Addr)
+		f.picRegs[reg] = base
+	}
+}
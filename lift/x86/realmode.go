@@ -0,0 +1,277 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// segmentSize specifies the size in bytes of segments of 16-bit real mode.
+const segmentSize = 0x10000
+
+// isSegReg reports whether the given register is a segment register.
+func isSegReg(reg x86asm.Reg) bool {
+	return x86asm.ES <= reg && reg <= x86asm.GS
+}
+
+// stackWord returns the size in bytes of values pushed onto the stack; 2 in
+// 16-bit real mode and 4 otherwise.
+func (l *Lifter) stackWord() int64 {
+	if l.Mode == 16 {
+		return 2
+	}
+	return 4
+}
+
+// retAddrSize returns the size in bytes of the return address of the function
+// on the stack; i.e. 4 for far procedures (CS:IP) of 16-bit real mode.
+func (f *Func) retAddrSize() int64 {
+	if f.far {
+		return 4
+	}
+	return f.l.stackWord()
+}
+
+// isFar reports whether the given function is a far procedure of 16-bit real
+// mode; i.e. returning with RETF.
+func isFar(asmFunc *x86.Func) bool {
+	for _, block := range asmFunc.Blocks {
+		if block.Term.Op == x86asm.LRET {
+			return true
+		}
+	}
+	return false
+}
+
+// realModeSegs returns the default values of the segment registers of 16-bit
+// real mode code; i.e. the initial segment registers at the entry point, with
+// the data segment replaced by the constant segment loaded into DS by the
+// startup code (e.g. `mov ax, DGROUP; mov ds, ax`). The data segment is assumed
+// to be preserved by every function (as in the small and medium memory models
+// of DOS compilers).
+func (l *Lifter) realModeSegs() map[x86asm.Reg]uint16 {
+	rm := l.File.RealMode
+	segs := map[x86asm.Reg]uint16{
+		x86asm.CS: rm.CS,
+		x86asm.DS: rm.DS,
+		x86asm.ES: rm.ES,
+		x86asm.SS: rm.SS,
+	}
+	consts := make(map[x86asm.Reg][]uint16)
+	invalid := make(map[x86asm.Reg]bool)
+	l.segDefs(l.startupInsts(l.File.Entry), segs, consts, invalid)
+	for _, reg := range []x86asm.Reg{x86asm.DS, x86asm.SS} {
+		if vs := consts[reg]; len(vs) > 0 {
+			dbg.Printf("default segment %v = 0x%04X, as loaded by startup code", reg, vs[0])
+			segs[reg] = vs[0]
+		}
+	}
+	return segs
+}
+
+// detectSegs locates the segment registers holding a constant segment within
+// the function of 16-bit real mode code. Segment registers hold their default
+// values (see realModeSegs), unless defined by the function; registers defined
+// to a single constant segment (e.g. `push cs; pop ds`) are assumed to hold the
+// segment throughout the function, and registers defined otherwise are
+// disregarded.
+//
+// CS is only assumed to hold the code segment of the entry point for functions
+// located within the segment.
+func (f *Func) detectSegs() {
+	l := f.l
+	if l.Mode != 16 || l.segs == nil {
+		return
+	}
+	segs := make(map[x86asm.Reg]uint16)
+	for reg, seg := range l.segs {
+		segs[reg] = seg
+	}
+	if cs, ok := segs[x86asm.CS]; ok {
+		start := bin.SegmentBase(cs)
+		if f.AsmFunc.Addr < start || f.AsmFunc.Addr >= start+segmentSize {
+			delete(segs, x86asm.CS)
+		}
+	}
+	consts := make(map[x86asm.Reg][]uint16)
+	invalid := make(map[x86asm.Reg]bool)
+	for _, block := range f.AsmFunc.Blocks {
+		l.segDefs(block.Insts, segs, consts, invalid)
+	}
+	for reg, vs := range consts {
+		for _, v := range vs[1:] {
+			if v != vs[0] {
+				invalid[reg] = true
+			}
+		}
+		segs[reg] = vs[0]
+	}
+	for reg := range invalid {
+		delete(segs, reg)
+	}
+	for reg, seg := range segs {
+		trace.Printf("segment %v = 0x%04X in function at %v", reg, seg, f.AsmFunc.Addr)
+	}
+	f.segRegs = segs
+}
+
+// segDefs records the definitions of segment registers by the given sequence of
+// instructions; constant segments loaded through a general purpose register
+// (e.g. `mov ax, DGROUP; mov ds, ax`) or through the stack (e.g. `push cs;
+// pop ds`) are appended to consts, and registers defined otherwise are recorded
+// in invalid. The values of segment registers prior to the sequence are given
+// by segs.
+func (l *Lifter) segDefs(insts []*x86.Inst, segs map[x86asm.Reg]uint16, consts map[x86asm.Reg][]uint16, invalid map[x86asm.Reg]bool) {
+	// Current values of segment registers.
+	cur := make(map[x86asm.Reg]uint16)
+	for reg, seg := range segs {
+		cur[reg] = seg
+	}
+	// Constant values of 16-bit general purpose registers, identified by their
+	// largest enclosing register.
+	gprs := make(map[x86asm.Reg]uint16)
+	// Values pushed onto the stack; or nil if unknown.
+	var stack []*uint16
+	define := func(reg x86asm.Reg, v *uint16) {
+		if v == nil {
+			invalid[reg] = true
+			delete(cur, reg)
+			return
+		}
+		consts[reg] = append(consts[reg], *v)
+		cur[reg] = *v
+	}
+	// value returns the value of the given 16-bit operand; or nil if unknown.
+	value := func(arg x86asm.Arg) *uint16 {
+		switch arg := arg.(type) {
+		case x86asm.Imm:
+			v := uint16(arg)
+			return &v
+		case x86asm.Reg:
+			if isSegReg(arg) {
+				if v, ok := cur[arg]; ok {
+					return &v
+				}
+				return nil
+			}
+			if v, ok := gprs[l.parentReg(arg)]; ok && regBits(arg) == 16 {
+				return &v
+			}
+		}
+		return nil
+	}
+	for _, inst := range insts {
+		dst, _ := inst.Args[0].(x86asm.Reg)
+		switch inst.Op {
+		case x86asm.PUSH:
+			stack = append(stack, value(inst.Args[0]))
+			continue
+		case x86asm.POP:
+			var v *uint16
+			if n := len(stack); n > 0 {
+				v = stack[n-1]
+				stack = stack[:n-1]
+			}
+			if isSegReg(dst) {
+				define(dst, v)
+				continue
+			}
+		case x86asm.MOV:
+			if isSegReg(dst) {
+				define(dst, value(inst.Args[1]))
+				continue
+			}
+		case x86asm.LDS:
+			define(x86asm.DS, nil)
+		case x86asm.LES:
+			define(x86asm.ES, nil)
+		case x86asm.LSS:
+			define(x86asm.SS, nil)
+		case x86asm.LFS:
+			define(x86asm.FS, nil)
+		case x86asm.LGS:
+			define(x86asm.GS, nil)
+		}
+		_, defs := l.regUseDef(inst)
+		for _, reg := range defs {
+			delete(gprs, reg)
+		}
+		if inst.Op == x86asm.MOV && l.parentReg(dst) != 0 && regBits(dst) == 16 {
+			if imm, ok := inst.Args[1].(x86asm.Imm); ok {
+				gprs[l.parentReg(dst)] = uint16(imm)
+			}
+		}
+	}
+}
+
+// segBase returns the linear base address of the segment of the given memory
+// reference of 16-bit real mode, based on the given constant segment registers;
+// the segment override, or the default segment (SS for memory references based
+// on BP or SP, and DS otherwise). The boolean return value indicates whether
+// the segment is known.
+//
+// In 32- and 64-bit mode, the base address of memory references without
+// segment override is 0, and other segments are unknown.
+func (l *Lifter) segBase(segRegs map[x86asm.Reg]uint16, mem x86asm.Mem) (bin.Address, bool) {
+	if l.Mode != 16 {
+		return 0, mem.Segment == 0
+	}
+	seg := mem.Segment
+	if seg == 0 {
+		switch mem.Base {
+		case x86asm.BP, x86asm.SP, x86asm.EBP, x86asm.ESP:
+			seg = x86asm.SS
+		default:
+			seg = x86asm.DS
+		}
+	}
+	v, ok := segRegs[seg]
+	if !ok {
+		return 0, false
+	}
+	return bin.SegmentBase(v), true
+}
+
+// memDisp returns the displacement of the given memory reference, as an
+// offset within its segment in 16-bit real mode.
+func (l *Lifter) memDisp(mem x86asm.Mem) bin.Address {
+	if l.Mode == 16 {
+		return bin.Address(uint16(mem.Disp))
+	}
+	return bin.Address(mem.Disp)
+}
+
+// isSegOverride reports whether the given instruction prefix is a segment
+// override prefix resolved by f.mem; i.e. any segment override prefix of 16-bit
+// real mode, and FS and GS override prefixes of 32- and 64-bit code.
+func (l *Lifter) isSegOverride(prefix x86asm.Prefix) bool {
+	switch prefix &^ x86asm.PrefixImplicit {
+	case x86asm.PrefixFS, x86asm.PrefixGS:
+		return true
+	case x86asm.PrefixES, x86asm.PrefixCS, x86asm.PrefixSS, x86asm.PrefixDS:
+		return l.Mode == 16
+	}
+	return false
+}
+
+// nearInst returns the near equivalent of the given direct far call or jump
+// instruction of 16-bit real mode (e.g. `call 0x1234:0x0010` is translated to
+// a CALL instruction with relative target). Targets are identified by linear
+// address, and far calls are thus lifted as regular calls. The boolean return
+// value indicates success.
+func nearInst(inst *x86.Inst) (*x86.Inst, bool) {
+	target, ok := x86.FarTarget(inst)
+	if !ok {
+		return nil, false
+	}
+	near := *inst
+	switch inst.Op {
+	case x86asm.LCALL:
+		near.Op = x86asm.CALL
+	case x86asm.LJMP:
+		near.Op = x86asm.JMP
+	}
+	next := inst.Addr + bin.Address(inst.Len)
+	near.Args = x86asm.Args{x86asm.Rel(int32(target - next))}
+	return &near, true
+}
@@ -38,7 +38,7 @@ func regType(reg x86asm.Reg) types.Type {
 		panic(fmt.Errorf("support for register %v not yet implemented", reg))
 	// XMM registers.
 	case x86asm.X0, x86asm.X1, x86asm.X2, x86asm.X3, x86asm.X4, x86asm.X5, x86asm.X6, x86asm.X7, x86asm.X8, x86asm.X9, x86asm.X10, x86asm.X11, x86asm.X12, x86asm.X13, x86asm.X14, x86asm.X15:
-		panic(fmt.Errorf("support for register %v not yet implemented", reg))
+		return types.I128
 	// Segment registers.
 	case x86asm.ES, x86asm.CS, x86asm.SS, x86asm.DS, x86asm.FS, x86asm.GS:
 		return types.I16
@@ -74,3 +74,33 @@ func regType(reg x86asm.Reg) types.Type {
 		panic(fmt.Errorf("support for register %v not yet implemented", reg))
 	}
 }
+
+// regPair returns the high and low halves of the given PSEUDO-register (e.g.
+// EDX and EAX of EDX:EAX), and a boolean indicating success.
+func regPair(reg x86asm.Reg) (hi, lo *x86.Reg, ok bool) {
+	switch reg {
+	case x86.X86asm_DX_AX:
+		return x86.DX, x86.AX, true
+	case x86.X86asm_EDX_EAX:
+		return x86.EDX, x86.EAX, true
+	case x86.X86asm_RDX_RAX:
+		return x86.RDX, x86.RAX, true
+	}
+	return nil, nil, false
+}
+
+// subReg returns the parent register holding the storage of the given x86
+// sub-register (e.g. EAX of AH in 32-bit mode), and the bit offset of the
+// sub-register within the parent register. The boolean return value indicates
+// whether reg is a sub-register of a larger general purpose register.
+func (l *Lifter) subReg(reg x86asm.Reg) (parent x86asm.Reg, shift int, ok bool) {
+	parent = l.parentReg(reg)
+	if parent == 0 || parent == reg || x86asm.X0 <= reg && reg <= x86asm.X15 {
+		return 0, 0, false
+	}
+	switch reg {
+	case x86asm.AH, x86asm.CH, x86asm.DH, x86asm.BH:
+		shift = 8
+	}
+	return parent, shift, true
+}
@@ -0,0 +1,400 @@
+package x86
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Return type inference states.
+const (
+	// Return type not yet inferred.
+	retUnknown = iota
+	// Return type inference in progress; used to break cycles of recursive
+	// functions.
+	retPending
+	// Return type inferred.
+	retDone
+)
+
+// resolveRet infers the return type of the function if the function signature
+// is unknown, and updates the function signature accordingly.
+//
+// Return type inference depends on the uses of return registers in callers,
+// and is therefore postponed until all function lifters have been created;
+// i.e. the first time the function is lifted or called.
+func (f *Func) resolveRet() {
+	if !f.sigUnknown || f.AsmFunc == nil || f.retState != retUnknown {
+		return
+	}
+	f.retState = retPending
+	if ret := f.inferRet(); !types.Equal(ret, types.Void) {
+		sig := types.NewFunc(ret, f.Sig.Params...)
+		sig.Variadic = f.Sig.Variadic
+		f.Sig = sig
+		f.Typ = types.NewPointer(sig)
+		dbg.Printf("inferred return type of function %q at %v: %v", f.Name, f.AsmFunc.Addr, ret)
+	}
+	f.retState = retDone
+}
+
+// inferRet returns the inferred return type of the function, based on the
+// registers defined before every return and read by callers after the call;
+// EAX (i32) or EDX:EAX (i64) in 32-bit mode, and RAX (i64) or XMM0 (double) in
+// 64-bit mode.
+func (f *Func) inferRet() types.Type {
+	defined := f.definedAtRet()
+	if len(defined) == 0 {
+		return types.Void
+	}
+	used := f.usedByCallers()
+	isRet := func(reg x86asm.Reg) bool {
+		return defined[reg] && used[reg]
+	}
+	switch f.l.Mode {
+	case 16:
+		// Registers of 16-bit mode are identified by their enclosing 32-bit
+		// register.
+		if isRet(x86asm.EAX) {
+			if isRet(x86asm.EDX) {
+				return types.I32
+			}
+			return types.I16
+		}
+	case 32:
+		if isRet(x86asm.EAX) {
+			if isRet(x86asm.EDX) {
+				return types.I64
+			}
+			return types.I32
+		}
+	case 64:
+		switch {
+		case isRet(x86asm.RAX):
+			return types.I64
+		case isRet(x86asm.X0):
+			return types.Double
+		}
+	default:
+		panic(fmt.Errorf("support for processor mode %d not yet implemented", f.l.Mode))
+	}
+	return types.Void
+}
+
+// retRegs returns the registers holding return values of the given type.
+// Registers are identified by their largest enclosing register.
+func (l *Lifter) retRegs(ret types.Type) []x86asm.Reg {
+	if types.Equal(ret, types.Void) {
+		return nil
+	}
+	switch l.Mode {
+	case 16:
+		if types.Equal(ret, types.I32) {
+			return []x86asm.Reg{x86asm.EAX, x86asm.EDX}
+		}
+		return []x86asm.Reg{x86asm.EAX}
+	case 32:
+		if types.Equal(ret, types.I64) {
+			return []x86asm.Reg{x86asm.EAX, x86asm.EDX}
+		}
+		return []x86asm.Reg{x86asm.EAX}
+	case 64:
+		if _, ok := ret.(*types.FloatType); ok {
+			return []x86asm.Reg{x86asm.X0}
+		}
+		return []x86asm.Reg{x86asm.RAX}
+	default:
+		panic(fmt.Errorf("support for processor mode %d not yet implemented", l.Mode))
+	}
+}
+
+// retCandidates returns the registers which may hold return values.
+func (l *Lifter) retCandidates() []x86asm.Reg {
+	switch l.Mode {
+	case 16, 32:
+		return []x86asm.Reg{x86asm.EAX, x86asm.EDX}
+	case 64:
+		return []x86asm.Reg{x86asm.RAX, x86asm.X0}
+	default:
+		panic(fmt.Errorf("support for processor mode %d not yet implemented", l.Mode))
+	}
+}
+
+// isReturn reports whether the given instruction returns to the caller of the
+// function; i.e. a near or far RET instruction.
+func isReturn(inst *x86.Inst) bool {
+	return inst.Op == x86asm.RET || inst.Op == x86asm.LRET
+}
+
+// isCall reports whether the given instruction transfers control to the callee
+// of a function call; i.e. a CALL instruction or a tail call JMP instruction.
+func (f *Func) isCall(inst *x86.Inst) bool {
+	switch inst.Op {
+	case x86asm.CALL, x86asm.LCALL:
+		return true
+	case x86asm.JMP:
+		target, ok := f.getAddr(inst.Arg(0))
+		if !ok || f.contains(target) {
+			return false
+		}
+		_, ok = f.l.funcAt(target)
+		return ok
+	}
+	return false
+}
+
+// definedAtRet returns the set of registers defined on every path from the
+// entry of the function to a return; i.e. a RET instruction or tail call.
+// Registers clobbered by callees are only considered defined when holding the
+// return value of the callee.
+func (f *Func) definedAtRet() map[x86asm.Reg]bool {
+	// step updates the set of defined registers based on the given instruction.
+	step := func(inst *x86.Inst, defined map[x86asm.Reg]bool) {
+		_, defs := f.l.regUseDef(inst)
+		if f.isCall(inst) {
+			for _, reg := range defs {
+				delete(defined, reg)
+			}
+			if callee, ok := f.callee(inst); ok {
+				callee.resolveRet()
+				for _, reg := range f.l.retRegs(callee.Sig.Ret) {
+					defined[reg] = true
+				}
+			}
+			return
+		}
+		for _, reg := range defs {
+			defined[reg] = true
+		}
+	}
+	blockAddrs := f.blockAddrs()
+	preds := make(map[bin.Address][]bin.Address)
+	for _, blockAddr := range blockAddrs {
+		for _, succ := range f.succs(f.AsmFunc.Blocks[blockAddr]) {
+			preds[succ] = append(preds[succ], blockAddr)
+		}
+	}
+	// Forward data flow analysis; iterate until fixed point. The set of defined
+	// registers at the end of a basic block is nil until the basic block has
+	// been reached.
+	defOut := make(map[bin.Address]map[x86asm.Reg]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, blockAddr := range blockAddrs {
+			block := f.AsmFunc.Blocks[blockAddr]
+			var in map[x86asm.Reg]bool
+			if blockAddr != f.AsmFunc.Addr {
+				for _, pred := range preds[blockAddr] {
+					out, ok := defOut[pred]
+					if !ok {
+						continue
+					}
+					in = intersect(in, out)
+				}
+				if in == nil {
+					// Not yet reached.
+					continue
+				}
+			}
+			out := make(map[x86asm.Reg]bool)
+			for reg := range in {
+				out[reg] = true
+			}
+			for _, inst := range block.Insts {
+				step(inst, out)
+			}
+			step(block.Term, out)
+			if prev, ok := defOut[blockAddr]; !ok || len(prev) != len(out) {
+				defOut[blockAddr] = out
+				changed = true
+			}
+		}
+	}
+	// Registers defined at every return. Basic blocks terminated by CALL
+	// instructions end in calls to noreturn functions (e.g. exit), and are thus
+	// not returns.
+	var defined map[x86asm.Reg]bool
+	for _, blockAddr := range blockAddrs {
+		term := f.AsmFunc.Blocks[blockAddr].Term
+		if !isReturn(term) && !(term.Op == x86asm.JMP && f.isCall(term)) {
+			continue
+		}
+		out, ok := defOut[blockAddr]
+		if !ok {
+			continue
+		}
+		defined = intersect(defined, out)
+		if len(defined) == 0 {
+			return nil
+		}
+	}
+	return defined
+}
+
+// intersect returns the intersection of the given sets of registers. A nil set
+// represents the universal set.
+func intersect(a, b map[x86asm.Reg]bool) map[x86asm.Reg]bool {
+	if a == nil {
+		a, b = b, a
+	}
+	if b == nil {
+		return a
+	}
+	c := make(map[x86asm.Reg]bool)
+	for reg := range a {
+		if b[reg] {
+			c[reg] = true
+		}
+	}
+	return c
+}
+
+// usedByCallers returns the set of registers read after calls to the function
+// before being defined, on some path within a caller of the function.
+func (f *Func) usedByCallers() map[x86asm.Reg]bool {
+	used := make(map[x86asm.Reg]bool)
+	var funcAddrs bin.Addresses
+	for funcAddr, caller := range f.l.Funcs {
+		if caller.AsmFunc != nil {
+			funcAddrs = append(funcAddrs, funcAddr)
+		}
+	}
+	sort.Sort(funcAddrs)
+	for _, funcAddr := range funcAddrs {
+		caller := f.l.Funcs[funcAddr]
+		// liveIn is only computed for callers of f.
+		var liveIn map[bin.Address]map[x86asm.Reg]bool
+		var retRegs []x86asm.Reg
+		for _, block := range caller.AsmFunc.Blocks {
+			insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+			for i, inst := range insts {
+				if !caller.isCall(inst) {
+					continue
+				}
+				if callee, ok := caller.callee(inst); !ok || callee != f {
+					continue
+				}
+				if liveIn == nil {
+					caller.resolveRet()
+					retRegs = f.l.retRegs(caller.Sig.Ret)
+					liveIn = caller.liveRegs(retRegs)
+				}
+				// Registers not yet defined after the call.
+				pending := make(map[x86asm.Reg]bool)
+				for _, reg := range f.l.retCandidates() {
+					pending[reg] = true
+				}
+				if inst.Op == x86asm.JMP {
+					// Tail call; return value passed on to the caller of caller.
+					for _, reg := range retRegs {
+						used[reg] = true
+					}
+					continue
+				}
+				for _, inst := range insts[i+1:] {
+					uses, defs := f.l.regUseDef(inst)
+					if isReturn(inst) || inst.Op == x86asm.JMP && caller.isCall(inst) {
+						uses = retRegs
+					}
+					for _, reg := range uses {
+						if pending[reg] {
+							used[reg] = true
+						}
+					}
+					for _, reg := range defs {
+						delete(pending, reg)
+					}
+				}
+				for _, succ := range caller.succs(block) {
+					for reg := range liveIn[succ] {
+						if pending[reg] {
+							used[reg] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return used
+}
+
+// useRet loads and returns the return value of the function, emitting code to
+// f.
+func (f *Func) useRet() value.Value {
+	ret := f.Sig.Ret
+	switch f.l.Mode {
+	case 16:
+		if types.Equal(ret, types.I32) {
+			// Return value passed in DX:AX.
+			ax := f.useReg(x86.AX)
+			dx := f.useReg(x86.DX)
+			lo := f.cur.NewZExt(ax, types.I32)
+			tmp := f.cur.NewZExt(dx, types.I32)
+			hi := f.cur.NewShl(tmp, constant.NewInt(16, types.I32))
+			return f.cur.NewOr(hi, lo)
+		}
+		return f.useRegElem(x86.AX, ret)
+	case 32:
+		if types.Equal(ret, types.I64) {
+			// Return value passed in EDX:EAX.
+			eax := f.useReg(x86.EAX)
+			edx := f.useReg(x86.EDX)
+			lo := f.cur.NewZExt(eax, types.I64)
+			tmp := f.cur.NewZExt(edx, types.I64)
+			hi := f.cur.NewShl(tmp, constant.NewInt(32, types.I64))
+			return f.cur.NewOr(hi, lo)
+		}
+		return f.useReg(x86.EAX)
+	case 64:
+		if _, ok := ret.(*types.FloatType); ok {
+			return f.useRegElem(x86.X0, ret)
+		}
+		return f.useRegElem(x86.RAX, ret)
+	default:
+		panic(fmt.Errorf("support for processor mode %d not yet implemented", f.l.Mode))
+	}
+}
+
+// defRet stores the return value of a function call with the given function
+// signature, emitting code to f.
+func (f *Func) defRet(sig *types.FuncType, result value.Value) {
+	ret := sig.Ret
+	switch f.l.Mode {
+	case 16:
+		if types.Equal(ret, types.I32) {
+			// Return value passed in DX:AX.
+			lo := f.cur.NewTrunc(result, types.I16)
+			f.defReg(x86.AX, lo)
+			tmp := f.cur.NewLShr(result, constant.NewInt(16, types.I32))
+			hi := f.cur.NewTrunc(tmp, types.I16)
+			f.defReg(x86.DX, hi)
+			return
+		}
+		f.defRegElem(x86.AX, result, ret)
+	case 32:
+		if types.Equal(ret, types.I64) {
+			// Return value passed in EDX:EAX.
+			lo := f.cur.NewTrunc(result, types.I32)
+			f.defReg(x86.EAX, lo)
+			tmp := f.cur.NewLShr(result, constant.NewInt(32, types.I64))
+			hi := f.cur.NewTrunc(tmp, types.I32)
+			f.defReg(x86.EDX, hi)
+			return
+		}
+		f.defRegElem(x86.EAX, result, ret)
+	case 64:
+		if _, ok := ret.(*types.FloatType); ok {
+			f.defRegElem(x86.X0, result, ret)
+			return
+		}
+		f.defRegElem(x86.RAX, result, ret)
+	default:
+		panic(fmt.Errorf("support for processor mode %d not yet implemented", f.l.Mode))
+	}
+}
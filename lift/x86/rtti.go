@@ -0,0 +1,169 @@
+package x86
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+)
+
+// maxBaseClasses specifies the maximum number of base classes of class
+// hierarchy descriptors.
+const maxBaseClasses = 256
+
+// A Class is a C++ class, as recovered from MSVC run-time type information
+// (RTTI).
+type Class struct {
+	// Class name; e.g. "ns::Foo".
+	Name string
+	// Names of base classes, in order of the class hierarchy descriptor.
+	Bases []string
+}
+
+// parseRTTI parses the MSVC run-time type information of the vtable at the
+// given address. The boolean return value indicates success.
+//
+// The pointer-sized entry preceding the vtable points to the Complete Object
+// Locator of the class.
+//
+//    struct RTTICompleteObjectLocator {
+//       uint32 signature;        // 0 on x86, 1 on x64
+//       uint32 offset;           // offset of vtable within class
+//       uint32 cdOffset;         // constructor displacement offset
+//       uint32 pTypeDescriptor;  // VA on x86, RVA on x64
+//       uint32 pClassDescriptor; // VA on x86, RVA on x64
+//    };
+//
+//    struct RTTIClassHierarchyDescriptor {
+//       uint32 signature;
+//       uint32 attributes;
+//       uint32 numBaseClasses;
+//       uint32 pBaseClassArray;  // VA on x86, RVA on x64
+//    };
+//
+//    struct RTTIBaseClassDescriptor {
+//       uint32 pTypeDescriptor;  // VA on x86, RVA on x64
+//       ...
+//    };
+func (l *Lifter) parseRTTI(vtAddr bin.Address) (*Class, bool) {
+	ptrSize := bin.Address(l.Mode / 8)
+	v, err := l.File.ReadUintptr(vtAddr - ptrSize)
+	if err != nil {
+		return nil, false
+	}
+	col := bin.Address(v)
+	if l.File.Perm(col)&bin.PermR == 0 {
+		return nil, false
+	}
+	// RTTI addresses are image relative on x64.
+	rva := func(v uint32) bin.Address {
+		if l.Mode == 64 {
+			return l.File.ImageBase + bin.Address(v)
+		}
+		return bin.Address(v)
+	}
+	read := func(addr bin.Address) (bin.Address, bool) {
+		v, err := l.File.ReadUint32(addr)
+		if err != nil {
+			return 0, false
+		}
+		return rva(v), true
+	}
+	sig, err := l.File.ReadUint32(col)
+	if err != nil || l.Mode == 32 && sig != 0 || l.Mode == 64 && sig != 1 {
+		return nil, false
+	}
+	td, ok := read(col + 12)
+	if !ok {
+		return nil, false
+	}
+	name, ok := l.typeDescName(td)
+	if !ok {
+		return nil, false
+	}
+	class := &Class{Name: name}
+	// Parse base classes; the first entry of the base class array is the class
+	// itself.
+	chd, ok := read(col + 16)
+	if !ok {
+		return class, true
+	}
+	nbases, err := l.File.ReadUint32(chd + 8)
+	if err != nil || nbases > maxBaseClasses {
+		return class, true
+	}
+	bca, ok := read(chd + 12)
+	if !ok {
+		return class, true
+	}
+	for i := 1; i < int(nbases); i++ {
+		bcd, ok := read(bca + bin.Address(4*i))
+		if !ok {
+			break
+		}
+		btd, ok := read(bcd)
+		if !ok {
+			break
+		}
+		base, ok := l.typeDescName(btd)
+		if !ok {
+			break
+		}
+		class.Bases = append(class.Bases, base)
+	}
+	dbg.Printf("located RTTI of class %q (bases %q) at %v", class.Name, class.Bases, col)
+	return class, true
+}
+
+// typeDescName returns the demangled class name of the RTTI Type Descriptor at
+// the given address. The boolean return value indicates success.
+//
+//    struct TypeDescriptor {
+//       void *pVFTable;
+//       void *spare;
+//       char name[]; // e.g. ".?AVFoo@@"
+//    };
+func (l *Lifter) typeDescName(td bin.Address) (string, bool) {
+	data, ok := l.dataSection(td + bin.Address(2*l.Mode/8))
+	if !ok {
+		return "", false
+	}
+	end := bytes.IndexByte(data, 0)
+	if end == -1 {
+		return "", false
+	}
+	return demangleRTTI(string(data[:end]))
+}
+
+// demangleRTTI returns the class name of the given mangled RTTI type name; e.g.
+// "ns::Foo" for ".?AVFoo@ns@@". The boolean return value indicates success.
+//
+// Names of template classes (e.g. ".?AV?$Foo@H@@") are returned as is, after
+// the class or struct prefix.
+func demangleRTTI(s string) (string, bool) {
+	switch {
+	case strings.HasPrefix(s, ".?AV"), strings.HasPrefix(s, ".?AU"):
+		s = s[len(".?AV"):]
+	default:
+		return "", false
+	}
+	if strings.HasPrefix(s, "?") || !strings.HasSuffix(s, "@@") {
+		return s, len(s) > 0
+	}
+	parts := strings.Split(strings.TrimSuffix(s, "@@"), "@")
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, "::"), true
+}
+
+// ctorName returns the name of the constructor of the given class; e.g.
+// "ns::Foo::Foo" for "ns::Foo".
+func ctorName(class *Class) string {
+	name := class.Name
+	if pos := strings.LastIndex(name, "::"); pos != -1 {
+		name = name[pos+len("::"):]
+	}
+	return fmt.Sprintf("%s::%s", class.Name, name)
+}
@@ -0,0 +1,27 @@
+package x86
+
+import "testing"
+
+func TestDemangleRTTI(t *testing.T) {
+	golden := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{in: ".?AVFoo@@", want: "Foo", ok: true},
+		{in: ".?AUBar@@", want: "Bar", ok: true},
+		{in: ".?AVBaz@inner@outer@@", want: "outer::inner::Baz", ok: true},
+		{in: ".?AV?$Vec@H@@", want: "?$Vec@H@@", ok: true},
+		{in: ".H", ok: false},
+	}
+	for _, g := range golden {
+		got, ok := demangleRTTI(g.in)
+		if ok != g.ok {
+			t.Errorf("%q: success mismatch; expected %v, got %v", g.in, g.ok, ok)
+			continue
+		}
+		if got != g.want {
+			t.Errorf("%q: class name mismatch; expected %q, got %q", g.in, g.want, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package x86
+
+import (
+	"strings"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Runtime helpers ] #####################################################
+
+// Names of the helper functions provided by the runtime shim of lifted modules.
+const (
+	// RuntimeSyscall performs a Linux system call of 64-bit code (SYSCALL).
+	RuntimeSyscall = "sys.syscall"
+	// RuntimeInt80 performs a Linux system call of 32-bit code (INT 80h).
+	RuntimeInt80 = "sys.int80"
+	// RuntimeFSBase returns the base address of the FS segment.
+	RuntimeFSBase = "seg.fs_base"
+	// RuntimeGSBase returns the base address of the GS segment.
+	RuntimeGSBase = "seg.gs_base"
+	// RuntimeFastFail terminates the process with the given failure code
+	// (__fastfail of MSVC; INT 29h).
+	RuntimeFastFail = "sys.fastfail"
+)
+
+// intLinux invokes the Linux system call specified by EAX (INT 80h).
+const intLinux = 0x80
+
+// A syscallConv specifies the registers of a system call convention.
+type syscallConv struct {
+	// Registers holding the system call number and arguments.
+	params []x86asm.Reg
+	// Register holding the result of the system call.
+	ret x86asm.Reg
+}
+
+var (
+	// syscallConv64 specifies the system call convention of 64-bit Linux
+	// (SYSCALL).
+	syscallConv64 = syscallConv{
+		params: []x86asm.Reg{x86asm.RAX, x86asm.RDI, x86asm.RSI, x86asm.RDX, x86asm.R10, x86asm.R8, x86asm.R9},
+		ret:    x86asm.RAX,
+	}
+	// syscallConv32 specifies the system call convention of 32-bit Linux (INT
+	// 80h).
+	syscallConv32 = syscallConv{
+		params: []x86asm.Reg{x86asm.EAX, x86asm.EBX, x86asm.ECX, x86asm.EDX, x86asm.ESI, x86asm.EDI, x86asm.EBP},
+		ret:    x86asm.EAX,
+	}
+)
+
+// runtimeFunc returns the helper function of the runtime shim with the given
+// name and signature, declaring it on first use.
+func (l *Lifter) runtimeFunc(name string, ret types.Type, params ...*types.Param) *ir.Function {
+	if fn, ok := l.Stubs[name]; ok {
+		return fn
+	}
+	sig := types.NewFunc(ret, params...)
+	fn := &ir.Function{
+		Name: name,
+		Typ:  types.NewPointer(sig),
+		Sig:  sig,
+	}
+	l.Stubs[name] = fn
+	return fn
+}
+
+// liftSyscall lifts the given system call instruction (e.g. SYSCALL) to LLVM IR
+// as a call to the specified system call helper of the runtime shim (e.g.
+// @sys.syscall), emitting code to f. The system call number and arguments are
+// passed as arguments, and the result is stored in the result register of the
+// system call convention.
+func (f *Func) liftSyscall(inst *x86.Inst, name string, conv syscallConv) {
+	var params []*types.Param
+	var args []value.Value
+	for _, reg := range conv.params {
+		name := strings.ToLower(x86.Register(reg).String())
+		params = append(params, types.NewParam(name, regType(reg)))
+		args = append(args, f.useReg(x86.NewReg(reg, inst)))
+	}
+	callee := f.l.runtimeFunc(name, regType(conv.ret), params...)
+	call := f.cur.NewCall(callee, args...)
+	call.Metadata = map[string]*metadata.Metadata{
+		"addr": {
+			Nodes: []metadata.Node{&metadata.String{Val: inst.Addr.String()}},
+		},
+	}
+	f.defReg(x86.NewReg(conv.ret, inst), call)
+}
+
+// segMem returns a pointer to the memory referenced by the given FS- or
+// GS-relative memory argument of 32- or 64-bit code (e.g. the thread
+// information block at `fs:[0x18]`, or the stack protector canary at
+// `fs:[0x28]`), emitting code to f. The base address of the segment is
+// retrieved using the @seg.fs_base or @seg.gs_base helper of the runtime shim.
+func (f *Func) segMem(mem *x86.Mem) value.Value {
+	name := RuntimeFSBase
+	if mem.Mem.Segment == x86asm.GS {
+		name = RuntimeGSBase
+	}
+	callee := f.l.runtimeFunc(name, types.NewPointer(types.I8))
+	var src value.Value = f.cur.NewCall(callee)
+	// Segment:[Base+Scale*Index+Disp], as byte offsets from the segment base.
+	typ := types.NewInt(f.l.Mode)
+	if mem.Mem.Base != 0 {
+		base := f.convert(f.useReg(mem.Base()), typ)
+		src = f.cur.NewGetElementPtr(src, base)
+	}
+	if mem.Mem.Index != 0 {
+		index := f.convert(f.useReg(mem.Index()), typ)
+		if mem.Scale > 1 {
+			index = f.cur.NewMul(index, constant.NewInt(int64(mem.Scale), typ))
+		}
+		src = f.cur.NewGetElementPtr(src, index)
+	}
+	if mem.Disp != 0 {
+		src = f.cur.NewGetElementPtr(src, constant.NewInt(mem.Disp, typ))
+	}
+	return f.castToPtr(src, mem.Parent)
+}
@@ -0,0 +1,47 @@
+package x86
+
+import (
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// addBundledProtos refines the placeholder signatures of imported functions
+// based on the bundled signature databases of the file format of the binary
+// executable; Windows API functions and the C runtime (msvcrt.dll) of PE files,
+// and the C standard library and POSIX functions of ELF files. The startup
+// functions of the Microsoft C runtime are included for PE files compiled by
+// MSVC and MinGW.
+func (l *Lifter) addBundledProtos() error {
+	var src string
+	switch l.File.Format {
+	case "pe":
+		src = win32Header + libcHeader
+		switch l.File.Compiler {
+		case bin.CompilerMSVC, bin.CompilerMinGW:
+			src += msvcrtHeader
+		}
+	case "elf":
+		src = libcHeader
+	default:
+		return nil
+	}
+	protos, err := l.ParseHeader(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, proto := range protos {
+		proto.bundled = true
+		// TLS callbacks share the prototype of PIMAGE_TLS_CALLBACK.
+		if proto.Name == "TlsCallback" {
+			for _, name := range l.File.Roots {
+				if strings.HasPrefix(name, "TlsCallback_") {
+					protos = append(protos, &Proto{Name: name, CallConv: proto.CallConv, RegConv: proto.RegConv, Sig: proto.Sig, bundled: true})
+				}
+			}
+		}
+	}
+	l.AddProtos(protos)
+	return nil
+}
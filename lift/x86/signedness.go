@@ -0,0 +1,236 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Signedness inference ] ################################################
+
+// signedness specifies the signedness of an integer value.
+type signedness uint8
+
+// Signedness of integer values.
+const (
+	// Signedness not yet known.
+	signUnknown signedness = iota
+	// Signed integer value.
+	signSigned
+	// Unsigned integer value.
+	signUnsigned
+	// Integer value used both as signed and unsigned.
+	signConflict
+)
+
+// String returns the string representation of the signedness, as recorded in
+// "signedness" metadata.
+func (sign signedness) String() string {
+	switch sign {
+	case signSigned:
+		return "signed"
+	case signUnsigned:
+		return "unsigned"
+	case signConflict:
+		return "conflict"
+	}
+	return "unknown"
+}
+
+// join returns the signedness of a value used with both signednesses.
+func (sign signedness) join(other signedness) signedness {
+	switch {
+	case sign == signUnknown:
+		return other
+	case other == signUnknown || sign == other:
+		return sign
+	}
+	return signConflict
+}
+
+// condSign returns the signedness of the operands of comparisons, as implied by
+// the condition of the given conditional instruction; e.g. signed for JG and
+// unsigned for JA.
+func condSign(op x86asm.Op) signedness {
+	switch op {
+	case x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE,
+		x86asm.SETG, x86asm.SETGE, x86asm.SETL, x86asm.SETLE,
+		x86asm.CMOVG, x86asm.CMOVGE, x86asm.CMOVL, x86asm.CMOVLE:
+		return signSigned
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE,
+		x86asm.SETA, x86asm.SETAE, x86asm.SETB, x86asm.SETBE,
+		x86asm.CMOVA, x86asm.CMOVAE, x86asm.CMOVB, x86asm.CMOVBE:
+		return signUnsigned
+	}
+	return signUnknown
+}
+
+// inferSignedness infers the signedness of the integer values of memory
+// operands (i.e. stack slots and global variables) of the function, based on
+// the comparison conditions (e.g. JG vs JA) and shift types (SAR vs SHR)
+// applied to them; either directly, or through registers loaded from memory
+// within the same basic block.
+//
+// The signedness of stack slots is recorded in f.signs, indexed by address of
+// the instruction accessing the stack slot, and is attached to the stack slots
+// when lifted. The signedness of global variables is recorded in l.globalSigns,
+// indexed by global variable address.
+func (f *Func) inferSignedness() {
+	f.signs = make(map[bin.Address]signedness)
+	f.localSigns = make(map[string]signedness)
+	for _, block := range f.AsmFunc.Blocks {
+		insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+		for i, inst := range insts {
+			var sign signedness
+			var args []x86asm.Arg
+			switch inst.Op {
+			case x86asm.CMP, x86asm.TEST:
+				// Flags consumer following the comparison.
+				if i+1 < len(insts) {
+					sign = condSign(insts[i+1].Op)
+				}
+				if inst.Op == x86asm.TEST && sign == signUnsigned {
+					// E.g. `test eax, eax; jbe` is a zero check.
+					sign = signUnknown
+				}
+				args = inst.Args[:2]
+			case x86asm.SAR:
+				sign, args = signSigned, inst.Args[:1]
+			case x86asm.SHR:
+				sign, args = signUnsigned, inst.Args[:1]
+			}
+			if sign == signUnknown {
+				continue
+			}
+			for _, arg := range args {
+				switch arg := arg.(type) {
+				case x86asm.Mem:
+					f.recordSign(inst, arg, sign)
+				case x86asm.Reg:
+					// Locate load of register within basic block.
+					if load, ok := f.regLoad(insts[:i], arg); ok {
+						f.recordSign(load, load.Args[1].(x86asm.Mem), sign)
+					}
+				}
+			}
+		}
+	}
+}
+
+// regLoad returns the instruction of the given instructions which last defines
+// the specified register, if a load from memory (i.e. MOV, MOVZX or MOVSX). The
+// boolean return value indicates success.
+func (f *Func) regLoad(insts []*x86.Inst, reg x86asm.Reg) (*x86.Inst, bool) {
+	parent := f.l.parentReg(reg)
+	if parent == 0 {
+		return nil, false
+	}
+	for i := len(insts) - 1; i >= 0; i-- {
+		inst := insts[i]
+		_, defs := f.l.regUseDef(inst)
+		for _, def := range defs {
+			if def != parent {
+				continue
+			}
+			switch inst.Op {
+			case x86asm.MOV, x86asm.MOVZX, x86asm.MOVSX, x86asm.MOVSXD:
+				if _, ok := inst.Args[1].(x86asm.Mem); ok && inst.Args[0] == reg {
+					return inst, true
+				}
+			}
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// recordSign records the signedness of the integer value of the given memory
+// operand of the specified instruction.
+func (f *Func) recordSign(inst *x86.Inst, mem x86asm.Mem, sign signedness) {
+	addr, ok := f.globalAddr(inst, mem)
+	if !ok {
+		// Stack slot or indirect memory reference.
+		f.signs[inst.Addr] = f.signs[inst.Addr].join(sign)
+		return
+	}
+	f.l.globalSigns[addr] = f.l.globalSigns[addr].join(sign)
+}
+
+// globalAddr returns the address of the global variable (or array element)
+// accessed by the given memory operand of the specified instruction. The
+// boolean return value indicates whether the memory operand accesses a global
+// variable, as opposed to a stack slot or indirect memory reference.
+func (f *Func) globalAddr(inst *x86.Inst, mem x86asm.Mem) (bin.Address, bool) {
+	var addr bin.Address
+	switch {
+	case mem.Base == 0:
+		// Global variable or array element; e.g. `[4*ecx+addr]`.
+		addr = f.l.memDisp(mem)
+	case mem.Base == x86asm.RIP && mem.Index == 0:
+		addr = inst.Addr + bin.Address(inst.Len) + bin.Address(mem.Disp)
+	case f.picRegs[mem.Base] != 0:
+		addr = f.picRegs[mem.Base] + bin.Address(mem.Disp)
+	default:
+		return 0, false
+	}
+	if f.l.Mode == 16 {
+		if seg, ok := f.l.segBase(f.segRegs, mem); ok {
+			addr += seg
+		}
+	}
+	return addr, true
+}
+
+// markLocal attaches the signedness and recovered enum inferred for the stack
+// slot accessed by the given instruction to the stack slot of the specified
+// name, as "signedness" and "enum" metadata.
+func (f *Func) markLocal(v *ir.InstAlloca, name string, inst *x86.Inst) {
+	if inst == nil {
+		// Stack slot of parameter.
+		return
+	}
+	if sign, ok := f.signs[inst.Addr]; ok {
+		f.localSigns[name] = f.localSigns[name].join(sign)
+		setSignMetadata(&v.Metadata, f.localSigns[name])
+	}
+	if enum, ok := f.enums[inst.Addr]; ok {
+		setEnumMetadata(&v.Metadata, enum)
+	}
+}
+
+// markGlobals attaches the inferred signedness and recovered enums of global
+// variables as "signedness" and "enum" metadata.
+func (l *Lifter) markGlobals() {
+	for addr, sign := range l.globalSigns {
+		if g, ok := l.Globals[addr]; ok {
+			dbg.Printf("inferred signedness of global variable %q: %v", g.Name, sign)
+			setSignMetadata(&g.Metadata, sign)
+		}
+	}
+	for addr, enum := range l.globalEnums {
+		if g, ok := l.Globals[addr]; ok {
+			setEnumMetadata(&g.Metadata, enum)
+		}
+	}
+}
+
+// setSignMetadata records the given signedness in the "signedness" entry of the
+// metadata; e.g.
+//
+//    %esp_-8 = alloca i32, !signedness !{!"signed"}
+//
+// Conflicting signedness removes the entry.
+func setSignMetadata(md *map[string]*metadata.Metadata, sign signedness) {
+	if sign != signSigned && sign != signUnsigned {
+		delete(*md, "signedness")
+		return
+	}
+	if *md == nil {
+		*md = make(map[string]*metadata.Metadata)
+	}
+	(*md)["signedness"] = &metadata.Metadata{
+		Nodes: []metadata.Node{&metadata.String{Val: sign.String()}},
+	}
+}
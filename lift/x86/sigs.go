@@ -0,0 +1,191 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"github.com/mewkiz/pkg/jsonutil"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/pkg/errors"
+)
+
+// A FuncSig is a user-supplied function signature, as specified by the
+// sigs.json sidecar file.
+//
+// Example sigs.json contents.
+//
+//    {
+//       "0x401000": {
+//          "name": "WinMain",
+//          "callconv": "stdcall",
+//          "params": [
+//             {"name": "hInstance", "type": "i8*"},
+//             {"name": "hPrevInstance", "type": "i8*"},
+//             {"name": "lpCmdLine", "type": "i8*"},
+//             {"name": "nShowCmd", "type": "i32"}
+//          ],
+//          "ret": "i32"
+//       }
+//    }
+type FuncSig struct {
+	// Function name; defaults to the name of the function as located by other
+	// means (e.g. imports, exports or info.ll), or "f_XXXXXX" otherwise.
+	Name string `json:"name"`
+	// Calling convention (e.g. "cdecl", "stdcall", "fastcall", "thiscall",
+	// "register", "watcom", "pascal", "win64" or "sysv"); defaults to cdecl on
+	// 32-bit and to the default calling convention of the binary executable on
+	// 64-bit.
+	CallConv string `json:"callconv"`
+	// Function parameters.
+	Params []ParamSig `json:"params"`
+	// Return type in LLVM IR syntax; defaults to void.
+	Ret string `json:"ret"`
+	// Variadic specifies whether the function takes a variable number of
+	// arguments.
+	Variadic bool `json:"variadic"`
+}
+
+// A ParamSig is a user-supplied function parameter.
+type ParamSig struct {
+	// Parameter name (optional).
+	Name string `json:"name"`
+	// Parameter type in LLVM IR syntax.
+	Type string `json:"type"`
+}
+
+// callConvs maps from calling convention name of sigs.json to LLVM IR calling
+// convention.
+var callConvs = map[string]ir.CallConv{
+	"cdecl":    ir.CallConvNone,
+	"stdcall":  ir.CallConvX86_StdCall,
+	"fastcall": ir.CallConvX86_FastCall,
+	"thiscall": ir.CallConvX86_ThisCall,
+	"win64":    ir.CallConvX86_64_Win64,
+	"sysv":     ir.CallConvX86_64_SysV,
+}
+
+// parseSigs parses the user-supplied function signatures of the given JSON
+// file. A missing file is not considered an error.
+func parseSigs(jsonPath string) (map[bin.Address]*FuncSig, error) {
+	sigs := make(map[bin.Address]*FuncSig)
+	if !osutil.Exists(jsonPath) {
+		return sigs, nil
+	}
+	if err := jsonutil.ParseFile(jsonPath, &sigs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return sigs, nil
+}
+
+// AddSigs adds the user-supplied function signatures to the lifter, overriding
+// the signatures of functions located by other means.
+func (l *Lifter) AddSigs(sigs map[bin.Address]*FuncSig) error {
+	for entry, s := range sigs {
+		f, err := l.newSigFunc(entry, s)
+		if err != nil {
+			return errors.Wrapf(err, "invalid signature of function at %v", entry)
+		}
+		if prev, ok := l.Funcs[entry]; ok {
+			if len(f.Name) == 0 {
+				f.Name = prev.Name
+			}
+			delete(l.FuncByName, prev.Name)
+		}
+		if len(f.Name) == 0 {
+			f.Name = fmt.Sprintf("f_%06X", uint64(entry))
+		}
+		dbg.Printf("using user-supplied signature of function %q at %v", f.Name, entry)
+		l.Funcs[entry] = &Func{
+			Function: f,
+			regConv:  l.regConv(s.CallConv),
+		}
+		l.FuncByName[f.Name] = f
+	}
+	return nil
+}
+
+// RenameFuncs renames the functions at the given addresses, overriding the names
+// of functions located by other means (e.g. exports and symbols). Placeholder
+// declarations are added for functions not yet known to the lifter.
+func (l *Lifter) RenameFuncs(names map[bin.Address]string) {
+	for entry, name := range names {
+		f, ok := l.Funcs[entry]
+		if ok {
+			delete(l.FuncByName, f.Name)
+		} else {
+			sig := types.NewFunc(types.Void)
+			f = &Func{
+				Function: &ir.Function{
+					Typ: types.NewPointer(sig),
+					Sig: sig,
+					Metadata: map[string]*metadata.Metadata{
+						"addr": {
+							Nodes: []metadata.Node{&metadata.String{Val: entry.String()}},
+						},
+					},
+				},
+				sigUnknown: true,
+			}
+			l.Funcs[entry] = f
+		}
+		dbg.Printf("renaming function %q at %v to %q", f.Name, entry, name)
+		f.Name = name
+		l.FuncByName[name] = f.Function
+	}
+}
+
+// newSigFunc returns a new function declaration based on the given
+// user-supplied function signature. The function name is left empty if not
+// specified by the signature.
+func (l *Lifter) newSigFunc(entry bin.Address, s *FuncSig) (*ir.Function, error) {
+	ret := types.Type(types.Void)
+	if len(s.Ret) > 0 {
+		t, err := l.parseTypeString(s.Ret)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		ret = t
+	}
+	var params []*types.Param
+	for i, p := range s.Params {
+		t, err := l.parseTypeString(p.Type)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		name := p.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("arg_%d", i)
+		}
+		params = append(params, types.NewParam(name, t))
+	}
+	sig := types.NewFunc(ret, params...)
+	sig.Variadic = s.Variadic
+	var callconv ir.CallConv
+	switch {
+	case l.regConv(s.CallConv) != nil:
+		// Register calling conventions are emitted using the C calling
+		// convention.
+	case len(s.CallConv) > 0:
+		cc, ok := callConvs[s.CallConv]
+		if !ok {
+			return nil, errors.Errorf("support for calling convention %q not yet implemented", s.CallConv)
+		}
+		callconv = cc
+	case l.Mode == 64:
+		callconv = l.defaultCallConv()
+	}
+	return &ir.Function{
+		Name:     s.Name,
+		Typ:      types.NewPointer(sig),
+		Sig:      sig,
+		CallConv: callconv,
+		Metadata: map[string]*metadata.Metadata{
+			"addr": {
+				Nodes: []metadata.Node{&metadata.String{Val: entry.String()}},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,290 @@
+package x86
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/metadata"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Self-modifying code ] #################################################
+
+// A CodeWrite is a write into an executable section; e.g. by self-modifying
+// code or by the decryption loop of packed code.
+type CodeWrite struct {
+	// Address of the writing instruction.
+	Addr bin.Address
+	// Entry address of the writing function.
+	Func bin.Address
+	// Start address of the written range.
+	Start bin.Address
+	// End address of the written range (exclusive).
+	End bin.Address
+}
+
+// An AddrRange is an address range of code.
+type AddrRange struct {
+	// Start address.
+	Start bin.Address
+	// End address (exclusive).
+	End bin.Address
+}
+
+// String returns the string representation of the address range; e.g.
+// "0x401000-0x401010".
+func (r AddrRange) String() string {
+	return fmt.Sprintf("%v-%v", r.Start, r.End)
+}
+
+// detectCodeWrites records the writes of the function into executable
+// sections in l.codeWrites. The written range of indexed writes (e.g. `xor
+// [ecx+addr], al` of decryption loops) is bounded by value-set analysis, and
+// otherwise assumed to extend to the end of the executable section.
+//
+// Writes of 16-bit real mode code are not recorded, as code and data share
+// segments.
+func (f *Func) detectCodeWrites() {
+	if f.l.File.RealMode != nil {
+		return
+	}
+	for _, blockAddr := range f.blockAddrs() {
+		block := f.AsmFunc.Blocks[blockAddr]
+		for _, inst := range block.Insts {
+			if inst.MemBytes == 0 || !writesMem(inst) {
+				continue
+			}
+			mem, ok := inst.Args[0].(x86asm.Mem)
+			if !ok {
+				continue
+			}
+			start, end, ok := f.writeRange(inst, mem)
+			if !ok {
+				continue
+			}
+			w := CodeWrite{Addr: inst.Addr, Func: f.AsmFunc.Addr, Start: start, End: end}
+			dbg.Printf("write into executable section at %v by instruction at %v", AddrRange{Start: start, End: end}, inst.Addr)
+			f.l.codeWrites = append(f.l.codeWrites, w)
+		}
+	}
+}
+
+// writeRange returns the address range written by the given memory operand of
+// the instruction, and a boolean indicating whether the range is located in an
+// executable section.
+func (f *Func) writeRange(inst *x86.Inst, mem x86asm.Mem) (start, end bin.Address, ok bool) {
+	if mem.Segment != 0 {
+		return 0, 0, false
+	}
+	size := bin.Address(inst.MemBytes)
+	if v, ok := f.memSets[inst.Addr]; ok && v.region == vsaGlobal {
+		// Bounded by value-set analysis.
+		start, end = bin.Address(v.lo), bin.Address(v.hi)+size
+		if !f.l.File.IsCode(start) {
+			return 0, 0, false
+		}
+		return start, end, true
+	}
+	indexed := false
+	switch {
+	case mem.Base == x86asm.RIP && mem.Index == 0:
+		next := inst.Addr + bin.Address(inst.Len)
+		start = next + bin.Address(mem.Disp)
+	case f.picRegs[mem.Base] != 0:
+		start = f.picRegs[mem.Base] + bin.Address(mem.Disp)
+		indexed = mem.Index != 0
+	case mem.Base == 0 && mem.Index == 0:
+		start = f.l.memDisp(mem)
+	default:
+		// Array element; e.g. `[ecx+addr]`.
+		if !f.l.isAbsDisp(inst) {
+			// Offset from register; e.g. struct field.
+			return 0, 0, false
+		}
+		start = f.l.memDisp(mem)
+		indexed = true
+	}
+	sect, ok := f.l.codeSectionAt(start)
+	if !ok {
+		return 0, 0, false
+	}
+	end = start + size
+	if indexed {
+		end = sect.Addr + bin.Address(sectSize(sect))
+	}
+	return start, end, true
+}
+
+// isAbsDisp reports whether the displacement of the memory operand of the given
+// instruction is an absolute address; i.e. relocated if the executable has
+// relocations, and otherwise if the image base of the executable is non-zero.
+// Displacements of position independent executables loaded at image base 0
+// (e.g. struct fields of `[rbx+0x1100]`) may coincide with code addresses, and
+// are thus not considered absolute.
+func (l *Lifter) isAbsDisp(inst *x86.Inst) bool {
+	relocs := l.File.Relocs
+	if len(relocs) == 0 {
+		return l.File.ImageBase != 0
+	}
+	less := func(i int) bool {
+		return inst.Addr <= relocs[i]
+	}
+	i := sort.Search(len(relocs), less)
+	return i < len(relocs) && relocs[i] < inst.Addr+bin.Address(inst.Len)
+}
+
+// codeSectionAt returns the executable section containing the given address,
+// and a boolean indicating success.
+func (l *Lifter) codeSectionAt(addr bin.Address) (*bin.Section, bool) {
+	for _, sect := range l.File.Sections {
+		if sect.Perm&bin.PermX == 0 {
+			continue
+		}
+		if sect.Addr <= addr && addr < sect.Addr+bin.Address(sectSize(sect)) {
+			return sect, true
+		}
+	}
+	return nil, false
+}
+
+// DetectSMC locates the functions modified by self-modifying code; i.e.
+// functions with decoded instructions overlapping the writes into executable
+// sections, as recorded by the function lifters. Writing functions are
+// annotated with the modified address ranges as "smc" metadata; e.g.
+//
+//    define void @f_401000() !smc !{!"0x401020-0x401021"}
+//
+// The returned map maps from the entry address of modified functions to the
+// writes modifying them. The instructions of modified functions are unlikely
+// to be executed as decoded, and should not be lifted.
+//
+// DetectSMC should be called once the function lifters of all functions have
+// been created.
+func (l *Lifter) DetectSMC() map[bin.Address][]CodeWrite {
+	modified := make(map[bin.Address][]CodeWrite)
+	if len(l.codeWrites) == 0 {
+		return modified
+	}
+	var funcAddrs bin.Addresses
+	for funcAddr, f := range l.Funcs {
+		if f.AsmFunc != nil {
+			funcAddrs = append(funcAddrs, funcAddr)
+		}
+	}
+	sort.Sort(funcAddrs)
+	ranges := make(map[bin.Address][]AddrRange)
+	for _, funcAddr := range funcAddrs {
+		f := l.Funcs[funcAddr]
+		for _, w := range l.codeWrites {
+			if !f.claims(w.Start, w.End) {
+				continue
+			}
+			warn.Printf("function %q at %v modified by instruction at %v (%v)", f.Name, funcAddr, w.Addr, AddrRange{Start: w.Start, End: w.End})
+			modified[funcAddr] = append(modified[funcAddr], w)
+			ranges[w.Func] = append(ranges[w.Func], AddrRange{Start: w.Start, End: w.End})
+		}
+	}
+	for funcAddr, rs := range ranges {
+		f, ok := l.Funcs[funcAddr]
+		if !ok {
+			continue
+		}
+		f.SMC = mergeRanges(rs)
+		md := &metadata.Metadata{}
+		for _, r := range f.SMC {
+			md.Nodes = append(md.Nodes, &metadata.String{Val: r.String()})
+		}
+		setMetadata(f.Function, "smc", md)
+	}
+	return modified
+}
+
+// claims reports whether any decoded instruction of the function overlaps the
+// given address range.
+func (f *Func) claims(start, end bin.Address) bool {
+	for _, block := range f.AsmFunc.Blocks {
+		insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+		for _, inst := range insts {
+			if inst.IsDummyTerm() {
+				continue
+			}
+			if inst.Addr < end && start < inst.Addr+bin.Address(inst.Len) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ### [ Overlapping code ] ####################################################
+
+// detectOverlaps locates the overlapping instructions of the function; i.e.
+// instructions decoded at different boundaries of the same bytes, as used by
+// obfuscated code to hide instructions within others (e.g. `jmp` into the
+// immediate operand of a preceding `mov`). Each decoding is lifted separately;
+// the address ranges of overlapping instructions are recorded in f.Overlaps,
+// and attached as "overlap" metadata to the function; e.g.
+//
+//    define void @f_401000() !overlap !{!"0x401005-0x40100C"}
+func (f *Func) detectOverlaps() {
+	lens := make(map[bin.Address]int)
+	for _, block := range f.AsmFunc.Blocks {
+		insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+		for _, inst := range insts {
+			if inst.IsDummyTerm() {
+				continue
+			}
+			lens[inst.Addr] = inst.Len
+		}
+	}
+	var addrs bin.Addresses
+	for addr := range lens {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	var rs []AddrRange
+	for i := 1; i < len(addrs); i++ {
+		prev, addr := addrs[i-1], addrs[i]
+		prevEnd := prev + bin.Address(lens[prev])
+		if prevEnd <= addr {
+			continue
+		}
+		end := addr + bin.Address(lens[addr])
+		if prevEnd > end {
+			end = prevEnd
+		}
+		rs = append(rs, AddrRange{Start: prev, End: end})
+	}
+	if len(rs) == 0 {
+		return
+	}
+	f.Overlaps = mergeRanges(rs)
+	md := &metadata.Metadata{}
+	for _, r := range f.Overlaps {
+		warn.Printf("overlapping instructions at %v in function %q", r, f.Name)
+		md.Nodes = append(md.Nodes, &metadata.String{Val: r.String()})
+	}
+	setMetadata(f.Function, "overlap", md)
+}
+
+// mergeRanges returns the given address ranges, sorted by start address, with
+// overlapping and adjacent ranges merged.
+func mergeRanges(rs []AddrRange) []AddrRange {
+	less := func(i, j int) bool {
+		return rs[i].Start < rs[j].Start
+	}
+	sort.Slice(rs, less)
+	var merged []AddrRange
+	for _, r := range rs {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End {
+			if r.End > merged[n-1].End {
+				merged[n-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
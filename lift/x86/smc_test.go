@@ -0,0 +1,113 @@
+package x86
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestDetectSMC(t *testing.T) {
+	const dir = "testdata/x86_32/smc"
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unable to retrieve current working directory; %+v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("%q: unable to change working directory; %+v", dir, err)
+	}
+	defer os.Chdir(wd)
+	l, err := newLifter("smc.so", 0)
+	if err != nil {
+		t.Fatalf("%q: unable to prepare lifter; %+v", dir, err)
+	}
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			t.Fatalf("%q: unable to decode function at %v; %+v", dir, funcAddr, err)
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	const (
+		smc     = bin.Address(0x10000000)
+		patched = bin.Address(0x10000008)
+		overlap = bin.Address(0x1000000A)
+	)
+
+	// Self-modifying code; `mov byte [patched], 0xC3` of smc.
+	modified := l.DetectSMC()
+	want := map[bin.Address][]CodeWrite{
+		patched: {{Addr: smc, Func: smc, Start: patched, End: patched + 1}},
+	}
+	if !reflect.DeepEqual(modified, want) {
+		t.Errorf("modified functions mismatch; expected %v, got %v", want, modified)
+	}
+	smcRanges := []AddrRange{{Start: patched, End: patched + 1}}
+	if got := l.Funcs[smc].SMC; !reflect.DeepEqual(got, smcRanges) {
+		t.Errorf("smc ranges mismatch; expected %v, got %v", smcRanges, got)
+	}
+	if got := l.Funcs[patched].SMC; len(got) != 0 {
+		t.Errorf("expected no smc ranges of modified function, got %v", got)
+	}
+
+	// Overlapping instructions; `jmp overlap+1` and `inc eax` of overlap.
+	overlaps := []AddrRange{{Start: overlap, End: overlap + 3}}
+	if got := l.Funcs[overlap].Overlaps; !reflect.DeepEqual(got, overlaps) {
+		t.Errorf("overlapping instructions mismatch; expected %v, got %v", overlaps, got)
+	}
+	for _, funcAddr := range []bin.Address{smc, patched} {
+		if got := l.Funcs[funcAddr].Overlaps; len(got) != 0 {
+			t.Errorf("%v: expected no overlapping instructions, got %v", funcAddr, got)
+		}
+	}
+}
+
+func TestDetectSMCPIE(t *testing.T) {
+	// Code of the .text section of a position independent executable loaded at
+	// image base 0, located at 0x1000.
+	//
+	//    pie:
+	//       mov     [rbx+0x1100], eax  ; struct field, not a code address
+	//       ret
+	//
+	//    field:                        ; located at 0x1100
+	//       ret
+	code := make([]byte, 0x101)
+	for i := range code {
+		// int3 padding.
+		code[i] = 0xCC
+	}
+	copy(code, []byte{0x89, 0x83, 0x00, 0x11, 0x00, 0x00, 0xC3})
+	code[0x100] = 0xC3
+	const (
+		pie   = bin.Address(0x1000)
+		field = bin.Address(0x1100)
+	)
+	file := &bin.File{
+		Format: "elf",
+		Arch:   bin.ArchX86_64,
+		Entry:  pie,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x1000, Data: code, MemSize: len(code), Perm: bin.PermR | bin.PermX},
+		},
+		Exports: map[bin.Address]string{pie: "pie", field: "field"},
+	}
+	l, err := NewLifter(file)
+	if err != nil {
+		t.Fatalf("unable to prepare lifter; %+v", err)
+	}
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			t.Fatalf("unable to decode function at %v; %+v", funcAddr, err)
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	if modified := l.DetectSMC(); len(modified) != 0 {
+		t.Errorf("expected no modified functions, got %v", modified)
+	}
+	if got := l.Funcs[pie].SMC; len(got) != 0 {
+		t.Errorf("expected no smc ranges, got %v", got)
+	}
+}
@@ -0,0 +1,225 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Stack pointer delta verification ] ####################################
+
+// Kinds of stack imbalances.
+const (
+	// The stack pointer delta differs between paths joining at a basic block.
+	ImbalanceMerge = "merge"
+	// The stack pointer delta is non-zero at a return or tail call.
+	ImbalanceReturn = "return"
+	// The number of bytes purged on return differs between return instructions,
+	// or from the calling convention of the function signature (e.g. stdcall
+	// mismatch).
+	ImbalancePurge = "purge"
+)
+
+// A StackImbalance records an imbalance of the stack pointer of a function.
+// Imbalances are indicative of misidentified functions (e.g. data or a function
+// chunk decoded as a function), calling convention mismatches, and discovery
+// bugs (e.g. a missing basic block or an unresolved no-return call).
+type StackImbalance struct {
+	// Address of the instruction at which the imbalance was detected.
+	Addr bin.Address
+	// Kind of imbalance; one of ImbalanceMerge, ImbalanceReturn and
+	// ImbalancePurge.
+	Kind string
+	// Stack pointer delta relative to function entry (or number of bytes purged
+	// on return) along the offending path.
+	Got int64
+	// Expected stack pointer delta (or number of bytes purged on return).
+	Want int64
+}
+
+// CheckStack tracks the stack pointer delta along all paths of the function, and
+// returns the stack imbalances detected, sorted by address. Each stack
+// imbalance is reported as a warning.
+//
+// Paths through calls with an unknown number of purged arguments (e.g.
+// indirect calls) or unknown updates of the stack pointer (e.g. stack
+// alignment; `and esp, 0xFFFFFFF0`) are not verified beyond that point.
+func (f *Func) CheckStack() []StackImbalance {
+	if f.AsmFunc == nil || len(f.AsmFunc.Blocks) == 0 {
+		return nil
+	}
+	// Size in bytes of values pushed onto the stack.
+	word := f.l.stackWord()
+	sp, bp := x86asm.ESP, x86asm.EBP
+	switch f.l.Mode {
+	case 16:
+		sp, bp = x86asm.SP, x86asm.BP
+	case 64:
+		word = 8
+		sp, bp = x86asm.RSP, x86asm.RBP
+	}
+	var imbalances []StackImbalance
+	report := func(addr bin.Address, kind string, got, want int64) {
+		imbalances = append(imbalances, StackImbalance{Addr: addr, Kind: kind, Got: got, Want: want})
+	}
+	// Stack pointer and frame pointer displacement at entry of each basic block,
+	// relative to ESP at function entry.
+	type frame struct {
+		esp, ebp int64
+		hasESP   bool
+		hasEBP   bool
+	}
+	frames := map[bin.Address]frame{f.AsmFunc.Addr: {hasESP: true}}
+	queue := []bin.Address{f.AsmFunc.Addr}
+	// Number of bytes purged on return, as specified by the calling convention
+	// of the function signature if known, and otherwise by the first return
+	// instruction reached.
+	purge, hasPurge := int64(0), false
+	if !f.sigUnknown && f.l.Mode != 64 {
+		purge, hasPurge = f.l.sigPurge(f), true
+	}
+	for len(queue) > 0 {
+		addr := queue[0]
+		queue = queue[1:]
+		block := f.AsmFunc.Blocks[addr]
+		fr := frames[addr]
+		for _, inst := range block.Insts {
+			switch inst.Op {
+			case x86asm.PUSH, x86asm.PUSHF, x86asm.PUSHFD, x86asm.PUSHFQ:
+				fr.esp -= word
+			case x86asm.POP, x86asm.POPF, x86asm.POPFD, x86asm.POPFQ:
+				fr.esp += word
+				if inst.Args[0] == bp {
+					fr.hasEBP = false
+				}
+			case x86asm.PUSHA, x86asm.PUSHAD:
+				fr.esp -= 8 * word
+			case x86asm.POPA, x86asm.POPAD:
+				fr.esp += 8 * word
+				fr.hasEBP = false
+			case x86asm.CALL, x86asm.LCALL:
+				n, ok := f.stackPurge(inst)
+				if !ok {
+					// Unknown number of arguments purged by callee.
+					fr.hasESP = false
+				}
+				fr.esp += n
+			case x86asm.ENTER:
+				imm, _ := inst.Args[0].(x86asm.Imm)
+				fr.esp -= word
+				fr.ebp = fr.esp
+				fr.hasEBP = fr.hasESP
+				fr.esp -= int64(imm)
+			case x86asm.LEAVE:
+				fr.esp = fr.ebp + word
+				fr.hasESP = fr.hasEBP
+				fr.hasEBP = false
+			case x86asm.MOV:
+				switch {
+				case inst.Args[0] == bp && inst.Args[1] == sp:
+					fr.ebp = fr.esp
+					fr.hasEBP = fr.hasESP
+				case inst.Args[0] == sp && inst.Args[1] == bp:
+					fr.esp = fr.ebp
+					fr.hasESP = fr.hasEBP
+				case inst.Args[0] == sp:
+					fr.hasESP = false
+				case inst.Args[0] == bp:
+					fr.hasEBP = false
+				}
+			case x86asm.LEA:
+				// E.g. `lea esp, [ebp-0xC]` of function epilogues.
+				mem, _ := inst.Args[1].(x86asm.Mem)
+				switch {
+				case inst.Args[0] != sp && inst.Args[0] != bp:
+				case mem.Index != 0 || mem.Segment != 0 && mem.Segment != x86asm.SS:
+					if inst.Args[0] == sp {
+						fr.hasESP = false
+					} else {
+						fr.hasEBP = false
+					}
+				case inst.Args[0] == sp && mem.Base == sp:
+					fr.esp += mem.Disp
+				case inst.Args[0] == sp && mem.Base == bp:
+					fr.esp = fr.ebp + mem.Disp
+					fr.hasESP = fr.hasEBP
+				case inst.Args[0] == bp && mem.Base == sp:
+					fr.ebp = fr.esp + mem.Disp
+					fr.hasEBP = fr.hasESP
+				case inst.Args[0] == bp && mem.Base == bp:
+					fr.ebp += mem.Disp
+				default:
+					if inst.Args[0] == sp {
+						fr.hasESP = false
+					} else {
+						fr.hasEBP = false
+					}
+				}
+			default:
+				if inst.Args[0] == bp {
+					fr.hasEBP = false
+				}
+				if inst.Args[0] != sp {
+					break
+				}
+				imm, ok := inst.Args[1].(x86asm.Imm)
+				switch {
+				case inst.Op == x86asm.ADD && ok:
+					fr.esp += int64(imm)
+				case inst.Op == x86asm.SUB && ok:
+					fr.esp -= int64(imm)
+				default:
+					// E.g. stack alignment; `and esp, 0xFFFFFFF0`.
+					fr.hasESP = false
+				}
+			}
+		}
+		// Verify stack pointer delta at returns and tail calls.
+		term := block.Term
+		if fr.hasESP && (isReturn(term) || term.Op == x86asm.JMP && f.isCall(term)) {
+			if fr.esp != 0 {
+				report(term.Addr, ImbalanceReturn, fr.esp, 0)
+			}
+		}
+		if isReturn(term) {
+			n := int64(0)
+			if imm, ok := term.Args[0].(x86asm.Imm); ok {
+				n = int64(imm)
+			}
+			switch {
+			case !hasPurge:
+				purge, hasPurge = n, true
+			case n != purge:
+				report(term.Addr, ImbalancePurge, n, purge)
+			}
+		}
+		// Verify stack pointer delta at merge points.
+		for _, succ := range f.succs(block) {
+			prev, ok := frames[succ]
+			if !ok {
+				frames[succ] = fr
+				queue = append(queue, succ)
+				continue
+			}
+			if prev.hasESP && fr.hasESP && prev.esp != fr.esp {
+				report(succ, ImbalanceMerge, fr.esp, prev.esp)
+			}
+		}
+	}
+	less := func(i, j int) bool {
+		return imbalances[i].Addr < imbalances[j].Addr
+	}
+	sort.SliceStable(imbalances, less)
+	for _, imb := range imbalances {
+		switch imb.Kind {
+		case ImbalanceMerge:
+			warn.Printf("stack imbalance in function %q at %v; stack pointer delta %d along one path and %d along another", f.Name, imb.Addr, imb.Got, imb.Want)
+		case ImbalanceReturn:
+			warn.Printf("stack imbalance in function %q at %v; stack pointer delta %d at return", f.Name, imb.Addr, imb.Got)
+		case ImbalancePurge:
+			warn.Printf("stack imbalance in function %q at %v; %d bytes purged on return, expected %d", f.Name, imb.Addr, imb.Got, imb.Want)
+		}
+	}
+	return imbalances
+}
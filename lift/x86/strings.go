@@ -0,0 +1,254 @@
+package x86
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// minStringLen specifies the minimum number of characters (excluding NUL
+// terminator) of string literals.
+const minStringLen = 4
+
+// detectStrings locates string literals (ASCII or UTF-16LE) in data sections
+// referenced from the instructions of the given function, and records them as
+// global variables.
+func (l *Lifter) detectStrings(asmFunc *x86.Func) {
+	for _, block := range asmFunc.Blocks {
+		for _, inst := range block.Insts {
+			for _, arg := range inst.Args {
+				if arg == nil {
+					break
+				}
+				addr, ok := refAddr(inst, arg)
+				if !ok {
+					continue
+				}
+				if _, ok := l.Globals[addr]; ok {
+					continue
+				}
+				var g *ir.Global
+				if s, ok := l.cstring(addr); ok {
+					dbg.Printf("located string literal at %v referenced from instruction at %v: %q", addr, inst.Addr, s)
+					g = newString(addr, s)
+				} else if s, ok := l.wstring(addr); ok {
+					dbg.Printf("located wide string literal at %v referenced from instruction at %v: %q", addr, inst.Addr, s)
+					g = newWideString(addr, s)
+				} else if known, ok := l.KnownStrings[addr]; ok {
+					if g, ok = l.knownString(addr, known); !ok {
+						continue
+					}
+					dbg.Printf("using known string literal at %v referenced from instruction at %v: %q", addr, inst.Addr, known.Text)
+				} else {
+					continue
+				}
+				l.Globals[addr] = g
+				l.Strings[addr] = g
+			}
+		}
+	}
+}
+
+// refAddr returns the address referenced by the given instruction argument;
+// i.e. an immediate or the displacement of a direct memory reference. The
+// boolean return value indicates success.
+func refAddr(inst *x86.Inst, arg x86asm.Arg) (bin.Address, bool) {
+	switch a := arg.(type) {
+	case x86asm.Imm:
+		if a > 0 {
+			return bin.Address(a), true
+		}
+	case x86asm.Mem:
+		if a.Segment != 0 || a.Index != 0 {
+			return 0, false
+		}
+		switch a.Base {
+		case 0:
+			return bin.Address(a.Disp), true
+		case x86asm.IP, x86asm.EIP, x86asm.RIP:
+			next := inst.Addr + bin.Address(inst.Len)
+			return next + bin.Address(a.Disp), true
+		}
+	}
+	return 0, false
+}
+
+// dataSection returns the contents of the non-executable section containing
+// the given address, starting at the address. The boolean return value
+// indicates success.
+func (l *Lifter) dataSection(addr bin.Address) ([]byte, bool) {
+	sect, ok := l.sectionAt(addr)
+	if !ok || addr >= sect.Addr+bin.Address(len(sect.Data)) {
+		return nil, false
+	}
+	return sect.Data[addr-sect.Addr:], true
+}
+
+// cstring returns the NUL-terminated ASCII string located at the given address
+// of a data section. The boolean return value indicates success.
+func (l *Lifter) cstring(addr bin.Address) (string, bool) {
+	data, ok := l.dataSection(addr)
+	if !ok {
+		return "", false
+	}
+	end := bytes.IndexByte(data, 0)
+	if end < minStringLen {
+		return "", false
+	}
+	for _, b := range data[:end] {
+		if !isPrint(rune(b)) {
+			return "", false
+		}
+	}
+	return string(data[:end]), true
+}
+
+// wstring returns the NUL-terminated UTF-16LE string located at the given
+// address of a data section. The boolean return value indicates success.
+func (l *Lifter) wstring(addr bin.Address) (string, bool) {
+	units, ok := l.wideUnits(addr)
+	if !ok || len(units) < minStringLen {
+		return "", false
+	}
+	rs := utf16.Decode(units)
+	for _, r := range rs {
+		if r == unicode.ReplacementChar || !isPrint(r) && !unicode.IsPrint(r) {
+			return "", false
+		}
+	}
+	return string(rs), true
+}
+
+// wideUnits returns the UTF-16 code units (excluding NUL terminator) of the
+// NUL-terminated wide string located at the given address of a data section.
+// The boolean return value indicates success.
+func (l *Lifter) wideUnits(addr bin.Address) ([]uint16, bool) {
+	data, ok := l.dataSection(addr)
+	if !ok {
+		return nil, false
+	}
+	var units []uint16
+	for i := 0; ; i += 2 {
+		if i+2 > len(data) {
+			// Missing NUL terminator.
+			return nil, false
+		}
+		unit, err := l.File.ReadUint16(addr + bin.Address(i))
+		if err != nil {
+			return nil, false
+		}
+		if unit == 0 {
+			break
+		}
+		units = append(units, unit)
+	}
+	return units, true
+}
+
+// A KnownString is a string literal located by external analysis (e.g.
+// radare2).
+type KnownString struct {
+	// Contents of the string literal, as reported by external analysis.
+	Text string
+	// Wide specifies whether the string literal is encoded in UTF-16LE.
+	Wide bool
+}
+
+// knownString returns a new constant global variable of the given known string
+// literal at the specified address. The contents are read from the data
+// section, up to the NUL terminator, without the length and character
+// restrictions of string literals located by heuristics. The boolean return
+// value indicates success.
+func (l *Lifter) knownString(addr bin.Address, known KnownString) (*ir.Global, bool) {
+	if known.Wide {
+		units, ok := l.wideUnits(addr)
+		if !ok || len(units) == 0 {
+			return nil, false
+		}
+		rs := utf16.Decode(units)
+		for _, r := range rs {
+			if r == unicode.ReplacementChar {
+				// Invalid surrogates are not preserved by newWideString.
+				return nil, false
+			}
+		}
+		return newWideString(addr, string(rs)), true
+	}
+	data, ok := l.dataSection(addr)
+	if !ok {
+		return nil, false
+	}
+	end := bytes.IndexByte(data, 0)
+	if end < 1 {
+		return nil, false
+	}
+	return newString(addr, string(data[:end])), true
+}
+
+// isPrint reports whether the given character is a printable ASCII character
+// or common whitespace character of string literals.
+func isPrint(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r':
+		return true
+	}
+	return 0x20 <= r && r <= 0x7E
+}
+
+// newString returns a new constant global variable of the given string literal
+// at the specified address, including NUL terminator.
+func newString(addr bin.Address, s string) *ir.Global {
+	name := fmt.Sprintf("str_%06X", uint64(addr))
+	init := constant.NewCharArray(append([]byte(s), 0))
+	content := init.Type()
+	return &ir.Global{
+		Name:    name,
+		Typ:     types.NewPointer(content),
+		Content: content,
+		Init:    init,
+		IsConst: true,
+		Metadata: map[string]*metadata.Metadata{
+			"addr": {
+				Nodes: []metadata.Node{&metadata.String{Val: addr.String()}},
+			},
+		},
+	}
+}
+
+// newWideString returns a new constant global variable of the given wide string
+// literal at the specified address, including NUL terminator. The decoded text
+// is preserved as "text" metadata.
+func newWideString(addr bin.Address, s string) *ir.Global {
+	name := fmt.Sprintf("wstr_%06X", uint64(addr))
+	var elems []constant.Constant
+	for _, unit := range utf16.Encode([]rune(s)) {
+		elems = append(elems, constant.NewInt(int64(unit), types.I16))
+	}
+	elems = append(elems, constant.NewInt(0, types.I16))
+	init := constant.NewArray(elems...)
+	content := init.Type()
+	return &ir.Global{
+		Name:    name,
+		Typ:     types.NewPointer(content),
+		Content: content,
+		Init:    init,
+		IsConst: true,
+		Metadata: map[string]*metadata.Metadata{
+			"addr": {
+				Nodes: []metadata.Node{&metadata.String{Val: addr.String()}},
+			},
+			"text": {
+				Nodes: []metadata.Node{&metadata.String{Val: s}},
+			},
+		},
+	}
+}
@@ -0,0 +1,375 @@
+package x86
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Struct layout recovery ] ##############################################
+
+// maxStructSize specifies the maximum size in bytes of recovered structs;
+// accesses at larger offsets from a base pointer are assumed not to be struct
+// fields.
+const maxStructSize = 0x10000
+
+// A structLayout is the recovered layout of a struct; maps from byte offset to
+// the size in bytes of the field at the given offset.
+type structLayout map[int64]int
+
+// offsets returns the field offsets of the struct layout in increasing order.
+func (layout structLayout) offsets() []int64 {
+	var offs []int64
+	for off := range layout {
+		offs = append(offs, off)
+	}
+	less := func(i, j int) bool {
+		return offs[i] < offs[j]
+	}
+	sort.Slice(offs, less)
+	return offs
+}
+
+// valid reports whether the struct layout has at least two non-overlapping
+// fields within the bounds of a struct.
+func (layout structLayout) valid() bool {
+	offs := layout.offsets()
+	if len(offs) < 2 || offs[0] < 0 || offs[len(offs)-1] >= maxStructSize {
+		return false
+	}
+	for i := 1; i < len(offs); i++ {
+		if offs[i-1]+int64(layout[offs[i-1]]) > offs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// merge merges the fields of the given struct layout into the struct layout.
+func (layout structLayout) merge(other structLayout) {
+	for off, size := range other {
+		if size > layout[off] {
+			layout[off] = size
+		}
+	}
+}
+
+// key returns a canonical string representation of the struct layout.
+func (layout structLayout) key() string {
+	buf := &bytes.Buffer{}
+	for _, off := range layout.offsets() {
+		fmt.Fprintf(buf, "%d:%d,", off, layout[off])
+	}
+	return buf.String()
+}
+
+// A recoveredStruct is a struct type recovered from the accesses of functions
+// to memory at constant offsets from a common base pointer.
+type recoveredStruct struct {
+	// Named struct type.
+	typ *types.StructType
+	// Field indices, indexed by byte offset.
+	fields map[int64]int
+}
+
+// structType returns the named struct type (e.g. %struct.obj_0) of the given
+// struct layout. Fields are integers of the access size, and the gaps between
+// fields are padded with byte arrays. Identical layouts share the same type.
+func (l *Lifter) structType(layout structLayout) *recoveredStruct {
+	key := layout.key()
+	if rs, ok := l.structs[key]; ok {
+		return rs
+	}
+	rs := &recoveredStruct{
+		fields: make(map[int64]int),
+	}
+	var fields []types.Type
+	end := int64(0)
+	for _, off := range layout.offsets() {
+		if off > end {
+			// Padding.
+			fields = append(fields, types.NewArray(types.I8, off-end))
+		}
+		rs.fields[off] = len(fields)
+		fields = append(fields, intType(layout[off]))
+		end = off + int64(layout[off])
+	}
+	rs.typ = types.NewStruct(fields...)
+	rs.typ.Name = fmt.Sprintf("struct.obj_%d", len(l.structs))
+	dbg.Printf("recovered struct type %%%s with fields at offsets %v", rs.typ.Name, layout.offsets())
+	l.structs[key] = rs
+	l.Types = append(l.Types, rs.typ)
+	return rs
+}
+
+// A fieldAccess records the struct field accessed by a memory reference
+// relative to a base pointer.
+type fieldAccess struct {
+	// Struct type of the base pointer.
+	rs *recoveredStruct
+	// Byte offset of the field.
+	off int64
+}
+
+// recoverStructs clusters the memory accesses of the function at constant
+// offsets from a common base pointer (e.g. `mov eax, [ecx+8]`) into struct
+// layouts. Base pointers are identified by the registers holding them; copies
+// between registers are tracked within the function, and the values of a
+// register reaching a basic block along different paths are unified.
+//
+// Accesses through base pointers of heap objects (e.g. this pointers) are
+// recorded in f.fieldAccesses, indexed by instruction address. Base pointers
+// holding the constant address of a global variable give rise to struct-typed
+// global variables, as inferred by inferGlobals.
+func (f *Func) recoverStructs() {
+	f.fieldAccesses = make(map[bin.Address]fieldAccess)
+	if f.l.Mode == 16 {
+		// Memory references of 16-bit real mode are segmented.
+		return
+	}
+	// Union-find of base pointer objects.
+	var parent []int
+	var find func(id int) int
+	find = func(id int) int {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	union := func(a, b int) {
+		a, b = find(a), find(b)
+		if a < b {
+			parent[b] = a
+		} else if b < a {
+			parent[a] = b
+		}
+	}
+	fresh := func() int {
+		id := len(parent)
+		parent = append(parent, id)
+		return id
+	}
+	// Constant addresses held by base pointer objects.
+	consts := make(map[int]bin.Address)
+	type objAccess struct {
+		obj  int
+		addr bin.Address
+		off  int64
+		size int
+	}
+	var accs []objAccess
+	// Base pointer objects of registers at entry and exit of each basic block.
+	type regObjs map[x86asm.Reg]int
+	in := make(map[bin.Address]regObjs)
+	out := make(map[bin.Address]regObjs)
+	for _, blockAddr := range f.blockAddrs() {
+		block := f.AsmFunc.Blocks[blockAddr]
+		st := make(regObjs)
+		for reg, id := range in[blockAddr] {
+			st[reg] = id
+		}
+		obj := func(reg x86asm.Reg) int {
+			reg = f.l.parentReg(reg)
+			id, ok := st[reg]
+			if !ok {
+				id = fresh()
+				st[reg] = id
+			}
+			return id
+		}
+		for _, inst := range block.Insts {
+			// Record accesses relative to base pointer.
+			if inst.Op != x86asm.LEA && inst.MemBytes > 0 {
+				for _, arg := range inst.Args {
+					mem, ok := arg.(x86asm.Mem)
+					if !ok {
+						continue
+					}
+					if f.isBasePtr(mem.Base) && mem.Index == 0 {
+						switch mem.Segment {
+						case 0, x86asm.DS, x86asm.ES, x86asm.SS:
+							accs = append(accs, objAccess{obj: obj(mem.Base), addr: inst.Addr, off: mem.Disp, size: inst.MemBytes})
+						}
+					}
+					break
+				}
+			}
+			// Track base pointers.
+			dst, _ := inst.Args[0].(x86asm.Reg)
+			switch {
+			case inst.Op == x86asm.MOV && f.isBasePtr(dst) && f.isBasePtr(inst.Args[1]):
+				st[f.l.parentReg(dst)] = obj(inst.Args[1].(x86asm.Reg))
+			case inst.Op == x86asm.MOV && f.isBasePtr(dst):
+				id := fresh()
+				if imm, ok := inst.Args[1].(x86asm.Imm); ok && imm > 0 {
+					consts[id] = bin.Address(imm)
+				}
+				st[f.l.parentReg(dst)] = id
+			case inst.Op == x86asm.LEA && f.isBasePtr(dst):
+				mem, _ := inst.Args[1].(x86asm.Mem)
+				id := fresh()
+				switch {
+				case mem.Index != 0:
+				case mem.Base == 0:
+					consts[id] = bin.Address(mem.Disp)
+				case mem.Base == x86asm.RIP:
+					consts[id] = inst.Addr + bin.Address(inst.Len) + bin.Address(mem.Disp)
+				case f.isBasePtr(mem.Base) && mem.Disp == 0:
+					id = obj(mem.Base)
+				}
+				st[f.l.parentReg(dst)] = id
+			case inst.Op == x86asm.XCHG && f.isBasePtr(dst) && f.isBasePtr(inst.Args[1]):
+				src := inst.Args[1].(x86asm.Reg)
+				a, b := obj(dst), obj(src)
+				st[f.l.parentReg(dst)], st[f.l.parentReg(src)] = b, a
+			case inst.Op == x86asm.CALL || inst.Op == x86asm.LCALL:
+				// Caller-saved registers are clobbered by the callee.
+				for _, reg := range []x86asm.Reg{x86asm.EAX, x86asm.ECX, x86asm.EDX, x86asm.ESI, x86asm.EDI, x86asm.R8L, x86asm.R9L, x86asm.R10L, x86asm.R11L} {
+					if f.l.Mode == 32 && reg >= x86asm.ESI {
+						break
+					}
+					delete(st, f.l.parentReg(reg))
+				}
+			default:
+				_, defs := f.l.regUseDef(inst)
+				for _, reg := range defs {
+					delete(st, reg)
+				}
+			}
+		}
+		out[blockAddr] = st
+		// Propagate base pointers to successors.
+		for _, succ := range f.succs(block) {
+			if _, ok := in[succ]; !ok {
+				in[succ] = make(regObjs)
+				for reg, id := range st {
+					in[succ][reg] = id
+				}
+			}
+		}
+	}
+	// Unify base pointers of registers reaching basic blocks along different
+	// paths.
+	for blockAddr, st := range out {
+		for _, succ := range f.succs(f.AsmFunc.Blocks[blockAddr]) {
+			for reg, id := range in[succ] {
+				if id2, ok := st[reg]; ok {
+					union(id, id2)
+				}
+			}
+		}
+	}
+	// Cluster accesses by base pointer.
+	layouts := make(map[int]structLayout)
+	var roots []int
+	for _, acc := range accs {
+		root := find(acc.obj)
+		layout, ok := layouts[root]
+		if !ok {
+			layout = make(structLayout)
+			layouts[root] = layout
+			roots = append(roots, root)
+		}
+		if acc.size > layout[acc.off] {
+			layout[acc.off] = acc.size
+		}
+	}
+	// Constant addresses of base pointers; conflicting constants are discarded.
+	rootConsts := make(map[int]bin.Address)
+	conflict := make(map[int]bool)
+	for id, addr := range consts {
+		root := find(id)
+		if prev, ok := rootConsts[root]; ok && prev != addr {
+			conflict[root] = true
+		}
+		rootConsts[root] = addr
+	}
+	sort.Ints(roots)
+	for _, root := range roots {
+		layout := layouts[root]
+		if !layout.valid() {
+			continue
+		}
+		if addr, ok := rootConsts[root]; ok {
+			if !conflict[root] {
+				f.l.addStructGlobal(addr, layout)
+			}
+			continue
+		}
+		rs := f.l.structType(layout)
+		for _, acc := range accs {
+			if find(acc.obj) == root {
+				f.fieldAccesses[acc.addr] = fieldAccess{rs: rs, off: acc.off}
+			}
+		}
+	}
+}
+
+// isBasePtr reports whether the given argument is a general purpose register of
+// pointer size which may hold the base pointer of a struct; i.e. not the stack,
+// frame or PIC base register.
+func (f *Func) isBasePtr(arg x86asm.Arg) bool {
+	reg, ok := arg.(x86asm.Reg)
+	if !ok || f.l.parentReg(reg) == 0 || regBytes(reg)*8 != f.l.Mode {
+		return false
+	}
+	switch reg {
+	case x86asm.EBP, x86asm.RBP:
+		return false
+	}
+	_, ok = f.picRegs[reg]
+	return !ok
+}
+
+// addStructGlobal records the struct layout accessed relative to the given
+// constant address of a global variable, merging layouts recorded by other
+// functions. Layouts are discarded if not contained within a single data
+// section.
+func (l *Lifter) addStructGlobal(addr bin.Address, layout structLayout) {
+	sect, ok := l.sectionAt(addr)
+	if !ok {
+		return
+	}
+	end := sect.Addr + bin.Address(sectSize(sect))
+	offs := layout.offsets()
+	last := offs[len(offs)-1]
+	if addr+bin.Address(last)+bin.Address(layout[last]) > end {
+		return
+	}
+	prev, ok := l.structGlobals[addr]
+	if !ok {
+		l.structGlobals[addr] = layout
+		return
+	}
+	merged := make(structLayout)
+	merged.merge(prev)
+	merged.merge(layout)
+	if merged.valid() {
+		l.structGlobals[addr] = merged
+	}
+}
+
+// structField returns a pointer to the struct field accessed by the given
+// memory reference relative to a base pointer, as recovered by recoverStructs,
+// emitting code to f. The boolean return value indicates success.
+func (f *Func) structField(mem *x86.Mem) (value.Value, bool) {
+	fa, ok := f.fieldAccesses[mem.Parent.Addr]
+	if !ok {
+		return nil, false
+	}
+	typ := types.NewPointer(fa.rs.typ)
+	src := f.convert(f.useReg(mem.Base()), typ)
+	indices := []value.Value{
+		constant.NewInt(0, types.I64),
+		constant.NewInt(int64(fa.rs.fields[fa.off]), types.I32),
+	}
+	v := f.cur.NewGetElementPtr(src, indices...)
+	return f.castToPtr(v, mem.Parent), true
+}
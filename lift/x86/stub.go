@@ -0,0 +1,94 @@
+package x86
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+)
+
+// liftState records the state of a function lifter prior to lifting an
+// instruction, so that code emitted by a failed attempt may be discarded.
+type liftState struct {
+	// Current basic block being generated.
+	cur *ir.BasicBlock
+	// Number of instructions of the current basic block.
+	ninsts int
+	// Terminator of the current basic block.
+	term ir.Terminator
+	// Number of basic blocks of the function.
+	nblocks int
+	// ESP and EBP disposition.
+	espDisp, ebpDisp int64
+	// Frame pointer tracking.
+	hasFrame bool
+}
+
+// saveState returns the current state of the function lifter.
+func (f *Func) saveState() *liftState {
+	return &liftState{
+		cur:      f.cur,
+		ninsts:   len(f.cur.Insts),
+		term:     f.cur.Term,
+		nblocks:  len(f.Blocks),
+		espDisp:  f.espDisp,
+		ebpDisp:  f.ebpDisp,
+		hasFrame: f.hasFrame,
+	}
+}
+
+// restoreState restores the state of the function lifter, discarding code
+// emitted since the state was saved.
+func (f *Func) restoreState(s *liftState) {
+	f.cur = s.cur
+	f.cur.Insts = f.cur.Insts[:s.ninsts]
+	f.cur.Term = s.term
+	f.Blocks = f.Blocks[:s.nblocks]
+	f.espDisp = s.espDisp
+	f.ebpDisp = s.ebpDisp
+	f.hasFrame = s.hasFrame
+}
+
+// liftStub lifts the given unsupported x86 instruction to LLVM IR as a call to
+// a stub function named after the opcode (e.g. @asm.cpuid), emitting code to
+// f. The address, machine code bytes and operands of the original instruction
+// are preserved as metadata of the call instruction.
+func (f *Func) liftStub(inst *x86.Inst) {
+	name := "asm." + strings.ToLower(inst.Op.String())
+	stub, ok := f.l.Stubs[name]
+	if !ok {
+		sig := types.NewFunc(types.Void)
+		stub = &ir.Function{
+			Name: name,
+			Typ:  types.NewPointer(sig),
+			Sig:  sig,
+		}
+		f.l.Stubs[name] = stub
+	}
+	call := f.cur.NewCall(stub)
+	var operands []metadata.Node
+	for _, arg := range inst.Args {
+		if arg == nil {
+			break
+		}
+		operands = append(operands, &metadata.String{Val: arg.String()})
+	}
+	code := f.l.File.Code(inst.Addr)
+	if len(code) > inst.Len {
+		code = code[:inst.Len]
+	}
+	call.Metadata = map[string]*metadata.Metadata{
+		"addr": {
+			Nodes: []metadata.Node{&metadata.String{Val: inst.Addr.String()}},
+		},
+		"bytes": {
+			Nodes: []metadata.Node{&metadata.String{Val: fmt.Sprintf("%X", code)}},
+		},
+		"operands": {
+			Nodes: operands,
+		},
+	}
+}
@@ -0,0 +1,582 @@
+package x86
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+)
+
+// ### [ Taint analysis ] ######################################################
+
+// A taintFunc specifies how an external function introduces untrusted data.
+type taintFunc struct {
+	// Indices of arguments pointing to memory which receives untrusted data
+	// (e.g. the buffer of recv).
+	bufs []int
+	// The return value is untrusted, or points to untrusted data (e.g. the
+	// command line returned by GetCommandLineA).
+	ret bool
+}
+
+// taintSources maps from the name of each taint source to the external
+// functions introducing untrusted data of the source. In addition, the argv
+// source taints the command line arguments of the main function.
+var taintSources = map[string]map[string]taintFunc{
+	"argv": {
+		"GetCommandLineA":    {ret: true},
+		"GetCommandLineW":    {ret: true},
+		"CommandLineToArgvW": {ret: true},
+		"__p___argv":         {ret: true},
+		"__p___wargv":        {ret: true},
+	},
+	"recv": {
+		"recv":             {bufs: []int{1}},
+		"recvfrom":         {bufs: []int{1}},
+		"recvmsg":          {bufs: []int{1}},
+		"WSARecv":          {bufs: []int{1}},
+		"WSARecvFrom":      {bufs: []int{1}},
+		"InternetReadFile": {bufs: []int{1}},
+	},
+	"ReadFile": {
+		"ReadFile":   {bufs: []int{1}},
+		"ReadFileEx": {bufs: []int{1}},
+		"read":       {bufs: []int{1}},
+		"fread":      {bufs: []int{0}},
+		"fgets":      {bufs: []int{0}, ret: true},
+		"fgetws":     {bufs: []int{0}, ret: true},
+		"gets":       {bufs: []int{0}, ret: true},
+		"getline":    {bufs: []int{0}},
+	},
+	"getenv": {
+		"getenv":                  {ret: true},
+		"_wgetenv":                {ret: true},
+		"GetEnvironmentVariableA": {bufs: []int{1}},
+		"GetEnvironmentVariableW": {bufs: []int{1}},
+	},
+}
+
+// TaintSources returns the names of the supported taint sources, in
+// alphabetical order.
+func TaintSources() []string {
+	var names []string
+	for name := range taintSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A taintSink specifies the arguments of an external function which must not
+// hold untrusted data.
+type taintSink struct {
+	// Kind of sink; e.g. "copy" for unbounded copies.
+	kind string
+	// Indices of checked arguments.
+	args []int
+}
+
+// taintSinks maps from the name of external functions to their sinks.
+var taintSinks = map[string]taintSink{
+	// Unbounded copies.
+	"strcpy":    {kind: "copy", args: []int{1}},
+	"strcat":    {kind: "copy", args: []int{1}},
+	"wcscpy":    {kind: "copy", args: []int{1}},
+	"wcscat":    {kind: "copy", args: []int{1}},
+	"lstrcpyA":  {kind: "copy", args: []int{1}},
+	"lstrcpyW":  {kind: "copy", args: []int{1}},
+	"lstrcatA":  {kind: "copy", args: []int{1}},
+	"lstrcatW":  {kind: "copy", args: []int{1}},
+	"sprintf":   {kind: "copy", args: []int{1}},
+	"vsprintf":  {kind: "copy", args: []int{1}},
+	"wsprintfA": {kind: "copy", args: []int{1}},
+	"wsprintfW": {kind: "copy", args: []int{1}},
+	// Sizes of bounded copies.
+	"memcpy":  {kind: "size", args: []int{2}},
+	"memmove": {kind: "size", args: []int{2}},
+	"strncpy": {kind: "size", args: []int{2}},
+	"strncat": {kind: "size", args: []int{2}},
+	// Sizes of allocations.
+	"malloc":       {kind: "alloc", args: []int{0}},
+	"calloc":       {kind: "alloc", args: []int{0, 1}},
+	"realloc":      {kind: "alloc", args: []int{1}},
+	"HeapAlloc":    {kind: "alloc", args: []int{2}},
+	"VirtualAlloc": {kind: "alloc", args: []int{1}},
+	// Format strings.
+	"printf":   {kind: "format", args: []int{0}},
+	"vprintf":  {kind: "format", args: []int{0}},
+	"fprintf":  {kind: "format", args: []int{1}},
+	"vfprintf": {kind: "format", args: []int{1}},
+	"snprintf": {kind: "format", args: []int{2}},
+	"syslog":   {kind: "format", args: []int{1}},
+	// Command execution.
+	"system":         {kind: "exec", args: []int{0}},
+	"popen":          {kind: "exec", args: []int{0}},
+	"execl":          {kind: "exec", args: []int{0}},
+	"execlp":         {kind: "exec", args: []int{0}},
+	"execv":          {kind: "exec", args: []int{0, 1}},
+	"execve":         {kind: "exec", args: []int{0, 1}},
+	"execvp":         {kind: "exec", args: []int{0, 1}},
+	"WinExec":        {kind: "exec", args: []int{0}},
+	"ShellExecuteA":  {kind: "exec", args: []int{2, 3}},
+	"ShellExecuteW":  {kind: "exec", args: []int{2, 3}},
+	"CreateProcessA": {kind: "exec", args: []int{0, 1}},
+	"CreateProcessW": {kind: "exec", args: []int{0, 1}},
+	// Loading of libraries.
+	"dlopen":         {kind: "load", args: []int{0}},
+	"LoadLibraryA":   {kind: "load", args: []int{0}},
+	"LoadLibraryW":   {kind: "load", args: []int{0}},
+	"LoadLibraryExA": {kind: "load", args: []int{0}},
+	"LoadLibraryExW": {kind: "load", args: []int{0}},
+	// File paths.
+	"fopen":       {kind: "path", args: []int{0}},
+	"open":        {kind: "path", args: []int{0}},
+	"CreateFileA": {kind: "path", args: []int{0}},
+	"CreateFileW": {kind: "path", args: []int{0}},
+}
+
+// taintCopies maps from the name of external functions copying data between
+// buffers to the index of the destination argument. Untrusted data held by or
+// pointed to by the remaining arguments is copied to the memory pointed to by
+// the destination argument.
+var taintCopies = map[string]int{
+	"strcpy":    0,
+	"strncpy":   0,
+	"strcat":    0,
+	"strncat":   0,
+	"wcscpy":    0,
+	"wcscat":    0,
+	"lstrcpyA":  0,
+	"lstrcpyW":  0,
+	"lstrcatA":  0,
+	"lstrcatW":  0,
+	"memcpy":    0,
+	"memmove":   0,
+	"sprintf":   0,
+	"snprintf":  0,
+	"vsprintf":  0,
+	"wsprintfA": 0,
+	"wsprintfW": 0,
+}
+
+// A TaintResult records the untrusted data reaching a lifted function.
+type TaintResult struct {
+	// Function name.
+	Func string `json:"func"`
+	// Entry address of the function.
+	Addr bin.Address `json:"addr"`
+	// Taint sources of untrusted data held by or pointed to by parameters,
+	// indexed by parameter name.
+	Params map[string][]string `json:"params,omitempty"`
+	// Taint sources of untrusted data held by or pointed to by the return
+	// value.
+	Ret []string `json:"ret,omitempty"`
+	// Untrusted data reaching sinks called by the function.
+	Flows []TaintFlow `json:"flows,omitempty"`
+}
+
+// A TaintFlow records untrusted data reaching an argument of a sink.
+type TaintFlow struct {
+	// Name of the sink function; e.g. "strcpy".
+	Sink string `json:"sink"`
+	// Kind of sink; e.g. "copy", "size", "alloc", "format", "exec", "load" or
+	// "path".
+	Kind string `json:"kind"`
+	// Index of the argument holding or pointing to untrusted data.
+	Arg int `json:"arg"`
+	// Taint sources of the untrusted data.
+	Sources []string `json:"sources"`
+	// Address of the call instruction; or 0 if unknown (i.e. lifted without
+	// DebugAddrs).
+	Addr bin.Address `json:"addr,omitempty"`
+}
+
+// Taint propagates untrusted data of the given taint sources (e.g. "argv",
+// "recv" and "ReadFile") forward through the given lifted functions, and
+// returns the untrusted data reaching the parameters, return values and sinks
+// (e.g. strcpy and system) of each function; functions not reached by
+// untrusted data are omitted.
+//
+// Untrusted data is tracked across values, memory (e.g. stack slots, global
+// variables and buffers returned by external functions) and calls between
+// lifted functions. The analysis is flow-insensitive and may therefore report
+// spurious flows; precision is improved by promoting registers and status
+// flags into SSA values (see PromoteRegs) before the analysis.
+func (l *Lifter) Taint(fs []*Func, sources []string) ([]TaintResult, error) {
+	t := &tainter{
+		l:     l,
+		funcs: make(map[*ir.Function]bool),
+		vals:  make(map[value.Value]*taintInfo),
+		mems:  make(map[value.Value]*taintInfo),
+		rets:  make(map[value.Value]*taintInfo),
+	}
+	if len(sources) > 64 {
+		return nil, errors.Errorf("too many taint sources; expected <= 64, got %d", len(sources))
+	}
+	for _, source := range sources {
+		if _, ok := taintSources[source]; !ok {
+			return nil, errors.Errorf("unknown taint source %q; valid sources: %s", source, strings.Join(TaintSources(), ", "))
+		}
+		t.sources = append(t.sources, source)
+		// Untrusted memory of the source; pointers loaded from the memory
+		// point to untrusted memory (e.g. argv[i][j]).
+		root := &ir.Global{Name: "taint." + source}
+		t.roots = append(t.roots, root)
+		t.mems[root] = &taintInfo{
+			srcs: 1 << uint(len(t.sources)-1),
+			objs: map[value.Value]bool{root: true},
+		}
+	}
+	for _, f := range fs {
+		if len(f.Blocks) == 0 {
+			continue
+		}
+		t.funcs[f.Function] = true
+		// Parameters point to memory of callers.
+		for _, param := range f.Sig.Params {
+			t.join(t.info(t.vals, param), &taintInfo{objs: map[value.Value]bool{param: true}})
+		}
+	}
+	t.taintMain()
+	for t.changed = true; t.changed; {
+		t.changed = false
+		for _, f := range fs {
+			if !t.funcs[f.Function] {
+				continue
+			}
+			for _, block := range f.Blocks {
+				for _, inst := range block.Insts {
+					t.inst(inst)
+				}
+				if term, ok := block.Term.(*ir.TermRet); ok && term.X != nil {
+					t.join(t.info(t.rets, f.Function), t.val(term.X))
+				}
+			}
+		}
+	}
+	return t.results(fs), nil
+}
+
+// taintInfo records the untrusted data held by a value or memory object.
+type taintInfo struct {
+	// Bit set of taint sources of the untrusted data held by the value.
+	srcs uint64
+	// Memory objects the value may point to; i.e. allocas, global variables,
+	// parameters (memory of callers), results of external calls (memory of
+	// external functions) and untrusted memory of taint sources.
+	objs map[value.Value]bool
+}
+
+// tainter tracks the propagation of untrusted data through lifted functions.
+type tainter struct {
+	// Lifter.
+	l *Lifter
+	// Taint sources, indexed by bit.
+	sources []string
+	// Untrusted memory of taint sources, indexed by bit.
+	roots []*ir.Global
+	// Lifted functions with bodies.
+	funcs map[*ir.Function]bool
+	// Untrusted data of values.
+	vals map[value.Value]*taintInfo
+	// Untrusted data of memory contents, indexed by memory object.
+	mems map[value.Value]*taintInfo
+	// Untrusted data of return values, indexed by lifted function.
+	rets map[value.Value]*taintInfo
+	// Untrusted data has propagated since the last iteration.
+	changed bool
+}
+
+// taintMain taints the command line arguments of the main function (argv of
+// main, or lpCmdLine of WinMain), if the argv taint source is selected.
+func (t *tainter) taintMain() {
+	bit := -1
+	for i, source := range t.sources {
+		if source == "argv" {
+			bit = i
+		}
+	}
+	if bit == -1 || t.l.Main == 0 {
+		return
+	}
+	f, ok := t.l.Funcs[t.l.Main]
+	if !ok {
+		return
+	}
+	index := 1
+	if strings.HasSuffix(strings.ToLower(f.Name), "winmain") {
+		index = 2
+	}
+	if index >= len(f.Sig.Params) {
+		return
+	}
+	dbg.Printf("tainting parameter %q of main function %q", f.Sig.Params[index].Name, f.Name)
+	root := t.roots[bit]
+	t.join(t.info(t.vals, f.Sig.Params[index]), &taintInfo{objs: map[value.Value]bool{root: true}})
+}
+
+// inst propagates untrusted data through the given instruction.
+func (t *tainter) inst(inst ir.Instruction) {
+	switch inst := inst.(type) {
+	case *ir.InstAlloca:
+		// Pointer to memory object; see val.
+	case *ir.InstLoad:
+		t.join(t.info(t.vals, inst), t.load(inst.Src))
+	case *ir.InstStore:
+		v := t.val(inst.Src)
+		for obj := range t.val(inst.Dst).objs {
+			t.join(t.info(t.mems, obj), v)
+		}
+	case *ir.InstCall:
+		t.call(inst)
+	default:
+		v, ok := inst.(value.Value)
+		if !ok {
+			return
+		}
+		res := t.info(t.vals, v)
+		mapOperands(inst, func(v value.Value) value.Value {
+			t.join(res, t.val(v))
+			return v
+		})
+	}
+}
+
+// call propagates untrusted data through the given call instruction.
+func (t *tainter) call(inst *ir.InstCall) {
+	res := t.info(t.vals, inst)
+	callee, ok := inst.Callee.(*ir.Function)
+	if ok && t.funcs[callee] {
+		// Call to lifted function.
+		for i, arg := range inst.Args {
+			if i < len(callee.Sig.Params) {
+				t.join(t.info(t.vals, callee.Sig.Params[i]), t.val(arg))
+			}
+		}
+		if ret, ok := t.rets[callee]; ok {
+			t.join(res, ret)
+		}
+		return
+	}
+	// Call to external function or indirect call. The result may be derived
+	// from untrusted data of the arguments (e.g. atoi), and points to memory
+	// of the external function.
+	var srcs uint64
+	for _, arg := range inst.Args {
+		srcs |= t.val(arg).srcs | t.load(arg).srcs
+	}
+	t.join(res, &taintInfo{srcs: srcs, objs: map[value.Value]bool{inst: true}})
+	if !ok {
+		return
+	}
+	if dst, ok := taintCopies[callee.Name]; ok && dst < len(inst.Args) {
+		for i, arg := range inst.Args {
+			if i == dst {
+				continue
+			}
+			v := t.load(arg)
+			v.srcs |= t.val(arg).srcs
+			for obj := range t.val(inst.Args[dst]).objs {
+				t.join(t.info(t.mems, obj), v)
+			}
+		}
+	}
+	for bit, source := range t.sources {
+		fn, ok := taintSources[source][callee.Name]
+		if !ok {
+			continue
+		}
+		src := &taintInfo{srcs: 1 << uint(bit)}
+		for _, buf := range fn.bufs {
+			if buf >= len(inst.Args) {
+				continue
+			}
+			for obj := range t.val(inst.Args[buf]).objs {
+				t.join(t.info(t.mems, obj), src)
+			}
+		}
+		if fn.ret {
+			root := t.roots[bit]
+			t.join(res, &taintInfo{srcs: src.srcs, objs: map[value.Value]bool{root: true}})
+		}
+	}
+}
+
+// load returns the untrusted data loaded through the given pointer; i.e. the
+// untrusted data of the memory objects pointed to, and of the pointer itself
+// (e.g. an untrusted index into a table).
+func (t *tainter) load(ptr value.Value) *taintInfo {
+	return t.deref(t.val(ptr))
+}
+
+// deref returns the untrusted data loaded through a pointer with the given
+// untrusted data.
+func (t *tainter) deref(p *taintInfo) *taintInfo {
+	v := &taintInfo{srcs: p.srcs}
+	for obj := range p.objs {
+		if mem, ok := t.mems[obj]; ok {
+			v.join(mem)
+		}
+	}
+	return v
+}
+
+// val returns the untrusted data of the given value. Allocas and global
+// variables point to themselves, and constant expressions propagate the
+// untrusted data of their operands.
+func (t *tainter) val(v value.Value) *taintInfo {
+	switch v := v.(type) {
+	case *ir.InstAlloca, *ir.Global:
+		return &taintInfo{objs: map[value.Value]bool{v: true}}
+	case *constant.ExprGetElementPtr:
+		return t.val(v.Src)
+	case *constant.ExprBitCast:
+		return t.val(v.From)
+	case *constant.ExprPtrToInt:
+		return t.val(v.From)
+	case *constant.ExprIntToPtr:
+		return t.val(v.From)
+	}
+	if info, ok := t.vals[v]; ok {
+		return info
+	}
+	return &taintInfo{}
+}
+
+// info returns the untrusted data of the given key of m, allocating it on
+// first use.
+func (t *tainter) info(m map[value.Value]*taintInfo, key value.Value) *taintInfo {
+	info, ok := m[key]
+	if !ok {
+		info = &taintInfo{}
+		m[key] = info
+	}
+	return info
+}
+
+// join joins the untrusted data of src into dst, recording whether untrusted
+// data has propagated.
+func (t *tainter) join(dst, src *taintInfo) {
+	if dst.join(src) {
+		t.changed = true
+	}
+}
+
+// join joins the untrusted data of src into info. The boolean return value
+// indicates whether info changed.
+func (info *taintInfo) join(src *taintInfo) bool {
+	if info == src {
+		return false
+	}
+	changed := false
+	if src.srcs&^info.srcs != 0 {
+		info.srcs |= src.srcs
+		changed = true
+	}
+	for obj := range src.objs {
+		if info.objs[obj] {
+			continue
+		}
+		if info.objs == nil {
+			info.objs = make(map[value.Value]bool)
+		}
+		info.objs[obj] = true
+		changed = true
+	}
+	return changed
+}
+
+// names returns the names of the taint sources of the given bit set.
+func (t *tainter) names(srcs uint64) []string {
+	var names []string
+	for bit, source := range t.sources {
+		if srcs&(1<<uint(bit)) != 0 {
+			names = append(names, source)
+		}
+	}
+	return names
+}
+
+// results returns the untrusted data reaching the parameters, return values
+// and sinks of the given lifted functions.
+func (t *tainter) results(fs []*Func) []TaintResult {
+	var results []TaintResult
+	for _, f := range fs {
+		if !t.funcs[f.Function] {
+			continue
+		}
+		r := TaintResult{
+			Func: f.Name,
+		}
+		if f.AsmFunc != nil {
+			r.Addr = f.AsmFunc.Addr
+		}
+		for _, param := range f.Sig.Params {
+			if srcs := t.val(param).srcs | t.load(param).srcs; srcs != 0 {
+				if r.Params == nil {
+					r.Params = make(map[string][]string)
+				}
+				r.Params[param.Name] = t.names(srcs)
+			}
+		}
+		if ret, ok := t.rets[f.Function]; ok {
+			r.Ret = t.names(t.deref(ret).srcs)
+		}
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				call, ok := inst.(*ir.InstCall)
+				if !ok {
+					continue
+				}
+				r.Flows = append(r.Flows, t.flows(call)...)
+			}
+		}
+		if len(r.Params) == 0 && len(r.Ret) == 0 && len(r.Flows) == 0 {
+			continue
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// flows returns the untrusted data reaching the arguments of the given call to
+// a sink.
+func (t *tainter) flows(call *ir.InstCall) []TaintFlow {
+	callee, ok := call.Callee.(*ir.Function)
+	if !ok || t.funcs[callee] {
+		return nil
+	}
+	sink, ok := taintSinks[callee.Name]
+	if !ok {
+		return nil
+	}
+	var addr bin.Address
+	if node, ok := call.Metadata["addr"]; ok {
+		if err := metadata.Unmarshal(node, &addr); err != nil {
+			warn.Printf("unable to parse address of call to %q; %v", callee.Name, err)
+		}
+	}
+	var flows []TaintFlow
+	for _, arg := range sink.args {
+		if arg >= len(call.Args) {
+			continue
+		}
+		srcs := t.val(call.Args[arg]).srcs | t.load(call.Args[arg]).srcs
+		if srcs == 0 {
+			continue
+		}
+		flows = append(flows, TaintFlow{
+			Sink:    callee.Name,
+			Kind:    sink.kind,
+			Arg:     arg,
+			Sources: t.names(srcs),
+			Addr:    addr,
+		})
+	}
+	return flows
+}
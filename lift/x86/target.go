@@ -0,0 +1,56 @@
+package x86
+
+// Data layouts of x86 targets, as used by LLVM.
+const (
+	// 32-bit ELF and other non-Windows targets.
+	dataLayout32 = "e-m:e-p:32:32-f64:32:64-f80:32-n8:16:32-S128"
+	// 32-bit Windows (MSVC).
+	dataLayout32Win = "e-m:x-p:32:32-i64:64-f80:32-n8:16:32-a:0:32-S32"
+	// 64-bit ELF and other non-Windows targets.
+	dataLayout64 = "e-m:e-i64:64-f80:128-n8:16:32:64-S128"
+	// 64-bit Windows (MSVC).
+	dataLayout64Win = "e-m:w-i64:64-f80:128-n8:16:32:64-S128"
+)
+
+// TargetTriple returns the target triple of the lifted LLVM IR module, based on
+// the processor mode and file format of the binary executable; e.g.
+// "i686-pc-windows-msvc" for 32-bit PE files.
+func (l *Lifter) TargetTriple() string {
+	switch l.Mode {
+	case 16:
+		// Real mode code; e.g. DOS executables.
+		return "i386-pc-unknown-code16"
+	case 64:
+		switch l.File.Format {
+		case "pe":
+			return "x86_64-pc-windows-msvc"
+		case "elf":
+			return "x86_64-pc-linux-gnu"
+		}
+		return "x86_64-unknown-unknown"
+	}
+	switch l.File.Format {
+	case "pe":
+		return "i686-pc-windows-msvc"
+	case "elf":
+		return "i686-pc-linux-gnu"
+	}
+	return "i386-unknown-unknown"
+}
+
+// DataLayout returns the data layout of the lifted LLVM IR module, based on the
+// processor mode and file format of the binary executable. The data layout
+// specifies pointer sizes and the alignment of types.
+func (l *Lifter) DataLayout() string {
+	win := l.File.Format == "pe"
+	switch {
+	case l.Mode == 64 && win:
+		return dataLayout64Win
+	case l.Mode == 64:
+		return dataLayout64
+	case win:
+		return dataLayout32Win
+	default:
+		return dataLayout32
+	}
+}
@@ -3,7 +3,6 @@ package x86
 import (
 	"github.com/decomp/exp/bin"
 	"github.com/decomp/exp/disasm/x86"
-	"github.com/kr/pretty"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
@@ -107,7 +106,7 @@ func (f *Func) liftTermJB(term *x86.Inst) error {
 func (f *Func) liftTermJCXZ(term *x86.Inst) error {
 	// Jump if CX register is zero.
 	//    (CX=0)
-	pretty.Println("term:", term)
+	trace.Dump("term:", term)
 	panic("emitTermJCXZ: not yet implemented")
 }
 
@@ -175,7 +174,7 @@ func (f *Func) liftTermJP(term *x86.Inst) error {
 func (f *Func) liftTermJRCXZ(term *x86.Inst) error {
 	// Jump if RCX register is zero.
 	//    (RCX=0)
-	pretty.Println("term:", term)
+	trace.Dump("term:", term)
 	panic("emitTermJRCXZ: not yet implemented")
 }
 
@@ -288,6 +287,17 @@ func (f *Func) liftTermJcc(arg *x86.Arg, cond value.Value) error {
 	if !ok {
 		return errors.Errorf("unable to locate address for terminator argument %v", arg)
 	}
+	// Branch unconditionally if an edge is infeasible, as determined by
+	// symbolic execution.
+	pruned := false
+	for _, infeasible := range arg.Parent.Infeasible {
+		switch infeasible {
+		case targetAddr:
+			targetAddr, pruned = nextAddr, true
+		case nextAddr:
+			nextAddr, pruned = targetAddr, true
+		}
+	}
 	target, ok := f.blocks[targetAddr]
 	if !ok {
 		return errors.Errorf("unable to locate target basic block at %v", targetAddr)
@@ -297,6 +307,11 @@ func (f *Func) liftTermJcc(arg *x86.Arg, cond value.Value) error {
 	if !ok {
 		return errors.Errorf("unable to locate fallthrough basic block at %v", nextAddr)
 	}
+	if pruned {
+		f.cur.NewBr(target)
+		f.cur = next
+		return nil
+	}
 	f.cur.NewCondBr(cond, target, next)
 	f.cur = next
 	return nil
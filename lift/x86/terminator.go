@@ -6,7 +6,6 @@ import (
 
 	"github.com/decomp/exp/bin"
 	"github.com/decomp/exp/disasm/x86"
-	"github.com/kr/pretty"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
@@ -39,7 +38,11 @@ func (f *Func) liftTerm(term *x86.Inst) error {
 		case x86asm.PrefixData16, x86asm.PrefixData16 | x86asm.PrefixImplicit:
 			// prefix already supported.
 		default:
-			pretty.Println("terminator with prefix:", term)
+			if f.l.isSegOverride(prefix) {
+				// Segment override resolved by f.mem.
+				continue
+			}
+			trace.Dump("terminator with prefix:", term)
 			panic(fmt.Errorf("support for %v terminator with prefix not yet implemented", term.Op))
 		}
 	}
@@ -95,9 +98,20 @@ func (f *Func) liftTerm(term *x86.Inst) error {
 	// Unconditional jump terminators.
 	case x86asm.JMP:
 		return f.liftTermJMP(term)
+	case x86asm.LJMP:
+		return f.liftTermLJMP(term)
 	// Return terminators.
-	case x86asm.RET:
+	case x86asm.RET, x86asm.LRET, x86asm.IRET:
 		return f.liftTermRET(term)
+	// Calls to noreturn functions.
+	case x86asm.CALL, x86asm.LCALL:
+		return f.liftTermCALL(term)
+	// Interrupts terminating the program.
+	case x86asm.INT:
+		return f.liftTermINT(term)
+	// Traps raising exceptions.
+	case x86asm.UD1, x86asm.UD2, x86asm.HLT:
+		return f.liftTermTrap(term)
 	default:
 		panic(fmt.Errorf("support for x86 terminator opcode %v not yet implemented", term.Op))
 	}
@@ -108,6 +122,42 @@ func (f *Func) liftTerm(term *x86.Inst) error {
 // liftTermJMP lifts the given x86 JMP terminator to LLVM IR, emitting code to
 // f.
 func (f *Func) liftTermJMP(term *x86.Inst) error {
+	// Handle indirect jumps resolved by emulation, as direct jumps.
+	if term.EmuTarget != 0 {
+		direct := *term
+		direct.EmuTarget = 0
+		next := term.Addr + bin.Address(term.Len)
+		direct.Args = x86asm.Args{x86asm.Rel(int32(term.EmuTarget - next))}
+		return f.liftTermJMP(&direct)
+	}
+
+	// Handle indirect jumps resolved by symbolic execution, as switch
+	// statements over the jump target.
+	if len(term.SymTargets) > 0 {
+		var cases []*ir.Case
+		typ := types.NewInt(f.l.Mode)
+		for _, targetAddr := range term.SymTargets {
+			target, ok := f.blocks[targetAddr]
+			if !ok {
+				// E.g. tail call.
+				cases = nil
+				break
+			}
+			c := ir.NewCase(constant.NewInt(int64(targetAddr), typ), target)
+			cases = append(cases, c)
+		}
+		if len(cases) > 0 {
+			// The target is always one of the resolved targets. Thus, the
+			// default branch is unreachable.
+			addr := f.useArgElem(term.Arg(0), typ)
+			unreachable := &ir.BasicBlock{}
+			unreachable.NewUnreachable()
+			f.AppendBlock(unreachable)
+			f.cur.NewSwitch(addr, unreachable, cases...)
+			return nil
+		}
+	}
+
 	// Handle tail calls.
 	if f.isTailCall(term) {
 		// Hack: interpret the JMP instruction as a CALL instruction. This works
@@ -119,7 +169,7 @@ func (f *Func) liftTermJMP(term *x86.Inst) error {
 		// Handle return values.
 		if !types.Equal(f.Sig.Ret, types.Void) {
 			// Non-void functions, pass return value in EAX.
-			result := f.useReg(x86.EAX)
+			result := f.useRet()
 			f.cur.NewRet(result)
 			return nil
 		}
@@ -183,10 +233,64 @@ func (f *Func) liftTermJMP(term *x86.Inst) error {
 			return nil
 		}
 	}
-	pretty.Println("term:", term)
+	trace.Dump("term:", term)
 	panic("emitTermJMP: not yet implemented")
 }
 
+// --- [ LJMP ] ----------------------------------------------------------------
+
+// liftTermLJMP lifts the given x86 LJMP terminator to LLVM IR, emitting code to
+// f.
+func (f *Func) liftTermLJMP(term *x86.Inst) error {
+	// Direct far jump of 16-bit real mode; e.g. `jmp 0x1234:0x0010`.
+	if near, ok := nearInst(term); ok {
+		return f.liftTermJMP(near)
+	}
+	trace.Dump("term:", term)
+	panic("emitTermLJMP: not yet implemented")
+}
+
+// --- [ CALL ] ----------------------------------------------------------------
+
+// liftTermCALL lifts the given x86 CALL terminator to LLVM IR, emitting code to
+// f. CALL terminators invoke functions which do not return to their caller
+// (e.g. exit), and are thus followed by an unreachable terminator.
+func (f *Func) liftTermCALL(term *x86.Inst) error {
+	call := f.liftInstCALL
+	if term.Op == x86asm.LCALL {
+		call = f.liftInstLCALL
+	}
+	if err := call(term); err != nil {
+		return errors.WithStack(err)
+	}
+	f.cur.NewUnreachable()
+	return nil
+}
+
+// --- [ INT ] -----------------------------------------------------------------
+
+// liftTermINT lifts the given x86 INT terminator to LLVM IR, emitting code to
+// f. INT terminators invoke interrupts which terminate the program (e.g. INT
+// 20h or INT 21h/AH=4Ch) or traps (e.g. INT3), and are thus followed by an
+// unreachable terminator.
+func (f *Func) liftTermINT(term *x86.Inst) error {
+	if x86.IsTrap(term, f.l.Mode) {
+		return f.liftTermTrap(term)
+	}
+	if imm, ok := term.Args[0].(x86asm.Imm); ok && imm != x86.IntDOS {
+		if dos, ok := intFuncs[uint8(imm)]; ok {
+			f.liftDOSCall(term, 0, dos)
+			f.cur.NewUnreachable()
+			return nil
+		}
+	}
+	if err := f.liftInstINT(term); err != nil {
+		return errors.WithStack(err)
+	}
+	f.cur.NewUnreachable()
+	return nil
+}
+
 // --- [ RET ] -----------------------------------------------------------------
 
 // liftTermRET lifts the given x86 RET terminator to LLVM IR, emitting code to
@@ -194,7 +298,7 @@ func (f *Func) liftTermJMP(term *x86.Inst) error {
 func (f *Func) liftTermRET(term *x86.Inst) error {
 	// Handle return values of non-void functions (passed through EAX).
 	if !types.Equal(f.Sig.Ret, types.Void) {
-		result := f.useReg(x86.EAX)
+		result := f.useRet()
 		f.cur.NewRet(result)
 		return nil
 	}
@@ -212,8 +316,8 @@ func (f *Func) isTailCall(inst *x86.Inst) bool {
 			return false
 		}
 		if !f.l.IsFunc(target) {
-			fmt.Println("arg:", arg)
-			pretty.Println(arg)
+			trace.Println("arg:", arg)
+			trace.Dump(arg)
 			panic(fmt.Errorf("tail call to non-function address %v", target))
 		}
 		return true
@@ -225,8 +329,8 @@ func (f *Func) isTailCall(inst *x86.Inst) bool {
 			for _, target := range targets {
 				if !f.contains(target) {
 					if !f.l.IsFunc(target) {
-						fmt.Println("arg:", arg)
-						pretty.Println(arg)
+						trace.Println("arg:", arg)
+						trace.Dump(arg)
 						panic(fmt.Errorf("tail call to non-function address %v", target))
 					}
 					return true
@@ -252,8 +356,8 @@ func (f *Func) isTailCall(inst *x86.Inst) bool {
 		return true
 	}
 
-	fmt.Println("arg:", arg)
-	pretty.Println(arg)
+	trace.Println("arg:", arg)
+	trace.Dump(arg)
 	panic("not yet implemented")
 }
 
@@ -0,0 +1,14 @@
+[BITS 16]
+
+section .text
+
+; === [ Pascal calling convention ] ============================================
+
+; Arguments pushed from left to right and purged by the callee; the first
+; argument is located at the highest stack offset.
+pascal:
+	push    bp
+	mov     bp, sp
+	mov     ax, [bp+6]
+	pop     bp
+	ret     4
@@ -0,0 +1,44 @@
+define i16 @pascal(i16 %a, i16 %b) !addr !{!"0x0"} {
+; <label>:0
+	%sp = alloca i16
+	%eax = alloca i32
+	%esp = alloca i32
+	%ebp = alloca i32
+	%esp_-2 = alloca i16
+	%esp_2 = alloca i16
+	%esp_4 = alloca i16
+	%1 = load i32, i32* %esp
+	store i16 %a, i16* %esp_4
+	%2 = load i32, i32* %esp
+	store i16 %b, i16* %esp_2
+	br label %block_000000
+block_000000:
+	%3 = load i32, i32* %ebp
+	%4 = trunc i32 %3 to i16
+	%5 = load i32, i32* %esp
+	store i16 %4, i16* %esp_-2
+	%6 = load i16, i16* %sp
+	%7 = zext i16 %6 to i32
+	%8 = load i32, i32* %ebp
+	%9 = and i32 %8, -65536
+	%10 = or i32 %9, %7
+	store i32 %10, i32* %ebp
+	%11 = load i32, i32* %ebp
+	%12 = trunc i32 %11 to i16
+	%13 = load i16, i16* %esp_4
+	%14 = zext i16 %13 to i32
+	%15 = load i32, i32* %eax
+	%16 = and i32 %15, -65536
+	%17 = or i32 %16, %14
+	store i32 %17, i32* %eax
+	%18 = load i32, i32* %esp
+	%19 = load i16, i16* %esp_-2
+	%20 = zext i16 %19 to i32
+	%21 = load i32, i32* %ebp
+	%22 = and i32 %21, -65536
+	%23 = or i32 %22, %20
+	store i32 %23, i32* %ebp
+	%24 = load i32, i32* %eax
+	%25 = trunc i32 %24 to i16
+	ret i16 %25
+}
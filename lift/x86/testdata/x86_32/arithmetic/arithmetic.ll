@@ -1,96 +1,170 @@
 define void @div_r8() !addr !{!"0x10000000"} {
 ; <label>:0
-	%al = alloca i8
-	%bl = alloca i8
-	%ah = alloca i8
-	%ax = alloca i16
 	%eax = alloca i32
+	%ebx = alloca i32
 	br label %block_10000000
 block_10000000:
-	store i32 84, i16* %ax
-	store i32 2, i8* %bl
-	%1 = load i8, i8* %bl
-	%2 = load i16, i16* %ax
-	%3 = zext i8 %1 to i16
-	%4 = udiv i16 %2, %3
-	%5 = urem i16 %2, %3
-	store i16 %4, i8* %al
-	store i16 %5, i8* %ah
-	%6 = load i32, i32* %eax
-	%7 = and i32 %6, 255
-	store i32 %7, i32* %eax
+	%1 = load i32, i32* %eax
+	%2 = and i32 %1, -65536
+	%3 = or i32 %2, 84
+	store i32 %3, i32* %eax
+	%4 = load i32, i32* %ebx
+	%5 = and i32 %4, -256
+	%6 = or i32 %5, 2
+	store i32 %6, i32* %ebx
+	%7 = load i32, i32* %ebx
+	%8 = trunc i32 %7 to i8
+	%9 = load i32, i32* %eax
+	%10 = trunc i32 %9 to i16
+	%11 = zext i8 %8 to i16
+	%12 = udiv i16 %10, %11
+	%13 = urem i16 %10, %11
+	%14 = trunc i16 %12 to i8
+	%15 = zext i8 %14 to i32
+	%16 = load i32, i32* %eax
+	%17 = and i32 %16, -256
+	%18 = or i32 %17, %15
+	store i32 %18, i32* %eax
+	%19 = trunc i16 %13 to i8
+	%20 = zext i8 %19 to i32
+	%21 = shl i32 %20, 8
+	%22 = load i32, i32* %eax
+	%23 = and i32 %22, -65281
+	%24 = or i32 %23, %21
+	store i32 %24, i32* %eax
+	%25 = load i32, i32* %eax
+	%26 = and i32 %25, 255
+	store i32 %26, i32* %eax
 	ret void
 }
 
 define void @div_m8() !addr !{!"0x1000000E"} {
 ; <label>:0
-	%al = alloca i8
-	%ah = alloca i8
-	%ax = alloca i16
 	%eax = alloca i32
 	br label %block_1000000E
 block_1000000E:
-	store i32 84, i16* %ax
+	%1 = load i32, i32* %eax
+	%2 = and i32 %1, -65536
+	%3 = or i32 %2, 84
+	store i32 %3, i32* %eax
 	store i32 2, i8* @m8
-	%1 = load i8, i8* @m8
-	%2 = load i16, i16* %ax
-	%3 = zext i8 %1 to i16
-	%4 = udiv i16 %2, %3
-	%5 = urem i16 %2, %3
-	store i16 %4, i8* %al
-	store i16 %5, i8* %ah
-	%6 = load i32, i32* %eax
-	%7 = and i32 %6, 255
-	store i32 %7, i32* %eax
+	%4 = load i8, i8* @m8
+	%5 = load i32, i32* %eax
+	%6 = trunc i32 %5 to i16
+	%7 = zext i8 %4 to i16
+	%8 = udiv i16 %6, %7
+	%9 = urem i16 %6, %7
+	%10 = trunc i16 %8 to i8
+	%11 = zext i8 %10 to i32
+	%12 = load i32, i32* %eax
+	%13 = and i32 %12, -256
+	%14 = or i32 %13, %11
+	store i32 %14, i32* %eax
+	%15 = trunc i16 %9 to i8
+	%16 = zext i8 %15 to i32
+	%17 = shl i32 %16, 8
+	%18 = load i32, i32* %eax
+	%19 = and i32 %18, -65281
+	%20 = or i32 %19, %17
+	store i32 %20, i32* %eax
+	%21 = load i32, i32* %eax
+	%22 = and i32 %21, 255
+	store i32 %22, i32* %eax
 	ret void
 }
 
 define void @div_r16() !addr !{!"0x10000025"} {
 ; <label>:0
-	%ax = alloca i16
-	%dx = alloca i16
-	%bx = alloca i16
 	%eax = alloca i32
-	%"dx\3Aax" = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
 	br label %block_10000025
 block_10000025:
-	store i32 0, i16* %dx
-	store i32 84, i16* %ax
-	store i32 2, i16* %bx
-	%1 = load i16, i16* %bx
-	%2 = load i32, i32* %"dx\3Aax"
-	%3 = zext i16 %1 to i32
-	%4 = udiv i32 %2, %3
-	%5 = urem i32 %2, %3
-	store i32 %4, i16* %ax
-	store i32 %5, i16* %dx
-	%6 = load i32, i32* %eax
-	%7 = and i32 %6, 65535
-	store i32 %7, i32* %eax
+	%1 = load i32, i32* %edx
+	%2 = and i32 %1, -65536
+	%3 = or i32 %2, 0
+	store i32 %3, i32* %edx
+	%4 = load i32, i32* %eax
+	%5 = and i32 %4, -65536
+	%6 = or i32 %5, 84
+	store i32 %6, i32* %eax
+	%7 = load i32, i32* %ebx
+	%8 = and i32 %7, -65536
+	%9 = or i32 %8, 2
+	store i32 %9, i32* %ebx
+	%10 = load i32, i32* %ebx
+	%11 = trunc i32 %10 to i16
+	%12 = load i32, i32* %edx
+	%13 = trunc i32 %12 to i16
+	%14 = zext i16 %13 to i32
+	%15 = shl i32 %14, 16
+	%16 = load i32, i32* %eax
+	%17 = trunc i32 %16 to i16
+	%18 = zext i16 %17 to i32
+	%19 = or i32 %15, %18
+	%20 = zext i16 %11 to i32
+	%21 = udiv i32 %19, %20
+	%22 = urem i32 %19, %20
+	%23 = trunc i32 %21 to i16
+	%24 = zext i16 %23 to i32
+	%25 = load i32, i32* %eax
+	%26 = and i32 %25, -65536
+	%27 = or i32 %26, %24
+	store i32 %27, i32* %eax
+	%28 = trunc i32 %22 to i16
+	%29 = zext i16 %28 to i32
+	%30 = load i32, i32* %edx
+	%31 = and i32 %30, -65536
+	%32 = or i32 %31, %29
+	store i32 %32, i32* %edx
+	%33 = load i32, i32* %eax
+	%34 = and i32 %33, 65535
+	store i32 %34, i32* %eax
 	ret void
 }
 
 define void @div_m16() !addr !{!"0x1000003A"} {
 ; <label>:0
-	%ax = alloca i16
-	%dx = alloca i16
 	%eax = alloca i32
-	%"dx\3Aax" = alloca i32
+	%edx = alloca i32
 	br label %block_1000003A
 block_1000003A:
-	store i32 0, i16* %dx
-	store i32 84, i16* %ax
+	%1 = load i32, i32* %edx
+	%2 = and i32 %1, -65536
+	%3 = or i32 %2, 0
+	store i32 %3, i32* %edx
+	%4 = load i32, i32* %eax
+	%5 = and i32 %4, -65536
+	%6 = or i32 %5, 84
+	store i32 %6, i32* %eax
 	store i32 2, i16* @m16
-	%1 = load i16, i16* @m16
-	%2 = load i32, i32* %"dx\3Aax"
-	%3 = zext i16 %1 to i32
-	%4 = udiv i32 %2, %3
-	%5 = urem i32 %2, %3
-	store i32 %4, i16* %ax
-	store i32 %5, i16* %dx
-	%6 = load i32, i32* %eax
-	%7 = and i32 %6, 65535
-	store i32 %7, i32* %eax
+	%7 = load i16, i16* @m16
+	%8 = load i32, i32* %edx
+	%9 = trunc i32 %8 to i16
+	%10 = zext i16 %9 to i32
+	%11 = shl i32 %10, 16
+	%12 = load i32, i32* %eax
+	%13 = trunc i32 %12 to i16
+	%14 = zext i16 %13 to i32
+	%15 = or i32 %11, %14
+	%16 = zext i16 %7 to i32
+	%17 = udiv i32 %15, %16
+	%18 = urem i32 %15, %16
+	%19 = trunc i32 %17 to i16
+	%20 = zext i16 %19 to i32
+	%21 = load i32, i32* %eax
+	%22 = and i32 %21, -65536
+	%23 = or i32 %22, %20
+	store i32 %23, i32* %eax
+	%24 = trunc i32 %18 to i16
+	%25 = zext i16 %24 to i32
+	%26 = load i32, i32* %edx
+	%27 = and i32 %26, -65536
+	%28 = or i32 %27, %25
+	store i32 %28, i32* %edx
+	%29 = load i32, i32* %eax
+	%30 = and i32 %29, 65535
+	store i32 %30, i32* %eax
 	ret void
 }
 
@@ -99,19 +173,25 @@ define void @div_r32() !addr !{!"0x10000058"} {
 	%eax = alloca i32
 	%edx = alloca i32
 	%ebx = alloca i32
-	%"edx\3Aeax" = alloca i64
 	br label %block_10000058
 block_10000058:
 	store i32 0, i32* %edx
 	store i32 84, i32* %eax
 	store i32 2, i32* %ebx
 	%1 = load i32, i32* %ebx
-	%2 = load i64, i64* %"edx\3Aeax"
-	%3 = zext i32 %1 to i64
-	%4 = udiv i64 %2, %3
-	%5 = urem i64 %2, %3
-	store i64 %4, i32* %eax
-	store i64 %5, i32* %edx
+	%2 = load i32, i32* %edx
+	%3 = zext i32 %2 to i64
+	%4 = shl i64 %3, 32
+	%5 = load i32, i32* %eax
+	%6 = zext i32 %5 to i64
+	%7 = or i64 %4, %6
+	%8 = zext i32 %1 to i64
+	%9 = udiv i64 %7, %8
+	%10 = urem i64 %7, %8
+	%11 = trunc i64 %9 to i32
+	store i32 %11, i32* %eax
+	%12 = trunc i64 %10 to i32
+	store i32 %12, i32* %edx
 	ret void
 }
 
@@ -119,18 +199,24 @@ define void @div_m32() !addr !{!"0x1000006A"} {
 ; <label>:0
 	%eax = alloca i32
 	%edx = alloca i32
-	%"edx\3Aeax" = alloca i64
 	br label %block_1000006A
 block_1000006A:
 	store i32 0, i32* %edx
 	store i32 84, i32* %eax
 	store i32 2, i32* @m32
 	%1 = load i32, i32* @m32
-	%2 = load i64, i64* %"edx\3Aeax"
-	%3 = zext i32 %1 to i64
-	%4 = udiv i64 %2, %3
-	%5 = urem i64 %2, %3
-	store i64 %4, i32* %eax
-	store i64 %5, i32* %edx
+	%2 = load i32, i32* %edx
+	%3 = zext i32 %2 to i64
+	%4 = shl i64 %3, 32
+	%5 = load i32, i32* %eax
+	%6 = zext i32 %5 to i64
+	%7 = or i64 %4, %6
+	%8 = zext i32 %1 to i64
+	%9 = udiv i64 %7, %8
+	%10 = urem i64 %7, %8
+	%11 = trunc i64 %9 to i32
+	store i32 %11, i32* %eax
+	%12 = trunc i64 %10 to i32
+	store i32 %12, i32* %edx
 	ret void
 }
@@ -0,0 +1,23 @@
+[BITS 32]
+
+global lookup:function
+global lookup_word:function
+
+section .text
+
+; === [ Array stride inference ] ===============================================
+
+; Array of 32-bit integers indexed by ECX.
+lookup:
+	mov     eax, [4*ecx+table]
+	ret
+
+; Array of 16-bit integers indexed by ECX.
+lookup_word:
+	movzx   eax, word [2*ecx+words]
+	ret
+
+section .data
+
+table: dd 1, 2, 3, 4
+words: dw 1, 2, 3
@@ -0,0 +1,23 @@
+[BITS 32]
+
+global leaf:function
+global caller:function
+
+section .text
+
+; === [ Function attribute inference ] =========================================
+
+; Leaf function without memory accesses; readnone.
+leaf:
+	mov     eax, 42
+	ret
+
+; Caller writing the return value of leaf to a global variable.
+caller:
+	call    leaf
+	mov     [counter], eax
+	ret
+
+section .bss
+
+counter: resd 1
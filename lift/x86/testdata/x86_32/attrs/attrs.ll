@@ -0,0 +1,21 @@
+define i32 @leaf() nounwind readnone !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	br label %block_10000000
+block_10000000:
+	store i32 42, i32* %eax
+	%1 = load i32, i32* %eax
+	ret i32 %1
+}
+
+define void @caller() nounwind !addr !{!"0x10000006"} {
+; <label>:0
+	%eax = alloca i32
+	br label %block_10000006
+block_10000006:
+	%1 = call i32 @leaf()
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	store i32 %2, i32* @counter
+	ret void
+}
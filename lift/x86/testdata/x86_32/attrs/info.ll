@@ -0,0 +1 @@
+@counter = global i32 zeroinitializer, !addr !{!"0x30000000"}
@@ -0,0 +1,57 @@
+[BITS 32]
+
+global dispatch:function
+global classify:function
+
+section .text
+
+; === [ Enum recovery ] ========================================================
+
+; --- [ Global variable ] ------------------------------------------------------
+
+; If-else chain comparing state against 1, 2 and 3, through EAX.
+dispatch:
+	mov     eax, [state]
+	cmp     eax, 1
+	je      .one
+	cmp     eax, 2
+	je      .two
+	cmp     eax, 3
+	je      .three
+	xor     eax, eax
+	ret
+.one:
+	mov     eax, 10
+	ret
+.two:
+	mov     eax, 20
+	ret
+.three:
+	mov     eax, 30
+	ret
+
+; --- [ Stack slot ] -----------------------------------------------------------
+
+; If-else chain comparing the stack slot at [esp+4] against 0, 1 and 2.
+classify:
+	cmp     dword [esp+4], 0
+	je      .zero
+	cmp     dword [esp+4], 1
+	je      .one
+	cmp     dword [esp+4], 2
+	je      .two
+	mov     eax, -1
+	ret
+.zero:
+	xor     eax, eax
+	ret
+.one:
+	mov     eax, 1
+	ret
+.two:
+	mov     eax, 2
+	ret
+
+section .bss
+
+state: resd 1
@@ -0,0 +1,23 @@
+[BITS 32]
+
+global scale:function
+
+section .text
+
+; === [ Floating-point retyping ] ==============================================
+
+; Scales factor by ratio and the float parameter at [esp+4]; count is only
+; accessed as an integer.
+scale:
+	fld     dword [factor]
+	fmul    qword [ratio]
+	fmul    dword [esp+4]
+	fstp    dword [factor]
+	mov     eax, [count]
+	ret
+
+section .data
+
+factor: dd 1.5
+ratio:  dq 2.0
+count:  dd 3
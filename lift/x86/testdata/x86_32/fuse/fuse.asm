@@ -0,0 +1,30 @@
+[BITS 32]
+
+global fuse_cmp:function
+global fuse_test:function
+
+section .text
+
+; === [ CMP+Jcc fusion ] =======================================================
+
+; --- [ CMP ] ------------------------------------------------------------------
+
+fuse_cmp:
+	cmp     eax, 5
+	je      .equal
+	mov     eax, 1
+	ret
+.equal:
+	mov     eax, 2
+	ret
+
+; --- [ TEST ] -----------------------------------------------------------------
+
+fuse_test:
+	test    ecx, ecx
+	jne     .nonzero
+	mov     eax, 0
+	ret
+.nonzero:
+	mov     eax, ecx
+	ret
@@ -0,0 +1,36 @@
+define void @fuse_cmp() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	br label %block_10000000
+block_10000000:
+	%1 = load i32, i32* %eax
+	%2 = icmp eq i32 %1, 5
+	br i1 %2, label %block_1000000B, label %block_10000005
+block_10000005:
+	store i32 1, i32* %eax
+	ret void
+block_1000000B:
+	store i32 2, i32* %eax
+	ret void
+}
+
+define x86_thiscallcc void @fuse_test(i32 %arg_ecx) !addr !{!"0x10000011"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	store i32 %arg_ecx, i32* %ecx
+	br label %block_10000011
+block_10000011:
+	%1 = load i32, i32* %ecx
+	%2 = load i32, i32* %ecx
+	%3 = and i32 %1, %2
+	%4 = icmp ne i32 %3, 0
+	br i1 %4, label %block_1000001B, label %block_10000015
+block_10000015:
+	store i32 0, i32* %eax
+	ret void
+block_1000001B:
+	%5 = load i32, i32* %ecx
+	store i32 %5, i32* %eax
+	ret void
+}
@@ -6,6 +6,7 @@ define void @_start() !addr !{!"0x400000"} {
 block_400000:
 	%1 = load i32, i32* %esp
 	store i32 42, i32* %esp_-4
-	call void @exit()
-	ret void
+	%2 = load i32, i32* %esp_-4
+	call void @exit(i32 %2)
+	unreachable
 }
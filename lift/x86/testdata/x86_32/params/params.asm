@@ -0,0 +1,50 @@
+[BITS 32]
+
+global cdecl_esp:function
+global cdecl_ebp:function
+global stdcall:function
+global fastcall:function
+global thiscall:function
+
+section .text
+
+; === [ Parameter inference ] ==================================================
+
+; --- [ cdecl ] ----------------------------------------------------------------
+
+; Arguments accessed through ESP.
+cdecl_esp:
+	mov     eax, [esp+4]
+	mov     ecx, [esp+8]
+	ret
+
+; Arguments accessed through the EBP frame pointer.
+cdecl_ebp:
+	push    ebp
+	mov     ebp, esp
+	mov     eax, [ebp+8]
+	pop     ebp
+	ret
+
+; --- [ stdcall ] --------------------------------------------------------------
+
+; Arguments purged by callee; the second argument is never read.
+stdcall:
+	mov     eax, [esp+4]
+	ret     8
+
+; --- [ fastcall ] -------------------------------------------------------------
+
+; Arguments passed in ECX and EDX.
+fastcall:
+	mov     eax, ecx
+	add     eax, edx
+	ret
+
+; --- [ thiscall ] -------------------------------------------------------------
+
+; Object pointer passed in ECX, and arguments on the stack.
+thiscall:
+	mov     eax, ecx
+	add     eax, [esp+4]
+	ret     4
@@ -0,0 +1,103 @@
+define void @cdecl_esp(i32 %arg_4, i32 %arg_8) !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%esp = alloca i32
+	%esp_4 = alloca i32
+	%esp_8 = alloca i32
+	%1 = load i32, i32* %esp
+	store i32 %arg_4, i32* %esp_4
+	%2 = load i32, i32* %esp
+	store i32 %arg_8, i32* %esp_8
+	br label %block_10000000
+block_10000000:
+	%3 = load i32, i32* %esp
+	%4 = load i32, i32* %esp_4
+	store i32 %4, i32* %eax
+	%5 = load i32, i32* %esp
+	%6 = load i32, i32* %esp_8
+	store i32 %6, i32* %ecx
+	ret void
+}
+
+define void @cdecl_ebp(i32 %arg_4) !addr !{!"0x10000009"} {
+; <label>:0
+	%eax = alloca i32
+	%esp = alloca i32
+	%ebp = alloca i32
+	%esp_-4 = alloca i32
+	%esp_4 = alloca i32
+	%1 = load i32, i32* %esp
+	store i32 %arg_4, i32* %esp_4
+	br label %block_10000009
+block_10000009:
+	%2 = load i32, i32* %ebp
+	%3 = load i32, i32* %esp
+	store i32 %2, i32* %esp_-4
+	%4 = load i32, i32* %esp
+	store i32 %4, i32* %ebp
+	%5 = load i32, i32* %ebp
+	%6 = load i32, i32* %esp_4
+	store i32 %6, i32* %eax
+	%7 = load i32, i32* %esp
+	%8 = load i32, i32* %esp_-4
+	store i32 %8, i32* %ebp
+	ret void
+}
+
+define x86_stdcallcc void @stdcall(i32 %arg_4, i32 %arg_8) !addr !{!"0x10000011"} {
+; <label>:0
+	%eax = alloca i32
+	%esp = alloca i32
+	%esp_4 = alloca i32
+	%esp_8 = alloca i32
+	%1 = load i32, i32* %esp
+	store i32 %arg_4, i32* %esp_4
+	%2 = load i32, i32* %esp
+	store i32 %arg_8, i32* %esp_8
+	br label %block_10000011
+block_10000011:
+	%3 = load i32, i32* %esp
+	%4 = load i32, i32* %esp_4
+	store i32 %4, i32* %eax
+	ret void
+}
+
+define x86_fastcallcc void @fastcall(i32 %arg_ecx, i32 %arg_edx) !addr !{!"0x10000018"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	store i32 %arg_ecx, i32* %ecx
+	store i32 %arg_edx, i32* %edx
+	br label %block_10000018
+block_10000018:
+	%1 = load i32, i32* %ecx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = load i32, i32* %edx
+	%4 = add i32 %2, %3
+	store i32 %4, i32* %eax
+	ret void
+}
+
+define x86_thiscallcc void @thiscall(i32 %arg_ecx, i32 %arg_4) !addr !{!"0x1000001D"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%esp = alloca i32
+	%esp_4 = alloca i32
+	store i32 %arg_ecx, i32* %ecx
+	%1 = load i32, i32* %esp
+	store i32 %arg_4, i32* %esp_4
+	br label %block_1000001D
+block_1000001D:
+	%2 = load i32, i32* %ecx
+	store i32 %2, i32* %eax
+	%3 = load i32, i32* %eax
+	%4 = load i32, i32* %esp
+	%5 = load i32, i32* %esp_4
+	%6 = add i32 %3, %5
+	store i32 %6, i32* %eax
+	ret void
+}
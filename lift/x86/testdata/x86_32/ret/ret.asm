@@ -0,0 +1,60 @@
+[BITS 32]
+
+global caller:function
+global get42:function
+global caller64:function
+global get64:function
+global caller_check:function
+global check:function
+global fail:function
+
+section .text
+
+; === [ Return type inference ] ================================================
+
+; --- [ EAX ] ------------------------------------------------------------------
+
+caller:
+	call    get42
+	mov     ecx, eax
+	ret
+
+; Return value passed in EAX.
+get42:
+	mov     eax, 42
+	ret
+
+; --- [ EDX:EAX ] --------------------------------------------------------------
+
+caller64:
+	call    get64
+	mov     ecx, eax
+	mov     ebx, edx
+	ret
+
+; Return value passed in EDX:EAX.
+get64:
+	mov     eax, 1
+	mov     edx, 2
+	ret
+
+; --- [ Noreturn error path ] --------------------------------------------------
+
+caller_check:
+	call    check
+	mov     ecx, eax
+	ret
+
+; Return value passed in EAX, with an error path ending in a call to a noreturn
+; function.
+check:
+	mov     eax, 42
+	test    eax, eax
+	je      .fail
+	ret
+.fail:
+	call    fail
+
+; Noreturn function (noreturn.json).
+fail:
+	ud2
@@ -0,0 +1,98 @@
+define void @caller() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	br label %block_10000000
+block_10000000:
+	%1 = call i32 @get42()
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	store i32 %2, i32* %ecx
+	ret void
+}
+
+define i32 @get42() !addr !{!"0x10000008"} {
+; <label>:0
+	%eax = alloca i32
+	br label %block_10000008
+block_10000008:
+	store i32 42, i32* %eax
+	%1 = load i32, i32* %eax
+	ret i32 %1
+}
+
+define void @caller64() !addr !{!"0x1000000E"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_1000000E
+block_1000000E:
+	%1 = call i64 @get64()
+	%2 = trunc i64 %1 to i32
+	store i32 %2, i32* %eax
+	%3 = lshr i64 %1, 32
+	%4 = trunc i64 %3 to i32
+	store i32 %4, i32* %edx
+	%5 = load i32, i32* %eax
+	store i32 %5, i32* %ecx
+	%6 = load i32, i32* %edx
+	store i32 %6, i32* %ebx
+	ret void
+}
+
+define i64 @get64() !addr !{!"0x10000018"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	br label %block_10000018
+block_10000018:
+	store i32 1, i32* %eax
+	store i32 2, i32* %edx
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %edx
+	%3 = zext i32 %1 to i64
+	%4 = zext i32 %2 to i64
+	%5 = shl i64 %4, 32
+	%6 = or i64 %5, %3
+	ret i64 %6
+}
+
+define void @caller_check() !addr !{!"0x10000023"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	br label %block_10000023
+block_10000023:
+	%1 = call i32 @check()
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	store i32 %2, i32* %ecx
+	ret void
+}
+
+define i32 @check() !addr !{!"0x1000002B"} {
+; <label>:0
+	%eax = alloca i32
+	br label %block_1000002B
+block_1000002B:
+	store i32 42, i32* %eax
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = and i32 %1, %2
+	%4 = icmp eq i32 %3, 0
+	br i1 %4, label %block_10000035, label %block_10000034
+block_10000034:
+	%5 = load i32, i32* %eax
+	ret i32 %5
+block_10000035:
+	call void @fail()
+	unreachable
+}
+
+define void @fail() !addr !{!"0x1000003A"} {
+block_1000003A:
+	call void @llvm.trap(), !addr !{!"0x1000003A"}
+	unreachable
+}
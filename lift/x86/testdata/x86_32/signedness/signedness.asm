@@ -0,0 +1,31 @@
+[BITS 32]
+
+global signs:function
+
+section .text
+
+; === [ Signedness inference ] =================================================
+
+signs:
+	; Signed comparison of count.
+	cmp     dword [count], 10
+	jg      .done
+	; Unsigned comparison of limit, through EAX.
+	mov     eax, [limit]
+	cmp     eax, 10
+	ja      .done
+	; Arithmetic shift of the stack slot at [esp+4], through ECX.
+	mov     ecx, [esp+4]
+	sar     ecx, 1
+	; Signed comparison and logical shift of flags.
+	cmp     dword [flags], 0
+	jl      .done
+	shr     dword [flags], 1
+.done:
+	ret
+
+section .bss
+
+count: resd 1
+limit: resd 1
+flags: resd 1
@@ -0,0 +1,28 @@
+[BITS 32]
+
+global smc:function
+global patched:function
+global overlap:function
+
+section .text
+
+; === [ Self-modifying code ] ==================================================
+
+; Writes a RET instruction over the first instruction of patched.
+smc:
+	mov     byte [patched], 0xC3
+	ret
+
+; Modified by smc.
+patched:
+	nop
+	ret
+
+; --- [ Overlapping instructions ] ---------------------------------------------
+
+; Jump into the second byte of the JMP instruction itself, which decodes as
+; `inc eax`.
+overlap:
+	db      0xEB, 0xFF        ; jmp     overlap+1
+	db      0xC0              ; (inc    eax)
+	ret
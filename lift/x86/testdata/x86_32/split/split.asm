@@ -0,0 +1,21 @@
+[BITS 32]
+
+global split:function
+
+section .text
+
+; === [ Basic block splitting ] ================================================
+
+; The loop header is located in the middle of the basic block starting at the
+; function entry, and is only discovered as a branch target after the basic
+; block has been decoded.
+
+split:
+	mov     eax, 0
+	mov     ecx, 10
+.loop:
+	add     eax, ecx
+	sub     ecx, 1
+	cmp     ecx, 0
+	jne     .loop
+	ret
@@ -0,0 +1,23 @@
+define void @split() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	br label %block_10000000
+block_10000000:
+	store i32 0, i32* %eax
+	store i32 10, i32* %ecx
+	br label %block_1000000A
+block_1000000A:
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %ecx
+	%3 = add i32 %1, %2
+	store i32 %3, i32* %eax
+	%4 = load i32, i32* %ecx
+	%5 = sub i32 %4, 1
+	store i32 %5, i32* %ecx
+	%6 = load i32, i32* %ecx
+	%7 = icmp ne i32 %6, 0
+	br i1 %7, label %block_1000000A, label %block_10000014
+block_10000014:
+	ret void
+}
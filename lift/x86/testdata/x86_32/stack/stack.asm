@@ -0,0 +1,43 @@
+[BITS 32]
+
+global balanced:function
+global imbalance:function
+global purge:function
+global merge:function
+
+section .text
+
+; === [ Stack pointer delta verification ] =====================================
+
+; Balanced stack pointer.
+balanced:
+	push    ebp
+	mov     ebp, esp
+	push    ebx
+	pop     ebx
+	pop     ebp
+	ret
+
+; --- [ Return ] ---------------------------------------------------------------
+
+; Stack pointer delta of -4 at return.
+imbalance:
+	push    ebx
+	ret
+
+; --- [ Purge ] ----------------------------------------------------------------
+
+; Purges 4 bytes on return; stdcall function with two parameters (sigs.json).
+purge:
+	mov     eax, [esp+4]
+	ret     4
+
+; --- [ Merge ] ----------------------------------------------------------------
+
+; Stack pointer delta of 0 and -4 along the paths joining at .skip.
+merge:
+	test    eax, eax
+	je      .skip
+	push    eax
+.skip:
+	ret
@@ -0,0 +1,30 @@
+[BITS 32]
+
+global object:function
+global init_point:function
+
+section .text
+
+; === [ Struct layout recovery ] ===============================================
+
+; --- [ Heap object ] ----------------------------------------------------------
+
+; Accesses the fields at offsets 4 and 8 of the object pointed to by ECX.
+object:
+	mov     eax, [ecx+4]
+	mov     edx, [ecx+8]
+	ret
+
+; --- [ Global variable ] ------------------------------------------------------
+
+; Accesses the fields at offsets 0 and 4 of point, through a base pointer
+; holding its address.
+init_point:
+	mov     ecx, point
+	mov     dword [ecx], 1
+	mov     word [ecx+4], 2
+	ret
+
+section .bss
+
+point: resb 8
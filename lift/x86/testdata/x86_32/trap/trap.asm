@@ -0,0 +1,64 @@
+[BITS 32]
+
+global trap_int3:function
+global trap_ud2:function
+global trap_hlt:function
+global trap_fastfail:function
+global trap_into:function
+global trap_div:function
+global trap_idiv:function
+
+section .text
+
+; === [ Traps ] ================================================================
+
+; --- [ INT3 ] -----------------------------------------------------------------
+
+trap_int3:
+	int3
+
+; --- [ UD2 ] ------------------------------------------------------------------
+
+trap_ud2:
+	ud2
+
+; --- [ HLT ] ------------------------------------------------------------------
+
+trap_hlt:
+	hlt
+
+; --- [ INT 29h ] --------------------------------------------------------------
+
+; __fastfail(7) of MSVC.
+trap_fastfail:
+	mov     ecx, 7
+	int     0x29
+
+; --- [ INTO ] -----------------------------------------------------------------
+
+trap_into:
+	into
+	ret
+
+; === [ Divide error exceptions ] ==============================================
+
+; --- [ DIV ] ------------------------------------------------------------------
+
+; Divide by zero (ECX = 0), and quotient overflow (EDX >= ECX).
+trap_div:
+	mov     ecx, [esp+4]
+	mov     eax, 42
+	mov     edx, 0
+	div     ecx
+	ret
+
+; --- [ IDIV ] -----------------------------------------------------------------
+
+; Divide by zero (ECX = 0), and quotient overflow (e.g. INT_MIN / -1, or
+; EDX:EAX = 2^40 and ECX = 2).
+trap_idiv:
+	mov     ecx, [esp+4]
+	mov     eax, 42
+	mov     edx, 0
+	idiv    ecx
+	ret
@@ -0,0 +1,138 @@
+define void @trap_int3() !addr !{!"0x10000000"} {
+block_10000000:
+	call void @llvm.debugtrap(), !addr !{!"0x10000000"}
+	unreachable
+}
+
+define void @trap_ud2() !addr !{!"0x10000001"} {
+block_10000001:
+	call void @llvm.trap(), !addr !{!"0x10000001"}
+	unreachable
+}
+
+define void @trap_hlt() !addr !{!"0x10000003"} {
+block_10000003:
+	call void @llvm.trap(), !addr !{!"0x10000003"}
+	unreachable
+}
+
+define void @trap_fastfail() !addr !{!"0x10000004"} {
+; <label>:0
+	%ecx = alloca i32
+	br label %block_10000004
+block_10000004:
+	store i32 7, i32* %ecx
+	%1 = load i32, i32* %ecx
+	call void @sys.fastfail(i32 %1), !addr !{!"0x10000009"}
+	unreachable
+}
+
+define void @trap_into() !addr !{!"0x1000000B"} {
+; <label>:0
+	%of = alloca i1
+	br label %block_1000000B
+block_1000000B:
+	%1 = load i1, i1* %of
+	br i1 %1, label %2, label %3
+; <label>:2
+	call void @llvm.trap(), !addr !{!"0x1000000B"}
+	unreachable
+; <label>:3
+	ret void
+}
+
+define void @trap_div(i32 %arg_4) !addr !{!"0x1000000D"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%esp = alloca i32
+	%esp_4 = alloca i32
+	%1 = load i32, i32* %esp
+	store i32 %arg_4, i32* %esp_4
+	br label %block_1000000D
+block_1000000D:
+	%2 = load i32, i32* %esp
+	%3 = load i32, i32* %esp_4
+	store i32 %3, i32* %ecx
+	store i32 42, i32* %eax
+	store i32 0, i32* %edx
+	%4 = load i32, i32* %ecx
+	%5 = icmp eq i32 %4, 0
+	br i1 %5, label %6, label %7
+; <label>:6
+	call void @llvm.trap(), !addr !{!"0x1000001B"}
+	unreachable
+; <label>:7
+	%8 = load i32, i32* %edx
+	%9 = icmp uge i32 %8, %4
+	br i1 %9, label %10, label %11
+; <label>:10
+	call void @llvm.trap(), !addr !{!"0x1000001B"}
+	unreachable
+; <label>:11
+	%12 = load i32, i32* %edx
+	%13 = zext i32 %12 to i64
+	%14 = shl i64 %13, 32
+	%15 = load i32, i32* %eax
+	%16 = zext i32 %15 to i64
+	%17 = or i64 %14, %16
+	%18 = zext i32 %4 to i64
+	%19 = udiv i64 %17, %18
+	%20 = urem i64 %17, %18
+	%21 = trunc i64 %19 to i32
+	store i32 %21, i32* %eax
+	%22 = trunc i64 %20 to i32
+	store i32 %22, i32* %edx
+	ret void
+}
+
+define void @trap_idiv(i32 %arg_4) !addr !{!"0x1000001E"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%esp = alloca i32
+	%esp_4 = alloca i32
+	%1 = load i32, i32* %esp
+	store i32 %arg_4, i32* %esp_4
+	br label %block_1000001E
+block_1000001E:
+	%2 = load i32, i32* %esp
+	%3 = load i32, i32* %esp_4
+	store i32 %3, i32* %ecx
+	store i32 42, i32* %eax
+	store i32 0, i32* %edx
+	%4 = load i32, i32* %ecx
+	%5 = icmp eq i32 %4, 0
+	br i1 %5, label %6, label %7
+; <label>:6
+	call void @llvm.trap(), !addr !{!"0x1000002C"}
+	unreachable
+; <label>:7
+	%8 = load i32, i32* %edx
+	%9 = zext i32 %8 to i64
+	%10 = shl i64 %9, 32
+	%11 = load i32, i32* %eax
+	%12 = zext i32 %11 to i64
+	%13 = or i64 %10, %12
+	%14 = sext i32 %4 to i64
+	%15 = sext i64 %13 to i128
+	%16 = sext i64 %14 to i128
+	%17 = sdiv i128 %15, %16
+	%18 = icmp slt i128 %17, -2147483648
+	%19 = icmp sgt i128 %17, 2147483647
+	%20 = or i1 %18, %19
+	br i1 %20, label %21, label %22
+; <label>:21
+	call void @llvm.trap(), !addr !{!"0x1000002C"}
+	unreachable
+; <label>:22
+	%23 = sdiv i64 %13, %14
+	%24 = srem i64 %13, %14
+	%25 = trunc i64 %23 to i32
+	store i32 %25, i32* %eax
+	%26 = trunc i64 %24 to i32
+	store i32 %26, i32* %edx
+	ret void
+}
@@ -0,0 +1 @@
+@table = global [4 x i32] zeroinitializer, !addr !{!"0x30000000"}
@@ -0,0 +1,35 @@
+[BITS 32]
+
+global vsa_stack:function
+global vsa_global:function
+
+section .text
+
+; === [ Value-set analysis ] ===================================================
+
+; --- [ Stack slots ] ----------------------------------------------------------
+
+; Indexed access through a copy of the stack pointer, bounded to a single stack
+; slot.
+vsa_stack:
+	xor     eax, eax
+	mov     ecx, esp
+	mov     dword [ecx+eax*4-8], 42
+	mov     eax, [esp-8]
+	ret
+
+; --- [ Global variables ] -----------------------------------------------------
+
+; Indexed access through a pointer to a global array, bounded to the extent of
+; the array.
+vsa_global:
+	mov     eax, [esp+4]
+	and     eax, 3
+	mov     ecx, table
+	mov     eax, [ecx+eax*4]
+	ret
+
+section .bss
+
+table:
+	resd    4
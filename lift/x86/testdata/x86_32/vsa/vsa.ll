@@ -0,0 +1,50 @@
+define void @vsa_stack() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%esp = alloca i32
+	%esp_-8 = alloca i32
+	br label %block_10000000
+block_10000000:
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = xor i32 %1, %2
+	store i32 %3, i32* %eax
+	%4 = load i32, i32* %esp
+	store i32 %4, i32* %ecx
+	store i32 42, i32* %esp_-8
+	%5 = load i32, i32* %esp
+	%6 = load i32, i32* %esp_-8
+	store i32 %6, i32* %eax
+	ret void
+}
+
+define void @vsa_global(i32 %arg_4) !addr !{!"0x10000011"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%esp = alloca i32
+	%esp_4 = alloca i32
+	%1 = load i32, i32* %esp
+	store i32 %arg_4, i32* %esp_4
+	br label %block_10000011
+block_10000011:
+	%2 = load i32, i32* %esp
+	%3 = load i32, i32* %esp_4
+	store i32 %3, i32* %eax
+	%4 = load i32, i32* %eax
+	%5 = and i32 %4, 3
+	store i32 %5, i32* %eax
+	store i32 805306368, i32* %ecx
+	%6 = load i32, i32* %ecx
+	%7 = add i32 %6, -805306368
+	%8 = load i32, i32* %eax
+	%9 = mul i32 %8, 4
+	%10 = add i32 %9, %7
+	%11 = bitcast [4 x i32]* @table to i8*
+	%12 = getelementptr i8, i8* %11, i32 %10
+	%13 = bitcast i8* %12 to i32*
+	%14 = load i32, i32* %13
+	store i32 %14, i32* %eax
+	ret void
+}
@@ -1,197 +1,295 @@
 define void @div_r8() !addr !{!"0x10000000"} {
 ; <label>:0
-	%al = alloca i8
-	%bl = alloca i8
-	%ah = alloca i8
-	%ax = alloca i16
 	%rax = alloca i64
+	%rbx = alloca i64
 	br label %block_10000000
 block_10000000:
-	store i32 84, i16* %ax
-	store i32 2, i8* %bl
-	%1 = load i8, i8* %bl
-	%2 = load i16, i16* %ax
-	%3 = zext i8 %1 to i16
-	%4 = udiv i16 %2, %3
-	%5 = urem i16 %2, %3
-	store i16 %4, i8* %al
-	store i16 %5, i8* %ah
-	%6 = load i64, i64* %rax
-	%7 = and i64 %6, 255
-	store i64 %7, i64* %rax
+	%1 = load i64, i64* %rax
+	%2 = and i64 %1, -65536
+	%3 = or i64 %2, 84
+	store i64 %3, i64* %rax
+	%4 = load i64, i64* %rbx
+	%5 = and i64 %4, -256
+	%6 = or i64 %5, 2
+	store i64 %6, i64* %rbx
+	%7 = load i64, i64* %rbx
+	%8 = trunc i64 %7 to i8
+	%9 = load i64, i64* %rax
+	%10 = trunc i64 %9 to i16
+	%11 = zext i8 %8 to i16
+	%12 = udiv i16 %10, %11
+	%13 = urem i16 %10, %11
+	%14 = trunc i16 %12 to i8
+	%15 = zext i8 %14 to i64
+	%16 = load i64, i64* %rax
+	%17 = and i64 %16, -256
+	%18 = or i64 %17, %15
+	store i64 %18, i64* %rax
+	%19 = trunc i16 %13 to i8
+	%20 = zext i8 %19 to i64
+	%21 = shl i64 %20, 8
+	%22 = load i64, i64* %rax
+	%23 = and i64 %22, -65281
+	%24 = or i64 %23, %21
+	store i64 %24, i64* %rax
+	%25 = load i64, i64* %rax
+	%26 = and i64 %25, 255
+	store i64 %26, i64* %rax
 	ret void
 }
 
 define void @div_m8() !addr !{!"0x1000000F"} {
 ; <label>:0
-	%al = alloca i8
-	%ah = alloca i8
-	%ax = alloca i16
 	%rax = alloca i64
 	br label %block_1000000F
 block_1000000F:
-	store i32 84, i16* %ax
+	%1 = load i64, i64* %rax
+	%2 = and i64 %1, -65536
+	%3 = or i64 %2, 84
+	store i64 %3, i64* %rax
 	store i32 2, i8* @m8
-	%1 = load i8, i8* @m8
-	%2 = load i16, i16* %ax
-	%3 = zext i8 %1 to i16
-	%4 = udiv i16 %2, %3
-	%5 = urem i16 %2, %3
-	store i16 %4, i8* %al
-	store i16 %5, i8* %ah
-	%6 = load i64, i64* %rax
-	%7 = and i64 %6, 255
-	store i64 %7, i64* %rax
+	%4 = load i8, i8* @m8
+	%5 = load i64, i64* %rax
+	%6 = trunc i64 %5 to i16
+	%7 = zext i8 %4 to i16
+	%8 = udiv i16 %6, %7
+	%9 = urem i16 %6, %7
+	%10 = trunc i16 %8 to i8
+	%11 = zext i8 %10 to i64
+	%12 = load i64, i64* %rax
+	%13 = and i64 %12, -256
+	%14 = or i64 %13, %11
+	store i64 %14, i64* %rax
+	%15 = trunc i16 %9 to i8
+	%16 = zext i8 %15 to i64
+	%17 = shl i64 %16, 8
+	%18 = load i64, i64* %rax
+	%19 = and i64 %18, -65281
+	%20 = or i64 %19, %17
+	store i64 %20, i64* %rax
+	%21 = load i64, i64* %rax
+	%22 = and i64 %21, 255
+	store i64 %22, i64* %rax
 	ret void
 }
 
 define void @div_r16() !addr !{!"0x10000027"} {
 ; <label>:0
-	%ax = alloca i16
-	%dx = alloca i16
-	%bx = alloca i16
 	%rax = alloca i64
-	%"dx\3Aax" = alloca i32
+	%rdx = alloca i64
+	%rbx = alloca i64
 	br label %block_10000027
 block_10000027:
-	store i32 0, i16* %dx
-	store i32 84, i16* %ax
-	store i32 2, i16* %bx
-	%1 = load i16, i16* %bx
-	%2 = load i32, i32* %"dx\3Aax"
-	%3 = zext i16 %1 to i32
-	%4 = udiv i32 %2, %3
-	%5 = urem i32 %2, %3
-	store i32 %4, i16* %ax
-	store i32 %5, i16* %dx
-	%6 = load i64, i64* %rax
-	%7 = and i64 %6, 65535
-	store i64 %7, i64* %rax
+	%1 = load i64, i64* %rdx
+	%2 = and i64 %1, -65536
+	%3 = or i64 %2, 0
+	store i64 %3, i64* %rdx
+	%4 = load i64, i64* %rax
+	%5 = and i64 %4, -65536
+	%6 = or i64 %5, 84
+	store i64 %6, i64* %rax
+	%7 = load i64, i64* %rbx
+	%8 = and i64 %7, -65536
+	%9 = or i64 %8, 2
+	store i64 %9, i64* %rbx
+	%10 = load i64, i64* %rbx
+	%11 = trunc i64 %10 to i16
+	%12 = load i64, i64* %rdx
+	%13 = trunc i64 %12 to i16
+	%14 = zext i16 %13 to i32
+	%15 = shl i32 %14, 16
+	%16 = load i64, i64* %rax
+	%17 = trunc i64 %16 to i16
+	%18 = zext i16 %17 to i32
+	%19 = or i32 %15, %18
+	%20 = zext i16 %11 to i32
+	%21 = udiv i32 %19, %20
+	%22 = urem i32 %19, %20
+	%23 = trunc i32 %21 to i16
+	%24 = zext i16 %23 to i64
+	%25 = load i64, i64* %rax
+	%26 = and i64 %25, -65536
+	%27 = or i64 %26, %24
+	store i64 %27, i64* %rax
+	%28 = trunc i32 %22 to i16
+	%29 = zext i16 %28 to i64
+	%30 = load i64, i64* %rdx
+	%31 = and i64 %30, -65536
+	%32 = or i64 %31, %29
+	store i64 %32, i64* %rdx
+	%33 = load i64, i64* %rax
+	%34 = and i64 %33, 65535
+	store i64 %34, i64* %rax
 	ret void
 }
 
 define void @div_m16() !addr !{!"0x1000003D"} {
 ; <label>:0
-	%ax = alloca i16
-	%dx = alloca i16
 	%rax = alloca i64
-	%"dx\3Aax" = alloca i32
+	%rdx = alloca i64
 	br label %block_1000003D
 block_1000003D:
-	store i32 0, i16* %dx
-	store i32 84, i16* %ax
+	%1 = load i64, i64* %rdx
+	%2 = and i64 %1, -65536
+	%3 = or i64 %2, 0
+	store i64 %3, i64* %rdx
+	%4 = load i64, i64* %rax
+	%5 = and i64 %4, -65536
+	%6 = or i64 %5, 84
+	store i64 %6, i64* %rax
 	store i32 2, i16* @m16
-	%1 = load i16, i16* @m16
-	%2 = load i32, i32* %"dx\3Aax"
-	%3 = zext i16 %1 to i32
-	%4 = udiv i32 %2, %3
-	%5 = urem i32 %2, %3
-	store i32 %4, i16* %ax
-	store i32 %5, i16* %dx
-	%6 = load i64, i64* %rax
-	%7 = and i64 %6, 65535
-	store i64 %7, i64* %rax
+	%7 = load i16, i16* @m16
+	%8 = load i64, i64* %rdx
+	%9 = trunc i64 %8 to i16
+	%10 = zext i16 %9 to i32
+	%11 = shl i32 %10, 16
+	%12 = load i64, i64* %rax
+	%13 = trunc i64 %12 to i16
+	%14 = zext i16 %13 to i32
+	%15 = or i32 %11, %14
+	%16 = zext i16 %7 to i32
+	%17 = udiv i32 %15, %16
+	%18 = urem i32 %15, %16
+	%19 = trunc i32 %17 to i16
+	%20 = zext i16 %19 to i64
+	%21 = load i64, i64* %rax
+	%22 = and i64 %21, -65536
+	%23 = or i64 %22, %20
+	store i64 %23, i64* %rax
+	%24 = trunc i32 %18 to i16
+	%25 = zext i16 %24 to i64
+	%26 = load i64, i64* %rdx
+	%27 = and i64 %26, -65536
+	%28 = or i64 %27, %25
+	store i64 %28, i64* %rdx
+	%29 = load i64, i64* %rax
+	%30 = and i64 %29, 65535
+	store i64 %30, i64* %rax
 	ret void
 }
 
 define void @div_r32() !addr !{!"0x1000005C"} {
 ; <label>:0
-	%eax = alloca i32
-	%edx = alloca i32
-	%ebx = alloca i32
 	%rax = alloca i64
+	%rdx = alloca i64
 	%rbx = alloca i64
-	%"edx\3Aeax" = alloca i64
 	br label %block_1000005C
 block_1000005C:
-	store i32 0, i32* %edx
-	store i32 84, i32* %eax
-	store i32 2, i32* %ebx
-	%1 = load i32, i32* %ebx
-	%2 = load i64, i64* %"edx\3Aeax"
-	%3 = zext i32 %1 to i64
-	%4 = udiv i64 %2, %3
-	%5 = urem i64 %2, %3
-	store i64 %4, i32* %eax
-	store i64 %5, i32* %edx
-	store i32 -1, i32* %ebx
-	%6 = load i64, i64* %rax
-	%7 = load i64, i64* %rbx
-	%8 = and i64 %6, %7
-	store i64 %8, i64* %rax
+	store i64 0, i64* %rdx
+	store i64 84, i64* %rax
+	store i64 2, i64* %rbx
+	%1 = load i64, i64* %rbx
+	%2 = trunc i64 %1 to i32
+	%3 = load i64, i64* %rdx
+	%4 = trunc i64 %3 to i32
+	%5 = zext i32 %4 to i64
+	%6 = shl i64 %5, 32
+	%7 = load i64, i64* %rax
+	%8 = trunc i64 %7 to i32
+	%9 = zext i32 %8 to i64
+	%10 = or i64 %6, %9
+	%11 = zext i32 %2 to i64
+	%12 = udiv i64 %10, %11
+	%13 = urem i64 %10, %11
+	%14 = trunc i64 %12 to i32
+	%15 = zext i32 %14 to i64
+	store i64 %15, i64* %rax
+	%16 = trunc i64 %13 to i32
+	%17 = zext i32 %16 to i64
+	store i64 %17, i64* %rdx
+	store i64 4294967295, i64* %rbx
+	%18 = load i64, i64* %rax
+	%19 = load i64, i64* %rbx
+	%20 = and i64 %18, %19
+	store i64 %20, i64* %rax
 	ret void
 }
 
 define void @div_m32() !addr !{!"0x10000076"} {
 ; <label>:0
-	%eax = alloca i32
-	%edx = alloca i32
-	%ebx = alloca i32
 	%rax = alloca i64
+	%rdx = alloca i64
 	%rbx = alloca i64
-	%"edx\3Aeax" = alloca i64
 	br label %block_10000076
 block_10000076:
-	store i32 0, i32* %edx
-	store i32 84, i32* %eax
+	store i64 0, i64* %rdx
+	store i64 84, i64* %rax
 	store i32 2, i32* @m32
 	%1 = load i32, i32* @m32
-	%2 = load i64, i64* %"edx\3Aeax"
-	%3 = zext i32 %1 to i64
-	%4 = udiv i64 %2, %3
-	%5 = urem i64 %2, %3
-	store i64 %4, i32* %eax
-	store i64 %5, i32* %edx
-	store i32 -1, i32* %ebx
+	%2 = load i64, i64* %rdx
+	%3 = trunc i64 %2 to i32
+	%4 = zext i32 %3 to i64
+	%5 = shl i64 %4, 32
 	%6 = load i64, i64* %rax
-	%7 = load i64, i64* %rbx
-	%8 = and i64 %6, %7
-	store i64 %8, i64* %rax
+	%7 = trunc i64 %6 to i32
+	%8 = zext i32 %7 to i64
+	%9 = or i64 %5, %8
+	%10 = zext i32 %1 to i64
+	%11 = udiv i64 %9, %10
+	%12 = urem i64 %9, %10
+	%13 = trunc i64 %11 to i32
+	%14 = zext i32 %13 to i64
+	store i64 %14, i64* %rax
+	%15 = trunc i64 %12 to i32
+	%16 = zext i32 %15 to i64
+	store i64 %16, i64* %rdx
+	store i64 4294967295, i64* %rbx
+	%17 = load i64, i64* %rax
+	%18 = load i64, i64* %rbx
+	%19 = and i64 %17, %18
+	store i64 %19, i64* %rax
 	ret void
 }
 
 define void @div_r64() !addr !{!"0x10000099"} {
 ; <label>:0
-	%eax = alloca i32
-	%edx = alloca i32
-	%ebx = alloca i32
 	%rax = alloca i64
 	%rdx = alloca i64
 	%rbx = alloca i64
-	%"rdx\3Arax" = alloca i128
 	br label %block_10000099
 block_10000099:
-	store i32 0, i32* %edx
-	store i32 84, i32* %eax
-	store i32 2, i32* %ebx
+	store i64 0, i64* %rdx
+	store i64 84, i64* %rax
+	store i64 2, i64* %rbx
 	%1 = load i64, i64* %rbx
-	%2 = load i128, i128* %"rdx\3Arax"
-	%3 = zext i64 %1 to i128
-	%4 = udiv i128 %2, %3
-	%5 = urem i128 %2, %3
-	store i128 %4, i64* %rax
-	store i128 %5, i64* %rdx
+	%2 = load i64, i64* %rdx
+	%3 = zext i64 %2 to i128
+	%4 = shl i128 %3, 64
+	%5 = load i64, i64* %rax
+	%6 = zext i64 %5 to i128
+	%7 = or i128 %4, %6
+	%8 = zext i64 %1 to i128
+	%9 = udiv i128 %7, %8
+	%10 = urem i128 %7, %8
+	%11 = trunc i128 %9 to i64
+	store i64 %11, i64* %rax
+	%12 = trunc i128 %10 to i64
+	store i64 %12, i64* %rdx
 	ret void
 }
 
 define void @div_m64() !addr !{!"0x100000AC"} {
 ; <label>:0
-	%eax = alloca i32
-	%edx = alloca i32
 	%rax = alloca i64
 	%rdx = alloca i64
-	%"rdx\3Arax" = alloca i128
 	br label %block_100000AC
 block_100000AC:
-	store i32 0, i32* %edx
-	store i32 84, i32* %eax
+	store i64 0, i64* %rdx
+	store i64 84, i64* %rax
 	store i32 2, i64* @m64
 	%1 = load i64, i64* @m64
-	%2 = load i128, i128* %"rdx\3Arax"
-	%3 = zext i64 %1 to i128
-	%4 = udiv i128 %2, %3
-	%5 = urem i128 %2, %3
-	store i128 %4, i64* %rax
-	store i128 %5, i64* %rdx
+	%2 = load i64, i64* %rdx
+	%3 = zext i64 %2 to i128
+	%4 = shl i128 %3, 64
+	%5 = load i64, i64* %rax
+	%6 = zext i64 %5 to i128
+	%7 = or i128 %4, %6
+	%8 = zext i64 %1 to i128
+	%9 = udiv i128 %7, %8
+	%10 = urem i128 %7, %8
+	%11 = trunc i128 %9 to i64
+	store i64 %11, i64* %rax
+	%12 = trunc i128 %10 to i64
+	store i64 %12, i64* %rdx
 	ret void
 }
@@ -1,9 +1,11 @@
 define void @_start() !addr !{!"0x400000"} {
 ; <label>:0
-	%edi = alloca i32
+	%rdi = alloca i64
 	br label %block_400000
 block_400000:
-	store i32 42, i32* %edi
-	call void @exit()
-	ret void
+	store i64 42, i64* %rdi
+	%1 = load i64, i64* %rdi
+	%2 = trunc i64 %1 to i32
+	call void @exit(i32 %2)
+	unreachable
 }
@@ -0,0 +1,21 @@
+[BITS 64]
+
+global sysv:function
+global sysv_stack:function
+
+section .text
+
+; === [ Parameter inference ] ==================================================
+
+; --- [ System V AMD64 ] -------------------------------------------------------
+
+; Arguments passed in RDI and RSI.
+sysv:
+	mov     rax, rdi
+	add     rax, rsi
+	ret
+
+; Arguments following the register arguments are passed on the stack.
+sysv_stack:
+	mov     rax, [rsp+8]
+	ret
@@ -0,0 +1,44 @@
+define x86_64_sysvcc void @sysv(i64 %arg_rdi, i64 %arg_rsi) !addr !{!"0x10000000"} {
+; <label>:0
+	%rax = alloca i64
+	%rsi = alloca i64
+	%rdi = alloca i64
+	store i64 %arg_rdi, i64* %rdi
+	store i64 %arg_rsi, i64* %rsi
+	br label %block_10000000
+block_10000000:
+	%1 = load i64, i64* %rdi
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = load i64, i64* %rsi
+	%4 = add i64 %2, %3
+	store i64 %4, i64* %rax
+	ret void
+}
+
+define x86_64_sysvcc void @sysv_stack(i64 %arg_rdi, i64 %arg_rsi, i64 %arg_rdx, i64 %arg_rcx, i64 %arg_r8, i64 %arg_r9, i64 %arg_8) !addr !{!"0x10000007"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rsp = alloca i64
+	%rsi = alloca i64
+	%rdi = alloca i64
+	%r8 = alloca i64
+	%r9 = alloca i64
+	%rsp_8 = alloca i64
+	store i64 %arg_rdi, i64* %rdi
+	store i64 %arg_rsi, i64* %rsi
+	store i64 %arg_rdx, i64* %rdx
+	store i64 %arg_rcx, i64* %rcx
+	store i64 %arg_r8, i64* %r8
+	store i64 %arg_r9, i64* %r9
+	%1 = load i64, i64* %rsp
+	store i64 %arg_8, i64* %rsp_8
+	br label %block_10000007
+block_10000007:
+	%2 = load i64, i64* %rsp
+	%3 = load i64, i64* %rsp_8
+	store i64 %3, i64* %rax
+	ret void
+}
@@ -0,0 +1,185 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// maxThunkChain specifies the maximum length of chains of thunks (e.g. an
+// incremental linking thunk jumping to an import thunk).
+const maxThunkChain = 8
+
+// findThunks locates the thunk functions of the binary executable; i.e.
+// functions consisting of a single JMP instruction, such as incremental linking
+// thunks (`jmp f`) and import thunks (`jmp [__imp_f]`). The thunks are mapped to
+// their ultimate targets; either the entry address of a function or the address
+// of an import.
+func (l *Lifter) findThunks() map[bin.Address]bin.Address {
+	thunks := make(map[bin.Address]bin.Address)
+	for _, funcAddr := range l.FuncAddrs {
+		if target, ok := l.thunkChain(funcAddr); ok {
+			thunks[funcAddr] = target
+		}
+	}
+	return thunks
+}
+
+// thunkChain follows the chain of thunks starting at the given address, and
+// returns the ultimate target. The boolean return value reports whether the
+// given address is the address of a thunk. Cycles of thunks (e.g. A -> B -> A)
+// and chains exceeding maxThunkChain never reach a function, and are thus not
+// considered thunks.
+func (l *Lifter) thunkChain(addr bin.Address) (bin.Address, bool) {
+	target, ok := l.thunkTarget(addr)
+	if !ok {
+		return 0, false
+	}
+	visited := map[bin.Address]bool{addr: true}
+	for i := 1; ; i++ {
+		if visited[target] {
+			warn.Printf("cycle of thunks at %v", addr)
+			return 0, false
+		}
+		next, ok := l.thunkTarget(target)
+		if !ok {
+			return target, true
+		}
+		if i >= maxThunkChain {
+			warn.Printf("chain of thunks at %v exceeds maximum length %d", addr, maxThunkChain)
+			return 0, false
+		}
+		visited[target] = true
+		target = next
+	}
+}
+
+// thunkTarget returns the target of the thunk at the given address. The boolean
+// return value reports whether the given address is the address of a thunk.
+func (l *Lifter) thunkTarget(addr bin.Address) (bin.Address, bool) {
+	if _, ok := l.File.Imports[addr]; ok || !l.File.IsCode(addr) {
+		return 0, false
+	}
+	inst, err := l.DecodeInst(addr)
+	if err != nil || inst.Op != x86asm.JMP {
+		return 0, false
+	}
+	switch arg := inst.Args[0].(type) {
+	case x86asm.Rel:
+		// Incremental linking thunk.
+		next := addr + bin.Address(inst.Len)
+		target := next + bin.Address(arg)
+		if target == addr || !l.isFuncEntry(target) {
+			return 0, false
+		}
+		return target, true
+	case x86asm.Mem:
+		// Import thunk.
+		var slot bin.Address
+		switch {
+		case arg.Base == 0 && arg.Index == 0:
+			slot = bin.Address(arg.Disp)
+		case arg.Base == x86asm.RIP && arg.Index == 0:
+			slot = addr + bin.Address(inst.Len) + bin.Address(arg.Disp)
+		default:
+			return 0, false
+		}
+		if _, ok := l.File.Imports[slot]; !ok {
+			return 0, false
+		}
+		return slot, true
+	}
+	return 0, false
+}
+
+// isFuncEntry reports whether the given address is the entry address of a
+// function.
+func (l *Lifter) isFuncEntry(addr bin.Address) bool {
+	if _, ok := l.Funcs[addr]; ok {
+		return true
+	}
+	less := func(i int) bool {
+		return addr <= l.FuncAddrs[i]
+	}
+	index := sort.Search(len(l.FuncAddrs), less)
+	return index < len(l.FuncAddrs) && l.FuncAddrs[index] == addr
+}
+
+// ResolveThunk returns the ultimate target of the thunk at the given address,
+// or the address itself if not the address of a thunk.
+func (l *Lifter) ResolveThunk(addr bin.Address) bin.Address {
+	if target, ok := l.Thunks[addr]; ok {
+		return target
+	}
+	return addr
+}
+
+// funcAt returns the function at the given address, as resolved through thunks.
+// The boolean return value indicates success.
+func (l *Lifter) funcAt(addr bin.Address) (*Func, bool) {
+	f, ok := l.Funcs[l.ResolveThunk(addr)]
+	return f, ok
+}
+
+// Forwarders returns the forwarding functions of the named thunks, sorted by
+// thunk address; i.e. functions named after the thunk, which call the target of
+// the thunk with their arguments and return its result. References to thunks
+// resolve to their targets; forwarders retain the names of thunks (e.g.
+// exports) in the lifted module.
+//
+// Forwarders should be called once the signatures of the targets have been
+// resolved (i.e. after lifting).
+func (l *Lifter) Forwarders() []*ir.Function {
+	var thunks bin.Addresses
+	for thunk := range l.NamedThunks {
+		thunks = append(thunks, thunk)
+	}
+	sort.Sort(thunks)
+	var fns []*ir.Function
+	for _, thunk := range thunks {
+		target, ok := l.Funcs[l.Thunks[thunk]]
+		if !ok {
+			continue
+		}
+		fns = append(fns, forwarder(l.NamedThunks[thunk], thunk, target.Function))
+	}
+	return fns
+}
+
+// forwarder returns a function of the given name and address, forwarding its
+// arguments to the target function and returning its result.
+func forwarder(name string, addr bin.Address, target *ir.Function) *ir.Function {
+	var params []*types.Param
+	var args []value.Value
+	for _, p := range target.Sig.Params {
+		param := types.NewParam(p.Name, p.Typ)
+		params = append(params, param)
+		args = append(args, param)
+	}
+	sig := types.NewFunc(target.Sig.Ret, params...)
+	f := &ir.Function{
+		Name:     name,
+		Typ:      types.NewPointer(sig),
+		Sig:      sig,
+		CallConv: target.CallConv,
+		Metadata: map[string]*metadata.Metadata{
+			"addr": {
+				Nodes: []metadata.Node{&metadata.String{Val: addr.String()}},
+			},
+		},
+	}
+	block := &ir.BasicBlock{}
+	f.AppendBlock(block)
+	result := block.NewCall(target, args...)
+	if types.Equal(sig.Ret, types.Void) {
+		block.NewRet(nil)
+	} else {
+		block.NewRet(result)
+	}
+	return f
+}
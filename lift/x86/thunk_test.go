@@ -0,0 +1,79 @@
+package x86
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestThunks(t *testing.T) {
+	// Code of the .text section, located at 0x1000.
+	//
+	//    f:
+	//       xor     eax, eax
+	//       ret
+	//
+	//    exp_f:                  ; named thunk of f
+	//       jmp     f
+	//
+	//    a:                      ; cycle of thunks
+	//       jmp     b
+	//    b:
+	//       jmp     a
+	code := []byte{
+		0x31, 0xC0,
+		0xC3,
+		0xE9, 0xF8, 0xFF, 0xFF, 0xFF,
+		0xE9, 0x00, 0x00, 0x00, 0x00,
+		0xE9, 0xF6, 0xFF, 0xFF, 0xFF,
+	}
+	const (
+		f    = bin.Address(0x1000)
+		expF = bin.Address(0x1003)
+		a    = bin.Address(0x1008)
+		b    = bin.Address(0x100D)
+	)
+	file := &bin.File{
+		Arch:  bin.ArchX86_32,
+		Entry: f,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x1000, Data: code, MemSize: len(code), Perm: bin.PermR | bin.PermX},
+		},
+		Exports: map[bin.Address]string{f: "f", expF: "exp_f", a: "a", b: "b"},
+	}
+	l, err := NewLifter(file)
+	if err != nil {
+		t.Fatalf("unable to prepare lifter; %+v", err)
+	}
+	// The thunks of the cycle never reach a function, and are thus not thunks.
+	thunks := map[bin.Address]bin.Address{expF: f}
+	if !reflect.DeepEqual(l.Thunks, thunks) {
+		t.Errorf("thunks mismatch; expected %v, got %v", thunks, l.Thunks)
+	}
+	named := map[bin.Address]string{expF: "exp_f"}
+	if !reflect.DeepEqual(l.NamedThunks, named) {
+		t.Errorf("named thunks mismatch; expected %v, got %v", named, l.NamedThunks)
+	}
+	for _, addr := range []bin.Address{a, b} {
+		if _, ok := l.Funcs[addr]; !ok {
+			t.Errorf("unable to locate function of thunk cycle at %v", addr)
+		}
+	}
+
+	// Named thunks are emitted as forwarding functions of their targets.
+	fns := l.Forwarders()
+	if len(fns) != 1 {
+		t.Fatalf("forwarders mismatch; expected 1 forwarder, got %d", len(fns))
+	}
+	fn := fns[0]
+	if fn.Name != "exp_f" {
+		t.Errorf("forwarder name mismatch; expected %q, got %q", "exp_f", fn.Name)
+	}
+	if len(fn.Blocks) != 1 || len(fn.Blocks[0].Insts) != 1 {
+		t.Fatalf("forwarder body mismatch; expected a single call instruction")
+	}
+	if got, want := fn.Sig.String(), l.Funcs[f].Sig.String(); got != want {
+		t.Errorf("forwarder signature mismatch; expected %q, got %q", want, got)
+	}
+}
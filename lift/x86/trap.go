@@ -0,0 +1,132 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Traps ] ###############################################################
+
+// Names of the LLVM intrinsics of traps.
+const (
+	// intrinsicTrap raises an exception which terminates the program (e.g.
+	// UD2).
+	intrinsicTrap = "llvm.trap"
+	// intrinsicDebugTrap raises a breakpoint exception (e.g. INT3).
+	intrinsicDebugTrap = "llvm.debugtrap"
+)
+
+// liftTermTrap lifts the given x86 trap terminator (e.g. INT3, UD2 or INT 29h)
+// to LLVM IR, emitting code to f. Breakpoints are lifted as calls to
+// @llvm.debugtrap, __fastfail of MSVC (INT 29h) as calls to the @sys.fastfail
+// helper of the runtime shim with the failure code of ECX, and remaining traps
+// as calls to @llvm.trap. Trap terminators do not resume execution of the
+// program, and are thus followed by an unreachable terminator.
+func (f *Func) liftTermTrap(term *x86.Inst) error {
+	switch {
+	case term.Op == x86asm.INT && term.Args[0] == x86asm.Imm(x86.IntBreakpoint):
+		f.trap(term, f.l.runtimeFunc(intrinsicDebugTrap, types.Void))
+	case term.Op == x86asm.INT && term.Args[0] == x86asm.Imm(x86.IntFastFail):
+		callee := f.l.runtimeFunc(RuntimeFastFail, types.Void, types.NewParam("code", types.I32))
+		f.trap(term, callee, f.useReg(x86.ECX))
+	default:
+		f.trap(term, f.l.runtimeFunc(intrinsicTrap, types.Void))
+	}
+	f.cur.NewUnreachable()
+	return nil
+}
+
+// trap emits a call to the given trap function (e.g. @llvm.trap) for the given
+// instruction, emitting code to f.
+func (f *Func) trap(inst *x86.Inst, callee *ir.Function, args ...value.Value) {
+	call := f.cur.NewCall(callee, args...)
+	call.Metadata = map[string]*metadata.Metadata{
+		"addr": {
+			Nodes: []metadata.Node{&metadata.String{Val: inst.Addr.String()}},
+		},
+	}
+}
+
+// trapIf emits a conditional call to @llvm.trap for the given instruction,
+// taken if cond is true, emitting code to f; e.g. the overflow exception of
+// INTO.
+func (f *Func) trapIf(inst *x86.Inst, cond value.Value) {
+	targetTrue := &ir.BasicBlock{}
+	exit := &ir.BasicBlock{}
+	f.AppendBlock(targetTrue)
+	f.AppendBlock(exit)
+	f.cur.NewCondBr(cond, targetTrue, exit)
+	f.cur = targetTrue
+	f.trap(inst, f.l.runtimeFunc(intrinsicTrap, types.Void))
+	f.cur.NewUnreachable()
+	f.cur = exit
+}
+
+// trapDivZero emits a call to @llvm.trap for the given DIV or IDIV instruction,
+// taken if the divisor y is zero, emitting code to f. This models the divide
+// error exception (#DE) of x86; division by zero is undefined behaviour in LLVM
+// IR, and may thus be optimized away. The check is only emitted if l.TrapDiv is
+// set.
+func (f *Func) trapDivZero(inst *x86.Inst, y value.Value) {
+	if !f.l.TrapDiv {
+		return
+	}
+	zero := constant.NewInt(0, y.Type())
+	cond := f.cur.NewICmp(ir.IntEQ, y, zero)
+	f.trapIf(inst, cond)
+}
+
+// trapDivOverflow emits a call to @llvm.trap for the given DIV instruction,
+// taken if the quotient of the dividend and the non-zero divisor y overflows
+// the quotient register of the given type, emitting code to f; i.e. if the high
+// half of the dividend (e.g. EDX of EDX:EAX) is greater than or equal to the
+// divisor. The check is only emitted if l.TrapDiv is set.
+func (f *Func) trapDivOverflow(inst *x86.Inst, y value.Value, typ *types.IntType) {
+	if !f.l.TrapDiv {
+		return
+	}
+	var hi *x86.Reg
+	switch typ.Size {
+	case 8:
+		hi = x86.AH
+	case 16:
+		hi = x86.DX
+	case 32:
+		hi = x86.EDX
+	case 64:
+		hi = x86.RDX
+	default:
+		panic(fmt.Errorf("support for argument bit size %d not yet implemented", typ.Size))
+	}
+	cond := f.cur.NewICmp(ir.IntUGE, f.useReg(hi), y)
+	f.trapIf(inst, cond)
+}
+
+// trapIDivOverflow emits a call to @llvm.trap for the given IDIV instruction,
+// taken if the quotient of the dividend x and the non-zero divisor y (both
+// sign-extended to twice the size of the quotient type) is outside of the
+// signed range of the quotient register of the given type, emitting code to f;
+// e.g. INT_MIN / -1. The quotient is computed at four times the size of the
+// quotient type, as the signed division of x and y may itself overflow, which
+// is undefined behaviour in LLVM IR. The check is only emitted if l.TrapDiv is
+// set.
+func (f *Func) trapIDivOverflow(inst *x86.Inst, x, y value.Value, typ *types.IntType) {
+	if !f.l.TrapDiv {
+		return
+	}
+	wide := types.NewInt(4 * typ.Size)
+	quo := f.cur.NewSDiv(f.cur.NewSExt(x, wide), f.cur.NewSExt(y, wide))
+	min := constant.NewInt(-1<<uint(typ.Size-1), wide)
+	max := constant.NewInt(1<<uint(typ.Size-1)-1, wide)
+	cond1 := f.cur.NewICmp(ir.IntSLT, quo, min)
+	cond2 := f.cur.NewICmp(ir.IntSGT, quo, max)
+	cond := f.cur.NewOr(cond1, cond2)
+	f.trapIf(inst, cond)
+}
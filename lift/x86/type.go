@@ -64,8 +64,26 @@ func (l *Lifter) sizeOfType(t types.Type) int64 {
 	return bits / 8
 }
 
+// SizeOf returns the size of the given type in number of bytes.
+func (l *Lifter) SizeOf(t types.Type) int64 {
+	return l.sizeOfType(t)
+}
+
 // parseType returns the LLVM IR type represented by the given string.
 func (l *Lifter) parseType(typStr string) types.Type {
+	t, err := l.parseTypeString(typStr)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// parseTypeString returns the LLVM IR type represented by the given string, or
+// an error if unable to parse the type.
+func (l *Lifter) parseTypeString(typStr string) (types.Type, error) {
+	if typStr == "void" {
+		return types.Void, nil
+	}
 	module := &ir.Module{
 		Types: l.Types,
 	}
@@ -73,7 +91,7 @@ func (l *Lifter) parseType(typStr string) types.Type {
 	s := fmt.Sprintf("%s\n\n@dummy = external global %s", module, typStr)
 	m, err := asm.ParseString(s)
 	if err != nil {
-		panic(fmt.Errorf("unable to parse type %q; %v", s, err))
+		return nil, fmt.Errorf("unable to parse type %q; %v", s, err)
 	}
-	return m.Globals[0].Typ.Elem
+	return m.Globals[0].Typ.Elem, nil
 }
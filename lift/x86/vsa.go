@@ -0,0 +1,753 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Value-set analysis ] ##################################################
+
+// vsaRegion is a memory region of value-set analysis.
+type vsaRegion uint8
+
+// Memory regions.
+const (
+	// Absolute values; e.g. integers and addresses of global variables.
+	vsaGlobal vsaRegion = iota
+	// Offsets relative to the stack pointer at function entry.
+	vsaStack
+)
+
+// vsaLimit specifies the bound of offsets of value sets; value sets exceeding
+// the bound are unknown.
+const vsaLimit = 1 << 40
+
+// vsaSP is the key of the stack pointer in the value sets of registers.
+const vsaSP = x86asm.ESP
+
+// vsaMaxVisits specifies the number of times the state of a basic block may
+// change before being widened.
+const vsaMaxVisits = 3
+
+// A valueSet is an abstract value of value-set analysis; a strided interval of
+// offsets {lo, lo+stride, ..., hi} within a memory region.
+type valueSet struct {
+	// Memory region.
+	region vsaRegion
+	// Bounds of offsets.
+	lo, hi int64
+	// Stride of offsets; or 0 if the value set is a singleton.
+	stride int64
+	// Specifies whether the value set is unknown.
+	top bool
+}
+
+// vsTop is the unknown value set.
+var vsTop = valueSet{top: true}
+
+// vsConst returns the value set of the given constant.
+func vsConst(c int64) valueSet {
+	return valueSet{region: vsaGlobal, lo: c, hi: c}
+}
+
+// vsRange returns the value set of offsets within the given memory region and
+// bounds.
+func vsRange(region vsaRegion, lo, hi, stride int64) valueSet {
+	if lo <= -vsaLimit || hi >= vsaLimit || lo > hi {
+		return vsTop
+	}
+	if lo == hi {
+		stride = 0
+	}
+	return valueSet{region: region, lo: lo, hi: hi, stride: stride}
+}
+
+// singleton reports whether the value set contains a single offset, and
+// returns the offset.
+func (v valueSet) singleton() (int64, bool) {
+	return v.lo, !v.top && v.lo == v.hi
+}
+
+// join returns the union of the value sets.
+func (v valueSet) join(w valueSet) valueSet {
+	if v.top || w.top || v.region != w.region {
+		return vsTop
+	}
+	lo, hi := min64(v.lo, w.lo), max64(v.hi, w.hi)
+	stride := gcd64(gcd64(v.stride, w.stride), abs64(v.lo-w.lo))
+	return vsRange(v.region, lo, hi, stride)
+}
+
+// add returns the value set of v + w.
+func (v valueSet) add(w valueSet) valueSet {
+	if v.top || w.top || v.region == vsaStack && w.region == vsaStack {
+		return vsTop
+	}
+	region := v.region
+	if w.region == vsaStack {
+		region = vsaStack
+	}
+	return vsRange(region, v.lo+w.lo, v.hi+w.hi, gcd64(v.stride, w.stride))
+}
+
+// sub returns the value set of v - w.
+func (v valueSet) sub(w valueSet) valueSet {
+	if v.top || w.top || v.region == vsaGlobal && w.region == vsaStack {
+		return vsTop
+	}
+	region := v.region
+	if w.region == vsaStack {
+		// Difference of stack addresses.
+		region = vsaGlobal
+	}
+	return vsRange(region, v.lo-w.hi, v.hi-w.lo, gcd64(v.stride, w.stride))
+}
+
+// mul returns the value set of v * k.
+func (v valueSet) mul(k int64) valueSet {
+	if v.top || v.region != vsaGlobal || abs64(k) >= vsaLimit || abs64(v.lo) >= vsaLimit/(abs64(k)+1) || abs64(v.hi) >= vsaLimit/(abs64(k)+1) {
+		return vsTop
+	}
+	lo, hi := v.lo*k, v.hi*k
+	if k < 0 {
+		lo, hi = hi, lo
+	}
+	return vsRange(vsaGlobal, lo, hi, v.stride*abs64(k))
+}
+
+// meet returns the intersection of the value set with the given bounds of
+// absolute values. The boolean return value indicates whether the
+// intersection is non-empty.
+func (v valueSet) meet(lo, hi int64) (valueSet, bool) {
+	if v.top {
+		return vsRange(vsaGlobal, lo, hi, 1), true
+	}
+	if v.region != vsaGlobal {
+		return v, true
+	}
+	if v.stride > 0 && lo > v.lo {
+		// Align lower bound to stride.
+		lo = v.lo + (lo-v.lo+v.stride-1)/v.stride*v.stride
+	}
+	lo, hi = max64(lo, v.lo), min64(hi, v.hi)
+	if lo > hi {
+		return vsTop, false
+	}
+	return vsRange(vsaGlobal, lo, hi, v.stride), true
+}
+
+// A vsaState is the abstract state of value-set analysis at an instruction.
+type vsaState struct {
+	// Value sets of general purpose registers, indexed by largest enclosing
+	// register (e.g. EAX for AL); registers not present are unknown.
+	regs map[x86asm.Reg]valueSet
+	// Value sets of stack slots, indexed by offset relative to the stack
+	// pointer at function entry; stack slots not present are unknown.
+	slots map[int64]vsaSlot
+}
+
+// A vsaSlot is the value set of a stack slot of the given size in bytes.
+type vsaSlot struct {
+	size int64
+	v    valueSet
+}
+
+// clone returns a copy of the abstract state.
+func (st *vsaState) clone() *vsaState {
+	c := &vsaState{
+		regs:  make(map[x86asm.Reg]valueSet, len(st.regs)),
+		slots: make(map[int64]vsaSlot, len(st.slots)),
+	}
+	for reg, v := range st.regs {
+		c.regs[reg] = v
+	}
+	for off, slot := range st.slots {
+		c.slots[off] = slot
+	}
+	return c
+}
+
+// join returns the union of the abstract states. The widen flag specifies
+// whether to widen changed value sets to unknown, in order to guarantee
+// termination of the analysis of loops.
+func (st *vsaState) join(other *vsaState, widen bool) *vsaState {
+	c := &vsaState{
+		regs:  make(map[x86asm.Reg]valueSet),
+		slots: make(map[int64]vsaSlot),
+	}
+	for reg, v := range st.regs {
+		w, ok := other.regs[reg]
+		if !ok {
+			continue
+		}
+		u := v.join(w)
+		if widen && u != v {
+			continue
+		}
+		if !u.top {
+			c.regs[reg] = u
+		}
+	}
+	for off, slot := range st.slots {
+		s, ok := other.slots[off]
+		if !ok || s.size != slot.size {
+			continue
+		}
+		u := slot.v.join(s.v)
+		if widen && u != slot.v {
+			continue
+		}
+		if !u.top {
+			c.slots[off] = vsaSlot{size: slot.size, v: u}
+		}
+	}
+	return c
+}
+
+// equal reports whether the abstract states are equal.
+func (st *vsaState) equal(other *vsaState) bool {
+	if len(st.regs) != len(other.regs) || len(st.slots) != len(other.slots) {
+		return false
+	}
+	for reg, v := range st.regs {
+		if w, ok := other.regs[reg]; !ok || v != w {
+			return false
+		}
+	}
+	for off, slot := range st.slots {
+		if s, ok := other.slots[off]; !ok || s != slot {
+			return false
+		}
+	}
+	return true
+}
+
+// analyzeValueSets performs value-set analysis of the function, to bound the
+// addresses of indirect memory references (e.g. `mov eax, [ebx+ecx*4]`) to
+// stack slots and global variables. The value sets of the addresses of memory
+// references are recorded in f.memSets, indexed by instruction address.
+//
+// Offsets of the stack region are tracked consistently with the stack slots of
+// the lifter; i.e. relative to the stack pointer at function entry, with
+// arguments purged by callees of known signature. Value sets are refined by the
+// bounds checks of conditional branches (e.g. `cmp eax, 7; ja default`).
+func (f *Func) analyzeValueSets() {
+	f.memSets = make(map[bin.Address]valueSet)
+	if f.l.Mode == 16 || len(f.AsmFunc.Blocks) == 0 {
+		// Memory references of 16-bit real mode are segmented.
+		return
+	}
+	entry := &vsaState{
+		regs:  map[x86asm.Reg]valueSet{vsaSP: vsRange(vsaStack, 0, 0, 0)},
+		slots: make(map[int64]vsaSlot),
+	}
+	in := map[bin.Address]*vsaState{f.AsmFunc.Addr: entry}
+	visits := make(map[bin.Address]int)
+	queue := []bin.Address{f.AsmFunc.Addr}
+	for len(queue) > 0 {
+		addr := queue[0]
+		queue = queue[1:]
+		block, ok := f.AsmFunc.Blocks[addr]
+		if !ok {
+			continue
+		}
+		st := in[addr].clone()
+		for _, inst := range block.Insts {
+			f.vsaExec(st, inst)
+		}
+		for _, succ := range f.succs(block) {
+			out, ok := f.vsaRefine(st, block, succ)
+			if !ok {
+				// Infeasible edge.
+				continue
+			}
+			old, ok := in[succ]
+			if !ok {
+				in[succ] = out
+				queue = append(queue, succ)
+				continue
+			}
+			visits[succ]++
+			joined := old.join(out, visits[succ] > vsaMaxVisits)
+			if !joined.equal(old) {
+				in[succ] = joined
+				queue = append(queue, succ)
+			}
+		}
+	}
+	// Record value sets of memory references.
+	for addr, block := range f.AsmFunc.Blocks {
+		st, ok := in[addr]
+		if !ok {
+			continue
+		}
+		st = st.clone()
+		for _, inst := range block.Insts {
+			f.vsaRecord(st, inst)
+			f.vsaExec(st, inst)
+		}
+		f.vsaRecord(st, block.Term)
+	}
+}
+
+// vsaRecord records the value set of the address of the memory reference of
+// the given instruction, if bounded.
+func (f *Func) vsaRecord(st *vsaState, inst *x86.Inst) {
+	for _, arg := range inst.Args {
+		mem, ok := arg.(x86asm.Mem)
+		if !ok {
+			continue
+		}
+		if v := f.vsaAddr(st, inst, mem); !v.top {
+			f.memSets[inst.Addr] = v
+		}
+		return
+	}
+}
+
+// vsaAddr returns the value set of the address of the given memory reference.
+func (f *Func) vsaAddr(st *vsaState, inst *x86.Inst, mem x86asm.Mem) valueSet {
+	switch mem.Segment {
+	case 0, x86asm.CS, x86asm.DS, x86asm.ES, x86asm.SS:
+		// Flat memory model.
+	default:
+		return vsTop
+	}
+	v := vsConst(mem.Disp)
+	switch {
+	case mem.Base == x86asm.IP || mem.Base == x86asm.EIP || mem.Base == x86asm.RIP:
+		v = vsConst(int64(inst.Addr) + int64(inst.Len) + mem.Disp)
+	case mem.Base != 0:
+		if addr, ok := f.picRegs[mem.Base]; ok {
+			v = v.add(vsConst(int64(addr)))
+		} else {
+			v = v.add(f.vsaReg(st, mem.Base))
+		}
+	}
+	if mem.Index != 0 {
+		v = v.add(f.vsaReg(st, mem.Index).mul(int64(mem.Scale)))
+	}
+	return v
+}
+
+// vsaReg returns the value set of the given register.
+func (f *Func) vsaReg(st *vsaState, reg x86asm.Reg) valueSet {
+	parent := f.l.parentReg(reg)
+	if isSPReg(reg) {
+		parent = vsaSP
+	}
+	v, ok := st.regs[parent]
+	if !ok {
+		return vsTop
+	}
+	switch size := regBytes(reg); {
+	case size == f.l.Mode/8:
+		return v
+	case size == 4 && v.region == vsaGlobal && v.lo >= 0 && v.hi <= 0xFFFFFFFF:
+		// Lower 32 bits of 64-bit register.
+		return v
+	}
+	return vsTop
+}
+
+// vsaSetReg sets the value set of the given register.
+func (f *Func) vsaSetReg(st *vsaState, reg x86asm.Reg, v valueSet) {
+	parent := f.l.parentReg(reg)
+	if isSPReg(reg) {
+		parent = vsaSP
+	}
+	if parent == 0 {
+		return
+	}
+	switch size := regBytes(reg); {
+	case size == f.l.Mode/8:
+		// Full register.
+	case size == 4 && v.region == vsaGlobal && v.lo >= 0 && v.hi <= 0xFFFFFFFF:
+		// 32-bit writes zero-extend the value to the full 64-bit register.
+	default:
+		v = vsTop
+	}
+	if v.top {
+		delete(st.regs, parent)
+		return
+	}
+	st.regs[parent] = v
+}
+
+// vsaLoad returns the value set of the given instruction argument.
+func (f *Func) vsaLoad(st *vsaState, inst *x86.Inst, arg x86asm.Arg) valueSet {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		return f.vsaReg(st, arg)
+	case x86asm.Imm:
+		return vsConst(int64(arg))
+	case x86asm.Mem:
+		addr := f.vsaAddr(st, inst, arg)
+		if off, ok := addr.singleton(); ok && addr.region == vsaStack {
+			if slot, ok := st.slots[off]; ok && slot.size == int64(inst.MemBytes) {
+				return slot.v
+			}
+		}
+	}
+	return vsTop
+}
+
+// vsaStore stores the value set to the given instruction argument.
+func (f *Func) vsaStore(st *vsaState, inst *x86.Inst, arg x86asm.Arg, v valueSet) {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		f.vsaSetReg(st, arg, v)
+	case x86asm.Mem:
+		f.vsaStoreMem(st, f.vsaAddr(st, inst, arg), int64(inst.MemBytes), v)
+	}
+}
+
+// vsaStoreMem stores the value set to the memory at the given address and of
+// the specified size in bytes.
+func (f *Func) vsaStoreMem(st *vsaState, addr valueSet, size int64, v valueSet) {
+	switch {
+	case addr.top:
+		// Store through unknown pointer.
+		st.slots = make(map[int64]vsaSlot)
+		return
+	case addr.region == vsaGlobal:
+		// Global variables are not tracked.
+		return
+	}
+	// Forget overlapping stack slots.
+	for off, slot := range st.slots {
+		if off < addr.hi+size && addr.lo < off+slot.size {
+			delete(st.slots, off)
+		}
+	}
+	if off, ok := addr.singleton(); ok && !v.top {
+		st.slots[off] = vsaSlot{size: size, v: v}
+	}
+}
+
+// vsaPush pushes the value set to the stack.
+func (f *Func) vsaPush(st *vsaState, v valueSet) {
+	word := f.l.stackWord()
+	sp := f.vsaReg(st, f.spReg()).add(vsConst(-word))
+	f.vsaSetReg(st, f.spReg(), sp)
+	f.vsaStoreMem(st, sp, word, v)
+}
+
+// vsaPop pops a value set from the stack.
+func (f *Func) vsaPop(st *vsaState) valueSet {
+	word := f.l.stackWord()
+	sp := f.vsaReg(st, f.spReg())
+	v := vsTop
+	if off, ok := sp.singleton(); ok && sp.region == vsaStack {
+		if slot, ok := st.slots[off]; ok && slot.size == word {
+			v = slot.v
+		}
+	}
+	f.vsaSetReg(st, f.spReg(), sp.add(vsConst(word)))
+	return v
+}
+
+// vsaExec updates the abstract state based on the given instruction.
+func (f *Func) vsaExec(st *vsaState, inst *x86.Inst) {
+	args := inst.Args
+	load := func(i int) valueSet {
+		return f.vsaLoad(st, inst, args[i])
+	}
+	store := func(v valueSet) {
+		f.vsaStore(st, inst, args[0], v)
+	}
+	switch inst.Op {
+	case x86asm.NOP, x86asm.CMP, x86asm.TEST, x86asm.CLD, x86asm.STD, x86asm.CLC, x86asm.STC, x86asm.CMC:
+		// no effect on registers or memory.
+	case x86asm.MOV:
+		store(load(1))
+	case x86asm.MOVZX:
+		size := argBytes(inst, args[1])
+		v, _ := load(1).meet(0, int64(1)<<uint(8*size)-1)
+		store(v)
+	case x86asm.MOVSX, x86asm.MOVSXD:
+		v := load(1)
+		if c, ok := v.singleton(); !ok || v.region != vsaGlobal || c < 0 {
+			v = vsTop
+		}
+		store(v)
+	case x86asm.LEA:
+		mem, _ := args[1].(x86asm.Mem)
+		store(f.vsaAddr(st, inst, mem))
+	case x86asm.XCHG:
+		a, b := load(0), load(1)
+		store(b)
+		f.vsaStore(st, inst, args[1], a)
+	case x86asm.ADD:
+		store(load(0).add(load(1)))
+	case x86asm.SUB:
+		if args[0] == args[1] {
+			store(vsConst(0))
+			break
+		}
+		store(load(0).sub(load(1)))
+	case x86asm.INC:
+		store(load(0).add(vsConst(1)))
+	case x86asm.DEC:
+		store(load(0).add(vsConst(-1)))
+	case x86asm.NEG:
+		store(vsConst(0).sub(load(0)))
+	case x86asm.XOR:
+		if args[0] == args[1] {
+			store(vsConst(0))
+			break
+		}
+		store(vsTop)
+	case x86asm.AND:
+		v := vsTop
+		if imm, ok := args[1].(x86asm.Imm); ok && imm >= 0 {
+			v, _ = load(0).meet(0, int64(imm))
+			if v.top || v.region != vsaGlobal {
+				v = vsRange(vsaGlobal, 0, int64(imm), 1)
+			}
+		}
+		store(v)
+	case x86asm.SHL:
+		v := vsTop
+		if imm, ok := args[1].(x86asm.Imm); ok && imm < 32 {
+			v = load(0).mul(1 << uint(imm))
+		}
+		store(v)
+	case x86asm.IMUL:
+		switch {
+		case args[1] == nil:
+			f.vsaSetReg(st, x86asm.EAX, vsTop)
+			f.vsaSetReg(st, x86asm.EDX, vsTop)
+		case args[2] != nil:
+			v := vsTop
+			if imm, ok := args[2].(x86asm.Imm); ok {
+				v = load(1).mul(int64(imm))
+			}
+			store(v)
+		default:
+			v := vsTop
+			if imm, ok := args[1].(x86asm.Imm); ok {
+				v = load(0).mul(int64(imm))
+			}
+			store(v)
+		}
+	case x86asm.PUSH:
+		f.vsaPush(st, load(0))
+	case x86asm.POP:
+		store(f.vsaPop(st))
+	case x86asm.PUSHF, x86asm.PUSHFD, x86asm.PUSHFQ:
+		f.vsaPush(st, vsTop)
+	case x86asm.POPF, x86asm.POPFD, x86asm.POPFQ:
+		f.vsaPop(st)
+	case x86asm.LEAVE:
+		bp := x86asm.EBP
+		if f.l.Mode == 64 {
+			bp = x86asm.RBP
+		}
+		f.vsaSetReg(st, f.spReg(), f.vsaReg(st, bp))
+		f.vsaSetReg(st, bp, f.vsaPop(st))
+	case x86asm.CALL, x86asm.LCALL:
+		// Caller-saved registers are clobbered by the callee, which may write
+		// to any memory.
+		for _, reg := range []x86asm.Reg{x86asm.EAX, x86asm.ECX, x86asm.EDX, x86asm.ESI, x86asm.EDI, x86asm.R8L, x86asm.R9L, x86asm.R10L, x86asm.R11L} {
+			if f.l.Mode == 32 && reg >= x86asm.ESI {
+				break
+			}
+			f.vsaSetReg(st, reg, vsTop)
+		}
+		st.slots = make(map[int64]vsaSlot)
+		purge, _ := f.stackPurge(inst)
+		f.vsaSetReg(st, f.spReg(), f.vsaReg(st, f.spReg()).add(vsConst(purge)))
+	case x86asm.PUSHAD, x86asm.POPAD, x86asm.ENTER:
+		st.regs = map[x86asm.Reg]valueSet{}
+		st.slots = make(map[int64]vsaSlot)
+	default:
+		// Unknown instruction; the destination argument and implicitly written
+		// registers are clobbered.
+		if _, ok := args[0].(x86asm.Mem); ok {
+			store(vsTop)
+		}
+		_, defs := f.l.regUseDef(inst)
+		for _, reg := range defs {
+			f.vsaSetReg(st, reg, vsTop)
+		}
+		if reg, ok := args[0].(x86asm.Reg); ok && isSPReg(reg) {
+			f.vsaSetReg(st, reg, vsTop)
+		}
+	}
+}
+
+// vsaRefine returns the abstract state along the edge from the given basic
+// block to its successor, as refined by the bounds check of a conditional
+// branch; i.e. a CMP instruction comparing a register or stack slot with an
+// immediate, followed by a conditional jump. The boolean return value
+// indicates whether the edge is feasible.
+func (f *Func) vsaRefine(st *vsaState, block *x86.BasicBlock, succ bin.Address) (*vsaState, bool) {
+	term := block.Term
+	n := len(block.Insts)
+	if n == 0 || block.Insts[n-1].Op != x86asm.CMP || term.IsDummyTerm() {
+		return st, true
+	}
+	cmp := block.Insts[n-1]
+	imm, ok := cmp.Args[1].(x86asm.Imm)
+	if !ok {
+		return st, true
+	}
+	rel, ok := term.Args[0].(x86asm.Rel)
+	if !ok {
+		return st, true
+	}
+	next := term.Addr + bin.Address(term.Len)
+	target := next + bin.Address(rel)
+	if target == next {
+		// Both edges lead to the same successor.
+		return st, true
+	}
+	taken := succ == target
+	size := argBytes(cmp, cmp.Args[0])
+	k := int64(imm)
+	mask := int64(1)<<uint(8*size) - 1
+	if size >= 8 {
+		mask = vsaLimit - 1
+	}
+	// Bounds of the compared value, along the edge.
+	lo, hi := int64(-vsaLimit+1), int64(vsaLimit-1)
+	op := term.Op
+	if !taken {
+		switch op {
+		case x86asm.JA:
+			op = x86asm.JBE
+		case x86asm.JAE:
+			op = x86asm.JB
+		case x86asm.JB:
+			op = x86asm.JAE
+		case x86asm.JBE:
+			op = x86asm.JA
+		case x86asm.JE:
+			op = x86asm.JNE
+		case x86asm.JNE:
+			op = x86asm.JE
+		case x86asm.JG:
+			op = x86asm.JLE
+		case x86asm.JGE:
+			op = x86asm.JL
+		case x86asm.JL:
+			op = x86asm.JGE
+		case x86asm.JLE:
+			op = x86asm.JG
+		default:
+			return st, true
+		}
+	}
+	switch op {
+	case x86asm.JBE:
+		if k < 0 {
+			return st, true
+		}
+		lo, hi = 0, k&mask
+	case x86asm.JB:
+		if k <= 0 {
+			return st, true
+		}
+		lo, hi = 0, k&mask-1
+	case x86asm.JE:
+		lo, hi = k, k
+	case x86asm.JLE:
+		hi = k
+	case x86asm.JL:
+		hi = k - 1
+	case x86asm.JGE:
+		lo = k
+	case x86asm.JG:
+		lo = k + 1
+	default:
+		return st, true
+	}
+	v := f.vsaLoad(st, cmp, cmp.Args[0])
+	if v.top && (lo == -vsaLimit+1 || hi == vsaLimit-1) {
+		// Unbounded refinement.
+		return st, true
+	}
+	refined, ok := v.meet(lo, hi)
+	if !ok {
+		return nil, false
+	}
+	out := st.clone()
+	f.vsaStore(out, cmp, cmp.Args[0], refined)
+	return out, true
+}
+
+// ### [ helpers ] #############################################################
+
+// spReg returns the stack pointer register of the function.
+func (f *Func) spReg() x86asm.Reg {
+	if f.l.Mode == 64 {
+		return x86asm.RSP
+	}
+	return x86asm.ESP
+}
+
+// isSPReg reports whether the given register is a stack pointer register.
+func isSPReg(reg x86asm.Reg) bool {
+	return reg == x86asm.SP || reg == x86asm.ESP || reg == x86asm.RSP
+}
+
+// regBytes returns the size in bytes of the given general purpose register; or
+// 0 if not a general purpose register.
+func regBytes(reg x86asm.Reg) int {
+	switch {
+	case x86asm.AL <= reg && reg <= x86asm.R15B:
+		return 1
+	case x86asm.AX <= reg && reg <= x86asm.R15W:
+		return 2
+	case x86asm.EAX <= reg && reg <= x86asm.R15L:
+		return 4
+	case x86asm.RAX <= reg && reg <= x86asm.R15:
+		return 8
+	}
+	return 0
+}
+
+// argBytes returns the size in bytes of the given instruction argument.
+func argBytes(inst *x86.Inst, arg x86asm.Arg) int {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		return regBytes(arg)
+	case x86asm.Mem:
+		return inst.MemBytes
+	}
+	return inst.DataSize / 8
+}
+
+// min64 returns the minimum of x and y.
+func min64(x, y int64) int64 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// max64 returns the maximum of x and y.
+func max64(x, y int64) int64 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+// abs64 returns the absolute value of x.
+func abs64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// gcd64 returns the greatest common divisor of x and y.
+func gcd64(x, y int64) int64 {
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return x
+}
@@ -0,0 +1,367 @@
+package x86
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// maxVTableLen specifies the maximum number of virtual methods of vtables.
+const maxVTableLen = 1024
+
+// A VTable is a virtual method table of a C++ class; i.e. an array of function
+// pointers in read-only data, the address of which is stored into objects by
+// constructors.
+type VTable struct {
+	// Address of the vtable.
+	Addr bin.Address
+	// Addresses of the virtual methods of the vtable, in slot order.
+	Methods []bin.Address
+	// C++ class of the vtable, as recovered from RTTI; or nil if unknown.
+	Class *Class
+}
+
+// vtableAt returns the vtable located at the given address, parsing it if not
+// already known. The boolean return value indicates success.
+//
+// A vtable is an array of one or more pointer-sized addresses into executable
+// sections, located in a read-only non-executable section.
+func (l *Lifter) vtableAt(addr bin.Address) (*VTable, bool) {
+	if vt, ok := l.VTables[addr]; ok {
+		return vt, true
+	}
+	perm := l.File.Perm(addr)
+	if perm&bin.PermR == 0 || perm&(bin.PermW|bin.PermX) != 0 {
+		return nil, false
+	}
+	ptrSize := bin.Address(l.Mode / 8)
+	vt := &VTable{Addr: addr}
+	for i := 0; i < maxVTableLen; i++ {
+		slot := addr + bin.Address(i)*ptrSize
+		if i > 0 {
+			// Stop at the start of succeeding vtables.
+			if _, ok := l.VTables[slot]; ok {
+				break
+			}
+		}
+		v, err := l.File.ReadUintptr(slot)
+		if err != nil {
+			break
+		}
+		method := bin.Address(v)
+		if !l.File.IsCode(method) {
+			break
+		}
+		vt.Methods = append(vt.Methods, method)
+	}
+	if len(vt.Methods) == 0 {
+		return nil, false
+	}
+	if l.File.Format == "pe" {
+		if class, ok := l.parseRTTI(addr); ok {
+			vt.Class = class
+		}
+	}
+	dbg.Printf("located vtable at %v with %d virtual methods", addr, len(vt.Methods))
+	l.VTables[addr] = vt
+	return vt, true
+}
+
+// vtableState tracks the registers holding vtable related values within a basic
+// block.
+type vtableState struct {
+	// Registers holding constant addresses.
+	consts map[x86asm.Reg]bin.Address
+	// Registers pointing to objects of known vtable.
+	objs map[x86asm.Reg]*VTable
+	// Registers holding vtable pointers.
+	vptrs map[x86asm.Reg]*VTable
+	// Registers holding the this pointer of the function.
+	this map[x86asm.Reg]bool
+	// Registers pushed onto the stack since the last call; i.e. registers which
+	// may pass stack arguments to the next call.
+	pushed []x86asm.Reg
+}
+
+// clear forgets the values held by the given register.
+func (s *vtableState) clear(reg x86asm.Reg) {
+	delete(s.consts, reg)
+	delete(s.objs, reg)
+	delete(s.vptrs, reg)
+	delete(s.this, reg)
+}
+
+// detectVTables locates vtables stored into objects by the instructions of the
+// function (e.g. `mov dword [ecx], vtable` in constructors), and resolves the
+// targets of virtual calls through vtables of objects of known type (e.g.
+// `mov eax, [ecx]` followed by `call [eax+8]`). Values are tracked within
+// basic blocks.
+//
+// Functions storing a vtable of known C++ class into their this pointer are
+// named after the constructor of the class. Note, destructors may also match.
+func (f *Func) detectVTables() {
+	for _, block := range f.AsmFunc.Blocks {
+		s := &vtableState{
+			consts: make(map[x86asm.Reg]bin.Address),
+			objs:   make(map[x86asm.Reg]*VTable),
+			vptrs:  make(map[x86asm.Reg]*VTable),
+			this:   make(map[x86asm.Reg]bool),
+		}
+		if block.Addr == f.AsmFunc.Addr {
+			s.this[f.thisReg()] = true
+		}
+		insts := append(block.Insts[:len(block.Insts):len(block.Insts)], block.Term)
+		for _, inst := range insts {
+			f.trackVTables(s, inst)
+		}
+	}
+	if f.class != nil && f.Name == fmt.Sprintf("f_%06X", uint64(f.AsmFunc.Addr)) {
+		f.Name = ctorName(f.class)
+		dbg.Printf("located constructor %q at %v", f.Name, f.AsmFunc.Addr)
+		f.l.FuncByName[f.Name] = f.Function
+	}
+}
+
+// thisReg returns the register used to pass the this pointer of the C++ method;
+// i.e. the first argument register of its calling convention. The this pointer
+// is passed in ECX on 32-bit (thiscall), unless passed in the first register of
+// a register calling convention (e.g. EAX of Delphi), and in RCX (Microsoft
+// x64) or RDI (System V) on 64-bit.
+func (f *Func) thisReg() x86asm.Reg {
+	if f.regConv != nil && len(f.regConv.Regs) > 0 {
+		return f.regConv.Regs[0]
+	}
+	if f.l.Mode == 64 {
+		regs, ok := paramRegs[f.CallConv]
+		if !ok {
+			regs = paramRegs[f.l.defaultCallConv()]
+		}
+		return regs[0]
+	}
+	return x86asm.ECX
+}
+
+// callArgRegs returns the registers which may pass arguments to the callee of
+// the given call instruction; i.e. the argument registers of the calling
+// convention of the callee, and the registers pushed onto the stack since the
+// last call.
+func (f *Func) callArgRegs(s *vtableState, inst *x86.Inst) []x86asm.Reg {
+	regs := append([]x86asm.Reg(nil), s.pushed...)
+	if fn, ok := f.callee(inst); ok && fn.regConv != nil {
+		return append(regs, fn.regConv.Regs...)
+	}
+	switch f.l.Mode {
+	case 32:
+		if rc := f.l.defaultRegConv(); rc != nil {
+			return append(regs, rc.Regs...)
+		}
+		// thiscall and fastcall.
+		return append(regs, x86asm.ECX, x86asm.EDX)
+	case 64:
+		return append(regs, paramRegs[f.l.defaultCallConv()]...)
+	}
+	return regs
+}
+
+// trackVTables updates the vtable state of the basic block based on the given
+// instruction.
+func (f *Func) trackVTables(s *vtableState, inst *x86.Inst) {
+	l := f.l
+	var (
+		// Destination register.
+		dst x86asm.Reg
+		// Values held by dst after the instruction.
+		addr    bin.Address
+		hasAddr bool
+		obj     *VTable
+		vptr    *VTable
+		this    bool
+	)
+	switch inst.Op {
+	case x86asm.MOV:
+		switch a := inst.Args[0].(type) {
+		case x86asm.Reg:
+			dst = l.parentReg(a)
+			switch b := inst.Args[1].(type) {
+			case x86asm.Imm:
+				addr, hasAddr = refAddr(inst, b)
+			case x86asm.Reg:
+				src := l.parentReg(b)
+				addr, hasAddr = s.consts[src]
+				obj, vptr = s.objs[src], s.vptrs[src]
+				this = s.this[src]
+			case x86asm.Mem:
+				// Load of vtable pointer; e.g. `mov eax, [ecx]`.
+				if b.Segment == 0 && b.Index == 0 && b.Disp == 0 {
+					vptr = s.objs[l.parentReg(b.Base)]
+				}
+			}
+		case x86asm.Mem:
+			// Store of vtable pointer; e.g. `mov dword [ecx], vtable`.
+			if a.Segment != 0 || a.Index != 0 || l.parentReg(a.Base) == 0 {
+				break
+			}
+			var v bin.Address
+			var ok bool
+			switch b := inst.Args[1].(type) {
+			case x86asm.Imm:
+				v, ok = refAddr(inst, b)
+			case x86asm.Reg:
+				v, ok = s.consts[l.parentReg(b)]
+			}
+			if !ok {
+				break
+			}
+			if vt, ok := l.vtableAt(v); ok && a.Disp == 0 {
+				base := l.parentReg(a.Base)
+				s.objs[base] = vt
+				if s.this[base] && vt.Class != nil {
+					f.class = vt.Class
+				}
+			}
+		}
+	case x86asm.LEA:
+		if a, ok := inst.Args[0].(x86asm.Reg); ok {
+			dst = l.parentReg(a)
+			addr, hasAddr = refAddr(inst, inst.Args[1])
+		}
+	case x86asm.CALL:
+		// Virtual call; e.g. `call [eax+8]`.
+		mem, ok := inst.Args[0].(x86asm.Mem)
+		if !ok || mem.Segment != 0 || mem.Index != 0 || mem.Disp < 0 {
+			break
+		}
+		base := l.parentReg(mem.Base)
+		vt, ok := s.vptrs[base]
+		if !ok {
+			if addr, ok := s.consts[base]; ok {
+				vt, ok = l.vtableAt(addr)
+			}
+		}
+		if vt == nil {
+			break
+		}
+		slot := int(mem.Disp) / (l.Mode / 8)
+		if slot >= len(vt.Methods) {
+			break
+		}
+		target := vt.Methods[slot]
+		dbg.Printf("resolved virtual call at %v to slot %d of vtable at %v (%v)", inst.Addr, slot, vt.Addr, target)
+		f.virtCalls[inst.Addr] = target
+	case x86asm.PUSH:
+		if a, ok := inst.Args[0].(x86asm.Reg); ok {
+			s.pushed = append(s.pushed, l.parentReg(a))
+		}
+	}
+	if inst.Op == x86asm.CALL || inst.Op == x86asm.LCALL {
+		// The callee may store another vtable into objects passed to it (e.g.
+		// constructors of base classes); forget the vtables of objects passed to
+		// the callee, including copies held by callee-saved registers.
+		passed := make(map[*VTable]bool)
+		for _, reg := range f.callArgRegs(s, inst) {
+			if vt, ok := s.objs[reg]; ok {
+				passed[vt] = true
+			}
+			delete(s.vptrs, reg)
+		}
+		for reg, vt := range s.objs {
+			if passed[vt] {
+				delete(s.objs, reg)
+			}
+		}
+		s.pushed = nil
+	}
+	// Forget values of clobbered registers.
+	_, defs := l.regUseDef(inst)
+	for _, reg := range defs {
+		s.clear(reg)
+	}
+	if dst == 0 {
+		return
+	}
+	if hasAddr {
+		s.consts[dst] = addr
+	}
+	if obj != nil {
+		s.objs[dst] = obj
+	}
+	if vptr != nil {
+		s.vptrs[dst] = vptr
+	}
+	if this {
+		s.this[dst] = true
+	}
+}
+
+// emitVTables adds the located vtables as global variables to the lifter;
+// arrays of function pointers. Addresses covered by known global variables are
+// skipped.
+func (l *Lifter) emitVTables() {
+	var addrs bin.Addresses
+	for addr := range l.VTables {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	ptrType := types.NewPointer(types.I8)
+	// Address ranges of known global variables.
+	known := l.globalRanges()
+	// End address of the previously added vtable.
+	var end bin.Address
+	for _, addr := range addrs {
+		if addr < end || inRanges(known, addr) {
+			continue
+		}
+		vt := l.VTables[addr]
+		name := fmt.Sprintf("vtable_%06X", uint64(addr))
+		if vt.Class != nil {
+			name = fmt.Sprintf("%s::vftable", vt.Class.Name)
+		}
+		var elems []constant.Constant
+		for _, method := range vt.Methods {
+			if fn, ok := l.funcAt(method); ok {
+				if vt.Class != nil {
+					fn.nameThis()
+				}
+				elems = append(elems, constant.NewBitCast(fn.Function, ptrType))
+				continue
+			}
+			x := constant.NewInt(int64(method), types.NewInt(l.Mode))
+			elems = append(elems, constant.NewIntToPtr(x, ptrType))
+		}
+		init := constant.NewArray(elems...)
+		content := init.Type()
+		g := &ir.Global{
+			Name:    name,
+			Typ:     types.NewPointer(content),
+			Content: content,
+			Init:    init,
+			IsConst: true,
+			Metadata: map[string]*metadata.Metadata{
+				"addr": {
+					Nodes: []metadata.Node{&metadata.String{Val: addr.String()}},
+				},
+			},
+		}
+		l.Globals[addr] = g
+		end = addr + bin.Address(l.sizeOfType(content))
+	}
+}
+
+// nameThis names the parameter holding the this pointer of the C++ method or
+// constructor.
+func (f *Func) nameThis() {
+	name := regParam(f.thisReg(), nil).Name
+	for _, param := range f.Sig.Params {
+		if param.Name == name {
+			param.Name = "this"
+			return
+		}
+	}
+}
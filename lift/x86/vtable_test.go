@@ -0,0 +1,81 @@
+package x86
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+func TestDetectVTables(t *testing.T) {
+	// Code of the .text section of a System V executable, located at 0x1000.
+	//
+	//    method0:
+	//       ret
+	//    method1:
+	//       ret
+	//
+	//    ctor:                          ; located at 0x1010
+	//       mov     qword [rdi], vtable
+	//       mov     rbx, rdi            ; copy in callee-saved register
+	//       mov     rax, [rdi]
+	//       call    [rax+8]             ; method1
+	//       mov     rax, [rbx]          ; vtable may be replaced by the callee
+	//       call    [rax+8]             ; unresolved
+	//       ret
+	code := make([]byte, 0x30)
+	for i := range code {
+		// int3 padding.
+		code[i] = 0xCC
+	}
+	code[0], code[1] = 0xC3, 0xC3
+	copy(code[0x10:], []byte{
+		0x48, 0xC7, 0x07, 0x00, 0x20, 0x00, 0x00,
+		0x48, 0x89, 0xFB,
+		0x48, 0x8B, 0x07,
+		0xFF, 0x50, 0x08,
+		0x48, 0x8B, 0x03,
+		0xFF, 0x50, 0x08,
+		0xC3,
+	})
+	// Contents of the .rodata section, located at 0x2000.
+	//
+	//    vtable:
+	//       dq      method0, method1
+	rodata := make([]byte, 0x18)
+	rodata[0], rodata[1] = 0x00, 0x10
+	rodata[8], rodata[9] = 0x01, 0x10
+	const (
+		method1 = bin.Address(0x1001)
+		ctor    = bin.Address(0x1010)
+		call    = bin.Address(0x101D)
+	)
+	file := &bin.File{
+		Format: "elf",
+		Arch:   bin.ArchX86_64,
+		Entry:  ctor,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x1000, Data: code, MemSize: len(code), Perm: bin.PermR | bin.PermX},
+			{Name: ".rodata", Addr: 0x2000, Data: rodata, MemSize: len(rodata), Perm: bin.PermR},
+		},
+		Exports: map[bin.Address]string{0x1000: "method0", method1: "method1", ctor: "ctor"},
+	}
+	l, err := NewLifter(file)
+	if err != nil {
+		t.Fatalf("unable to prepare lifter; %+v", err)
+	}
+	asmFunc, err := l.DecodeFunc(ctor)
+	if err != nil {
+		t.Fatalf("unable to decode function at %v; %+v", ctor, err)
+	}
+	f := l.NewFunc(asmFunc)
+	// The this pointer is passed in RDI on System V.
+	if got := f.thisReg(); got != x86asm.RDI {
+		t.Errorf("this register mismatch; expected %v, got %v", x86asm.RDI, got)
+	}
+	virtCalls := map[bin.Address]bin.Address{call: method1}
+	if !reflect.DeepEqual(f.virtCalls, virtCalls) {
+		t.Errorf("virtual calls mismatch; expected %v, got %v", virtCalls, f.virtCalls)
+	}
+}
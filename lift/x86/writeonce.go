@@ -0,0 +1,154 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ### [ Write-once global variables ] #########################################
+
+// writesMem reports whether the given instruction writes to its first operand,
+// if a memory operand. Instructions not known to only read their first operand
+// are assumed to write to it.
+func writesMem(inst *x86.Inst) bool {
+	switch inst.Op {
+	case x86asm.CMP, x86asm.TEST, x86asm.PUSH, x86asm.BT, x86asm.JMP,
+		x86asm.CALL, x86asm.LJMP, x86asm.LCALL, x86asm.NOP, x86asm.FLD,
+		x86asm.FILD, x86asm.FBLD, x86asm.FADD, x86asm.FIADD, x86asm.FSUB,
+		x86asm.FISUB, x86asm.FSUBR, x86asm.FISUBR, x86asm.FMUL, x86asm.FIMUL,
+		x86asm.FDIV, x86asm.FIDIV, x86asm.FDIVR, x86asm.FIDIVR, x86asm.FCOM,
+		x86asm.FCOMP, x86asm.FICOM, x86asm.FICOMP, x86asm.FLDCW,
+		x86asm.FLDENV, x86asm.FRSTOR:
+		return false
+	}
+	return true
+}
+
+// isStore reports whether the given instruction writes to its first operand
+// without reading it; e.g. MOV, as opposed to ADD.
+func isStore(inst *x86.Inst) bool {
+	switch inst.Op {
+	case x86asm.MOV, x86asm.MOVD, x86asm.MOVQ, x86asm.MOVSS, x86asm.MOVSD_XMM,
+		x86asm.POP, x86asm.FST, x86asm.FSTP, x86asm.FIST, x86asm.FISTP,
+		x86asm.FISTTP, x86asm.FNSTCW, x86asm.FNSTSW:
+		return true
+	}
+	return isSetcc(inst.Op)
+}
+
+// isSetcc reports whether the given opcode is a SETcc instruction.
+func isSetcc(op x86asm.Op) bool {
+	switch op {
+	case x86asm.SETA, x86asm.SETAE, x86asm.SETB, x86asm.SETBE, x86asm.SETE,
+		x86asm.SETG, x86asm.SETGE, x86asm.SETL, x86asm.SETLE, x86asm.SETNE,
+		x86asm.SETNO, x86asm.SETNP, x86asm.SETNS, x86asm.SETO, x86asm.SETP,
+		x86asm.SETS:
+		return true
+	}
+	return false
+}
+
+// recordWrite records the access to the memory operand at the given operand
+// index of the specified instruction in acc, as a read and/or write. Reads of
+// the address by the function are recorded in reads, and constant stores to
+// the address by the function in stores.
+func recordWrite(acc *dataAccess, addr bin.Address, inst *x86.Inst, index int, reads, stores map[bin.Address]bool) {
+	if index != 0 || !writesMem(inst) {
+		reads[addr] = true
+		return
+	}
+	acc.writes++
+	if !isStore(inst) {
+		// Read-modify-write; e.g. `add [addr], 1`.
+		reads[addr] = true
+		return
+	}
+	if _, ok := inst.Args[1].(x86asm.Imm); ok && inst.Op == x86asm.MOV {
+		acc.constStore = inst
+		stores[addr] = true
+	}
+}
+
+// writeOnce returns the constant stored to the write-once global variable of
+// the given type at the specified address, and a boolean indicating whether
+// the global variable is write-once; i.e. written exactly once with a
+// constant of the same width (e.g. `mov dword [addr], 5` in initialization
+// code), not read by the function storing the constant (e.g. lazy
+// initialization), and without its address taken (e.g. `lea eax, [addr]` or
+// `push addr`).
+//
+// The constant must be stored by initialization code (see initStore), which is
+// executed before the global variable is read by other functions.
+func (l *Lifter) writeOnce(addr bin.Address, content types.Type) (constant.Constant, bool) {
+	acc, ok := l.accesses[addr]
+	if !ok || acc.writes != 1 || acc.constStore == nil || acc.selfRead || acc.stride != 0 || l.addrTaken[addr] {
+		return nil, false
+	}
+	if !l.initStore(acc) {
+		return nil, false
+	}
+	t, ok := content.(*types.IntType)
+	if !ok || int(t.Size) != 8*acc.constStore.MemBytes {
+		return nil, false
+	}
+	imm := acc.constStore.Args[1].(x86asm.Imm)
+	return constant.NewInt(int64(imm), t), true
+}
+
+// initStore reports whether the constant store of the given access is located
+// in initialization code, and thus dominates the reads of other functions;
+// i.e. in the startup code of the C runtime executed before main, or in the
+// entry block of the entry point or main function, before any call.
+func (l *Lifter) initStore(acc *dataAccess) bool {
+	if l.Main != 0 {
+		if l.startup == nil {
+			l.startup = l.StartupFuncs()
+		}
+		if l.startup[acc.storeFunc] {
+			return true
+		}
+	}
+	if acc.storeFunc != l.File.Entry && (l.Main == 0 || acc.storeFunc != l.Main) {
+		return false
+	}
+	f, ok := l.Funcs[acc.storeFunc]
+	if !ok || f.AsmFunc == nil {
+		return false
+	}
+	entry, ok := f.AsmFunc.Blocks[f.AsmFunc.Addr]
+	if !ok {
+		return false
+	}
+	for _, inst := range entry.Insts {
+		switch {
+		case inst.Addr == acc.constStore.Addr:
+			return true
+		case inst.Op == x86asm.CALL || inst.Op == x86asm.LCALL:
+			// Callees may read the global variable before the store.
+			return false
+		}
+	}
+	return false
+}
+
+// markWriteOnce marks the write-once global variable at the given address
+// constant, with the constant stored to it as initializer. The store of the
+// constant is omitted when lifted, as recorded in l.constStores.
+func (l *Lifter) markWriteOnce(addr bin.Address, g *ir.Global) {
+	if g.IsConst {
+		return
+	}
+	init, ok := l.writeOnce(addr, g.Content)
+	if !ok {
+		return
+	}
+	store := l.accesses[addr].constStore
+	dbg.Printf("write-once global variable %q at %v; initialized by store at %v", g.Name, addr, store.Addr)
+	g.Init = init
+	g.IsConst = true
+	l.constStores[store.Addr] = true
+}
@@ -0,0 +1,82 @@
+package x86
+
+import (
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestWriteOnce(t *testing.T) {
+	// Code of the .text section, located at 0x1000.
+	//
+	//    start:
+	//       mov     dword [0x2000], 5  ; precedes calls; write-once
+	//       call    reader
+	//       mov     dword [0x2004], 7  ; read by reader before the store
+	//       ret
+	//
+	//    reader:                       ; located at 0x1020
+	//       mov     eax, [0x2000]
+	//       add     eax, [0x2004]
+	//       ret
+	code := make([]byte, 0x2C)
+	for i := range code {
+		// int3 padding.
+		code[i] = 0xCC
+	}
+	copy(code, []byte{
+		0xC7, 0x05, 0x00, 0x20, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00,
+		0xE8, 0x11, 0x00, 0x00, 0x00,
+		0xC7, 0x05, 0x04, 0x20, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00,
+		0xC3,
+	})
+	copy(code[0x20:], []byte{
+		0xA1, 0x00, 0x20, 0x00, 0x00,
+		0x03, 0x05, 0x04, 0x20, 0x00, 0x00,
+		0xC3,
+	})
+	const (
+		start  = bin.Address(0x1000)
+		reader = bin.Address(0x1020)
+		// Global variables.
+		once  = bin.Address(0x2000)
+		later = bin.Address(0x2004)
+		// Stores of the global variables.
+		onceStore  = bin.Address(0x1000)
+		laterStore = bin.Address(0x100F)
+	)
+	file := &bin.File{
+		Arch:  bin.ArchX86_32,
+		Entry: start,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x1000, Data: code, MemSize: len(code), Perm: bin.PermR | bin.PermX},
+			{Name: ".data", Addr: 0x2000, Data: make([]byte, 8), MemSize: 8, Perm: bin.PermR | bin.PermW},
+		},
+		Exports: map[bin.Address]string{reader: "reader"},
+	}
+	l, err := NewLifter(file)
+	if err != nil {
+		t.Fatalf("unable to prepare lifter; %+v", err)
+	}
+	l.ConstGlobals = true
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			t.Fatalf("unable to decode function at %v; %+v", funcAddr, err)
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	l.inferGlobals()
+	if g, ok := l.Globals[once]; !ok || !g.IsConst {
+		t.Errorf("%v: expected write-once global variable", once)
+	}
+	if !l.constStores[onceStore] {
+		t.Errorf("%v: expected omitted store of write-once global variable", onceStore)
+	}
+	if g, ok := l.Globals[later]; !ok || g.IsConst {
+		t.Errorf("%v: expected non-constant global variable", later)
+	}
+	if l.constStores[laterStore] {
+		t.Errorf("%v: expected store of non-constant global variable", laterStore)
+	}
+}
@@ -0,0 +1,190 @@
+// Package logging provides leveled loggers with text or JSON output.
+//
+// The verbosity level and output format are global, and shared by the loggers
+// of all packages.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kr/pretty"
+	"github.com/pkg/errors"
+)
+
+// Level specifies the verbosity level of log messages.
+type Level int
+
+// Verbosity levels.
+const (
+	// LevelQuiet suppresses all log messages.
+	LevelQuiet Level = iota
+	// LevelInfo specifies informational messages and warnings.
+	LevelInfo
+	// LevelDebug specifies debug messages.
+	LevelDebug
+	// LevelTrace specifies detailed tracing messages (e.g. dumps of
+	// instructions and operands).
+	LevelTrace
+)
+
+// levelNames maps from verbosity level to name.
+var levelNames = map[Level]string{
+	LevelQuiet: "quiet",
+	LevelInfo:  "info",
+	LevelDebug: "debug",
+	LevelTrace: "trace",
+}
+
+// String returns the string representation of the verbosity level.
+func (level Level) String() string {
+	if s, ok := levelNames[level]; ok {
+		return s
+	}
+	return fmt.Sprintf("Level(%d)", int(level))
+}
+
+// Set sets level to the verbosity level represented by s.
+func (level *Level) Set(s string) error {
+	for l, name := range levelNames {
+		if s == name {
+			*level = l
+			return nil
+		}
+	}
+	var names []string
+	for _, name := range levelNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return errors.Errorf("invalid verbosity level %q;\n\tvalid verbosity levels: %s", s, strings.Join(names, ", "))
+}
+
+// Global logging settings.
+var (
+	// mu protects the global logging settings and serializes output.
+	mu sync.Mutex
+	// Verbosity level; messages of higher levels are suppressed.
+	verbosity = LevelInfo
+	// jsonFormat specifies whether to output log messages as JSON objects, one
+	// per line.
+	jsonFormat bool
+)
+
+// SetLevel sets the verbosity level of all loggers.
+func SetLevel(level Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	verbosity = level
+}
+
+// SetFormat sets the output format of all loggers; either "text" or "json".
+func SetFormat(format string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	switch format {
+	case "text":
+		jsonFormat = false
+	case "json":
+		jsonFormat = true
+	default:
+		return errors.Errorf(`invalid log format %q; expected "text" or "json"`, format)
+	}
+	return nil
+}
+
+// A Logger logs messages of a given verbosity level.
+type Logger struct {
+	// Name of the logger (e.g. "lift"); included in JSON output.
+	name string
+	// Verbosity level of log messages.
+	level Level
+	// Prefix of text output.
+	prefix string
+	// Output writer; or nil to use standard error.
+	w io.Writer
+}
+
+// New returns a new logger with the given name, which logs messages of the
+// specified verbosity level. The prefix is prepended to text output.
+func New(name string, level Level, prefix string) *Logger {
+	return &Logger{name: name, level: level, prefix: prefix}
+}
+
+// SetOutput sets the output writer of the logger.
+func (l *Logger) SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	l.w = w
+}
+
+// Printf logs the given message, with arguments handled in the manner of
+// fmt.Printf.
+func (l *Logger) Printf(format string, args ...interface{}) {
+	if !l.enabled() {
+		return
+	}
+	l.output(fmt.Sprintf(format, args...))
+}
+
+// Println logs the given message, with arguments handled in the manner of
+// fmt.Println.
+func (l *Logger) Println(args ...interface{}) {
+	if !l.enabled() {
+		return
+	}
+	l.output(fmt.Sprintln(args...))
+}
+
+// Dump logs the given values, pretty-printing nested structures.
+func (l *Logger) Dump(args ...interface{}) {
+	if !l.enabled() {
+		return
+	}
+	l.output(pretty.Sprint(args...))
+}
+
+// enabled reports whether messages of the logger are enabled at the current
+// verbosity level.
+func (l *Logger) enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return l.level <= verbosity
+}
+
+// output writes the given message.
+func (l *Logger) output(msg string) {
+	mu.Lock()
+	defer mu.Unlock()
+	w := l.w
+	if w == nil {
+		w = os.Stderr
+	}
+	msg = strings.TrimSuffix(msg, "\n")
+	if jsonFormat {
+		entry := struct {
+			Time   string `json:"time"`
+			Level  string `json:"level"`
+			Logger string `json:"logger"`
+			Msg    string `json:"msg"`
+		}{
+			Time:   time.Now().Format(time.RFC3339),
+			Level:  l.level.String(),
+			Logger: l.name,
+			Msg:    msg,
+		}
+		buf, err := json.Marshal(entry)
+		if err != nil {
+			panic(fmt.Errorf("unable to encode log message; %v", err))
+		}
+		w.Write(append(buf, '\n'))
+		return
+	}
+	fmt.Fprintf(w, "%s%s\n", l.prefix, msg)
+}
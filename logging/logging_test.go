@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	defer SetLevel(LevelInfo)
+	defer SetFormat("text")
+	buf := &bytes.Buffer{}
+	dbg := New("test", LevelDebug, "test: ")
+	dbg.SetOutput(buf)
+	SetLevel(LevelInfo)
+	dbg.Printf("suppressed")
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected output at info level; %q", buf.String())
+	}
+	SetLevel(LevelDebug)
+	dbg.Printf("foo %d", 42)
+	if got, want := buf.String(), "test: foo 42\n"; got != want {
+		t.Fatalf("text output mismatch; expected %q, got %q", want, got)
+	}
+	buf.Reset()
+	if err := SetFormat("json"); err != nil {
+		t.Fatal(err)
+	}
+	dbg.Println("bar")
+	var entry map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("unable to decode JSON output %q; %v", buf.String(), err)
+	}
+	if entry["level"] != "debug" || entry["logger"] != "test" || entry["msg"] != "bar" {
+		t.Fatalf("JSON output mismatch; got %v", entry)
+	}
+}
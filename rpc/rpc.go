@@ -0,0 +1,487 @@
+// Package rpc implements the gRPC interface of the lifter, as specified by the
+// protocol buffer schema of decomp.proto.
+//
+// The Go types of messages mirror the messages of the schema, and are encoded
+// based on their struct tags by github.com/golang/protobuf; changes to the
+// schema must be reflected in the Go types. Clients of other languages use
+// bindings generated from the schema; e.g.
+//
+//    protoc --python_out=. --grpc_python_out=. decomp.proto
+package rpc
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// UploadRequest is the request of Upload.
+type UploadRequest struct {
+	// File name of the binary executable (e.g. "foo.exe").
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Contents of the binary executable.
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *UploadRequest) Reset() { *m = UploadRequest{} }
+
+// String returns the text format of the message.
+func (m *UploadRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*UploadRequest) ProtoMessage() {}
+
+// ListBinariesRequest is the request of ListBinaries.
+type ListBinariesRequest struct {
+}
+
+// Reset resets the message to its zero value.
+func (m *ListBinariesRequest) Reset() { *m = ListBinariesRequest{} }
+
+// String returns the text format of the message.
+func (m *ListBinariesRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*ListBinariesRequest) ProtoMessage() {}
+
+// ListBinariesResponse is the response of ListBinaries.
+type ListBinariesResponse struct {
+	// Uploaded binary executables, in order of upload.
+	Binaries []*Binary `protobuf:"bytes,1,rep,name=binaries,proto3" json:"binaries,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *ListBinariesResponse) Reset() { *m = ListBinariesResponse{} }
+
+// String returns the text format of the message.
+func (m *ListBinariesResponse) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*ListBinariesResponse) ProtoMessage() {}
+
+// BinaryRequest is the request of GetBinary, Progress, RemoveBinary,
+// ListFunctions and GetAnalysis.
+type BinaryRequest struct {
+	// ID of the binary executable.
+	ID string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *BinaryRequest) Reset() { *m = BinaryRequest{} }
+
+// String returns the text format of the message.
+func (m *BinaryRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*BinaryRequest) ProtoMessage() {}
+
+// RemoveBinaryResponse is the response of RemoveBinary.
+type RemoveBinaryResponse struct {
+}
+
+// Reset resets the message to its zero value.
+func (m *RemoveBinaryResponse) Reset() { *m = RemoveBinaryResponse{} }
+
+// String returns the text format of the message.
+func (m *RemoveBinaryResponse) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*RemoveBinaryResponse) ProtoMessage() {}
+
+// ListFunctionsResponse is the response of ListFunctions.
+type ListFunctionsResponse struct {
+	// Functions, in order of address.
+	Funcs []*Function `protobuf:"bytes,1,rep,name=funcs,proto3" json:"funcs,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *ListFunctionsResponse) Reset() { *m = ListFunctionsResponse{} }
+
+// String returns the text format of the message.
+func (m *ListFunctionsResponse) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*ListFunctionsResponse) ProtoMessage() {}
+
+// FunctionRequest is the request of GetFunction.
+type FunctionRequest struct {
+	// ID of the binary executable.
+	ID string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Name or address (e.g. "f_401000" or "0x401000") of the function.
+	Func string `protobuf:"bytes,2,opt,name=func,proto3" json:"func,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *FunctionRequest) Reset() { *m = FunctionRequest{} }
+
+// String returns the text format of the message.
+func (m *FunctionRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*FunctionRequest) ProtoMessage() {}
+
+// State specifies the state of lifting a binary executable.
+type State int32
+
+// Values of State.
+const (
+	// Waiting for the lifting of previously uploaded binary executables.
+	State_STATE_QUEUED State = 0
+	// The functions of the binary executable are being decoded.
+	State_STATE_DECODING State = 1
+	// The functions of the binary executable are being lifted.
+	State_STATE_LIFTING State = 2
+	// Lifting is done.
+	State_STATE_DONE State = 3
+	// The binary executable failed to be parsed or lifting was cancelled.
+	State_STATE_FAILED State = 4
+)
+
+// State_name maps from the values of State to their names.
+var State_name = map[int32]string{
+	0: "STATE_QUEUED",
+	1: "STATE_DECODING",
+	2: "STATE_LIFTING",
+	3: "STATE_DONE",
+	4: "STATE_FAILED",
+}
+
+// State_value maps from the names of State values to their values.
+var State_value = map[string]int32{
+	"STATE_QUEUED":   0,
+	"STATE_DECODING": 1,
+	"STATE_LIFTING":  2,
+	"STATE_DONE":     3,
+	"STATE_FAILED":   4,
+}
+
+// String returns the name of the State value.
+func (x State) String() string {
+	return proto.EnumName(State_name, int32(x))
+}
+
+// Binary records the status of lifting an uploaded binary executable.
+type Binary struct {
+	// ID of the binary executable.
+	ID string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// File name of the binary executable.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// State of lifting.
+	State State `protobuf:"varint,3,opt,name=state,proto3,enum=decomp.State" json:"state,omitempty"`
+	// Number of functions lifted or failed.
+	Done int64 `protobuf:"varint,4,opt,name=done,proto3" json:"done,omitempty"`
+	// Number of functions.
+	Total int64 `protobuf:"varint,5,opt,name=total,proto3" json:"total,omitempty"`
+	// Number of functions which failed to be decoded or lifted.
+	Failures int64 `protobuf:"varint,6,opt,name=failures,proto3" json:"failures,omitempty"`
+	// Error message if the binary executable failed to be lifted.
+	Error string `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *Binary) Reset() { *m = Binary{} }
+
+// String returns the text format of the message.
+func (m *Binary) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*Binary) ProtoMessage() {}
+
+// FuncState specifies the state of lifting a function.
+type FuncState int32
+
+// Values of FuncState.
+const (
+	// The function is waiting to be lifted.
+	FuncState_FUNC_PENDING FuncState = 0
+	// The function has been lifted.
+	FuncState_FUNC_LIFTED FuncState = 1
+	// The function failed to be decoded or lifted.
+	FuncState_FUNC_FAILED FuncState = 2
+)
+
+// FuncState_name maps from the values of FuncState to their names.
+var FuncState_name = map[int32]string{
+	0: "FUNC_PENDING",
+	1: "FUNC_LIFTED",
+	2: "FUNC_FAILED",
+}
+
+// FuncState_value maps from the names of FuncState values to their values.
+var FuncState_value = map[string]int32{
+	"FUNC_PENDING": 0,
+	"FUNC_LIFTED":  1,
+	"FUNC_FAILED":  2,
+}
+
+// String returns the name of the FuncState value.
+func (x FuncState) String() string {
+	return proto.EnumName(FuncState_name, int32(x))
+}
+
+// Function records a function of a binary executable.
+type Function struct {
+	// Entry address of the function.
+	Addr uint64 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// Function name.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// State of lifting.
+	State FuncState `protobuf:"varint,3,opt,name=state,proto3,enum=decomp.FuncState" json:"state,omitempty"`
+	// Error message if the function failed to be decoded or lifted.
+	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// Function signature in LLVM IR syntax.
+	Sig string `protobuf:"bytes,5,opt,name=sig,proto3" json:"sig,omitempty"`
+	// Basic blocks of the function, sorted by address.
+	Blocks []*BasicBlock `protobuf:"bytes,6,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	// LLVM IR assembly of the lifted function, as a self-contained module.
+	LLVMIR string `protobuf:"bytes,7,opt,name=llvm_ir,json=llvmIr,proto3" json:"llvm_ir,omitempty"`
+	// Control flow graph of the lifted function in Graphviz DOT format.
+	Cfg string `protobuf:"bytes,8,opt,name=cfg,proto3" json:"cfg,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *Function) Reset() { *m = Function{} }
+
+// String returns the text format of the message.
+func (m *Function) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*Function) ProtoMessage() {}
+
+// BasicBlock records a basic block of a function.
+type BasicBlock struct {
+	// Start address of the basic block.
+	Addr uint64 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// End address of the basic block (exclusive).
+	End uint64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	// Instructions of the basic block, including terminator.
+	Insts []*Instruction `protobuf:"bytes,3,rep,name=insts,proto3" json:"insts,omitempty"`
+	// Addresses of successor basic blocks.
+	Succs []uint64 `protobuf:"varint,4,rep,packed,name=succs,proto3" json:"succs,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *BasicBlock) Reset() { *m = BasicBlock{} }
+
+// String returns the text format of the message.
+func (m *BasicBlock) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*BasicBlock) ProtoMessage() {}
+
+// Instruction records an x86 instruction.
+type Instruction struct {
+	// Address of the instruction.
+	Addr uint64 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// Machine code of the instruction.
+	Code []byte `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	// Disassembly of the instruction in Intel syntax.
+	Asm string `protobuf:"bytes,3,opt,name=asm,proto3" json:"asm,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *Instruction) Reset() { *m = Instruction{} }
+
+// String returns the text format of the message.
+func (m *Instruction) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*Instruction) ProtoMessage() {}
+
+// Analysis records the results of analysing a binary executable.
+type Analysis struct {
+	// Functions.
+	Funcs []*FuncInfo `protobuf:"bytes,1,rep,name=funcs,proto3" json:"funcs,omitempty"`
+	// Call graph edges.
+	Calls []*CallEdge `protobuf:"bytes,2,rep,name=calls,proto3" json:"calls,omitempty"`
+	// Global variables.
+	Globals []*Global `protobuf:"bytes,3,rep,name=globals,proto3" json:"globals,omitempty"`
+	// String literals.
+	Strings []*StringLiteral `protobuf:"bytes,4,rep,name=strings,proto3" json:"strings,omitempty"`
+	// Functions which failed to be decoded or lifted.
+	Failures []*Failure `protobuf:"bytes,5,rep,name=failures,proto3" json:"failures,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *Analysis) Reset() { *m = Analysis{} }
+
+// String returns the text format of the message.
+func (m *Analysis) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*Analysis) ProtoMessage() {}
+
+// FuncInfo records the analysis results of a function.
+type FuncInfo struct {
+	// Entry address of the function.
+	Addr uint64 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// Function name.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Function signature.
+	Sig string `protobuf:"bytes,3,opt,name=sig,proto3" json:"sig,omitempty"`
+	// Address ranges of the basic blocks of the function, sorted by address.
+	Blocks []*BlockRange `protobuf:"bytes,4,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	// Stack imbalances of the function, sorted by address.
+	Imbalances []*StackImbalance `protobuf:"bytes,5,rep,name=imbalances,proto3" json:"imbalances,omitempty"`
+	// Names of well-known constants of cryptographic, checksum and compression
+	// algorithms referenced by the function (e.g. "AES S-box").
+	Crypto []string `protobuf:"bytes,6,rep,name=crypto,proto3" json:"crypto,omitempty"`
+	// Names of the YARA rules matching within the function.
+	Yara []string `protobuf:"bytes,7,rep,name=yara,proto3" json:"yara,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *FuncInfo) Reset() { *m = FuncInfo{} }
+
+// String returns the text format of the message.
+func (m *FuncInfo) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*FuncInfo) ProtoMessage() {}
+
+// BlockRange records the address range of a basic block.
+type BlockRange struct {
+	// Start address of the basic block.
+	Start uint64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	// End address of the basic block (exclusive).
+	End uint64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *BlockRange) Reset() { *m = BlockRange{} }
+
+// String returns the text format of the message.
+func (m *BlockRange) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*BlockRange) ProtoMessage() {}
+
+// StackImbalance records an imbalance of the stack pointer of a function.
+type StackImbalance struct {
+	// Address of the instruction at which the imbalance was detected.
+	Addr uint64 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// Kind of imbalance; "merge", "return" or "purge".
+	Kind string `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Stack pointer delta relative to function entry (or number of bytes
+	// purged on return) along the offending path.
+	Got int64 `protobuf:"varint,3,opt,name=got,proto3" json:"got,omitempty"`
+	// Expected stack pointer delta (or number of bytes purged on return).
+	Want int64 `protobuf:"varint,4,opt,name=want,proto3" json:"want,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *StackImbalance) Reset() { *m = StackImbalance{} }
+
+// String returns the text format of the message.
+func (m *StackImbalance) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*StackImbalance) ProtoMessage() {}
+
+// CallEdge records a call from one function to another.
+type CallEdge struct {
+	// Entry address of the caller.
+	Caller uint64 `protobuf:"varint,1,opt,name=caller,proto3" json:"caller,omitempty"`
+	// Address of the call instruction.
+	Site uint64 `protobuf:"varint,2,opt,name=site,proto3" json:"site,omitempty"`
+	// Address of the callee; or the address of the function pointer for
+	// indirect calls through memory (e.g. imports).
+	Callee uint64 `protobuf:"varint,3,opt,name=callee,proto3" json:"callee,omitempty"`
+	// Name of the callee; or empty if unknown.
+	Name string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *CallEdge) Reset() { *m = CallEdge{} }
+
+// String returns the text format of the message.
+func (m *CallEdge) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*CallEdge) ProtoMessage() {}
+
+// Global records an inferred global variable.
+type Global struct {
+	// Address of the global variable.
+	Addr uint64 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// Global variable name.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Content type of the global variable.
+	Type string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	// Global variable is located in a read-only section.
+	Const bool `protobuf:"varint,4,opt,name=const,proto3" json:"const,omitempty"`
+	// Names of the YARA rules matching within the global variable.
+	Yara []string `protobuf:"bytes,5,rep,name=yara,proto3" json:"yara,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *Global) Reset() { *m = Global{} }
+
+// String returns the text format of the message.
+func (m *Global) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*Global) ProtoMessage() {}
+
+// StringLiteral records a string literal referenced from code.
+type StringLiteral struct {
+	// Address of the string literal.
+	Addr uint64 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// Global variable name.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Contents of the string literal; excluding NUL terminator.
+	Text string `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *StringLiteral) Reset() { *m = StringLiteral{} }
+
+// String returns the text format of the message.
+func (m *StringLiteral) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*StringLiteral) ProtoMessage() {}
+
+// Failure records a function which failed to be decoded or lifted.
+type Failure struct {
+	// Entry address of the function.
+	Addr uint64 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// Function name.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Stage of failure; either "decode" or "lift".
+	Stage string `protobuf:"bytes,3,opt,name=stage,proto3" json:"stage,omitempty"`
+	// Error message.
+	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *Failure) Reset() { *m = Failure{} }
+
+// String returns the text format of the message.
+func (m *Failure) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffer message.
+func (*Failure) ProtoMessage() {}
+
+func init() {
+	proto.RegisterEnum("decomp.State", State_name, State_value)
+	proto.RegisterEnum("decomp.FuncState", FuncState_name, FuncState_value)
+	proto.RegisterType((*UploadRequest)(nil), "decomp.UploadRequest")
+	proto.RegisterType((*ListBinariesRequest)(nil), "decomp.ListBinariesRequest")
+	proto.RegisterType((*ListBinariesResponse)(nil), "decomp.ListBinariesResponse")
+	proto.RegisterType((*BinaryRequest)(nil), "decomp.BinaryRequest")
+	proto.RegisterType((*RemoveBinaryResponse)(nil), "decomp.RemoveBinaryResponse")
+	proto.RegisterType((*ListFunctionsResponse)(nil), "decomp.ListFunctionsResponse")
+	proto.RegisterType((*FunctionRequest)(nil), "decomp.FunctionRequest")
+	proto.RegisterType((*Binary)(nil), "decomp.Binary")
+	proto.RegisterType((*Function)(nil), "decomp.Function")
+	proto.RegisterType((*BasicBlock)(nil), "decomp.BasicBlock")
+	proto.RegisterType((*Instruction)(nil), "decomp.Instruction")
+	proto.RegisterType((*Analysis)(nil), "decomp.Analysis")
+	proto.RegisterType((*FuncInfo)(nil), "decomp.FuncInfo")
+	proto.RegisterType((*BlockRange)(nil), "decomp.BlockRange")
+	proto.RegisterType((*StackImbalance)(nil), "decomp.StackImbalance")
+	proto.RegisterType((*CallEdge)(nil), "decomp.CallEdge")
+	proto.RegisterType((*Global)(nil), "decomp.Global")
+	proto.RegisterType((*StringLiteral)(nil), "decomp.StringLiteral")
+	proto.RegisterType((*Failure)(nil), "decomp.Failure")
+}
@@ -0,0 +1,285 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ### [ Server ] ##############################################################
+
+// DecompServer is the server API of the Decomp service.
+type DecompServer interface {
+	// Upload uploads a binary executable, and starts lifting it.
+	Upload(context.Context, *UploadRequest) (*Binary, error)
+	// ListBinaries lists the uploaded binary executables, in order of upload.
+	ListBinaries(context.Context, *ListBinariesRequest) (*ListBinariesResponse, error)
+	// GetBinary returns the status of lifting a binary executable.
+	GetBinary(context.Context, *BinaryRequest) (*Binary, error)
+	// Progress streams the status of lifting a binary executable, each time the
+	// status changes, until lifting is done or failed.
+	Progress(*BinaryRequest, Decomp_ProgressServer) error
+	// RemoveBinary cancels lifting and removes a binary executable.
+	RemoveBinary(context.Context, *BinaryRequest) (*RemoveBinaryResponse, error)
+	// ListFunctions lists the status of lifting the functions of a binary
+	// executable, in order of address; without basic blocks and LLVM IR.
+	ListFunctions(context.Context, *BinaryRequest) (*ListFunctionsResponse, error)
+	// GetFunction returns a lifted function, with basic blocks, instructions and
+	// LLVM IR.
+	GetFunction(context.Context, *FunctionRequest) (*Function, error)
+	// GetAnalysis returns the analysis results of a lifted binary executable.
+	GetAnalysis(context.Context, *BinaryRequest) (*Analysis, error)
+}
+
+// Decomp_ProgressServer is the server stream of the Progress RPC.
+type Decomp_ProgressServer interface {
+	// Send sends the status of lifting a binary executable.
+	Send(*Binary) error
+	grpc.ServerStream
+}
+
+// RegisterDecompServer registers the given implementation of the Decomp
+// service with the gRPC server.
+func RegisterDecompServer(s *grpc.Server, srv DecompServer) {
+	s.RegisterService(&decompServiceDesc, srv)
+}
+
+// decompServiceDesc describes the methods of the Decomp service.
+var decompServiceDesc = grpc.ServiceDesc{
+	ServiceName: "decomp.Decomp",
+	HandlerType: (*DecompServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Upload",
+			Handler: unaryHandler("Upload", func() interface{} { return new(UploadRequest) }, func(srv DecompServer, ctx context.Context, in interface{}) (interface{}, error) {
+				return srv.Upload(ctx, in.(*UploadRequest))
+			}),
+		},
+		{
+			MethodName: "ListBinaries",
+			Handler: unaryHandler("ListBinaries", func() interface{} { return new(ListBinariesRequest) }, func(srv DecompServer, ctx context.Context, in interface{}) (interface{}, error) {
+				return srv.ListBinaries(ctx, in.(*ListBinariesRequest))
+			}),
+		},
+		{
+			MethodName: "GetBinary",
+			Handler: unaryHandler("GetBinary", func() interface{} { return new(BinaryRequest) }, func(srv DecompServer, ctx context.Context, in interface{}) (interface{}, error) {
+				return srv.GetBinary(ctx, in.(*BinaryRequest))
+			}),
+		},
+		{
+			MethodName: "RemoveBinary",
+			Handler: unaryHandler("RemoveBinary", func() interface{} { return new(BinaryRequest) }, func(srv DecompServer, ctx context.Context, in interface{}) (interface{}, error) {
+				return srv.RemoveBinary(ctx, in.(*BinaryRequest))
+			}),
+		},
+		{
+			MethodName: "ListFunctions",
+			Handler: unaryHandler("ListFunctions", func() interface{} { return new(BinaryRequest) }, func(srv DecompServer, ctx context.Context, in interface{}) (interface{}, error) {
+				return srv.ListFunctions(ctx, in.(*BinaryRequest))
+			}),
+		},
+		{
+			MethodName: "GetFunction",
+			Handler: unaryHandler("GetFunction", func() interface{} { return new(FunctionRequest) }, func(srv DecompServer, ctx context.Context, in interface{}) (interface{}, error) {
+				return srv.GetFunction(ctx, in.(*FunctionRequest))
+			}),
+		},
+		{
+			MethodName: "GetAnalysis",
+			Handler: unaryHandler("GetAnalysis", func() interface{} { return new(BinaryRequest) }, func(srv DecompServer, ctx context.Context, in interface{}) (interface{}, error) {
+				return srv.GetAnalysis(ctx, in.(*BinaryRequest))
+			}),
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Progress",
+			Handler:       progressHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "decomp.proto",
+}
+
+// unaryHandler returns the gRPC handler of the given unary method of the
+// Decomp service, decoding requests into new values returned by newIn, and
+// invoking call with the decoded request.
+func unaryHandler(method string, newIn func() interface{}, call func(srv DecompServer, ctx context.Context, in interface{}) (interface{}, error)) func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := newIn()
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(DecompServer), ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/decomp.Decomp/" + method,
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(DecompServer), ctx, req)
+		}
+		return interceptor(ctx, in, info, handler)
+	}
+}
+
+// progressHandler is the gRPC handler of the Progress method.
+func progressHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(BinaryRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(DecompServer).Progress(in, &decompProgressServer{stream})
+}
+
+// decompProgressServer is the server stream of the Progress RPC.
+type decompProgressServer struct {
+	grpc.ServerStream
+}
+
+// Send sends the status of lifting a binary executable.
+func (x *decompProgressServer) Send(m *Binary) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ### [ Client ] ##############################################################
+
+// DecompClient is the client API of the Decomp service.
+type DecompClient interface {
+	// Upload uploads a binary executable, and starts lifting it.
+	Upload(ctx context.Context, in *UploadRequest, opts ...grpc.CallOption) (*Binary, error)
+	// ListBinaries lists the uploaded binary executables, in order of upload.
+	ListBinaries(ctx context.Context, in *ListBinariesRequest, opts ...grpc.CallOption) (*ListBinariesResponse, error)
+	// GetBinary returns the status of lifting a binary executable.
+	GetBinary(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (*Binary, error)
+	// Progress streams the status of lifting a binary executable, each time the
+	// status changes, until lifting is done or failed.
+	Progress(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (Decomp_ProgressClient, error)
+	// RemoveBinary cancels lifting and removes a binary executable.
+	RemoveBinary(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (*RemoveBinaryResponse, error)
+	// ListFunctions lists the status of lifting the functions of a binary
+	// executable, in order of address; without basic blocks and LLVM IR.
+	ListFunctions(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (*ListFunctionsResponse, error)
+	// GetFunction returns a lifted function, with basic blocks, instructions and
+	// LLVM IR.
+	GetFunction(ctx context.Context, in *FunctionRequest, opts ...grpc.CallOption) (*Function, error)
+	// GetAnalysis returns the analysis results of a lifted binary executable.
+	GetAnalysis(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (*Analysis, error)
+}
+
+// Decomp_ProgressClient is the client stream of the Progress RPC.
+type Decomp_ProgressClient interface {
+	// Recv receives the status of lifting a binary executable.
+	Recv() (*Binary, error)
+	grpc.ClientStream
+}
+
+// NewDecompClient returns a client of the Decomp service using the given
+// connection.
+func NewDecompClient(cc *grpc.ClientConn) DecompClient {
+	return &decompClient{cc: cc}
+}
+
+// decompClient is a client of the Decomp service.
+type decompClient struct {
+	// Connection to the gRPC server.
+	cc *grpc.ClientConn
+}
+
+// Upload uploads a binary executable, and starts lifting it.
+func (c *decompClient) Upload(ctx context.Context, in *UploadRequest, opts ...grpc.CallOption) (*Binary, error) {
+	out := new(Binary)
+	if err := c.cc.Invoke(ctx, "/decomp.Decomp/Upload", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListBinaries lists the uploaded binary executables, in order of upload.
+func (c *decompClient) ListBinaries(ctx context.Context, in *ListBinariesRequest, opts ...grpc.CallOption) (*ListBinariesResponse, error) {
+	out := new(ListBinariesResponse)
+	if err := c.cc.Invoke(ctx, "/decomp.Decomp/ListBinaries", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetBinary returns the status of lifting a binary executable.
+func (c *decompClient) GetBinary(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (*Binary, error) {
+	out := new(Binary)
+	if err := c.cc.Invoke(ctx, "/decomp.Decomp/GetBinary", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Progress streams the status of lifting a binary executable, each time the
+// status changes, until lifting is done or failed.
+func (c *decompClient) Progress(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (Decomp_ProgressClient, error) {
+	stream, err := c.cc.NewStream(ctx, &decompServiceDesc.Streams[0], "/decomp.Decomp/Progress", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &decompProgressClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// decompProgressClient is the client stream of the Progress RPC.
+type decompProgressClient struct {
+	grpc.ClientStream
+}
+
+// Recv receives the status of lifting a binary executable.
+func (x *decompProgressClient) Recv() (*Binary, error) {
+	m := new(Binary)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RemoveBinary cancels lifting and removes a binary executable.
+func (c *decompClient) RemoveBinary(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (*RemoveBinaryResponse, error) {
+	out := new(RemoveBinaryResponse)
+	if err := c.cc.Invoke(ctx, "/decomp.Decomp/RemoveBinary", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListFunctions lists the status of lifting the functions of a binary
+// executable, in order of address; without basic blocks and LLVM IR.
+func (c *decompClient) ListFunctions(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (*ListFunctionsResponse, error) {
+	out := new(ListFunctionsResponse)
+	if err := c.cc.Invoke(ctx, "/decomp.Decomp/ListFunctions", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetFunction returns a lifted function, with basic blocks, instructions and
+// LLVM IR.
+func (c *decompClient) GetFunction(ctx context.Context, in *FunctionRequest, opts ...grpc.CallOption) (*Function, error) {
+	out := new(Function)
+	if err := c.cc.Invoke(ctx, "/decomp.Decomp/GetFunction", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAnalysis returns the analysis results of a lifted binary executable.
+func (c *decompClient) GetAnalysis(ctx context.Context, in *BinaryRequest, opts ...grpc.CallOption) (*Analysis, error) {
+	out := new(Analysis)
+	if err := c.cc.Invoke(ctx, "/decomp.Decomp/GetAnalysis", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestService(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	srv := &testServer{}
+	RegisterDecompServer(s, srv)
+	go s.Serve(lis)
+	defer s.Stop()
+	dial := func(ctx context.Context, addr string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}
+	cc, err := grpc.NewClient("passthrough:///bufnet", grpc.WithContextDialer(dial), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unable to create client connection; %v", err)
+	}
+	defer cc.Close()
+	c := NewDecompClient(cc)
+	ctx := context.Background()
+
+	// Upload.
+	data := []byte{0x55, 0x8B, 0xEC, 0x5D, 0xC3}
+	bin, err := c.Upload(ctx, &UploadRequest{Name: "foo.exe", Data: data})
+	if err != nil {
+		t.Fatalf("unable to upload binary executable; %v", err)
+	}
+	if srv.name != "foo.exe" || !reflect.DeepEqual(srv.data, data) {
+		t.Errorf("upload request mismatch; expected %q (% X), got %q (% X)", "foo.exe", data, srv.name, srv.data)
+	}
+	want := &Binary{ID: "1", Name: "foo.exe", State: State_STATE_QUEUED}
+	if !reflect.DeepEqual(bin, want) {
+		t.Errorf("binary mismatch of Upload; expected %v, got %v", want, bin)
+	}
+
+	// GetBinary.
+	bin, err = c.GetBinary(ctx, &BinaryRequest{ID: "1"})
+	if err != nil {
+		t.Fatalf("unable to get binary executable; %v", err)
+	}
+	want = &Binary{ID: "1", Name: "foo.exe", State: State_STATE_DONE, Done: 1, Total: 1}
+	if !reflect.DeepEqual(bin, want) {
+		t.Errorf("binary mismatch of GetBinary; expected %v, got %v", want, bin)
+	}
+	if _, err := c.GetBinary(ctx, &BinaryRequest{ID: "2"}); status.Code(err) != codes.NotFound {
+		t.Errorf("status code mismatch of unknown binary executable; expected %v, got %v", codes.NotFound, status.Code(err))
+	}
+
+	// GetFunction.
+	f, err := c.GetFunction(ctx, &FunctionRequest{ID: "1", Func: "0x401000"})
+	if err != nil {
+		t.Fatalf("unable to get function; %v", err)
+	}
+	if !reflect.DeepEqual(f, testFunc) {
+		t.Errorf("function mismatch; expected %v, got %v", testFunc, f)
+	}
+}
+
+// testFunc is the function returned by testServer.GetFunction.
+var testFunc = &Function{
+	Addr:   0x401000,
+	Name:   "f_401000",
+	State:  FuncState_FUNC_LIFTED,
+	Sig:    "void ()",
+	LLVMIR: "define void @f_401000() {\n; <label>:0\n\tret void\n}\n",
+	Cfg:    "digraph \"f_401000\" {\n}\n",
+	Blocks: []*BasicBlock{
+		{
+			Addr: 0x401000,
+			End:  0x401005,
+			Insts: []*Instruction{
+				{Addr: 0x401000, Code: []byte{0x55}, Asm: "push ebp"},
+				{Addr: 0x401001, Code: []byte{0x8B, 0xEC}, Asm: "mov ebp, esp"},
+				{Addr: 0x401003, Code: []byte{0x5D}, Asm: "pop ebp"},
+				{Addr: 0x401004, Code: []byte{0xC3}, Asm: "ret"},
+			},
+		},
+	},
+}
+
+// testServer is a Decomp server of a single binary executable, which is
+// reported as lifted once uploaded.
+type testServer struct {
+	// File name and contents of the uploaded binary executable.
+	name string
+	data []byte
+}
+
+// Upload records the uploaded binary executable.
+func (s *testServer) Upload(ctx context.Context, in *UploadRequest) (*Binary, error) {
+	s.name, s.data = in.Name, in.Data
+	return &Binary{ID: "1", Name: in.Name, State: State_STATE_QUEUED}, nil
+}
+
+// ListBinaries is not implemented.
+func (s *testServer) ListBinaries(ctx context.Context, in *ListBinariesRequest) (*ListBinariesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "ListBinaries not implemented")
+}
+
+// GetBinary returns the status of lifting the uploaded binary executable.
+func (s *testServer) GetBinary(ctx context.Context, in *BinaryRequest) (*Binary, error) {
+	if in.ID != "1" {
+		return nil, status.Errorf(codes.NotFound, "unknown binary executable %q", in.ID)
+	}
+	return &Binary{ID: "1", Name: s.name, State: State_STATE_DONE, Done: 1, Total: 1}, nil
+}
+
+// Progress is not implemented.
+func (s *testServer) Progress(in *BinaryRequest, stream Decomp_ProgressServer) error {
+	return status.Errorf(codes.Unimplemented, "Progress not implemented")
+}
+
+// RemoveBinary is not implemented.
+func (s *testServer) RemoveBinary(ctx context.Context, in *BinaryRequest) (*RemoveBinaryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "RemoveBinary not implemented")
+}
+
+// ListFunctions is not implemented.
+func (s *testServer) ListFunctions(ctx context.Context, in *BinaryRequest) (*ListFunctionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "ListFunctions not implemented")
+}
+
+// GetFunction returns testFunc.
+func (s *testServer) GetFunction(ctx context.Context, in *FunctionRequest) (*Function, error) {
+	if in.ID != "1" || in.Func != "0x401000" {
+		return nil, status.Errorf(codes.NotFound, "unable to locate lifted function %q", in.Func)
+	}
+	return testFunc, nil
+}
+
+// GetAnalysis is not implemented.
+func (s *testServer) GetAnalysis(ctx context.Context, in *BinaryRequest) (*Analysis, error) {
+	return nil, status.Errorf(codes.Unimplemented, "GetAnalysis not implemented")
+}